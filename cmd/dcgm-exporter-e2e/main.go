@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/e2eharness"
+)
+
+func main() {
+	opts := e2eharness.Options{}
+
+	flag.StringVar(&opts.RemoteHEInfo, "remote-hostengine-info", "localhost:5555",
+		"Address of the running nv-hostengine.")
+	flag.StringVar(&opts.Hostengine, "hostengine", "",
+		"Path of the nv-hostengine to start at the address for the run; the fake GPUs are removed when it stops.")
+	flag.IntVar(&opts.NumGPUs, "fake-gpus", 2, "Number of fake GPUs to create.")
+	flag.DurationVar(&opts.CollectInterval, "collect-interval", time.Second, "Exporter collect interval.")
+	flag.DurationVar(&opts.Timeout, "timeout", 2*time.Minute, "Time to wait for the expected metrics.")
+	flag.Parse()
+
+	err := e2eharness.Run(context.Background(), opts, e2eharness.DefaultInjections)
+	if err != nil {
+		slog.Error("End-to-end test failed: " + err.Error())
+		os.Exit(1)
+	}
+
+	slog.Info("End-to-end test passed")
+	os.Exit(0)
+}
@@ -270,6 +270,21 @@ func (mr *MockDCGMMockRecorder) GetDeviceInfo(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceInfo", reflect.TypeOf((*MockDCGM)(nil).GetDeviceInfo), arg0)
 }
 
+// GetDeviceTopology mocks base method.
+func (m *MockDCGM) GetDeviceTopology(arg0 uint) ([]dcgm.P2PLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceTopology", arg0)
+	ret0, _ := ret[0].([]dcgm.P2PLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceTopology indicates an expected call of GetDeviceTopology.
+func (mr *MockDCGMMockRecorder) GetDeviceTopology(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceTopology", reflect.TypeOf((*MockDCGM)(nil).GetDeviceTopology), arg0)
+}
+
 // GetEntityGroupEntities mocks base method.
 func (m *MockDCGM) GetEntityGroupEntities(arg0 dcgm.Field_Entity_Group) ([]uint, error) {
 	m.ctrl.T.Helper()
@@ -448,6 +463,21 @@ func (mr *MockDCGMMockRecorder) InjectFieldValue(arg0, arg1, arg2, arg3, arg4, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InjectFieldValue", reflect.TypeOf((*MockDCGM)(nil).InjectFieldValue), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// Introspect mocks base method.
+func (m *MockDCGM) Introspect() (dcgm.DcgmStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect")
+	ret0, _ := ret[0].(dcgm.DcgmStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockDCGMMockRecorder) Introspect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockDCGM)(nil).Introspect))
+}
+
 // LinkGetLatestValues mocks base method.
 func (m *MockDCGM) LinkGetLatestValues(arg0, arg1 uint, arg2 []dcgm.Short) ([]dcgm.FieldValue_v1, error) {
 	m.ctrl.T.Helper()
@@ -478,6 +508,21 @@ func (mr *MockDCGMMockRecorder) NewDefaultGroup(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewDefaultGroup", reflect.TypeOf((*MockDCGM)(nil).NewDefaultGroup), arg0)
 }
 
+// RunDiag mocks base method.
+func (m *MockDCGM) RunDiag(arg0 dcgm.DiagType, arg1 dcgm.GroupHandle) (dcgm.DiagResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDiag", arg0, arg1)
+	ret0, _ := ret[0].(dcgm.DiagResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunDiag indicates an expected call of RunDiag.
+func (mr *MockDCGMMockRecorder) RunDiag(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDiag", reflect.TypeOf((*MockDCGM)(nil).RunDiag), arg0, arg1)
+}
+
 // UpdateAllFields mocks base method.
 func (m *MockDCGM) UpdateAllFields() error {
 	m.ctrl.T.Helper()
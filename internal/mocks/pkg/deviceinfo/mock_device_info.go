@@ -264,3 +264,17 @@ func (mr *MockProviderMockRecorder) Switches() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Switches", reflect.TypeOf((*MockProvider)(nil).Switches))
 }
+
+// VGPUs mocks base method.
+func (m *MockProvider) VGPUs() []deviceinfo.VGPUInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VGPUs")
+	ret0, _ := ret[0].([]deviceinfo.VGPUInfo)
+	return ret0
+}
+
+// VGPUs indicates an expected call of VGPUs.
+func (mr *MockProviderMockRecorder) VGPUs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VGPUs", reflect.TypeOf((*MockProvider)(nil).VGPUs))
+}
@@ -69,10 +69,24 @@ func (mr *MockWatcherMockRecorder) GetDeviceFields(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceFields", reflect.TypeOf((*MockWatcher)(nil).GetDeviceFields), arg0, arg1)
 }
 
+// UpdateWatchFrequency mocks base method.
+func (m *MockWatcher) UpdateWatchFrequency(arg0 []dcgm.GroupHandle, arg1 dcgm.FieldHandle, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWatchFrequency", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWatchFrequency indicates an expected call of UpdateWatchFrequency.
+func (mr *MockWatcherMockRecorder) UpdateWatchFrequency(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWatchFrequency", reflect.TypeOf((*MockWatcher)(nil).UpdateWatchFrequency), arg0, arg1, arg2)
+}
+
 // WatchDeviceFields mocks base method.
-func (m *MockWatcher) WatchDeviceFields(arg0 []dcgm.Short, arg1 deviceinfo.Provider, arg2 int64) ([]dcgm.GroupHandle, dcgm.FieldHandle, []func(), error) {
+func (m *MockWatcher) WatchDeviceFields(arg0 []dcgm.Short, arg1 deviceinfo.Provider, arg2 int64, arg3 map[dcgm.Short]int64) ([]dcgm.GroupHandle, dcgm.FieldHandle, []func(), error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WatchDeviceFields", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "WatchDeviceFields", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]dcgm.GroupHandle)
 	ret1, _ := ret[1].(dcgm.FieldHandle)
 	ret2, _ := ret[2].([]func())
@@ -81,7 +95,7 @@ func (m *MockWatcher) WatchDeviceFields(arg0 []dcgm.Short, arg1 deviceinfo.Provi
 }
 
 // WatchDeviceFields indicates an expected call of WatchDeviceFields.
-func (mr *MockWatcherMockRecorder) WatchDeviceFields(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockWatcherMockRecorder) WatchDeviceFields(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeviceFields", reflect.TypeOf((*MockWatcher)(nil).WatchDeviceFields), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeviceFields", reflect.TypeOf((*MockWatcher)(nil).WatchDeviceFields), arg0, arg1, arg2, arg3)
 }
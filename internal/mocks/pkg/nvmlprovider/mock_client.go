@@ -65,6 +65,51 @@ func (mr *MockNVMLMockRecorder) Cleanup() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cleanup", reflect.TypeOf((*MockNVML)(nil).Cleanup))
 }
 
+// GetDeviceValues mocks base method.
+func (m *MockNVML) GetDeviceValues(arg0 string, arg1 []nvmlprovider.DeviceField) (map[nvmlprovider.DeviceField]float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceValues", arg0, arg1)
+	ret0, _ := ret[0].(map[nvmlprovider.DeviceField]float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceValues indicates an expected call of GetDeviceValues.
+func (mr *MockNVMLMockRecorder) GetDeviceValues(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceValues", reflect.TypeOf((*MockNVML)(nil).GetDeviceValues), arg0, arg1)
+}
+
+// GetDevices mocks base method.
+func (m *MockNVML) GetDevices() ([]nvmlprovider.GPUDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevices")
+	ret0, _ := ret[0].([]nvmlprovider.GPUDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDevices indicates an expected call of GetDevices.
+func (mr *MockNVMLMockRecorder) GetDevices() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevices", reflect.TypeOf((*MockNVML)(nil).GetDevices))
+}
+
+// GetEncoderDecoderStats mocks base method.
+func (m *MockNVML) GetEncoderDecoderStats(arg0 string) (*nvmlprovider.EncoderDecoderStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEncoderDecoderStats", arg0)
+	ret0, _ := ret[0].(*nvmlprovider.EncoderDecoderStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEncoderDecoderStats indicates an expected call of GetEncoderDecoderStats.
+func (mr *MockNVMLMockRecorder) GetEncoderDecoderStats(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEncoderDecoderStats", reflect.TypeOf((*MockNVML)(nil).GetEncoderDecoderStats), arg0)
+}
+
 // GetMIGDeviceInfoByID mocks base method.
 func (m *MockNVML) GetMIGDeviceInfoByID(arg0 string) (*nvmlprovider.MIGDeviceInfo, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +124,78 @@ func (mr *MockNVMLMockRecorder) GetMIGDeviceInfoByID(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMIGDeviceInfoByID", reflect.TypeOf((*MockNVML)(nil).GetMIGDeviceInfoByID), arg0)
 }
+
+// GetMIGDevices mocks base method.
+func (m *MockNVML) GetMIGDevices(arg0 string) (*nvmlprovider.MIGDevices, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMIGDevices", arg0)
+	ret0, _ := ret[0].(*nvmlprovider.MIGDevices)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMIGDevices indicates an expected call of GetMIGDevices.
+func (mr *MockNVMLMockRecorder) GetMIGDevices(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMIGDevices", reflect.TypeOf((*MockNVML)(nil).GetMIGDevices), arg0)
+}
+
+// GetPCIeErrorStats mocks base method.
+func (m *MockNVML) GetPCIeErrorStats(arg0 string) (*nvmlprovider.PCIeErrorStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPCIeErrorStats", arg0)
+	ret0, _ := ret[0].(*nvmlprovider.PCIeErrorStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPCIeErrorStats indicates an expected call of GetPCIeErrorStats.
+func (mr *MockNVMLMockRecorder) GetPCIeErrorStats(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPCIeErrorStats", reflect.TypeOf((*MockNVML)(nil).GetPCIeErrorStats), arg0)
+}
+
+// GetPendingModeChanges mocks base method.
+func (m *MockNVML) GetPendingModeChanges(arg0 string) (*nvmlprovider.PendingModeChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingModeChanges", arg0)
+	ret0, _ := ret[0].(*nvmlprovider.PendingModeChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingModeChanges indicates an expected call of GetPendingModeChanges.
+func (mr *MockNVMLMockRecorder) GetPendingModeChanges(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingModeChanges", reflect.TypeOf((*MockNVML)(nil).GetPendingModeChanges), arg0)
+}
+
+// GetProcesses mocks base method.
+func (m *MockNVML) GetProcesses(arg0 string, arg1 uint64) (*nvmlprovider.GPUProcesses, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcesses", arg0, arg1)
+	ret0, _ := ret[0].(*nvmlprovider.GPUProcesses)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcesses indicates an expected call of GetProcesses.
+func (mr *MockNVMLMockRecorder) GetProcesses(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcesses", reflect.TypeOf((*MockNVML)(nil).GetProcesses), arg0, arg1)
+}
+
+// GetProcessTypeStats mocks base method.
+func (m *MockNVML) GetProcessTypeStats(arg0 string, arg1 uint64) (*nvmlprovider.ProcessTypeStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcessTypeStats", arg0, arg1)
+	ret0, _ := ret[0].(*nvmlprovider.ProcessTypeStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcessTypeStats indicates an expected call of GetProcessTypeStats.
+func (mr *MockNVMLMockRecorder) GetProcessTypeStats(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcessTypeStats", reflect.TypeOf((*MockNVML)(nil).GetProcessTypeStats), arg0, arg1)
+}
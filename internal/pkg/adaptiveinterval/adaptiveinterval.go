@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package adaptiveinterval matches the collect interval to the observed scrape interval, so that every scrape
+// returns fresh values without watching fields more often than they are read.
+package adaptiveinterval
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	// sampleSize is the number of the most recent scrape intervals, which the estimate is based on
+	sampleSize = 8
+	// minSamples is the number of scrape intervals required before the collect interval is adjusted
+	minSamples = 3
+	// hysteresis is the relative difference between the estimate and the collect interval, which is ignored
+	hysteresis = 0.2
+	// confirmations is the number of consecutive scrapes, which must confirm the difference before adjusting
+	confirmations = 3
+)
+
+var (
+	collectInterval = selfmetrics.Default().Gauge("dcgm_exporter_collect_interval_milliseconds",
+		"Current interval of updating the watched fields, in milliseconds.")
+	scrapeInterval = selfmetrics.Default().Gauge("dcgm_exporter_scrape_interval_milliseconds",
+		"Estimated interval between scrapes, in milliseconds.")
+	adjustments = selfmetrics.Default().Counter("dcgm_exporter_collect_interval_adjustments_total",
+		"Number of adjustments of the collect interval to the scrape interval.")
+)
+
+// Setter applies a new collect interval, in milliseconds.
+type Setter func(collectInterval int64) error
+
+// Tracker observes scrapes and adjusts the collect interval to the median scrape interval, within bounds.
+type Tracker struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+	current     time.Duration
+	set         Setter
+
+	lastScrape time.Time
+	intervals  []time.Duration
+	pending    int
+	mtx        sync.Mutex
+}
+
+// NewTracker creates a tracker starting from the initial collect interval. The adjusted interval is kept
+// between minInterval and maxInterval.
+func NewTracker(initial, minInterval, maxInterval time.Duration, set Setter) *Tracker {
+	collectInterval.Set(float64(initial.Milliseconds()))
+
+	return &Tracker{
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		current:     initial,
+		set:         set,
+		intervals:   make([]time.Duration, 0, sampleSize),
+	}
+}
+
+// Current returns the current collect interval.
+func (t *Tracker) Current() time.Duration {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.current
+}
+
+// ObserveScrape records a scrape at the given time, and adjusts the collect interval when the scrape interval
+// has differed from it for several consecutive scrapes.
+func (t *Tracker) ObserveScrape(now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	last := t.lastScrape
+	t.lastScrape = now
+	if last.IsZero() || !now.After(last) {
+		return
+	}
+
+	if len(t.intervals) == sampleSize {
+		t.intervals = t.intervals[1:]
+	}
+	t.intervals = append(t.intervals, now.Sub(last))
+
+	if len(t.intervals) < minSamples {
+		return
+	}
+
+	estimate := median(t.intervals)
+	scrapeInterval.Set(float64(estimate.Milliseconds()))
+
+	target := min(max(estimate, t.minInterval), t.maxInterval).Round(time.Millisecond)
+	diff := target - t.current
+	if diff < 0 {
+		diff = -diff
+	}
+
+	if float64(diff) <= hysteresis*float64(t.current) {
+		t.pending = 0
+		return
+	}
+
+	t.pending++
+	if t.pending < confirmations {
+		return
+	}
+	t.pending = 0
+
+	if err := t.set(target.Milliseconds()); err != nil {
+		slog.Warn(fmt.Sprintf("Failed to change the collect interval to %s", target),
+			slog.String(logging.ErrorKey, err.Error()))
+		return
+	}
+
+	direction := "down"
+	if target > t.current {
+		direction = "up"
+	}
+
+	slog.Info(fmt.Sprintf("Changed the collect interval from %s to %s to match the scrape interval",
+		t.current, target))
+
+	t.current = target
+	collectInterval.Set(float64(target.Milliseconds()))
+	adjustments.Inc("direction", direction)
+}
+
+func median(intervals []time.Duration) time.Duration {
+	sorted := slices.Clone(intervals)
+	slices.Sort(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adaptiveinterval
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrapeEvery(tracker *Tracker, start time.Time, interval time.Duration, n int) time.Time {
+	for i := 0; i < n; i++ {
+		start = start.Add(interval)
+		tracker.ObserveScrape(start)
+	}
+	return start
+}
+
+func TestTracker_ObserveScrape(t *testing.T) {
+	t.Run("adjusts to the scrape interval after confirmations", func(t *testing.T) {
+		var applied []int64
+		tracker := NewTracker(30*time.Second, time.Second, time.Minute, func(ms int64) error {
+			applied = append(applied, ms)
+			return nil
+		})
+
+		// The first scrape has no interval, and minSamples are required before the confirmations start
+		now := scrapeEvery(tracker, time.Now(), 15*time.Second, minSamples+confirmations-1)
+		assert.Empty(t, applied)
+		assert.Equal(t, 30*time.Second, tracker.Current())
+
+		scrapeEvery(tracker, now, 15*time.Second, 1)
+		assert.Equal(t, []int64{15000}, applied)
+		assert.Equal(t, 15*time.Second, tracker.Current())
+	})
+
+	t.Run("ignores differences within hysteresis", func(t *testing.T) {
+		tracker := NewTracker(30*time.Second, time.Second, time.Minute, func(int64) error {
+			t.Fatal("the collect interval must not change")
+			return nil
+		})
+
+		scrapeEvery(tracker, time.Now(), 27*time.Second, 20)
+		assert.Equal(t, 30*time.Second, tracker.Current())
+	})
+
+	t.Run("keeps the interval within bounds", func(t *testing.T) {
+		tracker := NewTracker(30*time.Second, 10*time.Second, time.Minute, func(int64) error {
+			return nil
+		})
+
+		now := scrapeEvery(tracker, time.Now(), time.Second, 20)
+		assert.Equal(t, 10*time.Second, tracker.Current())
+
+		scrapeEvery(tracker, now, 5*time.Minute, 20)
+		assert.Equal(t, time.Minute, tracker.Current())
+	})
+
+	t.Run("ignores a single outlier", func(t *testing.T) {
+		tracker := NewTracker(15*time.Second, time.Second, time.Minute, func(int64) error {
+			t.Fatal("the collect interval must not change")
+			return nil
+		})
+
+		now := scrapeEvery(tracker, time.Now(), 15*time.Second, 5)
+		now = scrapeEvery(tracker, now, 2*time.Minute, 1)
+		scrapeEvery(tracker, now, 15*time.Second, 5)
+		assert.Equal(t, 15*time.Second, tracker.Current())
+	})
+
+	t.Run("keeps the interval when it cannot be applied", func(t *testing.T) {
+		tracker := NewTracker(30*time.Second, time.Second, time.Minute, func(int64) error {
+			return errors.New("boom")
+		})
+
+		scrapeEvery(tracker, time.Now(), 15*time.Second, 20)
+		assert.Equal(t, 30*time.Second, tracker.Current())
+	})
+}
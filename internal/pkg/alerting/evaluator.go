@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alerting
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	alertsFiring = selfmetrics.Default().Gauge("dcgm_exporter_alerts_firing",
+		"Number of series, for which the embedded alert rule is firing.")
+	notificationErrors = selfmetrics.Default().Counter("dcgm_exporter_alert_notification_errors_total",
+		"Number of alert notifications, which could not be delivered.")
+)
+
+// alertState tracks a rule for one series.
+type alertState struct {
+	labels   map[string]string
+	value    float64
+	activeAt time.Time
+	firing   bool
+}
+
+// Evaluator evaluates the rules against the collected values.
+type Evaluator struct {
+	rules    []Rule
+	notifier Notifier
+	now      func() time.Time
+
+	mtx    sync.Mutex
+	states map[string]map[string]*alertState
+}
+
+// NewEvaluator creates an evaluator of the rules.
+func NewEvaluator(rules []Rule, notifier Notifier) *Evaluator {
+	e := &Evaluator{
+		rules:    rules,
+		notifier: notifier,
+		now:      time.Now,
+		states:   map[string]map[string]*alertState{},
+	}
+
+	for _, rule := range rules {
+		e.states[rule.Name] = map[string]*alertState{}
+		alertsFiring.Set(0, "rule", rule.Name)
+	}
+
+	return e
+}
+
+// Run evaluates the rules against every collection until the channel is closed.
+func (e *Evaluator) Run(collections <-chan eventbus.CollectionEvent) {
+	for collection := range collections {
+		e.Evaluate(collection.Metrics)
+	}
+}
+
+// Evaluate evaluates the rules once. Series, which are no longer collected, are resolved.
+func (e *Evaluator) Evaluate(metricGroups registry.MetricsByCounterGroup) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	now := e.now()
+
+	for _, rule := range e.rules {
+		states := e.states[rule.Name]
+		seen := map[string]struct{}{}
+
+		for _, metrics := range metricGroups {
+			for counter, values := range metrics {
+				if counter.FieldName != rule.Field {
+					continue
+				}
+
+				for _, metric := range values {
+					value, err := strconv.ParseFloat(metric.Value, 64)
+					if err != nil || !rule.matches(value) {
+						continue
+					}
+
+					key, labels := seriesLabels(metric)
+					seen[key] = struct{}{}
+
+					state, exists := states[key]
+					if !exists {
+						state = &alertState{labels: labels, activeAt: now}
+						states[key] = state
+					}
+					state.value = value
+
+					if !state.firing && now.Sub(state.activeAt) >= rule.For {
+						state.firing = true
+						e.notify(rule, state, statusFiring, nil)
+					}
+				}
+			}
+		}
+
+		firing := 0
+		for key, state := range states {
+			if _, ok := seen[key]; !ok {
+				if state.firing {
+					e.notify(rule, state, statusResolved, &now)
+				}
+				delete(states, key)
+				continue
+			}
+			if state.firing {
+				firing++
+			}
+		}
+		alertsFiring.Set(float64(firing), "rule", rule.Name)
+	}
+}
+
+func (e *Evaluator) notify(rule Rule, state *alertState, status string, endsAt *time.Time) {
+	slog.Info(fmt.Sprintf("Alert %s is %s", rule.Name, status), slog.Any("labels", state.labels),
+		slog.Float64("value", state.value))
+
+	if e.notifier == nil {
+		return
+	}
+
+	err := e.notifier.Notify(Notification{
+		Status:   status,
+		Rule:     rule.Name,
+		Expr:     rule.Expr,
+		Value:    state.value,
+		Labels:   state.labels,
+		StartsAt: state.activeAt,
+		EndsAt:   endsAt,
+	})
+	if err != nil {
+		notificationErrors.Inc()
+		slog.Warn("Failed to notify alert", slog.String("rule", rule.Name),
+			slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// seriesLabels returns the identity of the series and its labels.
+func seriesLabels(metric collector.Metric) (string, map[string]string) {
+	labels := map[string]string{}
+	for k, v := range map[string]string{
+		"gpu":       metric.GPU,
+		"UUID":      metric.GPUUUID,
+		"device":    metric.GPUDevice,
+		"GPU_I_ID":  metric.GPUInstanceID,
+		"GPU_CI_ID": metric.ComputeInstanceID,
+		"Hostname":  metric.Hostname,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s/%s", metric.GPU, metric.GPUUUID, metric.GPUDevice, metric.GPUInstanceID,
+		metric.ComputeInstanceID)
+
+	return key, labels
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (r *recordingNotifier) Notify(n Notification) error {
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func newTemperatureMetrics(values ...string) registry.MetricsByCounterGroup {
+	counter := counters.Counter{FieldID: 150, FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge"}
+
+	var metrics []collector.Metric
+	for i, value := range values {
+		metrics = append(metrics, collector.Metric{
+			Counter:  counter,
+			Value:    value,
+			GPU:      strconv.Itoa(i),
+			GPUUUID:  "GPU-" + strconv.Itoa(i),
+			Hostname: "node",
+		})
+	}
+
+	return registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: {counter: metrics},
+	}
+}
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	notifier := &recordingNotifier{}
+	evaluator := NewEvaluator([]Rule{
+		{Name: "GPUTooHot", Expr: "DCGM_FI_DEV_GPU_TEMP > 90", Field: "DCGM_FI_DEV_GPU_TEMP", Op: ">", Threshold: 90,
+			For: 5 * time.Minute},
+	}, notifier)
+
+	clock := time.Unix(0, 0)
+	evaluator.now = func() time.Time { return clock }
+
+	firing := func() float64 {
+		v, _ := selfmetrics.Default().Value("dcgm_exporter_alerts_firing", "rule", "GPUTooHot")
+		return v
+	}
+
+	// GPU 0 is hot, but not long enough
+	evaluator.Evaluate(newTemperatureMetrics("95", "60"))
+	clock = clock.Add(4 * time.Minute)
+	evaluator.Evaluate(newTemperatureMetrics("95", "60"))
+	assert.Empty(t, notifier.notifications)
+	assert.Equal(t, float64(0), firing())
+
+	clock = clock.Add(time.Minute)
+	evaluator.Evaluate(newTemperatureMetrics("96", "60"))
+	require.Len(t, notifier.notifications, 1)
+	assert.Equal(t, Notification{
+		Status:   statusFiring,
+		Rule:     "GPUTooHot",
+		Expr:     "DCGM_FI_DEV_GPU_TEMP > 90",
+		Value:    96,
+		Labels:   map[string]string{"gpu": "0", "UUID": "GPU-0", "Hostname": "node"},
+		StartsAt: time.Unix(0, 0),
+	}, notifier.notifications[0])
+	assert.Equal(t, float64(1), firing())
+
+	// Firing alerts are notified once
+	clock = clock.Add(time.Minute)
+	evaluator.Evaluate(newTemperatureMetrics("96", "60"))
+	assert.Len(t, notifier.notifications, 1)
+
+	clock = clock.Add(time.Minute)
+	evaluator.Evaluate(newTemperatureMetrics("80", "60"))
+	require.Len(t, notifier.notifications, 2)
+	assert.Equal(t, statusResolved, notifier.notifications[1].Status)
+	require.NotNil(t, notifier.notifications[1].EndsAt)
+	assert.Equal(t, clock, *notifier.notifications[1].EndsAt)
+	assert.Equal(t, float64(0), firing())
+
+	// The pending duration restarts after the value recovered
+	clock = clock.Add(time.Minute)
+	evaluator.Evaluate(newTemperatureMetrics("95", "60"))
+	assert.Len(t, notifier.notifications, 2)
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Status == statusResolved {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+
+	n := Notification{
+		Status:   statusFiring,
+		Rule:     "GPUTooHot",
+		Expr:     "DCGM_FI_DEV_GPU_TEMP > 90",
+		Value:    95,
+		Labels:   map[string]string{"gpu": "0"},
+		StartsAt: time.Unix(0, 0).UTC(),
+	}
+	require.NoError(t, notifier.Notify(n))
+	assert.Equal(t, n, received)
+
+	n.Status = statusResolved
+	assert.ErrorContains(t, notifier.Notify(n), "500")
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package alerting evaluates threshold rules against the collected values in-process and notifies a webhook,
+// when a rule starts or stops firing. It is intended for edge deployments, which don't run Prometheus.
+package alerting
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+// Rule fires, when the value of the field compares to the threshold for at least the duration.
+type Rule struct {
+	Name      string
+	Expr      string
+	Field     string
+	Op        string
+	Threshold float64
+	For       time.Duration
+}
+
+// exprRegex matches the expressions of the rules, e.g. DCGM_FI_DEV_GPU_TEMP > 90
+var exprRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*(>=|<=|==|!=|>|<)\s*(\S+)$`)
+
+// LoadRules reads the rules file. Each line holds the rule name, the expression and the duration, e.g.
+// GPUTooHot, DCGM_FI_DEV_GPU_TEMP > 90, 5m. Lines starting with '#' are comments.
+func LoadRules(filename string) ([]Rule, error) {
+	records, err := counters.ReadCSVFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules file '%s'; err: %w", filename, err)
+	}
+
+	return ParseRules(records)
+}
+
+// ParseRules parses the records of a rules file.
+func ParseRules(records [][]string) ([]Rule, error) {
+	var rules []Rule
+	names := map[string]struct{}{}
+
+	for i, record := range records {
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+
+		if len(record) != 3 {
+			return nil, fmt.Errorf("malformed alert rule; err: failed to parse line %d (`%v`), expected 3 fields",
+				i, record)
+		}
+
+		rule, err := parseRule(record[0], record[1], record[2])
+		if err != nil {
+			return nil, fmt.Errorf("malformed alert rule; err: failed to parse line %d (`%v`): %w", i, record, err)
+		}
+
+		if _, exists := names[rule.Name]; exists {
+			return nil, fmt.Errorf("malformed alert rule; err: duplicate rule name '%s' on line %d", rule.Name, i)
+		}
+		names[rule.Name] = struct{}{}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parseRule(name, expr, duration string) (Rule, error) {
+	if name == "" {
+		return Rule{}, fmt.Errorf("rule name is empty")
+	}
+
+	matches := exprRegex.FindStringSubmatch(expr)
+	if matches == nil {
+		return Rule{}, fmt.Errorf("expression '%s' must be of the form '<field> <op> <threshold>'", expr)
+	}
+
+	threshold, err := strconv.ParseFloat(matches[3], 64)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid threshold '%s'", matches[3])
+	}
+
+	var forDuration time.Duration
+	if duration != "" {
+		forDuration, err = time.ParseDuration(duration)
+		if err != nil || forDuration < 0 {
+			return Rule{}, fmt.Errorf("invalid duration '%s'", duration)
+		}
+	}
+
+	return Rule{
+		Name:      name,
+		Expr:      expr,
+		Field:     matches[1],
+		Op:        matches[2],
+		Threshold: threshold,
+		For:       forDuration,
+	}, nil
+}
+
+// matches reports whether the value satisfies the expression of the rule.
+func (r Rule) matches(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alerting
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Rule
+		wantErr string
+	}{
+		{
+			name: "valid rules",
+			input: `# Name, expression, duration
+GPUTooHot, DCGM_FI_DEV_GPU_TEMP > 90, 5m
+XIDErrors, DCGM_FI_DEV_XID_ERRORS!=0,
+`,
+			want: []Rule{
+				{
+					Name: "GPUTooHot", Expr: "DCGM_FI_DEV_GPU_TEMP > 90", Field: "DCGM_FI_DEV_GPU_TEMP", Op: ">",
+					Threshold: 90, For: 5 * time.Minute,
+				},
+				{
+					Name: "XIDErrors", Expr: "DCGM_FI_DEV_XID_ERRORS!=0", Field: "DCGM_FI_DEV_XID_ERRORS", Op: "!=",
+					Threshold: 0,
+				},
+			},
+		},
+		{
+			name:    "invalid expression",
+			input:   "GPUTooHot, DCGM_FI_DEV_GPU_TEMP >> 90, 5m\n",
+			wantErr: "must be of the form",
+		},
+		{
+			name:    "invalid threshold",
+			input:   "GPUTooHot, DCGM_FI_DEV_GPU_TEMP > hot, 5m\n",
+			wantErr: "invalid threshold",
+		},
+		{
+			name:    "invalid duration",
+			input:   "GPUTooHot, DCGM_FI_DEV_GPU_TEMP > 90, 5 minutes\n",
+			wantErr: "invalid duration",
+		},
+		{
+			name:    "duplicate name",
+			input:   "GPUTooHot, DCGM_FI_DEV_GPU_TEMP > 90, 5m\nGPUTooHot, DCGM_FI_DEV_GPU_TEMP > 95, 1m\n",
+			wantErr: "duplicate rule name",
+		},
+		{
+			name:    "missing fields",
+			input:   "GPUTooHot, DCGM_FI_DEV_GPU_TEMP > 90\n",
+			wantErr: "expected 3 fields",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := counters.ReadCSV(strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			rules, err := ParseRules(records)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	for _, tt := range []struct {
+		op    string
+		value float64
+		want  bool
+	}{
+		{op: ">", value: 91, want: true},
+		{op: ">", value: 90, want: false},
+		{op: ">=", value: 90, want: true},
+		{op: "<", value: 89, want: true},
+		{op: "<=", value: 91, want: false},
+		{op: "==", value: 90, want: true},
+		{op: "!=", value: 90, want: false},
+	} {
+		rule := Rule{Op: tt.op, Threshold: 90}
+		assert.Equal(t, tt.want, rule.matches(tt.value), "%v %s 90", tt.value, tt.op)
+	}
+}
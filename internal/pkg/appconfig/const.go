@@ -20,6 +20,21 @@ const (
 	GPUUID     KubernetesGPUIDType = "uid"
 	DeviceName KubernetesGPUIDType = "device-name"
 
+	// GPUInstancesMixed renders metrics of GPU instances in the same families as metrics of physical GPUs.
+	GPUInstancesMixed GPUInstanceMetricsMode = "mixed"
+	// GPUInstancesSuffix renders metrics of GPU instances in separate families with the "_mig" suffix.
+	GPUInstancesSuffix GPUInstanceMetricsMode = "suffix"
+	// GPUInstancesLabel adds the "entity_type" label to every GPU and GPU instance metric.
+	GPUInstancesLabel GPUInstanceMetricsMode = "label"
+
+	// HostnameSourceAuto prefers the NODE_NAME environment variable, e.g. set from the downward API, over the
+	// hostname of the operating system.
+	HostnameSourceAuto HostnameSource = "auto"
+	// HostnameSourceNodeName requires the NODE_NAME environment variable.
+	HostnameSourceNodeName HostnameSource = "node-name"
+	// HostnameSourceOS ignores the NODE_NAME environment variable and uses the hostname of the operating system.
+	HostnameSourceOS HostnameSource = "os"
+
 	NvidiaResourceName      = "nvidia.com/gpu"
 	NvidiaMigResourcePrefix = "nvidia.com/mig-"
 	MIG_UUID_PREFIX         = "MIG-"
@@ -17,15 +17,33 @@
 package appconfig
 
 import (
+	"time"
+
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 )
 
 type KubernetesGPUIDType string
 
+// GPUInstanceMetricsMode defines how metrics of GPU instances are separated from metrics of physical GPUs.
+type GPUInstanceMetricsMode string
+
+// HostnameSource defines where the Hostname label of a local hostengine is read from.
+type HostnameSource string
+
 type DeviceOptions struct {
-	Flex       bool  // If true, then monitor all GPUs if MIG mode is disabled or all GPU instances if MIG is enabled.
-	MajorRange []int // The indices of each GPU/NvSwitch to monitor, or -1 to monitor all
-	MinorRange []int // The indices of each GPUInstance/NvLink to monitor, or -1 to monitor all
+	Flex             bool     // If true, then monitor all GPUs if MIG mode is disabled or all GPU instances if MIG is enabled.
+	MajorRange       []int    // The indices of each GPU/NvSwitch to monitor, or -1 to monitor all
+	MajorIdentifiers []string // The UUIDs or PCI bus IDs of each GPU to monitor, added to MajorRange once GPUs are found
+	MinorRange       []int    // The indices of each GPUInstance/NvLink to monitor, or -1 to monitor all
+	ComputeInstances bool     // If true, then monitor the compute instances of the monitored GPU instances too.
+}
+
+// EntityExclusion excludes the entities of an entity group from the export, which match the ID, or all of them, when
+// the ID is "*". GPUs are matched by index, UUID or PCI bus ID, NVLinks by <switch>/<port>, and the other entities by
+// their entity ID.
+type EntityExclusion struct {
+	EntityGroup dcgm.Field_Entity_Group
+	ID          string
 }
 
 type Config struct {
@@ -38,9 +56,11 @@ type Config struct {
 	UseOldNamespace            bool
 	UseRemoteHE                bool
 	RemoteHEInfo               string
+	RemoteHETargets            []string
 	GPUDeviceOptions           DeviceOptions
 	SwitchDeviceOptions        DeviceOptions
 	CPUDeviceOptions           DeviceOptions
+	ExcludedEntities           []EntityExclusion
 	NoHostname                 bool
 	UseFakeGPUs                bool
 	ConfigMapData              string
@@ -56,4 +76,68 @@ type Config struct {
 	PodResourcesKubeletSocket  string
 	HPCJobMappingDir           string
 	NvidiaResourceNames        []string
+	CollectEncoderDecoder      bool
+	CollectProcessTypes        bool
+	CollectDriverState         bool
+	PodResourcesTimeout        time.Duration
+	PodResourcesRefresh        time.Duration
+	PodResourcesCacheTTL       time.Duration
+	GPUInstanceMetrics         GPUInstanceMetricsMode
+	MIGUUIDLabel               bool
+	AllowedSourceCIDRs         []string
+	MetricsTokenFile           string
+	MetricsAllowedClientCNs    []string
+	AdaptiveCollectInterval    bool
+	MinCollectInterval         int
+	MaxCollectInterval         int
+	KubeletAPIURL              string
+	KubeletAPITokenFile        string
+	KubeletAPICAFile           string
+	KubeletAPIInsecure         bool
+	PodAttributionSource       bool
+	PodAttributionCgroups      bool
+	AlertRulesFile             string
+	AlertWebhookURL            string
+	HostengineLabel            string
+	AllocationEfficiency       bool
+	PodAggregates              bool
+	DownsampleCounters         []string
+	DownsampleWindow           time.Duration
+	HostnameSource             HostnameSource
+	IdleCounters               []string
+	IdleAfter                  time.Duration
+	KubeConfig                 string
+	KubernetesCAFile           string
+	KubernetesProxyURL         string
+	KubernetesNodeLabels       []string
+	EmitKubernetesEvents       bool
+	DRAResourceSlices          bool
+	WatchPodDeletions          bool
+	OpenMetricsExemplars       bool
+	NVMLFallback               bool
+	NVMLOnly                   bool
+	GoMaxProcs                 int
+	CollectWorkers             int
+	DmonColumns                []string
+	ConfigBackend              string
+	AdminTokenFile             string
+	DiagEndpoint               bool
+	PodAttributionGPUInstances bool
+	StaleMetricsMaxAge         time.Duration
+	ScrapeCacheTTL             time.Duration
+	HAStandby                  string
+	HAStandbyProbeInterval     time.Duration
+	HAStandbyFailures          int
+	CollectorsWatchInterval    time.Duration
+	TopologyWatchInterval      time.Duration
+	OTLPEndpoint               string
+	OTLPProtocol               string
+	OTLPPushInterval           time.Duration
+	OTLPResourceAttributes     []string
+	GRPCAddress                string
+	SkipEntityOnError          bool
+	MetricPrefix               string
+	LogFormat                  string
+	LogLevel                   string
+	Version                    string
 }
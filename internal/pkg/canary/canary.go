@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package canary verifies that the DCGM fields of a new counter set return values, before the counter set is
+// swapped into the serving pipeline on a configuration reload.
+package canary
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/utils"
+)
+
+const (
+	// The canary reads a single sample of every field
+	maxKeepAge     = 0
+	maxKeepSamples = 1
+)
+
+// Result is the outcome of the evaluation of a counter set.
+type Result struct {
+	// Passed lists the fields, which returned a value on at least one GPU.
+	Passed []string
+	// Failed maps the fields, which returned an error on every GPU, to the last error.
+	Failed map[string]string
+	// Skipped lists the fields, which were not evaluated, because they are not GPU fields or there are no GPUs.
+	Skipped []string
+}
+
+// OK reports whether none of the evaluated fields failed.
+func (r Result) OK() bool {
+	return len(r.Failed) == 0
+}
+
+// NewCounters returns the counters of next, which are not collected by current.
+func NewCounters(current, next counters.CounterList) counters.CounterList {
+	var added counters.CounterList
+	for _, counter := range next {
+		if !slices.ContainsFunc(current, func(c counters.Counter) bool { return c.FieldID == counter.FieldID }) {
+			added = append(added, counter)
+		}
+	}
+	return added
+}
+
+// Evaluate watches the GPU fields of the counters in a temporary group of all the GPUs, and checks that every
+// field returns a value, which is not an error, on at least one GPU. The temporary groups are destroyed before
+// Evaluate returns, so the watches of the serving pipeline are not affected.
+func Evaluate(cl counters.CounterList, updateFreq time.Duration) (Result, error) {
+	result := Result{Failed: map[string]string{}}
+
+	var fields []dcgm.Short
+	for _, counter := range cl {
+		if dcgmprovider.Client().FieldGetById(counter.FieldID).EntityLevel != dcgm.FE_GPU {
+			result.Skipped = append(result.Skipped, counter.FieldName)
+			continue
+		}
+		fields = append(fields, counter.FieldID)
+	}
+	if len(fields) == 0 {
+		return result, nil
+	}
+
+	gpus, err := dcgmprovider.Client().GetSupportedDevices()
+	if err != nil {
+		return result, fmt.Errorf("failed to list the GPUs; err: %w", err)
+	}
+	if len(gpus) == 0 {
+		for _, counter := range cl {
+			if slices.Contains(fields, counter.FieldID) {
+				result.Skipped = append(result.Skipped, counter.FieldName)
+			}
+		}
+		return result, nil
+	}
+
+	values, err := watchLatestValues(fields, gpus, updateFreq)
+	if err != nil {
+		return result, err
+	}
+
+	for _, counter := range cl {
+		if !slices.Contains(fields, counter.FieldID) {
+			continue
+		}
+		var lastErr string
+		for _, gpu := range gpus {
+			lastErr = fieldError(values[gpu][counter.FieldID])
+			if lastErr == "" {
+				break
+			}
+		}
+		if lastErr != "" {
+			result.Failed[counter.FieldName] = lastErr
+			continue
+		}
+		result.Passed = append(result.Passed, counter.FieldName)
+	}
+
+	return result, nil
+}
+
+// watchLatestValues watches the fields on the GPUs in temporary groups, and returns the first values by GPU
+// and field.
+func watchLatestValues(
+	fields []dcgm.Short, gpus []uint, updateFreq time.Duration,
+) (map[uint]map[dcgm.Short]dcgm.FieldValue_v1, error) {
+	number, err := utils.RandUint64()
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := dcgmprovider.Client().CreateGroup(fmt.Sprintf("canary-group-%d", number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the canary group; err: %w", err)
+	}
+	defer func() {
+		if err := dcgmprovider.Client().DestroyGroup(group); err != nil {
+			slog.LogAttrs(context.Background(), slog.LevelWarn, "Cannot destroy the canary group",
+				slog.Any(logging.GroupIDKey, group),
+				slog.String(logging.ErrorKey, err.Error()),
+			)
+		}
+	}()
+
+	for _, gpu := range gpus {
+		err = dcgmprovider.Client().AddEntityToGroup(group, dcgm.FE_GPU, gpu)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add GPU %d to the canary group; err: %w", gpu, err)
+		}
+	}
+
+	fieldGroup, err := dcgmprovider.Client().FieldGroupCreate(fmt.Sprintf("canary-fieldgroup-%d", number), fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the canary field group; err: %w", err)
+	}
+	defer func() {
+		if err := dcgmprovider.Client().FieldGroupDestroy(fieldGroup); err != nil {
+			slog.Warn("Cannot destroy the canary field group.", slog.String(logging.ErrorKey, err.Error()))
+		}
+	}()
+
+	err = dcgmprovider.Client().WatchFieldsWithGroupEx(fieldGroup, group, updateFreq.Microseconds(), maxKeepAge,
+		maxKeepSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the canary fields; err: %w", err)
+	}
+
+	err = dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the canary fields; err: %w", err)
+	}
+
+	values := make(map[uint]map[dcgm.Short]dcgm.FieldValue_v1, len(gpus))
+	for _, gpu := range gpus {
+		latest, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, gpu, fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the canary fields of GPU %d; err: %w", gpu, err)
+		}
+		values[gpu] = make(map[dcgm.Short]dcgm.FieldValue_v1, len(latest))
+		for _, value := range latest {
+			values[gpu][dcgm.Short(value.FieldId)] = value
+		}
+	}
+	return values, nil
+}
+
+// fieldError returns a description of the error returned instead of the value, or an empty string when the value
+// is not an error. A blank value only means that no sample was taken yet, so it is not an error.
+func fieldError(value dcgm.FieldValue_v1) string {
+	if value.Status != dcgm.DCGM_ST_OK {
+		return fmt.Sprintf("status %d", value.Status)
+	}
+
+	switch value.FieldType {
+	case dcgm.DCGM_FT_INT64:
+		switch value.Int64() {
+		case dcgm.DCGM_FT_INT32_NOT_FOUND, dcgm.DCGM_FT_INT64_NOT_FOUND:
+			return "not found"
+		case dcgm.DCGM_FT_INT32_NOT_SUPPORTED, dcgm.DCGM_FT_INT64_NOT_SUPPORTED:
+			return "not supported"
+		case dcgm.DCGM_FT_INT32_NOT_PERMISSIONED, dcgm.DCGM_FT_INT64_NOT_PERMISSIONED:
+			return "not permissioned"
+		}
+	case dcgm.DCGM_FT_DOUBLE:
+		switch value.Float64() {
+		case dcgm.DCGM_FT_FP64_NOT_FOUND:
+			return "not found"
+		case dcgm.DCGM_FT_FP64_NOT_SUPPORTED:
+			return "not supported"
+		case dcgm.DCGM_FT_FP64_NOT_PERMISSIONED:
+			return "not permissioned"
+		}
+	case dcgm.DCGM_FT_STRING:
+		switch value.String() {
+		case dcgm.DCGM_FT_STR_NOT_FOUND:
+			return "not found"
+		case dcgm.DCGM_FT_STR_NOT_SUPPORTED:
+			return "not supported"
+		case dcgm.DCGM_FT_STR_NOT_PERMISSIONED:
+			return "not permissioned"
+		}
+	case 0:
+		// The field was not returned at all
+		return "no value"
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package canary
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+func int64FieldValue(fieldID dcgm.Short, value int64) dcgm.FieldValue_v1 {
+	fieldValue := [4096]byte{}
+	binary.LittleEndian.PutUint64(fieldValue[:], uint64(value))
+	return dcgm.FieldValue_v1{
+		FieldId:   uint(fieldID),
+		FieldType: dcgm.DCGM_FT_INT64,
+		Value:     fieldValue,
+	}
+}
+
+func TestNewCounters(t *testing.T) {
+	current := counters.CounterList{
+		{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP, FieldName: "DCGM_FI_DEV_GPU_TEMP"},
+		{FieldID: dcgm.DCGM_FI_DEV_POWER_USAGE, FieldName: "DCGM_FI_DEV_POWER_USAGE"},
+	}
+	next := counters.CounterList{
+		{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP, FieldName: "DCGM_FI_DEV_GPU_TEMP"},
+		{FieldID: dcgm.DCGM_FI_DEV_SM_CLOCK, FieldName: "DCGM_FI_DEV_SM_CLOCK"},
+	}
+
+	assert.Equal(t, counters.CounterList{next[1]}, NewCounters(current, next))
+	assert.Empty(t, NewCounters(next, next))
+}
+
+func Test_fieldError(t *testing.T) {
+	tests := []struct {
+		name  string
+		value dcgm.FieldValue_v1
+		want  string
+	}{
+		{
+			name:  "Value",
+			value: int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, 42),
+		},
+		{
+			name:  "Blank",
+			value: int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT64_BLANK),
+		},
+		{
+			name:  "Not supported",
+			value: int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			want:  "not supported",
+		},
+		{
+			name:  "Not permissioned",
+			value: int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT32_NOT_PERMISSIONED),
+			want:  "not permissioned",
+		},
+		{
+			name: "Error status",
+			value: func() dcgm.FieldValue_v1 {
+				value := int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, 42)
+				value.Status = dcgm.DCGM_ST_NOT_SUPPORTED
+				return value
+			}(),
+			want: "status -6",
+		},
+		{
+			name: "Missing",
+			want: "no value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fieldError(tt.value))
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	cl := counters.CounterList{
+		{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP, FieldName: "DCGM_FI_DEV_GPU_TEMP"},
+		{FieldID: dcgm.DCGM_FI_DEV_SM_CLOCK, FieldName: "DCGM_FI_DEV_SM_CLOCK"},
+		{FieldID: dcgm.DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT, FieldName: "DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT"},
+	}
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FI_DEV_SM_CLOCK}
+	group := dcgm.GroupHandle{}
+	fieldGroup := dcgm.FieldHandle{}
+
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_GPU_TEMP)).Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_GPU})
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_SM_CLOCK)).Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_GPU})
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT)).
+		Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_SWITCH})
+	mockDCGM.EXPECT().GetSupportedDevices().Return([]uint{0, 1}, nil)
+	mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(group, nil)
+	mockDCGM.EXPECT().AddEntityToGroup(group, dcgm.FE_GPU, uint(0)).Return(nil)
+	mockDCGM.EXPECT().AddEntityToGroup(group, dcgm.FE_GPU, uint(1)).Return(nil)
+	mockDCGM.EXPECT().FieldGroupCreate(gomock.Any(), fields).Return(fieldGroup, nil)
+	mockDCGM.EXPECT().WatchFieldsWithGroupEx(fieldGroup, group, int64(1000000), float64(0), int32(1)).Return(nil)
+	mockDCGM.EXPECT().UpdateAllFields().Return(nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fields).Return([]dcgm.FieldValue_v1{
+		int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		int64FieldValue(dcgm.DCGM_FI_DEV_SM_CLOCK, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+	}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), fields).Return([]dcgm.FieldValue_v1{
+		int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, 42),
+		int64FieldValue(dcgm.DCGM_FI_DEV_SM_CLOCK, dcgm.DCGM_FT_INT64_NOT_PERMISSIONED),
+	}, nil)
+	mockDCGM.EXPECT().FieldGroupDestroy(fieldGroup).Return(nil)
+	mockDCGM.EXPECT().DestroyGroup(group).Return(nil)
+
+	result, err := Evaluate(cl, time.Second)
+	require.NoError(t, err)
+
+	assert.False(t, result.OK())
+	assert.Equal(t, []string{"DCGM_FI_DEV_GPU_TEMP"}, result.Passed)
+	assert.Equal(t, map[string]string{"DCGM_FI_DEV_SM_CLOCK": "not permissioned"}, result.Failed)
+	assert.Equal(t, []string{"DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT"}, result.Skipped)
+}
+
+func TestEvaluate_NoGPUFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT)).
+		Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_SWITCH})
+
+	result, err := Evaluate(counters.CounterList{
+		{FieldID: dcgm.DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT, FieldName: "DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT"},
+	}, time.Second)
+	require.NoError(t, err)
+
+	assert.True(t, result.OK())
+	assert.Empty(t, result.Passed)
+	assert.Equal(t, []string{"DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT"}, result.Skipped)
+}
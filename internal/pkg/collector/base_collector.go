@@ -40,6 +40,13 @@ func (c *baseExpCollector) createMetric(
 ) Metric {
 	gpuModel := getGPUModel(mi.DeviceInfo, c.config.ReplaceBlanksInModelName)
 
+	if name, value, ok := hostengineLabel(c.config); ok {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[name] = value
+	}
+
 	m := Metric{
 		Counter:      c.counter,
 		Value:        fmt.Sprint(val),
@@ -61,6 +68,9 @@ func (c *baseExpCollector) createMetric(
 		m.MigProfile = ""
 		m.GPUInstanceID = ""
 	}
+	if ci := mi.ComputeInstance(); ci != nil {
+		m.ComputeInstanceID = fmt.Sprintf("%d", ci.InstanceInfo.NvmlComputeInstanceId)
+	}
 	return m
 }
 
@@ -91,8 +101,21 @@ func (c *baseExpCollector) getLabelsFromCounters(mi devicemonitoring.Info, label
 	return nil
 }
 
+func (c *baseExpCollector) SetCollectInterval(collectInterval int64) error {
+	return c.deviceWatchList.SetCollectInterval(collectInterval)
+}
+
 func (c *baseExpCollector) Cleanup() {
 	for _, cleanup := range c.cleanups {
 		cleanup()
 	}
 }
+
+// hostengineLabel returns the name and the value of the label, which identifies the remote hostengine.
+func hostengineLabel(config *appconfig.Config) (string, string, bool) {
+	if config == nil || !config.UseRemoteHE || config.HostengineLabel == "" {
+		return "", "", false
+	}
+
+	return config.HostengineLabel, config.RemoteHEInfo, true
+}
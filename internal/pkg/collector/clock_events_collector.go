@@ -26,6 +26,7 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 )
 
 // IsDCGMExpClockEventsCountEnabled checks if the DCGM_EXP_CLOCK_EVENTS_COUNT counter exists
@@ -93,8 +94,9 @@ func NewClockEventsCollector(
 	deviceWatchList devicewatchlistmanager.WatchList,
 ) (Collector, error) {
 	if !IsDCGMExpClockEventsCountEnabled(counterList) {
-		slog.Error(counters.DCGMExpClockEventsCount + " collector is disabled")
-		return nil, fmt.Errorf(counters.DCGMExpClockEventsCount + " collector is disabled")
+		slog.Error(counters.DCGMExpClockEventsCount+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpClockEventsCount))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpClockEventsCount)
 	}
 
 	collector := clockEventsCollector{}
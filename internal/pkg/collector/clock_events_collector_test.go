@@ -157,7 +157,7 @@ func TestNewClockEventsCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, fmt.Errorf("some error"))
 			},
@@ -184,7 +184,7 @@ func TestNewClockEventsCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, nil)
 			},
@@ -223,7 +223,7 @@ func TestNewClockEventsCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, nil)
 			},
@@ -434,7 +434,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -494,7 +494,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -560,7 +560,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -636,7 +636,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -690,7 +690,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher, _, _ byte) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -717,7 +717,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher, _, _ byte) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -747,7 +747,7 @@ func Test_clockEventsCollector_GetMetrics(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher, _, _ byte) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
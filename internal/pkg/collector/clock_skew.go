@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	// Number of recent observations, which the skew is estimated from
+	clockSkewObservations = 10
+	// Skew, above which the clocks are considered out of sync and a warning is logged
+	clockSkewWarnThreshold = 5 * time.Second
+
+	clockSkewGauge = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_clock_skew_seconds",
+		"Estimated difference between the exporter clock and the hostengine clock, in seconds.")
+
+	// now is replaced in tests.
+	now = time.Now
+)
+
+// clockSkewEstimator estimates how far the exporter clock is ahead of the remote hostengine clock.
+// Each observation compares the local time to the newest sample timestamp. As samples are never newer
+// than the hostengine time, the difference is the skew plus the sample age, so the smallest difference
+// among the recent observations is used as the estimate.
+type clockSkewEstimator struct {
+	mtx          sync.Mutex
+	observations []time.Duration
+	next         int
+	skew         time.Duration
+	warned       bool
+}
+
+var hostengineClock = &clockSkewEstimator{}
+
+// observe updates the estimate from the timestamps, in microseconds, of the latest samples.
+func (e *clockSkewEstimator) observe(localNow time.Time, timestamps ...int64) {
+	var newest int64
+	for _, ts := range timestamps {
+		newest = max(newest, ts)
+	}
+	if newest <= 0 {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	diff := localNow.Sub(time.UnixMicro(newest))
+	if len(e.observations) < clockSkewObservations {
+		e.observations = append(e.observations, diff)
+	} else {
+		e.observations[e.next] = diff
+		e.next = (e.next + 1) % clockSkewObservations
+	}
+
+	e.skew = e.observations[0]
+	for _, o := range e.observations[1:] {
+		e.skew = min(e.skew, o)
+	}
+
+	clockSkewGauge.Set(e.skew.Seconds())
+
+	outOfSync := e.skew > clockSkewWarnThreshold || e.skew < -clockSkewWarnThreshold
+	if outOfSync && !e.warned {
+		slog.Warn(fmt.Sprintf("The exporter clock differs from the hostengine clock by %s; "+
+			"time windows are adjusted to the hostengine clock", e.skew))
+	}
+	e.warned = outOfSync
+}
+
+// now returns the current time on the hostengine clock.
+func (e *clockSkewEstimator) now() time.Time {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return now().Add(-e.skew)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestClockSkewEstimator(t *testing.T) {
+	localNow := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return localNow }
+	defer func() { now = time.Now }()
+
+	const skew = time.Minute
+	hostengineNow := localNow.Add(-skew)
+
+	tests := []struct {
+		name       string
+		timestamps [][]int64
+		wantSkew   time.Duration
+	}{
+		{
+			name:     "No observations",
+			wantSkew: 0,
+		},
+		{
+			name:       "Invalid timestamps are ignored",
+			timestamps: [][]int64{{0}, {}},
+			wantSkew:   0,
+		},
+		{
+			name: "Newest sample of the smallest observation",
+			timestamps: [][]int64{
+				{hostengineNow.Add(-20 * time.Second).UnixMicro(), hostengineNow.Add(-5 * time.Second).UnixMicro()},
+				{hostengineNow.Add(-time.Second).UnixMicro()},
+				{hostengineNow.Add(-10 * time.Second).UnixMicro()},
+			},
+			wantSkew: skew + time.Second,
+		},
+		{
+			name:       "Hostengine ahead of the exporter",
+			timestamps: [][]int64{{localNow.Add(30 * time.Second).UnixMicro()}},
+			wantSkew:   -30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &clockSkewEstimator{}
+			for _, timestamps := range tt.timestamps {
+				e.observe(localNow, timestamps...)
+			}
+			assert.Equal(t, tt.wantSkew, e.skew)
+			assert.Equal(t, localNow.Add(-tt.wantSkew), e.now())
+		})
+	}
+
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_hostengine_clock_skew_seconds")
+	assert.Equal(t, float64(-30), value)
+}
+
+func TestClockSkewEstimator_OldObservationsExpire(t *testing.T) {
+	localNow := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	e := &clockSkewEstimator{}
+	e.observe(localNow, localNow.Add(-time.Hour).UnixMicro())
+	assert.Equal(t, time.Hour, e.skew)
+
+	// The clocks were synchronized
+	for i := 0; i < clockSkewObservations; i++ {
+		e.observe(localNow, localNow.Add(-time.Second).UnixMicro())
+	}
+	assert.Equal(t, time.Second, e.skew)
+	assert.Len(t, e.observations, clockSkewObservations)
+}
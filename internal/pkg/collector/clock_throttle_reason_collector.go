@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const clockThrottleReasonLabel = "reason"
+
+// clockThrottleReasons are the reasons of the clock event bitmask, in the order of their bits
+var clockThrottleReasons = []clockEventBitmask{
+	DCGM_CLOCKS_THROTTLE_REASON_GPU_IDLE,
+	DCGM_CLOCKS_THROTTLE_REASON_CLOCKS_SETTING,
+	DCGM_CLOCKS_THROTTLE_REASON_SW_POWER_CAP,
+	DCGM_CLOCKS_THROTTLE_REASON_HW_SLOWDOWN,
+	DCGM_CLOCKS_THROTTLE_REASON_SYNC_BOOST,
+	DCGM_CLOCKS_THROTTLE_REASON_SW_THERMAL,
+	DCGM_CLOCKS_THROTTLE_REASON_HW_THERMAL,
+	DCGM_CLOCKS_THROTTLE_REASON_HW_POWER_BRAKE,
+	DCGM_CLOCKS_THROTTLE_REASON_DISPLAY_CLOCKS,
+}
+
+// clockThrottleReasonCollector decodes the latest clock event bitmask of a GPU into a series per reason, which is 1
+// while the clocks are reduced for the reason and 0 otherwise, so that alerts can select the reasons by label instead
+// of testing bits of the raw value. Unlike DCGM_EXP_CLOCK_EVENTS_COUNT, it reports the current state rather than the
+// number of samples within a window. GPUs without a value are omitted.
+type clockThrottleReasonCollector struct {
+	baseExpCollector
+}
+
+func (c *clockThrottleReasonCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, mi.DeviceInfo.GPU,
+			[]dcgm.Short{dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS})
+		if err != nil {
+			return nil, err
+		}
+
+		reasons, ok := clockEventReasons(latestValues)
+		if !ok {
+			continue
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, reason := range clockThrottleReasons {
+			reasonLabels := maps.Clone(labels)
+			reasonLabels[clockThrottleReasonLabel] = reason.String()
+
+			m := c.createMetric(reasonLabels, gpuInfo, uuid, boolToInt(reasons&reason != 0))
+			metrics[c.counter] = append(metrics[c.counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// clockEventReasons returns the clock event bitmask of the values. ok is false, when it has no value.
+func clockEventReasons(values []dcgm.FieldValue_v1) (reasons clockEventBitmask, ok bool) {
+	for _, val := range values {
+		if dcgm.Short(val.FieldId) != dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS || val.FieldType != dcgm.DCGM_FT_INT64 ||
+			toString(val) == skipDCGMValue {
+			continue
+		}
+		return clockEventBitmask(val.Int64()), true
+	}
+	return 0, false
+}
+
+func NewClockThrottleReasonCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpClockThrottleReasonEnabled(counterList) {
+		slog.Error(counters.DCGMExpClockThrottleReason+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpClockThrottleReason))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpClockThrottleReason)
+	}
+
+	deviceWatchList.SetDeviceFields([]dcgm.Short{dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS})
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &clockThrottleReasonCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpClockThrottleReason
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+	}, nil
+}
+
+func IsDCGMExpClockThrottleReasonEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpClockThrottleReason
+	})
+}
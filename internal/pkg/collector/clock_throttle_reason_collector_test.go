@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func Test_clockEventReasons(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []dcgm.FieldValue_v1
+		wantReasons clockEventBitmask
+		wantOK      bool
+	}{
+		{
+			name:   "No value",
+			values: []dcgm.FieldValue_v1{},
+		},
+		{
+			name: "Not supported",
+			values: []dcgm.FieldValue_v1{
+				int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			},
+		},
+		{
+			name: "Several reasons",
+			values: []dcgm.FieldValue_v1{
+				int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS,
+					int64(DCGM_CLOCKS_THROTTLE_REASON_SW_POWER_CAP|DCGM_CLOCKS_THROTTLE_REASON_HW_THERMAL)),
+			},
+			wantReasons: DCGM_CLOCKS_THROTTLE_REASON_SW_POWER_CAP | DCGM_CLOCKS_THROTTLE_REASON_HW_THERMAL,
+			wantOK:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, ok := clockEventReasons(tt.values)
+			assert.Equal(t, tt.wantReasons, reasons)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestClockThrottleReasonCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS}
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(fields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// GPU 0 is power capped and in a HW slowdown, GPU 1 doesn't report the reasons
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS,
+				int64(DCGM_CLOCKS_THROTTLE_REASON_SW_POWER_CAP|DCGM_CLOCKS_THROTTLE_REASON_HW_SLOWDOWN)),
+		}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), fields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS, dcgm.DCGM_FT_INT64_BLANK),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	reason := counters.Counter{FieldName: counters.DCGMExpClockThrottleReason, PromType: "gauge"}
+
+	c, err := NewClockThrottleReasonCollector(counters.CounterList{reason}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	for _, m := range metrics[reason] {
+		assert.Equal(t, "0", m.GPU)
+		got[m.Labels[clockThrottleReasonLabel]] = m.Value
+	}
+
+	assert.Equal(t, map[string]string{
+		"gpu_idle":       "0",
+		"clocks_setting": "0",
+		"power_cap":      "1",
+		"hw_slowdown":    "1",
+		"sync_boost":     "0",
+		"sw_thermal":     "0",
+		"hw_thermal":     "0",
+		"hw_power_brake": "0",
+		"display_clocks": "0",
+	}, got)
+}
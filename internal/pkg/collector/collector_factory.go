@@ -26,10 +26,175 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 )
 
+var collectorDegraded = selfmetrics.Default().Gauge("dcgm_exporter_collector_degraded",
+	"Collectors, which failed to initialize and are disabled (1 = disabled).")
+
+type expCollectorConstructor func(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error)
+
+// expCollectorKind describes a collector of exporter counters: whether the configuration enables it, the entity
+// type of its metrics, whose watch list it is created with, and how it is created.
+type expCollectorKind struct {
+	name        string
+	entity      dcgm.Field_Entity_Group
+	enabled     func(counters.CounterList, *appconfig.Config) bool
+	constructor expCollectorConstructor
+}
+
+// countersEnable adapts the collectors, which are enabled by listing their counter in the counters file.
+func countersEnable(enabled func(counters.CounterList) bool) func(counters.CounterList, *appconfig.Config) bool {
+	return func(counterList counters.CounterList, _ *appconfig.Config) bool {
+		return enabled(counterList)
+	}
+}
+
+// expCollectors are the collectors of exporter counters, in the order they are registered.
+var expCollectors = []expCollectorKind{
+	{
+		name:        counters.DCGMExpClockEventsCount,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpClockEventsCountEnabled),
+		constructor: NewClockEventsCollector,
+	},
+	{
+		name:        counters.DCGMExpClockThrottleReason,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpClockThrottleReasonEnabled),
+		constructor: NewClockThrottleReasonCollector,
+	},
+	{
+		name:        counters.DCGMExpXIDErrorsCount,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpXIDErrorsCountEnabled),
+		constructor: NewXIDCollector,
+	},
+	{
+		name:        counters.DCGMExpGPUHealthStatus,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpGPUHealthStatusEnabled),
+		constructor: NewGPUHealthStatusCollector,
+	},
+	{
+		name:        counters.DCGMExpMIGDeviceInfo,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpMIGDeviceInfoEnabled),
+		constructor: NewMIGDeviceInfoCollector,
+	},
+	{
+		name:        counters.DCGMExpMemoryTemp,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpMemoryThermalEnabled),
+		constructor: NewMemoryThermalCollector,
+	},
+	{
+		name:        counters.DCGMExpRemappedRows,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpMemoryHealthEnabled),
+		constructor: NewMemoryHealthCollector,
+	},
+	{
+		name:        counters.DCGMExpPCIeReplayCounter,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpPCIeErrorsEnabled),
+		constructor: NewPCIeErrorsCollector,
+	},
+	{
+		name:        counters.DCGMExpFabricInfo,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpFabricInfoEnabled),
+		constructor: NewFabricInfoCollector,
+	},
+	{
+		name:        counters.DCGMExpGPUTopology,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpGPUTopologyEnabled),
+		constructor: NewGPUTopologyCollector,
+	},
+	{
+		name:        counters.DCGMExpPowerLimitCapped,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpPowerLimitCappedEnabled),
+		constructor: NewPowerLimitCollector,
+	},
+	{
+		name:        counters.DCGMExpNVLinkStateTransitions,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpNVLinkTransitionsEnabled),
+		constructor: NewNVLinkTransitionsCollector,
+	},
+	{
+		name:        counters.DCGMExpNVSwitchLinkHealth,
+		entity:      dcgm.FE_SWITCH,
+		enabled:     countersEnable(IsDCGMExpNVSwitchLinkHealthEnabled),
+		constructor: NewNVSwitchLinkHealthCollector,
+	},
+	{
+		name:        counters.DCGMExpFBMemory,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpFBMemoryEnabled),
+		constructor: NewFBMemoryCollector,
+	},
+	{
+		name:        counters.DCGMExpPendingECCModeChange,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpConfigStateEnabled),
+		constructor: NewConfigStateCollector,
+	},
+	{
+		name:        counters.DCGMExpProcessMemUsed,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpProcessEnabled),
+		constructor: NewProcessCollector,
+	},
+	{
+		name:        counters.DCGMExpXIDErrorsTotal,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpXIDErrorsTotalEnabled),
+		constructor: NewXIDTotalCollector,
+	},
+	{
+		name:        counters.DCGMExpBuildInfo,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpBuildInfoEnabled),
+		constructor: NewBuildInfoCollector,
+	},
+	{
+		name:        counters.DCGMExpSystemInfo,
+		entity:      dcgm.FE_GPU,
+		enabled:     countersEnable(IsDCGMExpSystemInfoEnabled),
+		constructor: NewSystemInfoCollector,
+	},
+	{
+		name:        counters.DCGMExpEncoderSessionsCount,
+		entity:      dcgm.FE_GPU,
+		enabled:     func(_ counters.CounterList, config *appconfig.Config) bool { return config.CollectEncoderDecoder },
+		constructor: NewEncoderDecoderCollector,
+	},
+	{
+		name:        counters.DCGMExpComputeProcessCount,
+		entity:      dcgm.FE_GPU,
+		enabled:     func(_ counters.CounterList, config *appconfig.Config) bool { return config.CollectProcessTypes },
+		constructor: NewProcessTypeCollector,
+	},
+	{
+		name:        counters.DCGMExpDriverInfo,
+		entity:      dcgm.FE_GPU,
+		enabled:     func(_ counters.CounterList, config *appconfig.Config) bool { return config.CollectDriverState },
+		constructor: NewDriverStateCollector,
+	},
+}
+
 type Factory interface {
 	NewCollectors() []EntityCollectorTuple
+	TryNewCollectors() ([]EntityCollectorTuple, error)
+	NewEntityCollector(entityType dcgm.Field_Entity_Group) (EntityCollectorTuple, error)
 }
 
 type collectorFactory struct {
@@ -53,7 +218,35 @@ func InitCollectorFactory(
 	}
 }
 
+// collectorFailed handles a collector, which cannot be initialized. The error is returned, unless the factory is
+// configured to skip the collectors failing to initialize, e.g. NvLinks rejected by the hostengine; then only the
+// collector is disabled and reported as degraded.
+func (cf *collectorFactory) collectorFailed(name string, err error) error {
+	if !cf.config.SkipEntityOnError {
+		return fmt.Errorf("collector '%s' cannot be initialized; err: %w", name, err)
+	}
+
+	slog.Warn("Collector cannot be initialized and is disabled",
+		slog.String(logging.CollectorKey, name),
+		slog.String(logging.ErrorKey, err.Error()))
+	collectorDegraded.Set(1, "collector", name)
+	return nil
+}
+
+// NewCollectors creates the collectors of the counters. The exporter exits, when a collector cannot be initialized,
+// unless the factory is configured to skip it.
 func (cf *collectorFactory) NewCollectors() []EntityCollectorTuple {
+	entityCollectorTuples, err := cf.TryNewCollectors()
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	return entityCollectorTuples
+}
+
+// TryNewCollectors creates the collectors of the counters like NewCollectors, but returns the error of a collector,
+// which cannot be initialized, after cleaning up the collectors created before.
+func (cf *collectorFactory) TryNewCollectors() ([]EntityCollectorTuple, error) {
 	slog.Debug("Counters are being initialized.",
 		slog.String(logging.DumpKey, fmt.Sprintf("%+v", cf.counterSet.DCGMCounters)))
 
@@ -64,6 +257,7 @@ func (cf *collectorFactory) NewCollectors() []EntityCollectorTuple {
 		dcgm.FE_LINK,
 		dcgm.FE_CPU,
 		dcgm.FE_CPU_CORE,
+		dcgm.FE_VGPU,
 	}
 
 	for _, entityType := range entityTypes {
@@ -74,9 +268,10 @@ func (cf *collectorFactory) NewCollectors() []EntityCollectorTuple {
 			}
 
 			if dcgmCollector, err := cf.enableDCGMCollector(entityWatchList); err != nil {
-				slog.Error(fmt.Sprintf("DCGM collector for entity type '%s' cannot be initialized; err: %v",
-					entityType.String(), err))
-				os.Exit(1)
+				if err := cf.collectorFailed(entityType.String(), err); err != nil {
+					cleanupCollectors(entityCollectorTuples)
+					return nil, err
+				}
 			} else {
 				entityCollectorTuples = append(entityCollectorTuples, EntityCollectorTuple{
 					entity:    entityType,
@@ -86,43 +281,64 @@ func (cf *collectorFactory) NewCollectors() []EntityCollectorTuple {
 		}
 	}
 
-	if IsDCGMExpClockEventsCountEnabled(cf.counterSet.ExporterCounters) {
-		if newCollector, err := cf.enableExpCollector(counters.DCGMExpClockEventsCount); err != nil {
-			slog.Error(fmt.Sprintf("collector '%s' cannot be initialized; err: %v", counters.DCGMExpClockEventsCount, err))
-			os.Exit(1)
-		} else {
-			entityCollectorTuples = append(entityCollectorTuples, EntityCollectorTuple{
-				entity:    dcgm.FE_GPU,
-				collector: newCollector,
-			})
+	for _, ec := range expCollectors {
+		if !ec.enabled(cf.counterSet.ExporterCounters, cf.config) {
+			continue
 		}
-	}
 
-	if IsDCGMExpXIDErrorsCountEnabled(cf.counterSet.ExporterCounters) {
-		if newCollector, err := cf.enableExpCollector(counters.DCGMExpXIDErrorsCount); err != nil {
-			slog.Error(fmt.Sprintf("collector '%s' cannot be initialized; err: %v", counters.DCGMExpXIDErrorsCount, err))
-			os.Exit(1)
-		} else {
-			entityCollectorTuples = append(entityCollectorTuples, EntityCollectorTuple{
-				entity:    dcgm.FE_GPU,
-				collector: newCollector,
-			})
+		if ec.entity != dcgm.FE_GPU {
+			// Like the DCGM collectors, the collectors of other entities are only created, when the node has them
+			if _, exists := cf.deviceWatchListManager.EntityWatchList(ec.entity); !exists {
+				slog.Info(fmt.Sprintf("Not collecting %s; no %s entities", ec.name, ec.entity.String()))
+				continue
+			}
 		}
-	}
 
-	if IsDCGMExpGPUHealthStatusEnabled(cf.counterSet.ExporterCounters) {
-		if newCollector, err := cf.enableExpCollector(counters.DCGMExpGPUHealthStatus); err != nil {
-			slog.Error(fmt.Sprintf("collector '%s' cannot be initialized; err: %v", counters.DCGMExpGPUHealthStatus, err))
-			os.Exit(1)
+		if newCollector, err := cf.enableExpCollector(ec); err != nil {
+			if err := cf.collectorFailed(ec.name, err); err != nil {
+				cleanupCollectors(entityCollectorTuples)
+				return nil, err
+			}
 		} else {
 			entityCollectorTuples = append(entityCollectorTuples, EntityCollectorTuple{
-				entity:    dcgm.FE_GPU,
+				entity:    ec.entity,
 				collector: newCollector,
 			})
 		}
 	}
 
-	return entityCollectorTuples
+	return entityCollectorTuples, nil
+}
+
+// cleanupCollectors cleans up the created collectors.
+func cleanupCollectors(entityCollectorTuples []EntityCollectorTuple) {
+	for _, tuple := range entityCollectorTuples {
+		tuple.collector.Cleanup()
+	}
+}
+
+// NewEntityCollector creates the DCGM collector for a single entity type. It is used for entities
+// discovered after the exporter has started, for example CPUs on Grace systems.
+func (cf *collectorFactory) NewEntityCollector(entityType dcgm.Field_Entity_Group) (EntityCollectorTuple, error) {
+	if len(cf.counterSet.DCGMCounters) == 0 {
+		return EntityCollectorTuple{}, fmt.Errorf("no DCGM counters to collect for entity type '%s'",
+			entityType.String())
+	}
+
+	entityWatchList, exists := cf.deviceWatchListManager.EntityWatchList(entityType)
+	if !exists || len(entityWatchList.DeviceFields()) == 0 {
+		return EntityCollectorTuple{}, fmt.Errorf("entity type '%s' does not exist", entityType.String())
+	}
+
+	dcgmCollector, err := cf.enableDCGMCollector(entityWatchList)
+	if err != nil {
+		return EntityCollectorTuple{}, err
+	}
+
+	return EntityCollectorTuple{
+		entity:    entityType,
+		collector: dcgmCollector,
+	}, nil
 }
 
 func (cf *collectorFactory) enableDCGMCollector(entityWatchList devicewatchlistmanager.WatchList) (Collector, error,
@@ -136,37 +352,20 @@ func (cf *collectorFactory) enableDCGMCollector(entityWatchList devicewatchlistm
 	return newCollector, nil
 }
 
-func (cf *collectorFactory) enableExpCollector(expCollectorName string) (Collector, error) {
-	entityType := dcgm.FE_GPU
-
-	item, exists := cf.deviceWatchListManager.EntityWatchList(entityType)
+func (cf *collectorFactory) enableExpCollector(ec expCollectorKind) (Collector, error) {
+	item, exists := cf.deviceWatchListManager.EntityWatchList(ec.entity)
 	if !exists {
-		return nil, fmt.Errorf("entity type '%s' does not exist", entityType.String())
-	}
-
-	var newCollector Collector
-	var err error
-	switch expCollectorName {
-	case counters.DCGMExpClockEventsCount:
-		newCollector, err = NewClockEventsCollector(cf.counterSet.ExporterCounters, cf.hostname, cf.config,
-			item)
-	case counters.DCGMExpXIDErrorsCount:
-		newCollector, err = NewXIDCollector(cf.counterSet.ExporterCounters, cf.hostname, cf.config,
-			item)
-	case counters.DCGMExpGPUHealthStatus:
-		newCollector, err = NewGPUHealthStatusCollector(cf.counterSet.ExporterCounters,
-			cf.hostname,
-			cf.config,
-			item,
-		)
-	default:
-		err = fmt.Errorf("invalid collector '%s'", expCollectorName)
+		return nil, fmt.Errorf("entity type '%s' does not exist", ec.entity.String())
 	}
+	// The exporter collectors read the values of their fields through a single field group, so the fields are
+	// watched with the collect interval, even when they are listed with their own interval.
+	item.SetFieldIntervals(nil)
 
+	newCollector, err := ec.constructor(cf.counterSet.ExporterCounters, cf.hostname, cf.config, item)
 	if err != nil {
 		return nil, err
 	}
 
-	slog.Info(fmt.Sprintf("collector '%s' initialized", expCollectorName))
+	slog.Info(fmt.Sprintf("collector '%s' initialized", ec.name))
 	return newCollector, nil
 }
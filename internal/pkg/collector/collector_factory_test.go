@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatcher"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 )
 
 var deviceWatcher = devicewatcher.NewDeviceWatcher()
@@ -76,7 +78,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 		config                    *appconfig.Config
 		setupDCGMMock             func(*mockdcgm.MockDCGM)
 		assert                    func(*testing.T, []EntityCollectorTuple)
-		wantsPanic                bool
+		wantsErr                  bool
 	}{
 		{
 			name: fmt.Sprintf("Collector enabled for the %s", dcgm.FE_GPU.String()),
@@ -137,7 +139,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 				mockGroupHandle := dcgm.GroupHandle{}
 				mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(mockGroupHandle, errors.New("boom")).AnyTimes()
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_CLOCK_EVENTS_COUNT collector is enabled",
@@ -180,9 +182,9 @@ func Test_collectorFactory_Register(t *testing.T) {
 					WatchList{}, false).AnyTimes()
 				return mockDeviceWatchListManager
 			},
-			hostname:   "testhost",
-			config:     &appconfig.Config{},
-			wantsPanic: true,
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			wantsErr: true,
 			assert: func(t *testing.T, entityCollectorTuples []EntityCollectorTuple) {
 				require.Len(t, entityCollectorTuples, 0)
 			},
@@ -209,9 +211,9 @@ func Test_collectorFactory_Register(t *testing.T) {
 				mockGroupHandle := dcgm.GroupHandle{}
 				mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(mockGroupHandle, errors.New("boom")).AnyTimes()
 			},
-			hostname:   "testhost",
-			config:     &appconfig.Config{},
-			wantsPanic: true,
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_XID_ERRORS_COUNT collector is enabled",
@@ -256,9 +258,9 @@ func Test_collectorFactory_Register(t *testing.T) {
 					WatchList{}, false).AnyTimes()
 				return mockDeviceWatchListManager
 			},
-			hostname:   "testhost",
-			config:     &appconfig.Config{},
-			wantsPanic: true,
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			wantsErr: true,
 			assert: func(t *testing.T, entityCollectorTuples []EntityCollectorTuple) {
 				require.Len(t, entityCollectorTuples, 0)
 			},
@@ -285,9 +287,9 @@ func Test_collectorFactory_Register(t *testing.T) {
 				mockGroupHandle := dcgm.GroupHandle{}
 				mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(mockGroupHandle, errors.New("boom")).AnyTimes()
 			},
-			hostname:   "testhost",
-			config:     &appconfig.Config{},
-			wantsPanic: true,
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector is enabled",
@@ -324,6 +326,59 @@ func Test_collectorFactory_Register(t *testing.T) {
 				require.IsType(t, &gpuHealthStatusCollector{}, entityCollectorTuples[0].Collector())
 			},
 		},
+		{
+			name: "DCGM_EXP_NVSWITCH_LINK_HEALTH collector is created with the NVSwitch watch list",
+			cs: &counters.CounterSet{
+				DCGMCounters: []counters.Counter{},
+				ExporterCounters: []counters.Counter{
+					{
+						FieldName: "DCGM_EXP_NVSWITCH_LINK_HEALTH",
+					},
+				},
+			},
+			getDeviceWatchListManager: func() devicewatchlistmanager.Manager {
+				mockSwitchInfo := mockdeviceinfo.NewMockProvider(ctrl)
+				mockSwitchInfo.EXPECT().InfoType().Return(dcgm.FE_SWITCH).AnyTimes()
+				switchWatchList := *devicewatchlistmanager.NewWatchList(mockSwitchInfo, nil, nil, deviceWatcher,
+					int64(1))
+
+				mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+				mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_SWITCH).Return(switchWatchList,
+					true).AnyTimes()
+				return mockDeviceWatchListManager
+			},
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			assert: func(t *testing.T, entityCollectorTuples []EntityCollectorTuple) {
+				require.Len(t, entityCollectorTuples, 1)
+				require.Equal(t, dcgm.FE_SWITCH, entityCollectorTuples[0].Entity())
+				require.IsType(t, &nvswitchLinkHealthCollector{}, entityCollectorTuples[0].Collector())
+				c := entityCollectorTuples[0].Collector().(*nvswitchLinkHealthCollector)
+				require.Equal(t, dcgm.FE_SWITCH, c.deviceWatchList.DeviceInfo().InfoType())
+			},
+		},
+		{
+			name: "DCGM_EXP_NVSWITCH_LINK_HEALTH collector is not created without NVSwitches",
+			cs: &counters.CounterSet{
+				DCGMCounters: []counters.Counter{},
+				ExporterCounters: []counters.Counter{
+					{
+						FieldName: "DCGM_EXP_NVSWITCH_LINK_HEALTH",
+					},
+				},
+			},
+			getDeviceWatchListManager: func() devicewatchlistmanager.Manager {
+				mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+				mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_SWITCH).Return(
+					devicewatchlistmanager.WatchList{}, false)
+				return mockDeviceWatchListManager
+			},
+			hostname: "testhost",
+			config:   &appconfig.Config{},
+			assert: func(t *testing.T, entityCollectorTuples []EntityCollectorTuple) {
+				require.Empty(t, entityCollectorTuples)
+			},
+		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized",
 			cs: &counters.CounterSet{
@@ -345,7 +400,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 			setupDCGMMock: func(mockDCGM *mockdcgm.MockDCGM) {
 				mockDCGM.EXPECT().GetSupportedDevices().Return([]uint{}, errors.New("boom!")).AnyTimes()
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when zero supported devices",
@@ -368,7 +423,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 			setupDCGMMock: func(mockDCGM *mockdcgm.MockDCGM) {
 				mockDCGM.EXPECT().GetSupportedDevices().Return([]uint{}, nil)
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when entity group can not be created",
@@ -394,7 +449,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 					return strings.HasPrefix(x.(string), "gpu_health_monitor_")
 				})).Return(dcgm.GroupHandle{}, errors.New("boom!"))
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when entity can not be added to the group",
@@ -421,7 +476,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 				})).Return(dcgm.GroupHandle{}, nil)
 				mockDCGM.EXPECT().AddEntityToGroup(gomock.Any(), gomock.Any(), gomock.Eq(uint(0))).Return(errors.New("boom!"))
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when enable healthcheck returns an error",
@@ -449,7 +504,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 				mockDCGM.EXPECT().AddEntityToGroup(gomock.Any(), gomock.Any(), gomock.Eq(uint(0))).Return(nil)
 				mockDCGM.EXPECT().HealthSet(gomock.Any(), gomock.Eq(dcgm.DCGM_HEALTH_WATCH_ALL)).Return(errors.New("boom!"))
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when deviceinfo.Initialize returns an error",
@@ -478,7 +533,7 @@ func Test_collectorFactory_Register(t *testing.T) {
 				mockDCGM.EXPECT().HealthSet(gomock.Any(), gomock.Eq(dcgm.DCGM_HEALTH_WATCH_ALL)).Return(nil)
 				mockDCGM.EXPECT().GetAllDeviceCount().Return(uint(0), errors.New("boom!"))
 			},
-			wantsPanic: true,
+			wantsErr: true,
 		},
 		{
 			name: "DCGM_EXP_GPU_HEALTH_STATUS collector can not be initialized when device watch returns an error",
@@ -512,7 +567,41 @@ func Test_collectorFactory_Register(t *testing.T) {
 					return strings.HasPrefix(x.(string), "gpu-collector-group")
 				})).Return(dcgm.GroupHandle{}, errors.New("boom!"))
 			},
-			wantsPanic: true,
+			wantsErr: true,
+		},
+		{
+			name: "DCGM_EXP_GPU_HEALTH_STATUS collector is disabled when it can not be initialized and entities are skipped on error",
+			cs: &counters.CounterSet{
+				DCGMCounters: []counters.Counter{},
+				ExporterCounters: []counters.Counter{
+					{
+						FieldName: "DCGM_EXP_GPU_HEALTH_STATUS",
+					},
+				},
+			},
+			getDeviceWatchListManager: func() devicewatchlistmanager.Manager {
+				mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+				mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(defaultDeviceWatchList,
+					true)
+				return mockDeviceWatchListManager
+			},
+			hostname: "testhost",
+			config:   &appconfig.Config{SkipEntityOnError: true},
+			setupDCGMMock: func(mockDCGM *mockdcgm.MockDCGM) {
+				mockDCGM.EXPECT().GetSupportedDevices().Return([]uint{0}, nil)
+				mockDCGM.EXPECT().CreateGroup(gomock.Cond(func(x any) bool {
+					return strings.HasPrefix(x.(string), "gpu_health_monitor_")
+				})).Return(dcgm.GroupHandle{}, nil)
+				mockDCGM.EXPECT().AddEntityToGroup(gomock.Any(), gomock.Any(), gomock.Eq(uint(0))).Return(nil)
+				mockDCGM.EXPECT().HealthSet(gomock.Any(), gomock.Eq(dcgm.DCGM_HEALTH_WATCH_ALL)).Return(errors.New("boom!"))
+			},
+			assert: func(t *testing.T, tuples []EntityCollectorTuple) {
+				require.Empty(t, tuples)
+				value, exists := selfmetrics.Default().Value("dcgm_exporter_collector_degraded",
+					"collector", "DCGM_EXP_GPU_HEALTH_STATUS")
+				require.True(t, exists)
+				require.Equal(t, float64(1), value)
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -541,11 +630,10 @@ func Test_collectorFactory_Register(t *testing.T) {
 				tt.setupDCGMMock(mockDCGMProvider)
 			}
 
-			if tt.wantsPanic {
-				require.PanicsWithValue(t, "os.Exit", func() {
-					InitCollectorFactory(tt.cs, tt.getDeviceWatchListManager(), tt.hostname,
-						tt.config).NewCollectors()
-				})
+			if tt.wantsErr {
+				_, err := InitCollectorFactory(tt.cs, tt.getDeviceWatchListManager(), tt.hostname,
+					tt.config).TryNewCollectors()
+				require.Error(t, err)
 				return
 			}
 			entityCollectors := InitCollectorFactory(tt.cs, tt.getDeviceWatchListManager(), tt.hostname,
@@ -578,3 +666,40 @@ func setupDCGMMockForDCGMExpMetrics(fields []dcgm.Short) func(mockDCGM *mockdcgm
 		).Return(nil).AnyTimes()
 	}
 }
+
+// newMockGPUDeviceInfo returns the device info of the GPUs, as the exporter collectors see it.
+func newMockGPUDeviceInfo(ctrl *gomock.Controller, gpus []deviceinfo.GPUInfo) *mockdeviceinfo.MockProvider {
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+	mockDeviceInfo.EXPECT().GPUCount().Return(uint(len(gpus))).AnyTimes()
+	mockDeviceInfo.EXPECT().GPU(gomock.Any()).DoAndReturn(func(i uint) deviceinfo.GPUInfo {
+		return gpus[i]
+	}).AnyTimes()
+	return mockDeviceInfo
+}
+
+func Test_expCollectors(t *testing.T) {
+	// Every collector is enabled either by its counter or by a flag of the configuration
+	enabledConfig := &appconfig.Config{
+		CollectEncoderDecoder: true,
+		CollectProcessTypes:   true,
+		CollectDriverState:    true,
+	}
+
+	names := map[string]struct{}{}
+	for _, ec := range expCollectors {
+		t.Run(ec.name, func(t *testing.T) {
+			_, duplicate := names[ec.name]
+			assert.False(t, duplicate, "the collector is listed twice")
+			names[ec.name] = struct{}{}
+
+			assert.False(t, ec.enabled(nil, &appconfig.Config{}), "the collector is enabled by default")
+			assert.True(t, ec.enabled(counters.CounterList{{FieldName: ec.name}}, enabledConfig))
+
+			c, err := ec.constructor(nil, "", &appconfig.Config{}, devicewatchlistmanager.WatchList{})
+			assert.Nil(t, c)
+			assert.ErrorContains(t, err, "collector is disabled")
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	configStateCurrentModeLabel = "current_mode"
+	configStatePendingModeLabel = "pending_mode"
+
+	configStateModeEnabled  = "enabled"
+	configStateModeDisabled = "disabled"
+)
+
+// configStateCounters are the exporter counters computed by the configStateCollector
+var configStateCounters = []string{
+	counters.DCGMExpPendingECCModeChange,
+	counters.DCGMExpPendingMIGModeChange,
+}
+
+// configStateCollector reports whether a change of the ECC or the MIG mode of a GPU is pending, i.e. whether the
+// GPU must be reset or the node must be rebooted to apply it, so that fleet automation can schedule reboots. DCGM
+// has no fields for the pending modes, so they are read from NVML. Modes are reported per physical GPU, also when
+// MIG is enabled, and GPUs, which don't support a mode, are omitted.
+type configStateCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+}
+
+// modeChange is the current and the pending state of a mode
+type modeChange struct {
+	current bool
+	pending bool
+}
+
+func (c *configStateCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	if nvmlprovider.Client() == nil {
+		return metrics, nil
+	}
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		modes, err := nvmlprovider.Client().GetPendingModeChanges(mi.DeviceInfo.UUID)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Unable to read pending mode changes for GPU %d", mi.DeviceInfo.GPU),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for name, change := range modeChanges(modes) {
+			counter, exists := c.enabled[name]
+			if !exists {
+				continue
+			}
+
+			metricLabels := maps.Clone(labels)
+			metricLabels[configStateCurrentModeLabel] = modeState(change.current)
+			metricLabels[configStatePendingModeLabel] = modeState(change.pending)
+
+			m := c.createMetric(metricLabels, gpuInfo, uuid, boolToInt(change.current != change.pending))
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// modeChanges maps the modes, which the GPU supports, to their counter.
+func modeChanges(modes *nvmlprovider.PendingModeChanges) map[string]modeChange {
+	changes := map[string]modeChange{}
+	if modes.ECCModeSupported {
+		changes[counters.DCGMExpPendingECCModeChange] = modeChange{
+			current: modes.ECCModeCurrent,
+			pending: modes.ECCModePending,
+		}
+	}
+	if modes.MIGModeSupported {
+		changes[counters.DCGMExpPendingMIGModeChange] = modeChange{
+			current: modes.MIGModeCurrent,
+			pending: modes.MIGModePending,
+		}
+	}
+	return changes
+}
+
+func modeState(enabled bool) string {
+	if enabled {
+		return configStateModeEnabled
+	}
+	return configStateModeDisabled
+}
+
+func NewConfigStateCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpConfigStateEnabled(counterList) {
+		slog.Error(counters.DCGMExpPendingECCModeChange+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpPendingECCModeChange))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpPendingECCModeChange)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(configStateCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	return &configStateCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(configStateCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+		},
+		enabled: enabled,
+	}, nil
+}
+
+func IsDCGMExpConfigStateEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(configStateCounters, c.FieldName)
+	})
+}
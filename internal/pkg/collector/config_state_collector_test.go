@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestConfigStateCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+		{DeviceInfo: dcgm.Device{GPU: 2, UUID: "GPU-00000000-0000-0000-0000-000000000002"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetPendingModeChanges(gpus[0].DeviceInfo.UUID).Return(&nvmlprovider.PendingModeChanges{
+		ECCModeSupported: true,
+		ECCModeCurrent:   true,
+		ECCModePending:   false,
+		MIGModeSupported: true,
+		MIGModeCurrent:   true,
+		MIGModePending:   true,
+	}, nil)
+	mockNVML.EXPECT().GetPendingModeChanges(gpus[1].DeviceInfo.UUID).Return(&nvmlprovider.PendingModeChanges{
+		ECCModeSupported: true,
+		ECCModeCurrent:   true,
+		ECCModePending:   true,
+	}, nil)
+	mockNVML.EXPECT().GetPendingModeChanges(gpus[2].DeviceInfo.UUID).Return(nil, errors.New("GPU is lost"))
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	ecc := counters.Counter{FieldName: counters.DCGMExpPendingECCModeChange, PromType: "gauge"}
+	mig := counters.Counter{FieldName: counters.DCGMExpPendingMIGModeChange, PromType: "gauge"}
+
+	c, err := NewConfigStateCollector(counters.CounterList{ecc, mig}, "testhost", &appconfig.Config{},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[ecc], 2)
+	assert.Equal(t, "0", metrics[ecc][0].GPU)
+	assert.Equal(t, "1", metrics[ecc][0].Value)
+	assert.Equal(t, "enabled", metrics[ecc][0].Labels[configStateCurrentModeLabel])
+	assert.Equal(t, "disabled", metrics[ecc][0].Labels[configStatePendingModeLabel])
+	assert.Equal(t, "1", metrics[ecc][1].GPU)
+	assert.Equal(t, "0", metrics[ecc][1].Value)
+
+	require.Len(t, metrics[mig], 1, "GPUs, which don't support MIG, are omitted")
+	assert.Equal(t, "0", metrics[mig][0].GPU)
+	assert.Equal(t, "0", metrics[mig][0].Value)
+}
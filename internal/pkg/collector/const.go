@@ -19,6 +19,10 @@ package collector
 const (
 	windowSizeInMSLabel = "window_size_in_ms"
 
+	// switchIDLabel and portLabel identify the NVSwitch and its port of the NVSwitch and NVLink series
+	switchIDLabel = "switch_id"
+	portLabel     = "port"
+
 	skipDCGMValue   = "SKIPPING DCGM VALUE"
 	FailedToConvert = "ERROR - FAILED TO CONVERT TO STRING"
 )
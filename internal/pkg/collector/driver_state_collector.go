@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/driverstate"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	kernelModuleVersionLabel = "kernel_module_version"
+	kernelModuleTypeLabel    = "kernel_module_type"
+	gccVersionLabel          = "gcc_version"
+	kernelReleaseLabel       = "kernel_release"
+	moduleLabel              = "module"
+)
+
+var (
+	driverInfoCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMDriverInfo),
+		FieldName: counters.DCGMExpDriverInfo,
+		PromType:  "gauge",
+		Help:      "Versions of the loaded kernel module of the driver and of the kernel, as labels.",
+	}
+
+	driverModuleLoadedCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMDriverModuleLoaded),
+		FieldName: counters.DCGMExpDriverModuleLoaded,
+		PromType:  "gauge",
+		Help:      "Whether the kernel module of the driver is loaded (1) or not (0).",
+	}
+
+	driverPersistenceModeCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMDriverPersistenceMode),
+		FieldName: counters.DCGMExpDriverPersistenceMode,
+		PromType:  "gauge",
+		Help:      "Whether the persistence mode is enabled (1) or not (0).",
+	}
+
+	driverOpenHandlesCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMDriverOpenHandles),
+		FieldName: counters.DCGMExpDriverOpenHandles,
+		PromType:  "gauge",
+		Help:      "Number of open file descriptors of the device node of the GPU.",
+	}
+)
+
+// driverStateCollector exports the state of the driver, which DCGM doesn't report, from procfs, sysfs and NVML: the
+// versions of the kernel module and of the kernel, whether the kernel modules are loaded, the persistence mode and
+// the open handles of the device nodes. The values of the host are repeated for every GPU, like the version info.
+type driverStateCollector struct {
+	baseExpCollector
+}
+
+func (c *driverStateCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	versions := map[string]string{}
+	version, err := driverstate.ReadVersion()
+	if err != nil {
+		slog.Debug("Unable to read the version of the kernel module", slog.String(logging.ErrorKey, err.Error()))
+		versions = nil
+	} else {
+		versions[kernelModuleVersionLabel] = version.KernelModule
+		versions[kernelModuleTypeLabel] = version.ModuleType
+		versions[gccVersionLabel] = version.GCC
+		// The kernel release is left empty, when it can't be read
+		versions[kernelReleaseLabel], _ = driverstate.ReadKernelRelease()
+	}
+
+	loaded := map[string]int{}
+	for _, module := range driverstate.Modules {
+		loaded[module] = boolToInt(driverstate.ModuleLoaded(module))
+	}
+
+	minors, err := driverstate.ReadDeviceMinors()
+	if err != nil {
+		slog.Debug("Unable to read the minor numbers of the GPUs", slog.String(logging.ErrorKey, err.Error()))
+	}
+	handles, err := driverstate.CountOpenHandles()
+	if err != nil {
+		slog.Debug("Unable to count the open handles of the GPUs", slog.String(logging.ErrorKey, err.Error()))
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if versions != nil {
+			infoLabels := maps.Clone(labels)
+			maps.Copy(infoLabels, versions)
+			c.appendMetric(metrics, driverInfoCounter, c.createMetric(infoLabels, gpuInfo, uuid, 1))
+		}
+
+		for _, module := range driverstate.Modules {
+			moduleLabels := maps.Clone(labels)
+			moduleLabels[moduleLabel] = module
+			c.appendMetric(metrics, driverModuleLoadedCounter,
+				c.createMetric(moduleLabels, gpuInfo, uuid, loaded[module]))
+		}
+
+		values, err := nvmlprovider.Client().GetDeviceValues(mi.DeviceInfo.UUID,
+			[]nvmlprovider.DeviceField{nvmlprovider.PersistenceMode})
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Unable to read the persistence mode of GPU %d", mi.DeviceInfo.GPU),
+				slog.String(logging.ErrorKey, err.Error()))
+		} else if mode, exists := values[nvmlprovider.PersistenceMode]; exists {
+			c.appendMetric(metrics, driverPersistenceModeCounter, c.createMetric(labels, gpuInfo, uuid, int(mode)))
+		}
+
+		if minor, found := minors.Minor(mi.DeviceInfo.PCI.BusID); found && handles != nil {
+			c.appendMetric(metrics, driverOpenHandlesCounter,
+				c.createMetric(labels, gpuInfo, uuid, handles[minor]))
+		}
+	}
+
+	return metrics, nil
+}
+
+func (c *driverStateCollector) appendMetric(metrics MetricsByCounter, counter counters.Counter, m Metric) {
+	counter = counter.WithPrefix(c.config.MetricPrefix)
+	m.Counter = counter
+	metrics[counter] = append(metrics[counter], m)
+}
+
+func NewDriverStateCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !config.CollectDriverState {
+		slog.Error(counters.DCGMExpDriverInfo+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpDriverInfo))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpDriverInfo)
+	}
+
+	if nvmlprovider.Client() == nil {
+		return nil, fmt.Errorf("NVML provider is not initialized")
+	}
+
+	return &driverStateCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter:         driverInfoCounter,
+			labelsCounters:  counterList.LabelCounters(),
+			hostname:        hostname,
+			config:          config,
+		},
+	}, nil
+}
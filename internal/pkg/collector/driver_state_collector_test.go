@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	sysOS "os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/driverstate"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestDriverStateCollector_GetMetrics(t *testing.T) {
+	proc := t.TempDir()
+	modules := t.TempDir()
+	defer func(procPath, modulesPath string) {
+		driverstate.ProcPath = procPath
+		driverstate.ModulesPath = modulesPath
+	}(driverstate.ProcPath, driverstate.ModulesPath)
+	driverstate.ProcPath = proc
+	driverstate.ModulesPath = modules
+
+	files := map[string]string{
+		filepath.Join(proc, "driver/nvidia/version"): "NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.15  " +
+			"Tue Mar  5 22:23:56 UTC 2024\nGCC version:  gcc version 12.3.0 (Ubuntu 12.3.0-1ubuntu1~22.04)\n",
+		filepath.Join(proc, "sys/kernel/osrelease"):                        "6.1.58+\n",
+		filepath.Join(proc, "driver/nvidia/gpus/0000:3b:00.0/information"): "Device Minor: \t 0\n",
+		filepath.Join(modules, "nvidia/initstate"):                         "live\n",
+		filepath.Join(modules, "nvidia_uvm/initstate"):                     "live\n",
+	}
+	for path, content := range files {
+		require.NoError(t, sysOS.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, sysOS.WriteFile(path, []byte(content), 0o600))
+	}
+	require.NoError(t, sysOS.MkdirAll(filepath.Join(proc, "100/fd"), 0o755))
+	require.NoError(t, sysOS.Symlink("/dev/nvidia0", filepath.Join(proc, "100/fd/3")))
+	require.NoError(t, sysOS.Symlink("/dev/nvidia0", filepath.Join(proc, "100/fd/4")))
+
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{
+			DeviceInfo: dcgm.Device{
+				GPU:  0,
+				UUID: "GPU-00000000-0000-0000-0000-000000000000",
+				PCI:  dcgm.PCIInfo{BusID: "00000000:3B:00.0"},
+			},
+		},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetDeviceValues(gpus[0].DeviceInfo.UUID, []nvmlprovider.DeviceField{nvmlprovider.PersistenceMode}).
+		Return(map[nvmlprovider.DeviceField]float64{nvmlprovider.PersistenceMode: 1}, nil)
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	config := &appconfig.Config{CollectDriverState: true}
+	c, err := NewDriverStateCollector(nil, "testhost", config,
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	require.Len(t, metrics, 4)
+
+	info := metrics[driverInfoCounter]
+	require.Len(t, info, 1)
+	assert.Equal(t, "1", info[0].Value)
+	assert.Equal(t, map[string]string{
+		kernelModuleVersionLabel: "550.54.15",
+		kernelModuleTypeLabel:    driverstate.ModuleTypeProprietary,
+		gccVersionLabel:          "12.3.0",
+		kernelReleaseLabel:       "6.1.58+",
+	}, info[0].Labels)
+
+	loaded := map[string]string{}
+	for _, m := range metrics[driverModuleLoadedCounter] {
+		loaded[m.Labels[moduleLabel]] = m.Value
+	}
+	assert.Equal(t, map[string]string{"nvidia": "1", "nvidia_uvm": "1", "nvidia_modeset": "0", "nvidia_drm": "0"},
+		loaded)
+
+	require.Len(t, metrics[driverPersistenceModeCounter], 1)
+	assert.Equal(t, "1", metrics[driverPersistenceModeCounter][0].Value)
+
+	require.Len(t, metrics[driverOpenHandlesCounter], 1)
+	assert.Equal(t, "2", metrics[driverOpenHandlesCounter][0].Value)
+	assert.Equal(t, gpus[0].DeviceInfo.UUID, metrics[driverOpenHandlesCounter][0].GPUUUID)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+var (
+	encoderSessionsCountCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMEncoderSessionsCount),
+		FieldName: counters.DCGMExpEncoderSessionsCount,
+		PromType:  "gauge",
+		Help:      "Number of active video encoder sessions.",
+	}
+
+	encoderUtilCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMEncoderUtil),
+		FieldName: counters.DCGMExpEncoderUtil,
+		PromType:  "gauge",
+		Help:      "Video encoder utilization reported by NVML (in %).",
+	}
+
+	decoderUtilCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMDecoderUtil),
+		FieldName: counters.DCGMExpDecoderUtil,
+		PromType:  "gauge",
+		Help:      "Video decoder utilization reported by NVML (in %).",
+	}
+)
+
+// encoderDecoderCollector reads video encoder sessions and encoder/decoder utilization through NVML.
+// The values are only available for physical GPUs, so GPU instances are reported as their parent GPU.
+type encoderDecoderCollector struct {
+	baseExpCollector
+}
+
+func (c *encoderDecoderCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		stats, err := nvmlprovider.Client().GetEncoderDecoderStats(mi.DeviceInfo.UUID)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Unable to read encoder and decoder stats for GPU %d", mi.DeviceInfo.GPU),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		values := map[counters.Counter]int{
+			encoderSessionsCountCounter: stats.EncoderSessionCount,
+			encoderUtilCounter:          int(stats.EncoderUtilization),
+			decoderUtilCounter:          int(stats.DecoderUtilization),
+		}
+
+		for counter, val := range values {
+			counter = counter.WithPrefix(c.config.MetricPrefix)
+			m := c.createMetric(labels, gpuInfo, uuid, val)
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+func NewEncoderDecoderCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !config.CollectEncoderDecoder {
+		slog.Error(counters.DCGMExpEncoderSessionsCount+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpEncoderSessionsCount))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpEncoderSessionsCount)
+	}
+
+	if nvmlprovider.Client() == nil {
+		return nil, fmt.Errorf("NVML provider is not initialized")
+	}
+
+	return &encoderDecoderCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter:         encoderSessionsCountCounter,
+			labelsCounters:  counterList.LabelCounters(),
+			hostname:        hostname,
+			config:          config,
+		},
+	}, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestEncoderDecoderCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{
+			DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"},
+		},
+		{
+			DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"},
+			GPUInstances: []deviceinfo.GPUInstanceInfo{
+				{EntityId: 1, ProfileName: "1g.10gb"},
+				{EntityId: 2, ProfileName: "1g.10gb"},
+			},
+			MigEnabled: true,
+		},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetEncoderDecoderStats(gpus[0].DeviceInfo.UUID).Return(&nvmlprovider.EncoderDecoderStats{
+		EncoderSessionCount: 3,
+		EncoderUtilization:  40,
+		DecoderUtilization:  10,
+	}, nil)
+	mockNVML.EXPECT().GetEncoderDecoderStats(gpus[1].DeviceInfo.UUID).Return(nil, errors.New("Not Supported"))
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	config := &appconfig.Config{CollectEncoderDecoder: true}
+	c, err := NewEncoderDecoderCollector(nil, "testhost", config,
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+
+	expected := map[string]string{
+		encoderSessionsCountCounter.FieldName: "3",
+		encoderUtilCounter.FieldName:          "40",
+		decoderUtilCounter.FieldName:          "10",
+	}
+
+	for counter, values := range metrics {
+		require.Len(t, values, 1)
+		assert.Equal(t, expected[counter.FieldName], values[0].Value)
+		assert.Equal(t, "0", values[0].GPU)
+		assert.Equal(t, gpus[0].DeviceInfo.UUID, values[0].GPUUUID)
+		assert.Equal(t, "testhost", values[0].Hostname)
+		assert.NotNil(t, values[0].Attributes)
+	}
+}
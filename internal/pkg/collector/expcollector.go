@@ -27,6 +27,7 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 )
 
 type expCollector struct {
@@ -44,7 +45,8 @@ func (c *expCollector) getMetrics() (MetricsByCounter, error) {
 
 	mapEntityIDToValues := map[uint]map[int64]int{}
 
-	window := time.Now().Add(-time.Duration(c.windowSize) * time.Millisecond)
+	// The window is compared to the sample timestamps, so it is computed on the hostengine clock
+	window := hostengineClock.now().Add(-time.Duration(c.windowSize) * time.Millisecond)
 
 	for _, group := range c.deviceWatchList.DeviceGroups() {
 		values, _, err := dcgmprovider.Client().GetValuesSince(group, c.deviceWatchList.DeviceFieldGroup(), window)
@@ -130,7 +132,7 @@ func newExpCollector(
 
 	collector.cleanups, err = collector.deviceWatchList.Watch()
 	if err != nil {
-		slog.Warn(fmt.Sprintf("Failed to watch metrics: %s", err))
+		slog.Warn("Failed to watch metrics", slog.String(logging.ErrorKey, err.Error()))
 		return expCollector{}, err
 	}
 
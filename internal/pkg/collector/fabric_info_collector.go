@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	fabricClusterUUIDLabel = "fabric_cluster_uuid"
+	fabricCliqueIDLabel    = "fabric_clique_id"
+)
+
+// fabricInfoFields are the DCGM fields, which identify the NVLink fabric partition of a GPU
+var fabricInfoFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_FABRIC_CLUSTER_UUID,
+	dcgm.DCGM_FI_DEV_FABRIC_CLIQUE_ID,
+}
+
+// fabricInfoCollector exports an info metric per GPU, which is attached to a multi-node NVLink fabric, with the
+// UUID of the fabric cluster and the ID of the clique (fabric partition) of the GPU. GPUs in the same clique share
+// the same NVSwitch failure domain, so the metric can be joined with other metrics to correlate anomalies of
+// cross-node jobs with fabric partitions. GPUs, which are not attached to a fabric, are omitted.
+type fabricInfoCollector struct {
+	baseExpCollector
+}
+
+func (c *fabricInfoCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, mi.DeviceInfo.GPU,
+			fabricInfoFields)
+		if err != nil {
+			return nil, err
+		}
+
+		clusterUUID, cliqueID, attached := fabricPartition(latestValues)
+		if !attached {
+			continue
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		labels[fabricClusterUUIDLabel] = clusterUUID
+		labels[fabricCliqueIDLabel] = cliqueID
+
+		metrics[c.counter] = append(metrics[c.counter], c.createMetric(labels, gpuInfo, uuid, 1))
+	}
+
+	return metrics, nil
+}
+
+// fabricPartition returns the fabric cluster UUID and the clique ID from the latest values of the fabric fields.
+// A GPU is attached to a fabric, when DCGM reports both of them.
+func fabricPartition(values []dcgm.FieldValue_v1) (clusterUUID, cliqueID string, attached bool) {
+	for _, val := range values {
+		v := toString(val)
+		if v == skipDCGMValue || v == FailedToConvert {
+			continue
+		}
+
+		switch dcgm.Short(val.FieldId) {
+		case dcgm.DCGM_FI_DEV_FABRIC_CLUSTER_UUID:
+			clusterUUID = v
+		case dcgm.DCGM_FI_DEV_FABRIC_CLIQUE_ID:
+			cliqueID = v
+		}
+	}
+	return clusterUUID, cliqueID, clusterUUID != "" && cliqueID != ""
+}
+
+func NewFabricInfoCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpFabricInfoEnabled(counterList) {
+		slog.Error(counters.DCGMExpFabricInfo+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpFabricInfo))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpFabricInfo)
+	}
+
+	deviceWatchList.SetDeviceFields(fabricInfoFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fabricInfoCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpFabricInfo
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+	}, nil
+}
+
+func IsDCGMExpFabricInfoEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpFabricInfo
+	})
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func stringFieldValue(fieldID dcgm.Short, value string) dcgm.FieldValue_v1 {
+	fieldValue := [4096]byte{}
+	copy(fieldValue[:], value)
+	return dcgm.FieldValue_v1{
+		FieldId:   uint(fieldID),
+		FieldType: dcgm.DCGM_FT_STRING,
+		Value:     fieldValue,
+	}
+}
+
+func TestFabricInfoCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(fabricInfoFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// GPU 0 is attached to a fabric, GPU 1 doesn't support the fabric fields
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fabricInfoFields).
+		Return([]dcgm.FieldValue_v1{
+			stringFieldValue(dcgm.DCGM_FI_DEV_FABRIC_CLUSTER_UUID, "9e3f1c2a-0000-0000-0000-000000000001"),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FABRIC_CLIQUE_ID, 7),
+		}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), fabricInfoFields).
+		Return([]dcgm.FieldValue_v1{
+			stringFieldValue(dcgm.DCGM_FI_DEV_FABRIC_CLUSTER_UUID, dcgm.DCGM_FT_STR_NOT_SUPPORTED),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FABRIC_CLIQUE_ID, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	fabricInfo := counters.Counter{FieldName: counters.DCGMExpFabricInfo, PromType: "gauge"}
+
+	c, err := NewFabricInfoCollector(counters.CounterList{fabricInfo}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[fabricInfo], 1)
+	assert.Equal(t, "0", metrics[fabricInfo][0].GPU)
+	assert.Equal(t, "1", metrics[fabricInfo][0].Value)
+	assert.Equal(t, "9e3f1c2a-0000-0000-0000-000000000001", metrics[fabricInfo][0].Labels[fabricClusterUUIDLabel])
+	assert.Equal(t, "7", metrics[fabricInfo][0].Labels[fabricCliqueIDLabel])
+}
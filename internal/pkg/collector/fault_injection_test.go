@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+// newFaultyDCGMCollector returns the collector of the temperature of a GPU, which is read from a DCGM with the
+// injected faults.
+func newFaultyDCGMCollector(t *testing.T, faults dcgmprovider.Faults) *DCGMCollector {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+	mockDeviceInfo.EXPECT().GPUCount().Return(uint(1)).AnyTimes()
+	mockDeviceInfo.EXPECT().GPU(uint(0)).Return(deviceinfo.GPUInfo{DeviceInfo: dcgm.Device{GPU: 0}}).AnyTimes()
+
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_TEMP}
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(fields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fields).
+		DoAndReturn(func(dcgm.Field_Entity_Group, uint, []dcgm.Short) ([]dcgm.FieldValue_v1, error) {
+			return []dcgm.FieldValue_v1{int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, 65)}, nil
+		}).AnyTimes()
+
+	realDCGM := dcgmprovider.Client()
+	t.Cleanup(func() { dcgmprovider.SetClient(realDCGM) })
+	dcgmprovider.SetClient(dcgmprovider.WithFaults(mockDCGM, faults))
+
+	c, err := NewDCGMCollector([]counters.Counter{{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_TEMP,
+		FieldName: "DCGM_FI_DEV_GPU_TEMP",
+		PromType:  "gauge",
+	}}, "testhost", &appconfig.Config{},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, fields, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+	return c
+}
+
+func TestDCGMCollector_InjectedFaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		faults  dcgmprovider.Faults
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "No faults",
+			want: []string{"65"},
+		},
+		{
+			name:    "Failing calls",
+			faults:  dcgmprovider.Faults{ErrorRate: 1},
+			wantErr: true,
+		},
+		{
+			name:   "Blank values are skipped",
+			faults: dcgmprovider.Faults{BlankRate: 1},
+			want:   nil,
+		},
+		{
+			name:   "NaN values",
+			faults: dcgmprovider.Faults{NaNRate: 1},
+			want:   []string{"NaN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFaultyDCGMCollector(t, tt.faults)
+
+			metrics, err := c.GetMetrics()
+			if tt.wantErr {
+				require.ErrorIs(t, err, dcgmprovider.ErrInjectedFault)
+				select {
+				case <-dcgmprovider.ConnectionLost():
+					t.Fatal("an injected fault was reported as a lost connection")
+				default:
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for _, values := range metrics {
+				for _, m := range values {
+					got = append(got, m.Value)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDCGMCollector_RandomInjectedFaults(t *testing.T) {
+	c := newFaultyDCGMCollector(t, dcgmprovider.Faults{ErrorRate: 0.2, BlankRate: 0.2, NaNRate: 0.2})
+
+	// Every collection either fails, or returns the valid, the blank or the NaN value
+	for i := 0; i < 500; i++ {
+		metrics, err := c.GetMetrics()
+		if err != nil {
+			require.ErrorIs(t, err, dcgmprovider.ErrInjectedFault)
+			continue
+		}
+		for _, values := range metrics {
+			for _, m := range values {
+				assert.Contains(t, []string{"65", "NaN"}, m.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	fbMemoryStateLabel = "state"
+
+	fbMemoryStateTotal    = "total"
+	fbMemoryStateReserved = "reserved"
+	fbMemoryStateUsed     = "used"
+	fbMemoryStateFree     = "free"
+)
+
+// fbMemoryCounters are the exporter counters computed by the fbMemoryCollector
+var fbMemoryCounters = []string{
+	counters.DCGMExpFBMemory,
+	counters.DCGMExpFBUsedPercent,
+}
+
+// fbMemoryFields are the DCGM fields, which the framebuffer memory breakdown is read from
+var fbMemoryFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_FB_TOTAL,
+	dcgm.DCGM_FI_DEV_FB_RESERVED,
+	dcgm.DCGM_FI_DEV_FB_USED,
+	dcgm.DCGM_FI_DEV_FB_FREE,
+}
+
+// fbMemoryStates maps the framebuffer memory fields to the value of the state label
+var fbMemoryStates = map[dcgm.Short]string{
+	dcgm.DCGM_FI_DEV_FB_TOTAL:    fbMemoryStateTotal,
+	dcgm.DCGM_FI_DEV_FB_RESERVED: fbMemoryStateReserved,
+	dcgm.DCGM_FI_DEV_FB_USED:     fbMemoryStateUsed,
+	dcgm.DCGM_FI_DEV_FB_FREE:     fbMemoryStateFree,
+}
+
+// fbMemoryCollector reports the framebuffer memory of every monitored entity, i.e. of the GPUs and, when MIG is
+// enabled, of the GPU instances, broken down into total, reserved, used and free memory (in MiB), and the used
+// memory as a percentage of the memory available to applications, which excludes the reserved memory.
+// Whether the reserved memory is reported depends on the GPU model and the driver, so it is probed per GPU model,
+// and omitted for the models, which don't report it.
+type fbMemoryCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+	// reservedSupported tells per GPU model whether the reserved memory is reported
+	reservedSupported sync.Map
+}
+
+func (c *fbMemoryCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	for _, mi := range monitoringInfo {
+		latestValues, err := dcgmprovider.Client().EntityGetLatestValues(mi.Entity.EntityGroupId, mi.Entity.EntityId,
+			fbMemoryFields)
+		if err != nil {
+			return nil, err
+		}
+
+		values := fbMemoryValues(latestValues)
+		if len(values) == 0 {
+			continue
+		}
+
+		if !c.probeReserved(mi, values) {
+			delete(values, fbMemoryStateReserved)
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(mi, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if counter, exists := c.enabled[counters.DCGMExpFBMemory]; exists {
+			for state, value := range values {
+				metricLabels := maps.Clone(labels)
+				metricLabels[fbMemoryStateLabel] = state
+
+				m := c.createMetric(metricLabels, mi, uuid, int(value))
+				m.Counter = counter
+				metrics[counter] = append(metrics[counter], m)
+			}
+		}
+
+		if counter, exists := c.enabled[counters.DCGMExpFBUsedPercent]; exists {
+			percent, ok := fbUsedPercent(values)
+			if !ok {
+				continue
+			}
+
+			m := c.createMetric(labels, mi, uuid, 0)
+			m.Counter = counter
+			m.Value = strconv.FormatFloat(percent, 'f', 2, 64)
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// probeReserved reports whether the GPU model of the entity reports the reserved memory. The first values
+// including the total memory decide for a model, and models, which don't report it, are logged once.
+func (c *fbMemoryCollector) probeReserved(mi devicemonitoring.Info, values map[string]int64) bool {
+	model := mi.DeviceInfo.Identifiers.Model
+	_, reported := values[fbMemoryStateReserved]
+	if _, hasTotal := values[fbMemoryStateTotal]; !hasTotal {
+		// The fields are not updated yet
+		return reported
+	}
+
+	supported, loaded := c.reservedSupported.LoadOrStore(model, reported)
+	if !loaded && !reported {
+		slog.Info(fmt.Sprintf("GPU model '%s' doesn't report reserved framebuffer memory; "+
+			"it is omitted from %s", model, counters.DCGMExpFBMemory))
+	}
+
+	return supported.(bool)
+}
+
+// fbMemoryValues maps the framebuffer memory values to their state. Fields without value are omitted.
+func fbMemoryValues(latestValues []dcgm.FieldValue_v1) map[string]int64 {
+	values := map[string]int64{}
+	for _, val := range latestValues {
+		state, exists := fbMemoryStates[dcgm.Short(val.FieldId)]
+		if !exists || val.FieldType != dcgm.DCGM_FT_INT64 || toString(val) == skipDCGMValue {
+			continue
+		}
+		values[state] = val.Int64()
+	}
+
+	return values
+}
+
+// fbUsedPercent returns the used memory as a percentage of the used and free memory, which is the memory
+// available to applications. ok is false, when either is missing or no memory is available.
+func fbUsedPercent(values map[string]int64) (percent float64, ok bool) {
+	used, hasUsed := values[fbMemoryStateUsed]
+	free, hasFree := values[fbMemoryStateFree]
+	if !hasUsed || !hasFree || used+free <= 0 {
+		return 0, false
+	}
+
+	return float64(used) / float64(used+free) * 100, true
+}
+
+func NewFBMemoryCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpFBMemoryEnabled(counterList) {
+		slog.Error(counters.DCGMExpFBMemory+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpFBMemory))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpFBMemory)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(fbMemoryCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields(fbMemoryFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fbMemoryCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(fbMemoryCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled: enabled,
+	}, nil
+}
+
+func IsDCGMExpFBMemoryEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(fbMemoryCounters, c.FieldName)
+	})
+}
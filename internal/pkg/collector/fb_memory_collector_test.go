@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func Test_fbUsedPercent(t *testing.T) {
+	percent, ok := fbUsedPercent(map[string]int64{fbMemoryStateUsed: 1024, fbMemoryStateFree: 3072})
+	assert.True(t, ok)
+	assert.Equal(t, float64(25), percent)
+
+	_, ok = fbUsedPercent(map[string]int64{fbMemoryStateUsed: 1024})
+	assert.False(t, ok)
+
+	_, ok = fbUsedPercent(map[string]int64{fbMemoryStateUsed: 0, fbMemoryStateFree: 0})
+	assert.False(t, ok)
+}
+
+func TestFBMemoryCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, Identifiers: dcgm.DeviceIdentifiers{Model: "NVIDIA H100 80GB HBM3"}}},
+		{DeviceInfo: dcgm.Device{GPU: 1, Identifiers: dcgm.DeviceIdentifiers{Model: "Tesla T4"}}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(fbMemoryFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fbMemoryFields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_TOTAL, 81559),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_RESERVED, 559),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_USED, 20250),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_FREE, 60750),
+		}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), fbMemoryFields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_TOTAL, 15360),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_RESERVED, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_USED, 0),
+			int64FieldValue(dcgm.DCGM_FI_DEV_FB_FREE, 15360),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	memory := counters.Counter{FieldName: counters.DCGMExpFBMemory, PromType: "gauge"}
+	usedPercent := counters.Counter{FieldName: counters.DCGMExpFBUsedPercent, PromType: "gauge"}
+
+	c, err := NewFBMemoryCollector(counters.CounterList{memory, usedPercent}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	for _, m := range metrics[memory] {
+		values[m.GPU+"/"+m.Labels[fbMemoryStateLabel]] = m.Value
+	}
+	assert.Equal(t, map[string]string{
+		"0/total":    "81559",
+		"0/reserved": "559",
+		"0/used":     "20250",
+		"0/free":     "60750",
+		"1/total":    "15360",
+		"1/used":     "0",
+		"1/free":     "15360",
+	}, values)
+
+	require.Len(t, metrics[usedPercent], 2)
+	assert.Equal(t, "25.00", metrics[usedPercent][0].Value)
+	assert.Equal(t, "0.00", metrics[usedPercent][1].Value)
+}
@@ -22,6 +22,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 )
 
 const unknownErr = "Unknown Error"
@@ -42,6 +44,9 @@ type DCGMCollector struct {
 	deviceWatchList          devicewatchlistmanager.WatchList
 	hostname                 string
 	replaceBlanksInModelName bool
+	trackClockSkew           bool
+	nvmlFallback             bool
+	config                   *appconfig.Config
 }
 
 func NewDCGMCollector(
@@ -58,6 +63,7 @@ func NewDCGMCollector(
 		counters:        c,
 		deviceWatchList: deviceWatchList,
 		hostname:        hostname,
+		config:          config,
 	}
 
 	if config == nil {
@@ -67,8 +73,12 @@ func NewDCGMCollector(
 
 	collector.useOldNamespace = config.UseOldNamespace
 	collector.replaceBlanksInModelName = config.ReplaceBlanksInModelName
+	// A local hostengine shares the clock with the exporter
+	collector.trackClockSkew = config.UseRemoteHE
+	// NVML only reads the GPUs of the local node, which a remote hostengine may not monitor
+	collector.nvmlFallback = config.NVMLFallback && !config.UseRemoteHE
 
-	cleanups, err := deviceWatchList.Watch()
+	cleanups, err := collector.deviceWatchList.Watch()
 	if err != nil {
 		return nil, err
 	}
@@ -84,11 +94,17 @@ func (c *DCGMCollector) Cleanup() {
 	}
 }
 
+func (c *DCGMCollector) SetCollectInterval(collectInterval int64) error {
+	return c.deviceWatchList.SetCollectInterval(collectInterval)
+}
+
 func (c *DCGMCollector) GetMetrics() (MetricsByCounter, error) {
 	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
 
 	metrics := make(MetricsByCounter)
 
+	var timestamps []int64
+
 	for _, mi := range monitoringInfo {
 		var vals []dcgm.FieldValue_v1
 		var err error
@@ -101,33 +117,64 @@ func (c *DCGMCollector) GetMetrics() (MetricsByCounter, error) {
 		}
 
 		if err != nil {
-			if derr, ok := err.(*dcgm.DcgmError); ok {
-				if derr.Code == dcgm.DCGM_ST_CONNECTION_NOT_VALID {
-					slog.Error("Could not retrieve metrics: " + err.Error())
+			if dcgmprovider.IsConnectionLost(err) {
+				slog.Error("Could not retrieve metrics",
+					slog.String(logging.FieldEntityGroupKey, mi.Entity.EntityGroupId.String()),
+					slog.Uint64(logging.GPUIDKey, uint64(mi.DeviceInfo.GPU)),
+					slog.String(logging.ErrorKey, err.Error()))
+				if c.config == nil || !c.config.UseRemoteHE {
 					os.Exit(1)
 				}
+				// The connection to the remote hostengine is established again, and the collection is rebuilt
+				dcgmprovider.ReportConnectionLost()
 			}
 			return nil, err
 		}
 
+		if c.trackClockSkew {
+			for _, val := range vals {
+				if val.Status == 0 {
+					timestamps = append(timestamps, val.Ts)
+				}
+			}
+		}
+
 		// InstanceInfo will be nil for GPUs
 		switch c.deviceWatchList.DeviceInfo().InfoType() {
 		case dcgm.FE_SWITCH, dcgm.FE_LINK:
 			toSwitchMetric(metrics, vals, c.counters, mi, c.useOldNamespace, c.hostname)
 		case dcgm.FE_CPU, dcgm.FE_CPU_CORE:
 			toCPUMetric(metrics, vals, c.counters, mi, c.useOldNamespace, c.hostname)
+		case dcgm.FE_VGPU:
+			toVGPUMetric(metrics, vals, c.counters, mi, c.useOldNamespace, c.hostname, c.replaceBlanksInModelName)
 		default:
+			if c.nvmlFallback && mi.InstanceInfo == nil {
+				vals = withNVMLFallback(mi.DeviceInfo.UUID, vals)
+			}
 			toMetric(metrics,
 				vals,
 				c.counters,
 				mi.DeviceInfo,
 				mi.InstanceInfo,
+				mi.ComputeInstance(),
 				c.useOldNamespace,
 				c.hostname,
 				c.replaceBlanksInModelName)
 		}
 	}
 
+	if c.trackClockSkew {
+		hostengineClock.observe(now(), timestamps...)
+	}
+
+	if name, value, ok := hostengineLabel(c.config); ok {
+		for _, values := range metrics {
+			for _, m := range values {
+				m.Labels[name] = value
+			}
+		}
+	}
+
 	return metrics, nil
 }
 
@@ -145,7 +192,7 @@ func toSwitchMetric(
 	metrics MetricsByCounter,
 	values []dcgm.FieldValue_v1, c []counters.Counter, mi devicemonitoring.Info, useOld bool, hostname string,
 ) {
-	labels := map[string]string{}
+	labels := switchLabels(mi)
 
 	for _, val := range values {
 		v := toString(val)
@@ -171,6 +218,7 @@ func toSwitchMetric(
 			m = Metric{
 				Counter:      counter,
 				Value:        v,
+				Timestamp:    sampleTime(val),
 				UUID:         uuid,
 				GPU:          fmt.Sprintf("%d", mi.Entity.EntityId),
 				GPUUUID:      "",
@@ -187,6 +235,22 @@ func toSwitchMetric(
 	}
 }
 
+// switchLabels returns the labels, which identify the NVSwitch of a switch entity, or the NVSwitch and the port of a
+// link entity, with the plain IDs, so the series of a switch and of its ports can be joined on switch_id.
+func switchLabels(mi devicemonitoring.Info) map[string]string {
+	labels := map[string]string{}
+
+	switch mi.Entity.EntityGroupId {
+	case dcgm.FE_SWITCH:
+		labels[switchIDLabel] = strconv.FormatUint(uint64(mi.Entity.EntityId), 10)
+	case dcgm.FE_LINK:
+		labels[switchIDLabel] = strconv.FormatUint(uint64(mi.ParentId), 10)
+		labels[portLabel] = strconv.FormatUint(uint64(mi.Entity.EntityId), 10)
+	}
+
+	return labels
+}
+
 func toCPUMetric(
 	metrics MetricsByCounter,
 	values []dcgm.FieldValue_v1, c []counters.Counter, mi devicemonitoring.Info, useOld bool, hostname string,
@@ -217,6 +281,7 @@ func toCPUMetric(
 			m = Metric{
 				Counter:      counter,
 				Value:        v,
+				Timestamp:    sampleTime(val),
 				UUID:         uuid,
 				GPU:          fmt.Sprintf("%d", mi.Entity.EntityId),
 				GPUUUID:      "",
@@ -233,12 +298,31 @@ func toCPUMetric(
 	}
 }
 
+// toVGPUMetric converts the values of a vGPU instance to metrics of its parent GPU, which are labeled with the
+// ID of the vGPU instance.
+func toVGPUMetric(
+	metrics MetricsByCounter,
+	values []dcgm.FieldValue_v1, c []counters.Counter, mi devicemonitoring.Info, useOld bool, hostname string,
+	replaceBlanksInModelName bool,
+) {
+	vgpuMetrics := make(MetricsByCounter)
+	toMetric(vgpuMetrics, values, c, mi.DeviceInfo, nil, nil, useOld, hostname, replaceBlanksInModelName)
+
+	for counter, values := range vgpuMetrics {
+		for i := range values {
+			values[i].VGPUID = fmt.Sprintf("%d", mi.Entity.EntityId)
+		}
+		metrics[counter] = append(metrics[counter], values...)
+	}
+}
+
 func toMetric(
 	metrics MetricsByCounter,
 	values []dcgm.FieldValue_v1,
 	c []counters.Counter,
 	d dcgm.Device,
 	instanceInfo *deviceinfo.GPUInstanceInfo,
+	computeInstance *deviceinfo.ComputeInstanceInfo,
 	useOld bool,
 	hostname string,
 	replaceBlanksInModelName bool,
@@ -280,8 +364,9 @@ func toMetric(
 		}
 
 		m := Metric{
-			Counter: counter,
-			Value:   v,
+			Counter:   counter,
+			Value:     v,
+			Timestamp: sampleTime(val),
 
 			UUID:         uuid,
 			GPU:          fmt.Sprintf("%d", d.GPU),
@@ -301,6 +386,9 @@ func toMetric(
 			m.MigProfile = ""
 			m.GPUInstanceID = ""
 		}
+		if computeInstance != nil {
+			m.ComputeInstanceID = fmt.Sprintf("%d", computeInstance.InstanceInfo.NvmlComputeInstanceId)
+		}
 
 		metrics[m.Counter] = append(metrics[m.Counter], m)
 	}
@@ -317,6 +405,14 @@ func getGPUModel(d dcgm.Device, replaceBlanksInModelName bool) string {
 	return gpuModel
 }
 
+// sampleTime returns the time of the field value, which DCGM reports in microseconds since the epoch.
+func sampleTime(value dcgm.FieldValue_v1) time.Time {
+	if value.Ts == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(value.Ts)
+}
+
 func toString(value dcgm.FieldValue_v1) string {
 	switch value.FieldType {
 	case dcgm.DCGM_FT_INT64:
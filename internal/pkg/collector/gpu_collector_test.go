@@ -23,9 +23,18 @@ import (
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
 )
 
 func TestToMetric(t *testing.T) {
@@ -79,7 +88,7 @@ func TestToMetric(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("When replaceBlanksInModelName is %t", tc.replaceBlanksInModelName), func(t *testing.T) {
 			metrics := make(map[counters.Counter][]Metric)
-			toMetric(metrics, values, c, d, instanceInfo, false, "", tc.replaceBlanksInModelName)
+			toMetric(metrics, values, c, d, instanceInfo, nil, false, "", tc.replaceBlanksInModelName)
 			assert.Len(t, metrics, 1)
 			// We get metric value with 0 index
 			metricValues := metrics[reflect.ValueOf(metrics).MapKeys()[0].Interface().(counters.Counter)]
@@ -92,6 +101,43 @@ func TestToMetric(t *testing.T) {
 	}
 }
 
+func TestToVGPUMetric(t *testing.T) {
+	fieldValue := [4096]byte{}
+	fieldValue[0] = 1
+	values := []dcgm.FieldValue_v1{
+		{
+			FieldId:   dcgm.DCGM_FI_DEV_VGPU_LICENSE_STATUS,
+			FieldType: dcgm.DCGM_FT_INT64,
+			Value:     fieldValue,
+		},
+	}
+
+	c := []counters.Counter{
+		{
+			FieldID:   dcgm.DCGM_FI_DEV_VGPU_LICENSE_STATUS,
+			FieldName: "DCGM_FI_DEV_VGPU_LICENSE_STATUS",
+			PromType:  "gauge",
+		},
+	}
+
+	mi := devicemonitoring.Info{
+		Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_VGPU, EntityId: 3584},
+		DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-1"},
+		ParentId:   1,
+	}
+
+	metrics := make(MetricsByCounter)
+	toVGPUMetric(metrics, values, c, mi, false, "testhost", false)
+
+	require.Len(t, metrics[c[0]], 1)
+	m := metrics[c[0]][0]
+	assert.Equal(t, "1", m.Value)
+	assert.Equal(t, "3584", m.VGPUID)
+	assert.Equal(t, "1", m.GPU)
+	assert.Equal(t, "GPU-1", m.GPUUUID)
+	assert.Equal(t, "nvidia1", m.GPUDevice)
+}
+
 func TestToMetricWhenDCGM_FI_DEV_XID_ERRORSField(t *testing.T) {
 	c := []counters.Counter{
 		{
@@ -151,7 +197,7 @@ func TestToMetricWhenDCGM_FI_DEV_XID_ERRORSField(t *testing.T) {
 			}
 
 			metrics := make(map[counters.Counter][]Metric)
-			toMetric(metrics, values, c, d, instanceInfo, false, "", false)
+			toMetric(metrics, values, c, d, instanceInfo, nil, false, "", false)
 			assert.Len(t, metrics, 1)
 			// We get metric value with 0 index
 			metricValues := metrics[reflect.ValueOf(metrics).MapKeys()[0].Interface().(counters.Counter)]
@@ -166,3 +212,75 @@ func TestToMetricWhenDCGM_FI_DEV_XID_ERRORSField(t *testing.T) {
 		})
 	}
 }
+
+func TestHostengineLabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *appconfig.Config
+		wantLabels map[string]string
+	}{
+		{
+			name:       "Embedded hostengine",
+			config:     &appconfig.Config{HostengineLabel: "hostengine"},
+			wantLabels: map[string]string{},
+		},
+		{
+			name:       "Remote hostengine without label",
+			config:     &appconfig.Config{UseRemoteHE: true, RemoteHEInfo: "10.0.0.1:5555"},
+			wantLabels: map[string]string{},
+		},
+		{
+			name: "Remote hostengine with label",
+			config: &appconfig.Config{
+				UseRemoteHE: true, RemoteHEInfo: "10.0.0.1:5555", HostengineLabel: "hostengine",
+			},
+			wantLabels: map[string]string{"hostengine": "10.0.0.1:5555"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &baseExpCollector{config: tt.config}
+			m := c.createMetric(map[string]string{}, devicemonitoring.Info{}, "UUID", 1)
+			assert.Equal(t, tt.wantLabels, m.Labels)
+		})
+	}
+}
+
+func TestDCGMCollector_ConnectionLost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+	mockDeviceInfo.EXPECT().GPUCount().Return(uint(1)).AnyTimes()
+	mockDeviceInfo.EXPECT().GPU(uint(0)).Return(deviceinfo.GPUInfo{DeviceInfo: dcgm.Device{GPU: 0}}).AnyTimes()
+
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_TEMP}
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(fields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fields).
+		Return(nil, &dcgm.DcgmError{Code: dcgm.DCGM_ST_CONNECTION_NOT_VALID})
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	c, err := NewDCGMCollector([]counters.Counter{{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP}}, "testhost",
+		&appconfig.Config{UseRemoteHE: true},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, fields, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	_, err = c.GetMetrics()
+	require.Error(t, err)
+	assert.True(t, dcgmprovider.IsConnectionLost(err))
+
+	select {
+	case <-dcgmprovider.ConnectionLost():
+	default:
+		t.Fatal("the lost connection to the remote hostengine was not reported")
+	}
+}
@@ -19,11 +19,12 @@ package collector
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"maps"
 	"slices"
+	"sync"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
-	"github.com/sirupsen/logrus"
 
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
@@ -48,13 +49,39 @@ var gpuHealthChecks = []dcgm.HealthSystem{
 	dcgm.DCGM_HEALTH_WATCH_DRIVER,
 }
 
+// gpuHealthCounters are the exporter counters computed by the gpuHealthStatusCollector
+var gpuHealthCounters = []string{
+	counters.DCGMExpGPUHealthStatus,
+	counters.DCGMExpGPUHealthIncidentsCount,
+}
+
+// gpuHealthIncident identifies an incident reported by a health watch of an entity
+type gpuHealthIncident struct {
+	entity dcgm.GroupEntityPair
+	system dcgm.HealthSystem
+	code   dcgm.HealthCheckErrorCode
+}
+
+// gpuHealthStatusCollector reports the health of the GPUs per health watch. DCGM keeps reporting an incident
+// while its cause persists, so an incident is counted once, when it is reported by a check and was not reported by
+// the previous one.
 type gpuHealthStatusCollector struct {
 	baseExpCollector
+	enabled            map[string]counters.Counter
 	groupID            dcgm.GroupHandle
 	deviceInfoProvider deviceinfo.Provider
+
+	// active are the incidents reported by the previous check
+	active map[gpuHealthIncident]struct{}
+	// incidents is the number of incidents per entity and health watch
+	incidents map[dcgm.GroupEntityPair]map[dcgm.HealthSystem]int
+	mtx       sync.Mutex
 }
 
 func (c *gpuHealthStatusCollector) GetMetrics() (MetricsByCounter, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
 	// Read the GPU health status.
 	gpuHealthStatus, err := dcgmprovider.Client().HealthCheck(c.groupID)
 	if err != nil {
@@ -85,7 +112,9 @@ func (c *gpuHealthStatusCollector) GetMetrics() (MetricsByCounter, error) {
 	}
 
 	metrics := make(MetricsByCounter)
-	metrics[c.counter] = make([]Metric, 0)
+	for _, counter := range c.enabled {
+		metrics[counter] = make([]Metric, 0)
+	}
 
 	useOld := c.config.UseOldNamespace
 	uuid := "UUID"
@@ -109,9 +138,14 @@ func (c *gpuHealthStatusCollector) GetMetrics() (MetricsByCounter, error) {
 
 	// We assyme that each health check may produce only one incident per system
 	for _, incident := range gpuHealthStatus.Incidents {
+		if _, exists := entityHealthSystemToIncident[incident.EntityInfo]; !exists {
+			continue
+		}
 		entityHealthSystemToIncident[incident.EntityInfo][incident.System] = incident
 	}
 
+	c.countIncidents(gpuHealthStatus.Incidents)
+
 	labels := map[string]string{}
 
 	for _, mi := range monitoringInfoInGroup {
@@ -122,18 +156,54 @@ func (c *gpuHealthStatusCollector) GetMetrics() (MetricsByCounter, error) {
 			}
 		}
 		for _, healthSystem := range gpuHealthChecks {
-			incident := entityHealthSystemToIncident[mi.Entity][healthSystem]
-			metricValueLabels := maps.Clone(labels)
-			metricValueLabels["health_watch"] = healthSystemWatchToString(incident.System)
-			metricValueLabels["health_error_code"] = healthCheckErrorToString(incident.Error.Code)
-			m := c.createMetric(metricValueLabels, mi, uuid, int(incident.Health))
-			metrics[c.counter] = append(metrics[c.counter], m)
+			if counter, exists := c.enabled[counters.DCGMExpGPUHealthStatus]; exists {
+				incident := entityHealthSystemToIncident[mi.Entity][healthSystem]
+				metricValueLabels := maps.Clone(labels)
+				metricValueLabels["health_watch"] = healthSystemWatchToString(incident.System)
+				metricValueLabels["health_error_code"] = healthCheckErrorToString(incident.Error.Code)
+				m := c.createMetric(metricValueLabels, mi, uuid, int(incident.Health))
+				m.Counter = counter
+				metrics[counter] = append(metrics[counter], m)
+			}
+
+			if counter, exists := c.enabled[counters.DCGMExpGPUHealthIncidentsCount]; exists {
+				metricValueLabels := maps.Clone(labels)
+				metricValueLabels["health_watch"] = healthSystemWatchToString(healthSystem)
+				m := c.createMetric(metricValueLabels, mi, uuid, c.incidents[mi.Entity][healthSystem])
+				m.Counter = counter
+				metrics[counter] = append(metrics[counter], m)
+			}
 		}
 	}
 
 	return metrics, nil
 }
 
+// countIncidents counts the incidents, which were not reported by the previous check.
+func (c *gpuHealthStatusCollector) countIncidents(incidents []dcgm.Incident) {
+	active := make(map[gpuHealthIncident]struct{}, len(incidents))
+
+	for _, incident := range incidents {
+		if incident.Health == dcgm.DCGM_HEALTH_RESULT_PASS {
+			continue
+		}
+
+		key := gpuHealthIncident{entity: incident.EntityInfo, system: incident.System, code: incident.Error.Code}
+		active[key] = struct{}{}
+
+		if _, reported := c.active[key]; reported {
+			continue
+		}
+
+		if c.incidents[key.entity] == nil {
+			c.incidents[key.entity] = map[dcgm.HealthSystem]int{}
+		}
+		c.incidents[key.entity][key.system]++
+	}
+
+	c.active = active
+}
+
 func (c *gpuHealthStatusCollector) Cleanup() {
 	for _, cleanup := range c.cleanups {
 		cleanup()
@@ -147,25 +217,33 @@ func NewGPUHealthStatusCollector(
 	deviceWatchList devicewatchlistmanager.WatchList,
 ) (Collector, error) {
 	if !IsDCGMExpGPUHealthStatusEnabled(counterList) {
-		logrus.Error(counters.DCGMExpGPUHealthStatus + " collector is disabled")
-		return nil, fmt.Errorf(counters.DCGMExpGPUHealthStatus + " collector is disabled")
+		slog.Error(counters.DCGMExpGPUHealthStatus+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpGPUHealthStatus))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpGPUHealthStatus)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(gpuHealthCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
 	}
 
 	supportedGPUs, err := dcgmprovider.Client().GetSupportedDevices()
 	if err != nil {
-		logrus.WithError(err).Error("Failed to get supported GPU devices")
+		slog.Error("Failed to get supported GPU devices", slog.String(logging.ErrorKey, err.Error()))
 		return nil, err
 	}
 
 	if len(supportedGPUs) == 0 {
-		logrus.Error("No supported GPU devices found")
+		slog.Error("No supported GPU devices found")
 		return nil, errors.New("no supported GPU devices found")
 	}
 
 	// Create Group
 	newGroupNumber, err := utils.RandUint64()
 	if err != nil {
-		logrus.WithError(err).Error("Failed to generate new group number")
+		slog.Error("Failed to generate new group number", slog.String(logging.ErrorKey, err.Error()))
 		return nil, err
 	}
 
@@ -173,31 +251,37 @@ func NewGPUHealthStatusCollector(
 
 	groupID, err := dcgmprovider.Client().CreateGroup(fmt.Sprintf("gpu_health_monitor_%d", newGroupNumber))
 	if err != nil {
-		logrus.WithError(err).Error("Failed to create group")
+		slog.Error("Failed to create group", slog.String(logging.ErrorKey, err.Error()))
 		return nil, err
 	}
 
+	connection := dcgmprovider.Connection()
 	cleanups = append(cleanups, func() {
+		// The group was lost with its connection
+		if dcgmprovider.Connection() != connection {
+			return
+		}
 		destroyErr := dcgmprovider.Client().DestroyGroup(groupID)
 		if destroyErr != nil {
-			logrus.WithFields(logrus.Fields{
-				logging.GroupIDKey: groupID,
-				logrus.ErrorKey:    destroyErr,
-			}).Warn("cannot destroy group")
+			slog.Warn("cannot destroy group",
+				slog.Any(logging.GroupIDKey, groupID),
+				slog.String(logging.ErrorKey, destroyErr.Error()))
 		}
 	})
 
 	for _, gpu := range supportedGPUs {
 		err = dcgmprovider.Client().AddEntityToGroup(groupID, dcgm.FE_GPU, gpu)
 		if err != nil {
-			logrus.WithError(err).WithField("gpu", gpu).Error("Failed to add GPU device to group")
+			slog.Error("Failed to add GPU device to group",
+				slog.String(logging.ErrorKey, err.Error()),
+				slog.Uint64(logging.GPUIDKey, uint64(gpu)))
 			return nil, err
 		}
 	}
 
 	err = dcgmprovider.Client().HealthSet(groupID, dcgm.DCGM_HEALTH_WATCH_ALL)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to set health watch")
+		slog.Error("Failed to set health watch", slog.String(logging.ErrorKey, err.Error()))
 		return nil, err
 	}
 
@@ -215,7 +299,7 @@ func NewGPUHealthStatusCollector(
 	if !deviceWatchList.IsEmpty() {
 		watchListCleanups, err := deviceWatchList.Watch()
 		if err != nil {
-			logrus.WithError(err).Error("Failed to watch metrics")
+			slog.Error("Failed to watch metrics", slog.String(logging.ErrorKey, err.Error()))
 			return nil, err
 		}
 
@@ -225,7 +309,7 @@ func NewGPUHealthStatusCollector(
 	return &gpuHealthStatusCollector{
 		baseExpCollector: baseExpCollector{
 			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
-				return c.FieldName == counters.DCGMExpGPUHealthStatus
+				return slices.Contains(gpuHealthCounters, c.FieldName)
 			})],
 			labelsCounters:  counterList.LabelCounters(),
 			hostname:        hostname,
@@ -233,14 +317,16 @@ func NewGPUHealthStatusCollector(
 			cleanups:        cleanups,
 			deviceWatchList: deviceWatchList,
 		},
+		enabled:            enabled,
 		groupID:            groupID,
 		deviceInfoProvider: deviceInfoProvider,
+		incidents:          map[dcgm.GroupEntityPair]map[dcgm.HealthSystem]int{},
 	}, nil
 }
 
 func IsDCGMExpGPUHealthStatusEnabled(counterList counters.CounterList) bool {
 	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
-		return c.FieldName == counters.DCGMExpGPUHealthStatus
+		return slices.Contains(gpuHealthCounters, c.FieldName)
 	})
 }
 
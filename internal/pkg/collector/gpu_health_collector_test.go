@@ -290,6 +290,74 @@ func TestGPUHealthStatusCollector_GetMetrics_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestGPUHealthStatusCollector_IncidentsCount(t *testing.T) {
+	var counterList counters.CounterList = []counters.Counter{
+		{
+			FieldName: counters.DCGMExpGPUHealthIncidentsCount,
+			PromType:  "counter",
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockDCGMProvider := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer func() {
+		dcgmprovider.SetClient(realDCGM)
+	}()
+	dcgmprovider.SetClient(mockDCGMProvider)
+
+	setDefaultExpectationsForGPUHealthStatusCollectorMockDCGMProvider(t, mockDCGMProvider)
+
+	collector, err := NewGPUHealthStatusCollector(counterList, "", &appconfig.Config{},
+		getDefaultDeviceWatchListForGPUHealthStatusCollectorMockDCGMProvider(ctrl))
+	require.NoError(t, err)
+
+	incidentsCount := func() map[string]string {
+		metrics, err := collector.GetMetrics()
+		require.NoError(t, err)
+		require.Len(t, metrics[counterList[0]], len(gpuHealthChecks))
+
+		values := map[string]string{}
+		for _, m := range metrics[counterList[0]] {
+			values[m.Labels["health_watch"]] = m.Value
+		}
+		return values
+	}
+
+	// The thermal incident is counted once, while it is reported by every check
+	assert.Equal(t, "1", incidentsCount()["THERMAL"])
+	assert.Equal(t, "1", incidentsCount()["THERMAL"])
+	assert.Equal(t, "0", incidentsCount()["PCIE"])
+}
+
+func TestGPUHealthStatusCollector_countIncidents(t *testing.T) {
+	gpu := dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: 0}
+	thermal := dcgm.Incident{
+		System:     dcgm.DCGM_HEALTH_WATCH_THERMAL,
+		Health:     dcgm.DCGM_HEALTH_RESULT_WARN,
+		Error:      dcgm.DiagErrorDetail{Code: dcgm.DCGM_FR_THERMAL_VIOLATIONS},
+		EntityInfo: gpu,
+	}
+	pcie := dcgm.Incident{
+		System:     dcgm.DCGM_HEALTH_WATCH_PCIE,
+		Health:     dcgm.DCGM_HEALTH_RESULT_FAIL,
+		Error:      dcgm.DiagErrorDetail{Code: dcgm.DCGM_FR_PCI_REPLAY_RATE},
+		EntityInfo: gpu,
+	}
+
+	c := &gpuHealthStatusCollector{incidents: map[dcgm.GroupEntityPair]map[dcgm.HealthSystem]int{}}
+
+	c.countIncidents([]dcgm.Incident{thermal})
+	c.countIncidents([]dcgm.Incident{thermal, pcie})
+	// The thermal incident is cleared and raised again
+	c.countIncidents(nil)
+	c.countIncidents([]dcgm.Incident{thermal})
+
+	assert.Equal(t, 2, c.incidents[gpu][dcgm.DCGM_HEALTH_WATCH_THERMAL])
+	assert.Equal(t, 1, c.incidents[gpu][dcgm.DCGM_HEALTH_WATCH_PCIE])
+}
+
 func TestIsDCGMExpGPUHealthStatusEnabled(t *testing.T) {
 	tests := []struct {
 		name string
@@ -329,6 +397,16 @@ func TestIsDCGMExpGPUHealthStatusEnabled(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "incidents count enabled",
+			arg: counters.CounterList{
+				counters.Counter{
+					FieldID:   1,
+					FieldName: counters.DCGMExpGPUHealthIncidentsCount,
+				},
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
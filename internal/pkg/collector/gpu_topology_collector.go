@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	topologyGPUALabel     = "gpu_a"
+	topologyGPUBLabel     = "gpu_b"
+	topologyLinkTypeLabel = "link_type"
+	topologyPathLabel     = "path"
+	topologyHopsLabel     = "hops"
+
+	topologyLinkTypeNVLink = "NVLINK"
+	topologyLinkTypePCIe   = "PCIE"
+)
+
+// topologyHops orders the paths between two GPUs from the closest to the farthest, as reported by
+// nvidia-smi topo: NVLink and the same board first, the host bridges and the CPU interconnect last.
+var topologyHops = map[dcgm.P2PLinkType]int{
+	dcgm.SingleNVLINKLink:    1,
+	dcgm.TwoNVLINKLinks:      1,
+	dcgm.ThreeNVLINKLinks:    1,
+	dcgm.FourNVLINKLinks:     1,
+	dcgm.P2PLinkSameBoard:    1,
+	dcgm.P2PLinkSingleSwitch: 2,
+	dcgm.P2PLinkMultiSwitch:  3,
+	dcgm.P2PLinkHostBridge:   4,
+	dcgm.P2PLinkSameCPU:      5,
+	dcgm.P2PLinkCrossCPU:     6,
+}
+
+// gpuTopologyCollector exports an info metric per pair of GPUs with the path between them, the same matrix
+// nvidia-smi topo -m prints, so that schedulers and NCCL debugging can rely on it in Prometheus. Paths, which DCGM
+// doesn't know, are omitted.
+type gpuTopologyCollector struct {
+	baseExpCollector
+}
+
+func (c *gpuTopologyCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		links, err := dcgmprovider.Client().GetDeviceTopology(mi.DeviceInfo.GPU)
+		if err != nil {
+			return nil, err
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, link := range links {
+			hops, known := topologyHops[link.Link]
+			if !known {
+				continue
+			}
+
+			linkLabels := maps.Clone(labels)
+			linkLabels[topologyGPUALabel] = fmt.Sprint(mi.DeviceInfo.GPU)
+			linkLabels[topologyGPUBLabel] = fmt.Sprint(link.GPU)
+			linkLabels[topologyLinkTypeLabel] = topologyLinkType(link.Link)
+			linkLabels[topologyPathLabel] = link.Link.PCIPaths()
+			linkLabels[topologyHopsLabel] = fmt.Sprint(hops)
+
+			metrics[c.counter] = append(metrics[c.counter], c.createMetric(linkLabels, gpuInfo, uuid, 1))
+		}
+	}
+
+	return metrics, nil
+}
+
+// topologyLinkType returns whether the GPUs are connected with NVLink, or only through PCIe.
+func topologyLinkType(link dcgm.P2PLinkType) string {
+	switch link {
+	case dcgm.SingleNVLINKLink, dcgm.TwoNVLINKLinks, dcgm.ThreeNVLINKLinks, dcgm.FourNVLINKLinks:
+		return topologyLinkTypeNVLink
+	default:
+		return topologyLinkTypePCIe
+	}
+}
+
+func NewGPUTopologyCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpGPUTopologyEnabled(counterList) {
+		slog.Error(counters.DCGMExpGPUTopology+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpGPUTopology))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpGPUTopology)
+	}
+
+	return &gpuTopologyCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpGPUTopology
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+		},
+	}, nil
+}
+
+func IsDCGMExpGPUTopologyEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpGPUTopology
+	})
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func TestGPUTopologyCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	// GPU 0 and 1 are connected with NVLink, GPU 2 is not monitored and its path to GPU 1 is unknown
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().GetDeviceTopology(uint(0)).Return([]dcgm.P2PLink{
+		{GPU: 1, Link: dcgm.FourNVLINKLinks},
+		{GPU: 2, Link: dcgm.P2PLinkCrossCPU},
+	}, nil)
+	mockDCGM.EXPECT().GetDeviceTopology(uint(1)).Return([]dcgm.P2PLink{
+		{GPU: 0, Link: dcgm.FourNVLINKLinks},
+		{GPU: 2, Link: dcgm.P2PLinkUnknown},
+	}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	topology := counters.Counter{FieldName: counters.DCGMExpGPUTopology, PromType: "gauge"}
+
+	c, err := NewGPUTopologyCollector(counters.CounterList{topology}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	got := map[string]map[string]string{}
+	for _, m := range metrics[topology] {
+		assert.Equal(t, "1", m.Value)
+		got[m.Labels[topologyGPUALabel]+"-"+m.Labels[topologyGPUBLabel]] = m.Labels
+	}
+
+	require.Len(t, got, 3)
+	assert.Equal(t, topologyLinkTypeNVLink, got["0-1"][topologyLinkTypeLabel])
+	assert.Equal(t, "NV4", got["0-1"][topologyPathLabel])
+	assert.Equal(t, "1", got["0-1"][topologyHopsLabel])
+	assert.Equal(t, topologyLinkTypePCIe, got["0-2"][topologyLinkTypeLabel])
+	assert.Equal(t, "SYS", got["0-2"][topologyPathLabel])
+	assert.Equal(t, "6", got["0-2"][topologyHopsLabel])
+	assert.Equal(t, topologyLinkTypeNVLink, got["1-0"][topologyLinkTypeLabel])
+}
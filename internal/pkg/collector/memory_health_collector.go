@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	remappedRowsTypeLabel = "type"
+
+	remappedRowsCorrectable   = "correctable"
+	remappedRowsUncorrectable = "uncorrectable"
+)
+
+// memoryHealthCounters are the exporter counters computed by the memoryHealthCollector
+var memoryHealthCounters = []string{
+	counters.DCGMExpRemappedRows,
+	counters.DCGMExpRetiredPagesPending,
+	counters.DCGMExpGPUNeedsReset,
+}
+
+// memoryHealthFields are the DCGM fields, which the memory health counters are computed from. GPUs before Ampere
+// retire pages, Ampere and later GPUs remap rows instead, so a GPU reports only one of them.
+var memoryHealthFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS,
+	dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS,
+	dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING,
+	dcgm.DCGM_FI_DEV_RETIRED_PENDING,
+}
+
+// memoryHealthSample is a value of a memory health counter. rowType is only set for the remapped rows.
+type memoryHealthSample struct {
+	counter string
+	rowType string
+	value   int
+}
+
+// memoryHealthCollector summarizes the memory health of a GPU: the rows remapped because of correctable and
+// uncorrectable errors, the pages pending retirement, and whether a GPU reset is needed to remap the rows or retire
+// the pages, which are pending. Counters, which a GPU has no values for, are omitted for the GPU.
+type memoryHealthCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+}
+
+func (c *memoryHealthCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		values, err := latestInt64Values(mi.DeviceInfo.GPU, memoryHealthFields)
+		if err != nil {
+			return nil, err
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, sample := range computeMemoryHealth(values) {
+			counter, exists := c.enabled[sample.counter]
+			if !exists {
+				continue
+			}
+
+			sampleLabels := labels
+			if sample.rowType != "" {
+				sampleLabels = maps.Clone(labels)
+				sampleLabels[remappedRowsTypeLabel] = sample.rowType
+			}
+
+			m := c.createMetric(sampleLabels, gpuInfo, uuid, sample.value)
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// computeMemoryHealth computes the counters, which the fields they depend on are available for. A reset is needed,
+// when rows are pending remapping or pages are pending retirement, since both only take effect after a reset.
+func computeMemoryHealth(values map[dcgm.Short]int64) []memoryHealthSample {
+	var samples []memoryHealthSample
+
+	if correctable, exists := values[dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS]; exists {
+		samples = append(samples, memoryHealthSample{
+			counter: counters.DCGMExpRemappedRows, rowType: remappedRowsCorrectable, value: int(correctable),
+		})
+	}
+	if uncorrectable, exists := values[dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS]; exists {
+		samples = append(samples, memoryHealthSample{
+			counter: counters.DCGMExpRemappedRows, rowType: remappedRowsUncorrectable, value: int(uncorrectable),
+		})
+	}
+
+	retiredPending, hasRetiredPending := values[dcgm.DCGM_FI_DEV_RETIRED_PENDING]
+	if hasRetiredPending {
+		samples = append(samples, memoryHealthSample{
+			counter: counters.DCGMExpRetiredPagesPending, value: int(retiredPending),
+		})
+	}
+
+	remapPending, hasRemapPending := values[dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING]
+	if hasRemapPending || hasRetiredPending {
+		samples = append(samples, memoryHealthSample{
+			counter: counters.DCGMExpGPUNeedsReset, value: boolToInt(remapPending > 0 || retiredPending > 0),
+		})
+	}
+
+	return samples
+}
+
+func NewMemoryHealthCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpMemoryHealthEnabled(counterList) {
+		slog.Error(counters.DCGMExpRemappedRows+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpRemappedRows))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpRemappedRows)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(memoryHealthCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields(memoryHealthFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryHealthCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(memoryHealthCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled: enabled,
+	}, nil
+}
+
+func IsDCGMExpMemoryHealthEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(memoryHealthCounters, c.FieldName)
+	})
+}
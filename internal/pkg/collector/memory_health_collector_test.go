@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/testutils"
+)
+
+func TestComputeMemoryHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[dcgm.Short]int64
+		want   []memoryHealthSample
+	}{
+		{
+			name:   "no fields are supported",
+			values: map[dcgm.Short]int64{},
+		},
+		{
+			name: "rows pending remapping",
+			values: map[dcgm.Short]int64{
+				dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS:   2,
+				dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS: 1,
+				dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING:           1,
+			},
+			want: []memoryHealthSample{
+				{counter: counters.DCGMExpRemappedRows, rowType: remappedRowsCorrectable, value: 2},
+				{counter: counters.DCGMExpRemappedRows, rowType: remappedRowsUncorrectable, value: 1},
+				{counter: counters.DCGMExpGPUNeedsReset, value: 1},
+			},
+		},
+		{
+			name: "remapped rows without pending remapping",
+			values: map[dcgm.Short]int64{
+				dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS:   3,
+				dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS: 0,
+				dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING:           0,
+			},
+			want: []memoryHealthSample{
+				{counter: counters.DCGMExpRemappedRows, rowType: remappedRowsCorrectable, value: 3},
+				{counter: counters.DCGMExpRemappedRows, rowType: remappedRowsUncorrectable, value: 0},
+				{counter: counters.DCGMExpGPUNeedsReset, value: 0},
+			},
+		},
+		{
+			name:   "pages pending retirement",
+			values: map[dcgm.Short]int64{dcgm.DCGM_FI_DEV_RETIRED_PENDING: 2},
+			want: []memoryHealthSample{
+				{counter: counters.DCGMExpRetiredPagesPending, value: 2},
+				{counter: counters.DCGMExpGPUNeedsReset, value: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, computeMemoryHealth(tt.values))
+		})
+	}
+}
+
+func TestMemoryHealthCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := testutils.MockGPUDeviceInfo(ctrl, 2, nil)
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(memoryHealthFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// GPU 0 remaps rows and has a remapping pending, GPU 1 retires pages instead
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), memoryHealthFields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS, 4),
+			int64FieldValue(dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS, 1),
+			int64FieldValue(dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING, 1),
+			int64FieldValue(dcgm.DCGM_FI_DEV_RETIRED_PENDING, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), memoryHealthFields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_CORRECTABLE_REMAPPED_ROWS, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			int64FieldValue(dcgm.DCGM_FI_DEV_UNCORRECTABLE_REMAPPED_ROWS, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			int64FieldValue(dcgm.DCGM_FI_DEV_ROW_REMAP_PENDING, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+			int64FieldValue(dcgm.DCGM_FI_DEV_RETIRED_PENDING, 0),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	remappedRows := counters.Counter{FieldName: counters.DCGMExpRemappedRows, PromType: "gauge"}
+	needsReset := counters.Counter{FieldName: counters.DCGMExpGPUNeedsReset, PromType: "gauge"}
+
+	c, err := NewMemoryHealthCollector(counters.CounterList{remappedRows, needsReset}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	// The remapped rows are only reported for the GPU, which remaps rows
+	require.Len(t, metrics[remappedRows], 2)
+	assert.Equal(t, "0", metrics[remappedRows][0].GPU)
+	assert.Equal(t, remappedRowsCorrectable, metrics[remappedRows][0].Labels[remappedRowsTypeLabel])
+	assert.Equal(t, "4", metrics[remappedRows][0].Value)
+	assert.Equal(t, remappedRowsUncorrectable, metrics[remappedRows][1].Labels[remappedRowsTypeLabel])
+	assert.Equal(t, "1", metrics[remappedRows][1].Value)
+
+	require.Len(t, metrics[needsReset], 2)
+	assert.Equal(t, "1", metrics[needsReset][0].Value)
+	assert.Empty(t, metrics[needsReset][0].Labels[remappedRowsTypeLabel])
+	assert.Equal(t, "0", metrics[needsReset][1].Value)
+
+	// The pages pending retirement are not enabled in the counter list
+	assert.Len(t, metrics, 2)
+}
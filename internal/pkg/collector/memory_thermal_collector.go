@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// memoryThermalCounters are the exporter counters computed by the memoryThermalCollector
+var memoryThermalCounters = []string{
+	counters.DCGMExpMemoryTemp,
+	counters.DCGMExpMemoryThermalThrottle,
+	counters.DCGMExpMemoryClockReduced,
+}
+
+// memoryThermalFields are the DCGM fields, which the memory thermal counters are computed from
+var memoryThermalFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_MEMORY_TEMP,
+	dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP,
+	dcgm.DCGM_FI_DEV_MEM_CLOCK,
+	dcgm.DCGM_FI_DEV_MAX_MEM_CLOCK,
+	dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS,
+}
+
+// memoryThermalCollector reports the memory (HBM) temperature, whether the memory is throttled because it reached
+// its maximum operating temperature, and whether the memory clock is held below its maximum by a clock event.
+// The counters depend on fields, which are not supported by every SKU, so each counter is only reported for GPUs,
+// which returned values for all of its fields when the collector was created.
+type memoryThermalCollector struct {
+	baseExpCollector
+	enabled   map[string]counters.Counter
+	supported map[uint][]string
+}
+
+func (c *memoryThermalCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		supported := c.supported[mi.DeviceInfo.GPU]
+		if len(supported) == 0 {
+			continue
+		}
+
+		values, err := memoryThermalValues(mi.DeviceInfo.GPU)
+		if err != nil {
+			return nil, err
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for name, val := range computeMemoryThermalCounters(values) {
+			if !slices.Contains(supported, name) {
+				continue
+			}
+			counter, exists := c.enabled[name]
+			if !exists {
+				continue
+			}
+
+			m := c.createMetric(labels, gpuInfo, uuid, val)
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// memoryThermalValues reads the latest values of the memory thermal fields of a GPU. Fields without a value,
+// for example because they are not supported, are omitted.
+func memoryThermalValues(gpu uint) (map[dcgm.Short]int64, error) {
+	return latestInt64Values(gpu, memoryThermalFields)
+}
+
+// latestInt64Values reads the latest values of the integer fields of a GPU. Fields without a value, for example
+// because they are not supported, are omitted.
+func latestInt64Values(gpu uint, fields []dcgm.Short) (map[dcgm.Short]int64, error) {
+	latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, gpu, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[dcgm.Short]int64{}
+	for _, val := range latestValues {
+		if val.FieldType != dcgm.DCGM_FT_INT64 || toString(val) == skipDCGMValue {
+			continue
+		}
+		values[dcgm.Short(val.FieldId)] = val.Int64()
+	}
+
+	return values, nil
+}
+
+// computeMemoryThermalCounters computes the counters, which all fields are available for.
+func computeMemoryThermalCounters(values map[dcgm.Short]int64) map[string]int {
+	result := map[string]int{}
+
+	memoryTemp, hasMemoryTemp := values[dcgm.DCGM_FI_DEV_MEMORY_TEMP]
+	maxOpTemp, hasMaxOpTemp := values[dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP]
+	memClock, hasMemClock := values[dcgm.DCGM_FI_DEV_MEM_CLOCK]
+	maxMemClock, hasMaxMemClock := values[dcgm.DCGM_FI_DEV_MAX_MEM_CLOCK]
+	reasons, hasReasons := values[dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS]
+
+	if hasMemoryTemp {
+		result[counters.DCGMExpMemoryTemp] = int(memoryTemp)
+	}
+
+	if hasMemoryTemp && hasMaxOpTemp && hasReasons {
+		thermal := clockEventBitmask(reasons)&
+			(DCGM_CLOCKS_THROTTLE_REASON_SW_THERMAL|DCGM_CLOCKS_THROTTLE_REASON_HW_THERMAL) != 0
+		result[counters.DCGMExpMemoryThermalThrottle] = boolToInt(thermal && memoryTemp >= maxOpTemp)
+	}
+
+	if hasMemClock && hasMaxMemClock && hasReasons {
+		// An idle GPU lowers the memory clock on its own, which is not a clock event worth reporting
+		limited := clockEventBitmask(reasons)&^DCGM_CLOCKS_THROTTLE_REASON_GPU_IDLE != 0
+		result[counters.DCGMExpMemoryClockReduced] = boolToInt(limited && memClock < maxMemClock)
+	}
+
+	return result
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func NewMemoryThermalCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpMemoryThermalEnabled(counterList) {
+		slog.Error(counters.DCGMExpMemoryTemp+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpMemoryTemp))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpMemoryTemp)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(memoryThermalCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields(memoryThermalFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	collector := &memoryThermalCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(memoryThermalCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled:   enabled,
+		supported: map[uint][]string{},
+	}
+
+	err = collector.probe()
+	if err != nil {
+		collector.Cleanup()
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+// probe finds the enabled counters, which every GPU has values for.
+func (c *memoryThermalCollector) probe() error {
+	// The fields were just watched, so they don't have values until they are updated
+	err := dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo()) {
+		gpu := mi.DeviceInfo.GPU
+		if _, exists := c.supported[gpu]; exists {
+			continue
+		}
+
+		values, err := memoryThermalValues(gpu)
+		if err != nil {
+			return err
+		}
+
+		c.supported[gpu] = []string{}
+		available := computeMemoryThermalCounters(values)
+
+		for name := range c.enabled {
+			if _, exists := available[name]; exists {
+				c.supported[gpu] = append(c.supported[gpu], name)
+			} else {
+				slog.Info(fmt.Sprintf("%s is not supported by GPU %d and will not be reported", name, gpu))
+			}
+		}
+	}
+
+	return nil
+}
+
+func IsDCGMExpMemoryThermalEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(memoryThermalCounters, c.FieldName)
+	})
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/testutils"
+)
+
+func int64FieldValue(fieldID dcgm.Short, value int64) dcgm.FieldValue_v1 {
+	fieldValue := [4096]byte{}
+	binary.LittleEndian.PutUint64(fieldValue[:], uint64(value))
+	return dcgm.FieldValue_v1{
+		FieldId:   uint(fieldID),
+		FieldType: dcgm.DCGM_FT_INT64,
+		Value:     fieldValue,
+	}
+}
+
+func TestComputeMemoryThermalCounters(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[dcgm.Short]int64
+		want   map[string]int
+	}{
+		{
+			name:   "no fields are supported",
+			values: map[dcgm.Short]int64{},
+			want:   map[string]int{},
+		},
+		{
+			name:   "only memory temperature is supported",
+			values: map[dcgm.Short]int64{dcgm.DCGM_FI_DEV_MEMORY_TEMP: 70},
+			want:   map[string]int{counters.DCGMExpMemoryTemp: 70},
+		},
+		{
+			name: "memory is throttled at the maximum operating temperature",
+			values: map[dcgm.Short]int64{
+				dcgm.DCGM_FI_DEV_MEMORY_TEMP:          95,
+				dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP:      95,
+				dcgm.DCGM_FI_DEV_MEM_CLOCK:            1215,
+				dcgm.DCGM_FI_DEV_MAX_MEM_CLOCK:        1593,
+				dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS: int64(DCGM_CLOCKS_THROTTLE_REASON_HW_THERMAL),
+			},
+			want: map[string]int{
+				counters.DCGMExpMemoryTemp:            95,
+				counters.DCGMExpMemoryThermalThrottle: 1,
+				counters.DCGMExpMemoryClockReduced:    1,
+			},
+		},
+		{
+			name: "hot memory without thermal slowdown is not throttled",
+			values: map[dcgm.Short]int64{
+				dcgm.DCGM_FI_DEV_MEMORY_TEMP:          95,
+				dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP:      95,
+				dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS: int64(DCGM_CLOCKS_THROTTLE_REASON_SW_POWER_CAP),
+			},
+			want: map[string]int{
+				counters.DCGMExpMemoryTemp:            95,
+				counters.DCGMExpMemoryThermalThrottle: 0,
+			},
+		},
+		{
+			name: "idle GPU does not reduce the memory clock",
+			values: map[dcgm.Short]int64{
+				dcgm.DCGM_FI_DEV_MEM_CLOCK:            405,
+				dcgm.DCGM_FI_DEV_MAX_MEM_CLOCK:        1593,
+				dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS: int64(DCGM_CLOCKS_THROTTLE_REASON_GPU_IDLE),
+			},
+			want: map[string]int{
+				counters.DCGMExpMemoryClockReduced: 0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, computeMemoryThermalCounters(tt.values))
+		})
+	}
+}
+
+func TestMemoryThermalCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := testutils.MockGPUDeviceInfo(ctrl, 2, nil)
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(memoryThermalFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// GPU 0 supports every field, GPU 1 reports memory temperature only
+	gpu0Values := []dcgm.FieldValue_v1{
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEMORY_TEMP, 96),
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP, 95),
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEM_CLOCK, 1593),
+		int64FieldValue(dcgm.DCGM_FI_DEV_MAX_MEM_CLOCK, 1593),
+		int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS, int64(DCGM_CLOCKS_THROTTLE_REASON_SW_THERMAL)),
+	}
+	gpu1Values := []dcgm.FieldValue_v1{
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEMORY_TEMP, 60),
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEM_MAX_OP_TEMP, dcgm.DCGM_FT_INT32_NOT_SUPPORTED),
+		int64FieldValue(dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS, 0),
+	}
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().UpdateAllFields().Return(nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), memoryThermalFields).
+		Return(gpu0Values, nil).Times(2)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), memoryThermalFields).
+		Return(gpu1Values, nil).Times(2)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	memoryTemp := counters.Counter{FieldName: counters.DCGMExpMemoryTemp, PromType: "gauge"}
+	throttle := counters.Counter{FieldName: counters.DCGMExpMemoryThermalThrottle, PromType: "gauge"}
+
+	c, err := NewMemoryThermalCollector(counters.CounterList{memoryTemp, throttle}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[memoryTemp], 2)
+	assert.Equal(t, "96", metrics[memoryTemp][0].Value)
+	assert.Equal(t, "60", metrics[memoryTemp][1].Value)
+
+	// The throttle state is only reported for the GPU, which supports the maximum operating temperature
+	require.Len(t, metrics[throttle], 1)
+	assert.Equal(t, "0", metrics[throttle][0].GPU)
+	assert.Equal(t, "1", metrics[throttle][0].Value)
+
+	// The memory clock counter is not enabled in the counter list
+	assert.Len(t, metrics, 2)
+}
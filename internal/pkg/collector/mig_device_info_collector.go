@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvidiacaps"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	migUUIDLabel                 = "mig_uuid"
+	computeInstanceIDLabel       = "compute_instance_id"
+	gpuMinorLabel                = "gpu_minor"
+	gpuInstanceCapMinorLabel     = "gi_cap_minor"
+	gpuInstanceCapPathLabel      = "gi_cap_path"
+	computeInstanceCapMinorLabel = "ci_cap_minor"
+	computeInstanceCapPathLabel  = "ci_cap_path"
+)
+
+// migDeviceInfoCollector exports an info metric per MIG device, mapping the MIG UUID to the nvidia-caps
+// minor numbers and device nodes of its GPU and compute instances.
+type migDeviceInfoCollector struct {
+	baseExpCollector
+}
+
+func (c *migDeviceInfoCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	minors, err := nvidiacaps.ReadMIGMinors()
+	if err != nil {
+		// The device nodes are still reported as unknown, the MIG UUIDs are useful on their own
+		slog.Debug("Unable to read the MIG capabilities minor numbers", slog.String(logging.ErrorKey, err.Error()))
+	}
+
+	gpuMIGDevices := map[uint]*nvmlprovider.MIGDevices{}
+
+	for _, mi := range monitoringInfo {
+		if mi.InstanceInfo == nil {
+			continue
+		}
+
+		migDevices, exists := gpuMIGDevices[mi.DeviceInfo.GPU]
+		if !exists {
+			migDevices, err = nvmlprovider.Client().GetMIGDevices(mi.DeviceInfo.UUID)
+			if err != nil {
+				slog.Debug(fmt.Sprintf("Unable to read MIG devices for GPU %d", mi.DeviceInfo.GPU),
+					slog.String(logging.ErrorKey, err.Error()))
+			}
+			gpuMIGDevices[mi.DeviceInfo.GPU] = migDevices
+		}
+
+		if migDevices == nil {
+			continue
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(mi, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		gpuInstanceID := int(mi.InstanceInfo.Info.NvmlInstanceId)
+
+		labels[gpuMinorLabel] = fmt.Sprint(migDevices.MinorNumber)
+		if minor, path, found := minors.GPUInstance(migDevices.MinorNumber, gpuInstanceID); found {
+			labels[gpuInstanceCapMinorLabel] = fmt.Sprint(minor)
+			labels[gpuInstanceCapPathLabel] = path
+		}
+
+		instanceDevices := slices.DeleteFunc(slices.Clone(migDevices.Devices), func(d nvmlprovider.MIGDevice) bool {
+			return d.GPUInstanceID != gpuInstanceID
+		})
+
+		if len(instanceDevices) == 0 {
+			// The GPU instance has no compute instance yet, so there is no MIG device to map
+			metrics[c.counter] = append(metrics[c.counter], c.createMetric(labels, mi, uuid, 1))
+			continue
+		}
+
+		for _, device := range instanceDevices {
+			deviceLabels := maps.Clone(labels)
+			deviceLabels[migUUIDLabel] = device.UUID
+			deviceLabels[computeInstanceIDLabel] = fmt.Sprint(device.ComputeInstanceID)
+			if minor, path, found := minors.ComputeInstance(migDevices.MinorNumber, gpuInstanceID,
+				device.ComputeInstanceID); found {
+				deviceLabels[computeInstanceCapMinorLabel] = fmt.Sprint(minor)
+				deviceLabels[computeInstanceCapPathLabel] = path
+			}
+
+			metrics[c.counter] = append(metrics[c.counter], c.createMetric(deviceLabels, mi, uuid, 1))
+		}
+	}
+
+	return metrics, nil
+}
+
+func NewMIGDeviceInfoCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpMIGDeviceInfoEnabled(counterList) {
+		slog.Error(counters.DCGMExpMIGDeviceInfo+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpMIGDeviceInfo))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpMIGDeviceInfo)
+	}
+
+	if nvmlprovider.Client() == nil {
+		return nil, fmt.Errorf("NVML provider is not initialized")
+	}
+
+	return &migDeviceInfoCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpMIGDeviceInfo
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+		},
+	}, nil
+}
+
+func IsDCGMExpMIGDeviceInfoEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpMIGDeviceInfo
+	})
+}
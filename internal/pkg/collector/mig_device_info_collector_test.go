@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvidiacaps"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestMIGDeviceInfoCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	migMinors, err := os.CreateTemp(t.TempDir(), "mig-minors")
+	require.NoError(t, err)
+	_, err = migMinors.WriteString("config 1\nmonitor 2\n" +
+		"gpu3/gi1/access 30\ngpu3/gi1/ci0/access 31\ngpu3/gi1/ci1/access 32\ngpu3/gi2/access 39\n")
+	require.NoError(t, err)
+	require.NoError(t, migMinors.Close())
+
+	realMIGMinorsPath := nvidiacaps.MIGMinorsPath
+	defer func() { nvidiacaps.MIGMinorsPath = realMIGMinorsPath }()
+	nvidiacaps.MIGMinorsPath = migMinors.Name()
+
+	gpus := []deviceinfo.GPUInfo{
+		{
+			DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"},
+		},
+		{
+			DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"},
+			GPUInstances: []deviceinfo.GPUInstanceInfo{
+				{EntityId: 1, ProfileName: "2g.20gb", Info: dcgm.MigEntityInfo{NvmlInstanceId: 1}},
+				{EntityId: 2, ProfileName: "1g.10gb", Info: dcgm.MigEntityInfo{NvmlInstanceId: 2}},
+			},
+			MigEnabled: true,
+		},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetMIGDevices(gpus[1].DeviceInfo.UUID).Return(&nvmlprovider.MIGDevices{
+		MinorNumber: 3,
+		Devices: []nvmlprovider.MIGDevice{
+			{UUID: "MIG-11111111-1111-1111-1111-111111111110", GPUInstanceID: 1, ComputeInstanceID: 0},
+			{UUID: "MIG-11111111-1111-1111-1111-111111111111", GPUInstanceID: 1, ComputeInstanceID: 1},
+		},
+	}, nil).Times(1)
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	counterList := counters.CounterList{
+		{FieldID: dcgm.Short(counters.DCGMMIGDeviceInfo), FieldName: counters.DCGMExpMIGDeviceInfo, PromType: "gauge"},
+	}
+
+	c, err := NewMIGDeviceInfoCollector(counterList, "testhost", &appconfig.Config{},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	values := metrics[counterList[0]]
+	require.Len(t, values, 3)
+
+	for _, m := range values {
+		assert.Equal(t, "1", m.Value)
+		assert.Equal(t, "1", m.GPU)
+		assert.Equal(t, "3", m.Labels[gpuMinorLabel])
+	}
+
+	assert.Equal(t, "1", values[0].GPUInstanceID)
+	assert.Equal(t, "MIG-11111111-1111-1111-1111-111111111110", values[0].Labels[migUUIDLabel])
+	assert.Equal(t, "/dev/nvidia-caps/nvidia-cap30", values[0].Labels[gpuInstanceCapPathLabel])
+	assert.Equal(t, "31", values[0].Labels[computeInstanceCapMinorLabel])
+	assert.Equal(t, "/dev/nvidia-caps/nvidia-cap31", values[0].Labels[computeInstanceCapPathLabel])
+
+	assert.Equal(t, "MIG-11111111-1111-1111-1111-111111111111", values[1].Labels[migUUIDLabel])
+	assert.Equal(t, "1", values[1].Labels[computeInstanceIDLabel])
+	assert.Equal(t, "/dev/nvidia-caps/nvidia-cap32", values[1].Labels[computeInstanceCapPathLabel])
+
+	// The second GPU instance has no compute instance
+	assert.Equal(t, "2", values[2].GPUInstanceID)
+	assert.NotContains(t, values[2].Labels, migUUIDLabel)
+	assert.Equal(t, "39", values[2].Labels[gpuInstanceCapMinorLabel])
+}
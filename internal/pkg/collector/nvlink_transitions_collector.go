@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	nvlinkLabel          = "nvlink"
+	nvlinkDirectionLabel = "direction"
+
+	nvlinkDirectionUp   = "up"
+	nvlinkDirectionDown = "down"
+)
+
+// nvlinkID identifies an NVLink of a GPU.
+type nvlinkID struct {
+	gpu   uint
+	index uint
+}
+
+// nvlinkTransitionsCollector exports the number of state transitions of every NVLink of the GPUs, by direction: up
+// when the link becomes active, and down when it stops being active. The link status is compared between collect
+// cycles, so flapping links can be alerted on, while the link status alone only shows the instantaneous state.
+// Links, which are not supported, are omitted.
+type nvlinkTransitionsCollector struct {
+	baseExpCollector
+	states      map[nvlinkID]dcgm.Link_State
+	transitions map[nvlinkID]map[string]int
+}
+
+func (c *nvlinkTransitionsCollector) GetMetrics() (MetricsByCounter, error) {
+	links, err := dcgmprovider.Client().GetNvLinkLinkStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	linksByGPU := map[uint][]uint{}
+	for _, link := range links {
+		if link.ParentType != dcgm.FE_GPU || link.State == dcgm.LS_NOT_SUPPORTED {
+			continue
+		}
+		id := nvlinkID{gpu: link.ParentId, index: link.Index}
+		c.observe(id, link.State)
+		linksByGPU[id.gpu] = append(linksByGPU[id.gpu], id.index)
+	}
+
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		indexes := linksByGPU[mi.DeviceInfo.GPU]
+		if len(indexes) == 0 {
+			continue
+		}
+		slices.Sort(indexes)
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		gpuLabels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, gpuLabels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, index := range indexes {
+			transitions := c.transitions[nvlinkID{gpu: mi.DeviceInfo.GPU, index: index}]
+			for _, direction := range []string{nvlinkDirectionUp, nvlinkDirectionDown} {
+				labels := map[string]string{}
+				for k, v := range gpuLabels {
+					labels[k] = v
+				}
+				labels[nvlinkLabel] = strconv.FormatUint(uint64(index), 10)
+				labels[nvlinkDirectionLabel] = direction
+
+				metrics[c.counter] = append(metrics[c.counter],
+					c.createMetric(labels, gpuInfo, uuid, transitions[direction]))
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// observe records the state of the link, and counts the transition from its previous state. The first observed
+// state of a link is not a transition.
+func (c *nvlinkTransitionsCollector) observe(id nvlinkID, state dcgm.Link_State) {
+	previous, seen := c.states[id]
+	c.states[id] = state
+
+	if _, exists := c.transitions[id]; !exists {
+		c.transitions[id] = map[string]int{}
+	}
+
+	if direction, ok := nvlinkTransition(previous, state); seen && ok {
+		c.transitions[id][direction]++
+	}
+}
+
+// nvlinkTransition returns the direction of the transition between the states, if the link became active or
+// stopped being active.
+func nvlinkTransition(previous, current dcgm.Link_State) (string, bool) {
+	switch {
+	case previous != dcgm.LS_UP && current == dcgm.LS_UP:
+		return nvlinkDirectionUp, true
+	case previous == dcgm.LS_UP && current != dcgm.LS_UP:
+		return nvlinkDirectionDown, true
+	}
+	return "", false
+}
+
+func NewNVLinkTransitionsCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpNVLinkTransitionsEnabled(counterList) {
+		slog.Error(counters.DCGMExpNVLinkStateTransitions+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpNVLinkStateTransitions))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpNVLinkStateTransitions)
+	}
+
+	return &nvlinkTransitionsCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpNVLinkStateTransitions
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+		},
+		states:      map[nvlinkID]dcgm.Link_State{},
+		transitions: map[nvlinkID]map[string]int{},
+	}, nil
+}
+
+func IsDCGMExpNVLinkTransitionsEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpNVLinkStateTransitions
+	})
+}
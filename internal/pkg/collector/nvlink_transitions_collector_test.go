@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func TestNVLinkTransitionsCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	link := func(gpu, index uint, state dcgm.Link_State) dcgm.NvLinkStatus {
+		return dcgm.NvLinkStatus{ParentId: gpu, ParentType: dcgm.FE_GPU, State: state, Index: index}
+	}
+
+	// Link 1 of GPU 0 flaps down and up again; GPU 1 has no NVLinks; switch links are ignored
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	gomock.InOrder(
+		mockDCGM.EXPECT().GetNvLinkLinkStatus().Return([]dcgm.NvLinkStatus{
+			link(0, 0, dcgm.LS_UP), link(0, 1, dcgm.LS_UP), link(1, 0, dcgm.LS_NOT_SUPPORTED),
+			{ParentId: 0, ParentType: dcgm.FE_SWITCH, State: dcgm.LS_UP, Index: 0},
+		}, nil),
+		mockDCGM.EXPECT().GetNvLinkLinkStatus().Return([]dcgm.NvLinkStatus{
+			link(0, 0, dcgm.LS_UP), link(0, 1, dcgm.LS_DOWN), link(1, 0, dcgm.LS_NOT_SUPPORTED),
+		}, nil),
+		mockDCGM.EXPECT().GetNvLinkLinkStatus().Return([]dcgm.NvLinkStatus{
+			link(0, 0, dcgm.LS_UP), link(0, 1, dcgm.LS_UP), link(1, 0, dcgm.LS_NOT_SUPPORTED),
+		}, nil),
+	)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	transitions := counters.Counter{FieldName: counters.DCGMExpNVLinkStateTransitions, PromType: "counter"}
+
+	c, err := NewNVLinkTransitionsCollector(counters.CounterList{transitions}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+
+	values := func(metrics MetricsByCounter) map[string]string {
+		got := map[string]string{}
+		for _, m := range metrics[transitions] {
+			assert.Equal(t, "0", m.GPU)
+			got[m.Labels[nvlinkLabel]+"/"+m.Labels[nvlinkDirectionLabel]] = m.Value
+		}
+		return got
+	}
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0/up": "0", "0/down": "0", "1/up": "0", "1/down": "0"}, values(metrics))
+
+	_, err = c.GetMetrics()
+	require.NoError(t, err)
+
+	metrics, err = c.GetMetrics()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0/up": "0", "0/down": "0", "1/up": "1", "1/down": "1"}, values(metrics))
+}
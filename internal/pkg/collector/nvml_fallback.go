@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+// nvmlFallbackFields are the DCGM fields, which are read from NVML, when DCGM has no value for them, e.g. the fan
+// speed on SKUs, which DCGM doesn't report it for.
+var nvmlFallbackFields = map[dcgm.Short]nvmlprovider.DeviceField{
+	dcgm.DCGM_FI_DEV_FAN_SPEED:           nvmlprovider.FanSpeed,
+	dcgm.DCGM_FI_DEV_ENC_UTIL:            nvmlprovider.EncoderUtilization,
+	dcgm.DCGM_FI_DEV_DEC_UTIL:            nvmlprovider.DecoderUtilization,
+	dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER: nvmlprovider.PCIeReplayCounter,
+	dcgm.DCGM_FI_DEV_POWER_USAGE:         nvmlprovider.PowerUsage,
+	dcgm.DCGM_FI_DEV_GPU_TEMP:            nvmlprovider.GPUTemperature,
+}
+
+// withNVMLFallback returns the values of a GPU, where the values, which DCGM has none for, are replaced with the
+// values NVML reports, so that they are exported in the same metrics. Values, which NVML has none for either, stay
+// blank and are skipped.
+func withNVMLFallback(gpuUUID string, values []dcgm.FieldValue_v1) []dcgm.FieldValue_v1 {
+	missing := map[nvmlprovider.DeviceField]int{}
+	var fields []nvmlprovider.DeviceField
+	for i, val := range values {
+		field, exists := nvmlFallbackFields[dcgm.Short(val.FieldId)]
+		if !exists || toString(val) != skipDCGMValue {
+			continue
+		}
+		missing[field] = i
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 || nvmlprovider.Client() == nil {
+		return values
+	}
+
+	nvmlValues, err := nvmlprovider.Client().GetDeviceValues(gpuUUID, fields)
+	if err != nil {
+		slog.Debug(fmt.Sprintf("Failed to read the fields without DCGM values of the GPU %s from NVML", gpuUUID),
+			slog.String(logging.ErrorKey, err.Error()))
+		return values
+	}
+
+	values = slices.Clone(values)
+	for field, value := range nvmlValues {
+		i, exists := missing[field]
+		if !exists {
+			continue
+		}
+
+		switch values[i].FieldType {
+		case dcgm.DCGM_FT_INT64:
+			binary.NativeEndian.PutUint64(values[i].Value[:], uint64(int64(value)))
+		case dcgm.DCGM_FT_DOUBLE:
+			binary.NativeEndian.PutUint64(values[i].Value[:], math.Float64bits(value))
+		default:
+			continue
+		}
+		values[i].Status = 0
+	}
+
+	return values
+}
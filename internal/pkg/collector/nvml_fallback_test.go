@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestWithNVMLFallback(t *testing.T) {
+	const gpuUUID = "GPU-00000000-0000-0000-0000-000000000000"
+
+	// DCGM has no fan speed and power usage, and no memory clock, which NVML is not asked for
+	values := []dcgm.FieldValue_v1{
+		int64FieldValue(dcgm.DCGM_FI_DEV_GPU_TEMP, 65),
+		int64FieldValue(dcgm.DCGM_FI_DEV_FAN_SPEED, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		float64FieldValue(dcgm.DCGM_FI_DEV_POWER_USAGE, dcgm.DCGM_FT_FP64_BLANK),
+		int64FieldValue(dcgm.DCGM_FI_DEV_ENC_UTIL, dcgm.DCGM_FT_INT64_BLANK),
+		int64FieldValue(dcgm.DCGM_FI_DEV_MEM_CLOCK, dcgm.DCGM_FT_INT64_BLANK),
+	}
+
+	tests := []struct {
+		name      string
+		nvmlValue map[nvmlprovider.DeviceField]float64
+		nvmlErr   error
+		want      []string
+	}{
+		{
+			name: "fields without DCGM values are read from NVML",
+			nvmlValue: map[nvmlprovider.DeviceField]float64{
+				nvmlprovider.FanSpeed:   42,
+				nvmlprovider.PowerUsage: 251.5,
+			},
+			want: []string{"65", "42", "251.500000", skipDCGMValue, skipDCGMValue},
+		},
+		{
+			name:    "NVML fails",
+			nvmlErr: errors.New("Not Found"),
+			want:    []string{"65", skipDCGMValue, skipDCGMValue, skipDCGMValue, skipDCGMValue},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+			mockNVML.EXPECT().GetDeviceValues(gpuUUID, []nvmlprovider.DeviceField{
+				nvmlprovider.FanSpeed, nvmlprovider.PowerUsage, nvmlprovider.EncoderUtilization,
+			}).Return(tt.nvmlValue, tt.nvmlErr)
+
+			realNVML := nvmlprovider.Client()
+			defer nvmlprovider.SetClient(realNVML)
+			nvmlprovider.SetClient(mockNVML)
+
+			got := withNVMLFallback(gpuUUID, values)
+
+			gotStrings := make([]string, 0, len(got))
+			for _, val := range got {
+				gotStrings = append(gotStrings, toString(val))
+			}
+			assert.Equal(t, tt.want, gotStrings)
+			assert.Equal(t, skipDCGMValue, toString(values[1]), "the values of DCGM are not modified")
+		})
+	}
+}
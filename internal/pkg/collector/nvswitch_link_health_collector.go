@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// nvswitchLinkHealthCollector exports the health of the ports of every NVSwitch as the ratio (0 to 1) of its enabled
+// ports, which are up. Ports, which are not supported or disabled, are not counted, so a fully connected switch
+// reports 1, and a switch, which lost ports, reports less. The errors of the ports are exported by the NVLink fields
+// of the counters file.
+type nvswitchLinkHealthCollector struct {
+	baseExpCollector
+}
+
+func (c *nvswitchLinkHealthCollector) GetMetrics() (MetricsByCounter, error) {
+	links, err := dcgmprovider.Client().GetNvLinkLinkStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := map[uint]int{}
+	up := map[uint]int{}
+	for _, link := range links {
+		if link.ParentType != dcgm.FE_SWITCH ||
+			link.State == dcgm.LS_NOT_SUPPORTED || link.State == dcgm.LS_DISABLED {
+			continue
+		}
+		enabled[link.ParentId]++
+		if link.State == dcgm.LS_UP {
+			up[link.ParentId]++
+		}
+	}
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0, len(enabled))
+
+	switches := make([]uint, 0, len(enabled))
+	for id := range enabled {
+		switches = append(switches, id)
+	}
+	slices.Sort(switches)
+
+	for _, id := range switches {
+		switchID := strconv.FormatUint(uint64(id), 10)
+		labels := map[string]string{switchIDLabel: switchID}
+		if name, value, ok := hostengineLabel(c.config); ok {
+			labels[name] = value
+		}
+
+		metrics[c.counter] = append(metrics[c.counter], Metric{
+			Counter:    c.counter,
+			Value:      strconv.FormatFloat(float64(up[id])/float64(enabled[id]), 'f', -1, 64),
+			GPU:        switchID,
+			GPUDevice:  fmt.Sprintf("nvswitch%d", id),
+			Hostname:   c.hostname,
+			Labels:     labels,
+			Attributes: map[string]string{},
+		})
+	}
+
+	return metrics, nil
+}
+
+func NewNVSwitchLinkHealthCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpNVSwitchLinkHealthEnabled(counterList) {
+		slog.Error(counters.DCGMExpNVSwitchLinkHealth+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpNVSwitchLinkHealth))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpNVSwitchLinkHealth)
+	}
+
+	return &nvswitchLinkHealthCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpNVSwitchLinkHealth
+			})],
+			hostname: hostname,
+			config:   config,
+		},
+	}, nil
+}
+
+func IsDCGMExpNVSwitchLinkHealthEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpNVSwitchLinkHealth
+	})
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func TestNVSwitchLinkHealthCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	link := func(sw, index uint, state dcgm.Link_State) dcgm.NvLinkStatus {
+		return dcgm.NvLinkStatus{ParentId: sw, ParentType: dcgm.FE_SWITCH, State: state, Index: index}
+	}
+
+	// Switch 0 lost one of its four enabled ports; switch 1 is fully connected; GPU links are ignored
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().GetNvLinkLinkStatus().Return([]dcgm.NvLinkStatus{
+		link(0, 0, dcgm.LS_UP), link(0, 1, dcgm.LS_UP), link(0, 2, dcgm.LS_DOWN), link(0, 3, dcgm.LS_UP),
+		link(0, 4, dcgm.LS_DISABLED), link(0, 5, dcgm.LS_NOT_SUPPORTED),
+		link(1, 0, dcgm.LS_UP), link(1, 1, dcgm.LS_UP),
+		{ParentId: 0, ParentType: dcgm.FE_GPU, State: dcgm.LS_DOWN, Index: 0},
+	}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	health := counters.Counter{FieldName: counters.DCGMExpNVSwitchLinkHealth, PromType: "gauge"}
+
+	c, err := NewNVSwitchLinkHealthCollector(counters.CounterList{health}, "testhost", &appconfig.Config{},
+		devicewatchlistmanager.WatchList{})
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	require.Len(t, metrics[health], 2)
+
+	assert.Equal(t, "0", metrics[health][0].GPU)
+	assert.Equal(t, "0", metrics[health][0].Labels[switchIDLabel])
+	assert.Equal(t, "0.75", metrics[health][0].Value)
+	assert.Equal(t, "testhost", metrics[health][0].Hostname)
+
+	assert.Equal(t, "1", metrics[health][1].GPU)
+	assert.Equal(t, "1", metrics[health][1].Value)
+}
+
+func TestSwitchLabels(t *testing.T) {
+	assert.Equal(t, map[string]string{switchIDLabel: "2"}, switchLabels(devicemonitoring.Info{
+		Entity:   dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_SWITCH, EntityId: 2},
+		ParentId: devicemonitoring.PARENT_ID_IGNORED,
+	}))
+	assert.Equal(t, map[string]string{switchIDLabel: "2", portLabel: "17"}, switchLabels(devicemonitoring.Info{
+		Entity:   dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_LINK, EntityId: 17},
+		ParentId: 2,
+	}))
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	// pcieErrorsSourceLabel tells whether a PCIe error counter was read from DCGM or from NVML
+	pcieErrorsSourceLabel = "source"
+
+	pcieErrorsSourceDCGM = "dcgm"
+	pcieErrorsSourceNVML = "nvml"
+)
+
+// pcieErrorsCounters are the exporter counters computed by the pcieErrorsCollector
+var pcieErrorsCounters = []string{
+	counters.DCGMExpPCIeReplayCounter,
+	counters.DCGMExpPCIeCorrectableErrors,
+}
+
+// pcieErrorsFields are the DCGM fields, which are preferred over NVML when they have a value
+var pcieErrorsFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER,
+}
+
+// pcieErrorsCollector reports the PCIe replay counter and the number of correctable PCIe errors. Some driver and
+// DCGM versions return blank PCIe replay values, although NVML reports them, so the replay counter is read from
+// NVML when DCGM has no value. DCGM has no field for correctable PCIe errors, so they are always read from NVML.
+// The source label tells which of the two a value was read from.
+type pcieErrorsCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+}
+
+func (c *pcieErrorsCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		values, err := c.pcieErrorValues(mi.DeviceInfo.GPU, mi.DeviceInfo.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for name, value := range values {
+			counter := c.enabled[name]
+
+			metricLabels := maps.Clone(labels)
+			metricLabels[pcieErrorsSourceLabel] = value.source
+
+			m := c.createMetric(metricLabels, gpuInfo, uuid, value.value)
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// pcieErrorValue is the value of a PCIe error counter and where it was read from
+type pcieErrorValue struct {
+	value  int
+	source string
+}
+
+// pcieErrorValues reads the enabled PCIe error counters of a GPU. Counters, which neither DCGM nor NVML
+// have a value for, are omitted.
+func (c *pcieErrorsCollector) pcieErrorValues(gpu uint, uuid string) (map[string]pcieErrorValue, error) {
+	values := map[string]pcieErrorValue{}
+
+	if _, exists := c.enabled[counters.DCGMExpPCIeReplayCounter]; exists {
+		latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, gpu, pcieErrorsFields)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, val := range latestValues {
+			if dcgm.Short(val.FieldId) != dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER || val.FieldType != dcgm.DCGM_FT_INT64 ||
+				toString(val) == skipDCGMValue {
+				continue
+			}
+			values[counters.DCGMExpPCIeReplayCounter] = pcieErrorValue{
+				value:  int(val.Int64()),
+				source: pcieErrorsSourceDCGM,
+			}
+		}
+	}
+
+	_, replayFromDCGM := values[counters.DCGMExpPCIeReplayCounter]
+	_, correctableEnabled := c.enabled[counters.DCGMExpPCIeCorrectableErrors]
+	if replayFromDCGM && !correctableEnabled {
+		return values, nil
+	}
+
+	if nvmlprovider.Client() == nil {
+		return values, nil
+	}
+
+	stats, err := nvmlprovider.Client().GetPCIeErrorStats(uuid)
+	if err != nil {
+		slog.Debug(fmt.Sprintf("Unable to read PCIe error stats for GPU %d", gpu),
+			slog.String(logging.ErrorKey, err.Error()))
+		return values, nil
+	}
+
+	_, replayEnabled := c.enabled[counters.DCGMExpPCIeReplayCounter]
+	if replayEnabled && !replayFromDCGM && stats.ReplayCounterSupported {
+		values[counters.DCGMExpPCIeReplayCounter] = pcieErrorValue{
+			value:  int(stats.ReplayCounter),
+			source: pcieErrorsSourceNVML,
+		}
+	}
+
+	if correctableEnabled && stats.CorrectableErrorsSupported {
+		values[counters.DCGMExpPCIeCorrectableErrors] = pcieErrorValue{
+			value:  int(stats.CorrectableErrors),
+			source: pcieErrorsSourceNVML,
+		}
+	}
+
+	return values, nil
+}
+
+func NewPCIeErrorsCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpPCIeErrorsEnabled(counterList) {
+		slog.Error(counters.DCGMExpPCIeReplayCounter+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpPCIeReplayCounter))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpPCIeReplayCounter)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(pcieErrorsCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields(pcieErrorsFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pcieErrorsCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(pcieErrorsCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled: enabled,
+	}, nil
+}
+
+func IsDCGMExpPCIeErrorsEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(pcieErrorsCounters, c.FieldName)
+	})
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestPCIeErrorsCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(pcieErrorsFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// DCGM reports the replay counter of GPU 0, but returns a blank value for GPU 1
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), pcieErrorsFields).
+		Return([]dcgm.FieldValue_v1{int64FieldValue(dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER, 5)}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), pcieErrorsFields).
+		Return([]dcgm.FieldValue_v1{
+			int64FieldValue(dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER, dcgm.DCGM_FT_INT64_BLANK),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetPCIeErrorStats(gpus[0].DeviceInfo.UUID).Return(&nvmlprovider.PCIeErrorStats{
+		ReplayCounter:              4,
+		ReplayCounterSupported:     true,
+		CorrectableErrors:          11,
+		CorrectableErrorsSupported: true,
+	}, nil)
+	mockNVML.EXPECT().GetPCIeErrorStats(gpus[1].DeviceInfo.UUID).Return(&nvmlprovider.PCIeErrorStats{
+		ReplayCounter:          7,
+		ReplayCounterSupported: true,
+	}, nil)
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	replay := counters.Counter{FieldName: counters.DCGMExpPCIeReplayCounter, PromType: "counter"}
+	correctable := counters.Counter{FieldName: counters.DCGMExpPCIeCorrectableErrors, PromType: "counter"}
+
+	c, err := NewPCIeErrorsCollector(counters.CounterList{replay, correctable}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	// The DCGM value is preferred, and NVML fills in the blank value
+	require.Len(t, metrics[replay], 2)
+	assert.Equal(t, "5", metrics[replay][0].Value)
+	assert.Equal(t, pcieErrorsSourceDCGM, metrics[replay][0].Labels[pcieErrorsSourceLabel])
+	assert.Equal(t, "7", metrics[replay][1].Value)
+	assert.Equal(t, pcieErrorsSourceNVML, metrics[replay][1].Labels[pcieErrorsSourceLabel])
+
+	// Correctable errors are only reported for the GPU, which NVML supports them for
+	require.Len(t, metrics[correctable], 1)
+	assert.Equal(t, "0", metrics[correctable][0].GPU)
+	assert.Equal(t, "11", metrics[correctable][0].Value)
+	assert.Equal(t, pcieErrorsSourceNVML, metrics[correctable][0].Labels[pcieErrorsSourceLabel])
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// powerLimitFields are the DCGM fields, which the power limit state is computed from
+var powerLimitFields = []dcgm.Short{
+	dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT,
+	dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT,
+	dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF,
+}
+
+// powerLimitCounters are the exporter counters computed by the powerLimitCollector
+var powerLimitCounters = []string{
+	counters.DCGMExpPowerLimitCapped,
+	counters.DCGMExpPowerLimitBelowDefault,
+}
+
+// powerLimitCollector reports whether the power limit enforced on a GPU is below the configured power management
+// limit, e.g. because a lower limit is set by the system or a power cap is applied out of band, and whether it is
+// below the default limit of the GPU. Power limits apply to physical GPUs, so the state is reported per GPU, also
+// when MIG is enabled. GPUs, which don't report the limits a state is computed from, are omitted from it.
+type powerLimitCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+}
+
+func (c *powerLimitCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	for _, counter := range c.enabled {
+		metrics[counter] = make([]Metric, 0)
+	}
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, mi.DeviceInfo.GPU,
+			powerLimitFields)
+		if err != nil {
+			return nil, err
+		}
+
+		states := powerLimitStates(latestValues)
+		if len(states) == 0 {
+			continue
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for name, state := range states {
+			counter, exists := c.enabled[name]
+			if !exists {
+				continue
+			}
+
+			m := c.createMetric(maps.Clone(labels), gpuInfo, uuid, boolToInt(state))
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// powerLimitStates maps the power limit counters, whose limits have values, to their state.
+func powerLimitStates(values []dcgm.FieldValue_v1) map[string]bool {
+	states := map[string]bool{}
+	if capped, ok := powerLimitCapped(values); ok {
+		states[counters.DCGMExpPowerLimitCapped] = capped
+	}
+	if belowDefault, ok := powerLimitBelowDefault(values); ok {
+		states[counters.DCGMExpPowerLimitBelowDefault] = belowDefault
+	}
+	return states
+}
+
+// powerLimitCapped reports whether the enforced power limit is below the configured power management limit.
+// ok is false, when either limit has no value.
+func powerLimitCapped(values []dcgm.FieldValue_v1) (capped, ok bool) {
+	return enforcedPowerLimitBelow(values, dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT)
+}
+
+// powerLimitBelowDefault reports whether the enforced power limit is below the default power management limit.
+// ok is false, when either limit has no value.
+func powerLimitBelowDefault(values []dcgm.FieldValue_v1) (below, ok bool) {
+	return enforcedPowerLimitBelow(values, dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF)
+}
+
+// enforcedPowerLimitBelow reports whether the enforced power limit is below the limit of the field.
+func enforcedPowerLimitBelow(values []dcgm.FieldValue_v1, limitField dcgm.Short) (below, ok bool) {
+	limits := map[dcgm.Short]float64{}
+	for _, val := range values {
+		if val.FieldType != dcgm.DCGM_FT_DOUBLE || toString(val) == skipDCGMValue {
+			continue
+		}
+		limits[dcgm.Short(val.FieldId)] = val.Float64()
+	}
+
+	limit, hasLimit := limits[limitField]
+	enforced, hasEnforced := limits[dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT]
+	if !hasLimit || !hasEnforced {
+		return false, false
+	}
+
+	return enforced < limit, true
+}
+
+func NewPowerLimitCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpPowerLimitCappedEnabled(counterList) {
+		slog.Error(counters.DCGMExpPowerLimitCapped+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpPowerLimitCapped))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpPowerLimitCapped)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(powerLimitCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields(powerLimitFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &powerLimitCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(powerLimitCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled: enabled,
+	}, nil
+}
+
+func IsDCGMExpPowerLimitCappedEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(powerLimitCounters, c.FieldName)
+	})
+}
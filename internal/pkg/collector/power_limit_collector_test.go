@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func float64FieldValue(fieldID dcgm.Short, value float64) dcgm.FieldValue_v1 {
+	fieldValue := [4096]byte{}
+	binary.LittleEndian.PutUint64(fieldValue[:], math.Float64bits(value))
+	return dcgm.FieldValue_v1{
+		FieldId:   uint(fieldID),
+		FieldType: dcgm.DCGM_FT_DOUBLE,
+		Value:     fieldValue,
+	}
+}
+
+func Test_powerLimitCapped(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []dcgm.FieldValue_v1
+		wantCapped bool
+		wantOK     bool
+	}{
+		{
+			name: "Enforced equals configured",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, 700),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 700),
+			},
+			wantOK: true,
+		},
+		{
+			name: "Enforced below configured",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, 700),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 500),
+			},
+			wantCapped: true,
+			wantOK:     true,
+		},
+		{
+			name: "Enforced limit not supported",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, 700),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, dcgm.DCGM_FT_FP64_NOT_SUPPORTED),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capped, ok := powerLimitCapped(tt.values)
+			assert.Equal(t, tt.wantCapped, capped)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func Test_powerLimitBelowDefault(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []dcgm.FieldValue_v1
+		wantBelow bool
+		wantOK    bool
+	}{
+		{
+			name: "Enforced equals default",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF, 700),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 700),
+			},
+			wantOK: true,
+		},
+		{
+			name: "Enforced below default",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF, 700),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 600),
+			},
+			wantBelow: true,
+			wantOK:    true,
+		},
+		{
+			name: "Default limit not supported",
+			values: []dcgm.FieldValue_v1{
+				float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF, dcgm.DCGM_FT_FP64_NOT_SUPPORTED),
+				float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 600),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			below, ok := powerLimitBelowDefault(tt.values)
+			assert.Equal(t, tt.wantBelow, below)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestPowerLimitCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(powerLimitFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), powerLimitFields).
+		Return([]dcgm.FieldValue_v1{
+			float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, 700),
+			float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 700),
+			float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF, 700),
+		}, nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(1), powerLimitFields).
+		Return([]dcgm.FieldValue_v1{
+			float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, 700),
+			float64FieldValue(dcgm.DCGM_FI_DEV_ENFORCED_POWER_LIMIT, 450),
+			float64FieldValue(dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT_DEF, dcgm.DCGM_FT_FP64_NOT_SUPPORTED),
+		}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	capped := counters.Counter{FieldName: counters.DCGMExpPowerLimitCapped, PromType: "gauge"}
+	belowDefault := counters.Counter{FieldName: counters.DCGMExpPowerLimitBelowDefault, PromType: "gauge"}
+
+	c, err := NewPowerLimitCollector(counters.CounterList{capped, belowDefault}, "testhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[capped], 2)
+	assert.Equal(t, "0", metrics[capped][0].GPU)
+	assert.Equal(t, "0", metrics[capped][0].Value)
+	assert.Equal(t, "1", metrics[capped][1].GPU)
+	assert.Equal(t, "1", metrics[capped][1].Value)
+
+	// GPU 1 doesn't report its default limit
+	require.Len(t, metrics[belowDefault], 1)
+	assert.Equal(t, "0", metrics[belowDefault][0].GPU)
+	assert.Equal(t, "0", metrics[belowDefault][0].Value)
+	assert.Equal(t, counters.DCGMExpPowerLimitBelowDefault, metrics[belowDefault][0].Counter.FieldName)
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	processPidLabel  = "pid"
+	processNameLabel = "process_name"
+)
+
+// processCounters are the exporter counters computed by the processCollector
+var processCounters = []string{
+	counters.DCGMExpProcessMemUsed,
+	counters.DCGMExpProcessSMUtil,
+}
+
+// processCollector reports the used memory (in MiB) and the SM utilization (in %) of every compute and graphics
+// process running on a GPU, labeled with its PID and its name, so that the GPU usage can be attributed to processes
+// on nodes without Kubernetes. DCGM only reports processes, which are known in advance, so they are read from NVML.
+// Processes are reported per physical GPU, also when MIG is enabled. The used memory is omitted, when NVML can not
+// read it, and the SM utilization, when the process was not sampled since the previous scrape.
+type processCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+
+	// lastSeen holds the timestamp of the latest utilization sample per GPU UUID, so that every scrape
+	// only considers the samples taken since the previous one
+	lastSeen map[string]uint64
+	mtx      sync.Mutex
+}
+
+func (c *processCollector) GetMetrics() (MetricsByCounter, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	if nvmlprovider.Client() == nil {
+		return metrics, nil
+	}
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		processes, err := nvmlprovider.Client().GetProcesses(mi.DeviceInfo.UUID, c.lastSeen[mi.DeviceInfo.UUID])
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Unable to read processes for GPU %d", mi.DeviceInfo.GPU),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+		c.lastSeen[mi.DeviceInfo.UUID] = processes.LastSeenTimestamp
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, process := range processes.Processes {
+			values := map[string]int{}
+			if process.UsedMemorySupported {
+				values[counters.DCGMExpProcessMemUsed] = int(process.UsedMemory >> 20)
+			}
+			if process.SMUtilizationSampled {
+				values[counters.DCGMExpProcessSMUtil] = int(process.SMUtilization)
+			}
+
+			for name, value := range values {
+				counter, exists := c.enabled[name]
+				if !exists {
+					continue
+				}
+
+				metricLabels := maps.Clone(labels)
+				metricLabels[processPidLabel] = strconv.FormatUint(uint64(process.Pid), 10)
+				metricLabels[processNameLabel] = process.Name
+
+				m := c.createMetric(metricLabels, gpuInfo, uuid, value)
+				m.Counter = counter
+				metrics[counter] = append(metrics[counter], m)
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+func NewProcessCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpProcessEnabled(counterList) {
+		slog.Error(counters.DCGMExpProcessMemUsed+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpProcessMemUsed))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpProcessMemUsed)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(processCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	return &processCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(processCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+		},
+		enabled:  enabled,
+		lastSeen: map[string]uint64{},
+	}, nil
+}
+
+func IsDCGMExpProcessEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(processCounters, c.FieldName)
+	})
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestProcessCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	gomock.InOrder(
+		mockNVML.EXPECT().GetProcesses(gpus[0].DeviceInfo.UUID, uint64(0)).Return(&nvmlprovider.GPUProcesses{
+			Processes: []nvmlprovider.GPUProcess{
+				{
+					Pid: 1234, Name: "python3", UsedMemory: 2048 << 20, UsedMemorySupported: true,
+					SMUtilization: 87, SMUtilizationSampled: true,
+				},
+				{Pid: 5678, Name: "Xorg", UsedMemory: 16 << 20, UsedMemorySupported: true},
+			},
+			LastSeenTimestamp: 100,
+		}, nil),
+		// Only the samples since the previous scrape are read
+		mockNVML.EXPECT().GetProcesses(gpus[0].DeviceInfo.UUID, uint64(100)).Return(&nvmlprovider.GPUProcesses{
+			LastSeenTimestamp: 100,
+		}, nil),
+	)
+	mockNVML.EXPECT().GetProcesses(gpus[1].DeviceInfo.UUID, uint64(0)).Return(nil, errors.New("GPU is lost")).
+		Times(2)
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	memUsed := counters.Counter{FieldName: counters.DCGMExpProcessMemUsed, PromType: "gauge"}
+	smUtil := counters.Counter{FieldName: counters.DCGMExpProcessSMUtil, PromType: "gauge"}
+
+	c, err := NewProcessCollector(counters.CounterList{memUsed, smUtil}, "testhost", &appconfig.Config{},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[memUsed], 2)
+	assert.Equal(t, "0", metrics[memUsed][0].GPU)
+	assert.Equal(t, "2048", metrics[memUsed][0].Value)
+	assert.Equal(t, "1234", metrics[memUsed][0].Labels[processPidLabel])
+	assert.Equal(t, "python3", metrics[memUsed][0].Labels[processNameLabel])
+	assert.Equal(t, "16", metrics[memUsed][1].Value)
+	assert.Equal(t, "5678", metrics[memUsed][1].Labels[processPidLabel])
+
+	require.Len(t, metrics[smUtil], 1, "processes, which were not sampled, are omitted")
+	assert.Equal(t, "87", metrics[smUtil][0].Value)
+	assert.Equal(t, "1234", metrics[smUtil][0].Labels[processPidLabel])
+
+	metrics, err = c.GetMetrics()
+	require.NoError(t, err)
+	assert.Empty(t, metrics)
+}
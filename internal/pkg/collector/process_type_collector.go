@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+var (
+	computeProcessCountCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMComputeProcessCount),
+		FieldName: counters.DCGMExpComputeProcessCount,
+		PromType:  "gauge",
+		Help:      "Number of processes with a compute context.",
+	}
+
+	graphicsProcessCountCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMGraphicsProcessCount),
+		FieldName: counters.DCGMExpGraphicsProcessCount,
+		PromType:  "gauge",
+		Help:      "Number of processes with a graphics context.",
+	}
+
+	computeProcessUtilCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMComputeProcessUtil),
+		FieldName: counters.DCGMExpComputeProcessUtil,
+		PromType:  "gauge",
+		Help:      "SM utilization of processes with a compute context (in %).",
+	}
+
+	graphicsProcessUtilCounter = counters.Counter{
+		FieldID:   dcgm.Short(counters.DCGMGraphicsProcessUtil),
+		FieldName: counters.DCGMExpGraphicsProcessUtil,
+		PromType:  "gauge",
+		Help:      "SM utilization of processes with a graphics context (in %).",
+	}
+)
+
+// processTypeCollector reads the number of compute and graphics processes and their SM utilization
+// through NVML. The values are only available for physical GPUs, so GPU instances are reported as their
+// parent GPU.
+type processTypeCollector struct {
+	baseExpCollector
+
+	// lastSeen holds the timestamp of the latest utilization sample per GPU UUID, so that every scrape
+	// only considers the samples taken since the previous one
+	lastSeen map[string]uint64
+	mtx      sync.Mutex
+}
+
+func (c *processTypeCollector) GetMetrics() (MetricsByCounter, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		stats, err := nvmlprovider.Client().GetProcessTypeStats(mi.DeviceInfo.UUID, c.lastSeen[mi.DeviceInfo.UUID])
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Unable to read process type stats for GPU %d", mi.DeviceInfo.GPU),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+		c.lastSeen[mi.DeviceInfo.UUID] = stats.LastSeenTimestamp
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		values := map[counters.Counter]int{
+			computeProcessCountCounter:  stats.ComputeProcessCount,
+			graphicsProcessCountCounter: stats.GraphicsProcessCount,
+			computeProcessUtilCounter:   int(stats.ComputeUtilization),
+			graphicsProcessUtilCounter:  int(stats.GraphicsUtilization),
+		}
+
+		for counter, val := range values {
+			counter = counter.WithPrefix(c.config.MetricPrefix)
+			m := c.createMetric(labels, gpuInfo, uuid, val)
+			m.Counter = counter
+			metrics[counter] = append(metrics[counter], m)
+		}
+	}
+
+	return metrics, nil
+}
+
+func NewProcessTypeCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !config.CollectProcessTypes {
+		slog.Error(counters.DCGMExpComputeProcessCount+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpComputeProcessCount))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpComputeProcessCount)
+	}
+
+	if nvmlprovider.Client() == nil {
+		return nil, fmt.Errorf("NVML provider is not initialized")
+	}
+
+	return &processTypeCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter:         computeProcessCountCounter,
+			labelsCounters:  counterList.LabelCounters(),
+			hostname:        hostname,
+			config:          config,
+		},
+		lastSeen: map[string]uint64{},
+	}, nil
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestProcessTypeCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{
+			DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"},
+		},
+	}
+
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	gomock.InOrder(
+		mockNVML.EXPECT().GetProcessTypeStats(gpus[0].DeviceInfo.UUID, uint64(0)).
+			Return(&nvmlprovider.ProcessTypeStats{
+				ComputeProcessCount:  2,
+				GraphicsProcessCount: 1,
+				ComputeUtilization:   60,
+				GraphicsUtilization:  15,
+				LastSeenTimestamp:    1000,
+			}, nil),
+		// The next scrape only considers the samples taken after the previous one
+		mockNVML.EXPECT().GetProcessTypeStats(gpus[0].DeviceInfo.UUID, uint64(1000)).
+			Return(&nvmlprovider.ProcessTypeStats{LastSeenTimestamp: 1000}, nil),
+	)
+
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVML)
+
+	config := &appconfig.Config{CollectProcessTypes: true}
+	c, err := NewProcessTypeCollector(nil, "testhost", config,
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+	require.Len(t, metrics, 4)
+
+	expected := map[string]string{
+		computeProcessCountCounter.FieldName:  "2",
+		graphicsProcessCountCounter.FieldName: "1",
+		computeProcessUtilCounter.FieldName:   "60",
+		graphicsProcessUtilCounter.FieldName:  "15",
+	}
+
+	for counter, values := range metrics {
+		require.Len(t, values, 1)
+		assert.Equal(t, expected[counter.FieldName], values[0].Value)
+		assert.Equal(t, gpus[0].DeviceInfo.UUID, values[0].GPUUUID)
+	}
+
+	metrics, err = c.GetMetrics()
+	require.NoError(t, err)
+	for _, values := range metrics {
+		require.Len(t, values, 1)
+		assert.Equal(t, "0", values[0].Value)
+	}
+}
@@ -17,11 +17,10 @@
 package collector
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 
-	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
 )
 
@@ -33,6 +32,12 @@ type Collector interface {
 	Cleanup()
 }
 
+// IntervalSetter is implemented by collectors, which can change the update frequency of the watched fields
+// without being recreated.
+type IntervalSetter interface {
+	SetCollectInterval(collectInterval int64) error
+}
+
 type EntityCollectorTuple struct {
 	entity    dcgm.Field_Entity_Group
 	collector Collector
@@ -68,24 +73,20 @@ type Metric struct {
 
 	MigProfile    string
 	GPUInstanceID string
-	Hostname      string
+	// ComputeInstanceID is the ID of the compute instance within the GPU instance, for the metrics of compute instances
+	ComputeInstanceID string
+	// VGPUID is the ID of the vGPU instance, which runs on the GPU, for the metrics of vGPUs
+	VGPUID   string
+	Hostname string
 
 	Labels     map[string]string
 	Attributes map[string]string
-}
 
-func (m Metric) GetIDOfType(idType appconfig.KubernetesGPUIDType) (string, error) {
-	// For MIG devices, return the MIG profile instead of
-	if m.MigProfile != "" {
-		return fmt.Sprintf("%s-%s", m.GPU, m.GPUInstanceID), nil
-	}
-	switch idType {
-	case appconfig.GPUUID:
-		return m.GPUUUID, nil
-	case appconfig.DeviceName:
-		return m.GPUDevice, nil
-	}
-	return "", fmt.Errorf("unsupported KubernetesGPUIDType for MetricID '%s'", idType)
+	// Timestamp is the time of the sample, when the collector knows it, e.g. the timestamp of the DCGM field value
+	Timestamp time.Time
+	// Rate is the per-second rate of the value, which the registry computes for the counters with a rate view; it is
+	// empty, until the rate is known
+	Rate string
 }
 
 // MetricsByCounter represents a map where each Counter is associated with a slice of Metric objects
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	versionLabel        = "version"
+	goVersionLabel      = "go_version"
+	dcgmVersionLabel    = "dcgm_version"
+	driverVersionLabel  = "driver_version"
+	cudaVersionLabel    = "cuda_version"
+	hostengineModeLabel = "hostengine_mode"
+
+	// hostengineModeEmbedded, hostengineModeStandalone and hostengineModeNVML are the modes, the exporter reads the
+	// fields in: from the embedded DCGM, from a remote hostengine or from NVML only
+	hostengineModeEmbedded   = "embedded"
+	hostengineModeStandalone = "standalone"
+	hostengineModeNVML       = "nvml"
+)
+
+// systemInfoFields are the DCGM fields with the versions of the driver and of CUDA
+var systemInfoFields = []dcgm.Short{
+	dcgm.DCGM_FI_DRIVER_VERSION,
+	dcgm.DCGM_FI_CUDA_DRIVER_VERSION,
+}
+
+// versionInfoCollector exports an info metric per GPU, which carries versions as labels, for inventory dashboards.
+// The versions are read once, so they are refreshed, when the collectors are created again on a reload.
+type versionInfoCollector struct {
+	baseExpCollector
+	// versions returns the version labels of the GPU, and whether all of them are known
+	versions func(gpu uint) (map[string]string, bool, error)
+	// known are the version labels by GPU, once all of them are known
+	known map[uint]map[string]string
+}
+
+func (c *versionInfoCollector) GetMetrics() (MetricsByCounter, error) {
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	metrics[c.counter] = make([]Metric, 0)
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		versions, known := c.known[mi.DeviceInfo.GPU]
+		if !known {
+			var err error
+			versions, known, err = c.versions(mi.DeviceInfo.GPU)
+			if err != nil {
+				return nil, err
+			}
+			if known {
+				c.known[mi.DeviceInfo.GPU] = versions
+			}
+		}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		metrics[c.counter] = append(metrics[c.counter], c.createMetric(maps.Clone(versions), gpuInfo, uuid, 1))
+	}
+
+	return metrics, nil
+}
+
+// hostengineMode returns the mode, the exporter reads the fields in.
+func hostengineMode(config *appconfig.Config) string {
+	switch {
+	case config.NVMLOnly:
+		return hostengineModeNVML
+	case config.UseRemoteHE:
+		return hostengineModeStandalone
+	default:
+		return hostengineModeEmbedded
+	}
+}
+
+// systemVersions reads the versions of the driver and of CUDA of the GPU. The CUDA version is reported by DCGM as
+// major * 1000 + minor * 10, e.g. 12040 for 12.4.
+func systemVersions(gpu uint, labels map[string]string) (map[string]string, bool, error) {
+	latestValues, err := dcgmprovider.Client().EntityGetLatestValues(dcgm.FE_GPU, gpu, systemInfoFields)
+	if err != nil {
+		return nil, false, err
+	}
+
+	versions := maps.Clone(labels)
+	versions[driverVersionLabel] = ""
+	versions[cudaVersionLabel] = ""
+	for _, val := range latestValues {
+		if toString(val) == skipDCGMValue {
+			continue
+		}
+
+		switch dcgm.Short(val.FieldId) {
+		case dcgm.DCGM_FI_DRIVER_VERSION:
+			versions[driverVersionLabel] = toString(val)
+		case dcgm.DCGM_FI_CUDA_DRIVER_VERSION:
+			cuda := val.Int64()
+			versions[cudaVersionLabel] = fmt.Sprintf("%d.%d", cuda/1000, cuda%1000/10)
+		}
+	}
+
+	return versions, versions[driverVersionLabel] != "" && versions[cudaVersionLabel] != "", nil
+}
+
+func NewBuildInfoCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpBuildInfoEnabled(counterList) {
+		slog.Error(counters.DCGMExpBuildInfo+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpBuildInfo))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpBuildInfo)
+	}
+
+	labels := map[string]string{
+		versionLabel:   config.Version,
+		goVersionLabel: runtime.Version(),
+	}
+
+	return &versionInfoCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpBuildInfo
+			})],
+			hostname: hostname,
+			config:   config,
+		},
+		versions: func(uint) (map[string]string, bool, error) {
+			return labels, true, nil
+		},
+		known: map[uint]map[string]string{},
+	}, nil
+}
+
+func IsDCGMExpBuildInfoEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpBuildInfo
+	})
+}
+
+func NewSystemInfoCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpSystemInfoEnabled(counterList) {
+		slog.Error(counters.DCGMExpSystemInfo+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpSystemInfo))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpSystemInfo)
+	}
+
+	deviceWatchList.SetDeviceFields(systemInfoFields)
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		dcgmVersionLabel:    dcgmprovider.LibraryVersion(),
+		hostengineModeLabel: hostengineMode(config),
+	}
+
+	return &versionInfoCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return c.FieldName == counters.DCGMExpSystemInfo
+			})],
+			hostname: hostname,
+			config:   config,
+			cleanups: cleanups,
+		},
+		versions: func(gpu uint) (map[string]string, bool, error) {
+			return systemVersions(gpu, labels)
+		},
+		known: map[uint]map[string]string{},
+	}, nil
+}
+
+func IsDCGMExpSystemInfoEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return c.FieldName == counters.DCGMExpSystemInfo
+	})
+}
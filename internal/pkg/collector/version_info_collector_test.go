@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+)
+
+func newVersionInfoDeviceInfo(ctrl *gomock.Controller, gpus []deviceinfo.GPUInfo) *mockdeviceinfo.MockProvider {
+	mockDeviceInfo := newMockGPUDeviceInfo(ctrl, gpus)
+	return mockDeviceInfo
+}
+
+func TestBuildInfoCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := newVersionInfoDeviceInfo(ctrl, []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	})
+
+	buildInfo := counters.Counter{FieldName: counters.DCGMExpBuildInfo, PromType: "gauge"}
+
+	c, err := NewBuildInfoCollector(counters.CounterList{buildInfo}, "testhost",
+		&appconfig.Config{Version: "4.1.1-4.0.4"},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1))
+	require.NoError(t, err)
+
+	metrics, err := c.GetMetrics()
+	require.NoError(t, err)
+
+	require.Len(t, metrics[buildInfo], 2)
+	for i, metric := range metrics[buildInfo] {
+		assert.Equal(t, []string{"0", "1"}[i], metric.GPU)
+		assert.Equal(t, "1", metric.Value)
+		assert.Equal(t, "4.1.1-4.0.4", metric.Labels[versionLabel])
+		assert.Equal(t, runtime.Version(), metric.Labels[goVersionLabel])
+	}
+}
+
+func TestSystemInfoCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := newVersionInfoDeviceInfo(ctrl, []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+	})
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(systemInfoFields, mockDeviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, nil)
+
+	// The CUDA version isn't available on the first collection, and the versions are read only until they are known
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	gomock.InOrder(
+		mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), systemInfoFields).
+			Return([]dcgm.FieldValue_v1{
+				stringFieldValue(dcgm.DCGM_FI_DRIVER_VERSION, "550.90.07"),
+				int64FieldValue(dcgm.DCGM_FI_CUDA_DRIVER_VERSION, dcgm.DCGM_FT_INT64_BLANK),
+			}, nil),
+		mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), systemInfoFields).
+			Return([]dcgm.FieldValue_v1{
+				stringFieldValue(dcgm.DCGM_FI_DRIVER_VERSION, "550.90.07"),
+				int64FieldValue(dcgm.DCGM_FI_CUDA_DRIVER_VERSION, 12040),
+			}, nil),
+	)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	systemInfo := counters.Counter{FieldName: counters.DCGMExpSystemInfo, PromType: "gauge"}
+
+	c, err := NewSystemInfoCollector(counters.CounterList{systemInfo}, "testhost",
+		&appconfig.Config{UseRemoteHE: true},
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	wantCUDAVersions := []string{"", "12.4", "12.4"}
+	for _, wantCUDAVersion := range wantCUDAVersions {
+		metrics, err := c.GetMetrics()
+		require.NoError(t, err)
+
+		require.Len(t, metrics[systemInfo], 1)
+		assert.Equal(t, "1", metrics[systemInfo][0].Value)
+		assert.Equal(t, "550.90.07", metrics[systemInfo][0].Labels[driverVersionLabel])
+		assert.Equal(t, wantCUDAVersion, metrics[systemInfo][0].Labels[cudaVersionLabel])
+		assert.Equal(t, hostengineModeStandalone, metrics[systemInfo][0].Labels[hostengineModeLabel])
+		assert.Contains(t, metrics[systemInfo][0].Labels, dcgmVersionLabel)
+	}
+}
+
+func TestHostengineMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		config appconfig.Config
+		want   string
+	}{
+		{name: "Embedded", want: hostengineModeEmbedded},
+		{name: "Remote hostengine", config: appconfig.Config{UseRemoteHE: true}, want: hostengineModeStandalone},
+		{name: "NVML only", config: appconfig.Config{NVMLOnly: true}, want: hostengineModeNVML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hostengineMode(&tt.config))
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 )
 
 type xidCollector struct {
@@ -43,8 +44,9 @@ func NewXIDCollector(
 	deviceWatchList devicewatchlistmanager.WatchList,
 ) (Collector, error) {
 	if !IsDCGMExpXIDErrorsCountEnabled(counterList) {
-		slog.Error(counters.DCGMExpXIDErrorsCount + " collector is disabled")
-		return nil, fmt.Errorf(counters.DCGMExpXIDErrorsCount + " collector is disabled")
+		slog.Error(counters.DCGMExpXIDErrorsCount+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpXIDErrorsCount))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpXIDErrorsCount)
 	}
 
 	collector := xidCollector{}
@@ -155,7 +155,7 @@ func TestNewXIDCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, fmt.Errorf("some error"))
 			},
@@ -182,7 +182,7 @@ func TestNewXIDCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, nil)
 			},
@@ -221,7 +221,7 @@ func TestNewXIDCollector(t *testing.T) {
 			},
 			conditions: func(watcher *mockdevicewatcher.MockWatcher) {
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return(nil,
+					gomock.Any(), gomock.Any()).Return(nil,
 					dcgm.FieldHandle{},
 					sampleCleanups, nil)
 			},
@@ -416,7 +416,7 @@ func Test_xidCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -471,7 +471,7 @@ func Test_xidCollector_GetMetrics(t *testing.T) {
 				}
 
 				watcher.EXPECT().WatchDeviceFields(gomock.Any(), gomock.Any(),
-					gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
+					gomock.Any(), gomock.Any()).Return([]dcgm.GroupHandle{mockGroupHandle1},
 					mockFieldGroupHandle,
 					mockCleanups, nil)
 
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// xidTotalCounters are the exporter counters computed by the xidTotalCollector
+var xidTotalCounters = []string{
+	counters.DCGMExpXIDErrorsTotal,
+	counters.DCGMExpXIDLastSeenTimestamp,
+}
+
+// xidID identifies an XID error code of a GPU.
+type xidID struct {
+	gpu uint
+	xid int64
+}
+
+// xidTotalCollector exports the number of XID errors per GPU and XID code since the exporter started, and the time
+// every XID code was last seen, in seconds since the epoch. Unlike DCGM_EXP_XID_ERRORS_COUNT, which counts the errors
+// within a sliding window, the total only grows, so that increase() alerts on specific failure classes. Every update
+// of the XID field is read once, from the timestamp returned by the previous read.
+type xidTotalCollector struct {
+	baseExpCollector
+	enabled map[string]counters.Counter
+
+	// since is the timestamp of the next update of the XID field to read, per device group
+	since    map[dcgm.GroupHandle]time.Time
+	start    time.Time
+	totals   map[xidID]int
+	lastSeen map[xidID]int64
+	mtx      sync.Mutex
+}
+
+func (c *xidTotalCollector) GetMetrics() (MetricsByCounter, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	err := dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range c.deviceWatchList.DeviceGroups() {
+		since, exists := c.since[group]
+		if !exists {
+			since = c.start
+		}
+
+		values, next, err := dcgmprovider.Client().GetValuesSince(group, c.deviceWatchList.DeviceFieldGroup(), since)
+		if err != nil {
+			return nil, err
+		}
+		c.since[group] = next
+
+		c.observe(values)
+	}
+
+	monitoringInfo := devicemonitoring.GetMonitoredEntities(c.deviceWatchList.DeviceInfo())
+
+	metrics := make(MetricsByCounter)
+	for _, counter := range c.enabled {
+		metrics[counter] = make([]Metric, 0)
+	}
+
+	uuid := "UUID"
+	if c.config.UseOldNamespace {
+		uuid = "uuid"
+	}
+
+	seenGPUs := map[uint]struct{}{}
+
+	for _, mi := range monitoringInfo {
+		// XID errors are reported per physical GPU, also when MIG is enabled
+		if _, exists := seenGPUs[mi.DeviceInfo.GPU]; exists {
+			continue
+		}
+		seenGPUs[mi.DeviceInfo.GPU] = struct{}{}
+
+		gpuInfo := devicemonitoring.Info{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: mi.DeviceInfo.GPU},
+			DeviceInfo: mi.DeviceInfo,
+			ParentId:   devicemonitoring.PARENT_ID_IGNORED,
+		}
+
+		labels := map[string]string{}
+		if len(c.labelsCounters) > 0 && len(c.deviceWatchList.LabelDeviceFields()) > 0 {
+			err := c.getLabelsFromCounters(gpuInfo, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for id, total := range c.totals {
+			if id.gpu != mi.DeviceInfo.GPU {
+				continue
+			}
+
+			values := map[string]int{
+				counters.DCGMExpXIDErrorsTotal:       total,
+				counters.DCGMExpXIDLastSeenTimestamp: int(c.lastSeen[id] / int64(time.Second/time.Microsecond)),
+			}
+
+			for name, value := range values {
+				counter, exists := c.enabled[name]
+				if !exists {
+					continue
+				}
+
+				metricLabels := maps.Clone(labels)
+				metricLabels["xid"] = fmt.Sprint(id.xid)
+
+				m := c.createMetric(metricLabels, gpuInfo, uuid, value)
+				m.Counter = counter
+				metrics[counter] = append(metrics[counter], m)
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// observe counts the updates of the XID field. The timestamps of the updates are in microseconds.
+func (c *xidTotalCollector) observe(values []dcgm.FieldValue_v2) {
+	for _, val := range values {
+		if val.Status != 0 || val.FieldId != dcgm.DCGM_FI_DEV_XID_ERRORS {
+			continue
+		}
+
+		id := xidID{gpu: val.EntityId, xid: val.Int64()}
+		c.totals[id]++
+		c.lastSeen[id] = max(c.lastSeen[id], val.Ts)
+	}
+}
+
+func NewXIDTotalCollector(
+	counterList counters.CounterList,
+	hostname string,
+	config *appconfig.Config,
+	deviceWatchList devicewatchlistmanager.WatchList,
+) (Collector, error) {
+	if !IsDCGMExpXIDErrorsTotalEnabled(counterList) {
+		slog.Error(counters.DCGMExpXIDErrorsTotal+" collector is disabled",
+			slog.String(logging.CollectorKey, counters.DCGMExpXIDErrorsTotal))
+		return nil, fmt.Errorf("%s collector is disabled", counters.DCGMExpXIDErrorsTotal)
+	}
+
+	enabled := map[string]counters.Counter{}
+	for _, counter := range counterList {
+		if slices.Contains(xidTotalCounters, counter.FieldName) {
+			enabled[counter.FieldName] = counter
+		}
+	}
+
+	deviceWatchList.SetDeviceFields([]dcgm.Short{dcgm.DCGM_FI_DEV_XID_ERRORS})
+
+	cleanups, err := deviceWatchList.Watch()
+	if err != nil {
+		slog.Warn("Failed to watch metrics", slog.String(logging.ErrorKey, err.Error()))
+		return nil, err
+	}
+
+	return &xidTotalCollector{
+		baseExpCollector: baseExpCollector{
+			deviceWatchList: deviceWatchList,
+			counter: counterList[slices.IndexFunc(counterList, func(c counters.Counter) bool {
+				return slices.Contains(xidTotalCounters, c.FieldName)
+			})],
+			labelsCounters: counterList.LabelCounters(),
+			hostname:       hostname,
+			config:         config,
+			cleanups:       cleanups,
+		},
+		enabled: enabled,
+		since:   map[dcgm.GroupHandle]time.Time{},
+		// The timestamps are compared to the sample timestamps, so they are on the hostengine clock
+		start:    hostengineClock.now(),
+		totals:   map[xidID]int{},
+		lastSeen: map[xidID]int64{},
+	}, nil
+}
+
+func IsDCGMExpXIDErrorsTotalEnabled(counterList counters.CounterList) bool {
+	return slices.ContainsFunc(counterList, func(c counters.Counter) bool {
+		return slices.Contains(xidTotalCounters, c.FieldName)
+	})
+}
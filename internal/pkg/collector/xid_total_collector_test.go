@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatcher "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/testutils"
+)
+
+func xidValue(gpu uint, xid int64, ts time.Time) dcgm.FieldValue_v2 {
+	v := dcgm.FieldValue_v2{
+		EntityGroupId: dcgm.FE_GPU,
+		EntityId:      gpu,
+		FieldId:       dcgm.DCGM_FI_DEV_XID_ERRORS,
+		FieldType:     dcgm.DCGM_FT_INT64,
+		Ts:            ts.UnixMicro(),
+	}
+	v.Value[0] = byte(xid)
+	return v
+}
+
+func Test_xidTotalCollector_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer func() {
+		dcgmprovider.SetClient(realDCGM)
+	}()
+	dcgmprovider.SetClient(mockDCGM)
+
+	totalCounter := counters.Counter{FieldName: counters.DCGMExpXIDErrorsTotal, PromType: "counter"}
+	lastSeenCounter := counters.Counter{FieldName: counters.DCGMExpXIDLastSeenTimestamp, PromType: "gauge"}
+
+	deviceInfo := testutils.MockGPUDeviceInfo(ctrl, 2, nil)
+	deviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+
+	group := dcgm.GroupHandle{}
+	group.SetHandle(uintptr(1))
+
+	mockDeviceWatcher.EXPECT().WatchDeviceFields([]dcgm.Short{dcgm.DCGM_FI_DEV_XID_ERRORS}, deviceInfo, gomock.Any(), gomock.Any()).
+		Return([]dcgm.GroupHandle{group}, dcgm.FieldHandle{}, nil, nil)
+
+	collector, err := NewXIDTotalCollector(counters.CounterList{totalCounter, lastSeenCounter}, "localhost",
+		&appconfig.Config{}, *devicewatchlistmanager.NewWatchList(deviceInfo, nil, nil, mockDeviceWatcher, 1))
+	require.NoError(t, err)
+
+	first := time.Unix(1700000000, 0)
+	second := first.Add(time.Minute)
+	next := second.Add(time.Microsecond)
+
+	mockDCGM.EXPECT().UpdateAllFields().Return(nil).Times(2)
+	gomock.InOrder(
+		mockDCGM.EXPECT().GetValuesSince(group, gomock.Any(), gomock.Any()).
+			Return([]dcgm.FieldValue_v2{xidValue(0, 79, first), xidValue(0, 79, second), xidValue(1, 48, first)},
+				next, nil),
+		// Only the updates since the previous read are returned
+		mockDCGM.EXPECT().GetValuesSince(group, gomock.Any(), next).
+			Return([]dcgm.FieldValue_v2{xidValue(1, 48, second)}, next, nil),
+	)
+
+	type series struct {
+		gpu, xid, value string
+	}
+	collect := func() map[string][]series {
+		metrics, err := collector.GetMetrics()
+		require.NoError(t, err)
+
+		result := map[string][]series{}
+		for counter, values := range metrics {
+			for _, m := range values {
+				result[counter.FieldName] = append(result[counter.FieldName], series{m.GPU, m.Labels["xid"], m.Value})
+			}
+		}
+		return result
+	}
+
+	got := collect()
+	assert.ElementsMatch(t, []series{{"0", "79", "2"}, {"1", "48", "1"}}, got[counters.DCGMExpXIDErrorsTotal])
+	assert.ElementsMatch(t, []series{{"0", "79", "1700000060"}, {"1", "48", "1700000000"}},
+		got[counters.DCGMExpXIDLastSeenTimestamp])
+
+	got = collect()
+	assert.ElementsMatch(t, []series{{"0", "79", "2"}, {"1", "48", "2"}}, got[counters.DCGMExpXIDErrorsTotal])
+	assert.ElementsMatch(t, []series{{"0", "79", "1700000060"}, {"1", "48", "1700000060"}},
+		got[counters.DCGMExpXIDLastSeenTimestamp])
+}
+
+func TestIsDCGMExpXIDErrorsTotalEnabled(t *testing.T) {
+	assert.False(t, IsDCGMExpXIDErrorsTotalEnabled(counters.CounterList{{FieldName: counters.DCGMExpXIDErrorsCount}}))
+	assert.True(t, IsDCGMExpXIDErrorsTotalEnabled(counters.CounterList{{FieldName: counters.DCGMExpXIDErrorsTotal}}))
+	assert.True(t,
+		IsDCGMExpXIDErrorsTotalEnabled(counters.CounterList{{FieldName: counters.DCGMExpXIDLastSeenTimestamp}}))
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package configbackend reads the counters and the device options from a centralized key-value store, so bare-metal
+// fleets without Kubernetes can manage the configuration of the exporters in one place.
+package configbackend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Keys under the prefix of the backend. The device keys take the same values as the matching parameters.
+const (
+	CountersKey      = "counters"
+	GPUDevicesKey    = "devices"
+	SwitchDevicesKey = "switch-devices"
+	CPUDevicesKey    = "cpu-devices"
+)
+
+// Data is the configuration read from a backend. Device options are empty when their key is not set.
+type Data struct {
+	Counters      string
+	GPUDevices    string
+	SwitchDevices string
+	CPUDevices    string
+	// Revision changes whenever any of the keys under the prefix changes
+	Revision uint64
+}
+
+// Backend supplies the configuration.
+type Backend interface {
+	// Load reads the current configuration.
+	Load(ctx context.Context) (Data, error)
+	// Watch blocks until the configuration differs from the revision, and returns the new revision.
+	Watch(ctx context.Context, revision uint64) (uint64, error)
+}
+
+// New creates the backend of the URL, e.g. consul://127.0.0.1:8500/dcgm-exporter or
+// etcd+https://etcd.example.com:2379/dcgm-exporter. The path is the prefix of the keys.
+func New(rawURL string) (Backend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	kind, scheme, _ := strings.Cut(u.Scheme, "+")
+	if scheme == "" {
+		scheme = "http"
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	if u.Host == "" || prefix == "" {
+		return nil, fmt.Errorf("the URL must contain the address and the key prefix")
+	}
+
+	base := scheme + "://" + u.Host
+	client := &http.Client{}
+
+	switch kind {
+	case "consul":
+		return &consul{client: client, base: base, prefix: prefix}, nil
+	case "etcd":
+		return &etcd{client: client, base: base, prefix: prefix}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported backend '%s'", kind)
+}
+
+// newData maps the values of the keys, relative to the prefix, to the configuration.
+func newData(values map[string]string, revision uint64) (Data, error) {
+	d := Data{
+		Counters:      values[CountersKey],
+		GPUDevices:    values[GPUDevicesKey],
+		SwitchDevices: values[SwitchDevicesKey],
+		CPUDevices:    values[CPUDevicesKey],
+		Revision:      revision,
+	}
+	if strings.TrimSpace(d.Counters) == "" {
+		return d, fmt.Errorf("the '%s' key is missing or empty", CountersKey)
+	}
+
+	return d, nil
+}
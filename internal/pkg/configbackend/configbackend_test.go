@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configbackend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCounters = "DCGM_FI_DEV_GPU_TEMP, gauge, GPU temperature (in C).\n"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Backend
+		wantErr bool
+	}{
+		{url: "consul://127.0.0.1:8500/dcgm-exporter",
+			want: &consul{client: &http.Client{}, base: "http://127.0.0.1:8500", prefix: "dcgm-exporter"}},
+		{url: "etcd+https://etcd:2379/fleet/dcgm-exporter/",
+			want: &etcd{client: &http.Client{}, base: "https://etcd:2379", prefix: "fleet/dcgm-exporter"}},
+		{url: "zookeeper://127.0.0.1:2181/dcgm-exporter", wantErr: true},
+		{url: "consul+ftp://127.0.0.1:8500/dcgm-exporter", wantErr: true},
+		{url: "consul://127.0.0.1:8500", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := New(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConsul(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/dcgm-exporter/", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("recurse"))
+
+		// The blocking query returns the next index, as if a key changed
+		index := "7"
+		if r.URL.Query().Get("index") == "7" {
+			index = "8"
+		}
+		w.Header().Set("X-Consul-Index", index)
+		_ = json.NewEncoder(w).Encode([]consulPair{
+			{Key: "dcgm-exporter/counters", Value: []byte(testCounters)},
+			{Key: "dcgm-exporter/devices", Value: []byte("g:0,1")},
+		})
+	}))
+	defer server.Close()
+
+	backend, err := New("consul://" + strings.TrimPrefix(server.URL, "http://") + "/dcgm-exporter")
+	require.NoError(t, err)
+
+	data, err := backend.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Data{Counters: testCounters, GPUDevices: "g:0,1", Revision: 7}, data)
+
+	revision, err := backend.Watch(context.Background(), data.Revision)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), revision)
+}
+
+func TestConsul_MissingCounters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	backend, err := New("consul://" + strings.TrimPrefix(server.URL, "http://") + "/dcgm-exporter")
+	require.NoError(t, err)
+
+	_, err = backend.Load(context.Background())
+	assert.ErrorContains(t, err, "'counters' key")
+}
+
+func TestEtcd(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			assert.JSONEq(t, fmt.Sprintf(`{"key":"%s","range_end":"%s"}`,
+				encode("dcgm-exporter/"), encode("dcgm-exporter0")), string(body))
+			fmt.Fprintf(w, `{"header":{"revision":"41"},"kvs":[{"key":"%s","value":"%s","mod_revision":"40"},`+
+				`{"key":"%s","value":"%s","mod_revision":"12"}]}`,
+				encode("dcgm-exporter/counters"), encode(testCounters),
+				encode("dcgm-exporter/cpu-devices"), encode("f"))
+		case "/v3/watch":
+			assert.Contains(t, string(body), `"start_revision":"42"`)
+			fmt.Fprintln(w, `{"result":{"header":{"revision":"41"},"created":true}}`)
+			fmt.Fprintln(w, `{"result":{"header":{"revision":"45"},"events":[{"kv":{}}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := New("etcd://" + strings.TrimPrefix(server.URL, "http://") + "/dcgm-exporter")
+	require.NoError(t, err)
+
+	data, err := backend.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Data{Counters: testCounters, CPUDevices: "f", Revision: 41}, data)
+
+	revision, err := backend.Watch(context.Background(), data.Revision)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(45), revision)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// consulWait is the longest time a blocking query waits for a change
+const consulWait = 5 * time.Minute
+
+// consulPair is a key of the Consul KV store; the value is base64 encoded by encoding/json.
+type consulPair struct {
+	Key   string
+	Value []byte
+}
+
+// consul reads the configuration from the Consul KV store, and watches it with blocking queries.
+type consul struct {
+	client *http.Client
+	base   string
+	prefix string
+}
+
+func (c *consul) Load(ctx context.Context) (Data, error) {
+	pairs, index, err := c.get(ctx, nil)
+	if err != nil {
+		return Data{}, err
+	}
+
+	values := map[string]string{}
+	for _, pair := range pairs {
+		values[strings.TrimPrefix(pair.Key, c.prefix+"/")] = string(pair.Value)
+	}
+
+	return newData(values, index)
+}
+
+func (c *consul) Watch(ctx context.Context, revision uint64) (uint64, error) {
+	for {
+		query := url.Values{}
+		query.Set("index", strconv.FormatUint(revision, 10))
+		query.Set("wait", consulWait.String())
+
+		_, index, err := c.get(ctx, query)
+		if err != nil {
+			return revision, err
+		}
+		// The index is unchanged, when the wait time elapsed without a change
+		if index != revision {
+			return index, nil
+		}
+	}
+}
+
+// get lists the keys under the prefix, and returns them with the index of the KV store.
+func (c *consul) get(ctx context.Context, query url.Values) ([]consulPair, uint64, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("recurse", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.base+"/v1/kv/"+c.prefix+"/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	index, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// No keys under the prefix
+		return nil, index, nil
+	default:
+		return nil, 0, fmt.Errorf("consul returned %s", resp.Status)
+	}
+
+	var pairs []consulPair
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode the consul response; err: %w", err)
+	}
+
+	return pairs, index, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configbackend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader     `json:"header"`
+	Kvs    []etcdKeyValue `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header etcdHeader        `json:"header"`
+		Events []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// etcd reads the configuration from etcd through its JSON gateway, and watches it with a watch stream.
+type etcd struct {
+	client *http.Client
+	base   string
+	prefix string
+}
+
+func (e *etcd) Load(ctx context.Context) (Data, error) {
+	var resp etcdRangeResponse
+	if err := e.post(ctx, "/v3/kv/range", e.keyRange(), func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&resp)
+	}); err != nil {
+		return Data{}, err
+	}
+
+	revision, err := strconv.ParseUint(resp.Header.Revision, 10, 64)
+	if err != nil {
+		return Data{}, fmt.Errorf("invalid etcd revision '%s'", resp.Header.Revision)
+	}
+
+	values := map[string]string{}
+	for _, kv := range resp.Kvs {
+		values[strings.TrimPrefix(string(kv.Key), e.prefix+"/")] = string(kv.Value)
+	}
+
+	return newData(values, revision)
+}
+
+func (e *etcd) Watch(ctx context.Context, revision uint64) (uint64, error) {
+	request := e.keyRange()
+	request["start_revision"] = strconv.FormatUint(revision+1, 10)
+
+	var next uint64
+	err := e.post(ctx, "/v3/watch", map[string]any{"create_request": request}, func(body io.Reader) error {
+		decoder := json.NewDecoder(body)
+		for {
+			var resp etcdWatchResponse
+			if err := decoder.Decode(&resp); err != nil {
+				if errors.Is(err, io.EOF) {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if resp.Error != nil {
+				return errors.New(resp.Error.Message)
+			}
+			if len(resp.Result.Events) == 0 {
+				continue
+			}
+
+			var err error
+			next, err = strconv.ParseUint(resp.Result.Header.Revision, 10, 64)
+			return err
+		}
+	})
+	if err != nil {
+		return revision, fmt.Errorf("etcd watch failed; err: %w", err)
+	}
+
+	return next, nil
+}
+
+// keyRange is the range of the keys under the prefix; encoding/json encodes the byte slices in base64, as the
+// gateway expects.
+func (e *etcd) keyRange() map[string]any {
+	key := []byte(e.prefix + "/")
+	rangeEnd := bytes.Clone(key)
+	rangeEnd[len(rangeEnd)-1]++
+
+	return map[string]any{"key": key, "range_end": rangeEnd}
+}
+
+func (e *etcd) post(ctx context.Context, path string, request any, decode func(io.Reader) error) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd returned %s", resp.Status)
+	}
+
+	return decode(resp.Body)
+}
@@ -19,10 +19,84 @@ package counters
 const (
 	undefinedConfigMapData = "none"
 
+	// ConfigMapMetricsKey is the key of the counters file in a ConfigMap
+	ConfigMapMetricsKey = "metrics"
+
+	viewSeparator = "|"
+
+	// LabelGroupDevice contains the identifiers of the device besides its index, e.g. the UUID and the model name
+	LabelGroupDevice = "device"
+	// LabelGroupPod contains the pod, namespace and container the device is attributed to
+	LabelGroupPod = "pod"
+	// LabelGroupHostname contains the Hostname label
+	LabelGroupHostname = "hostname"
+	// LabelGroupCustom contains the labels of the fields with the label type in the counters file
+	LabelGroupCustom = "custom"
+
+	// labelGroupsNone attaches none of the label groups
+	labelGroupsNone = "none"
+
 	cpuFieldsStart = 1100
 	dcpFieldsStart = 1000
 
 	DCGMExpClockEventsCount = "DCGM_EXP_CLOCK_EVENTS_COUNT"
 	DCGMExpXIDErrorsCount   = "DCGM_EXP_XID_ERRORS_COUNT"
 	DCGMExpGPUHealthStatus  = "DCGM_EXP_GPU_HEALTH_STATUS"
+
+	DCGMExpClockThrottleReason = "DCGM_EXP_CLOCK_THROTTLE_REASON"
+
+	DCGMExpGPUHealthIncidentsCount = "DCGM_EXP_GPU_HEALTH_INCIDENTS_COUNT"
+
+	DCGMExpXIDErrorsTotal       = "DCGM_EXP_XID_ERRORS_TOTAL"
+	DCGMExpXIDLastSeenTimestamp = "DCGM_EXP_XID_LAST_SEEN_TIMESTAMP"
+
+	DCGMExpEncoderSessionsCount = "DCGM_EXP_ENCODER_SESSIONS_COUNT"
+	DCGMExpEncoderUtil          = "DCGM_EXP_ENCODER_UTIL"
+	DCGMExpDecoderUtil          = "DCGM_EXP_DECODER_UTIL"
+
+	DCGMExpComputeProcessCount  = "DCGM_EXP_COMPUTE_PROCESS_COUNT"
+	DCGMExpGraphicsProcessCount = "DCGM_EXP_GRAPHICS_PROCESS_COUNT"
+	DCGMExpComputeProcessUtil   = "DCGM_EXP_COMPUTE_PROCESS_UTIL"
+	DCGMExpGraphicsProcessUtil  = "DCGM_EXP_GRAPHICS_PROCESS_UTIL"
+
+	DCGMExpMIGDeviceInfo = "DCGM_EXP_MIG_DEVICE_INFO"
+
+	DCGMExpMemoryTemp            = "DCGM_EXP_MEMORY_TEMP"
+	DCGMExpMemoryThermalThrottle = "DCGM_EXP_MEMORY_THERMAL_THROTTLE"
+	DCGMExpMemoryClockReduced    = "DCGM_EXP_MEMORY_CLOCK_REDUCED"
+
+	DCGMExpRemappedRows        = "DCGM_EXP_REMAPPED_ROWS"
+	DCGMExpRetiredPagesPending = "DCGM_EXP_RETIRED_PAGES_PENDING"
+	DCGMExpGPUNeedsReset       = "DCGM_EXP_GPU_NEEDS_RESET"
+
+	DCGMExpPCIeReplayCounter     = "DCGM_EXP_PCIE_REPLAY_COUNTER"
+	DCGMExpPCIeCorrectableErrors = "DCGM_EXP_PCIE_CORRECTABLE_ERRORS"
+
+	DCGMExpFabricInfo = "DCGM_EXP_FABRIC_INFO"
+
+	DCGMExpGPUTopology = "DCGM_EXP_GPU_TOPOLOGY"
+
+	DCGMExpPowerLimitCapped       = "DCGM_EXP_POWER_LIMIT_CAPPED"
+	DCGMExpPowerLimitBelowDefault = "DCGM_EXP_POWER_LIMIT_BELOW_DEFAULT"
+
+	DCGMExpNVLinkStateTransitions = "DCGM_EXP_NVLINK_STATE_TRANSITIONS"
+
+	DCGMExpNVSwitchLinkHealth = "DCGM_EXP_NVSWITCH_LINK_HEALTH"
+
+	DCGMExpFBMemory      = "DCGM_EXP_FB_MEMORY"
+	DCGMExpFBUsedPercent = "DCGM_EXP_FB_USED_PERCENT"
+
+	DCGMExpPendingECCModeChange = "DCGM_EXP_PENDING_ECC_MODE_CHANGE"
+	DCGMExpPendingMIGModeChange = "DCGM_EXP_PENDING_MIG_MODE_CHANGE"
+
+	DCGMExpProcessMemUsed = "DCGM_EXP_PROCESS_MEM_USED"
+	DCGMExpProcessSMUtil  = "DCGM_EXP_PROCESS_SM_UTIL"
+
+	DCGMExpBuildInfo  = "DCGM_EXP_BUILD_INFO"
+	DCGMExpSystemInfo = "DCGM_EXP_SYSTEM_INFO"
+
+	DCGMExpDriverInfo            = "DCGM_EXP_DRIVER_INFO"
+	DCGMExpDriverModuleLoaded    = "DCGM_EXP_DRIVER_MODULE_LOADED"
+	DCGMExpDriverPersistenceMode = "DCGM_EXP_DRIVER_PERSISTENCE_MODE"
+	DCGMExpDriverOpenHandles     = "DCGM_EXP_DRIVER_OPEN_HANDLES"
 )
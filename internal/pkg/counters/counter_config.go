@@ -18,18 +18,24 @@ package counters
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/prometheus/common/model"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/kubeclient"
 )
 
 func GetCounterSet(c *appconfig.Config) (*CounterSet, error) {
@@ -42,7 +48,7 @@ func GetCounterSet(c *appconfig.Config) (*CounterSet, error) {
 
 	if c.ConfigMapData != undefinedConfigMapData {
 		var client kubernetes.Interface
-		client, err = getKubeClient()
+		client, err = kubeclient.NewClient(c)
 		if err != nil {
 			slog.Error(err.Error())
 			os.Exit(1)
@@ -82,17 +88,62 @@ func ReadCSVFile(filename string) ([][]string, error) {
 
 	defer file.Close()
 
-	r := csv.NewReader(file)
+	return ReadCSV(file)
+}
+
+// SourceRevision returns a digest of the counters, which GetCounterSet reads: the ConfigMap, when one is set, and the
+// counters file otherwise. It changes, whenever the counters change.
+func SourceRevision(kubeClient kubernetes.Interface, c *appconfig.Config) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+
+	if c.ConfigMapData != undefinedConfigMapData {
+		var metrics string
+		metrics, err = readConfigMapMetrics(kubeClient, c)
+		content = []byte(metrics)
+	} else {
+		content, err = readFile(c.CollectorsFile)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func readFile(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// ReadCSV reads the records of a counters file. Lines starting with '#' are comments.
+func ReadCSV(reader io.Reader) ([][]string, error) {
+	r := csv.NewReader(reader)
 	r.Comment = '#'
-	records, err := r.ReadAll()
 
-	return records, err
+	return r.ReadAll()
 }
 
 func ExtractCounters(records [][]string, c *appconfig.Config) (*CounterSet, error) {
 	res := CounterSet{}
 
+	records, lines, err := expandFieldIDs(records)
+	if err != nil {
+		return nil, err
+	}
+
 	for i, record := range records {
+		// The line of the record in the counters file, before the field IDs were expanded
+		line := lines[i]
 		useOld := false
 		if len(record) == 0 {
 			continue
@@ -102,27 +153,63 @@ func ExtractCounters(records [][]string, c *appconfig.Config) (*CounterSet, erro
 			record[j] = strings.Trim(r, " ")
 		}
 
-		if len(record) != 3 {
+		if len(record) < 3 || len(record) > 6 {
 			return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`), "+
-				"expected 3 fields", i,
+				"expected 3 to 6 fields", line,
 				record)
 		}
 
+		promType, views, err := parsePromType(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): %w", line, record, err)
+		}
+
+		var groups string
+		if len(record) >= 4 {
+			groups, err = parseLabelGroups(promType, record[3])
+			if err != nil {
+				return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): %w", line, record, err)
+			}
+		}
+
+		var name string
+		if len(record) == 5 {
+			name, err = parseMetricName(promType, record[4])
+			if err != nil {
+				return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): %w", line, record, err)
+			}
+		}
+
+		var interval time.Duration
+		if len(record) == 6 {
+			interval, err = parseInterval(record[5])
+			if err != nil {
+				return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): %w", line, record, err)
+			}
+		}
+
 		fieldID, ok := dcgm.DCGM_FI[record[0]]
 		oldFieldID, oldOk := dcgm.OLD_DCGM_FI[record[0]]
 		if !ok && !oldOk {
 
 			expField, err := IdentifyMetricType(record[0])
 			if err != nil {
-				return nil, fmt.Errorf("could not find DCGM field; err: %w", err)
+				return nil, unknownFieldError(record[0], err)
 			} else if expField != DCGMFIUnknown {
+				if interval != 0 {
+					return nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): "+
+						"the update interval of '%s' cannot be changed", line, record, record[0])
+				}
 				res.ExporterCounters = append(res.ExporterCounters,
 					Counter{
-						FieldID:   dcgm.Short(expField),
-						FieldName: record[0],
-						PromType:  record[1],
-						Help:      record[2],
-					})
+						FieldID:     dcgm.Short(expField),
+						FieldName:   record[0],
+						PromType:    promType,
+						Help:        record[2],
+						Views:       views,
+						LabelGroups: groups,
+						Name:        name,
+					}.WithPrefix(c.MetricPrefix))
 				continue
 			}
 		}
@@ -131,36 +218,187 @@ func ExtractCounters(records [][]string, c *appconfig.Config) (*CounterSet, erro
 			useOld = true
 		}
 
+		warnIfDeprecated(line, record[0])
+
 		if !useOld {
 			if !fieldIsSupported(uint(fieldID), c) {
-				slog.Warn(fmt.Sprintf("Skipping line %d ('%s'): metric not enabled", i, record[0]))
+				slog.Debug(fmt.Sprintf("Skipping line %d ('%s'): metric not enabled", line, record[0]))
+				res.Skipped = append(res.Skipped, unsupportedField(record[0], c))
 				continue
 			}
 
-			if _, ok := promMetricType[record[1]]; !ok {
-				return nil, fmt.Errorf("could not find Prometheus metric type '%s'", record[1])
+			if _, ok := promMetricType[promType]; !ok {
+				return nil, fmt.Errorf("could not find Prometheus metric type '%s'", promType)
 			}
 
 			res.DCGMCounters = append(res.DCGMCounters,
-				Counter{FieldID: fieldID, FieldName: record[0], PromType: record[1], Help: record[2]})
+				Counter{
+					FieldID:     fieldID,
+					FieldName:   record[0],
+					PromType:    promType,
+					Help:        record[2],
+					Views:       views,
+					LabelGroups: groups,
+					Name:        name,
+					Interval:    interval,
+				}.WithPrefix(c.MetricPrefix))
 		} else {
 			if !fieldIsSupported(uint(oldFieldID), c) {
-				slog.Warn(fmt.Sprintf("Skipping line %d ('%s'): metric not enabled", i, record[0]))
+				slog.Debug(fmt.Sprintf("Skipping line %d ('%s'): metric not enabled", line, record[0]))
+				res.Skipped = append(res.Skipped, unsupportedField(record[0], c))
 				continue
 			}
 
-			if _, ok := promMetricType[record[1]]; !ok {
-				return nil, fmt.Errorf("could not find Prometheus metric type '%s'", record[1])
+			if _, ok := promMetricType[promType]; !ok {
+				return nil, fmt.Errorf("could not find Prometheus metric type '%s'", promType)
 			}
 
 			res.DCGMCounters = append(res.DCGMCounters,
-				Counter{FieldID: oldFieldID, FieldName: record[0], PromType: record[1], Help: record[2]})
+				Counter{
+					FieldID:     oldFieldID,
+					FieldName:   record[0],
+					PromType:    promType,
+					Help:        record[2],
+					Views:       views,
+					LabelGroups: groups,
+					Name:        name,
+					Interval:    interval,
+				}.WithPrefix(c.MetricPrefix))
+		}
+	}
+
+	if err := checkMetricNames(res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Skipped) > 0 {
+		names := make([]string, 0, len(res.Skipped))
+		for _, skipped := range res.Skipped {
+			names = append(names, skipped.FieldName)
 		}
+		slog.Warn(fmt.Sprintf("Skipping %d counters, which are not enabled: %s", len(names), strings.Join(names, ", ")))
 	}
 
 	return &res, nil
 }
 
+// parsePromType splits the metric type column into the primary Prometheus type and additional views,
+// for example "counter|rate" produces the "counter" metric and its per second rate as a gauge.
+func parsePromType(value string) (string, string, error) {
+	parts := strings.Split(value, viewSeparator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	promType, views := parts[0], parts[1:]
+	if len(views) == 0 {
+		return promType, "", nil
+	}
+
+	if promType == "label" {
+		return "", "", fmt.Errorf("label '%s' cannot have additional views", value)
+	}
+
+	seen := map[string]bool{promType: true}
+	for _, view := range views {
+		if _, exists := viewSpecs[view]; !exists {
+			return "", "", fmt.Errorf("unsupported view '%s'", view)
+		}
+		if seen[view] {
+			return "", "", fmt.Errorf("duplicated view '%s'", view)
+		}
+		seen[view] = true
+	}
+
+	return promType, strings.Join(views, viewSeparator), nil
+}
+
+// parseLabelGroups validates the optional column with the groups of labels attached to the series of a field,
+// for example "device|hostname" attaches neither the pod nor the custom labels. "none" attaches none of the groups.
+// parseMetricName parses the name, which the metric family of the field is renamed to.
+func parseMetricName(promType, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if promType == "label" {
+		return "", fmt.Errorf("label cannot be renamed to '%s'", value)
+	}
+
+	if !model.IsValidMetricName(model.LabelValue(value)) {
+		return "", fmt.Errorf("invalid metric name '%s'", value)
+	}
+
+	return value, nil
+}
+
+// parseInterval parses the update interval of the field, e.g. "10s". The field is updated every collect interval,
+// when it is empty.
+func parseInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid update interval '%s'", value)
+	}
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("update interval '%s' must be positive", value)
+	}
+
+	return interval, nil
+}
+
+// checkMetricNames reports counters, which are rendered as the same metric family, e.g. because of the renames or
+// the metric prefix.
+func checkMetricNames(cs CounterSet) error {
+	fields := map[string]string{}
+	for _, counter := range slices.Concat(cs.DCGMCounters, cs.ExporterCounters) {
+		if counter.IsLabel() {
+			continue
+		}
+
+		name := counter.MetricName()
+		if field, exists := fields[name]; exists && field != counter.FieldName {
+			return fmt.Errorf("fields '%s' and '%s' are both exported as '%s'", field, counter.FieldName, name)
+		}
+		fields[name] = counter.FieldName
+	}
+
+	return nil
+}
+
+func parseLabelGroups(promType, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if promType == "label" {
+		return "", fmt.Errorf("label cannot select label groups '%s'", value)
+	}
+
+	if value == labelGroupsNone {
+		return labelGroupsNone, nil
+	}
+
+	groups := strings.Split(value, viewSeparator)
+	seen := map[string]bool{}
+	for i := range groups {
+		groups[i] = strings.TrimSpace(groups[i])
+		if !labelGroups[groups[i]] {
+			return "", fmt.Errorf("unsupported label group '%s'", groups[i])
+		}
+		if seen[groups[i]] {
+			return "", fmt.Errorf("duplicated label group '%s'", groups[i])
+		}
+		seen[groups[i]] = true
+	}
+
+	return strings.Join(groups, viewSeparator), nil
+}
+
 func fieldIsSupported(fieldID uint, c *appconfig.Config) bool {
 	if fieldID < dcpFieldsStart || fieldID >= cpuFieldsStart {
 		return true
@@ -181,25 +419,30 @@ func fieldIsSupported(fieldID uint, c *appconfig.Config) bool {
 	return false
 }
 
-func readConfigMap(kubeClient kubernetes.Interface, c *appconfig.Config) ([][]string, error) {
-	parts := strings.Split(c.ConfigMapData, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("malformed configmap-data '%s'", c.ConfigMapData)
+// unsupportedField explains why fieldIsSupported rejected a field.
+func unsupportedField(fieldName string, c *appconfig.Config) SkippedCounter {
+	if !c.CollectDCP {
+		return SkippedCounter{
+			FieldName: fieldName,
+			Code:      SkipCodeProfilingDisabled,
+			Reason:    "profiling metrics are not collected",
+		}
 	}
 
-	var cm *corev1.ConfigMap
-	cm, err := kubeClient.CoreV1().ConfigMaps(parts[0]).Get(context.TODO(), parts[1], metav1.GetOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve ConfigMap '%s'; err: %w", c.ConfigMapData, err)
+	return SkippedCounter{
+		FieldName: fieldName,
+		Code:      SkipCodeProfilingUnsupported,
+		Reason:    "profiling metric is not supported by the GPU",
 	}
+}
 
-	if _, ok := cm.Data["metrics"]; !ok {
-		return nil, fmt.Errorf("malformed ConfigMap '%s'; no 'metrics' key", c.ConfigMapData)
+func readConfigMap(kubeClient kubernetes.Interface, c *appconfig.Config) ([][]string, error) {
+	metrics, err := readConfigMapMetrics(kubeClient, c)
+	if err != nil {
+		return nil, err
 	}
 
-	r := csv.NewReader(strings.NewReader(cm.Data["metrics"]))
-	r.Comment = '#'
-	records, err := r.ReadAll()
+	records, err := ReadCSV(strings.NewReader(metrics))
 
 	if len(records) == 0 {
 		return nil, fmt.Errorf("malformed configmap contents; err: no metrics found")
@@ -208,16 +451,23 @@ func readConfigMap(kubeClient kubernetes.Interface, c *appconfig.Config) ([][]st
 	return records, err
 }
 
-func getKubeClient() (kubernetes.Interface, error) {
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, err
+// readConfigMapMetrics returns the counters of the 'metrics' key of the ConfigMap.
+func readConfigMapMetrics(kubeClient kubernetes.Interface, c *appconfig.Config) (string, error) {
+	parts := strings.Split(c.ConfigMapData, ":")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("malformed configmap-data '%s'", c.ConfigMapData)
 	}
 
-	client, err := kubernetes.NewForConfig(config)
+	var cm *corev1.ConfigMap
+	cm, err := kubeClient.CoreV1().ConfigMaps(parts[0]).Get(context.TODO(), parts[1], metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("could not retrieve ConfigMap '%s'; err: %w", c.ConfigMapData, err)
+	}
+
+	metrics, ok := cm.Data[ConfigMapMetricsKey]
+	if !ok {
+		return "", fmt.Errorf("malformed ConfigMap '%s'; no 'metrics' key", c.ConfigMapData)
 	}
 
-	return client, err
+	return metrics, nil
 }
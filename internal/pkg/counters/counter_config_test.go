@@ -17,14 +17,21 @@
 package counters
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
 )
 
 func TestEmptyConfigMap(t *testing.T) {
@@ -141,6 +148,27 @@ func TestExtractCounters(t *testing.T) {
 	}
 }
 
+func TestExtractCounters_SkippedProfilingFields(t *testing.T) {
+	records := [][]string{
+		{"DCGM_FI_DEV_GPU_TEMP", "gauge", "Temperature."},
+		{"DCGM_FI_PROF_GR_ENGINE_ACTIVE", "gauge", "Ratio of time the graphics engine is active."},
+	}
+
+	cs, err := ExtractCounters(records, &appconfig.Config{CollectDCP: false})
+	require.NoError(t, err)
+	assert.Len(t, cs.DCGMCounters, 1)
+	assert.Equal(t, []SkippedCounter{{
+		FieldName: "DCGM_FI_PROF_GR_ENGINE_ACTIVE",
+		Code:      SkipCodeProfilingDisabled,
+		Reason:    "profiling metrics are not collected",
+	}}, cs.Skipped)
+
+	cs, err = ExtractCounters(records, &appconfig.Config{CollectDCP: true})
+	require.NoError(t, err)
+	require.Len(t, cs.Skipped, 1)
+	assert.Equal(t, SkipCodeProfilingUnsupported, cs.Skipped[0].Code)
+}
+
 func extractCountersHelper(t *testing.T, input string, valid bool) {
 	tmpFile, err := os.CreateTemp(os.TempDir(), "prefix-")
 	if err != nil {
@@ -173,3 +201,402 @@ func extractCountersHelper(t *testing.T, input string, valid bool) {
 		assert.Nil(t, cc, "Expected no counters.")
 	}
 }
+
+func TestExtractCounters_Views(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    []string
+		wantType  string
+		wantViews string
+		wantErr   bool
+	}{
+		{
+			name:     "Single type",
+			record:   []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+			wantType: "gauge",
+		},
+		{
+			name:      "Counter with gauge and rate views",
+			record:    []string{"DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION", "counter | gauge|rate", "energy"},
+			wantType:  "counter",
+			wantViews: "gauge|rate",
+		},
+		{
+			name:    "Unsupported view",
+			record:  []string{"DCGM_FI_DEV_GPU_TEMP", "gauge|histogram", "temperature"},
+			wantErr: true,
+		},
+		{
+			name:    "View duplicates the primary type",
+			record:  []string{"DCGM_FI_DEV_GPU_TEMP", "gauge|gauge", "temperature"},
+			wantErr: true,
+		},
+		{
+			name:    "Label with views",
+			record:  []string{"DCGM_FI_DRIVER_VERSION", "label|gauge", "driver"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := ExtractCounters([][]string{tt.record}, &appconfig.Config{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, cs.DCGMCounters, 1)
+			assert.Equal(t, tt.wantType, cs.DCGMCounters[0].PromType)
+			assert.Equal(t, tt.wantViews, cs.DCGMCounters[0].Views)
+		})
+	}
+}
+
+func TestExtractCounters_LabelGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     []string
+		wantGroups string
+		wantErr    bool
+	}{
+		{
+			name:   "Without label groups",
+			record: []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+		},
+		{
+			name:   "Empty label groups",
+			record: []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", ""},
+		},
+		{
+			name:       "Selected label groups",
+			record:     []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "device | hostname"},
+			wantGroups: "device|hostname",
+		},
+		{
+			name:       "No label groups",
+			record:     []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "none"},
+			wantGroups: "none",
+		},
+		{
+			name:    "Unsupported label group",
+			record:  []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "device|node"},
+			wantErr: true,
+		},
+		{
+			name:    "Duplicated label group",
+			record:  []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "pod|pod"},
+			wantErr: true,
+		},
+		{
+			name:    "Label with label groups",
+			record:  []string{"DCGM_FI_DRIVER_VERSION", "label", "driver", "device"},
+			wantErr: true,
+		},
+		{
+			name:    "Too many fields",
+			record:  []string{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "device", "gpu_temp", "1s", "pod"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := ExtractCounters([][]string{tt.record}, &appconfig.Config{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, cs.DCGMCounters, 1)
+			assert.Equal(t, tt.wantGroups, cs.DCGMCounters[0].LabelGroups)
+		})
+	}
+}
+
+func TestExtractCounters_MetricNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   [][]string
+		prefix    string
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "Field names",
+			records:   [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"}},
+			wantNames: []string{"DCGM_FI_DEV_GPU_TEMP"},
+		},
+		{
+			name:      "Renamed field",
+			records:   [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "gpu_temperature_celsius"}},
+			wantNames: []string{"gpu_temperature_celsius"},
+		},
+		{
+			name: "Prefix replaces the namespace of the field names",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+				{"DCGM_FI_SYNC_BOOST", "gauge", "sync boost"},
+				{"DCGM_EXP_XID_ERRORS_COUNT", "gauge", "XID errors"},
+			},
+			prefix:    "gpu_",
+			wantNames: []string{"gpu_GPU_TEMP", "gpu_SYNC_BOOST", "gpu_XID_ERRORS_COUNT"},
+		},
+		{
+			name: "Renamed field keeps its name with a prefix",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "gpu_temperature_celsius"},
+				{"DCGM_FI_DEV_POWER_USAGE", "gauge", "power"},
+			},
+			prefix:    "gpu_",
+			wantNames: []string{"gpu_temperature_celsius", "gpu_POWER_USAGE"},
+		},
+		{
+			name:    "Invalid metric name",
+			records: [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "gpu-temp"}},
+			wantErr: true,
+		},
+		{
+			name:    "Renamed label",
+			records: [][]string{{"DCGM_FI_DRIVER_VERSION", "label", "driver", "", "driver"}},
+			wantErr: true,
+		},
+		{
+			name: "Fields exported as the same metric",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "gpu_temp"},
+				{"DCGM_FI_DEV_MEMORY_TEMP", "gauge", "memory temperature", "", "gpu_temp"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := ExtractCounters(tt.records, &appconfig.Config{MetricPrefix: tt.prefix})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, counter := range append(cs.DCGMCounters, cs.ExporterCounters...) {
+				names = append(names, counter.MetricName())
+			}
+			assert.Equal(t, tt.wantNames, names)
+		})
+	}
+}
+
+func TestExtractCounters_Intervals(t *testing.T) {
+	tests := []struct {
+		name          string
+		records       [][]string
+		wantIntervals map[dcgm.Short]time.Duration
+		wantErr       bool
+	}{
+		{
+			name: "Fields with and without an interval",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "", "1s"},
+				{"DCGM_FI_DEV_POWER_USAGE", "gauge", "power"},
+				{"DCGM_FI_DEV_SM_CLOCK", "gauge", "SM clock", "", "", ""},
+			},
+			wantIntervals: map[dcgm.Short]time.Duration{dcgm.DCGM_FI_DEV_GPU_TEMP: time.Second},
+		},
+		{
+			name: "Field listed twice",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "", "10s"},
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "gpu_temp", "5s"},
+			},
+			wantIntervals: map[dcgm.Short]time.Duration{dcgm.DCGM_FI_DEV_GPU_TEMP: 5 * time.Second},
+		},
+		{
+			name:    "Invalid interval",
+			records: [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "", "10"}},
+			wantErr: true,
+		},
+		{
+			name:    "Negative interval",
+			records: [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature", "", "", "-1s"}},
+			wantErr: true,
+		},
+		{
+			name:    "Interval of an exporter field",
+			records: [][]string{{"DCGM_EXP_XID_ERRORS_COUNT", "gauge", "XID errors", "", "", "10s"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := ExtractCounters(tt.records, &appconfig.Config{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantIntervals, cs.DCGMCounters.FieldIntervals())
+		})
+	}
+}
+
+func TestCounter_HasLabelGroup(t *testing.T) {
+	assert.True(t, Counter{}.HasLabelGroup(LabelGroupPod))
+	assert.True(t, Counter{LabelGroups: "device|pod"}.HasLabelGroup(LabelGroupPod))
+	assert.False(t, Counter{LabelGroups: "device|pod"}.HasLabelGroup(LabelGroupHostname))
+	assert.False(t, Counter{LabelGroups: "none"}.HasLabelGroup(LabelGroupDevice))
+}
+
+func TestExtractCounters_FieldIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    []string
+		wantNames []string
+		wantHelp  []string
+		wantErr   bool
+	}{
+		{
+			name:      "Single field ID",
+			record:    []string{"150", "gauge", "temperature"},
+			wantNames: []string{"DCGM_FI_DEV_GPU_TEMP"},
+			wantHelp:  []string{"temperature"},
+		},
+		{
+			name:   "Field ID range skips unknown IDs",
+			record: []string{"150-155", "gauge", "power and temperature"},
+			wantNames: []string{
+				"DCGM_FI_DEV_GPU_TEMP", "DCGM_FI_DEV_MEM_MAX_OP_TEMP", "DCGM_FI_DEV_GPU_MAX_OP_TEMP",
+				"DCGM_FI_DEV_POWER_USAGE",
+			},
+			wantHelp: []string{
+				"power and temperature", "power and temperature", "power and temperature", "power and temperature",
+			},
+		},
+		{
+			name:      "Empty help is generated from the field tag",
+			record:    []string{"155", "gauge", ""},
+			wantNames: []string{"DCGM_FI_DEV_POWER_USAGE"},
+			wantHelp:  []string{"DCGM field 155 (tag_155)."},
+		},
+		{
+			name:    "Unknown field ID",
+			record:  []string{"153", "gauge", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "Range without known field IDs",
+			record:  []string{"153-154", "gauge", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "Reversed range",
+			record:  []string{"155-150", "gauge", "reversed"},
+			wantErr: true,
+		},
+		{
+			name:    "Field ID out of range",
+			record:  []string{"70000", "gauge", "overflow"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+			mockDCGM.EXPECT().FieldGetById(gomock.Any()).DoAndReturn(func(fieldID dcgm.Short) dcgm.FieldMeta {
+				return dcgm.FieldMeta{FieldId: fieldID, Tag: fmt.Sprintf("tag_%d", fieldID)}
+			}).AnyTimes()
+
+			realDCGM := dcgmprovider.Client()
+			defer dcgmprovider.SetClient(realDCGM)
+			dcgmprovider.SetClient(mockDCGM)
+
+			cs, err := ExtractCounters([][]string{tt.record}, &appconfig.Config{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names, help []string
+			for _, counter := range cs.DCGMCounters {
+				names = append(names, counter.FieldName)
+				help = append(help, counter.Help)
+			}
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantHelp, help)
+		})
+	}
+}
+
+func TestExtractCounters_FieldIDsLine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().FieldGetById(gomock.Any()).DoAndReturn(func(fieldID dcgm.Short) dcgm.FieldMeta {
+		return dcgm.FieldMeta{FieldId: fieldID}
+	}).AnyTimes()
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	// The range is expanded to four records, but the malformed record is still reported on its own line
+	_, err := ExtractCounters([][]string{
+		{"150-155", "gauge", "power and temperature"},
+		{"DCGM_FI_DEV_SM_CLOCK", "gauge"},
+	}, &appconfig.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse line 1 ")
+}
+
+func TestCounter_ExpandViews(t *testing.T) {
+	counter := Counter{FieldID: 156, FieldName: "ENERGY", PromType: "counter", Help: "Energy", Views: "gauge|rate"}
+
+	assert.Nil(t, Counter{FieldName: "ENERGY"}.ExpandViews())
+	assert.Equal(t, []View{
+		{Counter: Counter{FieldID: 156, FieldName: "ENERGY_gauge", PromType: "gauge", Help: "Energy"}},
+		{Counter: Counter{FieldID: 156, FieldName: "ENERGY_rate", PromType: "gauge", Help: "Energy (per second rate)"}, Rate: true},
+	}, counter.ExpandViews())
+}
+
+func TestSourceRevision(t *testing.T) {
+	writeCounters := func(content string) string {
+		file, err := os.CreateTemp(t.TempDir(), "counters")
+		require.NoError(t, err)
+		_, err = file.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		return file.Name()
+	}
+
+	revision := func(c *appconfig.Config) string {
+		r, err := SourceRevision(nil, c)
+		require.NoError(t, err)
+		return r
+	}
+
+	const temp = "DCGM_FI_DEV_GPU_TEMP, gauge, GPU temperature (in C).\n"
+	const power = "DCGM_FI_DEV_POWER_USAGE, gauge, Power draw (in W).\n"
+
+	config := func(file string) *appconfig.Config {
+		return &appconfig.Config{CollectorsFile: file, ConfigMapData: undefinedConfigMapData}
+	}
+	assert.Equal(t, revision(config(writeCounters(temp))), revision(config(writeCounters(temp))))
+	assert.NotEqual(t, revision(config(writeCounters(temp))), revision(config(writeCounters(temp+power))))
+
+	_, err := SourceRevision(nil, config("/nonexistent/counters.csv"))
+	assert.Error(t, err)
+
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "configmap1", Namespace: "default"},
+		Data:       map[string]string{"metrics": temp},
+	})
+	c := &appconfig.Config{CollectorsFile: "/nonexistent/counters.csv", ConfigMapData: "default:configmap1"}
+	fromConfigMap, err := SourceRevision(clientset, c)
+	require.NoError(t, err)
+	assert.Equal(t, revision(config(writeCounters(temp))), fromConfigMap)
+}
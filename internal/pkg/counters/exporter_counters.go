@@ -25,6 +25,64 @@ const (
 	DCGMXIDErrorsCount   ExporterCounter = iota + 9000
 	DCGMClockEventsCount ExporterCounter = iota + 9000
 	DCGMGPUHealthStatus  ExporterCounter = iota + 9000
+
+	DCGMEncoderSessionsCount ExporterCounter = iota + 9000
+	DCGMEncoderUtil          ExporterCounter = iota + 9000
+	DCGMDecoderUtil          ExporterCounter = iota + 9000
+
+	DCGMMIGDeviceInfo ExporterCounter = iota + 9000
+
+	DCGMMemoryTemp            ExporterCounter = iota + 9000
+	DCGMMemoryThermalThrottle ExporterCounter = iota + 9000
+	DCGMMemoryClockReduced    ExporterCounter = iota + 9000
+
+	DCGMComputeProcessCount  ExporterCounter = iota + 9000
+	DCGMGraphicsProcessCount ExporterCounter = iota + 9000
+	DCGMComputeProcessUtil   ExporterCounter = iota + 9000
+	DCGMGraphicsProcessUtil  ExporterCounter = iota + 9000
+
+	DCGMPCIeReplayCounter     ExporterCounter = iota + 9000
+	DCGMPCIeCorrectableErrors ExporterCounter = iota + 9000
+
+	DCGMFabricInfo ExporterCounter = iota + 9000
+
+	DCGMPowerLimitCapped ExporterCounter = iota + 9000
+
+	DCGMNVLinkStateTransitions ExporterCounter = iota + 9000
+
+	DCGMFBMemory      ExporterCounter = iota + 9000
+	DCGMFBUsedPercent ExporterCounter = iota + 9000
+
+	DCGMPendingECCModeChange ExporterCounter = iota + 9000
+	DCGMPendingMIGModeChange ExporterCounter = iota + 9000
+
+	DCGMProcessMemUsed ExporterCounter = iota + 9000
+	DCGMProcessSMUtil  ExporterCounter = iota + 9000
+
+	DCGMGPUHealthIncidentsCount ExporterCounter = iota + 9000
+
+	DCGMXIDErrorsTotal       ExporterCounter = iota + 9000
+	DCGMXIDLastSeenTimestamp ExporterCounter = iota + 9000
+
+	DCGMGPUTopology ExporterCounter = iota + 9000
+
+	DCGMClockThrottleReason ExporterCounter = iota + 9000
+
+	DCGMRemappedRows        ExporterCounter = iota + 9000
+	DCGMRetiredPagesPending ExporterCounter = iota + 9000
+	DCGMGPUNeedsReset       ExporterCounter = iota + 9000
+
+	DCGMNVSwitchLinkHealth ExporterCounter = iota + 9000
+
+	DCGMBuildInfo  ExporterCounter = iota + 9000
+	DCGMSystemInfo ExporterCounter = iota + 9000
+
+	DCGMDriverInfo            ExporterCounter = iota + 9000
+	DCGMDriverModuleLoaded    ExporterCounter = iota + 9000
+	DCGMDriverPersistenceMode ExporterCounter = iota + 9000
+	DCGMDriverOpenHandles     ExporterCounter = iota + 9000
+
+	DCGMPowerLimitBelowDefault ExporterCounter = iota + 9000
 )
 
 // String method to convert the enum value to a string
@@ -36,6 +94,82 @@ func (enm ExporterCounter) String() string {
 		return DCGMExpClockEventsCount
 	case DCGMGPUHealthStatus:
 		return DCGMExpGPUHealthStatus
+	case DCGMEncoderSessionsCount:
+		return DCGMExpEncoderSessionsCount
+	case DCGMEncoderUtil:
+		return DCGMExpEncoderUtil
+	case DCGMDecoderUtil:
+		return DCGMExpDecoderUtil
+	case DCGMMIGDeviceInfo:
+		return DCGMExpMIGDeviceInfo
+	case DCGMMemoryTemp:
+		return DCGMExpMemoryTemp
+	case DCGMMemoryThermalThrottle:
+		return DCGMExpMemoryThermalThrottle
+	case DCGMMemoryClockReduced:
+		return DCGMExpMemoryClockReduced
+	case DCGMComputeProcessCount:
+		return DCGMExpComputeProcessCount
+	case DCGMGraphicsProcessCount:
+		return DCGMExpGraphicsProcessCount
+	case DCGMComputeProcessUtil:
+		return DCGMExpComputeProcessUtil
+	case DCGMGraphicsProcessUtil:
+		return DCGMExpGraphicsProcessUtil
+	case DCGMPCIeReplayCounter:
+		return DCGMExpPCIeReplayCounter
+	case DCGMPCIeCorrectableErrors:
+		return DCGMExpPCIeCorrectableErrors
+	case DCGMFabricInfo:
+		return DCGMExpFabricInfo
+	case DCGMPowerLimitCapped:
+		return DCGMExpPowerLimitCapped
+	case DCGMPowerLimitBelowDefault:
+		return DCGMExpPowerLimitBelowDefault
+	case DCGMNVLinkStateTransitions:
+		return DCGMExpNVLinkStateTransitions
+	case DCGMFBMemory:
+		return DCGMExpFBMemory
+	case DCGMFBUsedPercent:
+		return DCGMExpFBUsedPercent
+	case DCGMPendingECCModeChange:
+		return DCGMExpPendingECCModeChange
+	case DCGMPendingMIGModeChange:
+		return DCGMExpPendingMIGModeChange
+	case DCGMProcessMemUsed:
+		return DCGMExpProcessMemUsed
+	case DCGMProcessSMUtil:
+		return DCGMExpProcessSMUtil
+	case DCGMGPUHealthIncidentsCount:
+		return DCGMExpGPUHealthIncidentsCount
+	case DCGMXIDErrorsTotal:
+		return DCGMExpXIDErrorsTotal
+	case DCGMXIDLastSeenTimestamp:
+		return DCGMExpXIDLastSeenTimestamp
+	case DCGMGPUTopology:
+		return DCGMExpGPUTopology
+	case DCGMClockThrottleReason:
+		return DCGMExpClockThrottleReason
+	case DCGMRemappedRows:
+		return DCGMExpRemappedRows
+	case DCGMRetiredPagesPending:
+		return DCGMExpRetiredPagesPending
+	case DCGMGPUNeedsReset:
+		return DCGMExpGPUNeedsReset
+	case DCGMNVSwitchLinkHealth:
+		return DCGMExpNVSwitchLinkHealth
+	case DCGMBuildInfo:
+		return DCGMExpBuildInfo
+	case DCGMSystemInfo:
+		return DCGMExpSystemInfo
+	case DCGMDriverInfo:
+		return DCGMExpDriverInfo
+	case DCGMDriverModuleLoaded:
+		return DCGMExpDriverModuleLoaded
+	case DCGMDriverPersistenceMode:
+		return DCGMExpDriverPersistenceMode
+	case DCGMDriverOpenHandles:
+		return DCGMExpDriverOpenHandles
 	default:
 		return "DCGM_FI_UNKNOWN"
 	}
@@ -43,10 +177,37 @@ func (enm ExporterCounter) String() string {
 
 // DCGMFields maps DCGMExporterMetric String to enum
 var DCGMFields = map[string]ExporterCounter{
-	DCGMXIDErrorsCount.String():   DCGMXIDErrorsCount,
-	DCGMClockEventsCount.String(): DCGMClockEventsCount,
-	DCGMGPUHealthStatus.String():  DCGMGPUHealthStatus,
-	DCGMFIUnknown.String():        DCGMFIUnknown,
+	DCGMXIDErrorsCount.String():          DCGMXIDErrorsCount,
+	DCGMClockEventsCount.String():        DCGMClockEventsCount,
+	DCGMGPUHealthStatus.String():         DCGMGPUHealthStatus,
+	DCGMMIGDeviceInfo.String():           DCGMMIGDeviceInfo,
+	DCGMMemoryTemp.String():              DCGMMemoryTemp,
+	DCGMMemoryThermalThrottle.String():   DCGMMemoryThermalThrottle,
+	DCGMMemoryClockReduced.String():      DCGMMemoryClockReduced,
+	DCGMPCIeReplayCounter.String():       DCGMPCIeReplayCounter,
+	DCGMPCIeCorrectableErrors.String():   DCGMPCIeCorrectableErrors,
+	DCGMFabricInfo.String():              DCGMFabricInfo,
+	DCGMPowerLimitCapped.String():        DCGMPowerLimitCapped,
+	DCGMPowerLimitBelowDefault.String():  DCGMPowerLimitBelowDefault,
+	DCGMNVLinkStateTransitions.String():  DCGMNVLinkStateTransitions,
+	DCGMFBMemory.String():                DCGMFBMemory,
+	DCGMFBUsedPercent.String():           DCGMFBUsedPercent,
+	DCGMPendingECCModeChange.String():    DCGMPendingECCModeChange,
+	DCGMPendingMIGModeChange.String():    DCGMPendingMIGModeChange,
+	DCGMProcessMemUsed.String():          DCGMProcessMemUsed,
+	DCGMProcessSMUtil.String():           DCGMProcessSMUtil,
+	DCGMGPUHealthIncidentsCount.String(): DCGMGPUHealthIncidentsCount,
+	DCGMXIDErrorsTotal.String():          DCGMXIDErrorsTotal,
+	DCGMXIDLastSeenTimestamp.String():    DCGMXIDLastSeenTimestamp,
+	DCGMGPUTopology.String():             DCGMGPUTopology,
+	DCGMClockThrottleReason.String():     DCGMClockThrottleReason,
+	DCGMRemappedRows.String():            DCGMRemappedRows,
+	DCGMRetiredPagesPending.String():     DCGMRetiredPagesPending,
+	DCGMGPUNeedsReset.String():           DCGMGPUNeedsReset,
+	DCGMNVSwitchLinkHealth.String():      DCGMNVSwitchLinkHealth,
+	DCGMBuildInfo.String():               DCGMBuildInfo,
+	DCGMSystemInfo.String():              DCGMSystemInfo,
+	DCGMFIUnknown.String():               DCGMFIUnknown,
 }
 
 func IdentifyMetricType(s string) (ExporterCounter, error) {
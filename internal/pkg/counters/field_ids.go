@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// fieldIDsRegex matches a numeric field ID, for example "1001", or a range of field IDs, for example "1001-1012".
+var fieldIDsRegex = regexp.MustCompile(`^([0-9]+)(?:\s*-\s*([0-9]+))?$`)
+
+// getFieldNamesByID maps field IDs to their symbolic names. When several names share the same ID,
+// for example deprecated aliases, the first name in alphabetical order is used.
+var getFieldNamesByID = sync.OnceValue(func() map[dcgm.Short]string {
+	names := make([]string, 0, len(dcgm.DCGM_FI))
+	for name := range dcgm.DCGM_FI {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fieldNames := make(map[dcgm.Short]string, len(names))
+	for _, name := range names {
+		if _, exists := fieldNames[dcgm.DCGM_FI[name]]; !exists {
+			fieldNames[dcgm.DCGM_FI[name]] = name
+		}
+	}
+
+	return fieldNames
+})
+
+// expandFieldIDs replaces records, which reference fields by numeric IDs or ranges of IDs,
+// with one record per field, named by the symbolic field name. It returns the line of the original record of every
+// expanded record as well, so errors in the expanded records report the line of the counters file.
+func expandFieldIDs(records [][]string) ([][]string, []int, error) {
+	expanded := make([][]string, 0, len(records))
+	lines := make([]int, 0, len(records))
+
+	for i, record := range records {
+		if len(record) == 0 {
+			expanded = append(expanded, record)
+			lines = append(lines, i)
+			continue
+		}
+
+		matches := fieldIDsRegex.FindStringSubmatch(strings.TrimSpace(record[0]))
+		if matches == nil {
+			expanded = append(expanded, record)
+			lines = append(lines, i)
+			continue
+		}
+
+		first, last, err := parseFieldIDRange(matches[1], matches[2])
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed CSV record; err: failed to parse line %d (`%v`): %w", i, record, err)
+		}
+
+		isRange := first != last
+		found := 0
+
+		for fieldID := first; fieldID <= last; fieldID++ {
+			name, exists := getFieldNamesByID()[dcgm.Short(fieldID)]
+			if !exists {
+				if isRange {
+					slog.Debug(fmt.Sprintf("Skipping line %d: unknown field", i), slog.Any(logging.FieldIDKey, fieldID))
+					continue
+				}
+				return nil, nil, fmt.Errorf("could not find DCGM field with ID %d on line %d", fieldID, i)
+			}
+
+			fieldMeta := dcgmprovider.Client().FieldGetById(dcgm.Short(fieldID))
+			if uint(fieldMeta.FieldId) != fieldID {
+				return nil, nil, fmt.Errorf("DCGM does not recognize field with ID %d on line %d", fieldID, i)
+			}
+
+			newRecord := append([]string{name}, record[1:]...)
+			if len(newRecord) == 3 && strings.TrimSpace(newRecord[2]) == "" {
+				newRecord[2] = fmt.Sprintf("DCGM field %d (%s).", fieldID, fieldMeta.Tag)
+			}
+
+			expanded = append(expanded, newRecord)
+			lines = append(lines, i)
+			found++
+		}
+
+		if found == 0 {
+			return nil, nil, fmt.Errorf("could not find any DCGM field in range %d-%d on line %d", first, last, i)
+		}
+	}
+
+	return expanded, lines, nil
+}
+
+func parseFieldIDRange(from, to string) (uint, uint, error) {
+	first, err := strconv.ParseUint(from, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid field ID '%s'", from)
+	}
+
+	last := first
+	if to != "" {
+		last, err = strconv.ParseUint(to, 10, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid field ID '%s'", to)
+		}
+	}
+
+	if last < first {
+		return 0, 0, fmt.Errorf("invalid field ID range %d-%d", first, last)
+	}
+
+	return uint(first), uint(last), nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+)
+
+const maxFieldSuggestions = 3
+
+// deprecatedFields maps the DCGM field names, which were renamed, to their new names. The names of the
+// 1.x namespace (dcgm.OLD_DCGM_FI) are deprecated as well, and replaced by the field with the same ID.
+var deprecatedFields = map[string]string{
+	"DCGM_FI_DEV_CLOCK_THROTTLE_REASONS": "DCGM_FI_DEV_CLOCKS_EVENT_REASONS",
+}
+
+// replacementOf returns the new name of a deprecated field.
+func replacementOf(name string) (string, bool) {
+	if replacement, ok := deprecatedFields[name]; ok {
+		return replacement, true
+	}
+
+	fieldID, ok := dcgm.OLD_DCGM_FI[name]
+	if !ok {
+		return "", false
+	}
+
+	var replacements []string
+	for newName, id := range dcgm.DCGM_FI {
+		if id == fieldID {
+			if _, deprecated := deprecatedFields[newName]; !deprecated {
+				replacements = append(replacements, newName)
+			}
+		}
+	}
+	if len(replacements) == 0 {
+		return "", false
+	}
+	slices.Sort(replacements)
+
+	return replacements[0], true
+}
+
+// warnIfDeprecated logs the new name of a deprecated field.
+func warnIfDeprecated(line int, name string) {
+	if replacement, ok := replacementOf(name); ok {
+		slog.Warn(fmt.Sprintf("Line %d: field '%s' is deprecated, use '%s' instead", line, name, replacement))
+	}
+}
+
+// suggestFields returns the known field names closest to the name, by edit distance. Deprecated names are
+// replaced by their new names.
+func suggestFields(name string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	var candidates []candidate
+	add := func(known string) {
+		distance := editDistance(strings.ToUpper(name), strings.ToUpper(known))
+		// Names, which differ in more than a third of the characters, are not suggested
+		if distance <= max(len(name)/3, 1) {
+			candidates = append(candidates, candidate{name: known, distance: distance})
+		}
+	}
+
+	for known := range dcgm.DCGM_FI {
+		add(known)
+	}
+	for known := range dcgm.OLD_DCGM_FI {
+		add(known)
+	}
+	for known := range DCGMFields {
+		if known != DCGMFIUnknown.String() {
+			add(known)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	var suggestions []string
+	for _, c := range candidates {
+		suggestion := c.name
+		if replacement, ok := replacementOf(c.name); ok {
+			suggestion = fmt.Sprintf("%s (deprecated, renamed to %s)", c.name, replacement)
+		}
+		suggestions = append(suggestions, suggestion)
+		if len(suggestions) == maxFieldSuggestions {
+			break
+		}
+	}
+
+	return suggestions
+}
+
+// unknownFieldError returns the error for an unknown field, with suggestions of known field names.
+func unknownFieldError(name string, err error) error {
+	suggestions := suggestFields(name)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("could not find DCGM field; err: %w", err)
+	}
+
+	return fmt.Errorf("could not find DCGM field; err: %w; did you mean %s?", err, strings.Join(suggestions, ", "))
+}
+
+// editDistance returns the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
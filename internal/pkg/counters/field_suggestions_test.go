@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("", ""))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 1, editDistance("DCGM_FI_DEV_GPU_TMP", "DCGM_FI_DEV_GPU_TEMP"))
+}
+
+func TestReplacementOf(t *testing.T) {
+	tests := []struct {
+		name            string
+		wantReplacement string
+		wantOk          bool
+	}{
+		{name: "DCGM_FI_DEV_CLOCK_THROTTLE_REASONS", wantReplacement: "DCGM_FI_DEV_CLOCKS_EVENT_REASONS", wantOk: true},
+		{name: "dcgm_gpu_temp", wantReplacement: "DCGM_FI_DEV_GPU_TEMP", wantOk: true},
+		{name: "DCGM_FI_DEV_GPU_TEMP"},
+		{name: "DCGM_EXP_XID_ERRORS_COUNT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replacement, ok := replacementOf(tt.name)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantReplacement, replacement)
+		})
+	}
+}
+
+func TestExtractCounters_UnknownFieldSuggestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		wantErr string
+	}{
+		{
+			name:    "Misspelled DCGM field",
+			field:   "DCGM_FI_DEV_GPU_TMP",
+			wantErr: "did you mean DCGM_FI_DEV_GPU_TEMP",
+		},
+		{
+			name:    "Misspelled exporter field",
+			field:   "DCGM_EXP_XID_ERROR_COUNT",
+			wantErr: "did you mean DCGM_EXP_XID_ERRORS_COUNT",
+		},
+		{
+			name:    "Misspelled deprecated field",
+			field:   "DCGM_FI_DEV_CLOCK_THROTTLE_REASON",
+			wantErr: "DCGM_FI_DEV_CLOCK_THROTTLE_REASONS (deprecated, renamed to DCGM_FI_DEV_CLOCKS_EVENT_REASONS)",
+		},
+		{
+			name:    "No close field",
+			field:   "NOT_A_FIELD_AT_ALL",
+			wantErr: "could not find DCGM field; err: unknown ExporterCounter field 'NOT_A_FIELD_AT_ALL'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExtractCounters([][]string{{tt.field, "gauge", "help"}}, &appconfig.Config{})
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExtractCounters_DeprecatedField(t *testing.T) {
+	cs, err := ExtractCounters([][]string{{"DCGM_FI_DEV_CLOCK_THROTTLE_REASONS", "gauge", "help"}},
+		&appconfig.Config{})
+	require.NoError(t, err)
+	require.Len(t, cs.DCGMCounters, 1)
+	assert.Equal(t, "DCGM_FI_DEV_CLOCK_THROTTLE_REASONS", cs.DCGMCounters[0].FieldName)
+}
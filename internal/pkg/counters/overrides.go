@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"slices"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+// Overrides are the counters added to and removed from the counters file at runtime, e.g. through the admin API.
+// They are applied again, whenever the counters file is reloaded.
+type Overrides struct {
+	// Added are the CSV records of the added counters
+	Added [][]string
+	// Removed are the field names of the removed counters
+	Removed []string
+}
+
+// Update returns the overrides with the counters of the records added and the fields removed. A field, which is
+// added again, replaces its previous record, and a removed field is collected again, when it is added.
+func (o Overrides) Update(added [][]string, removed []string) Overrides {
+	var next Overrides
+
+	for _, record := range o.Added {
+		if !slices.Contains(removed, record[0]) &&
+			!slices.ContainsFunc(added, func(r []string) bool { return r[0] == record[0] }) {
+			next.Added = append(next.Added, record)
+		}
+	}
+	next.Added = append(next.Added, added...)
+
+	for _, field := range o.Removed {
+		if !slices.ContainsFunc(added, func(r []string) bool { return r[0] == field }) {
+			next.Removed = append(next.Removed, field)
+		}
+	}
+	for _, field := range removed {
+		if !slices.Contains(next.Removed, field) {
+			next.Removed = append(next.Removed, field)
+		}
+	}
+
+	return next
+}
+
+// IsEmpty reports whether the overrides don't change the counters.
+func (o Overrides) IsEmpty() bool {
+	return len(o.Added) == 0 && len(o.Removed) == 0
+}
+
+// Apply removes the counters of the removed fields from the counter set, and adds the counters of the added
+// records, replacing the counters of the same fields.
+func (o Overrides) Apply(cs *CounterSet, c *appconfig.Config) error {
+	if o.IsEmpty() {
+		return nil
+	}
+
+	added, err := ExtractCounters(o.Added, c)
+	if err != nil {
+		return err
+	}
+
+	// Fields can be added by field ID, so the added fields are identified by the names of their counters
+	fields := slices.Clone(o.Removed)
+	for _, counter := range slices.Concat(added.DCGMCounters, added.ExporterCounters) {
+		fields = append(fields, counter.FieldName)
+	}
+	for _, skipped := range added.Skipped {
+		fields = append(fields, skipped.FieldName)
+	}
+
+	replaced := func(counter Counter) bool { return slices.Contains(fields, counter.FieldName) }
+	cs.DCGMCounters = append(slices.DeleteFunc(cs.DCGMCounters, replaced), added.DCGMCounters...)
+	cs.ExporterCounters = append(slices.DeleteFunc(cs.ExporterCounters, replaced), added.ExporterCounters...)
+	cs.Skipped = append(slices.DeleteFunc(cs.Skipped, func(skipped SkippedCounter) bool {
+		return slices.Contains(fields, skipped.FieldName)
+	}), added.Skipped...)
+
+	return checkMetricNames(*cs)
+}
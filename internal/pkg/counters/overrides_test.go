@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestOverrides_Update(t *testing.T) {
+	var overrides Overrides
+	assert.True(t, overrides.IsEmpty())
+
+	overrides = overrides.Update([][]string{{"DCGM_FI_DEV_SM_CLOCK", "gauge", "SM clock"}},
+		[]string{"DCGM_FI_DEV_GPU_TEMP"})
+	assert.Equal(t, Overrides{
+		Added:   [][]string{{"DCGM_FI_DEV_SM_CLOCK", "gauge", "SM clock"}},
+		Removed: []string{"DCGM_FI_DEV_GPU_TEMP"},
+	}, overrides)
+
+	// Adding a removed field collects it again, and removing an added field drops it
+	overrides = overrides.Update([][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"}},
+		[]string{"DCGM_FI_DEV_SM_CLOCK"})
+	assert.Equal(t, Overrides{
+		Added:   [][]string{{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"}},
+		Removed: []string{"DCGM_FI_DEV_SM_CLOCK"},
+	}, overrides)
+}
+
+func TestOverrides_Apply(t *testing.T) {
+	config := &appconfig.Config{}
+	cs, err := ExtractCounters([][]string{
+		{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+		{"DCGM_FI_DEV_POWER_USAGE", "gauge", "power"},
+	}, config)
+	require.NoError(t, err)
+
+	overrides := Overrides{}.Update([][]string{
+		{"DCGM_FI_DEV_POWER_USAGE", "counter", "power"},
+		{"DCGM_FI_DEV_SM_CLOCK", "gauge", "SM clock"},
+	}, []string{"DCGM_FI_DEV_GPU_TEMP"})
+	require.NoError(t, overrides.Apply(cs, config))
+
+	var got []string
+	for _, counter := range cs.DCGMCounters {
+		got = append(got, counter.FieldName+":"+counter.PromType)
+	}
+	assert.Equal(t, []string{"DCGM_FI_DEV_POWER_USAGE:counter", "DCGM_FI_DEV_SM_CLOCK:gauge"}, got)
+
+	invalid := Overrides{}.Update([][]string{{"DCGM_FI_DEV_SM_CLOCK", "gauge|unknown", "SM clock"}}, nil)
+	assert.Error(t, invalid.Apply(cs, config))
+}
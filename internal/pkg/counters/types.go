@@ -17,6 +17,9 @@
 package counters
 
 import (
+	"strings"
+	"time"
+
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 )
 
@@ -25,14 +28,131 @@ type Counter struct {
 	FieldName string
 	PromType  string
 	Help      string
+	// Views contains additional output representations of the field, separated by '|'.
+	Views string
+	// LabelGroups contains the groups of labels attached to the series of the field, separated by '|'.
+	// All groups are attached, when it is empty.
+	LabelGroups string
+	// Name is the name of the metric family, when it differs from the field name.
+	Name string
+	// Interval is how often DCGM updates the field, when it differs from the collect interval.
+	Interval time.Duration
+}
+
+// MetricName returns the name of the metric family of the counter.
+func (c Counter) MetricName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.FieldName
+}
+
+// WithPrefix returns the counter, which metric name has the namespace of the field name, e.g. DCGM_FI_DEV_, replaced
+// by the prefix. Labels and renamed counters are returned unchanged.
+func (c Counter) WithPrefix(prefix string) Counter {
+	if prefix == "" || c.Name != "" || c.IsLabel() {
+		return c
+	}
+
+	name := c.FieldName
+	for _, namespace := range fieldNamespaces {
+		if strings.HasPrefix(name, namespace) {
+			name = strings.TrimPrefix(name, namespace)
+			break
+		}
+	}
+	c.Name = prefix + name
+
+	return c
+}
+
+// WithSuffix returns the counter, which field name and metric name have the suffix.
+func (c Counter) WithSuffix(suffix string) Counter {
+	c.FieldName += suffix
+	if c.Name != "" {
+		c.Name += suffix
+	}
+
+	return c
 }
 
 func (c Counter) IsLabel() bool {
 	return c.PromType == "label"
 }
 
+// HasLabelGroup reports whether the labels of the group are attached to the series of the counter.
+func (c Counter) HasLabelGroup(group string) bool {
+	if c.LabelGroups == "" {
+		return true
+	}
+
+	for _, name := range strings.Split(c.LabelGroups, viewSeparator) {
+		if name == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// View is an additional representation of a counter, rendered as a separate metric family.
+type View struct {
+	Counter Counter
+	// Rate is true when the view values are computed as per-second rate of the counter values.
+	Rate bool
+}
+
+// ExpandViews returns the additional representations of the counter.
+func (c Counter) ExpandViews() []View {
+	if c.Views == "" {
+		return nil
+	}
+
+	var views []View
+	for _, name := range strings.Split(c.Views, viewSeparator) {
+		spec, exists := viewSpecs[name]
+		if !exists {
+			continue
+		}
+		counter := Counter{
+			FieldID:     c.FieldID,
+			FieldName:   c.FieldName,
+			PromType:    spec.promType,
+			Help:        c.Help + spec.help,
+			LabelGroups: c.LabelGroups,
+			Name:        c.Name,
+		}
+		views = append(views, View{
+			Counter: counter.WithSuffix(spec.suffix),
+			Rate:    spec.rate,
+		})
+	}
+
+	return views
+}
+
 type CounterList []Counter
 
+// FieldIntervals returns the update intervals of the fields with their own interval. A field listed several times is
+// updated with the shortest of its intervals.
+func (c CounterList) FieldIntervals() map[dcgm.Short]time.Duration {
+	var intervals map[dcgm.Short]time.Duration
+	for _, counter := range c {
+		if counter.Interval == 0 {
+			continue
+		}
+		if intervals == nil {
+			intervals = map[dcgm.Short]time.Duration{}
+		}
+		if interval, exists := intervals[counter.FieldID]; !exists || counter.Interval < interval {
+			intervals[counter.FieldID] = counter.Interval
+		}
+	}
+
+	return intervals
+}
+
 func (c CounterList) LabelCounters() CounterList {
 	var labelsCounters CounterList
 	for _, counter := range c {
@@ -44,7 +164,23 @@ func (c CounterList) LabelCounters() CounterList {
 	return labelsCounters
 }
 
+const (
+	// SkipCodeProfilingDisabled is the code of counters skipped, because profiling metrics are not collected
+	SkipCodeProfilingDisabled = "profiling_disabled"
+	// SkipCodeProfilingUnsupported is the code of counters skipped, because the GPU doesn't support them
+	SkipCodeProfilingUnsupported = "profiling_unsupported"
+)
+
+// SkippedCounter is a counter from the counters file, which is not collected.
+type SkippedCounter struct {
+	FieldName string
+	// Code is a short, stable identifier of the reason, suitable as a label value
+	Code   string
+	Reason string
+}
+
 type CounterSet struct {
 	DCGMCounters     CounterList
 	ExporterCounters CounterList
+	Skipped          []SkippedCounter
 }
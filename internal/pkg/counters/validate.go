@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+// ValidateRecords checks every record of a counters file against the capabilities described by the config.
+// Unlike ExtractCounters, it doesn't stop on the first malformed record and it reports the records,
+// which the exporter would skip, for example profiling metrics not supported by the GPUs.
+func ValidateRecords(records [][]string, c *appconfig.Config) error {
+	var errs []error
+
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+
+		expanded, _, err := expandFieldIDs(atLine(i, record))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, r := range expanded {
+			if len(r) == 0 {
+				continue
+			}
+
+			cs, err := ExtractCounters(atLine(i, r), c)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if len(cs.DCGMCounters)+len(cs.ExporterCounters) == 0 {
+				errs = append(errs, fmt.Errorf("line %d ('%s'): metric is not enabled and would be skipped", i, r[0]))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// atLine returns records, where the record is preceded by empty records, so errors report its original line.
+func atLine(line int, record []string) [][]string {
+	records := make([][]string, line+1)
+	records[line] = record
+
+	return records
+}
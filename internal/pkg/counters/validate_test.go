@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestValidateRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		config  *appconfig.Config
+		wantErr []string
+	}{
+		{
+			name: "Valid records",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+				{},
+				{"DCGM_EXP_XID_ERRORS_COUNT", "gauge", "xid"},
+			},
+			config: &appconfig.Config{},
+		},
+		{
+			name: "All problems are reported with their lines",
+			records: [][]string{
+				{"DCGM_FI_DEV_GPU_TEMP", "gauge", "temperature"},
+				{"DCGM_FI_DEV_UNKNOWN", "gauge", "unknown"},
+				{"DCGM_FI_DEV_POWER_USAGE", "gauge"},
+				{"DCGM_FI_PROF_GR_ENGINE_ACTIVE", "gauge", "profiling"},
+			},
+			config: &appconfig.Config{},
+			wantErr: []string{
+				"could not find DCGM field; err: unknown ExporterCounter field 'DCGM_FI_DEV_UNKNOWN'",
+				"failed to parse line 2",
+				"line 3 ('DCGM_FI_PROF_GR_ENGINE_ACTIVE'): metric is not enabled and would be skipped",
+			},
+		},
+		{
+			name: "Profiling metrics supported by the GPUs",
+			records: [][]string{
+				{"DCGM_FI_PROF_GR_ENGINE_ACTIVE", "gauge", "profiling"},
+			},
+			config: &appconfig.Config{
+				CollectDCP: true,
+				MetricGroups: []dcgm.MetricGroup{
+					{FieldIds: []uint{dcgm.DCGM_FI_PROF_GR_ENGINE_ACTIVE}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRecords(tt.records, tt.config)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
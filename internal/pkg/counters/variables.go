@@ -27,3 +27,35 @@ var promMetricType = map[string]bool{
 	"summary":   true,
 	"label":     true,
 }
+
+type viewSpec struct {
+	suffix   string
+	promType string
+	help     string
+	rate     bool
+}
+
+// viewSpecs contains the supported additional representations of a field.
+var viewSpecs = map[string]viewSpec{
+	"counter": {suffix: "_total", promType: "counter"},
+	"gauge":   {suffix: "_gauge", promType: "gauge"},
+	"rate":    {suffix: "_rate", promType: "gauge", help: " (per second rate)", rate: true},
+}
+
+// labelGroups contains the groups of labels, which can be selected per field.
+var labelGroups = map[string]bool{
+	LabelGroupDevice:   true,
+	LabelGroupPod:      true,
+	LabelGroupHostname: true,
+	LabelGroupCustom:   true,
+}
+
+// fieldNamespaces are the prefixes of the field names, which the metric prefix replaces. The more specific ones come
+// first.
+var fieldNamespaces = []string{
+	"DCGM_FI_DEV_",
+	"DCGM_FI_PROF_",
+	"DCGM_FI_DRIVER_",
+	"DCGM_FI_",
+	"DCGM_EXP_",
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package counters
+
+import (
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+)
+
+// WatchedCounters returns the DCGM counters of the counter set, together with the DCGM fields, which the exporter
+// counters are computed from, e.g. DCGM_FI_DEV_XID_ERRORS for DCGM_EXP_XID_ERRORS_COUNT.
+func (cs *CounterSet) WatchedCounters() CounterList {
+	var allCounters CounterList
+
+	allCounters = append(allCounters, cs.DCGMCounters...)
+
+	allCounters = appendDCGMXIDErrorsCountDependency(allCounters, cs)
+	allCounters = appendDCGMClockEventsCountDependency(cs, allCounters)
+
+	return allCounters
+}
+
+// CopyLabelCounters copies the label counters from the DCGM counters to the exporter counters, so that the exporter
+// collectors attach the labels as well.
+func (cs *CounterSet) CopyLabelCounters() {
+	for i := range cs.DCGMCounters {
+		if cs.DCGMCounters[i].PromType == "label" {
+			cs.ExporterCounters = append(cs.ExporterCounters, cs.DCGMCounters[i])
+		}
+	}
+}
+
+// appendDCGMXIDErrorsCountDependency appends DCGM counters required for the DCGM_EXP_CLOCK_EVENTS_COUNT metric
+func appendDCGMClockEventsCountDependency(
+	cs *CounterSet, allCounters []Counter,
+) []Counter {
+	if len(cs.ExporterCounters) > 0 {
+		if containsField(cs.ExporterCounters, DCGMClockEventsCount) &&
+			!containsField(allCounters, dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS) {
+			allCounters = append(allCounters,
+				Counter{
+					FieldID: dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS,
+				})
+		}
+	}
+	return allCounters
+}
+
+// appendDCGMXIDErrorsCountDependency appends DCGM counters required for the DCGM_EXP_XID_ERRORS_COUNT and
+// DCGM_EXP_XID_ERRORS_TOTAL metrics
+func appendDCGMXIDErrorsCountDependency(
+	allCounters []Counter, cs *CounterSet,
+) []Counter {
+	if len(cs.ExporterCounters) > 0 {
+		if (containsField(cs.ExporterCounters, DCGMXIDErrorsCount) ||
+			containsField(cs.ExporterCounters, DCGMXIDErrorsTotal) ||
+			containsField(cs.ExporterCounters, DCGMXIDLastSeenTimestamp)) &&
+			!containsField(allCounters, dcgm.DCGM_FI_DEV_XID_ERRORS) {
+			allCounters = append(allCounters,
+				Counter{
+					FieldID: dcgm.DCGM_FI_DEV_XID_ERRORS,
+				})
+		}
+	}
+	return allCounters
+}
+
+func containsField(slice []Counter, fieldID ExporterCounter) bool {
+	return slices.ContainsFunc(slice, func(counter Counter) bool {
+		return counter.FieldID == dcgm.Short(fieldID)
+	})
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cputuning sizes GOMAXPROCS and the number of collectors gathering metrics concurrently from the CPU
+// limit of the container, so that a CPU-limited exporter doesn't run more threads than its quota allows, and
+// the collection doesn't starve the rendering of the metrics.
+package cputuning
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+
+	"go.uber.org/automaxprocs/maxprocs"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+// Sources of the tuned values
+const (
+	SourceOverride    = "override"
+	SourceEnvironment = "environment"
+	SourceCPUQuota    = "cpu_quota"
+	SourceDefault     = "default"
+)
+
+var (
+	goMaxProcsGauge = selfmetrics.Default().Gauge("dcgm_exporter_gomaxprocs",
+		"GOMAXPROCS of the exporter, by source: override, environment, cpu_quota or default.")
+	collectWorkersGauge = selfmetrics.Default().Gauge("dcgm_exporter_collect_workers",
+		"Maximum number of collectors gathering metrics concurrently, by source: override or the source of GOMAXPROCS.")
+)
+
+// setMaxProcs sets GOMAXPROCS from the CPU quota of the container, and logs what it did with logf
+var setMaxProcs = func(logf func(string, ...interface{})) error {
+	_, err := maxprocs.Set(maxprocs.Logger(logf))
+	return err
+}
+
+// Settings are the tuned values and where they come from.
+type Settings struct {
+	GoMaxProcs           int
+	GoMaxProcsSource     string
+	CollectWorkers       int
+	CollectWorkersSource string
+}
+
+// Tune sets GOMAXPROCS and chooses the number of collect workers. Positive goMaxProcs and collectWorkers
+// override the values derived from the CPU quota. Without a CPU quota, GOMAXPROCS is left to the Go runtime.
+// The number of collect workers follows GOMAXPROCS, unless it is overridden.
+func Tune(goMaxProcs, collectWorkers int) Settings {
+	settings := Settings{GoMaxProcsSource: SourceDefault}
+
+	if goMaxProcs > 0 {
+		runtime.GOMAXPROCS(goMaxProcs)
+		settings.GoMaxProcsSource = SourceOverride
+	} else {
+		// The Go runtime and automaxprocs honor the GOMAXPROCS environment variable, otherwise GOMAXPROCS only
+		// changes, when automaxprocs derives it from the CPU quota
+		before := runtime.GOMAXPROCS(0)
+		err := setMaxProcs(func(format string, args ...interface{}) {
+			slog.Debug(fmt.Sprintf(format, args...))
+		})
+		if err != nil {
+			slog.Warn("Failed to set GOMAXPROCS from the CPU quota", slog.String(logging.ErrorKey, err.Error()))
+		}
+
+		if _, exists := os.LookupEnv("GOMAXPROCS"); exists {
+			settings.GoMaxProcsSource = SourceEnvironment
+		} else if runtime.GOMAXPROCS(0) != before {
+			settings.GoMaxProcsSource = SourceCPUQuota
+		}
+	}
+	settings.GoMaxProcs = runtime.GOMAXPROCS(0)
+
+	settings.CollectWorkers, settings.CollectWorkersSource = settings.GoMaxProcs, settings.GoMaxProcsSource
+	if collectWorkers > 0 {
+		settings.CollectWorkers, settings.CollectWorkersSource = collectWorkers, SourceOverride
+	}
+
+	goMaxProcsGauge.Reset()
+	goMaxProcsGauge.Set(float64(settings.GoMaxProcs), "source", settings.GoMaxProcsSource)
+	collectWorkersGauge.Reset()
+	collectWorkersGauge.Set(float64(settings.CollectWorkers), "source", settings.CollectWorkersSource)
+
+	slog.Info("CPU tuning",
+		slog.Int("gomaxprocs", settings.GoMaxProcs),
+		slog.String("gomaxprocsSource", settings.GoMaxProcsSource),
+		slog.Int("collectWorkers", settings.CollectWorkers),
+		slog.String("collectWorkersSource", settings.CollectWorkersSource),
+	)
+
+	return settings
+}
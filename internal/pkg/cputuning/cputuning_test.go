@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cputuning
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestTune(t *testing.T) {
+	tests := []struct {
+		name           string
+		environment    string
+		quotaProcs     int
+		goMaxProcs     int
+		collectWorkers int
+		want           Settings
+	}{
+		{
+			name:       "CPU quota",
+			quotaProcs: 2,
+			want: Settings{
+				GoMaxProcs:           2,
+				GoMaxProcsSource:     SourceCPUQuota,
+				CollectWorkers:       2,
+				CollectWorkersSource: SourceCPUQuota,
+			},
+		},
+		{
+			name:           "CPU quota with overridden workers",
+			quotaProcs:     2,
+			collectWorkers: 8,
+			want: Settings{
+				GoMaxProcs:           2,
+				GoMaxProcsSource:     SourceCPUQuota,
+				CollectWorkers:       8,
+				CollectWorkersSource: SourceOverride,
+			},
+		},
+		{
+			name:        "Environment",
+			environment: "3",
+			want: Settings{
+				GoMaxProcs:           3,
+				GoMaxProcsSource:     SourceEnvironment,
+				CollectWorkers:       3,
+				CollectWorkersSource: SourceEnvironment,
+			},
+		},
+		{
+			name:       "CPU quota equal to the CPUs",
+			quotaProcs: 3,
+			want: Settings{
+				GoMaxProcs:           3,
+				GoMaxProcsSource:     SourceDefault,
+				CollectWorkers:       3,
+				CollectWorkersSource: SourceDefault,
+			},
+		},
+		{
+			name:       "Override",
+			quotaProcs: 2,
+			goMaxProcs: 5,
+			want: Settings{
+				GoMaxProcs:           5,
+				GoMaxProcsSource:     SourceOverride,
+				CollectWorkers:       5,
+				CollectWorkersSource: SourceOverride,
+			},
+		},
+		{
+			name: "No CPU quota",
+			want: Settings{
+				GoMaxProcs:           3,
+				GoMaxProcsSource:     SourceDefault,
+				CollectWorkers:       3,
+				CollectWorkersSource: SourceDefault,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+
+			t.Setenv("GOMAXPROCS", tt.environment)
+			if tt.environment == "" {
+				require.NoError(t, os.Unsetenv("GOMAXPROCS"))
+			}
+
+			realSetMaxProcs := setMaxProcs
+			defer func() { setMaxProcs = realSetMaxProcs }()
+			setMaxProcs = func(func(string, ...interface{})) error {
+				if tt.quotaProcs > 0 {
+					runtime.GOMAXPROCS(tt.quotaProcs)
+				}
+				return nil
+			}
+
+			assert.Equal(t, tt.want, Tune(tt.goMaxProcs, tt.collectWorkers))
+
+			value, exists := selfmetrics.Default().Value("dcgm_exporter_gomaxprocs", "source", tt.want.GoMaxProcsSource)
+			assert.True(t, exists)
+			assert.Equal(t, float64(tt.want.GoMaxProcs), value)
+
+			value, exists = selfmetrics.Default().Value("dcgm_exporter_collect_workers", "source",
+				tt.want.CollectWorkersSource)
+			assert.True(t, exists)
+			assert.Equal(t, float64(tt.want.CollectWorkers), value)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dashboardmodel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+const (
+	subsystemOther = "other"
+
+	panelTimeSeries = "timeseries"
+	panelGauge      = "gauge"
+
+	unitNone = "short"
+)
+
+type rule struct {
+	patterns []string
+	value    string
+}
+
+// subsystemRules are evaluated in order; the first rule with a pattern contained in the field name wins.
+var subsystemRules = []rule{
+	{patterns: []string{"_ENC_", "_DEC_", "ENCODER", "DECODER"}, value: "video"},
+	{patterns: []string{"PROF_"}, value: "profiling"},
+	{patterns: []string{"_CPU_"}, value: "cpu"},
+	{patterns: []string{"NVLINK", "PCIE", "NVSWITCH", "C2C"}, value: "interconnect"},
+	{patterns: []string{"XID", "ECC", "RETIRED", "REMAP", "HEALTH"}, value: "reliability"},
+	{patterns: []string{"CLOCK"}, value: "clocks"},
+	{patterns: []string{"TEMP", "POWER", "ENERGY"}, value: "power_thermal"},
+	{patterns: []string{"_UTIL", "_ACTIVE"}, value: "utilization"},
+	{patterns: []string{"_FB_", "_MEM"}, value: "memory"},
+}
+
+// unitRules are evaluated in order; the first rule with a pattern contained in the field name wins.
+var unitRules = []rule{
+	{patterns: []string{"_BYTES", "BANDWIDTH"}, value: "bytes"},
+	{patterns: []string{"_ACTIVE"}, value: "percentunit"},
+	{patterns: []string{"_UTIL"}, value: "percent"},
+	{patterns: []string{"TEMP"}, value: "celsius"},
+	{patterns: []string{"POWER_USAGE", "POWER_LIMIT"}, value: "watt"},
+	{patterns: []string{"_CLOCK"}, value: "rotmhz"},
+	{patterns: []string{"_FB_"}, value: "mbytes"},
+	{patterns: []string{"_TIME"}, value: "µs"},
+}
+
+// Build describes the enabled counters, grouped by subsystem. Label counters are not included.
+func Build(cs *counters.CounterSet) Model {
+	subsystems := map[string][]Panel{}
+
+	for _, counter := range cs.DCGMCounters {
+		if counter.IsLabel() {
+			continue
+		}
+		fieldMeta := dcgmprovider.Client().FieldGetById(counter.FieldID)
+		addPanel(subsystems, counter, fieldMeta.EntityLevel.String())
+	}
+
+	for _, counter := range cs.ExporterCounters {
+		if counter.IsLabel() {
+			continue
+		}
+		addPanel(subsystems, counter, dcgm.FE_GPU.String())
+	}
+
+	names := make([]string, 0, len(subsystems))
+	for name := range subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	model := Model{Subsystems: make([]Subsystem, 0, len(names))}
+	for _, name := range names {
+		model.Subsystems = append(model.Subsystems, Subsystem{Name: name, Panels: subsystems[name]})
+	}
+
+	return model
+}
+
+func addPanel(subsystems map[string][]Panel, counter counters.Counter, entity string) {
+	subsystem := match(subsystemRules, counter.FieldName, subsystemOther)
+	subsystems[subsystem] = append(subsystems[subsystem], newPanel(counter, entity))
+}
+
+func newPanel(counter counters.Counter, entity string) Panel {
+	panel := Panel{
+		Metric:     counter.FieldName,
+		Help:       counter.Help,
+		MetricType: counter.PromType,
+		Entity:     entity,
+		PanelType:  panelTimeSeries,
+		Unit:       match(unitRules, counter.FieldName, unitNone),
+		Expr:       counter.FieldName,
+	}
+
+	switch {
+	case counter.PromType == "counter":
+		panel.Expr = fmt.Sprintf("rate(%s[$__rate_interval])", counter.FieldName)
+	case panel.Unit == "percent" || panel.Unit == "percentunit":
+		panel.PanelType = panelGauge
+	}
+
+	return panel
+}
+
+func match(rules []rule, fieldName, fallback string) string {
+	for _, r := range rules {
+		for _, pattern := range r.patterns {
+			if strings.Contains(fieldName, pattern) {
+				return r.value
+			}
+		}
+	}
+
+	return fallback
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dashboardmodel
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+func TestBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().FieldGetById(gomock.Any()).DoAndReturn(func(fieldID dcgm.Short) dcgm.FieldMeta {
+		if fieldID == dcgm.DCGM_FI_DEV_CPU_UTIL_TOTAL {
+			return dcgm.FieldMeta{FieldId: fieldID, EntityLevel: dcgm.FE_CPU}
+		}
+		return dcgm.FieldMeta{FieldId: fieldID, EntityLevel: dcgm.FE_GPU}
+	}).AnyTimes()
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	cs := &counters.CounterSet{
+		DCGMCounters: counters.CounterList{
+			{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge", Help: "GPU utilization (in %)."},
+			{FieldID: dcgm.DCGM_FI_DEV_SM_CLOCK, FieldName: "DCGM_FI_DEV_SM_CLOCK", PromType: "gauge", Help: "SM clock frequency (in MHz)."},
+			{FieldID: dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER, FieldName: "DCGM_FI_DEV_PCIE_REPLAY_COUNTER", PromType: "counter", Help: "Total number of PCIe retries."},
+			{FieldID: dcgm.DCGM_FI_DEV_CPU_UTIL_TOTAL, FieldName: "DCGM_FI_DEV_CPU_UTIL_TOTAL", PromType: "gauge", Help: "CPU utilization."},
+			{FieldID: dcgm.DCGM_FI_DRIVER_VERSION, FieldName: "DCGM_FI_DRIVER_VERSION", PromType: "label", Help: "Driver version."},
+		},
+		ExporterCounters: counters.CounterList{
+			{FieldID: dcgm.Short(counters.DCGMXIDErrorsCount), FieldName: counters.DCGMExpXIDErrorsCount, PromType: "gauge", Help: "Count of XID Errors."},
+		},
+	}
+
+	model := Build(cs)
+
+	expected := Model{
+		Subsystems: []Subsystem{
+			{
+				Name: "clocks",
+				Panels: []Panel{
+					{
+						Metric:     "DCGM_FI_DEV_SM_CLOCK",
+						Help:       "SM clock frequency (in MHz).",
+						MetricType: "gauge",
+						Entity:     "GPU",
+						PanelType:  "timeseries",
+						Unit:       "rotmhz",
+						Expr:       "DCGM_FI_DEV_SM_CLOCK",
+					},
+				},
+			},
+			{
+				Name: "cpu",
+				Panels: []Panel{
+					{
+						Metric:     "DCGM_FI_DEV_CPU_UTIL_TOTAL",
+						Help:       "CPU utilization.",
+						MetricType: "gauge",
+						Entity:     "CPU",
+						PanelType:  "gauge",
+						Unit:       "percent",
+						Expr:       "DCGM_FI_DEV_CPU_UTIL_TOTAL",
+					},
+				},
+			},
+			{
+				Name: "interconnect",
+				Panels: []Panel{
+					{
+						Metric:     "DCGM_FI_DEV_PCIE_REPLAY_COUNTER",
+						Help:       "Total number of PCIe retries.",
+						MetricType: "counter",
+						Entity:     "GPU",
+						PanelType:  "timeseries",
+						Unit:       "short",
+						Expr:       "rate(DCGM_FI_DEV_PCIE_REPLAY_COUNTER[$__rate_interval])",
+					},
+				},
+			},
+			{
+				Name: "reliability",
+				Panels: []Panel{
+					{
+						Metric:     "DCGM_EXP_XID_ERRORS_COUNT",
+						Help:       "Count of XID Errors.",
+						MetricType: "gauge",
+						Entity:     "GPU",
+						PanelType:  "timeseries",
+						Unit:       "short",
+						Expr:       "DCGM_EXP_XID_ERRORS_COUNT",
+					},
+				},
+			},
+			{
+				Name: "utilization",
+				Panels: []Panel{
+					{
+						Metric:     "DCGM_FI_DEV_GPU_UTIL",
+						Help:       "GPU utilization (in %).",
+						MetricType: "gauge",
+						Entity:     "GPU",
+						PanelType:  "gauge",
+						Unit:       "percent",
+						Expr:       "DCGM_FI_DEV_GPU_UTIL",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, model)
+}
+
+func TestBuild_Empty(t *testing.T) {
+	model := Build(&counters.CounterSet{})
+	assert.NotNil(t, model.Subsystems)
+	assert.Empty(t, model.Subsystems)
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dashboardmodel
+
+// Model is a machine-readable description of the enabled counters, which can be converted into a dashboard.
+type Model struct {
+	Subsystems []Subsystem `json:"subsystems"`
+}
+
+// Subsystem groups panels of related counters, for example clocks or memory.
+type Subsystem struct {
+	Name   string  `json:"name"`
+	Panels []Panel `json:"panels"`
+}
+
+// Panel describes a single counter and how it is suggested to be visualized.
+// Unit contains a Grafana unit ID.
+type Panel struct {
+	Metric     string `json:"metric"`
+	Help       string `json:"help"`
+	MetricType string `json:"metricType"`
+	Entity     string `json:"entity"`
+	PanelType  string `json:"panelType"`
+	Unit       string `json:"unit"`
+	Expr       string `json:"expr"`
+}
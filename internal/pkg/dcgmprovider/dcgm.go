@@ -17,9 +17,11 @@
 package dcgmprovider
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
@@ -27,11 +29,60 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 )
 
-var dcgmInterface DCGM
+var (
+	dcgmInterface DCGM
+	// connectionLost holds a pending report of a lost connection to the remote hostengine
+	connectionLost = make(chan struct{}, 1)
+	// connection counts the reconnections to the remote hostengine
+	connection atomic.Uint64
+)
 
-// Initialize sets up the Singleton DCGM interface using the provided configuration.
+// Initialize sets up the Singleton DCGM interface using the provided configuration. The exporter exits, when DCGM
+// cannot be initialized.
 func Initialize(config *appconfig.Config) {
-	dcgmInterface = newDCGMProvider(config)
+	if err := TryInitialize(config); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// TryInitialize sets up the Singleton DCGM interface like Initialize, but returns the error, when DCGM cannot be
+// initialized, so that programs embedding the exporter can handle it. With the NVML-only mode, the interface is served
+// by NVML, which has to be initialized first. Faults are injected into the interface, when the FaultInjectionEnv
+// environment variable is set.
+func TryInitialize(config *appconfig.Config) error {
+	if config.NVMLOnly {
+		backend, err := newNVMLBackend()
+		if err != nil {
+			return err
+		}
+		dcgmInterface = backend
+	} else {
+		client, err := newDCGMProvider(config)
+		if err != nil {
+			return err
+		}
+		dcgmInterface = client
+	}
+
+	return injectFaults()
+}
+
+// injectFaults wraps the DCGM interface by the injection of the faults of the FaultInjectionEnv environment
+// variable, if it is set.
+func injectFaults() error {
+	spec, set := os.LookupEnv(FaultInjectionEnv)
+	if _, injected := dcgmInterface.(*faultInjector); !set || injected {
+		return nil
+	}
+
+	faults, err := ParseFaults(spec)
+	if err != nil {
+		return fmt.Errorf("invalid %s environment variable; err: %w", FaultInjectionEnv, err)
+	}
+	slog.Warn("Injecting faults into the calls to DCGM", slog.String("faults", spec))
+	dcgmInterface = WithFaults(dcgmInterface, faults)
+	return nil
 }
 
 // reset clears the current DCGM interface instance.
@@ -49,6 +100,76 @@ func SetClient(d DCGM) {
 	dcgmInterface = d
 }
 
+// ReportConnectionLost reports, that the connection to the remote hostengine was lost. Reports are coalesced, until
+// the connection is established again.
+func ReportConnectionLost() {
+	select {
+	case connectionLost <- struct{}{}:
+	default:
+	}
+}
+
+// ConnectionLost receives the reports of a lost connection to the remote hostengine.
+func ConnectionLost() <-chan struct{} {
+	return connectionLost
+}
+
+// Connection identifies the current connection to the remote hostengine. It changes on every reconnection, so that
+// the handles of a former connection, whose IDs may belong to other groups on the current one, aren't released.
+func Connection() uint64 {
+	return connection.Load()
+}
+
+// IsConnectionLost reports whether the error was returned, because the connection to the hostengine is not valid
+// anymore, e.g. after the hostengine restarted.
+func IsConnectionLost(err error) bool {
+	var derr *dcgm.DcgmError
+	return errors.As(err, &derr) && derr.Code == dcgm.DCGM_ST_CONNECTION_NOT_VALID
+}
+
+// Reconnect shuts down the connection to the remote hostengine and connects to it again. The groups and the field
+// watches of the former connection are lost, and have to be created again. The reports of the lost connection are
+// cleared, once it is connected.
+func Reconnect(config *appconfig.Config) error {
+	current := Client()
+	// The faults are injected into the new connection as well
+	injector, injected := current.(*faultInjector)
+	if injected {
+		current = injector.DCGM
+	}
+	setProvider := func(client dcgmProvider) {
+		if injected {
+			injector.DCGM = client
+			return
+		}
+		dcgmInterface = client
+	}
+
+	client, ok := current.(dcgmProvider)
+	if !ok {
+		return errors.New("DCGM is not connected to a remote hostengine")
+	}
+	client.shutdown()
+	client.shutdown = func() {}
+	setProvider(client)
+	connection.Add(1)
+
+	slog.Info("Attempting to reconnect to remote hostengine at " + config.RemoteHEInfo)
+	cleanup, err := dcgm.Init(dcgm.Standalone, config.RemoteHEInfo, "0")
+	if err != nil {
+		cleanup()
+		return err
+	}
+	client.shutdown = cleanup
+	setProvider(client)
+
+	select {
+	case <-connectionLost:
+	default:
+	}
+	return nil
+}
+
 // dcgmProvider implements DCGM Interface
 type dcgmProvider struct {
 	shutdown      func()
@@ -56,11 +177,11 @@ type dcgmProvider struct {
 }
 
 // newDCGMProvider initializes a new DCGM provider based on the provided configuration
-func newDCGMProvider(config *appconfig.Config) DCGM {
+func newDCGMProvider(config *appconfig.Config) (DCGM, error) {
 	// Check if a DCGM client already exists and return it if so.
 	if Client() != nil {
 		slog.Info("DCGM already initialized")
-		return Client()
+		return Client(), nil
 	}
 
 	client := dcgmProvider{}
@@ -71,8 +192,7 @@ func newDCGMProvider(config *appconfig.Config) DCGM {
 		cleanup, err := dcgm.Init(dcgm.Standalone, config.RemoteHEInfo, "0")
 		if err != nil {
 			cleanup()
-			slog.Error(err.Error())
-			os.Exit(1)
+			return nil, err
 		}
 		client.shutdown = cleanup
 	} else {
@@ -85,21 +205,19 @@ func newDCGMProvider(config *appconfig.Config) DCGM {
 		slog.Info("Attempting to initialize DCGM.")
 		cleanup, err := dcgm.Init(dcgm.Embedded)
 		if err != nil {
-			slog.Error(err.Error())
-			os.Exit(1)
+			return nil, err
 		}
 		client.shutdown = cleanup
 	}
 
 	// Initialize the DcgmFields module
 	if val := dcgm.FieldsInit(); val < 0 {
-		slog.Error(fmt.Sprintf("Failed to initialize DCGM Fields module; err: %d", val))
-		os.Exit(1)
-	} else {
-		slog.Info("Initialized DCGM Fields module.")
+		client.shutdown()
+		return nil, fmt.Errorf("failed to initialize DCGM Fields module; err: %d", val)
 	}
+	slog.Info("Initialized DCGM Fields module.")
 
-	return client
+	return client, nil
 }
 
 func (d dcgmProvider) AddEntityToGroup(
@@ -167,6 +285,10 @@ func (d dcgmProvider) GetDeviceInfo(gpuId uint) (dcgm.Device, error) {
 	return dcgm.GetDeviceInfo(gpuId)
 }
 
+func (d dcgmProvider) GetDeviceTopology(gpuId uint) ([]dcgm.P2PLink, error) {
+	return dcgm.GetDeviceTopology(gpuId)
+}
+
 func (d dcgmProvider) GetEntityGroupEntities(entityGroup dcgm.Field_Entity_Group) ([]uint, error) {
 	return dcgm.GetEntityGroupEntities(entityGroup)
 }
@@ -203,6 +325,10 @@ func (d dcgmProvider) InjectFieldValue(
 	return dcgm.InjectFieldValue(gpu, fieldID, fieldType, status, ts, value)
 }
 
+func (d dcgmProvider) Introspect() (dcgm.DcgmStatus, error) {
+	return dcgm.Introspect()
+}
+
 func (d dcgmProvider) LinkGetLatestValues(index uint, parentId uint, fields []dcgm.Short) ([]dcgm.FieldValue_v1,
 	error,
 ) {
@@ -251,6 +377,10 @@ func (d dcgmProvider) HealthCheck(groupID dcgm.GroupHandle) (dcgm.HealthResponse
 	return dcgm.HealthCheck(groupID)
 }
 
+func (d dcgmProvider) RunDiag(diagType dcgm.DiagType, groupID dcgm.GroupHandle) (dcgm.DiagResults, error) {
+	return dcgm.RunDiag(diagType, groupID)
+}
+
 func (d dcgmProvider) GetGroupInfo(groupID dcgm.GroupHandle) (*dcgm.GroupInfo, error) {
 	return dcgm.GetGroupInfo(groupID)
 }
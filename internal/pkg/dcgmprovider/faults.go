@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+)
+
+// FaultInjectionEnv is the environment variable, which enables the injection of faults into the calls to DCGM, e.g.
+// "errors=0.1,blanks=0.2,nans=0.05,delay=2s". It is meant for testing the resilience of the exporter only.
+const FaultInjectionEnv = "DCGM_EXPORTER_FAULT_INJECTION"
+
+// ErrInjectedFault is returned by the calls to DCGM, which failed by an injected fault.
+var ErrInjectedFault = errors.New("injected DCGM fault")
+
+// Faults are the rates of the faults, which are injected into the calls to DCGM, reading the field values.
+type Faults struct {
+	// ErrorRate is the rate of the calls, which fail
+	ErrorRate float64
+	// BlankRate is the rate of the field values, which are replaced by a blank value
+	BlankRate float64
+	// NaNRate is the rate of the field values, which are replaced by a NaN
+	NaNRate float64
+	// Delay is the time, every call is delayed by
+	Delay time.Duration
+}
+
+// ParseFaults parses the faults of the comma separated key=value pairs, e.g. "errors=0.1,delay=2s".
+func ParseFaults(spec string) (Faults, error) {
+	var faults Faults
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return Faults{}, fmt.Errorf("invalid fault %q; expected key=value", pair)
+		}
+
+		var err error
+		switch key {
+		case "errors":
+			faults.ErrorRate, err = parseRate(value)
+		case "blanks":
+			faults.BlankRate, err = parseRate(value)
+		case "nans":
+			faults.NaNRate, err = parseRate(value)
+		case "delay":
+			faults.Delay, err = time.ParseDuration(value)
+			if err == nil && faults.Delay < 0 {
+				err = errors.New("the delay must not be negative")
+			}
+		default:
+			err = errors.New("unknown fault")
+		}
+		if err != nil {
+			return Faults{}, fmt.Errorf("invalid fault %q; err: %w", pair, err)
+		}
+	}
+	return faults, nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, errors.New("the rate must be between 0 and 1")
+	}
+	return rate, nil
+}
+
+// WithFaults returns the DCGM interface, which injects the faults into the calls to the provided one.
+func WithFaults(d DCGM, faults Faults) DCGM {
+	return &faultInjector{DCGM: d, faults: faults, random: rand.Float64, sleep: time.Sleep}
+}
+
+// faultInjector injects faults into the calls to DCGM, which read the field values. The other calls are passed
+// through.
+type faultInjector struct {
+	DCGM
+	faults Faults
+	random func() float64
+	sleep  func(time.Duration)
+}
+
+// call delays the call, and returns the error of the call, if it fails.
+func (f *faultInjector) call(name string) error {
+	if f.faults.Delay > 0 {
+		f.sleep(f.faults.Delay)
+	}
+	if f.faults.ErrorRate > 0 && f.random() < f.faults.ErrorRate {
+		return fmt.Errorf("%w: %s", ErrInjectedFault, name)
+	}
+	return nil
+}
+
+// corrupt replaces the value by a blank value or a NaN, and returns the type of the replaced value.
+func (f *faultInjector) corrupt(fieldType uint, value *[4096]byte) (uint, bool) {
+	switch {
+	case f.faults.BlankRate > 0 && f.random() < f.faults.BlankRate:
+		*value = [4096]byte{}
+		switch fieldType {
+		case dcgm.DCGM_FT_DOUBLE:
+			binary.NativeEndian.PutUint64(value[:], math.Float64bits(dcgm.DCGM_FT_FP64_BLANK))
+		case dcgm.DCGM_FT_STRING:
+			copy(value[:], dcgm.DCGM_FT_STR_BLANK)
+		default:
+			fieldType = dcgm.DCGM_FT_INT64
+			binary.NativeEndian.PutUint64(value[:], uint64(dcgm.DCGM_FT_INT64_BLANK))
+		}
+		return fieldType, true
+	case f.faults.NaNRate > 0 && f.random() < f.faults.NaNRate:
+		*value = [4096]byte{}
+		binary.NativeEndian.PutUint64(value[:], math.Float64bits(math.NaN()))
+		return dcgm.DCGM_FT_DOUBLE, true
+	}
+	return fieldType, false
+}
+
+func (f *faultInjector) corruptV1(values []dcgm.FieldValue_v1) []dcgm.FieldValue_v1 {
+	for i := range values {
+		values[i].FieldType, _ = f.corrupt(values[i].FieldType, &values[i].Value)
+	}
+	return values
+}
+
+func (f *faultInjector) corruptV2(values []dcgm.FieldValue_v2) []dcgm.FieldValue_v2 {
+	for i := range values {
+		var corrupted bool
+		values[i].FieldType, corrupted = f.corrupt(values[i].FieldType, &values[i].Value)
+		if !corrupted {
+			continue
+		}
+		values[i].StringValue = nil
+		if values[i].FieldType == dcgm.DCGM_FT_STRING {
+			blank := dcgm.DCGM_FT_STR_BLANK
+			values[i].StringValue = &blank
+		}
+	}
+	return values
+}
+
+func (f *faultInjector) EntitiesGetLatestValues(
+	entities []dcgm.GroupEntityPair, fields []dcgm.Short, flags uint,
+) ([]dcgm.FieldValue_v2, error) {
+	if err := f.call("EntitiesGetLatestValues"); err != nil {
+		return nil, err
+	}
+	values, err := f.DCGM.EntitiesGetLatestValues(entities, fields, flags)
+	return f.corruptV2(values), err
+}
+
+func (f *faultInjector) EntityGetLatestValues(
+	entityGroup dcgm.Field_Entity_Group, entityId uint, fields []dcgm.Short,
+) ([]dcgm.FieldValue_v1, error) {
+	if err := f.call("EntityGetLatestValues"); err != nil {
+		return nil, err
+	}
+	values, err := f.DCGM.EntityGetLatestValues(entityGroup, entityId, fields)
+	return f.corruptV1(values), err
+}
+
+func (f *faultInjector) GetValuesSince(
+	gpuGroup dcgm.GroupHandle, fieldGroup dcgm.FieldHandle, sinceTime time.Time,
+) ([]dcgm.FieldValue_v2, time.Time, error) {
+	if err := f.call("GetValuesSince"); err != nil {
+		return nil, time.Time{}, err
+	}
+	values, next, err := f.DCGM.GetValuesSince(gpuGroup, fieldGroup, sinceTime)
+	return f.corruptV2(values), next, err
+}
+
+func (f *faultInjector) LinkGetLatestValues(
+	index uint, parentId uint, fields []dcgm.Short,
+) ([]dcgm.FieldValue_v1, error) {
+	if err := f.call("LinkGetLatestValues"); err != nil {
+		return nil, err
+	}
+	values, err := f.DCGM.LinkGetLatestValues(index, parentId, fields)
+	return f.corruptV1(values), err
+}
+
+func (f *faultInjector) UpdateAllFields() error {
+	if err := f.call("UpdateAllFields"); err != nil {
+		return err
+	}
+	return f.DCGM.UpdateAllFields()
+}
+
+func (f *faultInjector) HealthCheck(groupID dcgm.GroupHandle) (dcgm.HealthResponse, error) {
+	if err := f.call("HealthCheck"); err != nil {
+		return dcgm.HealthResponse{}, err
+	}
+	return f.DCGM.HealthCheck(groupID)
+}
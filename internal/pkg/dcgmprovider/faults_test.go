@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+)
+
+func TestParseFaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Faults
+		wantErr string
+	}{
+		{
+			name: "All faults",
+			spec: "errors=0.1, blanks=0.2,nans=1,delay=2s",
+			want: Faults{ErrorRate: 0.1, BlankRate: 0.2, NaNRate: 1, Delay: 2 * time.Second},
+		},
+		{
+			name: "No faults",
+			spec: "",
+			want: Faults{},
+		},
+		{
+			name:    "Missing value",
+			spec:    "errors",
+			wantErr: `invalid fault "errors"; expected key=value`,
+		},
+		{
+			name:    "Rate out of range",
+			spec:    "blanks=1.5",
+			wantErr: `invalid fault "blanks=1.5"; err: the rate must be between 0 and 1`,
+		},
+		{
+			name:    "Negative delay",
+			spec:    "delay=-1s",
+			wantErr: `invalid fault "delay=-1s"; err: the delay must not be negative`,
+		},
+		{
+			name:    "Unknown fault",
+			spec:    "panics=1",
+			wantErr: `invalid fault "panics=1"; err: unknown fault`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFaults(tt.spec)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func fieldValue(fieldType uint, value uint64) dcgm.FieldValue_v1 {
+	fv := dcgm.FieldValue_v1{FieldId: uint(dcgm.DCGM_FI_DEV_GPU_TEMP), FieldType: fieldType}
+	binary.NativeEndian.PutUint64(fv.Value[:], value)
+	return fv
+}
+
+func TestFaultInjector(t *testing.T) {
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_TEMP}
+
+	t.Run("Errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+		injector := WithFaults(mockDCGM, Faults{ErrorRate: 1})
+
+		_, err := injector.EntityGetLatestValues(dcgm.FE_GPU, 0, fields)
+		assert.ErrorIs(t, err, ErrInjectedFault)
+		assert.EqualError(t, err, "injected DCGM fault: EntityGetLatestValues")
+		assert.False(t, IsConnectionLost(err), "injected faults do not lose the connection")
+		assert.ErrorIs(t, injector.UpdateAllFields(), ErrInjectedFault)
+	})
+
+	t.Run("Blank values", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+		mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), fields).Return([]dcgm.FieldValue_v1{
+			fieldValue(dcgm.DCGM_FT_INT64, 42),
+			fieldValue(dcgm.DCGM_FT_DOUBLE, math.Float64bits(42.5)),
+		}, nil)
+
+		injector := WithFaults(mockDCGM, Faults{BlankRate: 1})
+
+		values, err := injector.EntityGetLatestValues(dcgm.FE_GPU, 0, fields)
+		require.NoError(t, err)
+		require.Len(t, values, 2)
+		assert.Equal(t, int64(dcgm.DCGM_FT_INT64_BLANK), values[0].Int64())
+		assert.Equal(t, dcgm.DCGM_FT_FP64_BLANK, values[1].Float64())
+	})
+
+	t.Run("NaN values", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+		mockDCGM.EXPECT().GetValuesSince(dcgm.GroupHandle{}, dcgm.FieldHandle{}, time.Time{}).
+			Return([]dcgm.FieldValue_v2{{FieldType: dcgm.DCGM_FT_INT64}}, time.Time{}, nil)
+
+		injector := WithFaults(mockDCGM, Faults{NaNRate: 1})
+
+		values, _, err := injector.GetValuesSince(dcgm.GroupHandle{}, dcgm.FieldHandle{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		assert.Equal(t, dcgm.DCGM_FT_DOUBLE, values[0].FieldType)
+		assert.True(t, math.IsNaN(values[0].Float64()))
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+		mockDCGM.EXPECT().UpdateAllFields().Return(nil)
+
+		var slept time.Duration
+		injector := WithFaults(mockDCGM, Faults{Delay: time.Second}).(*faultInjector)
+		injector.sleep = func(d time.Duration) { slept += d }
+
+		assert.NoError(t, injector.UpdateAllFields())
+		assert.Equal(t, time.Second, slept)
+	})
+
+	t.Run("Other calls are passed through", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+		mockDCGM.EXPECT().GetAllDeviceCount().Return(uint(2), nil)
+
+		injector := WithFaults(mockDCGM, Faults{ErrorRate: 1})
+
+		count, err := injector.GetAllDeviceCount()
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+	})
+}
+
+func TestInjectFaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	defer reset()
+	SetClient(mockDCGM)
+
+	require.NoError(t, injectFaults())
+	assert.Same(t, mockDCGM, Client(), "no faults are injected without the environment variable")
+
+	t.Setenv(FaultInjectionEnv, "errors=1")
+	require.NoError(t, injectFaults())
+	injector, ok := Client().(*faultInjector)
+	require.True(t, ok)
+	assert.Equal(t, Faults{ErrorRate: 1}, injector.faults)
+
+	require.NoError(t, injectFaults())
+	assert.Same(t, injector, Client(), "the faults are injected once")
+
+	SetClient(mockDCGM)
+	t.Setenv(FaultInjectionEnv, "errors=2")
+	assert.ErrorContains(t, injectFaults(), FaultInjectionEnv)
+}
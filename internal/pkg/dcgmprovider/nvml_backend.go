@@ -0,0 +1,474 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+// ErrNVMLBackendUnsupported is returned by the NVML backend for the DCGM features, which NVML has no equivalent for,
+// e.g. the profiling metrics and the health watches.
+var ErrNVMLBackendUnsupported = errors.New("not supported by the NVML backend")
+
+// nvmlAllGPUsGroup is the handle of the group, which GroupAllGPUs returns. The groups, which are created, get the
+// handles after it.
+const nvmlAllGPUsGroup = 1
+
+// nvmlBackendField is a DCGM field, which the NVML backend reads from NVML
+type nvmlBackendField struct {
+	fieldType uint
+	// device is the NVML value of a numeric field
+	device nvmlprovider.DeviceField
+	// identity returns the value of a string field from the GPU, which NVML enumerated
+	identity func(nvmlprovider.GPUDevice) string
+}
+
+// nvmlBackendFields are the DCGM fields, which the NVML backend has values for. The other fields are recognized, but
+// are always blank, so their metrics are skipped.
+var nvmlBackendFields = map[dcgm.Short]nvmlBackendField{
+	dcgm.DCGM_FI_DEV_GPU_TEMP:            {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.GPUTemperature},
+	dcgm.DCGM_FI_DEV_POWER_USAGE:         {fieldType: dcgm.DCGM_FT_DOUBLE, device: nvmlprovider.PowerUsage},
+	dcgm.DCGM_FI_DEV_FAN_SPEED:           {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.FanSpeed},
+	dcgm.DCGM_FI_DEV_GPU_UTIL:            {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.GPUUtilization},
+	dcgm.DCGM_FI_DEV_MEM_COPY_UTIL:       {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.MemoryCopyUtilization},
+	dcgm.DCGM_FI_DEV_ENC_UTIL:            {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.EncoderUtilization},
+	dcgm.DCGM_FI_DEV_DEC_UTIL:            {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.DecoderUtilization},
+	dcgm.DCGM_FI_DEV_FB_USED:             {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.FramebufferUsed},
+	dcgm.DCGM_FI_DEV_FB_FREE:             {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.FramebufferFree},
+	dcgm.DCGM_FI_DEV_SM_CLOCK:            {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.SMClock},
+	dcgm.DCGM_FI_DEV_MEM_CLOCK:           {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.MemoryClock},
+	dcgm.DCGM_FI_DEV_PCIE_REPLAY_COUNTER: {fieldType: dcgm.DCGM_FT_INT64, device: nvmlprovider.PCIeReplayCounter},
+	dcgm.DCGM_FI_DEV_NAME: {fieldType: dcgm.DCGM_FT_STRING, identity: func(d nvmlprovider.GPUDevice) string {
+		return d.Name
+	}},
+	dcgm.DCGM_FI_DEV_UUID: {fieldType: dcgm.DCGM_FT_STRING, identity: func(d nvmlprovider.GPUDevice) string {
+		return d.UUID
+	}},
+	dcgm.DCGM_FI_DEV_SERIAL: {fieldType: dcgm.DCGM_FT_STRING, identity: func(d nvmlprovider.GPUDevice) string {
+		return d.Serial
+	}},
+	dcgm.DCGM_FI_DEV_PCI_BUSID: {fieldType: dcgm.DCGM_FT_STRING, identity: func(d nvmlprovider.GPUDevice) string {
+		return d.PCIBusID
+	}},
+	dcgm.DCGM_FI_DRIVER_VERSION: {fieldType: dcgm.DCGM_FT_STRING, identity: func(d nvmlprovider.GPUDevice) string {
+		return d.DriverVersion
+	}},
+}
+
+// nvmlFieldNamesByID maps the IDs of the DCGM fields to their names, for the metadata of the fields, which the NVML
+// backend can't read from the DCGM fields module. When several names share the same ID, the first in order is used.
+var nvmlFieldNamesByID = sync.OnceValue(func() map[dcgm.Short]string {
+	names := make([]string, 0, len(dcgm.DCGM_FI))
+	for name := range dcgm.DCGM_FI {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fieldNames := make(map[dcgm.Short]string, len(names))
+	for _, name := range names {
+		if _, exists := fieldNames[dcgm.DCGM_FI[name]]; !exists {
+			fieldNames[dcgm.DCGM_FI[name]] = name
+		}
+	}
+	return fieldNames
+})
+
+// nvmlBackend implements the DCGM interface with NVML only, for the hosts, which DCGM isn't available on, e.g. WSL2.
+// It reports the GPUs, which NVML enumerates, without GPU instances, NVSwitches or CPUs, and the values of the fields
+// in nvmlBackendFields. Groups, field groups and watches are kept in memory, since every value is read from NVML,
+// when it is requested.
+type nvmlBackend struct {
+	devices []nvmlprovider.GPUDevice
+
+	mtx         sync.Mutex
+	nextHandle  uintptr
+	groups      map[uintptr][]dcgm.GroupEntityPair
+	fieldGroups map[uintptr][]dcgm.Short
+}
+
+// newNVMLBackend returns the NVML backend for the GPUs, which NVML enumerates. The NVML provider has to be
+// initialized first.
+func newNVMLBackend() (*nvmlBackend, error) {
+	if nvmlprovider.Client() == nil {
+		return nil, errors.New("NVML is not initialized")
+	}
+
+	devices, err := nvmlprovider.Client().GetDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate the GPUs with NVML; err: %w", err)
+	}
+
+	backend := &nvmlBackend{
+		devices:     devices,
+		nextHandle:  nvmlAllGPUsGroup + 1,
+		groups:      map[uintptr][]dcgm.GroupEntityPair{},
+		fieldGroups: map[uintptr][]dcgm.Short{},
+	}
+	for _, device := range devices {
+		backend.groups[nvmlAllGPUsGroup] = append(backend.groups[nvmlAllGPUsGroup],
+			dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: device.Index})
+	}
+
+	slog.Info(fmt.Sprintf("Using the NVML backend with %d GPUs; only %d DCGM fields have values",
+		len(devices), len(nvmlBackendFields)))
+
+	return backend, nil
+}
+
+func (n *nvmlBackend) newHandle() uintptr {
+	handle := n.nextHandle
+	n.nextHandle++
+	return handle
+}
+
+func (n *nvmlBackend) device(gpuId uint) (nvmlprovider.GPUDevice, error) {
+	if gpuId >= uint(len(n.devices)) {
+		return nvmlprovider.GPUDevice{}, fmt.Errorf("GPU %d not found", gpuId)
+	}
+	return n.devices[gpuId], nil
+}
+
+func (n *nvmlBackend) AddEntityToGroup(
+	groupId dcgm.GroupHandle, entityGroupId dcgm.Field_Entity_Group, entityId uint,
+) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	handle := groupId.GetHandle()
+	if _, exists := n.groups[handle]; !exists {
+		return fmt.Errorf("group %d not found", handle)
+	}
+	n.groups[handle] = append(n.groups[handle], dcgm.GroupEntityPair{EntityGroupId: entityGroupId, EntityId: entityId})
+	return nil
+}
+
+func (n *nvmlBackend) AddLinkEntityToGroup(dcgm.GroupHandle, uint, uint) error {
+	return fmt.Errorf("NVLinks are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) CreateFakeEntities([]dcgm.MigHierarchyInfo) ([]uint, error) {
+	return nil, fmt.Errorf("fake entities are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) CreateGroup(string) (dcgm.GroupHandle, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	var group dcgm.GroupHandle
+	group.SetHandle(n.newHandle())
+	n.groups[group.GetHandle()] = []dcgm.GroupEntityPair{}
+	return group, nil
+}
+
+func (n *nvmlBackend) DestroyGroup(groupId dcgm.GroupHandle) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	delete(n.groups, groupId.GetHandle())
+	return nil
+}
+
+func (n *nvmlBackend) EntitiesGetLatestValues(
+	entities []dcgm.GroupEntityPair, fields []dcgm.Short, _ uint,
+) ([]dcgm.FieldValue_v2, error) {
+	var values []dcgm.FieldValue_v2
+	for _, entity := range entities {
+		entityValues, err := n.EntityGetLatestValues(entity.EntityGroupId, entity.EntityId, fields)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range entityValues {
+			values = append(values, toFieldValueV2(entity, value))
+		}
+	}
+	return values, nil
+}
+
+func (n *nvmlBackend) EntityGetLatestValues(
+	entityGroup dcgm.Field_Entity_Group, entityId uint, fields []dcgm.Short,
+) ([]dcgm.FieldValue_v1, error) {
+	if entityGroup != dcgm.FE_GPU {
+		return nil, fmt.Errorf("entity group %d is %w", entityGroup, ErrNVMLBackendUnsupported)
+	}
+	device, err := n.device(entityId)
+	if err != nil {
+		return nil, err
+	}
+
+	var deviceFields []nvmlprovider.DeviceField
+	for _, field := range fields {
+		if backendField, exists := nvmlBackendFields[field]; exists && backendField.identity == nil {
+			deviceFields = append(deviceFields, backendField.device)
+		}
+	}
+
+	deviceValues := map[nvmlprovider.DeviceField]float64{}
+	if len(deviceFields) > 0 {
+		deviceValues, err = nvmlprovider.Client().GetDeviceValues(device.UUID, deviceFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts := time.Now().UnixMicro()
+	values := make([]dcgm.FieldValue_v1, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, nvmlFieldValue(field, device, deviceValues, ts))
+	}
+	return values, nil
+}
+
+// nvmlFieldValue returns the value of the field of the GPU, or a blank value, when NVML has none for it.
+func nvmlFieldValue(
+	field dcgm.Short, device nvmlprovider.GPUDevice, deviceValues map[nvmlprovider.DeviceField]float64, ts int64,
+) dcgm.FieldValue_v1 {
+	value := dcgm.FieldValue_v1{FieldId: uint(field), FieldType: dcgm.DCGM_FT_INT64, Ts: ts}
+	binary.NativeEndian.PutUint64(value.Value[:], uint64(dcgm.DCGM_FT_INT64_BLANK))
+
+	backendField, exists := nvmlBackendFields[field]
+	if !exists {
+		return value
+	}
+
+	value.FieldType = backendField.fieldType
+	if backendField.identity != nil {
+		copy(value.Value[:len(value.Value)-1], backendField.identity(device))
+		return value
+	}
+
+	deviceValue, exists := deviceValues[backendField.device]
+	switch {
+	case backendField.fieldType == dcgm.DCGM_FT_DOUBLE && exists:
+		binary.NativeEndian.PutUint64(value.Value[:], math.Float64bits(deviceValue))
+	case backendField.fieldType == dcgm.DCGM_FT_DOUBLE:
+		binary.NativeEndian.PutUint64(value.Value[:], math.Float64bits(dcgm.DCGM_FT_FP64_BLANK))
+	case exists:
+		binary.NativeEndian.PutUint64(value.Value[:], uint64(int64(deviceValue)))
+	}
+	return value
+}
+
+func toFieldValueV2(entity dcgm.GroupEntityPair, value dcgm.FieldValue_v1) dcgm.FieldValue_v2 {
+	v2 := dcgm.FieldValue_v2{
+		Version:       value.Version,
+		EntityGroupId: entity.EntityGroupId,
+		EntityId:      entity.EntityId,
+		FieldId:       value.FieldId,
+		FieldType:     value.FieldType,
+		Status:        value.Status,
+		Ts:            value.Ts,
+		Value:         value.Value,
+	}
+	if value.FieldType == dcgm.DCGM_FT_STRING {
+		str := value.String()
+		v2.StringValue = &str
+	}
+	return v2
+}
+
+func (n *nvmlBackend) Fv2_String(fv dcgm.FieldValue_v2) string {
+	if fv.FieldType == dcgm.DCGM_FT_STRING && fv.StringValue != nil {
+		return *fv.StringValue
+	}
+	return string(fv.Value[:])
+}
+
+// FieldGetById returns the metadata of the field, if DCGM knows it. The fields are all GPU fields, since the NVML
+// backend has no other entities.
+func (n *nvmlBackend) FieldGetById(fieldId dcgm.Short) dcgm.FieldMeta {
+	name, exists := nvmlFieldNamesByID()[fieldId]
+	if !exists {
+		return dcgm.FieldMeta{}
+	}
+
+	fieldType := dcgm.DCGM_FT_INT64
+	if backendField, exists := nvmlBackendFields[fieldId]; exists {
+		fieldType = backendField.fieldType
+	}
+
+	return dcgm.FieldMeta{
+		FieldId:     fieldId,
+		FieldType:   byte(fieldType),
+		Tag:         strings.ToLower(strings.TrimPrefix(name, "DCGM_FI_")),
+		EntityLevel: dcgm.FE_GPU,
+	}
+}
+
+func (n *nvmlBackend) FieldGroupCreate(_ string, fields []dcgm.Short) (dcgm.FieldHandle, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	var fieldGroup dcgm.FieldHandle
+	fieldGroup.SetHandle(n.newHandle())
+	n.fieldGroups[fieldGroup.GetHandle()] = append([]dcgm.Short(nil), fields...)
+	return fieldGroup, nil
+}
+
+func (n *nvmlBackend) FieldGroupDestroy(fieldsGroup dcgm.FieldHandle) error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	delete(n.fieldGroups, fieldsGroup.GetHandle())
+	return nil
+}
+
+func (n *nvmlBackend) GetAllDeviceCount() (uint, error) {
+	return uint(len(n.devices)), nil
+}
+
+func (n *nvmlBackend) GetCpuHierarchy() (dcgm.CpuHierarchy_v1, error) {
+	return dcgm.CpuHierarchy_v1{}, nil
+}
+
+func (n *nvmlBackend) GetDeviceInfo(gpuId uint) (dcgm.Device, error) {
+	device, err := n.device(gpuId)
+	if err != nil {
+		return dcgm.Device{}, err
+	}
+
+	return dcgm.Device{
+		GPU:           device.Index,
+		DCGMSupported: "No",
+		UUID:          device.UUID,
+		PCI:           dcgm.PCIInfo{BusID: device.PCIBusID},
+		Identifiers: dcgm.DeviceIdentifiers{
+			Model:         device.Name,
+			Serial:        device.Serial,
+			DriverVersion: device.DriverVersion,
+		},
+	}, nil
+}
+
+func (n *nvmlBackend) GetDeviceTopology(uint) ([]dcgm.P2PLink, error) {
+	return nil, fmt.Errorf("the GPU topology is %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) GetEntityGroupEntities(entityGroup dcgm.Field_Entity_Group) ([]uint, error) {
+	if entityGroup != dcgm.FE_GPU {
+		return []uint{}, nil
+	}
+
+	gpus := make([]uint, 0, len(n.devices))
+	for _, device := range n.devices {
+		gpus = append(gpus, device.Index)
+	}
+	return gpus, nil
+}
+
+func (n *nvmlBackend) GetGpuInstanceHierarchy() (dcgm.MigHierarchy_v2, error) {
+	return dcgm.MigHierarchy_v2{}, nil
+}
+
+func (n *nvmlBackend) GetNvLinkLinkStatus() ([]dcgm.NvLinkStatus, error) {
+	return []dcgm.NvLinkStatus{}, nil
+}
+
+func (n *nvmlBackend) GetSupportedDevices() ([]uint, error) {
+	return n.GetEntityGroupEntities(dcgm.FE_GPU)
+}
+
+func (n *nvmlBackend) GetSupportedMetricGroups(uint) ([]dcgm.MetricGroup, error) {
+	return nil, fmt.Errorf("profiling metrics are %w", ErrNVMLBackendUnsupported)
+}
+
+// GetValuesSince returns the latest values of the fields of the field group for the entities of the group, since
+// the NVML backend keeps no samples.
+func (n *nvmlBackend) GetValuesSince(
+	gpuGroup dcgm.GroupHandle, fieldGroup dcgm.FieldHandle, _ time.Time,
+) ([]dcgm.FieldValue_v2, time.Time, error) {
+	n.mtx.Lock()
+	entities, groupExists := n.groups[gpuGroup.GetHandle()]
+	fields, fieldGroupExists := n.fieldGroups[fieldGroup.GetHandle()]
+	n.mtx.Unlock()
+
+	if !groupExists {
+		return nil, time.Time{}, fmt.Errorf("group %d not found", gpuGroup.GetHandle())
+	}
+	if !fieldGroupExists {
+		return nil, time.Time{}, fmt.Errorf("field group %d not found", fieldGroup.GetHandle())
+	}
+
+	now := time.Now()
+	values, err := n.EntitiesGetLatestValues(entities, fields, 0)
+	return values, now, err
+}
+
+func (n *nvmlBackend) GroupAllGPUs() dcgm.GroupHandle {
+	var group dcgm.GroupHandle
+	group.SetHandle(nvmlAllGPUsGroup)
+	return group
+}
+
+func (n *nvmlBackend) Introspect() (dcgm.DcgmStatus, error) {
+	return dcgm.DcgmStatus{}, fmt.Errorf("the hostengine introspection is %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) InjectFieldValue(uint, uint, uint, int, int64, interface{}) error {
+	return fmt.Errorf("injecting values is %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) LinkGetLatestValues(uint, uint, []dcgm.Short) ([]dcgm.FieldValue_v1, error) {
+	return nil, fmt.Errorf("NVLinks are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) NewDefaultGroup(string) (dcgm.GroupHandle, error) {
+	return n.GroupAllGPUs(), nil
+}
+
+func (n *nvmlBackend) UpdateAllFields() error {
+	return nil
+}
+
+func (n *nvmlBackend) WatchFieldsWithGroupEx(dcgm.FieldHandle, dcgm.GroupHandle, int64, float64, int32) error {
+	return nil
+}
+
+func (n *nvmlBackend) Cleanup() {
+	reset()
+}
+
+func (n *nvmlBackend) HealthSet(dcgm.GroupHandle, dcgm.HealthSystem) error {
+	return fmt.Errorf("health watches are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) HealthGet(dcgm.GroupHandle) (dcgm.HealthSystem, error) {
+	return 0, fmt.Errorf("health watches are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) HealthCheck(dcgm.GroupHandle) (dcgm.HealthResponse, error) {
+	return dcgm.HealthResponse{}, fmt.Errorf("health watches are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) RunDiag(dcgm.DiagType, dcgm.GroupHandle) (dcgm.DiagResults, error) {
+	return dcgm.DiagResults{}, fmt.Errorf("diagnostics are %w", ErrNVMLBackendUnsupported)
+}
+
+func (n *nvmlBackend) GetGroupInfo(dcgm.GroupHandle) (*dcgm.GroupInfo, error) {
+	return nil, fmt.Errorf("group info is %w", ErrNVMLBackendUnsupported)
+}
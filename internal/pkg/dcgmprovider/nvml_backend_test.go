@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func newTestNVMLBackend(t *testing.T) (*nvmlBackend, *mocknvmlprovider.MockNVML) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetDevices().Return([]nvmlprovider.GPUDevice{
+		{Index: 0, UUID: "GPU-0", Name: "NVIDIA GeForce RTX 4090", PCIBusID: "00000000:01:00.0"},
+		{Index: 1, UUID: "GPU-1", Name: "NVIDIA GeForce RTX 4090", PCIBusID: "00000000:02:00.0"},
+	}, nil)
+
+	realNVML := nvmlprovider.Client()
+	t.Cleanup(func() { nvmlprovider.SetClient(realNVML) })
+	nvmlprovider.SetClient(mockNVML)
+
+	backend, err := newNVMLBackend()
+	require.NoError(t, err)
+	return backend, mockNVML
+}
+
+func TestNVMLBackend_Devices(t *testing.T) {
+	backend, _ := newTestNVMLBackend(t)
+
+	count, err := backend.GetAllDeviceCount()
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), count)
+
+	device, err := backend.GetDeviceInfo(1)
+	require.NoError(t, err)
+	assert.Equal(t, "GPU-1", device.UUID)
+	assert.Equal(t, "NVIDIA GeForce RTX 4090", device.Identifiers.Model)
+	assert.Equal(t, "00000000:02:00.0", device.PCI.BusID)
+
+	_, err = backend.GetDeviceInfo(2)
+	assert.Error(t, err)
+
+	switches, err := backend.GetEntityGroupEntities(dcgm.FE_SWITCH)
+	require.NoError(t, err)
+	assert.Empty(t, switches)
+
+	hierarchy, err := backend.GetGpuInstanceHierarchy()
+	require.NoError(t, err)
+	assert.Zero(t, hierarchy.Count)
+
+	_, err = backend.GetSupportedMetricGroups(0)
+	assert.ErrorIs(t, err, ErrNVMLBackendUnsupported)
+}
+
+func TestNVMLBackend_EntityGetLatestValues(t *testing.T) {
+	backend, mockNVML := newTestNVMLBackend(t)
+
+	// The fan speed isn't supported by the GPU, and NVML has no SM occupancy at all
+	mockNVML.EXPECT().GetDeviceValues("GPU-0", []nvmlprovider.DeviceField{
+		nvmlprovider.GPUTemperature, nvmlprovider.PowerUsage, nvmlprovider.FanSpeed,
+	}).Return(map[nvmlprovider.DeviceField]float64{
+		nvmlprovider.GPUTemperature: 65,
+		nvmlprovider.PowerUsage:     251.5,
+	}, nil)
+
+	values, err := backend.EntityGetLatestValues(dcgm.FE_GPU, 0, []dcgm.Short{
+		dcgm.DCGM_FI_DEV_GPU_TEMP,
+		dcgm.DCGM_FI_DEV_POWER_USAGE,
+		dcgm.DCGM_FI_DEV_FAN_SPEED,
+		dcgm.DCGM_FI_PROF_SM_OCCUPANCY,
+		dcgm.DCGM_FI_DEV_NAME,
+	})
+	require.NoError(t, err)
+	require.Len(t, values, 5)
+
+	assert.Equal(t, int64(65), values[0].Int64())
+	assert.Equal(t, dcgm.DCGM_FT_DOUBLE, values[1].FieldType)
+	assert.Equal(t, 251.5, values[1].Float64())
+	assert.Equal(t, dcgm.DCGM_FT_INT64_BLANK, values[2].Int64())
+	assert.Equal(t, dcgm.DCGM_FT_INT64_BLANK, values[3].Int64())
+	assert.Equal(t, dcgm.DCGM_FT_STRING, values[4].FieldType)
+	assert.Equal(t, "NVIDIA GeForce RTX 4090", values[4].String())
+
+	_, err = backend.EntityGetLatestValues(dcgm.FE_SWITCH, 0, []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_TEMP})
+	assert.ErrorIs(t, err, ErrNVMLBackendUnsupported)
+}
+
+func TestNVMLBackend_GetValuesSince(t *testing.T) {
+	backend, mockNVML := newTestNVMLBackend(t)
+
+	group, err := backend.CreateGroup("test")
+	require.NoError(t, err)
+	require.NoError(t, backend.AddEntityToGroup(group, dcgm.FE_GPU, 1))
+
+	fieldGroup, err := backend.FieldGroupCreate("test", []dcgm.Short{dcgm.DCGM_FI_DEV_GPU_UTIL})
+	require.NoError(t, err)
+
+	mockNVML.EXPECT().GetDeviceValues("GPU-1", []nvmlprovider.DeviceField{nvmlprovider.GPUUtilization}).
+		Return(map[nvmlprovider.DeviceField]float64{nvmlprovider.GPUUtilization: 87}, nil)
+
+	values, _, err := backend.GetValuesSince(group, fieldGroup, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, values, 1)
+	assert.Equal(t, dcgm.FE_GPU, values[0].EntityGroupId)
+	assert.Equal(t, uint(1), values[0].EntityId)
+	assert.Equal(t, int64(87), values[0].Int64())
+
+	require.NoError(t, backend.DestroyGroup(group))
+	_, _, err = backend.GetValuesSince(group, fieldGroup, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestNVMLBackend_FieldGetById(t *testing.T) {
+	backend, _ := newTestNVMLBackend(t)
+
+	meta := backend.FieldGetById(dcgm.DCGM_FI_DEV_POWER_USAGE)
+	assert.Equal(t, dcgm.Short(dcgm.DCGM_FI_DEV_POWER_USAGE), meta.FieldId)
+	assert.Equal(t, byte(dcgm.DCGM_FT_DOUBLE), meta.FieldType)
+	assert.Equal(t, dcgm.FE_GPU, meta.EntityLevel)
+
+	// Fields, which the backend has no values for, are recognized
+	meta = backend.FieldGetById(dcgm.DCGM_FI_PROF_SM_OCCUPANCY)
+	assert.Equal(t, dcgm.Short(dcgm.DCGM_FI_PROF_SM_OCCUPANCY), meta.FieldId)
+
+	assert.Zero(t, backend.FieldGetById(65000).FieldId)
+}
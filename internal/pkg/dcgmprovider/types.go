@@ -39,6 +39,7 @@ type DCGM interface {
 	GetAllDeviceCount() (uint, error)
 	GetCpuHierarchy() (dcgm.CpuHierarchy_v1, error)
 	GetDeviceInfo(uint) (dcgm.Device, error)
+	GetDeviceTopology(uint) ([]dcgm.P2PLink, error)
 	GetEntityGroupEntities(entityGroup dcgm.Field_Entity_Group) ([]uint, error)
 	GetGpuInstanceHierarchy() (dcgm.MigHierarchy_v2, error)
 	GetNvLinkLinkStatus() ([]dcgm.NvLinkStatus, error)
@@ -46,9 +47,11 @@ type DCGM interface {
 	GetSupportedMetricGroups(uint) ([]dcgm.MetricGroup, error)
 	GetValuesSince(dcgm.GroupHandle, dcgm.FieldHandle, time.Time) ([]dcgm.FieldValue_v2, time.Time, error)
 	GroupAllGPUs() dcgm.GroupHandle
+	Introspect() (dcgm.DcgmStatus, error)
 	InjectFieldValue(gpu uint, fieldID uint, fieldType uint, status int, ts int64, value interface{}) error
 	LinkGetLatestValues(uint, uint, []dcgm.Short) ([]dcgm.FieldValue_v1, error)
 	NewDefaultGroup(string) (dcgm.GroupHandle, error)
+	RunDiag(dcgm.DiagType, dcgm.GroupHandle) (dcgm.DiagResults, error)
 	UpdateAllFields() error
 	WatchFieldsWithGroupEx(dcgm.FieldHandle, dcgm.GroupHandle, int64, float64, int32) error
 	Cleanup()
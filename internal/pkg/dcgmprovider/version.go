@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// libdcgmName is the file name of the DCGM library, which is followed by its version, e.g. libdcgm.so.4.2.3
+const libdcgmName = "libdcgm.so."
+
+// procSelfMaps lists the files mapped into the memory of the exporter, including the loaded libraries
+var procSelfMaps = "/proc/self/maps"
+
+// LibraryVersion returns the version of the DCGM library loaded by the exporter, e.g. 4.2.3, which go-dcgm doesn't
+// report, from the file name of the library. It returns an empty string, when the library isn't loaded, e.g. with
+// the NVML-only mode, or its file name has no full version.
+func LibraryVersion() string {
+	maps, err := os.Open(procSelfMaps)
+	if err != nil {
+		return ""
+	}
+	defer maps.Close()
+
+	scanner := bufio.NewScanner(maps)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		// The soname, e.g. libdcgm.so.4, links to the library with the full version
+		path := fields[len(fields)-1]
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		if _, version, found := strings.Cut(filepath.Base(path), libdcgmName); found && strings.Contains(version, ".") {
+			return version
+		}
+	}
+	return ""
+}
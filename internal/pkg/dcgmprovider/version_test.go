@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dcgmprovider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryVersion(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "libdcgm.so.4.2.3")
+	require.NoError(t, os.WriteFile(library, nil, 0o644))
+	require.NoError(t, os.Symlink(library, filepath.Join(dir, "libdcgm.so.4")))
+
+	tests := []struct {
+		name string
+		maps string
+		want string
+	}{
+		{
+			name: "Library with the full version",
+			maps: "7f0000000000-7f0000001000 r-xp 00000000 08:01 1234 /usr/lib/libdcgm.so.4.2.3\n",
+			want: "4.2.3",
+		},
+		{
+			name: "Soname links to the library",
+			maps: fmt.Sprintf("7f0000000000-7f0000001000 r-xp 00000000 08:01 1234 %s\n",
+				filepath.Join(dir, "libdcgm.so.4")),
+			want: "4.2.3",
+		},
+		{
+			name: "Library isn't loaded",
+			maps: "7f0000000000-7f0000001000 r-xp 00000000 08:01 1234 /usr/lib/libc.so.6\n" +
+				"7ffc00000000-7ffc00021000 rw-p 00000000 00:00 0 [stack]\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maps := filepath.Join(t.TempDir(), "maps")
+			require.NoError(t, os.WriteFile(maps, []byte(tt.maps), 0o644))
+
+			realProcSelfMaps := procSelfMaps
+			defer func() { procSelfMaps = realProcSelfMaps }()
+			procSelfMaps = maps
+
+			assert.Equal(t, tt.want, LibraryVersion())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// ErrCPUModuleNotLoaded is returned when the hostengine has not loaded its system monitoring (sysmon) module, which
+// serves the CPU entities and fields. The hostengine loads it on the first CPU request, but only on NVIDIA CPUs, e.g.
+// Grace, and unless it is denylisted.
+var ErrCPUModuleNotLoaded = errors.New("the DCGM CPU (sysmon) module is not loaded")
+
+// NVIDIACPUExpected reports whether the CPUs may be NVIDIA CPUs, which are ARM64 CPUs. Elsewhere, the CPU module is
+// never loaded, so its load is not retried.
+var NVIDIACPUExpected = runtime.GOARCH == "arm64"
+
+// moduleNotLoadedMessage is the message of DCGM_ST_MODULE_NOT_LOADED, as go-dcgm returns the DCGM errors as text.
+const moduleNotLoadedMessage = "module of DCGM that is not currently loaded"
+
+var (
+	cpuModuleLoadAttempts = 3
+	cpuModuleLoadDelay    = 2 * time.Second
+)
+
+// getCPUHierarchy returns the CPU hierarchy. The first CPU request loads the CPU module of the hostengine, which may
+// fail while the hostengine is still initializing the CPUs, so the request is retried on NVIDIA CPUs.
+func getCPUHierarchy() (dcgm.CpuHierarchy_v1, error) {
+	attempts := 1
+	if NVIDIACPUExpected {
+		attempts = cpuModuleLoadAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		hierarchy, err := dcgmprovider.Client().GetCpuHierarchy()
+		if err == nil || !isModuleNotLoaded(err) {
+			return hierarchy, err
+		}
+
+		if attempt >= attempts {
+			return dcgm.CpuHierarchy_v1{}, fmt.Errorf("%w; it is loaded on NVIDIA CPUs, e.g. Grace, only, "+
+				"unless the hostengine was started with --denylist-modules; err: %s", ErrCPUModuleNotLoaded, err)
+		}
+
+		slog.Debug(fmt.Sprintf("The DCGM CPU module is not loaded yet; attempt: %d", attempt),
+			slog.String(logging.ErrorKey, err.Error()))
+		time.Sleep(cpuModuleLoadDelay)
+	}
+}
+
+func isModuleNotLoaded(err error) bool {
+	return strings.Contains(err.Error(), moduleNotLoadedMessage)
+}
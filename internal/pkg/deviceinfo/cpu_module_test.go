@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+func TestGetCPUHierarchy(t *testing.T) {
+	errNotLoaded := errors.New("Error retrieving DCGM MIG hierarchy: This request is serviced by a module of DCGM " +
+		"that is not currently loaded")
+	hierarchy := dcgm.CpuHierarchy_v1{NumCpus: 1}
+
+	tests := []struct {
+		name              string
+		nvidiaCPUExpected bool
+		mockCalls         func(mockDCGM *mockdcgm.MockDCGM)
+		want              dcgm.CpuHierarchy_v1
+		wantErr           error
+	}{
+		{
+			name:              "Module loaded on retry",
+			nvidiaCPUExpected: true,
+			mockCalls: func(mockDCGM *mockdcgm.MockDCGM) {
+				gomock.InOrder(
+					mockDCGM.EXPECT().GetCpuHierarchy().Return(dcgm.CpuHierarchy_v1{}, errNotLoaded),
+					mockDCGM.EXPECT().GetCpuHierarchy().Return(hierarchy, nil),
+				)
+			},
+			want: hierarchy,
+		},
+		{
+			name:              "Module never loaded",
+			nvidiaCPUExpected: true,
+			mockCalls: func(mockDCGM *mockdcgm.MockDCGM) {
+				mockDCGM.EXPECT().GetCpuHierarchy().Return(dcgm.CpuHierarchy_v1{}, errNotLoaded).Times(3)
+			},
+			wantErr: ErrCPUModuleNotLoaded,
+		},
+		{
+			name: "Module not retried on other CPUs",
+			mockCalls: func(mockDCGM *mockdcgm.MockDCGM) {
+				mockDCGM.EXPECT().GetCpuHierarchy().Return(dcgm.CpuHierarchy_v1{}, errNotLoaded)
+			},
+			wantErr: ErrCPUModuleNotLoaded,
+		},
+		{
+			name:              "Other errors are not retried",
+			nvidiaCPUExpected: true,
+			mockCalls: func(mockDCGM *mockdcgm.MockDCGM) {
+				mockDCGM.EXPECT().GetCpuHierarchy().Return(dcgm.CpuHierarchy_v1{}, errors.New("not supported"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+			realDCGM := dcgmprovider.Client()
+			nvidiaCPUExpected := NVIDIACPUExpected
+			loadDelay := cpuModuleLoadDelay
+			t.Cleanup(func() {
+				dcgmprovider.SetClient(realDCGM)
+				NVIDIACPUExpected = nvidiaCPUExpected
+				cpuModuleLoadDelay = loadDelay
+			})
+			dcgmprovider.SetClient(mockDCGM)
+			NVIDIACPUExpected = tt.nvidiaCPUExpected
+			cpuModuleLoadDelay = 0
+
+			tt.mockCalls(mockDCGM)
+
+			got, err := getCPUHierarchy()
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Contains(t, err.Error(), "not currently loaded")
+			case tt.want.NumCpus == 0:
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrCPUModuleNotLoaded)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@
 package deviceinfo
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
@@ -30,15 +32,24 @@ import (
 
 const deviceInitMessage = "System entities of type %s initialized"
 
+// ErrNoCPUs is returned when DCGM reports an empty CPU hierarchy. On Grace systems the hierarchy may be
+// populated only after DCGM finishes its own initialization, so callers may treat the error as retryable.
+var ErrNoCPUs = errors.New("no cpus to monitor")
+
+var errNoVGPUs = errors.New("no vgpus to monitor")
+
 type Info struct {
 	gpuCount uint
 	gpus     [dcgm.MAX_NUM_DEVICES]GPUInfo
 	switches []SwitchInfo
 	cpus     []CPUInfo
+	vgpus    []VGPUInfo
 	gOpt     appconfig.DeviceOptions
 	sOpt     appconfig.DeviceOptions
 	cOpt     appconfig.DeviceOptions
 	infoType dcgm.Field_Entity_Group
+
+	profileRetry *migProfileRetry
 }
 
 func (s *Info) GPUCount() uint {
@@ -46,10 +57,29 @@ func (s *Info) GPUCount() uint {
 }
 
 func (s *Info) GPUs() []GPUInfo {
-	return s.gpus[:]
+	if s.profileRetry == nil {
+		return s.gpus[:]
+	}
+
+	s.retryMigProfileNames()
+
+	s.profileRetry.mtx.RLock()
+	defer s.profileRetry.mtx.RUnlock()
+
+	gpus := s.gpus
+	return gpus[:]
 }
 
 func (s *Info) GPU(i uint) GPUInfo {
+	if s.profileRetry == nil {
+		return s.gpus[i]
+	}
+
+	s.retryMigProfileNames()
+
+	s.profileRetry.mtx.RLock()
+	defer s.profileRetry.mtx.RUnlock()
+
 	return s.gpus[i]
 }
 
@@ -69,6 +99,10 @@ func (s *Info) CPU(i uint) CPUInfo {
 	return s.cpus[i]
 }
 
+func (s *Info) VGPUs() []VGPUInfo {
+	return s.vgpus
+}
+
 func (s *Info) GOpts() appconfig.DeviceOptions {
 	return s.gOpt
 }
@@ -109,6 +143,9 @@ func Initialize(
 	case dcgm.FE_CPU_CORE:
 		deviceInfo.infoType = dcgm.FE_CPU_CORE
 		err = deviceInfo.initializeCPUInfo(cOpt)
+	case dcgm.FE_VGPU:
+		deviceInfo.infoType = dcgm.FE_VGPU
+		err = deviceInfo.initializeVGPUInfo(gOpt)
 	default:
 		err = fmt.Errorf("invalid entity type '%d'", entityType)
 	}
@@ -140,6 +177,8 @@ func (s *Info) initializeGPUInfo(gOpt appconfig.DeviceOptions, useFakeGPUs bool)
 		}
 	}
 
+	s.disambiguateDuplicateUUIDs()
+
 	hierarchy, err := dcgmprovider.Client().GetGpuInstanceHierarchy()
 	if err != nil {
 		return err
@@ -184,6 +223,15 @@ func (s *Info) initializeGPUInfo(gOpt appconfig.DeviceOptions, useFakeGPUs bool)
 		}
 	}
 
+	devices := make([]dcgm.Device, 0, s.gpuCount)
+	for i := uint(0); i < s.gpuCount; i++ {
+		devices = append(devices, s.gpus[i].DeviceInfo)
+	}
+	gOpt, err = resolveGPUIdentifiers(gOpt, devices)
+	if err != nil {
+		return err
+	}
+
 	s.gOpt = gOpt
 	err = s.verifyDevicePresence()
 	if err == nil {
@@ -193,13 +241,13 @@ func (s *Info) initializeGPUInfo(gOpt appconfig.DeviceOptions, useFakeGPUs bool)
 }
 
 func (s *Info) initializeCPUInfo(cOpt appconfig.DeviceOptions) error {
-	hierarchy, err := dcgmprovider.Client().GetCpuHierarchy()
+	hierarchy, err := getCPUHierarchy()
 	if err != nil {
 		return err
 	}
 
 	if hierarchy.NumCpus <= 0 {
-		return fmt.Errorf("no cpus to monitor")
+		return ErrNoCPUs
 	}
 
 	for i := 0; i < int(hierarchy.NumCpus); i++ {
@@ -284,11 +332,96 @@ func (s *Info) initializeNvSwitchInfo(sOpt appconfig.DeviceOptions) error {
 	return err
 }
 
+// initializeVGPUInfo discovers the vGPU instances of the GPUs, which are monitored as per the GPU device options.
+func (s *Info) initializeVGPUInfo(gOpt appconfig.DeviceOptions) error {
+	gpuCount, err := dcgmprovider.Client().GetAllDeviceCount()
+	if err != nil {
+		return err
+	}
+
+	if len(gOpt.MajorIdentifiers) > 0 {
+		devices := make([]dcgm.Device, 0, gpuCount)
+		for i := uint(0); i < gpuCount; i++ {
+			device, err := dcgmprovider.Client().GetDeviceInfo(i)
+			if err != nil {
+				return err
+			}
+			devices = append(devices, device)
+		}
+		gOpt, err = resolveGPUIdentifiers(gOpt, devices)
+		if err != nil {
+			return err
+		}
+	}
+
+	var entities []dcgm.GroupEntityPair
+	for i := uint(0); i < gpuCount; i++ {
+		if gOpt.Flex || s.shouldMonitor(gOpt.MajorRange, i) {
+			entities = append(entities, dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: i})
+		}
+	}
+
+	if len(entities) == 0 {
+		return errNoVGPUs
+	}
+
+	fields := []dcgm.Short{dcgm.DCGM_FI_DEV_VGPU_INSTANCE_IDS}
+	values, err := dcgmprovider.Client().EntitiesGetLatestValues(entities, fields, dcgm.DCGM_FV_FLAG_LIVE_DATA)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		ids := vgpuInstanceIDs(v)
+		if len(ids) == 0 {
+			continue
+		}
+
+		parent, err := dcgmprovider.Client().GetDeviceInfo(v.EntityId)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			s.vgpus = append(s.vgpus, VGPUInfo{EntityId: id, ParentGPU: parent})
+		}
+	}
+
+	if len(s.vgpus) == 0 {
+		return errNoVGPUs
+	}
+
+	s.gOpt = gOpt
+	slog.Debug(fmt.Sprintf(deviceInitMessage, s.infoType))
+	return nil
+}
+
+// vgpuInstanceIDs decodes the value of the DCGM_FI_DEV_VGPU_INSTANCE_IDS field, which is an array of 32-bit
+// integers in the host byte order. The first element is the number of the vGPU instances, which follow it.
+func vgpuInstanceIDs(v dcgm.FieldValue_v2) []uint {
+	if v.Status != 0 || v.FieldType != dcgm.DCGM_FT_BINARY {
+		return nil
+	}
+
+	count := uint(binary.NativeEndian.Uint32(v.Value[:4]))
+	count = min(count, uint(len(v.Value)/4-1))
+
+	ids := make([]uint, 0, count)
+	for i := uint(1); i <= count; i++ {
+		ids = append(ids, uint(binary.NativeEndian.Uint32(v.Value[i*4:])))
+	}
+
+	return ids
+}
+
 func (s *Info) setGPUInstanceProfileName(entityId uint, profileName string) bool {
 	for i := uint(0); i < s.gpuCount; i++ {
 		for j := range s.gpus[i].GPUInstances {
 			if s.gpus[i].GPUInstances[j].EntityId == entityId {
-				s.gpus[i].GPUInstances[j].ProfileName = profileName
+				// The instances are copied, because callers may still hold the previous ones
+				instances := slices.Clone(s.gpus[i].GPUInstances)
+				instances[j].ProfileName = profileName
+				s.gpus[i].GPUInstances = instances
 				return true
 			}
 		}
@@ -303,9 +436,20 @@ func (s *Info) setMigProfileNames(values []dcgm.FieldValue_v2) error {
 	errStr := "cannot find match for entities:"
 
 	for _, v := range values {
-		if !s.setGPUInstanceProfileName(v.EntityId, dcgmprovider.Client().Fv2_String(v)) {
+		profileName := dcgmprovider.Client().Fv2_String(v)
+		resolved := isResolvedProfileName(v, profileName)
+		if !resolved {
+			profileName = ""
+		}
+
+		if !s.setGPUInstanceProfileName(v.EntityId, profileName) {
 			errStr = fmt.Sprintf("%s group %d, id %d", errStr, v.EntityGroupId, v.EntityId)
 			errFound = true
+			continue
+		}
+
+		if !resolved {
+			s.requeueMigProfile(v.EntityId)
 		}
 	}
 
@@ -330,7 +474,14 @@ func (s *Info) populateMigProfileNames(entities []dcgm.GroupEntityPair) error {
 		return err
 	}
 
-	return s.setMigProfileNames(values)
+	err = s.setMigProfileNames(values)
+
+	if unresolved := s.unresolvedMigProfiles(); unresolved > 0 {
+		slog.Warn(fmt.Sprintf("%d MIG profile names are not resolved yet; will retry", unresolved))
+	}
+	unresolvedMigProfiles.Set(float64(s.unresolvedMigProfiles()))
+
+	return err
 }
 
 func (s *Info) gpuIDExists(gpuId int) bool {
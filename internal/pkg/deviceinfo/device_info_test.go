@@ -17,6 +17,7 @@
 package deviceinfo
 
 import (
+	"encoding/binary"
 	"fmt"
 	"slices"
 	"testing"
@@ -455,10 +456,51 @@ func TestInitialize(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:       "Initialize vGPUs",
+			gOpts:      appconfig.DeviceOptions{Flex: true},
+			entityType: dcgm.FE_VGPU,
+			mockCalls: func() {
+				mockDCGMProvider.EXPECT().GetAllDeviceCount().Return(uint(2), nil)
+				mockDCGMProvider.EXPECT().EntitiesGetLatestValues(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]dcgm.FieldValue_v2{
+						fakeVGPUInstanceIDs(0),
+						fakeVGPUInstanceIDs(1, 3584, 3585),
+					}, nil)
+				mockDCGMProvider.EXPECT().GetDeviceInfo(uint(1)).Return(dcgm.Device{GPU: 1, UUID: "GPU-1"}, nil)
+			},
+			expectedOutput: func() *Info {
+				return &Info{
+					vgpus: []VGPUInfo{
+						{EntityId: 3584, ParentGPU: dcgm.Device{GPU: 1, UUID: "GPU-1"}},
+						{EntityId: 3585, ParentGPU: dcgm.Device{GPU: 1, UUID: "GPU-1"}},
+					},
+					gOpt:     appconfig.DeviceOptions{Flex: true},
+					infoType: dcgm.FE_VGPU,
+				}
+			},
+			assertions: func(expected, actual *Info) {
+				assert.Equal(t, expected.vgpus, actual.VGPUs(), "vGPUs mismatch")
+				assert.Equal(t, expected.gOpt, actual.gOpt, "GPU options mismatch")
+				assert.Equal(t, expected.infoType, actual.infoType, "vGPU info type mismatch")
+			},
+			wantErr: false,
+		},
+		{
+			name:       "Initialize vGPUs without vGPU instances",
+			gOpts:      appconfig.DeviceOptions{Flex: true},
+			entityType: dcgm.FE_VGPU,
+			mockCalls: func() {
+				mockDCGMProvider.EXPECT().GetAllDeviceCount().Return(uint(1), nil)
+				mockDCGMProvider.EXPECT().EntitiesGetLatestValues(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]dcgm.FieldValue_v2{fakeVGPUInstanceIDs(0)}, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name:       "Initialize Invalid type error",
 			cOpts:      appconfig.DeviceOptions{Flex: true},
-			entityType: dcgm.FE_VGPU,
+			entityType: dcgm.FE_GPU_CI,
 			mockCalls:  func() {},
 			wantErr:    true,
 		},
@@ -482,6 +524,21 @@ func TestInitialize(t *testing.T) {
 	}
 }
 
+// fakeVGPUInstanceIDs returns the DCGM_FI_DEV_VGPU_INSTANCE_IDS value of the GPU with the vGPU instances.
+func fakeVGPUInstanceIDs(gpu uint, ids ...uint32) dcgm.FieldValue_v2 {
+	v := dcgm.FieldValue_v2{
+		EntityGroupId: dcgm.FE_GPU,
+		EntityId:      gpu,
+		FieldId:       dcgm.DCGM_FI_DEV_VGPU_INSTANCE_IDS,
+		FieldType:     dcgm.DCGM_FT_BINARY,
+	}
+	binary.NativeEndian.PutUint32(v.Value[:], uint32(len(ids)))
+	for i, id := range ids {
+		binary.NativeEndian.PutUint32(v.Value[(i+1)*4:], id)
+	}
+	return v
+}
+
 func TestInitializeGPUInfo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockDCGMProvider := mockdcgm.NewMockDCGM(ctrl)
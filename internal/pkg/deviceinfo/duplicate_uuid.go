@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+// duplicateUUIDSeparator separates the UUID from the PCI bus ID in the UUID of a GPU, which UUID is not unique
+const duplicateUUIDSeparator = "@"
+
+var duplicateUUIDs = selfmetrics.Default().Gauge("dcgm_exporter_duplicate_gpu_uuid",
+	"GPUs, which share their UUID with another GPU. Their UUID label is suffixed with the PCI bus ID.")
+
+// disambiguateDuplicateUUIDs suffixes the UUID of GPUs, which share their UUID with another GPU, with their PCI bus
+// ID. Broken vGPU and passthrough setups may report the same UUID for several GPUs, so their metrics would be
+// attributed to the wrong pods and collide when the other GPU labels are dropped.
+func (s *Info) disambiguateDuplicateUUIDs() {
+	duplicateUUIDs.Reset()
+
+	gpusByUUID := map[string][]uint{}
+	for i := uint(0); i < s.gpuCount; i++ {
+		uuid := s.gpus[i].DeviceInfo.UUID
+		if uuid != "" {
+			gpusByUUID[uuid] = append(gpusByUUID[uuid], i)
+		}
+	}
+
+	for i := uint(0); i < s.gpuCount; i++ {
+		device := &s.gpus[i].DeviceInfo
+		if len(gpusByUUID[device.UUID]) < 2 {
+			continue
+		}
+
+		uuid := device.UUID
+		device.UUID = uuid + duplicateUUIDSeparator + device.PCI.BusID
+		duplicateUUIDs.Set(1, "gpu", fmt.Sprint(device.GPU), "uuid", uuid, "pci_bus_id", device.PCI.BusID)
+	}
+
+	for uuid, gpus := range gpusByUUID {
+		if len(gpus) < 2 {
+			continue
+		}
+		ids := make([]string, 0, len(gpus))
+		for _, gpu := range gpus {
+			ids = append(ids, fmt.Sprint(gpu))
+		}
+		slog.Error(fmt.Sprintf("GPUs %s report the same UUID; their UUID label is suffixed with the PCI bus ID, "+
+			"and they can not be attributed to pods. Check the vGPU or passthrough configuration of the VMs.",
+			strings.Join(ids, ", ")), slog.String("uuid", uuid))
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestDisambiguateDuplicateUUIDs(t *testing.T) {
+	s := Info{gpuCount: 3}
+	s.gpus[0].DeviceInfo = dcgm.Device{GPU: 0, UUID: "GPU-dup", PCI: dcgm.PCIInfo{BusID: "00000000:3B:00.0"}}
+	s.gpus[1].DeviceInfo = dcgm.Device{GPU: 1, UUID: "GPU-unique", PCI: dcgm.PCIInfo{BusID: "00000000:5E:00.0"}}
+	s.gpus[2].DeviceInfo = dcgm.Device{GPU: 2, UUID: "GPU-dup", PCI: dcgm.PCIInfo{BusID: "00000000:86:00.0"}}
+
+	s.disambiguateDuplicateUUIDs()
+
+	assert.Equal(t, "GPU-dup@00000000:3B:00.0", s.GPU(0).DeviceInfo.UUID)
+	assert.Equal(t, "GPU-unique", s.GPU(1).DeviceInfo.UUID)
+	assert.Equal(t, "GPU-dup@00000000:86:00.0", s.GPU(2).DeviceInfo.UUID)
+
+	value, ok := selfmetrics.Default().Value("dcgm_exporter_duplicate_gpu_uuid",
+		"gpu", "2", "uuid", "GPU-dup", "pci_bus_id", "00000000:86:00.0")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), value)
+	_, ok = selfmetrics.Default().Value("dcgm_exporter_duplicate_gpu_uuid",
+		"gpu", "1", "uuid", "GPU-unique", "pci_bus_id", "00000000:5E:00.0")
+	assert.False(t, ok)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+// pciDomainDigits is the number of digits of the PCI domain in the bus IDs reported by DCGM, e.g. 00000000:3B:00.0.
+const pciDomainDigits = 8
+
+// resolveGPUIdentifiers adds the indices of the GPUs, which the device options select by UUID or PCI bus ID, to the
+// selected indices, since only the indices are known before the GPUs are found.
+func resolveGPUIdentifiers(gOpt appconfig.DeviceOptions, devices []dcgm.Device) (appconfig.DeviceOptions, error) {
+	if len(gOpt.MajorIdentifiers) == 0 {
+		return gOpt, nil
+	}
+
+	majorRange := slices.Clone(gOpt.MajorRange)
+	for _, id := range gOpt.MajorIdentifiers {
+		i := slices.IndexFunc(devices, func(device dcgm.Device) bool {
+			return MatchesGPUIdentifier(device, id)
+		})
+		if i < 0 {
+			return gOpt, fmt.Errorf("couldn't find requested GPU '%s'", id)
+		}
+		if !slices.Contains(majorRange, int(devices[i].GPU)) {
+			majorRange = append(majorRange, int(devices[i].GPU))
+		}
+	}
+
+	gOpt.MajorRange = majorRange
+	return gOpt, nil
+}
+
+// MatchesGPUIdentifier reports whether the GPU has the UUID or the PCI bus ID.
+func MatchesGPUIdentifier(device dcgm.Device, id string) bool {
+	if strings.EqualFold(device.UUID, id) {
+		return true
+	}
+	return device.PCI.BusID != "" && normalizePCIBusID(device.PCI.BusID) == normalizePCIBusID(id)
+}
+
+// normalizePCIBusID returns the PCI bus ID in the format of DCGM, with an 8 digit domain in upper case, so that the
+// bus IDs printed by lspci, e.g. 0000:3b:00.0 or 3b:00.0, match the ones of DCGM.
+func normalizePCIBusID(busID string) string {
+	busID = strings.ToUpper(busID)
+
+	domain, rest, found := strings.Cut(busID, ":")
+	if !found || !strings.Contains(rest, ":") {
+		// No domain
+		return strings.Repeat("0", pciDomainDigits) + ":" + busID
+	}
+	if len(domain) < pciDomainDigits {
+		domain = strings.Repeat("0", pciDomainDigits-len(domain)) + domain
+	}
+	return domain + ":" + rest
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestResolveGPUIdentifiers(t *testing.T) {
+	devices := []dcgm.Device{
+		{GPU: 0, UUID: "GPU-8a2b0c1d", PCI: dcgm.PCIInfo{BusID: "00000000:3B:00.0"}},
+		{GPU: 1, UUID: "GPU-5e6f7a8b", PCI: dcgm.PCIInfo{BusID: "00000000:5E:00.0"}},
+		{GPU: 2, UUID: "GPU-9c0d1e2f", PCI: dcgm.PCIInfo{BusID: "00000000:86:00.0"}},
+	}
+
+	tests := []struct {
+		name    string
+		gOpt    appconfig.DeviceOptions
+		want    []int
+		wantErr string
+	}{
+		{
+			name: "No identifiers",
+			gOpt: appconfig.DeviceOptions{MajorRange: []int{1}},
+			want: []int{1},
+		},
+		{
+			name: "UUID",
+			gOpt: appconfig.DeviceOptions{MajorIdentifiers: []string{"gpu-9C0D1E2F"}},
+			want: []int{2},
+		},
+		{
+			name: "PCI bus IDs",
+			gOpt: appconfig.DeviceOptions{MajorIdentifiers: []string{"0000:3b:00.0", "5e:00.0"}},
+			want: []int{0, 1},
+		},
+		{
+			name: "Identifiers and indices",
+			gOpt: appconfig.DeviceOptions{MajorRange: []int{0}, MajorIdentifiers: []string{"GPU-8a2b0c1d", "86:00.0"}},
+			want: []int{0, 2},
+		},
+		{
+			name:    "Unknown GPU",
+			gOpt:    appconfig.DeviceOptions{MajorIdentifiers: []string{"GPU-ffffffff"}},
+			wantErr: "couldn't find requested GPU 'GPU-ffffffff'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gOpt, err := resolveGPUIdentifiers(tt.gOpt, devices)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, gOpt.MajorRange)
+		})
+	}
+}
+
+func TestNormalizePCIBusID(t *testing.T) {
+	tests := []struct {
+		busID string
+		want  string
+	}{
+		{busID: "00000000:3B:00.0", want: "00000000:3B:00.0"},
+		{busID: "0000:3b:00.0", want: "00000000:3B:00.0"},
+		{busID: "3b:00.0", want: "00000000:3B:00.0"},
+		{busID: "0001:3b:00.0", want: "00000001:3B:00.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.busID, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizePCIBusID(tt.busID))
+		})
+	}
+}
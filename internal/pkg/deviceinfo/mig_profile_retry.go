@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	migProfileRetryInitialDelay = 5 * time.Second
+	migProfileRetryMaxDelay     = 5 * time.Minute
+)
+
+var (
+	unresolvedMigProfiles = selfmetrics.Default().Gauge("dcgm_exporter_unresolved_mig_profiles",
+		"Number of GPU instances, which MIG profile name is not resolved yet.")
+
+	now = time.Now
+)
+
+// migProfileRetry holds GPU instances, which MIG profile name could not be resolved yet. Shortly after MIG
+// reconfiguration, DCGM may report a blank name, so the resolution is retried with a backoff.
+type migProfileRetry struct {
+	pending     []dcgm.GroupEntityPair
+	delay       time.Duration
+	nextAttempt time.Time
+	// mtx guards the GPU instances of Info, which are updated in place once their profile name is resolved
+	mtx sync.RWMutex
+}
+
+// isResolvedProfileName reports whether DCGM returned an actual profile name.
+func isResolvedProfileName(v dcgm.FieldValue_v2, profileName string) bool {
+	if v.Status != dcgm.DCGM_ST_OK {
+		return false
+	}
+
+	switch profileName {
+	case "", dcgm.DCGM_FT_STR_BLANK, dcgm.DCGM_FT_STR_NOT_FOUND, dcgm.DCGM_FT_STR_NOT_SUPPORTED,
+		dcgm.DCGM_FT_STR_NOT_PERMISSIONED:
+		return false
+	}
+
+	return true
+}
+
+// requeueMigProfile schedules the profile name resolution of the GPU instance for a later attempt.
+func (s *Info) requeueMigProfile(entityID uint) {
+	if s.profileRetry == nil {
+		s.profileRetry = &migProfileRetry{delay: migProfileRetryInitialDelay}
+	}
+
+	s.profileRetry.pending = append(s.profileRetry.pending,
+		dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_I, EntityId: entityID})
+	s.profileRetry.nextAttempt = now().Add(s.profileRetry.delay)
+}
+
+// unresolvedMigProfiles returns the number of GPU instances waiting for the profile name resolution.
+func (s *Info) unresolvedMigProfiles() int {
+	if s.profileRetry == nil {
+		return 0
+	}
+
+	return len(s.profileRetry.pending)
+}
+
+// retryMigProfileNames resolves the pending profile names, when the next attempt is due.
+func (s *Info) retryMigProfileNames() {
+	r := s.profileRetry
+	if r == nil {
+		return
+	}
+
+	r.mtx.RLock()
+	due := len(r.pending) > 0 && !now().Before(r.nextAttempt)
+	r.mtx.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.pending) == 0 || now().Before(r.nextAttempt) {
+		return
+	}
+
+	pending := r.pending
+	r.pending = nil
+
+	values, err := dcgmprovider.Client().EntitiesGetLatestValues(pending, []dcgm.Short{dcgm.DCGM_FI_DEV_NAME},
+		dcgm.DCGM_FV_FLAG_LIVE_DATA)
+	if err != nil {
+		slog.Debug("Failed to resolve MIG profile names", slog.String(logging.ErrorKey, err.Error()))
+		r.pending = pending
+	} else if err := s.setMigProfileNames(values); err != nil {
+		slog.Debug("Failed to resolve MIG profile names", slog.String(logging.ErrorKey, err.Error()))
+	}
+
+	unresolvedMigProfiles.Set(float64(len(r.pending)))
+
+	if len(r.pending) == 0 {
+		slog.Info("All MIG profile names are resolved")
+		return
+	}
+
+	r.delay = min(r.delay*2, migProfileRetryMaxDelay)
+	r.nextAttempt = now().Add(r.delay)
+	slog.Debug(fmt.Sprintf("%d MIG profile names are not resolved yet; will retry in %s", len(r.pending), r.delay))
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deviceinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestMigProfileRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGMProvider := mockdcgm.NewMockDCGM(ctrl)
+	realDCGM := dcgmprovider.Client()
+	defer func() {
+		dcgmprovider.SetClient(realDCGM)
+	}()
+	dcgmprovider.SetClient(mockDCGMProvider)
+
+	clock := time.Unix(0, 0)
+	realNow := now
+	defer func() {
+		now = realNow
+	}()
+	now = func() time.Time { return clock }
+
+	deviceInfo := SpoofGPUDeviceInfo()
+	deviceInfo.gpus[1].GPUInstances[0].ProfileName = ""
+	previousInstances := deviceInfo.gpus[1].GPUInstances
+
+	values := []dcgm.FieldValue_v2{
+		{EntityGroupId: dcgm.FE_GPU_I, EntityId: 0},
+		{EntityGroupId: dcgm.FE_GPU_I, EntityId: 14},
+	}
+	mockDCGMProvider.EXPECT().Fv2_String(values[0]).Return(fakeProfileName)
+	mockDCGMProvider.EXPECT().Fv2_String(values[1]).Return(dcgm.DCGM_FT_STR_BLANK)
+
+	require.NoError(t, deviceInfo.setMigProfileNames(values))
+	assert.Equal(t, fakeProfileName, deviceInfo.GPU(0).GPUInstances[0].ProfileName)
+	assert.Equal(t, 1, deviceInfo.unresolvedMigProfiles())
+
+	// The next attempt is not due yet, so DCGM must not be queried
+	assert.Equal(t, "", deviceInfo.GPU(1).GPUInstances[0].ProfileName)
+
+	pending := []dcgm.GroupEntityPair{{EntityGroupId: dcgm.FE_GPU_I, EntityId: 14}}
+
+	// The name is still blank: the instance is requeued with a longer delay
+	clock = clock.Add(migProfileRetryInitialDelay)
+	mockDCGMProvider.EXPECT().EntitiesGetLatestValues(pending, []dcgm.Short{dcgm.DCGM_FI_DEV_NAME},
+		uint(dcgm.DCGM_FV_FLAG_LIVE_DATA)).Return(values[1:], nil)
+	mockDCGMProvider.EXPECT().Fv2_String(values[1]).Return(dcgm.DCGM_FT_STR_BLANK)
+	assert.Equal(t, "", deviceInfo.GPU(1).GPUInstances[0].ProfileName)
+	assert.Equal(t, 1, deviceInfo.unresolvedMigProfiles())
+	assert.Equal(t, 2*migProfileRetryInitialDelay, deviceInfo.profileRetry.delay)
+	unresolved, _ := selfmetrics.Default().Value("dcgm_exporter_unresolved_mig_profiles")
+	assert.Equal(t, float64(1), unresolved)
+
+	// The name is resolved: the instance is updated in place
+	clock = clock.Add(2 * migProfileRetryInitialDelay)
+	mockDCGMProvider.EXPECT().EntitiesGetLatestValues(pending, []dcgm.Short{dcgm.DCGM_FI_DEV_NAME},
+		uint(dcgm.DCGM_FV_FLAG_LIVE_DATA)).Return(values[1:], nil)
+	mockDCGMProvider.EXPECT().Fv2_String(values[1]).Return(fakeProfileName)
+	gpus := deviceInfo.GPUs()
+	assert.Equal(t, fakeProfileName, gpus[1].GPUInstances[0].ProfileName)
+	assert.Equal(t, 0, deviceInfo.unresolvedMigProfiles())
+	unresolved, _ = selfmetrics.Default().Value("dcgm_exporter_unresolved_mig_profiles")
+	assert.Equal(t, float64(0), unresolved)
+
+	// Previously returned instances are left untouched
+	assert.Equal(t, "", previousInstances[0].ProfileName)
+}
+
+func TestIsResolvedProfileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		profileName string
+		want        bool
+	}{
+		{name: "profile name", profileName: fakeProfileName, want: true},
+		{name: "empty", profileName: "", want: false},
+		{name: "blank", profileName: dcgm.DCGM_FT_STR_BLANK, want: false},
+		{name: "not found", profileName: dcgm.DCGM_FT_STR_NOT_FOUND, want: false},
+		{name: "error status", status: 1, profileName: fakeProfileName, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := dcgm.FieldValue_v2{Status: tt.status}
+			assert.Equal(t, tt.want, isResolvedProfileName(v, tt.profileName))
+		})
+	}
+}
@@ -32,6 +32,7 @@ type Provider interface {
 	Switch(i uint) SwitchInfo
 	CPUs() []CPUInfo
 	CPU(i uint) CPUInfo
+	VGPUs() []VGPUInfo
 	GOpts() appconfig.DeviceOptions
 	SOpts() appconfig.DeviceOptions
 	COpts() appconfig.DeviceOptions
@@ -70,3 +71,10 @@ type SwitchInfo struct {
 	EntityId uint
 	NvLinks  []dcgm.NvLinkStatus
 }
+
+// VGPUInfo is a vGPU instance running on a GPU of the host.
+type VGPUInfo struct {
+	EntityId uint
+	// ParentGPU is the GPU, which runs the vGPU instance
+	ParentGPU dcgm.Device
+}
@@ -34,15 +34,18 @@ func GetMonitoredEntities(deviceInfo deviceinfo.Provider) []Info {
 		monitoring = monitorAllCPUs(deviceInfo)
 	case dcgm.FE_CPU_CORE:
 		monitoring = monitorAllCPUCores(deviceInfo)
+	case dcgm.FE_VGPU:
+		monitoring = withoutPausedGPUs(monitorAllVGPUs(deviceInfo))
 	default:
 		if deviceInfo.GOpts().Flex {
 			monitoring = monitorAllGPUInstances(deviceInfo, true)
 		} else {
 			monitoring = handleGPUOptions(deviceInfo)
 		}
+		monitoring = withoutPausedGPUs(monitoring)
 	}
 
-	return monitoring
+	return withoutExcludedEntities(monitoring)
 }
 
 func handleGPUOptions(deviceInfo deviceinfo.Provider) []Info {
@@ -67,7 +70,9 @@ func handleGPUOptions(deviceInfo deviceinfo.Provider) []Info {
 	} else {
 		for _, gpuInstanceID := range deviceInfo.GOpts().MinorRange {
 			// We've already verified that everything in the options list exists
-			monitoring = append(monitoring, *monitorGPUInstance(deviceInfo, gpuInstanceID))
+			mi := *monitorGPUInstance(deviceInfo, gpuInstanceID)
+			monitoring = append(monitoring, mi)
+			monitoring = append(monitoring, monitorComputeInstances(deviceInfo, mi)...)
 		}
 	}
 
@@ -115,6 +120,7 @@ func monitorAllGPUInstances(deviceInfo deviceinfo.Provider, addFlexibly bool) []
 					PARENT_ID_IGNORED,
 				}
 				monitoring = append(monitoring, mi)
+				monitoring = append(monitoring, monitorComputeInstances(deviceInfo, mi)...)
 			}
 		}
 	}
@@ -122,6 +128,45 @@ func monitorAllGPUInstances(deviceInfo deviceinfo.Provider, addFlexibly bool) []
 	return monitoring
 }
 
+// monitorComputeInstances monitors the compute instances of the monitored GPU instance, when the compute instance
+// metrics are enabled.
+func monitorComputeInstances(deviceInfo deviceinfo.Provider, gpuInstance Info) []Info {
+	if len(gpuInstance.InstanceInfo.ComputeInstances) == 0 || !deviceInfo.GOpts().ComputeInstances {
+		return nil
+	}
+
+	var monitoring []Info
+
+	for _, ci := range gpuInstance.InstanceInfo.ComputeInstances {
+		mi := Info{
+			dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_CI, EntityId: ci.EntityId},
+			gpuInstance.DeviceInfo,
+			gpuInstance.InstanceInfo,
+			gpuInstance.Entity.EntityId,
+		}
+		monitoring = append(monitoring, mi)
+	}
+
+	return monitoring
+}
+
+// monitorAllVGPUs monitors the vGPU instances, which were discovered on the monitored GPUs.
+func monitorAllVGPUs(deviceInfo deviceinfo.Provider) []Info {
+	var monitoring []Info
+
+	for _, vgpu := range deviceInfo.VGPUs() {
+		mi := Info{
+			dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_VGPU, EntityId: vgpu.EntityId},
+			vgpu.ParentGPU,
+			nil,
+			vgpu.ParentGPU.GPU,
+		}
+		monitoring = append(monitoring, mi)
+	}
+
+	return monitoring
+}
+
 func monitorAllCPUs(deviceInfo deviceinfo.Provider) []Info {
 	var monitoring []Info
 
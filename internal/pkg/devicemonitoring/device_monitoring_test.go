@@ -1239,6 +1239,31 @@ func Test_monitorAllCPUs(t *testing.T) {
 	}
 }
 
+func Test_monitorAllVGPUs(t *testing.T) {
+	parent := dcgm.Device{GPU: 1, UUID: "GPU-1"}
+
+	ctrl := gomock.NewController(t)
+	deviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	deviceInfo.EXPECT().VGPUs().Return([]deviceinfo.VGPUInfo{
+		{EntityId: 3584, ParentGPU: parent},
+		{EntityId: 3585, ParentGPU: parent},
+	}).AnyTimes()
+
+	want := []Info{
+		{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_VGPU, EntityId: 3584},
+			DeviceInfo: parent,
+			ParentId:   1,
+		},
+		{
+			Entity:     dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_VGPU, EntityId: 3585},
+			DeviceInfo: parent,
+			ParentId:   1,
+		},
+	}
+	assert.Equal(t, want, monitorAllVGPUs(deviceInfo))
+}
+
 func Test_monitorAllCPUCores(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1608,3 +1633,83 @@ func Test_monitorGPUInstance(t *testing.T) {
 		})
 	}
 }
+
+func Test_monitorComputeInstances(t *testing.T) {
+	gpuInstance := deviceinfo.GPUInstanceInfo{
+		Info:        dcgm.MigEntityInfo{GpuUuid: "fake", NvmlInstanceId: 1, NvmlProfileSlices: 3},
+		ProfileName: "3g.40gb",
+		EntityId:    14,
+		ComputeInstances: []deviceinfo.ComputeInstanceInfo{
+			{InstanceInfo: dcgm.MigEntityInfo{NvmlInstanceId: 1, NvmlComputeInstanceId: 0}, EntityId: 30},
+			{InstanceInfo: dcgm.MigEntityInfo{NvmlInstanceId: 1, NvmlComputeInstanceId: 1}, EntityId: 31},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		computeInstances bool
+		want             []Info
+	}{
+		{
+			name:             "Compute instance metrics disabled",
+			computeInstances: false,
+			want: []Info{
+				{
+					Entity:       dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_I, EntityId: 14},
+					DeviceInfo:   dcgm.Device{GPU: uint(0)},
+					InstanceInfo: &gpuInstance,
+					ParentId:     PARENT_ID_IGNORED,
+				},
+			},
+		},
+		{
+			name:             "Compute instance metrics enabled",
+			computeInstances: true,
+			want: []Info{
+				{
+					Entity:       dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_I, EntityId: 14},
+					DeviceInfo:   dcgm.Device{GPU: uint(0)},
+					InstanceInfo: &gpuInstance,
+					ParentId:     PARENT_ID_IGNORED,
+				},
+				{
+					Entity:       dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_CI, EntityId: 30},
+					DeviceInfo:   dcgm.Device{GPU: uint(0)},
+					InstanceInfo: &gpuInstance,
+					ParentId:     14,
+				},
+				{
+					Entity:       dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_CI, EntityId: 31},
+					DeviceInfo:   dcgm.Device{GPU: uint(0)},
+					InstanceInfo: &gpuInstance,
+					ParentId:     14,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockGPUDeviceInfo := testutils.MockGPUDeviceInfo(ctrl, 1,
+				map[int][]deviceinfo.GPUInstanceInfo{0: {gpuInstance}})
+			mockGPUDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{
+				Flex:             true,
+				ComputeInstances: tt.computeInstances,
+			}).AnyTimes()
+
+			got := GetMonitoredEntities(mockGPUDeviceInfo)
+			assert.Equalf(t, tt.want, got, "Unexpected Output")
+
+			for _, mi := range got {
+				ci := mi.ComputeInstance()
+				if mi.Entity.EntityGroupId != dcgm.FE_GPU_CI {
+					assert.Nil(t, ci)
+					continue
+				}
+				if assert.NotNil(t, ci) {
+					assert.Equal(t, mi.Entity.EntityId, ci.EntityId)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package devicemonitoring
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+)
+
+// AllEntities is the ID of an entity exclusion, which excludes all the entities of its entity group.
+const AllEntities = "*"
+
+// excludedEntities are the entities, which are never monitored, e.g. known-bad GPUs or noisy NVLinks
+var excludedEntities = struct {
+	mtx        sync.RWMutex
+	exclusions []appconfig.EntityExclusion
+}{}
+
+// SetExclusions replaces the excluded entities. Excluding a GPU excludes its GPU instances, compute instances and
+// vGPUs too, excluding an NVSwitch its NVLinks, and excluding a CPU its cores.
+func SetExclusions(exclusions []appconfig.EntityExclusion) {
+	excludedEntities.mtx.Lock()
+	defer excludedEntities.mtx.Unlock()
+
+	excludedEntities.exclusions = slices.Clone(exclusions)
+}
+
+// IsExcluded reports whether the entity, or the entity it belongs to, is excluded.
+func IsExcluded(mi Info) bool {
+	excludedEntities.mtx.RLock()
+	defer excludedEntities.mtx.RUnlock()
+
+	return slices.ContainsFunc(excludedEntities.exclusions, func(exclusion appconfig.EntityExclusion) bool {
+		return isExcluded(mi, exclusion)
+	})
+}
+
+// withoutExcludedEntities removes the excluded entities.
+func withoutExcludedEntities(monitoring []Info) []Info {
+	return slices.DeleteFunc(monitoring, IsExcluded)
+}
+
+// isExcluded reports whether the entity, or the entity it belongs to, is excluded by the exclusion.
+func isExcluded(mi Info, exclusion appconfig.EntityExclusion) bool {
+	group := mi.Entity.EntityGroupId
+
+	switch exclusion.EntityGroup {
+	case dcgm.FE_GPU:
+		if group != dcgm.FE_GPU && group != dcgm.FE_GPU_I && group != dcgm.FE_GPU_CI && group != dcgm.FE_VGPU {
+			return false
+		}
+		return exclusion.ID == AllEntities || exclusion.ID == strconv.FormatUint(uint64(mi.DeviceInfo.GPU), 10) ||
+			deviceinfo.MatchesGPUIdentifier(mi.DeviceInfo, exclusion.ID)
+	case dcgm.FE_SWITCH:
+		switch group {
+		case dcgm.FE_SWITCH:
+			return matchesEntityID(exclusion.ID, mi.Entity.EntityId)
+		case dcgm.FE_LINK:
+			return matchesEntityID(exclusion.ID, mi.ParentId)
+		}
+	case dcgm.FE_LINK:
+		return group == dcgm.FE_LINK &&
+			(exclusion.ID == AllEntities || exclusion.ID == fmt.Sprintf("%d/%d", mi.ParentId, mi.Entity.EntityId))
+	case dcgm.FE_CPU:
+		switch group {
+		case dcgm.FE_CPU:
+			return matchesEntityID(exclusion.ID, mi.Entity.EntityId)
+		case dcgm.FE_CPU_CORE:
+			return matchesEntityID(exclusion.ID, mi.ParentId)
+		}
+	case dcgm.FE_CPU_CORE, dcgm.FE_VGPU:
+		return group == exclusion.EntityGroup && matchesEntityID(exclusion.ID, mi.Entity.EntityId)
+	}
+
+	return false
+}
+
+func matchesEntityID(id string, entityID uint) bool {
+	return id == AllEntities || id == strconv.FormatUint(uint64(entityID), 10)
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package devicemonitoring
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestWithoutExcludedEntities(t *testing.T) {
+	gpu0 := dcgm.Device{
+		GPU:  0,
+		UUID: "GPU-00000000-0000-0000-0000-000000000000",
+		PCI:  dcgm.PCIInfo{BusID: "00000000:3B:00.0"},
+	}
+	gpu1 := dcgm.Device{GPU: 1, UUID: "GPU-11111111-1111-1111-1111-111111111111"}
+
+	entities := map[string]Info{
+		"gpu 0":            {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: 0}, DeviceInfo: gpu0},
+		"gpu 1":            {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU, EntityId: 1}, DeviceInfo: gpu1},
+		"gpu instance 0":   {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_I, EntityId: 3}, DeviceInfo: gpu0},
+		"vgpu 7":           {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_VGPU, EntityId: 7}, DeviceInfo: gpu1},
+		"switch 0":         {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_SWITCH, EntityId: 0}},
+		"link 0/12":        {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_LINK, EntityId: 12}, ParentId: 0},
+		"link 1/12":        {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_LINK, EntityId: 12}, ParentId: 1},
+		"cpu 0":            {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_CPU, EntityId: 0}},
+		"cpu core 0/5":     {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_CPU_CORE, EntityId: 5}, ParentId: 0},
+		"cpu core 1/6":     {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_CPU_CORE, EntityId: 6}, ParentId: 1},
+		"compute instance": {Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU_CI, EntityId: 4}, DeviceInfo: gpu0},
+	}
+
+	tests := []struct {
+		name       string
+		exclusions []appconfig.EntityExclusion
+		want       []string
+	}{
+		{
+			name: "No exclusions",
+			want: []string{
+				"compute instance", "cpu 0", "cpu core 0/5", "cpu core 1/6", "gpu 0", "gpu 1", "gpu instance 0",
+				"link 0/12", "link 1/12", "switch 0", "vgpu 7",
+			},
+		},
+		{
+			name:       "GPU by UUID with its instances",
+			exclusions: []appconfig.EntityExclusion{{EntityGroup: dcgm.FE_GPU, ID: gpu0.UUID}},
+			want: []string{
+				"cpu 0", "cpu core 0/5", "cpu core 1/6", "gpu 1", "link 0/12", "link 1/12", "switch 0", "vgpu 7",
+			},
+		},
+		{
+			name: "GPUs by PCI bus ID and by index",
+			exclusions: []appconfig.EntityExclusion{
+				{EntityGroup: dcgm.FE_GPU, ID: "3b:00.0"},
+				{EntityGroup: dcgm.FE_GPU, ID: "1"},
+			},
+			want: []string{"cpu 0", "cpu core 0/5", "cpu core 1/6", "link 0/12", "link 1/12", "switch 0"},
+		},
+		{
+			name:       "All NVLinks",
+			exclusions: []appconfig.EntityExclusion{{EntityGroup: dcgm.FE_LINK, ID: AllEntities}},
+			want: []string{
+				"compute instance", "cpu 0", "cpu core 0/5", "cpu core 1/6", "gpu 0", "gpu 1", "gpu instance 0",
+				"switch 0", "vgpu 7",
+			},
+		},
+		{
+			name: "NVSwitch with its NVLinks, and a single NVLink",
+			exclusions: []appconfig.EntityExclusion{
+				{EntityGroup: dcgm.FE_SWITCH, ID: "0"},
+				{EntityGroup: dcgm.FE_LINK, ID: "1/12"},
+			},
+			want: []string{
+				"compute instance", "cpu 0", "cpu core 0/5", "cpu core 1/6", "gpu 0", "gpu 1", "gpu instance 0",
+				"vgpu 7",
+			},
+		},
+		{
+			name: "CPU with its cores, a core and a vGPU",
+			exclusions: []appconfig.EntityExclusion{
+				{EntityGroup: dcgm.FE_CPU, ID: "0"},
+				{EntityGroup: dcgm.FE_CPU_CORE, ID: "6"},
+				{EntityGroup: dcgm.FE_VGPU, ID: "7"},
+			},
+			want: []string{
+				"compute instance", "gpu 0", "gpu 1", "gpu instance 0", "link 0/12", "link 1/12", "switch 0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetExclusions(tt.exclusions)
+			defer SetExclusions(nil)
+
+			var got []string
+			for name, mi := range entities {
+				if len(withoutExcludedEntities([]Info{mi})) > 0 {
+					got = append(got, name)
+				}
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package devicemonitoring
+
+import (
+	"slices"
+	"sync"
+)
+
+// pausedGPUs are the GPUs, which are temporarily not monitored, e.g. while they are reset by an administrator
+var pausedGPUs = struct {
+	mtx  sync.RWMutex
+	gpus map[uint]struct{}
+}{gpus: map[uint]struct{}{}}
+
+// PauseGPU stops monitoring the GPU and its GPU instances, so no collector reads its values, and the groups created
+// afterwards leave it out, so its fields are not watched, until ResumeGPU is called. The groups, which exist already,
+// are created again by a reload.
+func PauseGPU(gpu uint) {
+	pausedGPUs.mtx.Lock()
+	defer pausedGPUs.mtx.Unlock()
+
+	pausedGPUs.gpus[gpu] = struct{}{}
+}
+
+// ResumeGPU monitors the GPU again.
+func ResumeGPU(gpu uint) {
+	pausedGPUs.mtx.Lock()
+	defer pausedGPUs.mtx.Unlock()
+
+	delete(pausedGPUs.gpus, gpu)
+}
+
+// withoutPausedGPUs removes the GPUs and GPU instances of the paused GPUs.
+func withoutPausedGPUs(monitoring []Info) []Info {
+	pausedGPUs.mtx.RLock()
+	defer pausedGPUs.mtx.RUnlock()
+
+	if len(pausedGPUs.gpus) == 0 {
+		return monitoring
+	}
+
+	return slices.DeleteFunc(monitoring, func(mi Info) bool {
+		_, paused := pausedGPUs.gpus[mi.DeviceInfo.GPU]
+		return paused
+	})
+}
@@ -28,3 +28,18 @@ type Info struct {
 	InstanceInfo *deviceinfo.GPUInstanceInfo
 	ParentId     uint
 }
+
+// ComputeInstance returns the compute instance of the entity, or nil, when the entity isn't a compute instance.
+func (i Info) ComputeInstance() *deviceinfo.ComputeInstanceInfo {
+	if i.Entity.EntityGroupId != dcgm.FE_GPU_CI || i.InstanceInfo == nil {
+		return nil
+	}
+
+	for j := range i.InstanceInfo.ComputeInstances {
+		if i.InstanceInfo.ComputeInstances[j].EntityId == i.Entity.EntityId {
+			return &i.InstanceInfo.ComputeInstances[j]
+		}
+	}
+
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
@@ -68,8 +69,12 @@ func shouldIncludeField(entityType, fieldLevel dcgm.Field_Entity_Group) bool {
 	}
 }
 
+// WatchDeviceFields watches the fields for the entities of the device info. Fields in fieldUpdateFreqs are watched
+// with their own update frequency, in their own field group, the others with updateFreqInUsec. The returned field
+// group contains the fields watched with updateFreqInUsec; it is empty, when every field has its own frequency.
 func (d *DeviceWatcher) WatchDeviceFields(
 	deviceFields []dcgm.Short, deviceInfo deviceinfo.Provider, updateFreqInUsec int64,
+	fieldUpdateFreqs map[dcgm.Short]int64,
 ) ([]dcgm.GroupHandle, dcgm.FieldHandle, []func(), error) {
 	var err error
 	var cleanups []func()
@@ -83,7 +88,7 @@ func (d *DeviceWatcher) WatchDeviceFields(
 		// This handles CPU Core case only.
 		groups, cleanups, err = d.createCPUCoreGroups(deviceInfo)
 	default:
-		// This handles GPUs (including GPU Instances), CPUs and Switches cases.
+		// This handles GPUs (including GPU Instances), vGPUs, CPUs and Switches cases.
 		groups, cleanups, err = d.createGroups(deviceInfo)
 	}
 	if err != nil {
@@ -92,20 +97,71 @@ func (d *DeviceWatcher) WatchDeviceFields(
 		return nil, dcgm.FieldHandle{}, cleanups, nil
 	}
 
-	fieldGroup, cleanup, fieldGroupErr := newFieldGroup(deviceFields)
-	if fieldGroupErr != nil {
-		return nil, dcgm.FieldHandle{}, utils.CleanupOnError(cleanups), fieldGroupErr
+	var defaultFieldGroup dcgm.FieldHandle
+	freqs, fieldsByFreq := fieldsByUpdateFreq(deviceFields, updateFreqInUsec, fieldUpdateFreqs)
+	for _, freq := range freqs {
+		fieldGroup, cleanup, fieldGroupErr := newFieldGroup(fieldsByFreq[freq])
+		if fieldGroupErr != nil {
+			return nil, dcgm.FieldHandle{}, utils.CleanupOnError(cleanups), fieldGroupErr
+		}
+		cleanups = append(cleanups, cleanup)
+
+		for _, group := range groups {
+			err = watchFieldGroup(group, fieldGroup, freq)
+			if err != nil {
+				return nil, dcgm.FieldHandle{}, utils.CleanupOnError(cleanups), err
+			}
+		}
+
+		if freq == updateFreqInUsec {
+			defaultFieldGroup = fieldGroup
+		}
+	}
+
+	return groups, defaultFieldGroup, cleanups, nil
+}
+
+// fieldsByUpdateFreq splits the fields by their update frequency. The default frequency comes first, when any field
+// has it, followed by the other frequencies in ascending order.
+func fieldsByUpdateFreq(
+	deviceFields []dcgm.Short, updateFreqInUsec int64, fieldUpdateFreqs map[dcgm.Short]int64,
+) ([]int64, map[int64][]dcgm.Short) {
+	fieldsByFreq := map[int64][]dcgm.Short{}
+	for _, field := range deviceFields {
+		freq, exists := fieldUpdateFreqs[field]
+		if !exists {
+			freq = updateFreqInUsec
+		}
+		fieldsByFreq[freq] = append(fieldsByFreq[freq], field)
+	}
+
+	freqs := make([]int64, 0, len(fieldsByFreq))
+	for freq := range fieldsByFreq {
+		if freq != updateFreqInUsec {
+			freqs = append(freqs, freq)
+		}
+	}
+	slices.Sort(freqs)
+	if _, exists := fieldsByFreq[updateFreqInUsec]; exists {
+		freqs = append([]int64{updateFreqInUsec}, freqs...)
 	}
-	cleanups = append(cleanups, cleanup)
 
+	return freqs, fieldsByFreq
+}
+
+// UpdateWatchFrequency changes the update frequency of fields, which are already watched for the groups.
+func (d *DeviceWatcher) UpdateWatchFrequency(
+	groups []dcgm.GroupHandle, fieldGroup dcgm.FieldHandle, updateFreqInUsec int64,
+) error {
 	for _, group := range groups {
-		err = watchFieldGroup(group, fieldGroup, updateFreqInUsec)
+		// Watching the same fields again replaces the update frequency of the existing watch
+		err := watchFieldGroup(group, fieldGroup, updateFreqInUsec)
 		if err != nil {
-			return nil, dcgm.FieldHandle{}, utils.CleanupOnError(cleanups), err
+			return err
 		}
 	}
 
-	return groups, fieldGroup, cleanups, nil
+	return nil
 }
 
 func (d *DeviceWatcher) createGroups(deviceInfo deviceinfo.Provider) ([]dcgm.GroupHandle, []func(),
@@ -162,6 +218,13 @@ func (d *DeviceWatcher) createCPUCoreGroups(deviceInfo deviceinfo.Provider) ([]d
 				continue
 			}
 
+			if devicemonitoring.IsExcluded(devicemonitoring.Info{
+				Entity:   dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_CPU_CORE, EntityId: core},
+				ParentId: cpu.EntityId,
+			}) {
+				continue
+			}
+
 			// Create per-cpu core groups or after max number of CPU cores have been added to current group
 			if groupCoreCount%dcgm.DCGM_GROUP_MAX_ENTITIES == 0 {
 				var cleanup func()
@@ -211,6 +274,13 @@ func (d *DeviceWatcher) createNVLinkGroups(deviceInfo deviceinfo.Provider) ([]dc
 				continue
 			}
 
+			if devicemonitoring.IsExcluded(devicemonitoring.Info{
+				Entity:   dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_LINK, EntityId: link.Index},
+				ParentId: link.ParentId,
+			}) {
+				continue
+			}
+
 			// Create per-switch link groups
 			if groupLinkCount == 0 {
 				var cleanup func()
@@ -247,7 +317,12 @@ func createGroup() (dcgm.GroupHandle, func(), error) {
 		return dcgm.GroupHandle{}, doNothing, err
 	}
 
+	connection := dcgmprovider.Connection()
 	cleanup := func() {
+		// The group was lost with its connection
+		if dcgmprovider.Connection() != connection {
+			return
+		}
 		destroyErr := dcgmprovider.Client().DestroyGroup(groupID)
 		if destroyErr != nil && !strings.Contains(destroyErr.Error(), DCGM_ST_NOT_CONFIGURED) {
 			slog.LogAttrs(context.Background(), slog.LevelWarn, "cannot destroy group",
@@ -271,7 +346,12 @@ func newFieldGroup(deviceFields []dcgm.Short) (dcgm.FieldHandle, func(), error)
 		return dcgm.FieldHandle{}, doNothing, err
 	}
 
+	connection := dcgmprovider.Connection()
 	cleanup := func() {
+		// The field group was lost with its connection
+		if dcgmprovider.Connection() != connection {
+			return
+		}
 		err := dcgmprovider.Client().FieldGroupDestroy(fieldGroup)
 		if err != nil {
 			slog.Warn("Cannot destroy field group.",
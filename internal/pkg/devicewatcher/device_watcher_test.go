@@ -675,7 +675,7 @@ func TestDeviceWatcher_WatchDeviceFields(t *testing.T) {
 
 			d := NewDeviceWatcher()
 			inputFields := []dcgm.Short{1, 2, 3, 4}
-			_, _, gotFuncs, err := d.WatchDeviceFields(inputFields, mockDeviceInfo, 1000000)
+			_, _, gotFuncs, err := d.WatchDeviceFields(inputFields, mockDeviceInfo, 1000000, nil)
 			// Ensure DestroyGroup functions gets called
 			for _, gotFunc := range gotFuncs {
 				gotFunc()
@@ -691,6 +691,22 @@ func TestDeviceWatcher_WatchDeviceFields(t *testing.T) {
 	}
 }
 
+func TestFieldsByUpdateFreq(t *testing.T) {
+	freqs, fieldsByFreq := fieldsByUpdateFreq([]dcgm.Short{1, 2, 3, 4, 5}, 1000000,
+		map[dcgm.Short]int64{2: 10000000, 4: 500000, 5: 10000000, 6: 500000})
+
+	assert.Equal(t, []int64{1000000, 500000, 10000000}, freqs)
+	assert.Equal(t, map[int64][]dcgm.Short{
+		1000000:  {1, 3},
+		500000:   {4},
+		10000000: {2, 5},
+	}, fieldsByFreq)
+
+	// No field is updated with the default frequency
+	freqs, _ = fieldsByUpdateFreq([]dcgm.Short{1}, 1000000, map[dcgm.Short]int64{1: 10000000})
+	assert.Equal(t, []int64{10000000}, freqs)
+}
+
 func TestDeviceWatcher_createGenericGroup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
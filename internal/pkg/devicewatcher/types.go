@@ -27,5 +27,7 @@ import (
 
 type Watcher interface {
 	GetDeviceFields([]counters.Counter, dcgm.Field_Entity_Group) []dcgm.Short
-	WatchDeviceFields([]dcgm.Short, deviceinfo.Provider, int64) ([]dcgm.GroupHandle, dcgm.FieldHandle, []func(), error)
+	WatchDeviceFields([]dcgm.Short, deviceinfo.Provider, int64, map[dcgm.Short]int64) ([]dcgm.GroupHandle,
+		dcgm.FieldHandle, []func(), error)
+	UpdateWatchFrequency([]dcgm.GroupHandle, dcgm.FieldHandle, int64) error
 }
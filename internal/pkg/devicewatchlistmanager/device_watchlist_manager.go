@@ -17,6 +17,9 @@
 package devicewatchlistmanager
 
 import (
+	"sync"
+	"time"
+
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
@@ -32,6 +35,7 @@ var DeviceTypesToWatch = []dcgm.Field_Entity_Group{
 	dcgm.FE_LINK,
 	dcgm.FE_CPU,
 	dcgm.FE_CPU_CORE,
+	dcgm.FE_VGPU,
 }
 
 type WatchList struct {
@@ -42,6 +46,8 @@ type WatchList struct {
 	labelDeviceFields []dcgm.Short
 	watcher           devicewatcher.Watcher
 	collectInterval   int64
+	// fieldIntervals are the update intervals of the fields, which differ from the collect interval
+	fieldIntervals map[dcgm.Short]time.Duration
 }
 
 func NewWatchList(
@@ -82,10 +88,44 @@ func (d *WatchList) Watch() ([]func(), error) {
 	var err error
 
 	d.deviceGroups, d.deviceFieldGroup, cleanups, err = d.watcher.WatchDeviceFields(d.deviceFields, d.deviceInfo,
-		d.collectInterval*1000)
+		d.collectInterval*1000, d.fieldUpdateFreqs())
 	return cleanups, err
 }
 
+// SetFieldIntervals sets the update intervals of the fields, which differ from the collect interval. They apply on
+// the next Watch.
+func (d *WatchList) SetFieldIntervals(fieldIntervals map[dcgm.Short]time.Duration) {
+	d.fieldIntervals = fieldIntervals
+}
+
+// fieldUpdateFreqs returns the update frequencies of the watched fields with their own interval, in microseconds.
+func (d *WatchList) fieldUpdateFreqs() map[dcgm.Short]int64 {
+	var freqs map[dcgm.Short]int64
+	for _, field := range d.deviceFields {
+		if interval, exists := d.fieldIntervals[field]; exists {
+			if freqs == nil {
+				freqs = map[dcgm.Short]int64{}
+			}
+			freqs[field] = interval.Microseconds()
+		}
+	}
+
+	return freqs
+}
+
+// SetCollectInterval changes the update frequency of the watched fields, in milliseconds. The fields are
+// watched with the new frequency on the next Watch when they are not watched yet. Fields with their own update
+// interval keep it.
+func (d *WatchList) SetCollectInterval(collectInterval int64) error {
+	d.collectInterval = collectInterval
+
+	if len(d.deviceGroups) == 0 || len(d.fieldUpdateFreqs()) == len(d.deviceFields) {
+		return nil
+	}
+
+	return d.watcher.UpdateWatchFrequency(d.deviceGroups, d.deviceFieldGroup, d.collectInterval*1000)
+}
+
 func (d *WatchList) DeviceGroups() []dcgm.GroupHandle {
 	return d.deviceGroups
 }
@@ -103,6 +143,7 @@ type WatchListManager struct {
 	sOpts            appconfig.DeviceOptions
 	cOpts            appconfig.DeviceOptions
 	useFakeGPUs      bool
+	mtx              sync.RWMutex
 }
 
 // NewWatchListManager creates a new instance of the WatchListManager
@@ -133,12 +174,18 @@ func (e *WatchListManager) CreateEntityWatchList(
 		return err
 	}
 
-	e.entityWatchLists[entityType] = *NewWatchList(
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	watchList := NewWatchList(
 		deviceInfo,
 		deviceFields,
 		labelDeviceFields,
 		watcher,
 		collectInterval)
+	watchList.SetFieldIntervals(e.counters.FieldIntervals())
+
+	e.entityWatchLists[entityType] = *watchList
 
 	return err
 }
@@ -146,6 +193,9 @@ func (e *WatchListManager) CreateEntityWatchList(
 // EntityWatchList returns a given entity's WatchList and true if such WatchList exists otherwise
 // an empty WatchList and false.
 func (e *WatchListManager) EntityWatchList(deviceType dcgm.Field_Entity_Group) (WatchList, bool) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
 	entityWatchList, exists := e.entityWatchLists[deviceType]
 	return entityWatchList, exists
 }
@@ -19,6 +19,7 @@ package devicewatchlistmanager
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 	"github.com/stretchr/testify/assert"
@@ -138,7 +139,7 @@ func TestNewWatchList(t *testing.T) {
 			}
 
 			mockDeviceWatcher.EXPECT().WatchDeviceFields(tt.args.deviceFields, tt.args.deviceInfo,
-				tt.args.collectInterval*1000).Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, err)
+				tt.args.collectInterval*1000, gomock.Any()).Return([]dcgm.GroupHandle{}, dcgm.FieldHandle{}, []func(){}, err)
 
 			got := NewWatchList(tt.args.deviceInfo, tt.args.deviceFields, tt.args.labelDeviceFields, mockDeviceWatcher,
 				tt.args.collectInterval)
@@ -167,6 +168,49 @@ func TestNewWatchList(t *testing.T) {
 	}
 }
 
+func TestWatchList_SetCollectInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	deviceInfo := mockDeviceInfoFunc(ctrl)
+	deviceFields := []dcgm.Short{1, 2}
+	groups := []dcgm.GroupHandle{{}}
+	fieldGroup := dcgm.FieldHandle{}
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	watchList := NewWatchList(deviceInfo, deviceFields, nil, mockDeviceWatcher, 30000)
+
+	// Fields, which are not watched yet, are watched with the new interval later
+	assert.NoError(t, watchList.SetCollectInterval(15000))
+
+	mockDeviceWatcher.EXPECT().WatchDeviceFields(deviceFields, deviceInfo, int64(15000*1000), gomock.Any()).
+		Return(groups, fieldGroup, []func(){}, nil)
+	_, err := watchList.Watch()
+	assert.NoError(t, err)
+
+	mockDeviceWatcher.EXPECT().UpdateWatchFrequency(groups, fieldGroup, int64(5000*1000)).Return(nil)
+	assert.NoError(t, watchList.SetCollectInterval(5000))
+}
+
+func TestWatchList_FieldIntervals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	deviceInfo := mockDeviceInfoFunc(ctrl)
+	groups := []dcgm.GroupHandle{{}}
+	fieldGroup := dcgm.FieldHandle{}
+
+	mockDeviceWatcher := mockdevicewatcher.NewMockWatcher(ctrl)
+	watchList := NewWatchList(deviceInfo, []dcgm.Short{1, 2}, nil, mockDeviceWatcher, 1000)
+	watchList.SetFieldIntervals(map[dcgm.Short]time.Duration{2: 10 * time.Second, 3: 5 * time.Second})
+
+	// Only the intervals of the watched fields are passed, in microseconds
+	mockDeviceWatcher.EXPECT().WatchDeviceFields([]dcgm.Short{1, 2}, deviceInfo, int64(1000*1000),
+		map[dcgm.Short]int64{2: 10000000}).Return(groups, fieldGroup, []func(){}, nil)
+	_, err := watchList.Watch()
+	assert.NoError(t, err)
+
+	// The fields with their own interval are not updated
+	watchList.SetDeviceFields([]dcgm.Short{2})
+	assert.NoError(t, watchList.SetCollectInterval(5000))
+}
+
 func TestNewWatchListManager(t *testing.T) {
 	type args struct {
 		counters counters.CounterList
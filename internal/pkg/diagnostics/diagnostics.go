@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package diagnostics runs the DCGM diagnostic on demand, e.g. after a node was drained, and keeps the result of the
+// last run, so remediation pipelines can validate the GPUs before the node is returned to service.
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/utils"
+)
+
+const resultFail = "fail"
+
+// ErrRunning is returned, when a diagnostic is requested while another one is running.
+var ErrRunning = errors.New("a diagnostic is already running")
+
+// levels are the diagnostic levels, which can be run on demand, as with dcgmi diag -r. The long levels run for
+// hours, so they are left to dcgmi on drained nodes.
+var levels = map[int]dcgm.DiagType{
+	1: dcgm.DiagQuick,
+	2: dcgm.DiagMedium,
+}
+
+var (
+	diagPassed = selfmetrics.Default().Gauge("dcgm_exporter_diagnostic_passed",
+		"Whether the last on-demand DCGM diagnostic passed (1) or failed (0), by level.")
+	diagLastRun = selfmetrics.Default().Gauge("dcgm_exporter_diagnostic_last_run_timestamp_seconds",
+		"Time the last on-demand DCGM diagnostic finished, as a Unix timestamp.")
+	diagTestResult = selfmetrics.Default().Gauge("dcgm_exporter_diagnostic_test_result",
+		"Result of every test of the last on-demand DCGM diagnostic: pass, warn, fail, skipped or notrun.")
+)
+
+// TestResult is the result of a test of the diagnostic.
+type TestResult struct {
+	Name         string `json:"name"`
+	Result       string `json:"result"`
+	Output       string `json:"output,omitempty"`
+	ErrorCode    uint   `json:"errorCode,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// Result is the result of a diagnostic run. It passed, when none of its tests failed.
+type Result struct {
+	Level    int          `json:"level"`
+	GPUs     []uint       `json:"gpus"`
+	Started  time.Time    `json:"started"`
+	Duration float64      `json:"durationSeconds"`
+	Passed   bool         `json:"passed"`
+	Tests    []TestResult `json:"tests"`
+}
+
+// Runner runs one diagnostic at a time, because the tests load the GPUs, and keeps the result of the last run.
+type Runner struct {
+	now func() time.Time
+
+	running sync.Mutex
+
+	mtx  sync.Mutex
+	last *Result
+}
+
+// New creates a runner, which has not run a diagnostic yet.
+func New() *Runner {
+	diagPassed.Reset()
+	diagLastRun.Reset()
+	diagTestResult.Reset()
+
+	return &Runner{now: time.Now}
+}
+
+// ParseLevel returns the diagnostic level of the value.
+func ParseLevel(value string) (int, error) {
+	level, err := strconv.Atoi(value)
+	if _, exists := levels[level]; err != nil || !exists {
+		return 0, fmt.Errorf("invalid level '%s'; it must be 1 (quick) or 2 (medium)", value)
+	}
+	return level, nil
+}
+
+// Run runs the diagnostic of the level on the GPUs, and returns its result. It returns ErrRunning, without
+// waiting, when another diagnostic is running.
+func (r *Runner) Run(level int, gpus []uint) (Result, error) {
+	diagType, exists := levels[level]
+	if !exists {
+		return Result{}, fmt.Errorf("invalid level %d", level)
+	}
+
+	if !r.running.TryLock() {
+		return Result{}, ErrRunning
+	}
+	defer r.running.Unlock()
+
+	result := Result{Level: level, GPUs: slices.Clone(gpus), Started: r.now()}
+	slog.Info("Running the DCGM diagnostic", slog.Int("level", level), slog.Any("gpus", gpus))
+
+	response, err := runDiag(diagType, gpus)
+	if err != nil {
+		return Result{}, err
+	}
+
+	finished := r.now()
+	result.Duration = finished.Sub(result.Started).Seconds()
+	result.Passed = true
+	for i, test := range response.Software {
+		name := test.TestName
+		if name == "" {
+			name = fmt.Sprintf("test %d", i)
+		}
+		result.Tests = append(result.Tests, TestResult{
+			Name:         name,
+			Result:       test.Status,
+			Output:       test.TestOutput,
+			ErrorCode:    test.ErrorCode,
+			ErrorMessage: test.ErrorMessage,
+		})
+		if test.Status == resultFail {
+			result.Passed = false
+		}
+	}
+
+	r.record(result, finished)
+	return result, nil
+}
+
+// Last returns the result of the last diagnostic, and false, when none ran yet.
+func (r *Runner) Last() (Result, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.last == nil {
+		return Result{}, false
+	}
+	return *r.last, true
+}
+
+func (r *Runner) record(result Result, finished time.Time) {
+	r.mtx.Lock()
+	r.last = &result
+	r.mtx.Unlock()
+
+	outcome := resultFail
+	passed := 0.0
+	if result.Passed {
+		outcome = "pass"
+		passed = 1
+	}
+
+	slog.Info("The DCGM diagnostic finished", slog.Int("level", result.Level), slog.String("outcome", outcome))
+	diagPassed.Reset()
+	diagPassed.Set(passed, "level", strconv.Itoa(result.Level))
+	diagLastRun.Set(float64(finished.Unix()))
+	diagTestResult.Reset()
+	for _, test := range result.Tests {
+		diagTestResult.Set(1, "test", test.Name, "result", test.Result)
+	}
+}
+
+// runDiag runs the diagnostic on the GPUs in a temporary group, which is destroyed before runDiag returns.
+func runDiag(diagType dcgm.DiagType, gpus []uint) (dcgm.DiagResults, error) {
+	number, err := utils.RandUint64()
+	if err != nil {
+		return dcgm.DiagResults{}, err
+	}
+
+	group, err := dcgmprovider.Client().CreateGroup(fmt.Sprintf("diag-group-%d", number))
+	if err != nil {
+		return dcgm.DiagResults{}, fmt.Errorf("failed to create the diagnostic group; err: %w", err)
+	}
+	defer func() {
+		if err := dcgmprovider.Client().DestroyGroup(group); err != nil {
+			slog.LogAttrs(context.Background(), slog.LevelWarn, "Cannot destroy the diagnostic group",
+				slog.Any(logging.GroupIDKey, group),
+				slog.String(logging.ErrorKey, err.Error()),
+			)
+		}
+	}()
+
+	for _, gpu := range gpus {
+		err = dcgmprovider.Client().AddEntityToGroup(group, dcgm.FE_GPU, gpu)
+		if err != nil {
+			return dcgm.DiagResults{}, fmt.Errorf("failed to add GPU %d to the diagnostic group; err: %w", gpu, err)
+		}
+	}
+
+	response, err := dcgmprovider.Client().RunDiag(diagType, group)
+	if err != nil {
+		return dcgm.DiagResults{}, fmt.Errorf("failed to run the diagnostic; err: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diagnostics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func setupMockDCGM(t *testing.T) *mockdcgm.MockDCGM {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	t.Cleanup(func() { dcgmprovider.SetClient(realDCGM) })
+	dcgmprovider.SetClient(mockDCGM)
+
+	group := dcgm.GroupHandle{}
+	mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(group, nil).AnyTimes()
+	mockDCGM.EXPECT().AddEntityToGroup(group, dcgm.FE_GPU, gomock.Any()).Return(nil).AnyTimes()
+	mockDCGM.EXPECT().DestroyGroup(group).Return(nil).AnyTimes()
+
+	return mockDCGM
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, level)
+
+	for _, value := range []string{"0", "3", "quick"} {
+		_, err := ParseLevel(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	mockDCGM := setupMockDCGM(t)
+	mockDCGM.EXPECT().RunDiag(dcgm.DiagType(dcgm.DiagQuick), gomock.Any()).Return(dcgm.DiagResults{
+		Software: []dcgm.DiagResult{
+			{Status: "pass", TestName: "presence (and version) of NVML lib"},
+			{Status: "fail", TestName: "pending frame buffer page retirement", ErrorCode: 5, ErrorMessage: "pending"},
+			{Status: "skipped"},
+		},
+	}, nil)
+
+	runner := New()
+	_, exists := runner.Last()
+	assert.False(t, exists)
+
+	result, err := runner.Run(1, []uint{0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Level)
+	assert.Equal(t, []uint{0, 1}, result.GPUs)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Tests, 3)
+	assert.Equal(t, "pending", result.Tests[1].ErrorMessage)
+	assert.Equal(t, "test 2", result.Tests[2].Name)
+
+	last, exists := runner.Last()
+	require.True(t, exists)
+	assert.Equal(t, result, last)
+
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_diagnostic_passed", "level", "1")
+	assert.Equal(t, 0.0, value)
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_diagnostic_test_result",
+		"test", "pending frame buffer page retirement", "result", "fail")
+	assert.Equal(t, 1.0, value)
+}
+
+func TestRunner_RunError(t *testing.T) {
+	mockDCGM := setupMockDCGM(t)
+	mockDCGM.EXPECT().RunDiag(gomock.Any(), gomock.Any()).Return(dcgm.DiagResults{}, errors.New("boom"))
+
+	runner := New()
+	_, err := runner.Run(2, []uint{0})
+	assert.ErrorContains(t, err, "boom")
+
+	_, exists := runner.Last()
+	assert.False(t, exists)
+}
+
+func TestRunner_RunConcurrently(t *testing.T) {
+	mockDCGM := setupMockDCGM(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockDCGM.EXPECT().RunDiag(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(dcgm.DiagType, dcgm.GroupHandle) (dcgm.DiagResults, error) {
+			close(started)
+			<-release
+			return dcgm.DiagResults{Software: []dcgm.DiagResult{{Status: "pass", TestName: "inforom corruption"}}}, nil
+		})
+
+	runner := New()
+	done := make(chan Result)
+	go func() {
+		result, err := runner.Run(1, []uint{0})
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	<-started
+	_, err := runner.Run(1, []uint{0})
+	assert.ErrorIs(t, err, ErrRunning)
+
+	close(release)
+	assert.True(t, (<-done).Passed)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dmon renders the collected GPU metrics in the CSV format of nvidia-smi dmon, so that tooling, which parses
+// the dmon output, can read the metrics from the exporter.
+package dmon
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+)
+
+// missingValue is printed by dmon for values, which are not available
+const missingValue = "-"
+
+// Column is a column of the dmon output and the counter its values are read from.
+type Column struct {
+	Name      string
+	Unit      string
+	FieldName string
+}
+
+// Columns are the supported columns, in the order of the dmon output.
+var Columns = []Column{
+	{Name: "pwr", Unit: "W", FieldName: "DCGM_FI_DEV_POWER_USAGE"},
+	{Name: "gtemp", Unit: "C", FieldName: "DCGM_FI_DEV_GPU_TEMP"},
+	{Name: "mtemp", Unit: "C", FieldName: "DCGM_FI_DEV_MEMORY_TEMP"},
+	{Name: "sm", Unit: "%", FieldName: "DCGM_FI_DEV_GPU_UTIL"},
+	{Name: "mem", Unit: "%", FieldName: "DCGM_FI_DEV_MEM_COPY_UTIL"},
+	{Name: "enc", Unit: "%", FieldName: "DCGM_FI_DEV_ENC_UTIL"},
+	{Name: "dec", Unit: "%", FieldName: "DCGM_FI_DEV_DEC_UTIL"},
+	{Name: "mclk", Unit: "MHz", FieldName: "DCGM_FI_DEV_MEM_CLOCK"},
+	{Name: "pclk", Unit: "MHz", FieldName: "DCGM_FI_DEV_SM_CLOCK"},
+	{Name: "fb", Unit: "MB", FieldName: "DCGM_FI_DEV_FB_USED"},
+}
+
+// ParseColumns returns the columns with the names, in the order of the names.
+func ParseColumns(names []string) ([]Column, error) {
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(Columns, func(c Column) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown dmon column '%s'", name)
+		}
+		columns = append(columns, Columns[i])
+	}
+	return columns, nil
+}
+
+// Render writes the header lines with the column names and units, and a line per GPU with the latest values.
+// Values of GPU instances are not part of the dmon output, so they are skipped.
+func Render(w io.Writer, columns []Column, metrics collector.MetricsByCounter) error {
+	// values maps the GPU index and the field name to the value
+	values := map[int]map[string]string{}
+	for counter, counterMetrics := range metrics {
+		for _, m := range counterMetrics {
+			if m.GPUInstanceID != "" {
+				continue
+			}
+			gpu, err := strconv.Atoi(m.GPU)
+			if err != nil {
+				continue
+			}
+			if _, exists := values[gpu]; !exists {
+				values[gpu] = map[string]string{}
+			}
+			if _, exists := values[gpu][counter.FieldName]; !exists {
+				values[gpu][counter.FieldName] = formatValue(m.Value)
+			}
+		}
+	}
+
+	names := []string{"#gpu"}
+	units := []string{"#Idx"}
+	for _, column := range columns {
+		names = append(names, column.Name)
+		units = append(units, column.Unit)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(names, ", ") + "\n")
+	b.WriteString(strings.Join(units, ", ") + "\n")
+
+	gpus := make([]int, 0, len(values))
+	for gpu := range values {
+		gpus = append(gpus, gpu)
+	}
+	slices.Sort(gpus)
+
+	for _, gpu := range gpus {
+		row := []string{strconv.Itoa(gpu)}
+		for _, column := range columns {
+			value, exists := values[gpu][column.FieldName]
+			if !exists {
+				value = missingValue
+			}
+			row = append(row, value)
+		}
+		b.WriteString(strings.Join(row, ", ") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatValue rounds the value to an integer, as dmon prints it.
+func formatValue(value string) string {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return missingValue
+	}
+	return strconv.FormatInt(int64(math.Round(v)), 10)
+}
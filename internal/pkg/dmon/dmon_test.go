@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dmon
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns([]string{"sm", "pwr"})
+	require.NoError(t, err)
+	assert.Equal(t, []Column{Columns[3], Columns[0]}, columns)
+
+	_, err = ParseColumns([]string{"sm", "jpg"})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	power := counters.Counter{FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	util := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+
+	metrics := collector.MetricsByCounter{
+		power: {
+			{GPU: "1", Value: "87.600000"},
+			{GPU: "0", Value: "43.120000"},
+			{GPU: "0", GPUInstanceID: "1", Value: "10.000000"},
+		},
+		util: {
+			{GPU: "0", Value: "12"},
+		},
+	}
+
+	columns, err := ParseColumns([]string{"pwr", "sm", "mclk"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, columns, metrics))
+	assert.Equal(t, `#gpu, pwr, sm, mclk
+#Idx, W, %, MHz
+0, 43, 12, -
+1, 88, -, -
+`, buf.String())
+}
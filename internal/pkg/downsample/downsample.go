@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package downsample aggregates a small set of counters into averages and maxima over a long window, which are
+// served separately for long-retention Prometheus tiers.
+package downsample
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/rendermetrics"
+)
+
+// series accumulates the values of one series in a window.
+type series struct {
+	group  dcgm.Field_Entity_Group
+	metric collector.Metric
+	sum    float64
+	max    float64
+	count  int
+}
+
+// Downsampler aggregates the values of the counters over windows aligned to the wall clock, and serves the
+// aggregates of the last completed window.
+type Downsampler struct {
+	fields map[string]struct{}
+	window time.Duration
+	suffix string
+	now    func() time.Time
+
+	mtx         sync.Mutex
+	windowStart time.Time
+	partial     bool
+	current     map[string]*series
+	completed   map[string]*series
+}
+
+// New creates a downsampler of the fields.
+func New(fields []string, window time.Duration) *Downsampler {
+	d := &Downsampler{
+		fields:    map[string]struct{}{},
+		window:    window,
+		suffix:    windowSuffix(window),
+		now:       time.Now,
+		current:   map[string]*series{},
+		completed: map[string]*series{},
+	}
+
+	for _, field := range fields {
+		d.fields[field] = struct{}{}
+	}
+
+	return d
+}
+
+// Run observes the collected values until the channel is closed.
+func (d *Downsampler) Run(collections <-chan eventbus.CollectionEvent) {
+	for collection := range collections {
+		d.Observe(collection.Metrics)
+	}
+}
+
+// Observe adds the values of the downsampled counters to the current window. When the window is over, its
+// aggregates replace the served ones.
+func (d *Downsampler) Observe(metricGroups registry.MetricsByCounterGroup) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	windowStart := d.now().Truncate(d.window)
+	if !windowStart.Equal(d.windowStart) {
+		// The first window is only partially observed, and is not served
+		if !d.partial && !d.windowStart.IsZero() {
+			d.completed = d.current
+		}
+		d.partial = d.windowStart.IsZero()
+		d.current = map[string]*series{}
+		d.windowStart = windowStart
+	}
+
+	for group, metrics := range metricGroups {
+		for counter, values := range metrics {
+			if _, ok := d.fields[counter.FieldName]; !ok {
+				continue
+			}
+
+			for _, metric := range values {
+				value, err := strconv.ParseFloat(metric.Value, 64)
+				if err != nil {
+					continue
+				}
+
+				key := seriesKey(group, metric)
+				s, exists := d.current[key]
+				if !exists {
+					s = &series{group: group, max: value}
+					d.current[key] = s
+				}
+				s.metric = metric
+				s.sum += value
+				s.max = max(s.max, value)
+				s.count++
+			}
+		}
+	}
+}
+
+// Render writes the aggregates of the last completed window in the Prometheus text format.
+func (d *Downsampler) Render(w io.Writer) error {
+	d.mtx.Lock()
+	groups := map[dcgm.Field_Entity_Group]collector.MetricsByCounter{}
+	for _, s := range d.completed {
+		metrics, exists := groups[s.group]
+		if !exists {
+			metrics = collector.MetricsByCounter{}
+			groups[s.group] = metrics
+		}
+
+		avgCounter := d.counter(s.metric.Counter, "avg", "Average")
+		maxCounter := d.counter(s.metric.Counter, "max", "Maximum")
+		metrics[avgCounter] = append(metrics[avgCounter], s.withValue(avgCounter, s.sum/float64(s.count)))
+		metrics[maxCounter] = append(metrics[maxCounter], s.withValue(maxCounter, s.max))
+	}
+	d.mtx.Unlock()
+
+	sortedGroups := make([]dcgm.Field_Entity_Group, 0, len(groups))
+	for group := range groups {
+		sortedGroups = append(sortedGroups, group)
+	}
+	slices.Sort(sortedGroups)
+
+	for _, group := range sortedGroups {
+		if err := rendermetrics.RenderGroup(w, group, groups[group]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// counter returns the counter of the aggregate of the counter.
+func (d *Downsampler) counter(c counters.Counter, aggregate, help string) counters.Counter {
+	counter := counters.Counter{
+		FieldID:   c.FieldID,
+		FieldName: c.FieldName,
+		PromType:  "gauge",
+		Help:      fmt.Sprintf("%s over %s of %s", help, d.suffix, c.MetricName()),
+		Name:      c.Name,
+	}
+	return counter.WithSuffix(fmt.Sprintf("_%s_%s", aggregate, d.suffix))
+}
+
+func (s *series) withValue(counter counters.Counter, value float64) collector.Metric {
+	m := s.metric
+	m.Counter = counter
+	m.Value = strconv.FormatFloat(value, 'f', -1, 64)
+	return m
+}
+
+func seriesKey(group dcgm.Field_Entity_Group, m collector.Metric) string {
+	attributes := make([]string, 0, len(m.Attributes))
+	for k, v := range m.Attributes {
+		attributes = append(attributes, k+"="+v)
+	}
+	slices.Sort(attributes)
+
+	return fmt.Sprintf("%d/%s/%s/%s/%s/%s/%s/%s", group, m.Counter.FieldName, m.GPU, m.GPUUUID, m.GPUDevice,
+		m.GPUInstanceID, m.ComputeInstanceID, strings.Join(attributes, ","))
+}
+
+// windowSuffix formats the window as the suffix of the metric names, e.g. 5m.
+func windowSuffix(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", window/time.Second)
+	}
+}
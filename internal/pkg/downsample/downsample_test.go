@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package downsample
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+var (
+	powerUsage = counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	gpuTemp    = counters.Counter{FieldID: 150, FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge"}
+)
+
+func newMetrics(power, temp string) registry.MetricsByCounterGroup {
+	metric := func(counter counters.Counter, value string) collector.Metric {
+		return collector.Metric{
+			Counter: counter,
+			Value:   value,
+			GPU:     "0",
+			GPUUUID: "GPU-0",
+			UUID:    "UUID",
+		}
+	}
+
+	return registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: {
+			powerUsage: {metric(powerUsage, power)},
+			gpuTemp:    {metric(gpuTemp, temp)},
+		},
+	}
+}
+
+func TestDownsampler(t *testing.T) {
+	d := New([]string{"DCGM_FI_DEV_POWER_USAGE"}, 5*time.Minute)
+
+	clock := time.Date(2024, 1, 1, 12, 3, 0, 0, time.UTC)
+	d.now = func() time.Time { return clock }
+
+	render := func() string {
+		var buf bytes.Buffer
+		require.NoError(t, d.Render(&buf))
+		return buf.String()
+	}
+
+	// The first window is only partially observed
+	d.Observe(newMetrics("500", "80"))
+	clock = clock.Add(2 * time.Minute)
+	d.Observe(newMetrics("100", "80"))
+	clock = clock.Add(time.Minute)
+	d.Observe(newMetrics("300", "80"))
+	clock = clock.Add(3 * time.Minute)
+	d.Observe(newMetrics("200", "80"))
+	assert.Empty(t, render())
+
+	// The window 12:05-12:10 is served once it is over
+	clock = clock.Add(time.Minute)
+	d.Observe(newMetrics("900", "80"))
+	out := render()
+	assert.Contains(t, out, `DCGM_FI_DEV_POWER_USAGE_avg_5m{gpu="0",UUID="GPU-0",pci_bus_id="",device="",modelName=""} 200`)
+	assert.Contains(t, out, `DCGM_FI_DEV_POWER_USAGE_max_5m{gpu="0",UUID="GPU-0",pci_bus_id="",device="",modelName=""} 300`)
+	assert.Contains(t, out, "# TYPE DCGM_FI_DEV_POWER_USAGE_avg_5m gauge")
+	assert.NotContains(t, out, "DCGM_FI_DEV_GPU_TEMP")
+	assert.Equal(t, 2, strings.Count(out, "# TYPE"))
+}
+
+func TestWindowSuffix(t *testing.T) {
+	assert.Equal(t, "5m", windowSuffix(5*time.Minute))
+	assert.Equal(t, "1h", windowSuffix(time.Hour))
+	assert.Equal(t, "90s", windowSuffix(90*time.Second))
+}
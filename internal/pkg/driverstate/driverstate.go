@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driverstate reads the state of the GPU driver from procfs and sysfs, which is available, even when DCGM
+// and NVML fail, e.g. after a mismatch of the kernel module and the user space driver.
+package driverstate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ProcPath is the mount point of procfs
+	ProcPath = "/proc"
+	// ModulesPath is the sysfs directory of the loaded kernel modules
+	ModulesPath = "/sys/module"
+)
+
+// Modules are the kernel modules of the driver
+var Modules = []string{"nvidia", "nvidia_uvm", "nvidia_modeset", "nvidia_drm"}
+
+// Kernel module types, the open GPU kernel modules or the proprietary ones
+const (
+	ModuleTypeOpen        = "open"
+	ModuleTypeProprietary = "proprietary"
+)
+
+var (
+	versionPattern    = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+	deviceNodePattern = regexp.MustCompile(`^/dev/nvidia(\d+)$`)
+)
+
+// Version is the version of the loaded kernel module, as reported in /proc/driver/nvidia/version
+type Version struct {
+	KernelModule string
+	// ModuleType is either ModuleTypeOpen or ModuleTypeProprietary
+	ModuleType string
+	// GCC is the version of the compiler, which built the kernel module
+	GCC string
+}
+
+// ReadVersion reads the version of the loaded kernel module.
+func ReadVersion() (Version, error) {
+	file, err := os.Open(filepath.Join(ProcPath, "driver/nvidia/version"))
+	if err != nil {
+		return Version{}, err
+	}
+	defer file.Close()
+
+	return ParseVersion(file)
+}
+
+// ParseVersion parses the content of the version file, e.g.
+//
+//	NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.15  Tue Mar  5 22:23:56 UTC 2024
+//	GCC version:  gcc version 12.3.0 (Ubuntu 12.3.0-1ubuntu1~22.04)
+func ParseVersion(r io.Reader) (Version, error) {
+	var version Version
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if nvrm, found := strings.CutPrefix(line, "NVRM version:"); found {
+			version.ModuleType = ModuleTypeProprietary
+			if strings.Contains(nvrm, "Open Kernel Module") {
+				version.ModuleType = ModuleTypeOpen
+			}
+			for _, field := range strings.Fields(nvrm) {
+				if versionPattern.MatchString(field) {
+					version.KernelModule = field
+					break
+				}
+			}
+		}
+		if gcc, found := strings.CutPrefix(line, "GCC version:"); found {
+			fields := strings.Fields(gcc)
+			for i := 0; i < len(fields)-1; i++ {
+				if fields[i] == "version" {
+					version.GCC = fields[i+1]
+					break
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Version{}, err
+	}
+	if version.KernelModule == "" {
+		return Version{}, fmt.Errorf("no kernel module version in the driver version file")
+	}
+
+	return version, nil
+}
+
+// ReadKernelRelease reads the release of the running kernel, e.g. 6.1.58+.
+func ReadKernelRelease() (string, error) {
+	release, err := os.ReadFile(filepath.Join(ProcPath, "sys/kernel/osrelease"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(release)), nil
+}
+
+// ModuleLoaded returns whether the kernel module is loaded and initialized. Modules, which are built into the kernel,
+// have no init state and are considered loaded.
+func ModuleLoaded(module string) bool {
+	dir := filepath.Join(ModulesPath, module)
+	if _, err := os.Stat(dir); err != nil {
+		return false
+	}
+
+	state, err := os.ReadFile(filepath.Join(dir, "initstate"))
+	if os.IsNotExist(err) {
+		return true
+	}
+	return err == nil && strings.TrimSpace(string(state)) == "live"
+}
+
+// DeviceMinors maps the PCI bus IDs of the GPUs to the minor numbers of their device nodes, /dev/nvidia<minor>
+type DeviceMinors map[string]int
+
+// Minor returns the minor number of the GPU with the PCI bus ID, in any of the formats of DCGM, NVML or procfs.
+func (m DeviceMinors) Minor(busID string) (int, bool) {
+	minor, exists := m[normalizeBusID(busID)]
+	return minor, exists
+}
+
+// ReadDeviceMinors reads the minor numbers of the GPUs from /proc/driver/nvidia/gpus/<bus ID>/information.
+func ReadDeviceMinors() (DeviceMinors, error) {
+	dirs, err := os.ReadDir(filepath.Join(ProcPath, "driver/nvidia/gpus"))
+	if err != nil {
+		return nil, err
+	}
+
+	minors := DeviceMinors{}
+	for _, dir := range dirs {
+		file, err := os.Open(filepath.Join(ProcPath, "driver/nvidia/gpus", dir.Name(), "information"))
+		if err != nil {
+			continue
+		}
+		minor, found := parseDeviceMinor(file)
+		file.Close()
+		if found {
+			minors[normalizeBusID(dir.Name())] = minor
+		}
+	}
+	return minors, nil
+}
+
+// parseDeviceMinor parses the "Device Minor:" line of the information file of a GPU.
+func parseDeviceMinor(r io.Reader) (int, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "Device Minor:"); found {
+			minor, err := strconv.Atoi(strings.TrimSpace(value))
+			return minor, err == nil
+		}
+	}
+	return 0, false
+}
+
+// normalizeBusID formats the PCI bus ID like procfs, with a domain of 4 digits in lower case, e.g. 0000:3b:00.0 for
+// 00000000:3B:00.0.
+func normalizeBusID(busID string) string {
+	busID = strings.ToLower(busID)
+	domain, rest, found := strings.Cut(busID, ":")
+	if !found {
+		return busID
+	}
+	if value, err := strconv.ParseUint(domain, 16, 32); err == nil {
+		domain = fmt.Sprintf("%04x", value)
+	}
+	return domain + ":" + rest
+}
+
+// CountOpenHandles counts the open file descriptors of the GPU device nodes by minor number, across the processes,
+// which are visible in procfs. The processes, whose file descriptors can't be read, e.g. for lack of permissions,
+// are skipped.
+func CountOpenHandles() (map[int]int, error) {
+	procs, err := os.ReadDir(ProcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	handles := map[int]int{}
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(ProcPath, proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if match := deviceNodePattern.FindStringSubmatch(target); match != nil {
+				minor, _ := strconv.Atoi(match[1])
+				handles[minor]++
+			}
+		}
+	}
+	return handles, nil
+}
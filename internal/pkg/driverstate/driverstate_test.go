@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package driverstate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Version
+		wantErr bool
+	}{
+		{
+			name: "Proprietary kernel module",
+			content: "NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.15  Tue Mar  5 22:23:56 UTC 2024\n" +
+				"GCC version:  gcc version 12.3.0 (Ubuntu 12.3.0-1ubuntu1~22.04)\n",
+			want: Version{KernelModule: "550.54.15", ModuleType: ModuleTypeProprietary, GCC: "12.3.0"},
+		},
+		{
+			name: "Open kernel module",
+			content: "NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  535.129.03  Release Build  " +
+				"(dvs-builder@U16-I3-B03-4-3)  Thu Oct 19 18:42:10 UTC 2023\n" +
+				"GCC version:  gcc version 11.4.0 (Ubuntu 11.4.0-1ubuntu1~22.04)\n",
+			want: Version{KernelModule: "535.129.03", ModuleType: ModuleTypeOpen, GCC: "11.4.0"},
+		},
+		{
+			name:    "No kernel module version",
+			content: "GCC version:  gcc version 12.3.0\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVersion(strings.NewReader(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestModuleLoaded(t *testing.T) {
+	modules := t.TempDir()
+	defer func(path string) { ModulesPath = path }(ModulesPath)
+	ModulesPath = modules
+
+	writeFile(t, filepath.Join(modules, "nvidia/initstate"), "live\n")
+	writeFile(t, filepath.Join(modules, "nvidia_uvm/initstate"), "going\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(modules, "nvidia_drm"), 0o755))
+
+	assert.True(t, ModuleLoaded("nvidia"))
+	assert.False(t, ModuleLoaded("nvidia_uvm"))
+	assert.True(t, ModuleLoaded("nvidia_drm"), "built-in modules have no init state")
+	assert.False(t, ModuleLoaded("nvidia_modeset"))
+}
+
+func TestReadDeviceMinors(t *testing.T) {
+	proc := t.TempDir()
+	defer func(path string) { ProcPath = path }(ProcPath)
+	ProcPath = proc
+
+	writeFile(t, filepath.Join(proc, "driver/nvidia/gpus/0000:3b:00.0/information"),
+		"Model: \t\t NVIDIA H100 80GB HBM3\nIRQ:   \t\t 123\nDevice Minor: \t 2\n")
+	writeFile(t, filepath.Join(proc, "driver/nvidia/gpus/0000:86:00.0/information"), "Model: \t\t NVIDIA H100\n")
+
+	minors, err := ReadDeviceMinors()
+	require.NoError(t, err)
+
+	minor, found := minors.Minor("00000000:3B:00.0")
+	assert.True(t, found)
+	assert.Equal(t, 2, minor)
+
+	_, found = minors.Minor("00000000:86:00.0")
+	assert.False(t, found)
+}
+
+func TestCountOpenHandles(t *testing.T) {
+	proc := t.TempDir()
+	defer func(path string) { ProcPath = path }(ProcPath)
+	ProcPath = proc
+
+	links := map[string]string{
+		"100/fd/3": "/dev/nvidia0",
+		"100/fd/4": "/dev/nvidiactl",
+		"100/fd/5": "/dev/nvidia1",
+		"200/fd/7": "/dev/nvidia0",
+		"200/fd/8": "/dev/null",
+	}
+	for link, target := range links {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(proc, link)), 0o755))
+		require.NoError(t, os.Symlink(target, filepath.Join(proc, link)))
+	}
+	writeFile(t, filepath.Join(proc, "self/fd/0"), "")
+	writeFile(t, filepath.Join(proc, "driver/nvidia/version"), "")
+
+	handles, err := CountOpenHandles()
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{0: 2, 1: 1}, handles)
+}
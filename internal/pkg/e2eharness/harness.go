@@ -0,0 +1,311 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package e2eharness drives a running nv-hostengine with injected fake GPUs, runs the exporter against it
+// and asserts on the scraped output. It allows testing discovery and watch logic on machines without GPUs.
+package e2eharness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/avast/retry-go/v4"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/pkg/cmd"
+)
+
+const (
+	injectInterval = time.Second
+	// hostengineStartTimeout limits how long the harness waits for the started nv-hostengine to accept connections
+	hostengineStartTimeout = 30 * time.Second
+)
+
+// Run creates fake GPUs, injects field values, starts the exporter and waits until the scraped output
+// contains the injected values. The exporter is stopped before Run returns.
+func Run(ctx context.Context, opts Options, injections []Injection) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if opts.Hostengine != "" {
+		stopHostengine, err := startHostengine(ctx, opts.Hostengine, opts.RemoteHEInfo)
+		if err != nil {
+			return err
+		}
+		// The fake GPUs are removed together with the hostengine
+		defer stopHostengine()
+	} else {
+		slog.Warn("The fake GPUs stay in the hostengine until it is restarted, because DCGM can't remove them")
+	}
+
+	// The exporter reuses the initialized DCGM client, which it shuts down when it stops. The client is shut
+	// down here only, when the exporter stopped before it took it over.
+	dcgmprovider.Initialize(&appconfig.Config{
+		UseRemoteHE:  true,
+		RemoteHEInfo: opts.RemoteHEInfo,
+	})
+	defer func() {
+		if client := dcgmprovider.Client(); client != nil {
+			client.Cleanup()
+		}
+	}()
+
+	gpuIDs, err := createFakeGPUs(opts.NumGPUs)
+	if err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Created fake GPUs: %v", gpuIDs))
+
+	if err = injectValues(gpuIDs, injections); err != nil {
+		return err
+	}
+
+	injectCtx, stopInjecting := context.WithCancel(ctx)
+	defer stopInjecting()
+	go keepInjecting(injectCtx, gpuIDs, injections)
+
+	countersFile, err := writeCountersFile(injections)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(countersFile)
+
+	address, err := freeAddress()
+	if err != nil {
+		return err
+	}
+
+	exporterCtx, stopExporter := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	var exporterErr error
+	go func() {
+		defer close(exited)
+		exporterErr = cmd.NewApp("e2e").RunContext(exporterCtx, []string{
+			"dcgm-exporter",
+			"--collectors=" + countersFile,
+			"--address=" + address,
+			"--remote-hostengine-info=" + opts.RemoteHEInfo,
+			"--fake-gpus",
+			fmt.Sprintf("--collect-interval=%d", opts.CollectInterval.Milliseconds()),
+		})
+	}()
+	// The values are not injected anymore, while the exporter is stopping
+	defer func() {
+		stopInjecting()
+		stopExporter()
+		<-exited
+	}()
+
+	url := fmt.Sprintf("http://%s/metrics", address)
+	slog.Info("Waiting for the expected metrics at " + url)
+
+	return retry.Do(
+		func() error {
+			select {
+			case <-exited:
+				return retry.Unrecoverable(fmt.Errorf("exporter stopped; err: %v", exporterErr))
+			default:
+			}
+
+			metrics, err := scrape(ctx, url)
+			if err != nil {
+				return err
+			}
+
+			return Verify(metrics, gpuIDs, injections)
+		},
+		retry.Context(ctx),
+		retry.UntilSucceeded(),
+		retry.Delay(opts.CollectInterval),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.WrapContextErrorWithLastError(true),
+		retry.OnRetry(func(n uint, err error) {
+			slog.Debug(fmt.Sprintf("Metrics are not ready yet; attempt: %d", n+1),
+				slog.String(logging.ErrorKey, err.Error()))
+		}),
+	)
+}
+
+// startHostengine starts nv-hostengine in the foreground, listening on the address, and waits until it accepts
+// connections. The returned function stops it.
+func startHostengine(ctx context.Context, path, address string) (func(), error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostengine address '%s'; err: %w", address, err)
+	}
+
+	args := []string{"-n", "-p", port}
+	// The hostengine binds to an IP address only, and to the loopback interface by default
+	if net.ParseIP(host) != nil {
+		args = append(args, "-b", host)
+	}
+
+	hostengine := exec.Command(path, args...)
+	hostengine.Stdout = os.Stdout
+	hostengine.Stderr = os.Stderr
+	if err = hostengine.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start '%s'; err: %w", path, err)
+	}
+	slog.Info(fmt.Sprintf("Started %s at %s", path, address))
+
+	stop := func() {
+		_ = hostengine.Process.Signal(os.Interrupt)
+		if err := hostengine.Wait(); err != nil {
+			slog.Warn("The hostengine stopped with an error", slog.String(logging.ErrorKey, err.Error()))
+		}
+	}
+
+	startCtx, cancel := context.WithTimeout(ctx, hostengineStartTimeout)
+	defer cancel()
+
+	err = retry.Do(
+		func() error {
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+		retry.Context(startCtx),
+		retry.UntilSucceeded(),
+		retry.Delay(100*time.Millisecond),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.WrapContextErrorWithLastError(true),
+	)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("hostengine is not accepting connections at '%s'; err: %w", address, err)
+	}
+
+	return stop, nil
+}
+
+func createFakeGPUs(numGPUs int) ([]uint, error) {
+	entities := make([]dcgm.MigHierarchyInfo, numGPUs)
+	for i := range entities {
+		entities[i] = dcgm.MigHierarchyInfo{Entity: dcgm.GroupEntityPair{EntityGroupId: dcgm.FE_GPU}}
+	}
+
+	gpuIDs, err := dcgmprovider.Client().CreateFakeEntities(entities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fake GPUs; err: %w", err)
+	}
+
+	return gpuIDs, nil
+}
+
+func injectValues(gpuIDs []uint, injections []Injection) error {
+	for _, gpuID := range gpuIDs {
+		for _, injection := range injections {
+			var value interface{} = injection.Value
+			if injection.FieldType == dcgm.DCGM_FT_INT64 {
+				value = int64(injection.Value)
+			}
+
+			err := dcgmprovider.Client().InjectFieldValue(gpuID, uint(injection.FieldID), injection.FieldType, 0,
+				time.Now().UnixMicro(), value)
+			if err != nil {
+				return fmt.Errorf("failed to inject '%s' into GPU %d; err: %w", injection.FieldName, gpuID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// keepInjecting refreshes the injected values, so they stay the latest values while the exporter is watching them.
+func keepInjecting(ctx context.Context, gpuIDs []uint, injections []Injection) {
+	ticker := time.NewTicker(injectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := injectValues(gpuIDs, injections); err != nil {
+				slog.Warn("Failed to inject field values", slog.String(logging.ErrorKey, err.Error()))
+			}
+		}
+	}
+}
+
+func writeCountersFile(injections []Injection) (string, error) {
+	file, err := os.CreateTemp("", "e2e-counters-*.csv")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	for _, injection := range injections {
+		fmt.Fprintf(&sb, "%s, gauge, injected by e2e harness\n", injection.FieldName)
+	}
+
+	if _, err = file.WriteString(sb.String()); err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	return l.Addr().String(), nil
+}
+
+func scrape(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(resp.Status)
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2eharness
+
+import (
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+)
+
+// Options configures the end-to-end run.
+type Options struct {
+	// RemoteHEInfo is the address of the running nv-hostengine, for example localhost:5555
+	RemoteHEInfo string
+	// Hostengine is the path of the nv-hostengine to start at RemoteHEInfo for the run. It is stopped at the end,
+	// which removes the fake GPUs. When empty, the running hostengine is used.
+	Hostengine string
+	// NumGPUs is the number of fake GPUs to create
+	NumGPUs int
+	// CollectInterval is passed to the exporter
+	CollectInterval time.Duration
+	// Timeout limits how long the harness waits for the expected metrics
+	Timeout time.Duration
+}
+
+// Injection is a field value injected into every fake GPU and expected in the scraped output.
+type Injection struct {
+	FieldID   dcgm.Short
+	FieldName string
+	FieldType uint
+	Value     float64
+}
+
+// DefaultInjections is the set of fields injected by default.
+var DefaultInjections = []Injection{
+	{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_TEMP,
+		FieldName: "DCGM_FI_DEV_GPU_TEMP",
+		FieldType: dcgm.DCGM_FT_INT64,
+		Value:     42,
+	},
+	{
+		FieldID:   dcgm.DCGM_FI_DEV_SM_CLOCK,
+		FieldName: "DCGM_FI_DEV_SM_CLOCK",
+		FieldType: dcgm.DCGM_FT_INT64,
+		Value:     1410,
+	},
+	{
+		FieldID:   dcgm.DCGM_FI_DEV_POWER_USAGE,
+		FieldName: "DCGM_FI_DEV_POWER_USAGE",
+		FieldType: dcgm.DCGM_FT_DOUBLE,
+		Value:     123.5,
+	},
+}
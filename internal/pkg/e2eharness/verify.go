@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2eharness
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Verify checks that the scraped metrics contain every injected value for every fake GPU.
+func Verify(metrics string, gpuIDs []uint, injections []Injection) error {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(metrics))
+	if err != nil {
+		return fmt.Errorf("failed to parse metrics; err: %w", err)
+	}
+
+	var errs []error
+
+	for _, injection := range injections {
+		family, exists := families[injection.FieldName]
+		if !exists {
+			errs = append(errs, fmt.Errorf("metric '%s' is missing", injection.FieldName))
+			continue
+		}
+
+		for _, gpuID := range gpuIDs {
+			value, found := gpuValue(family, fmt.Sprint(gpuID))
+			if !found {
+				errs = append(errs, fmt.Errorf("metric '%s' is missing for GPU %d", injection.FieldName, gpuID))
+				continue
+			}
+			if value != injection.Value {
+				errs = append(errs, fmt.Errorf("metric '%s' for GPU %d: expected %v, got %v",
+					injection.FieldName, gpuID, injection.Value, value))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func gpuValue(family *dto.MetricFamily, gpu string) (float64, bool) {
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() != "gpu" || label.GetValue() != gpu {
+				continue
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				return metric.GetGauge().GetValue(), true
+			case metric.GetCounter() != nil:
+				return metric.GetCounter().GetValue(), true
+			case metric.GetUntyped() != nil:
+				return metric.GetUntyped().GetValue(), true
+			}
+		}
+	}
+
+	return 0, false
+}
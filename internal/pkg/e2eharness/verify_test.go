@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2eharness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	injections := []Injection{
+		{FieldName: "DCGM_FI_DEV_GPU_TEMP", Value: 42},
+		{FieldName: "DCGM_FI_DEV_POWER_USAGE", Value: 123.5},
+	}
+
+	tests := []struct {
+		name    string
+		metrics string
+		wantErr string
+	}{
+		{
+			name: "All values present",
+			metrics: `# HELP DCGM_FI_DEV_GPU_TEMP injected
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="1",UUID="fake1"} 42
+DCGM_FI_DEV_GPU_TEMP{gpu="2",UUID="fake2"} 42
+# HELP DCGM_FI_DEV_POWER_USAGE injected
+# TYPE DCGM_FI_DEV_POWER_USAGE gauge
+DCGM_FI_DEV_POWER_USAGE{gpu="1",UUID="fake1"} 123.5
+DCGM_FI_DEV_POWER_USAGE{gpu="2",UUID="fake2"} 123.5
+`,
+		},
+		{
+			name: "Missing metric, missing GPU and wrong value",
+			metrics: `# HELP DCGM_FI_DEV_GPU_TEMP injected
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="1",UUID="fake1"} 41
+`,
+			wantErr: "metric 'DCGM_FI_DEV_GPU_TEMP' for GPU 1: expected 42, got 41\n" +
+				"metric 'DCGM_FI_DEV_GPU_TEMP' is missing for GPU 2\n" +
+				"metric 'DCGM_FI_DEV_POWER_USAGE' is missing",
+		},
+		{
+			name:    "Malformed output",
+			metrics: "DCGM_FI_DEV_GPU_TEMP{gpu=1} 42\n",
+			wantErr: "failed to parse metrics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.metrics, []uint{1, 2}, injections)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
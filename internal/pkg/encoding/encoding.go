@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package encoding writes the metrics in formats other than the Prometheus text format, so that consumers, such as
+// Telegraf or custom agents, can ingest them without a conversion layer.
+package encoding
+
+import (
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Encoder writes the metric families in an output format.
+type Encoder interface {
+	// ContentType returns the media type of the output, as sent in the Content-Type header.
+	ContentType() string
+	// Encode writes the families in the order given.
+	Encode(w io.Writer, families []*dto.MetricFamily) error
+}
+
+// encoders are the encoders by format name, as given in the format query parameter.
+var encoders = map[string]Encoder{
+	"json":   jsonEncoder{},
+	"influx": influxEncoder{},
+}
+
+// Names returns the names of the formats of the encoders, sorted.
+func Names() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ByName returns the encoder of the format name, and false, when there is none.
+func ByName(name string) (Encoder, bool) {
+	encoder, exists := encoders[strings.ToLower(name)]
+	return encoder, exists
+}
+
+// Negotiate returns the encoder, whose media type the Accept header prefers, and false, when the header prefers
+// another media type, e.g. the Prometheus text format, or is not set. Media types with equal quality are preferred in
+// the order of the header.
+func Negotiate(h http.Header) (Encoder, bool) {
+	var preferred Encoder
+	preferredWeight := 0.0
+
+	for _, value := range h.Values("Accept") {
+		for _, accepted := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+			if err != nil {
+				continue
+			}
+
+			weight := 1.0
+			if q, exists := params["q"]; exists {
+				weight, err = strconv.ParseFloat(q, 64)
+				if err != nil {
+					continue
+				}
+			}
+			if weight <= preferredWeight {
+				continue
+			}
+
+			preferred, preferredWeight = nil, weight
+			for _, encoder := range encoders {
+				if contentType, _, _ := mime.ParseMediaType(encoder.ContentType()); contentType == mediaType {
+					preferred = encoder
+				}
+			}
+		}
+	}
+
+	return preferred, preferred != nil
+}
+
+// value returns the value of a counter, a gauge or an untyped series, and false for other types or values, which
+// are not finite, as neither JSON nor the line protocol can represent them.
+func value(metricType dto.MetricType, m *dto.Metric) (float64, bool) {
+	var v float64
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		v = m.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		v = m.GetGauge().GetValue()
+	case dto.MetricType_UNTYPED:
+		v = m.GetUntyped().GetValue()
+	default:
+		return 0, false
+	}
+	return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoding
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func testFamilies() []*dto.MetricFamily {
+	return []*dto.MetricFamily{
+		{
+			Name: proto.String("DCGM_FI_DEV_GPU_TEMP"),
+			Help: proto.String("GPU temperature (in C)."),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{
+						{Name: proto.String("gpu"), Value: proto.String("0")},
+						{Name: proto.String("Hostname"), Value: proto.String("node 1")},
+						{Name: proto.String("pod"), Value: proto.String("")},
+						{Name: proto.String("modelName"), Value: proto.String("NVIDIA H100,SXM=80GB")},
+					},
+					Gauge: &dto.Gauge{Value: proto.Float64(34.5)},
+				},
+				{
+					Label: []*dto.LabelPair{{Name: proto.String("gpu"), Value: proto.String("1")}},
+					Gauge: &dto.Gauge{Value: proto.Float64(math.NaN())},
+				},
+			},
+		},
+		{
+			Name: proto.String("DCGM_FI_DEV_XID_ERRORS"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label:   []*dto.LabelPair{{Name: proto.String("gpu"), Value: proto.String("0")}},
+					Counter: &dto.Counter{Value: proto.Float64(2)},
+				},
+			},
+		},
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jsonEncoder{}.Encode(&buf, testFamilies()))
+
+	assert.JSONEq(t, `[
+		{"name": "DCGM_FI_DEV_GPU_TEMP", "help": "GPU temperature (in C).", "type": "gauge", "metrics": [
+			{"labels": {"gpu": "0", "Hostname": "node 1", "pod": "", "modelName": "NVIDIA H100,SXM=80GB"}, "value": 34.5}
+		]},
+		{"name": "DCGM_FI_DEV_XID_ERRORS", "type": "counter", "metrics": [{"labels": {"gpu": "0"}, "value": 2}]}
+	]`, buf.String())
+}
+
+func TestInfluxEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, influxEncoder{}.Encode(&buf, testFamilies()))
+
+	assert.Equal(t, `DCGM_FI_DEV_GPU_TEMP,Hostname=node\ 1,gpu=0,modelName=NVIDIA\ H100\,SXM\=80GB value=34.5
+DCGM_FI_DEV_XID_ERRORS,gpu=0 value=2
+`, buf.String())
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   Encoder
+	}{
+		{
+			name: "No Accept header",
+		},
+		{
+			name:   "Prometheus text format",
+			accept: "text/plain;version=0.0.4",
+		},
+		{
+			name:   "Any media type",
+			accept: "*/*",
+		},
+		{
+			name:   "JSON",
+			accept: "application/json",
+			want:   jsonEncoder{},
+		},
+		{
+			name:   "JSON preferred by quality",
+			accept: "text/plain;q=0.5, application/json;q=0.9",
+			want:   jsonEncoder{},
+		},
+		{
+			name:   "Prometheus text format preferred by quality",
+			accept: "application/json;q=0.5, text/plain",
+		},
+		{
+			name:   "Influx line protocol",
+			accept: "application/vnd.influx.line-protocol",
+			want:   influxEncoder{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.accept != "" {
+				h.Set("Accept", tt.accept)
+			}
+
+			got, ok := Negotiate(h)
+			assert.Equal(t, tt.want != nil, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestByName(t *testing.T) {
+	encoder, ok := ByName("JSON")
+	assert.True(t, ok)
+	assert.Equal(t, jsonEncoder{}, encoder)
+
+	_, ok = ByName("xml")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"influx", "json"}, Names())
+}
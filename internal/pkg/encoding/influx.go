@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoding
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// influxValueField is the field, which holds the value of a series in the line protocol.
+const influxValueField = "value"
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	tagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+)
+
+// influxEncoder writes a line of the InfluxDB line protocol per series. The measurement is the name of the family,
+// the labels are the tags, and the value is the value field. The lines have no timestamp, so the consumer stamps them
+// with the time it reads them.
+type influxEncoder struct{}
+
+func (influxEncoder) ContentType() string {
+	return "application/vnd.influx.line-protocol; charset=utf-8"
+}
+
+func (influxEncoder) Encode(w io.Writer, families []*dto.MetricFamily) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		measurement := measurementEscaper.Replace(family.GetName())
+		for _, m := range family.GetMetric() {
+			v, ok := value(family.GetType(), m)
+			if !ok {
+				continue
+			}
+
+			bw.WriteString(measurement)
+			for _, pair := range sortedLabels(m.GetLabel()) {
+				// The line protocol does not allow empty tag values
+				if pair.GetValue() == "" {
+					continue
+				}
+				bw.WriteByte(',')
+				bw.WriteString(tagEscaper.Replace(pair.GetName()))
+				bw.WriteByte('=')
+				bw.WriteString(tagEscaper.Replace(pair.GetValue()))
+			}
+			bw.WriteString(" " + influxValueField + "=")
+			bw.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// sortedLabels returns the labels sorted by name, as InfluxDB recommends for the tags.
+func sortedLabels(pairs []*dto.LabelPair) []*dto.LabelPair {
+	sorted := slices.Clone(pairs)
+	slices.SortFunc(sorted, func(a, b *dto.LabelPair) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return sorted
+}
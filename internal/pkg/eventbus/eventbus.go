@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eventbus is a small in-process publish/subscribe bus, which decouples the collection pipeline from the
+// features consuming its results. Collections, topology changes and errors are published on separate topics, so a
+// feature subscribes to the topic it needs instead of patching the pipeline.
+package eventbus
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	droppedEvents = selfmetrics.Default().Counter("dcgm_exporter_dropped_events_total",
+		"Number of internal events, which were dropped because a subscriber was not keeping up.")
+	collectionOverruns = selfmetrics.Default().Counter("dcgm_exporter_collection_overruns_total",
+		"Number of collection cycles, in which collectors did not finish within the collect interval.")
+)
+
+// CollectionEvent carries the metrics gathered from the collectors. The metrics are shared by all the
+// subscribers, which must not modify them.
+type CollectionEvent struct {
+	Time    time.Time
+	Metrics registry.MetricsByCounterGroup
+	// Partial is set, when collectors did not finish within the collect interval. Their metrics of a previous
+	// collection are included, and counted by the dcgm_exporter_stale_collector_results_total self-metric, unless
+	// they are older than registry.MaxStalenessIntervals collect intervals.
+	Partial bool
+}
+
+// TopologyChangeEvent reports that the entities of the group changed, e.g. they were discovered after startup.
+type TopologyChangeEvent struct {
+	EntityGroup dcgm.Field_Entity_Group
+}
+
+// ErrorEvent reports an error of the pipeline, which is not returned to any caller.
+type ErrorEvent struct {
+	Source string
+	Err    error
+}
+
+// Topic delivers the events of one type to its subscribers.
+type Topic[T any] struct {
+	name string
+
+	mtx         sync.Mutex
+	subscribers map[chan T]struct{}
+}
+
+func newTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name, subscribers: map[chan T]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the events published after the call. The channel buffers up to buffer
+// events; events, which do not fit, are dropped for this subscriber, so a slow subscriber never blocks the
+// publisher. The channel is closed once the context is done.
+func (t *Topic[T]) Subscribe(ctx context.Context, buffer int) <-chan T {
+	ch := make(chan T, buffer)
+
+	t.mtx.Lock()
+	t.subscribers[ch] = struct{}{}
+	t.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		delete(t.subscribers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// HasSubscribers reports whether anyone is subscribed, so publishers can skip producing unused events.
+func (t *Topic[T]) HasSubscribers() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return len(t.subscribers) > 0
+}
+
+// Publish delivers the event to the subscribers without blocking.
+func (t *Topic[T]) Publish(event T) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for ch := range t.subscribers {
+		select {
+		case ch <- event:
+		default:
+			droppedEvents.Inc("topic", t.name)
+		}
+	}
+}
+
+// Bus holds the topics of the pipeline.
+type Bus struct {
+	Collections     *Topic[CollectionEvent]
+	TopologyChanges *Topic[TopologyChangeEvent]
+	Errors          *Topic[ErrorEvent]
+}
+
+// New creates a bus without subscribers.
+func New() *Bus {
+	return &Bus{
+		Collections:     newTopic[CollectionEvent]("collection"),
+		TopologyChanges: newTopic[TopologyChangeEvent]("topology_change"),
+		Errors:          newTopic[ErrorEvent]("error"),
+	}
+}
+
+// Gatherer gathers the metrics within the deadline, see registry.Registry.GatherWithin, and returns the number of
+// collectors, which did not finish.
+type Gatherer func(
+	deadline, maxStaleness time.Duration, groups ...dcgm.Field_Entity_Group,
+) (registry.MetricsByCounterGroup, int, error)
+
+// PublishCollections gathers the metrics every interval and publishes them, until the context is done. Gathering
+// is skipped while there are no subscribers; failures are published as error events. A collection, which doesn't
+// finish within the interval, is published partially and counted as an overrun, so the collections don't drift.
+func (b *Bus) PublishCollections(ctx context.Context, interval time.Duration, gather Gatherer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !b.Collections.HasSubscribers() {
+			continue
+		}
+
+		metrics, overruns, err := gather(interval, registry.MaxStalenessIntervals*interval)
+		if err != nil {
+			slog.Warn("Failed to gather metrics", slog.String(logging.ErrorKey, err.Error()))
+			b.Errors.Publish(ErrorEvent{Source: "collection", Err: err})
+			continue
+		}
+		if overruns > 0 {
+			collectionOverruns.Inc()
+			slog.Debug("Collectors did not finish within the collect interval; publishing a partial collection",
+				slog.Int("collectors", overruns), slog.Duration("interval", interval))
+		}
+
+		b.Collections.Publish(CollectionEvent{Time: time.Now(), Metrics: metrics, Partial: overruns > 0})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestTopic(t *testing.T) {
+	topic := newTopic[int]("test")
+	assert.False(t, topic.HasSubscribers())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := topic.Subscribe(ctx, 1)
+	second := topic.Subscribe(context.Background(), 2)
+	assert.True(t, topic.HasSubscribers())
+
+	topic.Publish(1)
+	topic.Publish(2)
+
+	// The first subscriber buffers one event, so the second one is dropped for it only
+	assert.Equal(t, 1, <-first)
+	assert.Equal(t, 1, <-second)
+	assert.Equal(t, 2, <-second)
+	dropped, _ := selfmetrics.Default().Value("dcgm_exporter_dropped_events_total", "topic", "test")
+	assert.Equal(t, float64(1), dropped)
+
+	cancel()
+	_, open := <-first
+	assert.False(t, open)
+
+	topic.Publish(3)
+	assert.Equal(t, 3, <-second)
+}
+
+func TestPublishCollections(t *testing.T) {
+	bus := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	collections := bus.Collections.Subscribe(ctx, 1)
+	errs := bus.Errors.Subscribe(ctx, 1)
+
+	overruns, _ := selfmetrics.Default().Value("dcgm_exporter_collection_overruns_total")
+	gathered := 0
+	go bus.PublishCollections(ctx, time.Millisecond,
+		func(time.Duration, time.Duration, ...dcgm.Field_Entity_Group) (registry.MetricsByCounterGroup, int, error) {
+			gathered++
+			switch gathered {
+			case 1:
+				return nil, 0, errors.New("boom")
+			case 2:
+				return registry.MetricsByCounterGroup{}, 0, nil
+			default:
+				return registry.MetricsByCounterGroup{}, 1, nil
+			}
+		})
+
+	select {
+	case event := <-errs:
+		assert.Equal(t, "collection", event.Source)
+		assert.EqualError(t, event.Err, "boom")
+	case <-time.After(time.Second):
+		require.Fail(t, "no error event was published")
+	}
+
+	select {
+	case event := <-collections:
+		assert.NotNil(t, event.Metrics)
+		assert.False(t, event.Time.IsZero())
+		assert.False(t, event.Partial)
+	case <-time.After(time.Second):
+		require.Fail(t, "no collection event was published")
+	}
+
+	select {
+	case event := <-collections:
+		assert.True(t, event.Partial, "collectors, which did not finish, make the collection partial")
+	case <-time.After(time.Second):
+		require.Fail(t, "no partial collection event was published")
+	}
+	cancel()
+
+	after, _ := selfmetrics.Default().Value("dcgm_exporter_collection_overruns_total")
+	assert.Greater(t, after, overruns)
+}
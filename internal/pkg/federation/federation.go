@@ -0,0 +1,267 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package federation runs a dcgm-exporter worker per remote hostengine and serves their metrics merged. DCGM
+// supports a single hostengine connection per process, so every hostengine is collected by its own process.
+package federation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var targetUp = selfmetrics.Default().Gauge("dcgm_exporter_federation_target_up",
+	"Whether the metrics of the remote hostengine were scraped from its worker (1) or not (0).")
+
+// CommandFunc returns the command, which runs the worker of the hostengine at target, listening at address.
+type CommandFunc func(ctx context.Context, target, address string) *exec.Cmd
+
+// Options configure the Federation.
+type Options struct {
+	// Targets are the <HOST>:<PORT> addresses of the remote hostengines
+	Targets []string
+	Command CommandFunc
+	// Label is added to the metrics of a worker, which don't have it, with the address of its hostengine
+	Label string
+	// Timeout of scraping a worker
+	Timeout time.Duration
+	// RestartDelay is how long to wait, before a stopped worker is started again
+	RestartDelay time.Duration
+}
+
+// Federation supervises the workers and merges their metrics.
+type Federation struct {
+	workers      []worker
+	command      CommandFunc
+	label        string
+	client       *http.Client
+	restartDelay time.Duration
+}
+
+type worker struct {
+	target  string
+	address string
+}
+
+func New(opts Options) (*Federation, error) {
+	if len(opts.Targets) == 0 {
+		return nil, fmt.Errorf("no remote hostengines to federate")
+	}
+
+	workers := make([]worker, 0, len(opts.Targets))
+	for _, target := range opts.Targets {
+		address, err := freeAddress()
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate the address of the worker of '%s'; err: %w", target, err)
+		}
+		workers = append(workers, worker{target: target, address: address})
+	}
+
+	return &Federation{
+		workers:      workers,
+		command:      opts.Command,
+		label:        opts.Label,
+		client:       &http.Client{Timeout: opts.Timeout},
+		restartDelay: opts.RestartDelay,
+	}, nil
+}
+
+// Run starts the workers and restarts them, when they stop, until the context is done.
+func (f *Federation) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, w := range f.workers {
+		wg.Add(1)
+		go func(w worker) {
+			defer wg.Done()
+			f.supervise(ctx, w)
+		}(w)
+	}
+	wg.Wait()
+}
+
+func (f *Federation) supervise(ctx context.Context, w worker) {
+	for {
+		slog.Info(fmt.Sprintf("Starting the worker of the hostengine '%s' at %s", w.target, w.address))
+
+		cmd := f.command(ctx, w.target, w.address)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(syscall.SIGTERM)
+		}
+		cmd.WaitDelay = 5 * time.Second
+
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn(fmt.Sprintf("The worker of the hostengine '%s' stopped; restarting in %s", w.target, f.restartDelay),
+			slog.String(logging.ErrorKey, fmt.Sprint(err)))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.restartDelay):
+		}
+	}
+}
+
+// ServeHTTP serves the merged metrics of the workers.
+func (f *Federation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := f.WriteMetrics(r.Context(), &buf); err != nil {
+		slog.Error("Failed to merge the metrics of the workers.", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, "failed to merge the metrics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// WriteMetrics scrapes the workers concurrently and writes their metrics, merged by family, in the Prometheus text
+// format, followed by the self-metrics of the federation. Workers, which can't be scraped, are skipped.
+func (f *Federation) WriteMetrics(ctx context.Context, w io.Writer) error {
+	results := make([]map[string]*dto.MetricFamily, len(f.workers))
+
+	var wg sync.WaitGroup
+	for i, wk := range f.workers {
+		wg.Add(1)
+		go func(i int, wk worker) {
+			defer wg.Done()
+
+			families, err := f.scrape(ctx, wk.address)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("Failed to scrape the worker of the hostengine '%s'", wk.target),
+					slog.String(logging.ErrorKey, err.Error()))
+				targetUp.Set(0, "target", wk.target)
+				return
+			}
+			targetUp.Set(1, "target", wk.target)
+			results[i] = families
+		}(i, wk)
+	}
+	wg.Wait()
+
+	merged := map[string]*dto.MetricFamily{}
+	for i, families := range results {
+		mergeFamilies(merged, families, f.label, f.workers[i].target)
+	}
+
+	var self bytes.Buffer
+	if err := selfmetrics.Default().Render(&self); err != nil {
+		return err
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(&self)
+	if err != nil {
+		return fmt.Errorf("failed to parse the self-metrics; err: %w", err)
+	}
+	mergeFamilies(merged, families, "", "")
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, name := range names {
+		if err := encoder.Encode(merged[name]); err != nil {
+			return fmt.Errorf("failed to encode the metric family '%s'; err: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *Federation) scrape(ctx context.Context, address string) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/metrics", address), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+// mergeFamilies adds the metrics of the families to the merged ones. Unless the label is empty, it is added with the
+// value to the metrics, which don't have it. Families, which type differs from the merged one, are skipped.
+func mergeFamilies(merged, families map[string]*dto.MetricFamily, label, value string) {
+	for name, family := range families {
+		if label != "" {
+			for _, m := range family.GetMetric() {
+				if !slices.ContainsFunc(m.GetLabel(), func(l *dto.LabelPair) bool { return l.GetName() == label }) {
+					m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(label), Value: proto.String(value)})
+				}
+			}
+		}
+
+		existing, exists := merged[name]
+		if !exists {
+			merged[name] = family
+			continue
+		}
+
+		if existing.GetType() != family.GetType() {
+			slog.Warn(fmt.Sprintf("Metric family '%s' of '%s' has the type %s instead of %s; skipping", name, value,
+				family.GetType(), existing.GetType()))
+			continue
+		}
+		existing.Metric = append(existing.Metric, family.GetMetric()...)
+	}
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	return l.Addr().String(), nil
+}
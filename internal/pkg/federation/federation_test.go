@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package federation
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWorkerServer(t *testing.T, status int, body string) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestWriteMetrics(t *testing.T) {
+	node1 := newWorkerServer(t, http.StatusOK, `# HELP DCGM_FI_DEV_GPU_TEMP GPU temperature (in C).
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="0",Hostname="node1"} 40
+# HELP dcgm_exporter_idle_mode Whether only the idle counters are exported.
+# TYPE dcgm_exporter_idle_mode gauge
+dcgm_exporter_idle_mode 0
+`)
+	node2 := newWorkerServer(t, http.StatusOK, `# HELP DCGM_FI_DEV_GPU_TEMP GPU temperature (in C).
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="0",Hostname="node2",hostengine="custom"} 50
+`)
+	node3 := newWorkerServer(t, http.StatusInternalServerError, "")
+
+	f := &Federation{
+		workers: []worker{
+			{target: "node1:5555", address: node1},
+			{target: "node2:5555", address: node2},
+			{target: "node3:5555", address: node3},
+		},
+		label:  "hostengine",
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, f.WriteMetrics(context.Background(), &buf))
+	out := buf.String()
+
+	assert.Equal(t, 1, strings.Count(out, "# TYPE DCGM_FI_DEV_GPU_TEMP gauge"))
+	assert.Contains(t, out, `DCGM_FI_DEV_GPU_TEMP{gpu="0",Hostname="node1",hostengine="node1:5555"} 40`)
+	// The label of the worker is kept
+	assert.Contains(t, out, `DCGM_FI_DEV_GPU_TEMP{gpu="0",Hostname="node2",hostengine="custom"} 50`)
+	assert.Contains(t, out, `dcgm_exporter_idle_mode{hostengine="node1:5555"} 0`)
+
+	assert.Contains(t, out, `dcgm_exporter_federation_target_up{target="node1:5555"} 1`)
+	assert.Contains(t, out, `dcgm_exporter_federation_target_up{target="node3:5555"} 0`)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{})
+	require.Error(t, err)
+
+	f, err := New(Options{Targets: []string{"node1:5555", "node2:5555"}})
+	require.NoError(t, err)
+	require.Len(t, f.workers, 2)
+	assert.NotEqual(t, f.workers[0].address, f.workers[1].address)
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcapi
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const telemetryProto = "../../../api/telemetry/v1/telemetry.proto"
+
+// telemetryDescriptor describes api/telemetry/v1/telemetry.proto, so that the messages, which are encoded and
+// decoded by hand, can be checked against the protobuf runtime. TestTelemetryDescriptor checks it against the file.
+func telemetryDescriptor(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type,
+		label descriptorpb.FieldDescriptorProto_Label, typeName string,
+	) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName(name)),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("api/telemetry/v1/telemetry.proto"),
+		Package: proto.String("dcgmexporter.telemetry.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("FieldValuesRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("fields", int32(requestFields), str, repeated, ""),
+					field("entity_types", int32(requestEntityTypes), str, repeated, ""),
+				},
+			},
+			{
+				Name: proto.String("FieldValues"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("timestamp_unix_nano", int32(fieldValuesTimestamp),
+						descriptorpb.FieldDescriptorProto_TYPE_FIXED64, optional, ""),
+					field("values", int32(fieldValuesValues), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+						repeated, ".dcgmexporter.telemetry.v1.FieldValue"),
+				},
+			},
+			{
+				Name: proto.String("FieldValue"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("field", int32(fieldValueField), str, optional, ""),
+					field("entity_type", int32(fieldValueEntityType), str, optional, ""),
+					field("entity_id", int32(fieldValueEntityID), str, optional, ""),
+					field("uuid", int32(fieldValueUUID), str, optional, ""),
+					field("gpu_instance_id", int32(fieldValueGPUInstanceID), str, optional, ""),
+					field("compute_instance_id", int32(fieldValueComputeInstanceID), str, optional, ""),
+					field("value", int32(fieldValueValue), descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, optional, ""),
+					field("labels", int32(fieldValueLabels), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated,
+						".dcgmexporter.telemetry.v1.FieldValue.LabelsEntry"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("LabelsEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", int32(mapEntryKey), str, optional, ""),
+							field("value", int32(mapEntryValue), str, optional, ""),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Telemetry"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String(getFieldValuesMethod),
+						InputType:  proto.String(".dcgmexporter.telemetry.v1.FieldValuesRequest"),
+						OutputType: proto.String(".dcgmexporter.telemetry.v1.FieldValues"),
+					},
+					{
+						Name:            proto.String(streamFieldValuesMethod),
+						InputType:       proto.String(".dcgmexporter.telemetry.v1.FieldValuesRequest"),
+						OutputType:      proto.String(".dcgmexporter.telemetry.v1.FieldValues"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	require.NoError(t, err)
+	return fd
+}
+
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+var (
+	protoPackage = regexp.MustCompile(`^package\s+([\w.]+);`)
+	protoMessage = regexp.MustCompile(`^message\s+(\w+)\s*{`)
+	protoField   = regexp.MustCompile(`^(repeated\s+)?(map<\s*string,\s*string\s*>|\w+)\s+(\w+)\s*=\s*(\d+);`)
+	protoRPC     = regexp.MustCompile(`^rpc\s+(\w+)\((\w+)\)\s+returns\s+\((stream\s+)?(\w+)\);`)
+)
+
+// TestTelemetryDescriptor checks, that the descriptor, and so the field numbers of the encoding, match
+// api/telemetry/v1/telemetry.proto.
+func TestTelemetryDescriptor(t *testing.T) {
+	data, err := os.ReadFile(telemetryProto)
+	require.NoError(t, err)
+
+	var pkg string
+	var message string
+	var fromFile []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case protoPackage.MatchString(line):
+			pkg = protoPackage.FindStringSubmatch(line)[1]
+		case protoMessage.MatchString(line):
+			message = protoMessage.FindStringSubmatch(line)[1]
+		case protoField.MatchString(line):
+			m := protoField.FindStringSubmatch(line)
+			typ := strings.ReplaceAll(m[2], " ", "")
+			if m[1] != "" {
+				typ = "repeated " + typ
+			}
+			fromFile = append(fromFile, fmt.Sprintf("%s.%s %s = %s", message, m[3], typ, m[4]))
+		case protoRPC.MatchString(line):
+			m := protoRPC.FindStringSubmatch(line)
+			fromFile = append(fromFile, fmt.Sprintf("rpc %s(%s) returns (%s%s)", m[1], m[2], m[3], m[4]))
+		}
+	}
+
+	require.NotEmpty(t, fromFile)
+
+	fd := telemetryDescriptor(t)
+	assert.Equal(t, pkg, string(fd.Package()))
+
+	var fromDescriptor []string
+	for i := 0; i < fd.Messages().Len(); i++ {
+		md := fd.Messages().Get(i)
+		for j := 0; j < md.Fields().Len(); j++ {
+			f := md.Fields().Get(j)
+			var typ string
+			switch {
+			case f.IsMap():
+				typ = fmt.Sprintf("map<%s,%s>", f.MapKey().Kind(), f.MapValue().Kind())
+			case f.Kind() == protoreflect.MessageKind:
+				typ = string(f.Message().Name())
+			default:
+				typ = f.Kind().String()
+			}
+			if f.IsList() {
+				typ = "repeated " + typ
+			}
+			fromDescriptor = append(fromDescriptor, fmt.Sprintf("%s.%s %s = %d", md.Name(), f.Name(), typ,
+				f.Number()))
+		}
+	}
+	service := fd.Services().Get(0)
+	assert.Equal(t, serviceName, string(service.FullName()))
+	for i := 0; i < service.Methods().Len(); i++ {
+		m := service.Methods().Get(i)
+		stream := ""
+		if m.IsStreamingServer() {
+			stream = "stream "
+		}
+		fromDescriptor = append(fromDescriptor, fmt.Sprintf("rpc %s(%s) returns (%s%s)", m.Name(),
+			m.Input().Name(), stream, m.Output().Name()))
+	}
+
+	assert.ElementsMatch(t, fromFile, fromDescriptor)
+}
+
+// TestEncoding_Descriptor decodes the encoded messages with the protobuf runtime, as a client generated from
+// api/telemetry/v1/telemetry.proto does, and encodes the requests, as it does.
+func TestEncoding_Descriptor(t *testing.T) {
+	fd := telemetryDescriptor(t)
+	now := time.Unix(1700000000, 123)
+
+	fieldValues := dynamicpb.NewMessage(fd.Messages().ByName("FieldValues"))
+	require.NoError(t, proto.Unmarshal(encodeFieldValues(testCollection(now), filter{}), fieldValues))
+	assert.Empty(t, fieldValues.GetUnknown())
+
+	fields := fieldValues.Descriptor().Fields()
+	assert.Equal(t, uint64(now.UnixNano()), fieldValues.Get(fields.ByName("timestamp_unix_nano")).Uint())
+
+	values := fieldValues.Get(fields.ByName("values")).List()
+	require.Equal(t, 3, values.Len())
+
+	value := values.Get(1).Message()
+	valueFields := value.Descriptor().Fields()
+	assert.Empty(t, value.GetUnknown())
+	assert.Equal(t, "DCGM_FI_DEV_GPU_UTIL", value.Get(valueFields.ByName("field")).String())
+	assert.Equal(t, "gpu", value.Get(valueFields.ByName("entity_type")).String())
+	assert.Equal(t, "0", value.Get(valueFields.ByName("entity_id")).String())
+	assert.Equal(t, "GPU-0", value.Get(valueFields.ByName("uuid")).String())
+	assert.Equal(t, 42.0, value.Get(valueFields.ByName("value")).Float())
+	labels := value.Get(valueFields.ByName("labels")).Map()
+	assert.Equal(t, 1, labels.Len())
+	assert.Equal(t, "b", labels.Get(protoreflect.ValueOfString("a").MapKey()).String())
+
+	request := dynamicpb.NewMessage(fd.Messages().ByName("FieldValuesRequest"))
+	requestFields := request.Descriptor().Fields()
+	request.Mutable(requestFields.ByName("fields")).List().Append(protoreflect.ValueOfString("DCGM_FI_DEV_GPU_UTIL"))
+	request.Mutable(requestFields.ByName("entity_types")).List().Append(protoreflect.ValueOfString("cpu"))
+	b, err := proto.Marshal(request)
+	require.NoError(t, err)
+
+	got, err := decodeRequest(b)
+	require.NoError(t, err)
+	assert.Equal(t, filter{
+		fields:      []string{"DCGM_FI_DEV_GPU_UTIL"},
+		entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_CPU},
+	}, got)
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcapi
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+)
+
+// Field numbers of the messages, see api/telemetry/v1/telemetry.proto. The messages are encoded by hand, instead of
+// by generated code, and TestTelemetryDescriptor checks the numbers against the file.
+const (
+	requestFields      protowire.Number = 1
+	requestEntityTypes protowire.Number = 2
+
+	fieldValuesTimestamp protowire.Number = 1
+	fieldValuesValues    protowire.Number = 2
+
+	fieldValueField             protowire.Number = 1
+	fieldValueEntityType        protowire.Number = 2
+	fieldValueEntityID          protowire.Number = 3
+	fieldValueUUID              protowire.Number = 4
+	fieldValueGPUInstanceID     protowire.Number = 5
+	fieldValueComputeInstanceID protowire.Number = 6
+	fieldValueValue             protowire.Number = 7
+	fieldValueLabels            protowire.Number = 8
+
+	mapEntryKey   protowire.Number = 1
+	mapEntryValue protowire.Number = 2
+)
+
+// entityTypeNames maps the entity types of the API to entity groups.
+var entityTypeNames = map[string]dcgm.Field_Entity_Group{
+	"gpu":      dcgm.FE_GPU,
+	"switch":   dcgm.FE_SWITCH,
+	"link":     dcgm.FE_LINK,
+	"cpu":      dcgm.FE_CPU,
+	"cpu_core": dcgm.FE_CPU_CORE,
+	"vgpu":     dcgm.FE_VGPU,
+}
+
+// filter selects the field values of a request.
+type filter struct {
+	// fields are the names of the counters, all of them when empty
+	fields []string
+	// entityTypes are the entity groups, all of them when empty
+	entityTypes []dcgm.Field_Entity_Group
+}
+
+func (f filter) matches(group dcgm.Field_Entity_Group, field string) bool {
+	return (len(f.entityTypes) == 0 || slices.Contains(f.entityTypes, group)) &&
+		(len(f.fields) == 0 || slices.Contains(f.fields, field))
+}
+
+// decodeRequest decodes a FieldValuesRequest. Unknown fields are skipped.
+func decodeRequest(b []byte) (filter, error) {
+	var f filter
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return filter{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if typ != protowire.BytesType || (num != requestFields && num != requestEntityTypes) {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return filter{}, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+
+		value, n := protowire.ConsumeString(b)
+		if n < 0 {
+			return filter{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if num == requestFields {
+			f.fields = append(f.fields, value)
+			continue
+		}
+		group, exists := entityTypeNames[strings.ToLower(value)]
+		if !exists {
+			return filter{}, fmt.Errorf("invalid entity type '%s'", value)
+		}
+		f.entityTypes = append(f.entityTypes, group)
+	}
+	return f, nil
+}
+
+// encodeFieldValues encodes the values of the collection, which match the filter, as FieldValues. The values are
+// ordered by entity type and field, and values, which are not numbers, are omitted.
+func encodeFieldValues(event eventbus.CollectionEvent, f filter) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, fieldValuesTimestamp, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(event.Time.UnixNano()))
+
+	groups := make([]dcgm.Field_Entity_Group, 0, len(event.Metrics))
+	for group := range event.Metrics {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+
+	for _, group := range groups {
+		entityType, exists := entityTypeName(group)
+		if !exists {
+			continue
+		}
+
+		metrics := event.Metrics[group]
+		collected := make([]counters.Counter, 0, len(metrics))
+		for counter := range metrics {
+			if f.matches(group, counter.FieldName) {
+				collected = append(collected, counter)
+			}
+		}
+		slices.SortFunc(collected, func(a, b counters.Counter) int {
+			return cmp.Compare(a.FieldName, b.FieldName)
+		})
+
+		for _, counter := range collected {
+			for _, m := range metrics[counter] {
+				b = appendFieldValue(b, entityType, m)
+			}
+		}
+	}
+	return b
+}
+
+func appendFieldValue(b []byte, entityType string, m collector.Metric) []byte {
+	value, err := strconv.ParseFloat(m.Value, 64)
+	if err != nil {
+		return b
+	}
+
+	var msg []byte
+	msg = appendString(msg, fieldValueField, m.Counter.FieldName)
+	msg = appendString(msg, fieldValueEntityType, entityType)
+	msg = appendString(msg, fieldValueEntityID, m.GPU)
+	msg = appendString(msg, fieldValueUUID, m.GPUUUID)
+	msg = appendString(msg, fieldValueGPUInstanceID, m.GPUInstanceID)
+	msg = appendString(msg, fieldValueComputeInstanceID, m.ComputeInstanceID)
+	msg = protowire.AppendTag(msg, fieldValueValue, protowire.Fixed64Type)
+	msg = protowire.AppendFixed64(msg, math.Float64bits(value))
+	keys := make([]string, 0, len(m.Labels))
+	for key := range m.Labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		var entry []byte
+		entry = appendString(entry, mapEntryKey, key)
+		entry = appendString(entry, mapEntryValue, m.Labels[key])
+		msg = appendMessage(msg, fieldValueLabels, entry)
+	}
+
+	return appendMessage(b, fieldValuesValues, msg)
+}
+
+func entityTypeName(group dcgm.Field_Entity_Group) (string, bool) {
+	for name, g := range entityTypeNames {
+		if g == group {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// appendString appends the string, unless it is empty, which is the default value of proto3.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
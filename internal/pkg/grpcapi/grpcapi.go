@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpcapi serves the field values of the collections over gRPC, see api/telemetry/v1/telemetry.proto, so
+// that programmatic consumers, e.g. node-level schedulers and autoscalers, don't need to parse the Prometheus text
+// format. The messages are encoded with protowire, like the OTLP requests, so no generated code is needed.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	serviceName = "dcgmexporter.telemetry.v1.Telemetry"
+
+	getFieldValuesMethod    = "GetFieldValues"
+	streamFieldValuesMethod = "StreamFieldValues"
+
+	// unixPrefix is the prefix of the addresses of Unix domain sockets
+	unixPrefix = "unix:"
+)
+
+var calls = selfmetrics.Default().Counter("dcgm_exporter_grpc_calls_total",
+	"Number of calls of the gRPC API by method.")
+
+// Server serves the Telemetry service of the gRPC API.
+type Server struct {
+	collections *eventbus.Topic[eventbus.CollectionEvent]
+
+	mtx    sync.Mutex
+	latest *eventbus.CollectionEvent
+}
+
+// New creates the server of the collections published on the topic.
+func New(collections *eventbus.Topic[eventbus.CollectionEvent]) *Server {
+	return &Server{collections: collections}
+}
+
+// Listen listens on the address, either <host>:<port> or unix:<path>. An existing socket file is replaced.
+func Listen(address string) (net.Listener, error) {
+	if path, found := strings.CutPrefix(address, unixPrefix); found {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove the socket '%s'; err: %w", path, err)
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", address)
+}
+
+// Run serves the API on the listener, until the context is done.
+func (s *Server) Run(ctx context.Context, lis net.Listener) {
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: getFieldValuesMethod, Handler: s.getFieldValues},
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: streamFieldValuesMethod, Handler: s.streamFieldValues, ServerStreams: true},
+		},
+		Metadata: "api/telemetry/v1/telemetry.proto",
+	}, s)
+
+	go s.keepLatest(s.collections.Subscribe(ctx, 1))
+
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+
+	slog.Info("Serving the gRPC API", slog.String("address", lis.Addr().String()))
+	if err := server.Serve(lis); err != nil {
+		slog.Error("Failed to serve the gRPC API", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// keepLatest keeps the latest collection for GetFieldValues.
+func (s *Server) keepLatest(collections <-chan eventbus.CollectionEvent) {
+	for event := range collections {
+		s.mtx.Lock()
+		s.latest = &event
+		s.mtx.Unlock()
+	}
+}
+
+func (s *Server) latestCollection() (eventbus.CollectionEvent, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.latest == nil {
+		return eventbus.CollectionEvent{}, false
+	}
+	return *s.latest, true
+}
+
+func (s *Server) getFieldValues(
+	_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor,
+) (any, error) {
+	calls.Inc("method", getFieldValuesMethod)
+
+	var request []byte
+	if err := dec(&request); err != nil {
+		return nil, err
+	}
+	f, err := decodeRequest(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	event, ok := s.latestCollection()
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "no metrics were collected yet")
+	}
+
+	response := encodeFieldValues(event, f)
+	return &response, nil
+}
+
+func (s *Server) streamFieldValues(_ any, stream grpc.ServerStream) error {
+	calls.Inc("method", streamFieldValuesMethod)
+
+	var request []byte
+	if err := stream.RecvMsg(&request); err != nil {
+		return err
+	}
+	f, err := decodeRequest(request)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// The channel is closed, when the call ends
+	for event := range s.collections.Subscribe(stream.Context(), 1) {
+		response := encodeFieldValues(event, f)
+		if err := stream.SendMsg(&response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rawCodec passes the messages, which are encoded with protowire, through as they are.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,345 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+// fieldValue is a decoded FieldValue
+type fieldValue struct {
+	field      string
+	entityType string
+	entityID   string
+	uuid       string
+	value      float64
+	labels     map[string]string
+}
+
+func decodeFieldValues(t *testing.T, b []byte) (time.Time, []fieldValue) {
+	t.Helper()
+
+	var timestamp time.Time
+	var values []fieldValue
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		switch {
+		case num == fieldValuesTimestamp && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			require.GreaterOrEqual(t, n, 0)
+			timestamp = time.Unix(0, int64(v))
+			b = b[n:]
+		case num == fieldValuesValues && typ == protowire.BytesType:
+			msg, n := protowire.ConsumeBytes(b)
+			require.GreaterOrEqual(t, n, 0)
+			values = append(values, decodeFieldValue(t, msg))
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected field %d", num)
+		}
+	}
+	return timestamp, values
+}
+
+func decodeFieldValue(t *testing.T, b []byte) fieldValue {
+	t.Helper()
+
+	value := fieldValue{labels: map[string]string{}}
+	for len(b) > 0 {
+		num, _, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		if num == fieldValueValue {
+			v, n := protowire.ConsumeFixed64(b)
+			require.GreaterOrEqual(t, n, 0)
+			value.value = math.Float64frombits(v)
+			b = b[n:]
+			continue
+		}
+
+		s, n := protowire.ConsumeBytes(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		switch num {
+		case fieldValueField:
+			value.field = string(s)
+		case fieldValueEntityType:
+			value.entityType = string(s)
+		case fieldValueEntityID:
+			value.entityID = string(s)
+		case fieldValueUUID:
+			value.uuid = string(s)
+		case fieldValueLabels:
+			var key, val string
+			for len(s) > 0 {
+				num, _, n := protowire.ConsumeTag(s)
+				require.GreaterOrEqual(t, n, 0)
+				s = s[n:]
+				v, n := protowire.ConsumeString(s)
+				require.GreaterOrEqual(t, n, 0)
+				s = s[n:]
+				if num == mapEntryKey {
+					key = v
+				} else {
+					val = v
+				}
+			}
+			value.labels[key] = val
+		}
+	}
+	return value
+}
+
+func encodeRequest(fields, entityTypes []string) []byte {
+	var b []byte
+	for _, field := range fields {
+		b = appendString(b, requestFields, field)
+	}
+	for _, entityType := range entityTypes {
+		b = appendString(b, requestEntityTypes, entityType)
+	}
+	return b
+}
+
+func testCollection(now time.Time) eventbus.CollectionEvent {
+	gpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL"}
+	gpuTemp := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP, FieldName: "DCGM_FI_DEV_GPU_TEMP"}
+	cpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_CPU_UTIL_TOTAL, FieldName: "DCGM_FI_DEV_CPU_UTIL_TOTAL"}
+
+	return eventbus.CollectionEvent{
+		Time: now,
+		Metrics: registry.MetricsByCounterGroup{
+			dcgm.FE_GPU: {
+				gpuUtil: {
+					{Counter: gpuUtil, Value: "42", GPU: "0", GPUUUID: "GPU-0", Labels: map[string]string{"a": "b"}},
+					{Counter: gpuUtil, Value: "not a number", GPU: "1", GPUUUID: "GPU-1"},
+				},
+				gpuTemp: {
+					{Counter: gpuTemp, Value: "65", GPU: "0", GPUUUID: "GPU-0"},
+				},
+			},
+			dcgm.FE_CPU: {
+				cpuUtil: {
+					{Counter: cpuUtil, Value: "0.5", GPU: "0"},
+				},
+			},
+		},
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request []byte
+		want    filter
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:    "Fields and entity types",
+			request: encodeRequest([]string{"DCGM_FI_DEV_GPU_UTIL"}, []string{"gpu", "CPU"}),
+			want: filter{
+				fields:      []string{"DCGM_FI_DEV_GPU_UTIL"},
+				entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_GPU, dcgm.FE_CPU},
+			},
+		},
+		{
+			name: "Unknown fields are skipped",
+			request: protowire.AppendVarint(protowire.AppendTag(encodeRequest([]string{"DCGM_FI_DEV_GPU_UTIL"}, nil),
+				9, protowire.VarintType), 1),
+			want: filter{fields: []string{"DCGM_FI_DEV_GPU_UTIL"}},
+		},
+		{
+			name:    "Invalid entity type",
+			request: encodeRequest(nil, []string{"gpu_instance"}),
+			wantErr: true,
+		},
+		{
+			name:    "Truncated request",
+			request: encodeRequest([]string{"DCGM_FI_DEV_GPU_UTIL"}, nil)[:5],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRequest(tt.request)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncodeFieldValues(t *testing.T) {
+	now := time.Unix(1700000000, 123)
+
+	tests := []struct {
+		name   string
+		filter filter
+		want   []fieldValue
+	}{
+		{
+			name: "All values",
+			want: []fieldValue{
+				{field: "DCGM_FI_DEV_GPU_TEMP", entityType: "gpu", entityID: "0", uuid: "GPU-0", value: 65,
+					labels: map[string]string{}},
+				{field: "DCGM_FI_DEV_GPU_UTIL", entityType: "gpu", entityID: "0", uuid: "GPU-0", value: 42,
+					labels: map[string]string{"a": "b"}},
+				{field: "DCGM_FI_DEV_CPU_UTIL_TOTAL", entityType: "cpu", entityID: "0", value: 0.5,
+					labels: map[string]string{}},
+			},
+		},
+		{
+			name:   "Filtered by field",
+			filter: filter{fields: []string{"DCGM_FI_DEV_GPU_TEMP"}},
+			want: []fieldValue{
+				{field: "DCGM_FI_DEV_GPU_TEMP", entityType: "gpu", entityID: "0", uuid: "GPU-0", value: 65,
+					labels: map[string]string{}},
+			},
+		},
+		{
+			name:   "Filtered by entity type",
+			filter: filter{entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_CPU}},
+			want: []fieldValue{
+				{field: "DCGM_FI_DEV_CPU_UTIL_TOTAL", entityType: "cpu", entityID: "0", value: 0.5,
+					labels: map[string]string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp, values := decodeFieldValues(t, encodeFieldValues(testCollection(now), tt.filter))
+			assert.True(t, now.Equal(timestamp))
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	lis, err := Listen("unix:" + filepath.Join(t.TempDir(), "grpc.sock"))
+	require.NoError(t, err)
+
+	bus := eventbus.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		New(bus.Collections).Run(ctx, lis)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conn, err := grpc.NewClient("unix:"+lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	request := encodeRequest([]string{"DCGM_FI_DEV_GPU_TEMP"}, nil)
+
+	t.Run("GetFieldValues before the first collection", func(t *testing.T) {
+		require.Eventually(t, bus.Collections.HasSubscribers, time.Second, 10*time.Millisecond)
+
+		var response []byte
+		err := conn.Invoke(ctx, "/"+serviceName+"/"+getFieldValuesMethod, &request, &response)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("StreamFieldValues", func(t *testing.T) {
+		streamCtx, stopStream := context.WithCancel(ctx)
+		defer stopStream()
+
+		stream, err := conn.NewStream(streamCtx, &grpc.StreamDesc{ServerStreams: true},
+			"/"+serviceName+"/"+streamFieldValuesMethod)
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&request))
+		require.NoError(t, stream.CloseSend())
+
+		// Collections are published until the stream, which subscribes after the call started, receives one
+		received := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-received:
+					return
+				case <-ticker.C:
+					bus.Collections.Publish(testCollection(time.Now()))
+				}
+			}
+		}()
+
+		var response []byte
+		err = stream.RecvMsg(&response)
+		close(received)
+		require.NoError(t, err)
+
+		_, values := decodeFieldValues(t, response)
+		require.Len(t, values, 1)
+		assert.Equal(t, "DCGM_FI_DEV_GPU_TEMP", values[0].field)
+		assert.Equal(t, 65.0, values[0].value)
+	})
+
+	t.Run("GetFieldValues returns the latest collection", func(t *testing.T) {
+		var response []byte
+		require.Eventually(t, func() bool {
+			return conn.Invoke(ctx, "/"+serviceName+"/"+getFieldValuesMethod, &request, &response) == nil
+		}, time.Second, 10*time.Millisecond)
+
+		_, values := decodeFieldValues(t, response)
+		require.Len(t, values, 1)
+		assert.Equal(t, "GPU-0", values[0].uuid)
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		invalid := encodeRequest(nil, []string{"gpu_instance"})
+
+		var response []byte
+		err := conn.Invoke(ctx, "/"+serviceName+"/"+getFieldValuesMethod, &invalid, &response)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package hostenginestats exports the resource usage of the hostengine embedded in the exporter process
+// as self-metrics, to compare embedded and remote hostengine deployments.
+package hostenginestats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const procStatusPath = "/proc/self/status"
+
+var (
+	memoryBytes = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_memory_bytes",
+		"Memory used by the embedded hostengine, in bytes.")
+	cpuUtilization = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_cpu_utilization_percent",
+		"CPU utilization of the embedded hostengine, in percent.")
+	threads = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_threads",
+		"Number of threads of the exporter process, which were not created by the Go runtime.")
+	watchedValues = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_watched_values",
+		"Number of watched field and entity pairs.")
+)
+
+// Run updates the self-metrics every interval until the context is canceled.
+func Run(ctx context.Context, interval time.Duration, manager devicewatchlistmanager.Manager) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		Update(manager)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update updates the self-metrics once.
+func Update(manager devicewatchlistmanager.Manager) {
+	status, err := dcgmprovider.Client().Introspect()
+	if err != nil {
+		slog.Debug("Failed to introspect the hostengine", slog.String(logging.ErrorKey, err.Error()))
+	} else {
+		// The memory is reported in KB
+		memoryBytes.Set(float64(status.Memory * 1024))
+		cpuUtilization.Set(status.CPU)
+	}
+
+	if n, err := processThreads(); err == nil {
+		// Threads created by the hostengine and other C libraries aren't known to the Go runtime
+		threads.Set(float64(max(n-pprof.Lookup("threadcreate").Count(), 0)))
+	}
+
+	for _, group := range devicewatchlistmanager.DeviceTypesToWatch {
+		watchList, exists := manager.EntityWatchList(group)
+		if !exists {
+			continue
+		}
+		entities := len(devicemonitoring.GetMonitoredEntities(watchList.DeviceInfo()))
+		watchedValues.Set(float64(entities*len(watchList.DeviceFields())), "entity", group.String())
+	}
+}
+
+func processThreads() (int, error) {
+	file, err := os.Open(procStatusPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return parseThreads(file)
+}
+
+// parseThreads reads the number of threads from the content of /proc/<pid>/status.
+func parseThreads(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "Threads:")
+		if found {
+			return strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("no threads in %s", procStatusPath)
+}
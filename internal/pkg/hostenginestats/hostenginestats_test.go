@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostenginestats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func Test_parseThreads(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "Threads present",
+			status: "Name:\tdcgm-exporter\nVmRSS:\t  123456 kB\nThreads:\t42\nSigQ:\t0/127315\n",
+			want:   42,
+		},
+		{
+			name:    "Threads missing",
+			status:  "Name:\tdcgm-exporter\n",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed threads",
+			status:  "Threads:\tmany\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThreads(strings.NewReader(tt.status))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().Introspect().Return(dcgm.DcgmStatus{Memory: 2048, CPU: 1.5}, nil)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	mockManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockManager.EXPECT().EntityWatchList(gomock.Any()).Return(devicewatchlistmanager.WatchList{}, false).
+		Times(len(devicewatchlistmanager.DeviceTypesToWatch))
+
+	Update(mockManager)
+
+	memory, _ := selfmetrics.Default().Value("dcgm_exporter_hostengine_memory_bytes")
+	assert.Equal(t, float64(2048*1024), memory)
+	cpu, _ := selfmetrics.Default().Value("dcgm_exporter_hostengine_cpu_utilization_percent")
+	assert.Equal(t, 1.5, cpu)
+	_, exists := selfmetrics.Default().Value("dcgm_exporter_hostengine_threads")
+	assert.True(t, exists)
+}
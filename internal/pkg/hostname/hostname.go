@@ -17,6 +17,8 @@
 package hostname
 
 import (
+	"errors"
+	"log/slog"
 	"net"
 
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
@@ -25,12 +27,26 @@ import (
 
 var os osinterface.OS = osinterface.RealOS{}
 
+// Origins of the hostname, as reported by Resolve
+const (
+	OriginRemoteHostengine = "remote-hostengine"
+	OriginNodeName         = "NODE_NAME"
+	OriginOS               = "os"
+)
+
 // GetHostname return a hostname where metric was collected.
 func GetHostname(config *appconfig.Config) (string, error) {
+	hostname, _, err := Resolve(config)
+	return hostname, err
+}
+
+// Resolve returns a hostname where metric was collected and where it was read from.
+func Resolve(config *appconfig.Config) (string, string, error) {
 	if config.UseRemoteHE {
-		return parseRemoteHostname(config)
+		hostname, err := parseRemoteHostname(config)
+		return hostname, OriginRemoteHostengine, err
 	}
-	return getLocalHostname()
+	return getLocalHostname(config)
 }
 
 func parseRemoteHostname(config *appconfig.Config) (string, error) {
@@ -45,13 +61,22 @@ func parseRemoteHostname(config *appconfig.Config) (string, error) {
 	return host, nil
 }
 
-func getLocalHostname() (string, error) {
-	if nodeName := os.Getenv("NODE_NAME"); nodeName != "" {
-		return nodeName, nil
+func getLocalHostname(config *appconfig.Config) (string, string, error) {
+	if config.HostnameSource != appconfig.HostnameSourceOS {
+		if nodeName := os.Getenv("NODE_NAME"); nodeName != "" {
+			return nodeName, OriginNodeName, nil
+		}
+		if config.HostnameSource == appconfig.HostnameSourceNodeName {
+			return "", "", errors.New("the NODE_NAME environment variable is not set")
+		}
+		if config.Kubernetes {
+			slog.Warn("The NODE_NAME environment variable is not set, so the Hostname label may not match " +
+				"the Kubernetes node name; set it from the downward API (spec.nodeName).")
+		}
 	}
 	hostname, err := os.Hostname()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return hostname, nil
+	return hostname, OriginOS, nil
 }
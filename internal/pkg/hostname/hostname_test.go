@@ -97,6 +97,35 @@ func TestGetHostname(t *testing.T) {
 			want:    "",
 			wantErr: assert.Error,
 		},
+		{
+			name:   "When the hostname source is os, NODE_NAME is ignored",
+			config: &appconfig.Config{HostnameSource: appconfig.HostnameSourceOS},
+			hook: func() func() {
+				ctrl := gomock.NewController(t)
+				m := osmock.NewMockOS(ctrl)
+				m.EXPECT().Hostname().Return("test-hostname", nil)
+				os = m
+				return func() {
+					os = osinterface.RealOS{}
+				}
+			},
+			want: "test-hostname",
+		},
+		{
+			name:   "When the hostname source is node-name and NODE_NAME is not set",
+			config: &appconfig.Config{HostnameSource: appconfig.HostnameSourceNodeName},
+			hook: func() func() {
+				ctrl := gomock.NewController(t)
+				m := osmock.NewMockOS(ctrl)
+				m.EXPECT().Getenv(gomock.Eq("NODE_NAME"))
+				os = m
+				return func() {
+					os = osinterface.RealOS{}
+				}
+			},
+			want:    "",
+			wantErr: assert.Error,
+		},
 		{
 			name: "When appconfig.UseRemoteHE is true and remote hostname is name",
 			config: &appconfig.Config{
@@ -128,3 +157,23 @@ func TestGetHostname(t *testing.T) {
 		})
 	}
 }
+
+func TestResolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := osmock.NewMockOS(ctrl)
+	m.EXPECT().Getenv(gomock.Eq("NODE_NAME")).Return("node-1")
+	os = m
+	defer func() {
+		os = osinterface.RealOS{}
+	}()
+
+	hostname, origin, err := Resolve(&appconfig.Config{Kubernetes: true, HostnameSource: appconfig.HostnameSourceAuto})
+	assert.NoError(t, err)
+	assert.Equal(t, "node-1", hostname)
+	assert.Equal(t, OriginNodeName, origin)
+
+	hostname, origin, err = Resolve(&appconfig.Config{UseRemoteHE: true, RemoteHEInfo: "example.com:5555"})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", hostname)
+	assert.Equal(t, OriginRemoteHostengine, origin)
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package idlemode reduces the exported counters to a minimal set while no pod is allocated a GPU, so that
+// mostly idle nodes, like spot fleets, don't pay the scrape and storage cost of the full counter set.
+package idlemode
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	idleGauge = selfmetrics.Default().Gauge("dcgm_exporter_idle_mode",
+		"1 when only the idle counters are exported, because no pod was allocated a GPU for the idle period.")
+	transitions = selfmetrics.Default().Counter("dcgm_exporter_idle_mode_transitions_total",
+		"Number of switches between the full and the idle counter set.")
+)
+
+// Controller switches to the idle counters once no allocation was observed for the idle period, and back to
+// the full counter set as soon as an allocation is observed. The idle period starts over after every allocation,
+// so a node, which is allocated and released in quick succession, keeps exporting the full counter set.
+type Controller struct {
+	counters  []string
+	idleAfter time.Duration
+
+	lastAllocated time.Time
+	idle          bool
+	mtx           sync.Mutex
+}
+
+// New creates a controller, which exports the full counter set for at least the idle period after now.
+func New(counters []string, idleAfter time.Duration, now time.Time) *Controller {
+	idleGauge.Set(0)
+
+	return &Controller{
+		counters:      counters,
+		idleAfter:     idleAfter,
+		lastAllocated: now,
+	}
+}
+
+// Idle reports whether only the idle counters are exported.
+func (c *Controller) Idle() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.idle
+}
+
+// Observe records whether any GPU was allocated to a pod at the given time.
+func (c *Controller) Observe(now time.Time, allocated bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if allocated {
+		c.lastAllocated = now
+		if c.idle {
+			c.idle = false
+			slog.Info("A GPU is allocated to a pod; exporting all counters")
+			idleGauge.Set(0)
+			transitions.Inc("mode", "full")
+		}
+		return
+	}
+
+	if !c.idle && now.Sub(c.lastAllocated) >= c.idleAfter {
+		c.idle = true
+		slog.Info(fmt.Sprintf("No GPU was allocated to a pod for %s; exporting the idle counters only", c.idleAfter))
+		idleGauge.Set(1)
+		transitions.Inc("mode", "idle")
+	}
+}
+
+// Filter removes the counters, which are not idle counters, while the controller is idle.
+func (c *Controller) Filter(metricGroups registry.MetricsByCounterGroup) {
+	if !c.Idle() {
+		return
+	}
+
+	for _, metrics := range metricGroups {
+		for counter := range metrics {
+			if !slices.Contains(c.counters, counter.FieldName) {
+				delete(metrics, counter)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idlemode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestController_Observe(t *testing.T) {
+	start := time.Now()
+	c := New([]string{"DCGM_FI_DEV_GPU_UTIL"}, 10*time.Minute, start)
+
+	// The full counter set is exported for the idle period after the start
+	c.Observe(start.Add(9*time.Minute), false)
+	assert.False(t, c.Idle())
+
+	c.Observe(start.Add(10*time.Minute), false)
+	assert.True(t, c.Idle())
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_idle_mode")
+	assert.Equal(t, 1.0, value)
+
+	// An allocation restores the full counter set immediately
+	c.Observe(start.Add(11*time.Minute), true)
+	assert.False(t, c.Idle())
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_idle_mode")
+	assert.Equal(t, 0.0, value)
+
+	// and the idle period starts over once the allocation is gone
+	c.Observe(start.Add(12*time.Minute), false)
+	assert.False(t, c.Idle())
+	c.Observe(start.Add(21*time.Minute), true)
+	c.Observe(start.Add(30*time.Minute), false)
+	assert.False(t, c.Idle())
+	c.Observe(start.Add(31*time.Minute), false)
+	assert.True(t, c.Idle())
+}
+
+func TestController_Filter(t *testing.T) {
+	util := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+	power := counters.Counter{FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+
+	newGroups := func() registry.MetricsByCounterGroup {
+		return registry.MetricsByCounterGroup{
+			dcgm.FE_GPU: collector.MetricsByCounter{
+				util:  {{Counter: util, Value: "10"}},
+				power: {{Counter: power, Value: "100"}},
+			},
+		}
+	}
+
+	start := time.Now()
+	c := New([]string{util.FieldName}, time.Minute, start)
+
+	groups := newGroups()
+	c.Filter(groups)
+	assert.Len(t, groups[dcgm.FE_GPU], 2)
+
+	c.Observe(start.Add(time.Minute), false)
+
+	groups = newGroups()
+	c.Filter(groups)
+	assert.Len(t, groups[dcgm.FE_GPU], 1)
+	assert.Contains(t, groups[dcgm.FE_GPU], util)
+}
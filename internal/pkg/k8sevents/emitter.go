@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package k8sevents posts Kubernetes Events on the node, which the exporter runs on, when the collected metrics
+// report a critical condition of a GPU, so that remediation controllers and cluster autoscalers can react to it
+// without scraping the metrics.
+package k8sevents
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const component = "dcgm-exporter"
+
+// Reasons of the events
+const (
+	ReasonDoubleBitECCError = "GPUDoubleBitECCError"
+	ReasonThermalViolation  = "GPUThermalViolation"
+	ReasonXIDError          = "GPUXIDError"
+	ReasonResetRequired     = "GPUResetRequired"
+)
+
+var eventsEmitted = selfmetrics.Default().Counter("dcgm_exporter_kubernetes_events_total",
+	"Number of Kubernetes Events, which were posted on the node for critical GPU conditions.")
+
+// criticalXIDs are the XID errors, which need an action on the GPU or the node, with the recommended action.
+var criticalXIDs = map[int]string{
+	48:  "double-bit ECC error; drain the node and reset the GPU",
+	63:  "ECC page retirement or row remapping recorded; reset the GPU, when no work is running",
+	64:  "ECC page retirement or row remapping failed; drain the node and reset the GPU",
+	74:  "NVLink error; drain the node and reset the GPU",
+	79:  "GPU has fallen off the bus; drain the node and reboot it",
+	92:  "high single-bit ECC error rate; monitor the GPU and reset it, when the rate persists",
+	94:  "contained ECC error; restart the affected application",
+	95:  "uncontained ECC error; drain the node and reset the GPU",
+	119: "GSP RPC timeout; drain the node and reset the GPU",
+	120: "GSP error; drain the node and reset the GPU",
+}
+
+// Emitter detects the critical conditions in the collected metrics, and posts an event on the node, when a condition
+// starts. Counters, e.g. the double-bit ECC errors, are compared with their former value, so the errors, which
+// occurred before the exporter started, don't post events.
+type Emitter struct {
+	recorder record.EventRecorder
+	node     *corev1.ObjectReference
+
+	mtx sync.Mutex
+	// Last values of the series, by field and series
+	last map[string]float64
+}
+
+// New returns an emitter, which posts the events on the node through the client, and the function, which stops
+// posting them.
+func New(client kubernetes.Interface, nodeName string) (*Emitter, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component, Host: nodeName})
+
+	slog.Info(fmt.Sprintf("Posting Kubernetes Events on the node %q for critical GPU conditions", nodeName))
+
+	return newEmitter(recorder, nodeName), broadcaster.Shutdown
+}
+
+func newEmitter(recorder record.EventRecorder, nodeName string) *Emitter {
+	return &Emitter{
+		recorder: recorder,
+		// The kubelet refers to its node by name as well
+		node: &corev1.ObjectReference{Kind: "Node", Name: nodeName, UID: types.UID(nodeName)},
+		last: map[string]float64{},
+	}
+}
+
+// Run detects the conditions in every collection until the channel is closed.
+func (e *Emitter) Run(collections <-chan eventbus.CollectionEvent) {
+	for collection := range collections {
+		e.Process(collection.Metrics)
+	}
+}
+
+// Process detects the conditions in the metrics of one collection.
+func (e *Emitter) Process(metricGroups registry.MetricsByCounterGroup) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for _, metrics := range metricGroups {
+		for counter, values := range metrics {
+			for _, metric := range values {
+				value, err := strconv.ParseFloat(metric.Value, 64)
+				if err != nil {
+					continue
+				}
+
+				key := fmt.Sprintf("%s/%s/%s/%s", counter.FieldName, metric.GPUUUID, metric.GPUInstanceID,
+					metric.ComputeInstanceID)
+				last, seen := e.last[key]
+				e.last[key] = value
+
+				e.detect(counter.FieldName, metric, value, last, seen)
+			}
+		}
+	}
+}
+
+// detect posts the event of the condition, which the field reports, when it started since the last collection.
+func (e *Emitter) detect(field string, metric collector.Metric, value, last float64, seen bool) {
+	gpu := gpuName(metric)
+
+	switch field {
+	case "DCGM_FI_DEV_ECC_DBE_VOL_TOTAL":
+		if seen && value > last {
+			e.emit(ReasonDoubleBitECCError, fmt.Sprintf(
+				"%s reported %.0f new double-bit ECC errors; drain the node and reset the GPU", gpu, value-last))
+		}
+	case "DCGM_FI_DEV_THERMAL_VIOLATION":
+		if seen && value > last {
+			e.emit(ReasonThermalViolation, fmt.Sprintf(
+				"%s was throttled by a thermal violation for %.0f us; check the cooling of the node", gpu, value-last))
+		}
+	case "DCGM_FI_DEV_XID_ERRORS":
+		action, critical := criticalXIDs[int(value)]
+		if seen && value != last && critical {
+			e.emit(ReasonXIDError, fmt.Sprintf("%s reported XID %d: %s", gpu, int(value), action))
+		}
+	case counters.DCGMExpGPUNeedsReset:
+		// The condition is a state, so it is also reported, when it already holds at startup
+		if value == 1 && (!seen || last != 1) {
+			e.emit(ReasonResetRequired, fmt.Sprintf("%s needs a reset; drain the node and reset the GPU", gpu))
+		}
+	}
+}
+
+func (e *Emitter) emit(reason, message string) {
+	slog.Warn("Posting a Kubernetes Event on the node", slog.String("reason", reason), slog.String("message", message))
+	e.recorder.Event(e.node, corev1.EventTypeWarning, reason, message)
+	eventsEmitted.Inc("reason", reason)
+}
+
+// gpuName names the GPU, or the GPU instance, of the metric in the message of an event.
+func gpuName(metric collector.Metric) string {
+	name := fmt.Sprintf("GPU %s (%s)", metric.GPU, metric.GPUUUID)
+	if metric.GPUInstanceID != "" {
+		name = fmt.Sprintf("GPU instance %s of %s", metric.GPUInstanceID, name)
+	}
+	return name
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package k8sevents
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+func newMetrics(values map[string]string) registry.MetricsByCounterGroup {
+	metrics := collector.MetricsByCounter{}
+	for field, value := range values {
+		counter := counters.Counter{FieldName: field}
+		metrics[counter] = []collector.Metric{{Counter: counter, Value: value, GPU: "0", GPUUUID: "GPU-0"}}
+	}
+	return registry.MetricsByCounterGroup{dcgm.FE_GPU: metrics}
+}
+
+// events returns the events, which were posted since the last call.
+func events(recorder *record.FakeRecorder) []string {
+	var posted []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			posted = append(posted, event)
+		default:
+			return posted
+		}
+	}
+}
+
+func TestEmitter_Process(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	emitter := newEmitter(recorder, "node")
+
+	// The errors and the XID, which occurred before the exporter started, post no events, the reset needed does
+	emitter.Process(newMetrics(map[string]string{
+		"DCGM_FI_DEV_ECC_DBE_VOL_TOTAL": "2",
+		"DCGM_FI_DEV_THERMAL_VIOLATION": "1000",
+		"DCGM_FI_DEV_XID_ERRORS":        "79",
+		counters.DCGMExpGPUNeedsReset:   "1",
+	}))
+	assert.Equal(t, []string{
+		"Warning GPUResetRequired GPU 0 (GPU-0) needs a reset; drain the node and reset the GPU",
+	}, events(recorder))
+
+	// Nothing changed
+	emitter.Process(newMetrics(map[string]string{
+		"DCGM_FI_DEV_ECC_DBE_VOL_TOTAL": "2",
+		"DCGM_FI_DEV_THERMAL_VIOLATION": "1000",
+		"DCGM_FI_DEV_XID_ERRORS":        "79",
+		counters.DCGMExpGPUNeedsReset:   "1",
+	}))
+	assert.Empty(t, events(recorder))
+
+	// New errors, a non-critical XID, and the GPU was reset
+	emitter.Process(newMetrics(map[string]string{
+		"DCGM_FI_DEV_ECC_DBE_VOL_TOTAL": "3",
+		"DCGM_FI_DEV_THERMAL_VIOLATION": "1500",
+		"DCGM_FI_DEV_XID_ERRORS":        "13",
+		counters.DCGMExpGPUNeedsReset:   "0",
+	}))
+	assert.ElementsMatch(t, []string{
+		"Warning GPUDoubleBitECCError GPU 0 (GPU-0) reported 1 new double-bit ECC errors; drain the node and reset the GPU",
+		"Warning GPUThermalViolation GPU 0 (GPU-0) was throttled by a thermal violation for 500 us; check the cooling of the node",
+	}, events(recorder))
+
+	// A critical XID
+	emitter.Process(newMetrics(map[string]string{
+		"DCGM_FI_DEV_XID_ERRORS":      "48",
+		counters.DCGMExpGPUNeedsReset: "1",
+	}))
+	assert.ElementsMatch(t, []string{
+		"Warning GPUXIDError GPU 0 (GPU-0) reported XID 48: double-bit ECC error; drain the node and reset the GPU",
+		"Warning GPUResetRequired GPU 0 (GPU-0) needs a reset; drain the node and reset the GPU",
+	}, events(recorder))
+}
+
+func TestGPUName(t *testing.T) {
+	assert.Equal(t, "GPU 1 (GPU-1)", gpuName(collector.Metric{GPU: "1", GPUUUID: "GPU-1"}))
+	assert.Equal(t, "GPU instance 2 of GPU 1 (GPU-1)",
+		gpuName(collector.Metric{GPU: "1", GPUUUID: "GPU-1", GPUInstanceID: "2"}))
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kubeclient configures the clients of the Kubernetes API server and of the kubelet API, so that they
+// work outside of the cluster defaults, for example behind an intercepting proxy.
+package kubeclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+// RESTConfig returns the configuration of the Kubernetes API server client. It is read from the kubeconfig file,
+// when one is configured, and from the in-cluster service account otherwise. The CA bundle and the proxy
+// override the ones of the kubeconfig file.
+func RESTConfig(c *appconfig.Config) (*rest.Config, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+
+	if c.KubeConfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", c.KubeConfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.KubernetesCAFile != "" {
+		config.TLSClientConfig.CAFile = c.KubernetesCAFile
+		config.TLSClientConfig.CAData = nil
+	}
+
+	if c.KubernetesProxyURL != "" {
+		proxy, err := Proxy(c)
+		if err != nil {
+			return nil, err
+		}
+		config.Proxy = proxy
+	}
+
+	return config, nil
+}
+
+// NewClient creates a Kubernetes API server client.
+func NewClient(c *appconfig.Config) (kubernetes.Interface, error) {
+	config, err := RESTConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
+}
+
+// Proxy returns the proxy function of the clients. Without a configured proxy URL, the proxy is read from the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func Proxy(c *appconfig.Config) (func(*http.Request) (*url.URL, error), error) {
+	if c.KubernetesProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := url.Parse(c.KubernetesProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL '%s'; err: %w", c.KubernetesProxyURL, err)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
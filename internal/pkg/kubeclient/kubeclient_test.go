@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeclient
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://kubernetes.example.com:6443
+    certificate-authority-data: Y2VydGlmaWNhdGU=
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: secret
+`
+
+func TestRESTConfig(t *testing.T) {
+	kubeConfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeConfig, []byte(testKubeConfig), 0o600))
+
+	t.Run("reads the kubeconfig file", func(t *testing.T) {
+		config, err := RESTConfig(&appconfig.Config{KubeConfig: kubeConfig})
+		require.NoError(t, err)
+		assert.Equal(t, "https://kubernetes.example.com:6443", config.Host)
+		assert.Equal(t, []byte("certificate"), config.TLSClientConfig.CAData)
+		assert.Nil(t, config.Proxy)
+	})
+
+	t.Run("overrides the CA bundle and the proxy", func(t *testing.T) {
+		config, err := RESTConfig(&appconfig.Config{
+			KubeConfig:         kubeConfig,
+			KubernetesCAFile:   "/etc/ssl/proxy-ca.pem",
+			KubernetesProxyURL: "http://proxy.example.com:3128",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/etc/ssl/proxy-ca.pem", config.TLSClientConfig.CAFile)
+		assert.Nil(t, config.TLSClientConfig.CAData)
+
+		require.NotNil(t, config.Proxy)
+		req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+		require.NoError(t, err)
+		proxyURL, err := config.Proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, "http://proxy.example.com:3128", proxyURL.String())
+	})
+
+	t.Run("fails without a kubeconfig file outside of a cluster", func(t *testing.T) {
+		t.Setenv("KUBERNETES_SERVICE_HOST", "")
+		_, err := RESTConfig(&appconfig.Config{})
+		assert.Error(t, err)
+	})
+}
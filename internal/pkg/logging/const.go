@@ -25,4 +25,21 @@ const (
 	MetricsKey          = "metrics"
 	DeviceInfoKey       = "deviceInfo"
 	ErrorKey            = "error"
+	GPUIDKey            = "gpuID"
+	FieldIDKey          = "fieldID"
+	CollectorKey        = "collector"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Log levels
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
 )
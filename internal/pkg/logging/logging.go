@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Formats are the supported log formats.
+var Formats = []string{FormatText, FormatJSON}
+
+// Levels are the supported log levels.
+var Levels = []string{LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+// The logger of the process, before it is configured, writes the text format through the log package.
+var textLogger = slog.Default()
+
+// Configure sets the format and the minimum level of the default logger. The JSON format is written to w, while the
+// text format keeps the output of the log package.
+func Configure(format, level string, w io.Writer) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level '%s'", level)
+	}
+
+	switch format {
+	case FormatText:
+		slog.SetDefault(textLogger)
+		slog.SetLogLoggerLevel(lvl)
+	case FormatJSON:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})))
+	default:
+		return fmt.Errorf("invalid log format '%s'", format)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, Configure(FormatText, LevelInfo, nil))
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, Configure(FormatJSON, LevelWarn, &buf))
+
+	slog.Info("not logged")
+	slog.Warn("collector failed", slog.String(CollectorKey, "DCGM_EXP_XID_ERRORS_COUNT"), slog.Uint64(GPUIDKey, 1))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "collector failed", entry["msg"])
+	assert.Equal(t, "DCGM_EXP_XID_ERRORS_COUNT", entry[CollectorKey])
+	assert.Equal(t, float64(1), entry[GPUIDKey])
+
+	assert.Error(t, Configure("yaml", LevelInfo, &buf))
+	assert.Error(t, Configure(FormatJSON, "verbose", &buf))
+}
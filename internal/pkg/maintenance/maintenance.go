@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package maintenance tracks GPUs, which are under intentional maintenance, so dashboards can tell maintenance apart
+// from failures. Draining GPUs keep being collected, and their series are annotated; GPUs being reset are not
+// monitored, and their fields not watched, so the exporter and the hostengine don't read a GPU in the middle of its
+// reset.
+package maintenance
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	// DrainingLabel is added with the value "true" to the series of draining GPUs
+	DrainingLabel = "draining"
+
+	StateDraining  = "draining"
+	StateResetting = "resetting"
+)
+
+var maintenanceGauge = selfmetrics.Default().Gauge("dcgm_exporter_gpu_maintenance",
+	"GPUs under intentional maintenance by state: draining or resetting.")
+
+// GPUState is the maintenance state of a GPU.
+type GPUState struct {
+	GPU        uint       `json:"gpu"`
+	Draining   bool       `json:"draining"`
+	Resetting  bool       `json:"resetting"`
+	ResetUntil *time.Time `json:"resetUntil,omitempty"`
+}
+
+// Controller holds the maintenance state of the GPUs. A reset ends, when it is ended explicitly, or when its
+// duration elapsed, so a GPU is never left unmonitored by a forgotten reset.
+type Controller struct {
+	now func() time.Time
+	// reload creates the field watches again, without the GPUs being reset; it must not block
+	reload func()
+
+	mtx      sync.Mutex
+	draining map[uint]struct{}
+	resets   map[uint]time.Time
+}
+
+// New creates a controller without GPUs under maintenance.
+func New() *Controller {
+	maintenanceGauge.Reset()
+
+	return &Controller{
+		now:      time.Now,
+		draining: map[uint]struct{}{},
+		resets:   map[uint]time.Time{},
+	}
+}
+
+// SetReload sets the function, which creates the groups and the field watches again, so that the fields of a GPU
+// are unwatched, when its reset starts, and watched again, when it ends. The function must not block.
+func (c *Controller) SetReload(reload func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.reload = reload
+}
+
+// Drain marks the GPU as draining.
+func (c *Controller) Drain(gpu uint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.draining[gpu] = struct{}{}
+	slog.Info(fmt.Sprintf("GPU %d is draining", gpu))
+	c.publish()
+}
+
+// Undrain clears the draining mark of the GPU.
+func (c *Controller) Undrain(gpu uint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.draining, gpu)
+	slog.Info(fmt.Sprintf("GPU %d is no longer draining", gpu))
+	c.publish()
+}
+
+// StartReset stops monitoring the GPU for at most the duration.
+func (c *Controller) StartReset(gpu uint, duration time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	_, resetting := c.resets[gpu]
+	c.resets[gpu] = c.now().Add(duration)
+	if !resetting {
+		devicemonitoring.PauseGPU(gpu)
+		c.requestReload()
+	}
+	slog.Info(fmt.Sprintf("GPU %d is being reset; not monitoring it for up to %s", gpu, duration))
+	c.publish()
+}
+
+// EndReset monitors the GPU again.
+func (c *Controller) EndReset(gpu uint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.endReset(gpu)
+	c.publish()
+}
+
+func (c *Controller) endReset(gpu uint) {
+	if _, exists := c.resets[gpu]; !exists {
+		return
+	}
+	delete(c.resets, gpu)
+	devicemonitoring.ResumeGPU(gpu)
+	c.requestReload()
+	slog.Info(fmt.Sprintf("GPU %d reset ended; monitoring it again", gpu))
+}
+
+// requestReload creates the field watches again, since the monitored GPUs changed.
+func (c *Controller) requestReload() {
+	if c.reload != nil {
+		c.reload()
+	}
+}
+
+// States returns the GPUs under maintenance, ordered by GPU.
+func (c *Controller) States() []GPUState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.expireResets()
+
+	gpus := make([]uint, 0, len(c.draining)+len(c.resets))
+	for gpu := range c.draining {
+		gpus = append(gpus, gpu)
+	}
+	for gpu := range c.resets {
+		if _, exists := c.draining[gpu]; !exists {
+			gpus = append(gpus, gpu)
+		}
+	}
+	slices.Sort(gpus)
+
+	states := make([]GPUState, 0, len(gpus))
+	for _, gpu := range gpus {
+		_, draining := c.draining[gpu]
+		until, resetting := c.resets[gpu]
+		state := GPUState{GPU: gpu, Draining: draining, Resetting: resetting}
+		if resetting {
+			state.ResetUntil = &until
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// Annotate adds the draining label to the series of the draining GPUs, and ends the resets, which are over.
+func (c *Controller) Annotate(metricGroups registry.MetricsByCounterGroup) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.expireResets()
+
+	if len(c.draining) == 0 {
+		return
+	}
+
+	for group, metrics := range metricGroups {
+		if group != dcgm.FE_GPU && group != dcgm.FE_GPU_I {
+			continue
+		}
+		for _, values := range metrics {
+			for i := range values {
+				gpu, err := strconv.ParseUint(values[i].GPU, 10, 32)
+				if err != nil {
+					continue
+				}
+				if _, draining := c.draining[uint(gpu)]; !draining {
+					continue
+				}
+				// Labels may be shared by the series of a GPU, so they are copied
+				labels := maps.Clone(values[i].Labels)
+				if labels == nil {
+					labels = map[string]string{}
+				}
+				labels[DrainingLabel] = "true"
+				values[i].Labels = labels
+			}
+		}
+	}
+}
+
+func (c *Controller) expireResets() {
+	expired := false
+	for gpu, until := range c.resets {
+		if !c.now().Before(until) {
+			c.endReset(gpu)
+			expired = true
+		}
+	}
+	if expired {
+		c.publish()
+	}
+}
+
+func (c *Controller) publish() {
+	maintenanceGauge.Reset()
+	for gpu := range c.draining {
+		maintenanceGauge.Set(1, "gpu", fmt.Sprint(gpu), "state", StateDraining)
+	}
+	for gpu := range c.resets {
+		maintenanceGauge.Set(1, "gpu", fmt.Sprint(gpu), "state", StateResetting)
+	}
+}
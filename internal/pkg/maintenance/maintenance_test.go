@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestController_Annotate(t *testing.T) {
+	c := New()
+	c.Drain(1)
+
+	counter := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge"}
+	shared := map[string]string{"modelName": "A100"}
+	metricGroups := registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: {
+			counter: {
+				{GPU: "0", Value: "40", Labels: shared},
+				{GPU: "1", Value: "41", Labels: shared},
+			},
+		},
+	}
+
+	c.Annotate(metricGroups)
+
+	metrics := metricGroups[dcgm.FE_GPU][counter]
+	assert.NotContains(t, metrics[0].Labels, DrainingLabel)
+	assert.Equal(t, "true", metrics[1].Labels[DrainingLabel])
+	assert.NotContains(t, shared, DrainingLabel)
+
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_gpu_maintenance", "gpu", "1", "state", StateDraining)
+	assert.Equal(t, float64(1), value)
+
+	c.Undrain(1)
+	assert.Empty(t, c.States())
+	_, ok := selfmetrics.Default().Value("dcgm_exporter_gpu_maintenance", "gpu", "1", "state", StateDraining)
+	assert.False(t, ok)
+}
+
+func TestController_Reset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	gpus := []deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0}},
+		{DeviceInfo: dcgm.Device{GPU: 1}},
+	}
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{Flex: true}).AnyTimes()
+	mockDeviceInfo.EXPECT().GPUCount().Return(uint(len(gpus))).AnyTimes()
+	mockDeviceInfo.EXPECT().GPU(gomock.Any()).DoAndReturn(func(i uint) deviceinfo.GPUInfo {
+		return gpus[i]
+	}).AnyTimes()
+
+	monitoredGPUs := func() []uint {
+		var ids []uint
+		for _, mi := range devicemonitoring.GetMonitoredEntities(mockDeviceInfo) {
+			ids = append(ids, mi.DeviceInfo.GPU)
+		}
+		return ids
+	}
+
+	clock := time.Unix(0, 0)
+	c := New()
+	c.now = func() time.Time { return clock }
+	reloads := 0
+	c.SetReload(func() { reloads++ })
+
+	// The field watches are created again without the GPU, when its reset starts, and with it, when it ends
+	c.StartReset(1, time.Minute)
+	assert.Equal(t, []uint{0}, monitoredGPUs())
+	assert.Equal(t, 1, reloads)
+	until := clock.Add(time.Minute)
+	assert.Equal(t, []GPUState{{GPU: 1, Resetting: true, ResetUntil: &until}}, c.States())
+
+	// Extending a reset doesn't change the watched GPUs
+	c.StartReset(1, time.Minute)
+	assert.Equal(t, 1, reloads)
+
+	c.EndReset(1)
+	assert.Equal(t, []uint{0, 1}, monitoredGPUs())
+	assert.Equal(t, 2, reloads)
+
+	// A reset, which is not ended, ends once its duration elapsed
+	c.StartReset(0, time.Minute)
+	assert.Equal(t, []uint{1}, monitoredGPUs())
+	clock = clock.Add(time.Minute)
+	c.Annotate(registry.MetricsByCounterGroup{})
+	assert.Equal(t, []uint{0, 1}, monitoredGPUs())
+	assert.Empty(t, c.States())
+	assert.Equal(t, 4, reloads)
+
+	// Draining keeps watching the GPU
+	c.Drain(0)
+	c.Undrain(0)
+	assert.Equal(t, 4, reloads)
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package nvidiacaps resolves the minor numbers and device nodes of the nvidia-caps capabilities,
+// which grant access to MIG GPU and compute instances.
+package nvidiacaps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// devicePathPrefix is the prefix of the nvidia-caps device nodes, created by nvidia-modprobe
+const devicePathPrefix = "/dev/nvidia-caps/nvidia-cap"
+
+// MIGMinorsPath is the procfs file mapping the MIG capabilities to the nvidia-caps minor numbers
+var MIGMinorsPath = "/proc/driver/nvidia-caps/mig-minors"
+
+// MIGMinors maps MIG capabilities, such as "gpu0/gi1/access", to their minor numbers
+type MIGMinors map[string]int
+
+// ReadMIGMinors reads the MIG capabilities minor numbers from procfs.
+func ReadMIGMinors() (MIGMinors, error) {
+	file, err := os.Open(MIGMinorsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseMIGMinors(file)
+}
+
+// ParseMIGMinors parses the content of the mig-minors file, where each line is formatted as "<capability> <minor>".
+func ParseMIGMinors(r io.Reader) (MIGMinors, error) {
+	minors := MIGMinors{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line '%s' in %s", line, MIGMinorsPath)
+		}
+
+		minor, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid minor number in line '%s' in %s", line, MIGMinorsPath)
+		}
+
+		minors[fields[0]] = minor
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return minors, nil
+}
+
+// GPUInstance returns the minor number and the device node of the access capability of a GPU instance.
+// The GPU is identified by its device minor number.
+func (m MIGMinors) GPUInstance(gpuMinor, gpuInstanceID int) (int, string, bool) {
+	return m.lookup(fmt.Sprintf("gpu%d/gi%d/access", gpuMinor, gpuInstanceID))
+}
+
+// ComputeInstance returns the minor number and the device node of the access capability of a compute instance.
+// The GPU is identified by its device minor number.
+func (m MIGMinors) ComputeInstance(gpuMinor, gpuInstanceID, computeInstanceID int) (int, string, bool) {
+	return m.lookup(fmt.Sprintf("gpu%d/gi%d/ci%d/access", gpuMinor, gpuInstanceID, computeInstanceID))
+}
+
+func (m MIGMinors) lookup(capability string) (int, string, bool) {
+	minor, exists := m[capability]
+	if !exists {
+		return 0, "", false
+	}
+
+	return minor, devicePathPrefix + strconv.Itoa(minor), true
+}
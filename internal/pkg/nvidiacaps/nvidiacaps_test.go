@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nvidiacaps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const migMinors = `config 1
+monitor 2
+gpu0/gi0/access 3
+gpu0/gi0/ci0/access 4
+gpu0/gi1/access 12
+gpu0/gi1/ci0/access 13
+gpu1/gi2/access 156
+gpu1/gi2/ci1/access 158
+`
+
+func TestParseMIGMinors(t *testing.T) {
+	minors, err := ParseMIGMinors(strings.NewReader(migMinors))
+	require.NoError(t, err)
+	assert.Len(t, minors, 8)
+
+	minor, path, found := minors.GPUInstance(0, 1)
+	assert.True(t, found)
+	assert.Equal(t, 12, minor)
+	assert.Equal(t, "/dev/nvidia-caps/nvidia-cap12", path)
+
+	minor, path, found = minors.ComputeInstance(1, 2, 1)
+	assert.True(t, found)
+	assert.Equal(t, 158, minor)
+	assert.Equal(t, "/dev/nvidia-caps/nvidia-cap158", path)
+
+	_, _, found = minors.GPUInstance(1, 0)
+	assert.False(t, found)
+}
+
+func TestParseMIGMinorsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing minor", content: "gpu0/gi0/access\n"},
+		{name: "invalid minor", content: "gpu0/gi0/access three\n"},
+		{name: "extra fields", content: "gpu0/gi0/access 3 4\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMIGMinors(strings.NewReader(tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -17,9 +17,13 @@
 package nvmlprovider
 
 import (
+	"cmp"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -32,6 +36,121 @@ type MIGDeviceInfo struct {
 	ComputeInstanceID int
 }
 
+// EncoderDecoderStats contains video encoder and decoder statistics of a GPU
+type EncoderDecoderStats struct {
+	EncoderSessionCount int
+	EncoderUtilization  uint32
+	DecoderUtilization  uint32
+}
+
+// ProcessTypeStats contains the number of processes with compute and graphics contexts on a GPU
+// and their SM utilization. A process with both contexts is included in both.
+type ProcessTypeStats struct {
+	ComputeProcessCount  int
+	GraphicsProcessCount int
+	ComputeUtilization   uint32
+	GraphicsUtilization  uint32
+	// LastSeenTimestamp is the timestamp of the most recent utilization sample, in microseconds
+	LastSeenTimestamp uint64
+}
+
+// MIGDevice identifies a MIG device by its UUID and the GPU and compute instances it is made of
+type MIGDevice struct {
+	// Index is the index of the MIG device on its GPU
+	Index             int
+	UUID              string
+	GPUInstanceID     int
+	ComputeInstanceID int
+}
+
+// MIGDevices contains the device minor number of a GPU and its MIG devices
+type MIGDevices struct {
+	MinorNumber int
+	Devices     []MIGDevice
+}
+
+// PCIeErrorStats contains the PCIe replay counter and the number of correctable PCIe errors of a GPU.
+// A value is only set when NVML supports it for the GPU.
+type PCIeErrorStats struct {
+	ReplayCounter              uint64
+	ReplayCounterSupported     bool
+	CorrectableErrors          uint64
+	CorrectableErrorsSupported bool
+}
+
+// PendingModeChanges contains the current and the pending ECC and MIG modes of a GPU. A pending mode differs from
+// the current mode, until the GPU is reset or the node is rebooted. The modes are only set when NVML supports them
+// for the GPU.
+type PendingModeChanges struct {
+	ECCModeSupported bool
+	ECCModeCurrent   bool
+	ECCModePending   bool
+	MIGModeSupported bool
+	MIGModeCurrent   bool
+	MIGModePending   bool
+}
+
+// GPUProcess contains the memory and the SM utilization of a process running on a GPU
+type GPUProcess struct {
+	Pid  uint32
+	Name string
+	// UsedMemory is the used GPU memory in bytes, when UsedMemorySupported is set
+	UsedMemory          uint64
+	UsedMemorySupported bool
+	// SMUtilization is the SM utilization of the latest sample in %, when SMUtilizationSampled is set
+	SMUtilization        uint32
+	SMUtilizationSampled bool
+}
+
+// GPUProcesses contains the processes running on a GPU, ordered by PID
+type GPUProcesses struct {
+	Processes []GPUProcess
+	// LastSeenTimestamp is the timestamp of the most recent utilization sample, in microseconds
+	LastSeenTimestamp uint64
+}
+
+// DeviceField is a value of a GPU, which NVML reports, also on GPUs and drivers, which DCGM returns no value for
+type DeviceField int
+
+const (
+	// FanSpeed is the fan speed in % of the maximum
+	FanSpeed DeviceField = iota
+	// EncoderUtilization is the video encoder utilization in %
+	EncoderUtilization
+	// DecoderUtilization is the video decoder utilization in %
+	DecoderUtilization
+	// PCIeReplayCounter is the number of PCIe retries
+	PCIeReplayCounter
+	// PowerUsage is the power draw in W
+	PowerUsage
+	// GPUTemperature is the GPU temperature in C
+	GPUTemperature
+	// GPUUtilization is the GPU utilization in %
+	GPUUtilization
+	// MemoryCopyUtilization is the memory copy utilization in %
+	MemoryCopyUtilization
+	// FramebufferUsed is the used framebuffer memory in MiB
+	FramebufferUsed
+	// FramebufferFree is the free framebuffer memory in MiB
+	FramebufferFree
+	// SMClock is the SM clock in MHz
+	SMClock
+	// MemoryClock is the memory clock in MHz
+	MemoryClock
+	// PersistenceMode is 1, when the persistence mode is enabled, and 0 otherwise
+	PersistenceMode
+)
+
+// GPUDevice identifies a GPU, which NVML enumerates, by its index
+type GPUDevice struct {
+	Index         uint
+	UUID          string
+	Name          string
+	Serial        string
+	PCIBusID      string
+	DriverVersion string
+}
+
 var nvmlInterface NVML
 
 // Initialize sets up the Singleton NVML interface.
@@ -161,6 +280,482 @@ func getMIGDeviceInfoForOldDriver(uuid string) (*MIGDeviceInfo, error) {
 	}, nil
 }
 
+// GetEncoderDecoderStats returns the number of active encoder sessions and the encoder and decoder
+// utilization of the GPU identified by UUID
+func (n nvmlProvider) GetEncoderDecoderStats(uuid string) (*EncoderDecoderStats, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get encoder and decoder stats; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	sessionCount, _, _, ret := device.GetEncoderStats()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	encoderUtilization, _, ret := device.GetEncoderUtilization()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	decoderUtilization, _, ret := device.GetDecoderUtilization()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	return &EncoderDecoderStats{
+		EncoderSessionCount: sessionCount,
+		EncoderUtilization:  encoderUtilization,
+		DecoderUtilization:  decoderUtilization,
+	}, nil
+}
+
+// GetProcessTypeStats returns the number of compute and graphics processes of the GPU identified by UUID
+// and their SM utilization, based on the utilization samples newer than lastSeenTimestamp
+func (n nvmlProvider) GetProcessTypeStats(uuid string, lastSeenTimestamp uint64) (*ProcessTypeStats, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get process type stats; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	computeProcesses, ret := device.GetComputeRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	graphicsProcesses, ret := device.GetGraphicsRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	samples, ret := device.GetProcessUtilization(lastSeenTimestamp)
+	if ret != nvml.SUCCESS && ret != nvml.ERROR_NOT_FOUND {
+		// ERROR_NOT_FOUND means that no process was sampled since lastSeenTimestamp
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	stats := toProcessTypeStats(computeProcesses, graphicsProcesses, samples)
+	if stats.LastSeenTimestamp == 0 {
+		stats.LastSeenTimestamp = lastSeenTimestamp
+	}
+
+	return stats, nil
+}
+
+// toProcessTypeStats sums the SM utilization of the latest sample of every process by its context types.
+func toProcessTypeStats(
+	computeProcesses, graphicsProcesses []nvml.ProcessInfo, samples []nvml.ProcessUtilizationSample,
+) *ProcessTypeStats {
+	stats := &ProcessTypeStats{
+		ComputeProcessCount:  len(computeProcesses),
+		GraphicsProcessCount: len(graphicsProcesses),
+	}
+
+	latest := map[uint32]nvml.ProcessUtilizationSample{}
+	for _, sample := range samples {
+		if sample.TimeStamp >= latest[sample.Pid].TimeStamp {
+			latest[sample.Pid] = sample
+		}
+		stats.LastSeenTimestamp = max(stats.LastSeenTimestamp, sample.TimeStamp)
+	}
+
+	for _, process := range computeProcesses {
+		stats.ComputeUtilization += latest[process.Pid].SmUtil
+	}
+	for _, process := range graphicsProcesses {
+		stats.GraphicsUtilization += latest[process.Pid].SmUtil
+	}
+
+	stats.ComputeUtilization = min(stats.ComputeUtilization, 100)
+	stats.GraphicsUtilization = min(stats.GraphicsUtilization, 100)
+
+	return stats
+}
+
+// GetPCIeErrorStats returns the PCIe replay counter and the number of correctable PCIe errors of the GPU
+// identified by UUID
+func (n nvmlProvider) GetPCIeErrorStats(uuid string) (*PCIeErrorStats, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get PCIe error stats; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	values := []nvml.FieldValue{
+		{FieldId: nvml.FI_DEV_PCIE_REPLAY_COUNTER},
+		{FieldId: nvml.FI_DEV_PCIE_COUNT_CORRECTABLE_ERRORS},
+	}
+
+	ret = device.GetFieldValues(values)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	stats := &PCIeErrorStats{}
+	stats.ReplayCounter, stats.ReplayCounterSupported = fieldValueToUint64(values[0])
+	stats.CorrectableErrors, stats.CorrectableErrorsSupported = fieldValueToUint64(values[1])
+
+	return stats, nil
+}
+
+// GetPendingModeChanges returns the current and the pending ECC and MIG modes of the GPU identified by UUID
+func (n nvmlProvider) GetPendingModeChanges(uuid string) (*PendingModeChanges, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get pending mode changes; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	modes := &PendingModeChanges{}
+
+	eccCurrent, eccPending, ret := device.GetEccMode()
+	switch ret {
+	case nvml.SUCCESS:
+		modes.ECCModeSupported = true
+		modes.ECCModeCurrent = eccCurrent == nvml.FEATURE_ENABLED
+		modes.ECCModePending = eccPending == nvml.FEATURE_ENABLED
+	case nvml.ERROR_NOT_SUPPORTED:
+	default:
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	migCurrent, migPending, ret := device.GetMigMode()
+	switch ret {
+	case nvml.SUCCESS:
+		modes.MIGModeSupported = true
+		modes.MIGModeCurrent = migCurrent == nvml.DEVICE_MIG_ENABLE
+		modes.MIGModePending = migPending == nvml.DEVICE_MIG_ENABLE
+	case nvml.ERROR_NOT_SUPPORTED:
+	default:
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	return modes, nil
+}
+
+// GetProcesses returns the compute and graphics processes running on the GPU identified by UUID, with their used
+// memory and their SM utilization, based on the utilization samples newer than lastSeenTimestamp
+func (n nvmlProvider) GetProcesses(uuid string, lastSeenTimestamp uint64) (*GPUProcesses, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get processes; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	computeProcesses, ret := device.GetComputeRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	graphicsProcesses, ret := device.GetGraphicsRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	samples, ret := device.GetProcessUtilization(lastSeenTimestamp)
+	if ret != nvml.SUCCESS && ret != nvml.ERROR_NOT_FOUND {
+		// ERROR_NOT_FOUND means that no process was sampled since lastSeenTimestamp
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	processes := toGPUProcesses(slices.Concat(computeProcesses, graphicsProcesses), samples, func(pid uint32) string {
+		name, ret := nvml.SystemGetProcessName(int(pid))
+		if ret != nvml.SUCCESS {
+			return ""
+		}
+		return name
+	})
+	if processes.LastSeenTimestamp == 0 {
+		processes.LastSeenTimestamp = lastSeenTimestamp
+	}
+
+	return processes, nil
+}
+
+// toGPUProcesses merges the processes, which may be listed with both a compute and a graphics context, and adds the
+// latest utilization sample of every process.
+func toGPUProcesses(
+	running []nvml.ProcessInfo, samples []nvml.ProcessUtilizationSample, processName func(uint32) string,
+) *GPUProcesses {
+	result := &GPUProcesses{}
+
+	latest := map[uint32]nvml.ProcessUtilizationSample{}
+	for _, sample := range samples {
+		if sample.TimeStamp >= latest[sample.Pid].TimeStamp {
+			latest[sample.Pid] = sample
+		}
+		result.LastSeenTimestamp = max(result.LastSeenTimestamp, sample.TimeStamp)
+	}
+
+	byPid := map[uint32]*GPUProcess{}
+	for _, info := range running {
+		process, exists := byPid[info.Pid]
+		if !exists {
+			process = &GPUProcess{Pid: info.Pid, Name: processName(info.Pid)}
+			if sample, sampled := latest[info.Pid]; sampled {
+				process.SMUtilization = sample.SmUtil
+				process.SMUtilizationSampled = true
+			}
+			byPid[info.Pid] = process
+		}
+		// NVML reports the memory as not available, e.g. without the permission to read it
+		if info.UsedGpuMemory != uint64(math.MaxUint64) {
+			process.UsedMemory = max(process.UsedMemory, info.UsedGpuMemory)
+			process.UsedMemorySupported = true
+		}
+	}
+
+	for _, process := range byPid {
+		result.Processes = append(result.Processes, *process)
+	}
+	slices.SortFunc(result.Processes, func(a, b GPUProcess) int {
+		return cmp.Compare(a.Pid, b.Pid)
+	})
+
+	return result
+}
+
+// fieldValueToUint64 decodes an NVML field value according to its type. It returns false, when NVML
+// couldn't read the field or the value is not an unsigned integer.
+func fieldValueToUint64(value nvml.FieldValue) (uint64, bool) {
+	if nvml.Return(value.NvmlReturn) != nvml.SUCCESS {
+		return 0, false
+	}
+
+	switch nvml.ValueType(value.ValueType) {
+	case nvml.VALUE_TYPE_UNSIGNED_INT:
+		return uint64(binary.LittleEndian.Uint32(value.Value[:4])), true
+	case nvml.VALUE_TYPE_UNSIGNED_LONG, nvml.VALUE_TYPE_UNSIGNED_LONG_LONG:
+		return binary.LittleEndian.Uint64(value.Value[:]), true
+	default:
+		return 0, false
+	}
+}
+
+// GetMIGDevices returns the device minor number and the MIG devices of the GPU identified by UUID
+func (n nvmlProvider) GetMIGDevices(uuid string) (*MIGDevices, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get MIG devices; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	minorNumber, ret := device.GetMinorNumber()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	count, ret := device.GetMaxMigDeviceCount()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	migDevices := &MIGDevices{MinorNumber: minorNumber}
+
+	for i := 0; i < count; i++ {
+		migDevice, ret := device.GetMigDeviceHandleByIndex(i)
+		if ret == nvml.ERROR_NOT_FOUND {
+			// The MIG device indexes are sparse, when instances were destroyed
+			continue
+		}
+		if ret != nvml.SUCCESS {
+			return nil, errors.New(nvml.ErrorString(ret))
+		}
+
+		migUUID, ret := migDevice.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, errors.New(nvml.ErrorString(ret))
+		}
+
+		gi, ret := migDevice.GetGpuInstanceId()
+		if ret != nvml.SUCCESS {
+			return nil, errors.New(nvml.ErrorString(ret))
+		}
+
+		ci, ret := migDevice.GetComputeInstanceId()
+		if ret != nvml.SUCCESS {
+			return nil, errors.New(nvml.ErrorString(ret))
+		}
+
+		migDevices.Devices = append(migDevices.Devices, MIGDevice{
+			Index:             i,
+			UUID:              migUUID,
+			GPUInstanceID:     gi,
+			ComputeInstanceID: ci,
+		})
+	}
+
+	return migDevices, nil
+}
+
+// GetDeviceValues returns the values of the fields of the GPU identified by UUID. Fields, which NVML doesn't support
+// for the GPU, are omitted.
+func (n nvmlProvider) GetDeviceValues(uuid string, fields []DeviceField) (map[DeviceField]float64, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get device values; err: %v", err))
+		return nil, err
+	}
+
+	device, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	values := map[DeviceField]float64{}
+	for _, field := range fields {
+		var value float64
+		switch field {
+		case FanSpeed:
+			var speed uint32
+			speed, ret = device.GetFanSpeed()
+			value = float64(speed)
+		case EncoderUtilization:
+			var utilization uint32
+			utilization, _, ret = device.GetEncoderUtilization()
+			value = float64(utilization)
+		case DecoderUtilization:
+			var utilization uint32
+			utilization, _, ret = device.GetDecoderUtilization()
+			value = float64(utilization)
+		case PCIeReplayCounter:
+			var replays int
+			replays, ret = device.GetPcieReplayCounter()
+			value = float64(replays)
+		case PowerUsage:
+			var milliwatts uint32
+			milliwatts, ret = device.GetPowerUsage()
+			value = float64(milliwatts) / 1000
+		case GPUTemperature:
+			var temperature uint32
+			temperature, ret = device.GetTemperature(nvml.TEMPERATURE_GPU)
+			value = float64(temperature)
+		case GPUUtilization, MemoryCopyUtilization:
+			var utilization nvml.Utilization
+			utilization, ret = device.GetUtilizationRates()
+			value = float64(utilization.Gpu)
+			if field == MemoryCopyUtilization {
+				value = float64(utilization.Memory)
+			}
+		case FramebufferUsed, FramebufferFree:
+			var memory nvml.Memory
+			memory, ret = device.GetMemoryInfo()
+			value = float64(memory.Used) / (1 << 20)
+			if field == FramebufferFree {
+				value = float64(memory.Free) / (1 << 20)
+			}
+		case SMClock:
+			var clock uint32
+			clock, ret = device.GetClockInfo(nvml.CLOCK_SM)
+			value = float64(clock)
+		case MemoryClock:
+			var clock uint32
+			clock, ret = device.GetClockInfo(nvml.CLOCK_MEM)
+			value = float64(clock)
+		case PersistenceMode:
+			var mode nvml.EnableState
+			mode, ret = device.GetPersistenceMode()
+			if mode == nvml.FEATURE_ENABLED {
+				value = 1
+			}
+		default:
+			continue
+		}
+
+		if ret != nvml.SUCCESS {
+			slog.Debug(fmt.Sprintf("NVML has no value of the field %d of the GPU %s; err: %s",
+				field, uuid, nvml.ErrorString(ret)))
+			continue
+		}
+		values[field] = value
+	}
+
+	return values, nil
+}
+
+// GetDevices returns the GPUs, which NVML enumerates, in the order of their NVML index. The name, the serial and the
+// PCI bus ID are left empty, when NVML doesn't report them for a GPU, e.g. the serial on GeForce boards.
+func (n nvmlProvider) GetDevices() ([]GPUDevice, error) {
+	if err := n.preCheck(); err != nil {
+		slog.Error(fmt.Sprintf("failed to get devices; err: %v", err))
+		return nil, err
+	}
+
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, errors.New(nvml.ErrorString(ret))
+	}
+
+	driverVersion, ret := nvml.SystemGetDriverVersion()
+	if ret != nvml.SUCCESS {
+		driverVersion = ""
+	}
+
+	devices := make([]GPUDevice, 0, count)
+	for i := 0; i < count; i++ {
+		device, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get the GPU %d; err: %s", i, nvml.ErrorString(ret))
+		}
+
+		uuid, ret := device.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get the UUID of the GPU %d; err: %s", i, nvml.ErrorString(ret))
+		}
+
+		gpu := GPUDevice{Index: uint(i), UUID: uuid, DriverVersion: driverVersion}
+		if name, ret := device.GetName(); ret == nvml.SUCCESS {
+			gpu.Name = name
+		}
+		if serial, ret := device.GetSerial(); ret == nvml.SUCCESS {
+			gpu.Serial = serial
+		}
+		if pciInfo, ret := device.GetPciInfo(); ret == nvml.SUCCESS {
+			gpu.PCIBusID = pciBusID(pciInfo.BusId)
+		}
+		devices = append(devices, gpu)
+	}
+
+	return devices, nil
+}
+
+// pciBusID returns the PCI bus ID, which NVML reports as a NUL-terminated C string
+func pciBusID(busID [32]int8) string {
+	var b strings.Builder
+	for _, c := range busID {
+		if c == 0 {
+			break
+		}
+		b.WriteByte(byte(c))
+	}
+	return b.String()
+}
+
 // Cleanup performs cleanup operations for the NVML provider
 func (n nvmlProvider) Cleanup() {
 	if err := n.preCheck(); err == nil {
@@ -17,8 +17,11 @@
 package nvmlprovider
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,3 +116,119 @@ func Test_newNVMLProvider(t *testing.T) {
 		})
 	}
 }
+
+func Test_toProcessTypeStats(t *testing.T) {
+	compute := []nvml.ProcessInfo{{Pid: 1}, {Pid: 3}}
+	graphics := []nvml.ProcessInfo{{Pid: 2}, {Pid: 3}}
+	samples := []nvml.ProcessUtilizationSample{
+		{Pid: 1, TimeStamp: 100, SmUtil: 10},
+		{Pid: 1, TimeStamp: 200, SmUtil: 30},
+		{Pid: 2, TimeStamp: 150, SmUtil: 20},
+		{Pid: 3, TimeStamp: 120, SmUtil: 5},
+	}
+
+	stats := toProcessTypeStats(compute, graphics, samples)
+
+	// The process with both contexts is included in both, and only the latest sample of a process counts
+	assert.Equal(t, &ProcessTypeStats{
+		ComputeProcessCount:  2,
+		GraphicsProcessCount: 2,
+		ComputeUtilization:   35,
+		GraphicsUtilization:  25,
+		LastSeenTimestamp:    200,
+	}, stats)
+}
+
+func Test_toGPUProcesses(t *testing.T) {
+	running := []nvml.ProcessInfo{
+		{Pid: 3, UsedGpuMemory: 1 << 20},
+		{Pid: 1, UsedGpuMemory: 1 << 30},
+		{Pid: 3, UsedGpuMemory: 2 << 20},
+		{Pid: 2, UsedGpuMemory: math.MaxUint64},
+	}
+	samples := []nvml.ProcessUtilizationSample{
+		{Pid: 1, TimeStamp: 100, SmUtil: 10},
+		{Pid: 1, TimeStamp: 200, SmUtil: 30},
+		{Pid: 3, TimeStamp: 120, SmUtil: 5},
+	}
+
+	processes := toGPUProcesses(running, samples, func(pid uint32) string {
+		return fmt.Sprintf("process-%d", pid)
+	})
+
+	// The process with both contexts is merged, and only the latest sample of a process counts
+	assert.Equal(t, &GPUProcesses{
+		Processes: []GPUProcess{
+			{
+				Pid: 1, Name: "process-1", UsedMemory: 1 << 30, UsedMemorySupported: true,
+				SMUtilization: 30, SMUtilizationSampled: true,
+			},
+			{Pid: 2, Name: "process-2"},
+			{
+				Pid: 3, Name: "process-3", UsedMemory: 2 << 20, UsedMemorySupported: true,
+				SMUtilization: 5, SMUtilizationSampled: true,
+			},
+		},
+		LastSeenTimestamp: 200,
+	}, processes)
+}
+
+func Test_fieldValueToUint64(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     nvml.FieldValue
+		want      uint64
+		supported bool
+	}{
+		{
+			name: "unsigned int",
+			value: nvml.FieldValue{
+				ValueType: uint32(nvml.VALUE_TYPE_UNSIGNED_INT),
+				Value:     [8]byte{42, 0, 0, 0, 0xff, 0xff, 0xff, 0xff},
+			},
+			want:      42,
+			supported: true,
+		},
+		{
+			name: "unsigned long long",
+			value: nvml.FieldValue{
+				ValueType: uint32(nvml.VALUE_TYPE_UNSIGNED_LONG_LONG),
+				Value:     [8]byte{0, 0, 0, 0, 1, 0, 0, 0},
+			},
+			want:      1 << 32,
+			supported: true,
+		},
+		{
+			name: "not supported",
+			value: nvml.FieldValue{
+				ValueType:  uint32(nvml.VALUE_TYPE_UNSIGNED_INT),
+				NvmlReturn: uint32(nvml.ERROR_NOT_SUPPORTED),
+				Value:      [8]byte{42},
+			},
+		},
+		{
+			name: "double",
+			value: nvml.FieldValue{
+				ValueType: uint32(nvml.VALUE_TYPE_DOUBLE),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, supported := fieldValueToUint64(tt.value)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.supported, supported)
+		})
+	}
+}
+
+func Test_pciBusID(t *testing.T) {
+	var busID [32]int8
+	for i, c := range "00000000:01:00.0" {
+		busID[i] = int8(c)
+	}
+
+	assert.Equal(t, "00000000:01:00.0", pciBusID(busID))
+	assert.Equal(t, "", pciBusID([32]int8{}))
+}
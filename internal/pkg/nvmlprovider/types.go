@@ -20,5 +20,13 @@ package nvmlprovider
 
 type NVML interface {
 	GetMIGDeviceInfoByID(string) (*MIGDeviceInfo, error)
+	GetEncoderDecoderStats(string) (*EncoderDecoderStats, error)
+	GetMIGDevices(string) (*MIGDevices, error)
+	GetProcessTypeStats(string, uint64) (*ProcessTypeStats, error)
+	GetPCIeErrorStats(string) (*PCIeErrorStats, error)
+	GetPendingModeChanges(string) (*PendingModeChanges, error)
+	GetProcesses(string, uint64) (*GPUProcesses, error)
+	GetDeviceValues(string, []DeviceField) (map[DeviceField]float64, error)
+	GetDevices() ([]GPUDevice, error)
 	Cleanup()
 }
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"math"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the OTLP messages, see
+// https://github.com/open-telemetry/opentelemetry-proto/tree/main/opentelemetry/proto
+const (
+	exportRequestResourceMetrics protowire.Number = 1
+
+	resourceMetricsResource     protowire.Number = 1
+	resourceMetricsScopeMetrics protowire.Number = 2
+	resourceAttributes          protowire.Number = 1
+
+	scopeMetricsScope   protowire.Number = 1
+	scopeMetricsMetrics protowire.Number = 2
+	scopeName           protowire.Number = 1
+	scopeVersion        protowire.Number = 2
+
+	metricName        protowire.Number = 1
+	metricDescription protowire.Number = 2
+	metricGauge       protowire.Number = 5
+	metricSum         protowire.Number = 7
+
+	dataPoints                protowire.Number = 1
+	sumAggregationTemporality protowire.Number = 2
+	sumIsMonotonic            protowire.Number = 3
+
+	dataPointStartTime  protowire.Number = 2
+	dataPointTime       protowire.Number = 3
+	dataPointAsDouble   protowire.Number = 4
+	dataPointAttributes protowire.Number = 7
+
+	keyValueKey    protowire.Number = 1
+	keyValueValue  protowire.Number = 2
+	anyValueString protowire.Number = 1
+
+	aggregationTemporalityCumulative = 2
+)
+
+const instrumentationScope = "github.com/NVIDIA/dcgm-exporter"
+
+// Attribute is a string attribute of the resource or of a data point.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// encodeRequest encodes the metric families as an ExportMetricsServiceRequest of a single resource and of the
+// exporter's scope. Counters are exported as cumulative monotonic sums, which started at start, and all other types as
+// gauges.
+func encodeRequest(
+	families []*dto.MetricFamily, resource []Attribute, version string, start, now time.Time,
+) []byte {
+	var resourceMsg []byte
+	for _, attribute := range resource {
+		resourceMsg = appendKeyValue(resourceMsg, resourceAttributes, attribute.Key, attribute.Value)
+	}
+
+	var scopeMetrics []byte
+	var scopeMsg []byte
+	scopeMsg = appendString(scopeMsg, scopeName, instrumentationScope)
+	scopeMsg = appendString(scopeMsg, scopeVersion, version)
+	scopeMetrics = appendMessage(scopeMetrics, scopeMetricsScope, scopeMsg)
+	for _, family := range families {
+		scopeMetrics = appendMetric(scopeMetrics, family, start, now)
+	}
+
+	var resourceMetrics []byte
+	resourceMetrics = appendMessage(resourceMetrics, resourceMetricsResource, resourceMsg)
+	resourceMetrics = appendMessage(resourceMetrics, resourceMetricsScopeMetrics, scopeMetrics)
+
+	return appendMessage(nil, exportRequestResourceMetrics, resourceMetrics)
+}
+
+func appendMetric(b []byte, family *dto.MetricFamily, start, now time.Time) []byte {
+	sum := family.GetType() == dto.MetricType_COUNTER
+
+	var points []byte
+	for _, m := range family.GetMetric() {
+		value, ok := metricValue(family.GetType(), m)
+		if !ok {
+			continue
+		}
+
+		var point []byte
+		for _, label := range m.GetLabel() {
+			point = appendKeyValue(point, dataPointAttributes, label.GetName(), label.GetValue())
+		}
+		if sum {
+			point = appendFixed64(point, dataPointStartTime, uint64(start.UnixNano()))
+		}
+		point = appendFixed64(point, dataPointTime, uint64(now.UnixNano()))
+		point = appendFixed64(point, dataPointAsDouble, math.Float64bits(value))
+		points = appendMessage(points, dataPoints, point)
+	}
+
+	var metric []byte
+	metric = appendString(metric, metricName, family.GetName())
+	metric = appendString(metric, metricDescription, family.GetHelp())
+	if sum {
+		points = protowire.AppendTag(points, sumAggregationTemporality, protowire.VarintType)
+		points = protowire.AppendVarint(points, aggregationTemporalityCumulative)
+		points = protowire.AppendTag(points, sumIsMonotonic, protowire.VarintType)
+		points = protowire.AppendVarint(points, 1)
+		metric = appendMessage(metric, metricSum, points)
+	} else {
+		metric = appendMessage(metric, metricGauge, points)
+	}
+
+	return appendMessage(b, scopeMetricsMetrics, metric)
+}
+
+// metricValue returns the value of a gauge, counter or untyped metric. Summaries and histograms are not rendered by
+// the exporter.
+func metricValue(metricType dto.MetricType, m *dto.Metric) (float64, bool) {
+	switch metricType {
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue(), m.GetGauge() != nil
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue(), m.GetCounter() != nil
+	case dto.MetricType_UNTYPED:
+		return m.GetUntyped().GetValue(), m.GetUntyped() != nil
+	}
+	return 0, false
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func appendKeyValue(b []byte, num protowire.Number, key, value string) []byte {
+	var kv []byte
+	kv = appendString(kv, keyValueKey, key)
+	kv = appendMessage(kv, keyValueValue, appendString(nil, anyValueString, value))
+	return appendMessage(b, num, kv)
+}
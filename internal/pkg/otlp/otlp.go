@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package otlp pushes the exported metrics to an OpenTelemetry collector over OTLP/gRPC or OTLP/HTTP.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Protocols are the supported OTLP protocols.
+var Protocols = []string{ProtocolGRPC, ProtocolHTTP}
+
+var exports = selfmetrics.Default().Counter("dcgm_exporter_otlp_exports_total",
+	"Number of pushes to the OpenTelemetry collector by result.")
+
+// MetricsWriter writes the exported metrics in the Prometheus text format.
+type MetricsWriter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// Options configure the Exporter.
+type Options struct {
+	Endpoint string
+	Protocol string
+	Interval time.Duration
+	// Resource are the attributes of the resource as <key>=<value>, which override the service.name and
+	// service.version attributes
+	Resource []string
+	Version  string
+}
+
+// Exporter pushes the metrics rendered at /metrics to an OpenTelemetry collector. The metric names are the names of
+// the counters, and the labels of the entities and of the pods become attributes of the data points.
+type Exporter struct {
+	sender   sender
+	interval time.Duration
+	resource []Attribute
+	version  string
+	start    time.Time
+}
+
+func New(opts Options) (*Exporter, error) {
+	attributes, err := ParseAttributes(opts.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := newSender(opts.Protocol, opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := []Attribute{{Key: "service.name", Value: "dcgm-exporter"}, {Key: "service.version", Value: opts.Version}}
+	for _, attribute := range attributes {
+		resource = slices.DeleteFunc(resource, func(a Attribute) bool { return a.Key == attribute.Key })
+		resource = append(resource, attribute)
+	}
+
+	return &Exporter{
+		sender:   sender,
+		interval: opts.Interval,
+		resource: resource,
+		version:  opts.Version,
+		start:    time.Now(),
+	}, nil
+}
+
+// ParseAttributes parses attributes given as <key>=<value>.
+func ParseAttributes(values []string) ([]Attribute, error) {
+	var attributes []Attribute
+	for _, value := range values {
+		key, val, found := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid attribute '%s', expected <key>=<value>", value)
+		}
+		attributes = append(attributes, Attribute{Key: key, Value: strings.TrimSpace(val)})
+	}
+	return attributes, nil
+}
+
+// Run pushes the metrics every interval, until the context is done.
+func (e *Exporter) Run(ctx context.Context, mw MetricsWriter) {
+	defer func() {
+		if err := e.sender.close(); err != nil {
+			slog.Warn("Failed to close the OTLP client", slog.String(logging.ErrorKey, err.Error()))
+		}
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pushCtx, cancel := context.WithTimeout(ctx, e.interval)
+		err := e.Push(pushCtx, mw)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to push the metrics to the OpenTelemetry collector",
+				slog.String(logging.ErrorKey, err.Error()))
+		}
+	}
+}
+
+// Push renders the metrics and sends them to the collector.
+func (e *Exporter) Push(ctx context.Context, mw MetricsWriter) error {
+	err := e.push(ctx, mw)
+	if err != nil {
+		exports.Inc("result", "failure")
+		return err
+	}
+	exports.Inc("result", "success")
+	return nil
+}
+
+func (e *Exporter) push(ctx context.Context, mw MetricsWriter) error {
+	var buf bytes.Buffer
+	if err := mw.WriteMetrics(&buf); err != nil {
+		return fmt.Errorf("failed to render the metrics; err: %w", err)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(&buf)
+	if err != nil {
+		return fmt.Errorf("failed to parse the rendered metrics; err: %w", err)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	sorted := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, families[name])
+	}
+
+	err = e.sender.send(ctx, encodeRequest(sorted, e.resource, e.version, e.start, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to send the metrics; err: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const testMetrics = `# HELP DCGM_FI_DEV_GPU_TEMP GPU temperature (in C).
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000",pod="gpu-pod"} 42
+# HELP DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION Total energy consumption since boot (in mJ).
+# TYPE DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION counter
+DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000"} 1000
+`
+
+type fakeMetricsWriter string
+
+func (f fakeMetricsWriter) WriteMetrics(w io.Writer) error {
+	_, err := io.WriteString(w, string(f))
+	return err
+}
+
+// testMetric is the part of a decoded OTLP metric, which is checked by the tests.
+type testMetric struct {
+	sum        bool
+	value      float64
+	attributes map[string]string
+}
+
+// fields returns the values of the length-delimited fields of a message by field number.
+func fields(t *testing.T, msg []byte) map[protowire.Number][][]byte {
+	t.Helper()
+
+	result := map[protowire.Number][][]byte{}
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		require.GreaterOrEqual(t, n, 0)
+		msg = msg[n:]
+		if typ == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(msg)
+			require.GreaterOrEqual(t, n, 0)
+			result[num] = append(result[num], value)
+			msg = msg[n:]
+			continue
+		}
+		if typ == protowire.Fixed64Type {
+			value, n := protowire.ConsumeFixed64(msg)
+			require.GreaterOrEqual(t, n, 0)
+			result[num] = append(result[num], protowire.AppendFixed64(nil, value))
+			msg = msg[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, msg)
+		require.GreaterOrEqual(t, n, 0)
+		msg = msg[n:]
+	}
+	return result
+}
+
+func attributes(t *testing.T, keyValues [][]byte) map[string]string {
+	result := map[string]string{}
+	for _, kv := range keyValues {
+		f := fields(t, kv)
+		result[string(f[keyValueKey][0])] = string(fields(t, f[keyValueValue][0])[anyValueString][0])
+	}
+	return result
+}
+
+// decodeRequest decodes the resource attributes and the metrics of an ExportMetricsServiceRequest.
+func decodeRequest(t *testing.T, request []byte) (map[string]string, map[string]testMetric) {
+	t.Helper()
+
+	resourceMetrics := fields(t, fields(t, request)[exportRequestResourceMetrics][0])
+	resource := attributes(t, fields(t, resourceMetrics[resourceMetricsResource][0])[resourceAttributes])
+
+	metrics := map[string]testMetric{}
+	scopeMetrics := fields(t, resourceMetrics[resourceMetricsScopeMetrics][0])
+	assert.Equal(t, instrumentationScope, string(fields(t, scopeMetrics[scopeMetricsScope][0])[scopeName][0]))
+	for _, msg := range scopeMetrics[scopeMetricsMetrics] {
+		metric := fields(t, msg)
+		data, sum := metric[metricSum]
+		if !sum {
+			data = metric[metricGauge]
+		}
+		point := fields(t, fields(t, data[0])[dataPoints][0])
+		value, _ := protowire.ConsumeFixed64(point[dataPointAsDouble][0])
+		metrics[string(metric[metricName][0])] = testMetric{
+			sum:        sum,
+			value:      math.Float64frombits(value),
+			attributes: attributes(t, point[dataPointAttributes]),
+		}
+	}
+	return resource, metrics
+}
+
+func assertTestMetrics(t *testing.T, request []byte) {
+	t.Helper()
+
+	resource, metrics := decodeRequest(t, request)
+	assert.Equal(t, map[string]string{
+		"service.name":     "dcgm-exporter",
+		"service.version":  "4.0.0",
+		"k8s.cluster.name": "prod",
+	}, resource)
+	assert.Equal(t, map[string]testMetric{
+		"DCGM_FI_DEV_GPU_TEMP": {
+			value: 42,
+			attributes: map[string]string{
+				"gpu": "0", "UUID": "GPU-00000000-0000-0000-0000-000000000000", "pod": "gpu-pod",
+			},
+		},
+		"DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION": {
+			sum:        true,
+			value:      1000,
+			attributes: map[string]string{"gpu": "0", "UUID": "GPU-00000000-0000-0000-0000-000000000000"},
+		},
+	}, metrics)
+}
+
+func TestExporter_PushHTTP(t *testing.T) {
+	var (
+		request     []byte
+		path        string
+		contentType string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		request, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Options{
+		Endpoint: server.URL,
+		Protocol: ProtocolHTTP,
+		Interval: time.Second,
+		Resource: []string{"k8s.cluster.name=prod"},
+		Version:  "4.0.0",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Push(context.Background(), fakeMetricsWriter(testMetrics)))
+	assert.Equal(t, defaultHTTPPath, path)
+	assert.Equal(t, "application/x-protobuf", contentType)
+	assertTestMetrics(t, request)
+}
+
+func TestExporter_PushHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := New(Options{Endpoint: server.URL, Protocol: ProtocolHTTP, Interval: time.Second})
+	require.NoError(t, err)
+
+	err = exporter.Push(context.Background(), fakeMetricsWriter(testMetrics))
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestExporter_PushGRPC(t *testing.T) {
+	var (
+		request []byte
+		method  string
+	)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			method, _ = grpc.MethodFromServerStream(stream)
+			if err := stream.RecvMsg(&request); err != nil {
+				return err
+			}
+			return stream.SendMsg(&[]byte{})
+		}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	exporter, err := New(Options{
+		Endpoint: "http://" + listener.Addr().String(),
+		Protocol: ProtocolGRPC,
+		Interval: time.Second,
+		Resource: []string{"k8s.cluster.name=prod"},
+		Version:  "4.0.0",
+	})
+	require.NoError(t, err)
+	defer exporter.sender.close()
+
+	require.NoError(t, exporter.Push(context.Background(), fakeMetricsWriter(testMetrics)))
+	assert.Equal(t, exportMethod, method)
+	assertTestMetrics(t, request)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	_, err := New(Options{Endpoint: "otel-collector:4317", Protocol: ProtocolGRPC})
+	assert.ErrorContains(t, err, "invalid endpoint")
+
+	_, err = New(Options{Endpoint: "http://otel-collector:4318", Protocol: ProtocolHTTP, Resource: []string{"prod"}})
+	assert.ErrorContains(t, err, "invalid attribute")
+}
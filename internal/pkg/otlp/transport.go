@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// exportMethod is the method of the OTLP metrics service.
+	exportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	// defaultHTTPPath is the path of the OTLP/HTTP metrics endpoint.
+	defaultHTTPPath = "/v1/metrics"
+)
+
+// sender sends encoded ExportMetricsServiceRequests to the collector.
+type sender interface {
+	send(ctx context.Context, request []byte) error
+	close() error
+}
+
+// newSender returns the sender of the protocol. The endpoint is the URL of the collector; the https scheme enables
+// TLS for both protocols.
+func newSender(protocol, endpoint string) (sender, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint '%s', expected http(s)://<host>:<port>", endpoint)
+	}
+
+	switch protocol {
+	case ProtocolGRPC:
+		creds := insecure.NewCredentials()
+		if u.Scheme == "https" {
+			creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		}
+		conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the gRPC client of '%s'; err: %w", endpoint, err)
+		}
+		return &grpcSender{conn: conn}, nil
+	case ProtocolHTTP:
+		if u.Path == "" || u.Path == "/" {
+			u.Path = defaultHTTPPath
+		}
+		return &httpSender{client: &http.Client{}, url: u.String()}, nil
+	}
+	return nil, fmt.Errorf("unsupported protocol '%s'", protocol)
+}
+
+// grpcSender calls the Export method of the OTLP metrics service with the encoded requests.
+type grpcSender struct {
+	conn *grpc.ClientConn
+}
+
+func (s *grpcSender) send(ctx context.Context, request []byte) error {
+	var response []byte
+	return s.conn.Invoke(ctx, exportMethod, &request, &response, grpc.ForceCodec(rawCodec{}))
+}
+
+func (s *grpcSender) close() error {
+	return s.conn.Close()
+}
+
+// rawCodec passes the messages, which are already encoded, through as they are.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// httpSender posts the encoded requests to the OTLP/HTTP endpoint.
+type httpSender struct {
+	client *http.Client
+	url    string
+}
+
+func (s *httpSender) send(ctx context.Context, request []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *httpSender) close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
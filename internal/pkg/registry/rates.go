@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+type rateSample struct {
+	value     float64
+	timestamp time.Time
+}
+
+type rateSeries struct {
+	last rateSample
+	rate string
+	// gathering is the number of the latest gathering, which included the series
+	gathering uint64
+}
+
+// rateTracker computes the per-second rates of the counters with a rate view from the timestamps of their samples.
+// The rate of a sample is computed once, so gathering the same sample again, e.g. for several scrapes, returns the
+// same rate.
+type rateTracker struct {
+	series    map[string]*rateSeries
+	gathering uint64
+	mtx       sync.Mutex
+}
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{series: map[string]*rateSeries{}}
+}
+
+// update sets the rates of the gathered metrics. The metrics without a sample timestamp are timed by the gathering.
+// After a complete gathering, the series, which it didn't include, are forgotten.
+func (r *rateTracker) update(metrics MetricsByCounterGroup, gatheredAt time.Time, complete bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.gathering++
+
+	for group, metricsByCounter := range metrics {
+		for counter, values := range metricsByCounter {
+			if !hasRateView(counter.ExpandViews()) {
+				continue
+			}
+
+			for i := range values {
+				ts := values[i].Timestamp
+				if ts.IsZero() {
+					ts = gatheredAt
+				}
+				values[i].Rate = r.observe(seriesKey(group, values[i]), values[i].Value, ts)
+			}
+		}
+	}
+
+	if complete {
+		for key, series := range r.series {
+			if series.gathering != r.gathering {
+				delete(r.series, key)
+			}
+		}
+	}
+}
+
+// observe records the sample and returns the per-second rate since the previous sample. The rate is empty for the
+// first sample, on counter reset and for non-numeric values.
+func (r *rateTracker) observe(key, value string, ts time.Time) string {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ""
+	}
+
+	series, exists := r.series[key]
+	if !exists {
+		r.series[key] = &rateSeries{last: rateSample{value: v, timestamp: ts}, gathering: r.gathering}
+		return ""
+	}
+	series.gathering = r.gathering
+
+	// The sample was observed already, or is older than the latest one
+	if !ts.After(series.last.timestamp) {
+		return series.rate
+	}
+
+	series.rate = ""
+	if v >= series.last.value {
+		rate := (v - series.last.value) / ts.Sub(series.last.timestamp).Seconds()
+		series.rate = strconv.FormatFloat(rate, 'f', -1, 64)
+	}
+	series.last = rateSample{value: v, timestamp: ts}
+
+	return series.rate
+}
+
+func hasRateView(views []counters.View) bool {
+	for _, view := range views {
+		if view.Rate {
+			return true
+		}
+	}
+	return false
+}
+
+func seriesKey(group dcgm.Field_Entity_Group, m collector.Metric) string {
+	return fmt.Sprintf("%d/%s/%s/%s/%s/%s/%s/%s/%s", group, m.Counter.FieldName, m.GPU, m.GPUUUID, m.GPUDevice,
+		m.GPUInstanceID, m.ComputeInstanceID, m.VGPUID, m.Hostname)
+}
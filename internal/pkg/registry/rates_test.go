@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	collectorpkg "github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestRateTracker_Update(t *testing.T) {
+	energy := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION,
+		FieldName: "DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION",
+		PromType:  "counter",
+		Views:     "rate",
+	}
+	plain := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	gathered := func(gpu, value string, ts time.Time) MetricsByCounterGroup {
+		return MetricsByCounterGroup{
+			dcgm.FE_GPU: {
+				energy: {{GPU: gpu, Counter: energy, Value: value, Timestamp: ts}},
+				plain:  {{GPU: gpu, Counter: plain, Value: value, Timestamp: ts}},
+			},
+		}
+	}
+	rate := func(metrics MetricsByCounterGroup) string {
+		return metrics[dcgm.FE_GPU][energy][0].Rate
+	}
+
+	tracker := newRateTracker()
+
+	first := gathered("0", "1000", start)
+	tracker.update(first, start, true)
+	assert.Empty(t, rate(first), "the rate requires two samples")
+
+	second := gathered("0", "1500", start.Add(10*time.Second))
+	tracker.update(second, start.Add(30*time.Second), true)
+	assert.Equal(t, "50", rate(second), "the rate is computed from the sample timestamps")
+	assert.Empty(t, second[dcgm.FE_GPU][plain][0].Rate, "counters without a rate view get no rate")
+
+	// Another consumer gathering the same sample gets the same rate
+	again := gathered("0", "1500", start.Add(10*time.Second))
+	tracker.update(again, start.Add(31*time.Second), true)
+	assert.Equal(t, "50", rate(again))
+
+	reset := gathered("0", "10", start.Add(20*time.Second))
+	tracker.update(reset, start.Add(40*time.Second), true)
+	assert.Empty(t, rate(reset), "counter reset must not produce a negative rate")
+
+	// A partial gathering keeps the series, which it didn't include
+	tracker.update(gathered("1", "0", start.Add(30*time.Second)), start.Add(50*time.Second), false)
+	assert.Len(t, tracker.series, 2)
+
+	// A complete gathering forgets them
+	tracker.update(gathered("1", "0", start.Add(40*time.Second)), start.Add(60*time.Second), true)
+	require.Len(t, tracker.series, 1)
+	assert.Contains(t, tracker.series, seriesKey(dcgm.FE_GPU, collectorpkg.Metric{GPU: "1", Counter: energy}))
+}
+
+func TestRateTracker_Update_WithoutTimestamps(t *testing.T) {
+	counter := counters.Counter{FieldName: "DCGM_EXP_TEST", PromType: "counter", Views: "rate"}
+	gathered := func(value string) MetricsByCounterGroup {
+		return MetricsByCounterGroup{dcgm.FE_GPU: {counter: {{GPU: "0", Counter: counter, Value: value}}}}
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newRateTracker()
+	tracker.update(gathered("0"), start, true)
+
+	metrics := gathered("20")
+	tracker.update(metrics, start.Add(4*time.Second), true)
+	assert.Equal(t, "5", metrics[dcgm.FE_GPU][counter][0].Rate, "the samples are timed by the gathering")
+}
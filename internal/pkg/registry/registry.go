@@ -17,27 +17,62 @@
 package registry
 
 import (
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
-	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 )
 
-// groupCounterTuple represents a composite key, that consists Group and Counter.
-// The groupCounterTuple is necessary to maintain uniqueness of Group and Counter pairs.
-type groupCounterTuple struct {
-	Group   dcgm.Field_Entity_Group
-	Counter counters.Counter
+var staleResults = selfmetrics.Default().Counter("dcgm_exporter_stale_collector_results_total",
+	"Number of times the metrics of a collector, which did not finish within the deadline, were served from its "+
+		"latest gathering instead.")
+
+// MaxStalenessIntervals is the number of deadlines, for which the consumers of GatherWithin republish the metrics of a
+// collector, which does not finish within the deadline, from its latest gathering.
+const MaxStalenessIntervals = 3
+
+// collectorKey identifies a registered collector by its group and its position in the group.
+type collectorKey struct {
+	group dcgm.Field_Entity_Group
+	index int
+}
+
+// gathering is the latest result of a collector.
+type gathering struct {
+	metrics collector.MetricsByCounter
+	time    time.Time
+}
+
+// run is a gathering of a collector in flight. done is closed, once metrics and err are set.
+type run struct {
+	key     collectorKey
+	done    chan struct{}
+	metrics collector.MetricsByCounter
+	err     error
 }
 
 type Registry struct {
 	collectorGroups     map[dcgm.Field_Entity_Group][]collector.Collector
 	collectorGroupsSeen map[collector.EntityCollectorTuple]struct{}
+	workers             int
 	mtx                 sync.RWMutex
+
+	// The gatherings in flight, which the other callers wait for instead of starting the collectors again, and the
+	// latest results of the collectors; they are updated by the collectors finishing after GatherWithin returned, so
+	// they have their own mutex
+	running    map[collectorKey]*run
+	latest     map[collectorKey]gathering
+	runningMtx sync.Mutex
+	// inFlight counts the gatherings in flight, so that Cleanup waits for them
+	inFlight sync.WaitGroup
+
+	// rates keeps the previous samples of the counters with a rate view, per registry, so that the rates don't
+	// depend on how many consumers gather the metrics
+	rates *rateTracker
 }
 
 // NewRegistry creates a new registry
@@ -45,11 +80,17 @@ func NewRegistry() *Registry {
 	return &Registry{
 		collectorGroups:     map[dcgm.Field_Entity_Group][]collector.Collector{},
 		collectorGroupsSeen: map[collector.EntityCollectorTuple]struct{}{},
+		running:             map[collectorKey]*run{},
+		latest:              map[collectorKey]gathering{},
+		rates:               newRateTracker(),
 	}
 }
 
 // Register registers a collector with the registry.
 func (r *Registry) Register(entityCollectorTuples collector.EntityCollectorTuple) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
 	if _, exists := r.collectorGroupsSeen[entityCollectorTuples]; exists {
 		return
 	}
@@ -58,60 +99,187 @@ func (r *Registry) Register(entityCollectorTuples collector.EntityCollectorTuple
 	r.collectorGroupsSeen[entityCollectorTuples] = struct{}{}
 }
 
-// Gather gathers metrics from all registered collectors.
-func (r *Registry) Gather() (MetricsByCounterGroup, error) {
+// Gather gathers metrics from the registered collectors of the given entity groups, or of all groups when
+// none is given. The collectors, which are gathering already, e.g. for GatherWithin, are waited for instead of being
+// started again, so that a collector never gathers concurrently.
+func (r *Registry) Gather(groups ...dcgm.Field_Entity_Group) (MetricsByCounterGroup, error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	var wg sync.WaitGroup
+	runs := r.startAll(groups)
+
+	output := MetricsByCounterGroup{}
+	for _, rn := range runs {
+		<-rn.done
+		if rn.err != nil {
+			return nil, rn.err
+		}
+		output.add(rn.key.group, rn.metrics)
+	}
+
+	r.rates.update(output, time.Now(), len(groups) == 0)
 
-	g := new(errgroup.Group)
+	return output, nil
+}
+
+// GatherWithin gathers metrics from the registered collectors of the given entity groups, or of all groups when none
+// is given, like Gather, but returns at the deadline, so a slow collector doesn't delay the metrics of the others. The
+// collectors, which are gathering already, are waited for up to the deadline, instead of being started again. The
+// collectors, which did not finish, keep running in the background. Their metrics from their latest gathering, which
+// is not older than maxStaleness, are returned instead, and counted by the dcgm_exporter_stale_collector_results_total
+// self-metric, so that the series stay the same. The number of collectors, which did not finish, is returned as well.
+func (r *Registry) GatherWithin(
+	deadline, maxStaleness time.Duration, groups ...dcgm.Field_Entity_Group,
+) (MetricsByCounterGroup, int, error) {
+	// The gatherings are only started under the lock, so it isn't held until the deadline
+	r.mtx.RLock()
+	runs := r.startAll(groups)
+	r.mtx.RUnlock()
 
-	var sm sync.Map
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	output := MetricsByCounterGroup{}
+	var pending []collectorKey
+	expired := false
+	for _, rn := range runs {
+		if !expired {
+			select {
+			case <-rn.done:
+			case <-timer.C:
+				expired = true
+			}
+		}
+
+		select {
+		case <-rn.done:
+			if rn.err != nil {
+				return nil, 0, rn.err
+			}
+			output.add(rn.key.group, rn.metrics)
+		default:
+			pending = append(pending, rn.key)
+		}
+	}
+
+	r.runningMtx.Lock()
+	defer r.runningMtx.Unlock()
+	for _, key := range pending {
+		if latest, exists := r.latest[key]; exists && time.Since(latest.time) <= maxStaleness {
+			output.add(key.group, latest.metrics)
+			staleResults.Inc("group", key.group.String())
+		}
+	}
+
+	r.rates.update(output, time.Now(), len(groups) == 0)
+
+	return output, len(pending), nil
+}
+
+// startAll starts gathering the collectors of the given entity groups, or of all groups when none is given, which
+// are not gathering already, and returns the gatherings of all of them. The caller holds r.mtx.
+func (r *Registry) startAll(groups []dcgm.Field_Entity_Group) []*run {
+	var sem chan struct{}
+	if r.workers > 0 {
+		sem = make(chan struct{}, r.workers)
+	}
 
+	r.runningMtx.Lock()
+	defer r.runningMtx.Unlock()
+
+	var runs []*run
 	for group, collectors := range r.collectorGroups {
-		for _, c := range collectors {
-			c := c // creates new c, see https://golang.org/doc/faq#closures_and_goroutines
-			group := group
-			wg.Add(1)
-			g.Go(func() error {
-				metrics, err := c.GetMetrics()
-				if err != nil {
-					return err
-				}
-
-				for counter, metricVals := range metrics {
-					val, _ := sm.LoadOrStore(groupCounterTuple{Group: group, Counter: counter}, []collector.Metric{})
-					out := val.([]collector.Metric)
-					out = append(out, metricVals...)
-					sm.Store(groupCounterTuple{Group: group, Counter: counter}, out)
-				}
-
-				return nil
-			})
+		if len(groups) > 0 && !slices.Contains(groups, group) {
+			continue
+		}
+
+		for i, c := range collectors {
+			key := collectorKey{group: group, index: i}
+			rn, running := r.running[key]
+			if !running {
+				rn = r.start(key, c, sem)
+			}
+			runs = append(runs, rn)
 		}
 	}
+	return runs
+}
+
+// start gathers the collector in the background. The caller holds r.runningMtx.
+func (r *Registry) start(key collectorKey, c collector.Collector, sem chan struct{}) *run {
+	rn := &run{key: key, done: make(chan struct{})}
+	r.running[key] = rn
+
+	r.inFlight.Add(1)
+	go func() {
+		defer r.inFlight.Done()
+
+		if sem != nil {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+		}
 
-	if err := g.Wait(); err != nil {
-		return nil, err
+		metrics, err := c.GetMetrics()
+
+		r.runningMtx.Lock()
+		rn.metrics, rn.err = metrics, err
+		delete(r.running, key)
+		if err == nil {
+			r.latest[key] = gathering{metrics: metrics, time: time.Now()}
+		}
+		r.runningMtx.Unlock()
+		close(rn.done)
+	}()
+
+	return rn
+}
+
+// add appends the metrics to the group.
+func (m MetricsByCounterGroup) add(group dcgm.Field_Entity_Group, metrics collector.MetricsByCounter) {
+	if _, exists := m[group]; !exists {
+		m[group] = collector.MetricsByCounter{}
 	}
 
-	output := MetricsByCounterGroup{}
+	for counter, values := range metrics {
+		m[group][counter] = append(m[group][counter], values...)
+	}
+}
 
-	sm.Range(func(key, value interface{}) bool {
-		tuple := key.(groupCounterTuple)
-		if _, exists := output[tuple.Group]; !exists {
-			output[tuple.Group] = map[counters.Counter][]collector.Metric{}
+// SetWorkers limits the number of collectors gathering metrics concurrently. Zero means no limit.
+func (r *Registry) SetWorkers(workers int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.workers = workers
+}
+
+// SetCollectInterval changes the update frequency, in milliseconds, of the fields watched by registered collectors.
+func (r *Registry) SetCollectInterval(collectInterval int64) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, collectors := range r.collectorGroups {
+		for _, c := range collectors {
+			setter, ok := c.(collector.IntervalSetter)
+			if !ok {
+				continue
+			}
+
+			if err := setter.SetCollectInterval(collectInterval); err != nil {
+				return err
+			}
 		}
-		output[tuple.Group][tuple.Counter] = value.([]collector.Metric)
-		return true // continue iteration
-	})
+	}
 
-	return output, nil
+	return nil
 }
 
-// Cleanup resources of registered collectors
+// Cleanup resources of registered collectors, after the collectors, which GatherWithin left running, finished.
 func (r *Registry) Cleanup() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.inFlight.Wait()
 	for _, collectors := range r.collectorGroups {
 		for _, c := range collectors {
 			c.Cleanup()
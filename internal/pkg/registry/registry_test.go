@@ -18,7 +18,9 @@ package registry
 
 import (
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -28,6 +30,7 @@ import (
 
 	collectorpkg "github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 )
 
 type mockCollector struct {
@@ -43,6 +46,15 @@ func (m *mockCollector) Cleanup() {
 	m.Called()
 }
 
+type mockIntervalCollector struct {
+	mockCollector
+}
+
+func (m *mockIntervalCollector) SetCollectInterval(collectInterval int64) error {
+	args := m.Called(collectInterval)
+	return args.Error(0)
+}
+
 func TestRegistry_Gather(t *testing.T) {
 	collector := new(mockCollector)
 
@@ -139,3 +151,271 @@ func TestRegistry_Register_Accepts_Duplicates_(t *testing.T) {
 	assert.Len(t, reg.collectorGroups, 1)
 	assert.Len(t, reg.collectorGroupsSeen, 1)
 }
+
+func TestRegistry_SetCollectInterval(t *testing.T) {
+	reg := NewRegistry()
+
+	intervalCollector := new(mockIntervalCollector)
+	intervalCollector.On("SetCollectInterval", int64(15000)).Return(nil).Once()
+
+	tuple1 := collectorpkg.EntityCollectorTuple{}
+	tuple1.SetEntity(dcgm.FE_GPU)
+	tuple1.SetCollector(intervalCollector)
+	reg.Register(tuple1)
+
+	// Collectors, which cannot change the interval, are skipped
+	tuple2 := collectorpkg.EntityCollectorTuple{}
+	tuple2.SetEntity(dcgm.FE_SWITCH)
+	tuple2.SetCollector(new(mockCollector))
+	reg.Register(tuple2)
+
+	require.NoError(t, reg.SetCollectInterval(15000))
+	intervalCollector.AssertExpectations(t)
+
+	intervalCollector.On("SetCollectInterval", int64(5000)).Return(errors.New("Boom!")).Once()
+	require.Error(t, reg.SetCollectInterval(5000))
+}
+
+func TestRegistry_Gather_Groups(t *testing.T) {
+	reg := NewRegistry()
+
+	counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	gpuCollector := new(mockCollector)
+	gpuCollector.On("GetMetrics").Return(collectorpkg.MetricsByCounter{
+		counter: {{GPU: "0", Counter: counter, Attributes: map[string]string{}}},
+	}, nil)
+
+	// The collector of the excluded group must not be called
+	linkCollector := new(mockCollector)
+
+	gpuTuple := collectorpkg.EntityCollectorTuple{}
+	gpuTuple.SetEntity(dcgm.FE_GPU)
+	gpuTuple.SetCollector(gpuCollector)
+	reg.Register(gpuTuple)
+
+	linkTuple := collectorpkg.EntityCollectorTuple{}
+	linkTuple.SetEntity(dcgm.FE_LINK)
+	linkTuple.SetCollector(linkCollector)
+	reg.Register(linkTuple)
+
+	got, err := reg.Gather(dcgm.FE_GPU)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Contains(t, got, dcgm.FE_GPU)
+	linkCollector.AssertNotCalled(t, "GetMetrics")
+}
+
+// concurrencyCollector records the maximum number of concurrent GetMetrics calls.
+type concurrencyCollector struct {
+	mockCollector
+	running *atomic.Int32
+	max     *atomic.Int32
+}
+
+func (c *concurrencyCollector) GetMetrics() (collectorpkg.MetricsByCounter, error) {
+	n := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		current := c.max.Load()
+		if n <= current || c.max.CompareAndSwap(current, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return collectorpkg.MetricsByCounter{}, nil
+}
+
+func TestRegistry_SetWorkers(t *testing.T) {
+	reg := NewRegistry()
+	reg.SetWorkers(1)
+
+	var running, maxRunning atomic.Int32
+	for _, entity := range []dcgm.Field_Entity_Group{dcgm.FE_GPU, dcgm.FE_SWITCH, dcgm.FE_LINK} {
+		tuple := collectorpkg.EntityCollectorTuple{}
+		tuple.SetEntity(entity)
+		tuple.SetCollector(&concurrencyCollector{running: &running, max: &maxRunning})
+		reg.Register(tuple)
+	}
+
+	_, err := reg.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), maxRunning.Load())
+}
+
+// blockingCollector returns its metrics, once it is released, and counts its GetMetrics calls.
+type blockingCollector struct {
+	mockCollector
+	metrics collectorpkg.MetricsByCounter
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (c *blockingCollector) GetMetrics() (collectorpkg.MetricsByCounter, error) {
+	c.calls.Add(1)
+	<-c.release
+	return c.metrics, nil
+}
+
+func TestRegistry_GatherWithin(t *testing.T) {
+	stale := selfmetrics.NewRegistry()
+	defer func(c *selfmetrics.Counter) { staleResults = c }(staleResults)
+	staleResults = stale.Counter("dcgm_exporter_stale_collector_results_total", "")
+
+	reg := NewRegistry()
+
+	counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	gpuCollector := new(mockCollector)
+	gpuCollector.On("GetMetrics").Return(collectorpkg.MetricsByCounter{
+		counter: {{GPU: "0", Counter: counter, Attributes: map[string]string{}}},
+	}, nil)
+
+	switchCounter := counters.Counter{FieldID: 856, FieldName: "DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT"}
+	switchCollector := &blockingCollector{
+		metrics: collectorpkg.MetricsByCounter{
+			switchCounter: {{GPU: "0", Counter: switchCounter, Attributes: map[string]string{}}},
+		},
+		release: make(chan struct{}, 1),
+	}
+
+	gpuTuple := collectorpkg.EntityCollectorTuple{}
+	gpuTuple.SetEntity(dcgm.FE_GPU)
+	gpuTuple.SetCollector(gpuCollector)
+	reg.Register(gpuTuple)
+
+	switchTuple := collectorpkg.EntityCollectorTuple{}
+	switchTuple.SetEntity(dcgm.FE_SWITCH)
+	switchTuple.SetCollector(switchCollector)
+	reg.Register(switchTuple)
+
+	// The first gathering of the switch collector finishes in time
+	switchCollector.release <- struct{}{}
+	got, pending, err := reg.GatherWithin(time.Second, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	require.Len(t, got[dcgm.FE_SWITCH][switchCounter], 1)
+	_, counted := stale.Value("dcgm_exporter_stale_collector_results_total", "group", dcgm.FE_SWITCH.String())
+	assert.False(t, counted)
+
+	// The second one overruns, so its latest metrics are returned and counted as stale, with the same series
+	got, pending, err = reg.GatherWithin(10*time.Millisecond, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	require.Len(t, got[dcgm.FE_GPU][counter], 1)
+	require.Len(t, got[dcgm.FE_SWITCH][switchCounter], 1)
+	assert.Empty(t, got[dcgm.FE_SWITCH][switchCounter][0].Attributes)
+	value, _ := stale.Value("dcgm_exporter_stale_collector_results_total", "group", dcgm.FE_SWITCH.String())
+	assert.Equal(t, 1.0, value)
+
+	// The collector, which is still running, is not started again; its stale metrics expire after maxStaleness
+	got, pending, err = reg.GatherWithin(10*time.Millisecond, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	assert.NotContains(t, got, dcgm.FE_SWITCH)
+	assert.Equal(t, int32(2), switchCollector.calls.Load())
+
+	close(switchCollector.release)
+}
+
+func TestRegistry_GatherWithin_RunningCollectors(t *testing.T) {
+	reg := NewRegistry()
+
+	counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	blocking := &blockingCollector{
+		metrics: collectorpkg.MetricsByCounter{
+			counter: {{GPU: "0", Counter: counter, Attributes: map[string]string{}}},
+		},
+		release: make(chan struct{}),
+	}
+	blocking.On("Cleanup").Return()
+
+	tuple := collectorpkg.EntityCollectorTuple{}
+	tuple.SetEntity(dcgm.FE_GPU)
+	tuple.SetCollector(blocking)
+	reg.Register(tuple)
+
+	_, pending, err := reg.GatherWithin(10*time.Millisecond, time.Minute, dcgm.FE_GPU)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// Gather waits for the collector, which is still running, instead of starting it again
+	gathered := make(chan MetricsByCounterGroup)
+	go func() {
+		got, err := reg.Gather()
+		assert.NoError(t, err)
+		gathered <- got
+	}()
+
+	select {
+	case <-gathered:
+		t.Fatal("Gather returned while a collector was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	blocking.release <- struct{}{}
+	select {
+	case got := <-gathered:
+		assert.Len(t, got[dcgm.FE_GPU][counter], 1)
+	case <-time.After(time.Second):
+		t.Fatal("Gather did not return after the collector finished")
+	}
+	assert.Equal(t, int32(1), blocking.calls.Load())
+
+	// Another gathering starts the collector again, and Cleanup waits for it to finish
+	_, pending, err = reg.GatherWithin(10*time.Millisecond, time.Minute, dcgm.FE_GPU)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// Cleanup waits for the collector to finish
+	cleaned := make(chan struct{})
+	go func() {
+		reg.Cleanup()
+		close(cleaned)
+	}()
+
+	select {
+	case <-cleaned:
+		t.Fatal("the registry was cleaned up while a collector was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(blocking.release)
+	select {
+	case <-cleaned:
+	case <-time.After(time.Second):
+		t.Fatal("the registry was not cleaned up after the collector finished")
+	}
+	blocking.AssertCalled(t, "Cleanup")
+}
+
+func TestRegistry_GatherWithin_WaitsForRunningCollectors(t *testing.T) {
+	reg := NewRegistry()
+
+	counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	blocking := &blockingCollector{
+		metrics: collectorpkg.MetricsByCounter{
+			counter: {{GPU: "0", Counter: counter, Attributes: map[string]string{}}},
+		},
+		release: make(chan struct{}),
+	}
+
+	tuple := collectorpkg.EntityCollectorTuple{}
+	tuple.SetEntity(dcgm.FE_GPU)
+	tuple.SetCollector(blocking)
+	reg.Register(tuple)
+
+	// The first caller, e.g. the event bus, gives up on the collector at its deadline
+	_, pending, err := reg.GatherWithin(10*time.Millisecond, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// The second caller gets the result of the same gathering, which finishes within its deadline
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(blocking.release)
+	}()
+	got, pending, err := reg.GatherWithin(time.Second, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	assert.Len(t, got[dcgm.FE_GPU][counter], 1)
+	assert.Equal(t, int32(1), blocking.calls.Load())
+}
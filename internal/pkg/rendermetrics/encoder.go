@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+// Encoder writes the gathered metrics of the entity groups in an output format, with the same series and labels,
+// which the templates of the Prometheus text format render.
+type Encoder interface {
+	Encode(w io.Writer, groups registry.MetricsByCounterGroup) error
+}
+
+// TextEncoder renders the metrics in the Prometheus text format, group by group.
+type TextEncoder struct{}
+
+func (TextEncoder) Encode(w io.Writer, groups registry.MetricsByCounterGroup) error {
+	for group, metrics := range groups {
+		if err := RenderGroup(w, group, metrics); err != nil {
+			return fmt.Errorf("failed to render the metrics of the group %s; err: %w", group.String(), err)
+		}
+	}
+	return nil
+}
+
+// FamilyEncoder converts the metrics to metric families, which it keeps, instead of writing them, so that they can be
+// written in other formats, e.g. OpenMetrics, without parsing the Prometheus text format.
+type FamilyEncoder struct {
+	families map[string]*dto.MetricFamily
+}
+
+// Encode adds the metrics of the groups to the families. Nothing is written to w.
+func (e *FamilyEncoder) Encode(_ io.Writer, groups registry.MetricsByCounterGroup) error {
+	if e.families == nil {
+		e.families = make(map[string]*dto.MetricFamily)
+	}
+
+	for group, metrics := range groups {
+		if err := e.add(group, selectLabelGroups(expandViews(metrics))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Families returns the families of the encoded metrics, sorted by name.
+func (e *FamilyEncoder) Families() []*dto.MetricFamily {
+	families := make([]*dto.MetricFamily, 0, len(e.families))
+	for _, family := range e.families {
+		families = append(families, family)
+	}
+	slices.SortFunc(families, func(a, b *dto.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return families
+}
+
+func (e *FamilyEncoder) add(group dcgm.Field_Entity_Group, metrics collector.MetricsByCounter) error {
+	for counter, values := range metrics {
+		name := counter.MetricName()
+		family, exists := e.families[name]
+		if !exists {
+			family = &dto.MetricFamily{
+				Name: proto.String(name),
+				Help: proto.String(counter.Help),
+				Type: familyType(counter.PromType).Enum(),
+			}
+			e.families[name] = family
+		}
+
+		for _, m := range values {
+			labels, err := seriesLabels(group, counter, m)
+			if err != nil {
+				return err
+			}
+
+			value, err := strconv.ParseFloat(m.Value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value '%s' of the metric '%s'; err: %w", m.Value, name, err)
+			}
+
+			series := &dto.Metric{Label: labels}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				series.Counter = &dto.Counter{Value: proto.Float64(value)}
+			case dto.MetricType_GAUGE:
+				series.Gauge = &dto.Gauge{Value: proto.Float64(value)}
+			default:
+				series.Untyped = &dto.Untyped{Value: proto.Float64(value)}
+			}
+			family.Metric = append(family.Metric, series)
+		}
+	}
+	return nil
+}
+
+// familyType returns the type of the family of the Prometheus type of a counter. The types, which a single value
+// can't represent, e.g. histograms, are untyped.
+func familyType(promType string) dto.MetricType {
+	switch promType {
+	case "counter":
+		return dto.MetricType_COUNTER
+	case "gauge":
+		return dto.MetricType_GAUGE
+	default:
+		return dto.MetricType_UNTYPED
+	}
+}
+
+// seriesLabels returns the labels of a series, sorted by name, as the template of the group renders them. A label,
+// which is set twice, has the later value.
+func seriesLabels(
+	group dcgm.Field_Entity_Group, counter counters.Counter, m collector.Metric,
+) ([]*dto.LabelPair, error) {
+	labels := map[string]string{}
+
+	device := func() {
+		if counter.HasLabelGroup(counters.LabelGroupDevice) {
+			labels[m.UUID] = m.GPUUUID
+			labels["pci_bus_id"] = m.GPUPCIBusID
+			labels["device"] = m.GPUDevice
+			labels["modelName"] = m.GPUModelName
+		}
+	}
+
+	attributes := false
+	switch group {
+	case dcgm.FE_GPU:
+		labels["gpu"] = m.GPU
+		device()
+		if m.MigProfile != "" {
+			labels["GPU_I_PROFILE"] = m.MigProfile
+			labels["GPU_I_ID"] = m.GPUInstanceID
+		}
+		if m.ComputeInstanceID != "" {
+			labels["GPU_CI_ID"] = m.ComputeInstanceID
+		}
+		attributes = true
+	case dcgm.FE_SWITCH:
+		labels["nvswitch"] = m.GPU
+	case dcgm.FE_LINK:
+		labels["nvlink"] = m.GPU
+		labels["nvswitch"] = m.GPUDevice
+	case dcgm.FE_CPU:
+		labels["cpu"] = m.GPU
+	case dcgm.FE_CPU_CORE:
+		labels["cpucore"] = m.GPU
+		labels["cpu"] = m.GPUDevice
+	case dcgm.FE_VGPU:
+		labels["vgpu_id"] = m.VGPUID
+		labels["gpu"] = m.GPU
+		device()
+		attributes = true
+	default:
+		return nil, fmt.Errorf("unexpected group: %s", group.String())
+	}
+
+	if m.Hostname != "" {
+		labels["Hostname"] = m.Hostname
+	}
+	for k, v := range m.Labels {
+		labels[k] = v
+	}
+	if attributes {
+		for k, v := range m.Attributes {
+			labels[k] = v
+		}
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pairs := make([]*dto.LabelPair, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(labels[name])})
+	}
+	return pairs, nil
+}
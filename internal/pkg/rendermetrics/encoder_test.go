@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+func TestFamilyEncoder_MatchesTextEncoder(t *testing.T) {
+	gpuUtil := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_UTIL,
+		FieldName: "DCGM_FI_DEV_GPU_UTIL",
+		PromType:  "gauge",
+		Help:      "GPU utilization (in %).",
+	}
+	xidErrors := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_XID_ERRORS,
+		FieldName: "DCGM_FI_DEV_XID_ERRORS",
+		PromType:  "counter",
+		Help:      "Value of the last XID error encountered.",
+	}
+	switchTemp := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT,
+		FieldName: "DCGM_FI_DEV_NVSWITCH_TEMPERATURE_CURRENT",
+		PromType:  "gauge",
+		Help:      "NvSwitch current temperature.",
+	}
+
+	groups := registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: collector.MetricsByCounter{
+			gpuUtil: {
+				{
+					Counter:      gpuUtil,
+					Value:        "42",
+					GPU:          "0",
+					GPUUUID:      "GPU-00000000-0000-0000-0000-000000000000",
+					GPUDevice:    "nvidia0",
+					GPUModelName: "NVIDIA A100",
+					GPUPCIBusID:  "00000000:07:00.0",
+					UUID:         "UUID",
+					Hostname:     "testhost",
+					Labels:       map[string]string{"pod": "trainer"},
+					Attributes:   map[string]string{"driver_version": "550.54.15"},
+				},
+				{
+					Counter:           gpuUtil,
+					Value:             "7",
+					GPU:               "1",
+					GPUUUID:           "GPU-11111111-1111-1111-1111-111111111111",
+					GPUDevice:         "nvidia1",
+					GPUModelName:      "NVIDIA A100",
+					GPUPCIBusID:       "00000000:0F:00.0",
+					UUID:              "UUID",
+					MigProfile:        "1g.10gb",
+					GPUInstanceID:     "3",
+					ComputeInstanceID: "0",
+					Hostname:          "testhost",
+				},
+			},
+			xidErrors: {
+				{
+					Counter:      xidErrors,
+					Value:        "13",
+					GPU:          "0",
+					GPUUUID:      "GPU-00000000-0000-0000-0000-000000000000",
+					GPUDevice:    "nvidia0",
+					GPUModelName: "NVIDIA A100",
+					GPUPCIBusID:  "00000000:07:00.0",
+					UUID:         "UUID",
+					Hostname:     "testhost",
+				},
+			},
+		},
+		dcgm.FE_SWITCH: collector.MetricsByCounter{
+			switchTemp: {
+				{
+					Counter:  switchTemp,
+					Value:    "38",
+					GPU:      "0",
+					Hostname: "testhost",
+				},
+			},
+		},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, TextEncoder{}.Encode(&text, groups))
+
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(&text)
+	require.NoError(t, err)
+
+	encoder := &FamilyEncoder{}
+	require.NoError(t, encoder.Encode(nil, groups))
+	families := encoder.Families()
+
+	require.Len(t, families, len(parsed))
+	for _, family := range families {
+		want, exists := parsed[family.GetName()]
+		require.True(t, exists, "unexpected family %s", family.GetName())
+
+		// The families have the labels sorted by name, the templates render them in their own order
+		for _, m := range want.Metric {
+			slices.SortFunc(m.Label, func(a, b *dto.LabelPair) int {
+				return strings.Compare(a.GetName(), b.GetName())
+			})
+		}
+
+		var wantText, gotText bytes.Buffer
+		_, err := expfmt.MetricFamilyToText(&wantText, want)
+		require.NoError(t, err)
+		_, err = expfmt.MetricFamilyToText(&gotText, family)
+		require.NoError(t, err)
+		assert.Equal(t, wantText.String(), gotText.String())
+	}
+}
+
+func TestFamilyEncoder_InvalidValue(t *testing.T) {
+	counter := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_UTIL,
+		FieldName: "DCGM_FI_DEV_GPU_UTIL",
+		PromType:  "gauge",
+		Help:      "GPU utilization (in %).",
+	}
+	groups := registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: collector.MetricsByCounter{
+			counter: {{Counter: counter, Value: "N/A", GPU: "0", UUID: "UUID"}},
+		},
+	}
+
+	encoder := &FamilyEncoder{}
+	err := encoder.Encode(nil, groups)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid value 'N/A'")
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"maps"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+)
+
+const (
+	gpuInstanceSuffix = "_mig"
+	gpuInstanceHelp   = " (GPU instance)"
+
+	entityTypeLabel           = "entity_type"
+	entityTypeGPU             = "gpu"
+	entityTypeGPUInstance     = "gpu_instance"
+	entityTypeComputeInstance = "compute_instance"
+)
+
+// SeparateGPUInstances separates metrics of GPU instances from metrics of physical GPUs, so that aggregations
+// over a family don't count the same GPU twice. Metrics of other entity groups are returned unchanged.
+func SeparateGPUInstances(
+	group dcgm.Field_Entity_Group, metrics collector.MetricsByCounter, mode appconfig.GPUInstanceMetricsMode,
+) collector.MetricsByCounter {
+	if group != dcgm.FE_GPU {
+		return metrics
+	}
+
+	switch mode {
+	case appconfig.GPUInstancesSuffix:
+		return suffixGPUInstances(metrics)
+	case appconfig.GPUInstancesLabel:
+		return labelGPUInstances(metrics)
+	default:
+		return metrics
+	}
+}
+
+// suffixGPUInstances moves metrics of GPU instances to families with the "_mig" suffix.
+func suffixGPUInstances(metrics collector.MetricsByCounter) collector.MetricsByCounter {
+	separated := make(collector.MetricsByCounter, len(metrics))
+
+	for counter, values := range metrics {
+		instanceCounter := counter.WithSuffix(gpuInstanceSuffix)
+		instanceCounter.Help += gpuInstanceHelp
+
+		for _, m := range values {
+			if m.MigProfile == "" {
+				separated[counter] = append(separated[counter], m)
+				continue
+			}
+			m.Counter = instanceCounter
+			separated[instanceCounter] = append(separated[instanceCounter], m)
+		}
+	}
+
+	return separated
+}
+
+// labelGPUInstances adds the "entity_type" label, which distinguishes GPU and compute instances from physical GPUs.
+func labelGPUInstances(metrics collector.MetricsByCounter) collector.MetricsByCounter {
+	labeled := make(collector.MetricsByCounter, len(metrics))
+
+	for counter, values := range metrics {
+		labeledValues := make([]collector.Metric, 0, len(values))
+		for _, m := range values {
+			labels := make(map[string]string, len(m.Labels)+1)
+			maps.Copy(labels, m.Labels)
+
+			labels[entityTypeLabel] = entityTypeGPU
+			if m.MigProfile != "" {
+				labels[entityTypeLabel] = entityTypeGPUInstance
+			}
+			if m.ComputeInstanceID != "" {
+				labels[entityTypeLabel] = entityTypeComputeInstance
+			}
+
+			m.Labels = labels
+			labeledValues = append(labeledValues, m)
+		}
+		labeled[counter] = labeledValues
+	}
+
+	return labeled
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestSeparateGPUInstances(t *testing.T) {
+	counter := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_UTIL,
+		FieldName: "TEST_UTIL",
+		PromType:  "gauge",
+		Help:      "Utilization",
+	}
+
+	newMetrics := func() collector.MetricsByCounter {
+		return collector.MetricsByCounter{
+			counter: {
+				{
+					Counter:  counter,
+					Value:    "50",
+					GPU:      "0",
+					GPUUUID:  "GPU-0",
+					UUID:     "UUID",
+					Hostname: "testhost",
+				},
+				{
+					Counter:       counter,
+					Value:         "20",
+					GPU:           "1",
+					GPUUUID:       "GPU-1",
+					UUID:          "UUID",
+					MigProfile:    "1g.10gb",
+					GPUInstanceID: "3",
+					Hostname:      "testhost",
+					Labels:        map[string]string{"pod": "p"},
+				},
+				{
+					Counter:           counter,
+					Value:             "10",
+					GPU:               "1",
+					GPUUUID:           "GPU-1",
+					UUID:              "UUID",
+					MigProfile:        "1g.10gb",
+					GPUInstanceID:     "3",
+					ComputeInstanceID: "0",
+					Hostname:          "testhost",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		group       dcgm.Field_Entity_Group
+		mode        appconfig.GPUInstanceMetricsMode
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:  "Mixed",
+			group: dcgm.FE_GPU,
+			mode:  appconfig.GPUInstancesMixed,
+			contains: []string{
+				`TEST_UTIL{gpu="0",UUID="GPU-0",pci_bus_id="",device="",modelName="",Hostname="testhost"} 50`,
+				`TEST_UTIL{gpu="1",UUID="GPU-1",pci_bus_id="",device="",modelName="",GPU_I_PROFILE="1g.10gb",GPU_I_ID="3",Hostname="testhost",pod="p"} 20`,
+				`TEST_UTIL{gpu="1",UUID="GPU-1",pci_bus_id="",device="",modelName="",GPU_I_PROFILE="1g.10gb",GPU_I_ID="3",GPU_CI_ID="0",Hostname="testhost"} 10`,
+			},
+			notContains: []string{"TEST_UTIL_mig", "entity_type"},
+		},
+		{
+			name:  "Suffix",
+			group: dcgm.FE_GPU,
+			mode:  appconfig.GPUInstancesSuffix,
+			contains: []string{
+				"# TYPE TEST_UTIL gauge",
+				`TEST_UTIL{gpu="0",UUID="GPU-0",pci_bus_id="",device="",modelName="",Hostname="testhost"} 50`,
+				"# HELP TEST_UTIL_mig Utilization (GPU instance)",
+				"# TYPE TEST_UTIL_mig gauge",
+				`TEST_UTIL_mig{gpu="1",UUID="GPU-1",pci_bus_id="",device="",modelName="",GPU_I_PROFILE="1g.10gb",GPU_I_ID="3",Hostname="testhost",pod="p"} 20`,
+			},
+			notContains: []string{`TEST_UTIL{gpu="1"`, "entity_type"},
+		},
+		{
+			name:  "Label",
+			group: dcgm.FE_GPU,
+			mode:  appconfig.GPUInstancesLabel,
+			contains: []string{
+				`TEST_UTIL{gpu="0",UUID="GPU-0",pci_bus_id="",device="",modelName="",Hostname="testhost",entity_type="gpu"} 50`,
+				`TEST_UTIL{gpu="1",UUID="GPU-1",pci_bus_id="",device="",modelName="",GPU_I_PROFILE="1g.10gb",GPU_I_ID="3",Hostname="testhost",entity_type="gpu_instance",pod="p"} 20`,
+				`TEST_UTIL{gpu="1",UUID="GPU-1",pci_bus_id="",device="",modelName="",GPU_I_PROFILE="1g.10gb",GPU_I_ID="3",GPU_CI_ID="0",Hostname="testhost",entity_type="compute_instance"} 10`,
+			},
+			notContains: []string{"TEST_UTIL_mig"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := newMetrics()
+
+			var buf bytes.Buffer
+			require.NoError(t, RenderGroup(&buf, tt.group, SeparateGPUInstances(tt.group, metrics, tt.mode)))
+
+			for _, want := range tt.contains {
+				assert.Contains(t, buf.String(), want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, buf.String(), unwanted)
+			}
+			assert.Equal(t, map[string]string{"pod": "p"}, metrics[counter][1].Labels, "input labels must not change")
+		})
+	}
+}
+
+func TestSeparateGPUInstances_OtherGroups(t *testing.T) {
+	metrics := collector.MetricsByCounter{
+		counters.Counter{FieldName: "TEST_SWITCH"}: {{Value: "1", MigProfile: "unexpected"}},
+	}
+
+	assert.Equal(t, metrics, SeparateGPUInstances(dcgm.FE_SWITCH, metrics, appconfig.GPUInstancesSuffix))
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"maps"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/transformation"
+)
+
+// selectLabelGroups removes the hostname, pod and custom labels from the series of the counters, which don't
+// select them in the counters file. The device labels are omitted by the templates.
+func selectLabelGroups(metrics collector.MetricsByCounter) collector.MetricsByCounter {
+	var selected collector.MetricsByCounter
+
+	for counter, values := range metrics {
+		if counter.LabelGroups == "" {
+			continue
+		}
+
+		if selected == nil {
+			selected = maps.Clone(metrics)
+		}
+
+		hostname := counter.HasLabelGroup(counters.LabelGroupHostname)
+		pod := counter.HasLabelGroup(counters.LabelGroupPod)
+		custom := counter.HasLabelGroup(counters.LabelGroupCustom)
+
+		selectedValues := make([]collector.Metric, len(values))
+		for i, m := range values {
+			if !hostname {
+				m.Hostname = ""
+			}
+			if !pod {
+				m.Attributes = withoutLabels(m.Attributes, transformation.IsPodAttribute)
+			}
+			if !custom {
+				m.Labels = withoutLabels(m.Labels, isCustomLabel)
+			}
+			selectedValues[i] = m
+		}
+		selected[counter] = selectedValues
+	}
+
+	if selected == nil {
+		return metrics
+	}
+
+	return selected
+}
+
+// isCustomLabel reports whether the label is a field with the label type in the counters file.
+func isCustomLabel(name string) bool {
+	if _, exists := dcgm.DCGM_FI[name]; exists {
+		return true
+	}
+	_, exists := dcgm.OLD_DCGM_FI[name]
+	return exists
+}
+
+// withoutLabels returns a copy of the labels without the ones matching the predicate, the labels are shared by the
+// metrics of all counters of an entity.
+func withoutLabels(labels map[string]string, remove func(string) bool) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	result := maps.Clone(labels)
+	maps.DeleteFunc(result, func(name, _ string) bool {
+		return remove(name)
+	})
+
+	return result
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestRenderGroup_LabelGroups(t *testing.T) {
+	labels := map[string]string{"DCGM_FI_DRIVER_VERSION": "550.54", "xid": "79"}
+	attributes := map[string]string{"pod": "trainer", "namespace": "ml", "err_code": "79"}
+
+	metricFor := func(counter counters.Counter) collector.Metric {
+		return collector.Metric{
+			Counter:      counter,
+			Value:        "42",
+			GPU:          "0",
+			GPUUUID:      "GPU-00000000-0000-0000-0000-000000000000",
+			GPUDevice:    "nvidia0",
+			GPUModelName: "NVIDIA A100",
+			GPUPCIBusID:  "00000000:3B:00.0",
+			UUID:         "UUID",
+			Hostname:     "testhost",
+			Labels:       labels,
+			Attributes:   attributes,
+		}
+	}
+
+	all := counters.Counter{FieldName: "TEST_ALL", PromType: "gauge", Help: "all"}
+	device := counters.Counter{FieldName: "TEST_DEVICE", PromType: "gauge", Help: "device", LabelGroups: "device"}
+	none := counters.Counter{FieldName: "TEST_NONE", PromType: "gauge", Help: "none", LabelGroups: "none"}
+
+	metrics := collector.MetricsByCounter{
+		all:    {metricFor(all)},
+		device: {metricFor(device)},
+		none:   {metricFor(none)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderGroup(&buf, dcgm.FE_GPU, metrics))
+
+	out := buf.String()
+	assert.Contains(t, out, `TEST_ALL{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000",`+
+		`pci_bus_id="00000000:3B:00.0",device="nvidia0",modelName="NVIDIA A100",Hostname="testhost",`+
+		`DCGM_FI_DRIVER_VERSION="550.54",xid="79",err_code="79",namespace="ml",pod="trainer"} 42`)
+	assert.Contains(t, out, `TEST_DEVICE{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000",`+
+		`pci_bus_id="00000000:3B:00.0",device="nvidia0",modelName="NVIDIA A100",xid="79",err_code="79"} 42`)
+	assert.Contains(t, out, `TEST_NONE{gpu="0",xid="79",err_code="79"} 42`)
+
+	// The labels are shared by the metrics of an entity and must not be modified
+	assert.Len(t, labels, 2)
+	assert.Len(t, attributes, 3)
+}
@@ -41,10 +41,10 @@ import (
 var (
 	gpuMetricsFormat = `
 {{- range $counter, $metrics := . -}}
-# HELP {{ $counter.FieldName }} {{ $counter.Help }}
-# TYPE {{ $counter.FieldName }} {{ $counter.PromType }}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
 {{- range $metric := $metrics }}
-{{ $counter.FieldName }}{gpu="{{ $metric.GPU }}",{{ $metric.UUID }}="{{ $metric.GPUUUID }}",pci_bus_id="{{ $metric.GPUPCIBusID }}",device="{{ $metric.GPUDevice }}",modelName="{{ $metric.GPUModelName }}"{{if $metric.MigProfile}},GPU_I_PROFILE="{{ $metric.MigProfile }}",GPU_I_ID="{{ $metric.GPUInstanceID }}"{{end}}{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+{{ $counter.MetricName }}{gpu="{{ $metric.GPU }}"{{if $counter.HasLabelGroup "device"}},{{ $metric.UUID }}="{{ $metric.GPUUUID }}",pci_bus_id="{{ $metric.GPUPCIBusID }}",device="{{ $metric.GPUDevice }}",modelName="{{ $metric.GPUModelName }}"{{end}}{{if $metric.MigProfile}},GPU_I_PROFILE="{{ $metric.MigProfile }}",GPU_I_ID="{{ $metric.GPUInstanceID }}"{{end}}{{if $metric.ComputeInstanceID}},GPU_CI_ID="{{ $metric.ComputeInstanceID }}"{{end}}{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
 
 {{- range $k, $v := $metric.Labels -}}
 	,{{ $k }}="{{ $v }}"
@@ -59,10 +59,10 @@ var (
 
 	switchMetricsFormat = `
 {{- range $counter, $metrics := . -}}
-# HELP {{ $counter.FieldName }} {{ $counter.Help }}
-# TYPE {{ $counter.FieldName }} {{ $counter.PromType }}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
 {{- range $metric := $metrics }}
-{{ $counter.FieldName }}{nvswitch="{{ $metric.GPU }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+{{ $counter.MetricName }}{nvswitch="{{ $metric.GPU }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
 
 {{- range $k, $v := $metric.Labels -}}
 	,{{ $k }}="{{ $v }}"
@@ -73,10 +73,10 @@ var (
 
 	linkMetricsFormat = `
 {{- range $counter, $metrics := . -}}
-# HELP {{ $counter.FieldName }} {{ $counter.Help }}
-# TYPE {{ $counter.FieldName }} {{ $counter.PromType }}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
 {{- range $metric := $metrics }}
-{{ $counter.FieldName }}{nvlink="{{ $metric.GPU }}",nvswitch="{{ $metric.GPUDevice }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+{{ $counter.MetricName }}{nvlink="{{ $metric.GPU }}",nvswitch="{{ $metric.GPUDevice }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
 
 {{- range $k, $v := $metric.Labels -}}
 	,{{ $k }}="{{ $v }}"
@@ -87,10 +87,10 @@ var (
 
 	cpuMetricsFormat = `
 {{- range $counter, $metrics := . -}}
-# HELP {{ $counter.FieldName }} {{ $counter.Help }}
-# TYPE {{ $counter.FieldName }} {{ $counter.PromType }}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
 {{- range $metric := $metrics }}
-{{ $counter.FieldName }}{cpu="{{ $metric.GPU }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+{{ $counter.MetricName }}{cpu="{{ $metric.GPU }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
 
 {{- range $k, $v := $metric.Labels -}}
 	,{{ $k }}="{{ $v }}"
@@ -101,14 +101,32 @@ var (
 
 	cpuCoreMetricsFormat = `
 {{- range $counter, $metrics := . -}}
-# HELP {{ $counter.FieldName }} {{ $counter.Help }}
-# TYPE {{ $counter.FieldName }} {{ $counter.PromType }}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
 {{- range $metric := $metrics }}
-{{ $counter.FieldName }}{cpucore="{{ $metric.GPU }}",cpu="{{ $metric.GPUDevice }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+{{ $counter.MetricName }}{cpucore="{{ $metric.GPU }}",cpu="{{ $metric.GPUDevice }}"{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
 
 {{- range $k, $v := $metric.Labels -}}
 	,{{ $k }}="{{ $v }}"
 {{- end -}}
+} {{ $metric.Value -}}
+{{- end }}
+{{ end }}`
+
+	vgpuMetricsFormat = `
+{{- range $counter, $metrics := . -}}
+# HELP {{ $counter.MetricName }} {{ $counter.Help }}
+# TYPE {{ $counter.MetricName }} {{ $counter.PromType }}
+{{- range $metric := $metrics }}
+{{ $counter.MetricName }}{vgpu_id="{{ $metric.VGPUID }}",gpu="{{ $metric.GPU }}"{{if $counter.HasLabelGroup "device"}},{{ $metric.UUID }}="{{ $metric.GPUUUID }}",pci_bus_id="{{ $metric.GPUPCIBusID }}",device="{{ $metric.GPUDevice }}",modelName="{{ $metric.GPUModelName }}"{{end}}{{if $metric.Hostname }},Hostname="{{ $metric.Hostname }}"{{end}}
+
+{{- range $k, $v := $metric.Labels -}}
+	,{{ $k }}="{{ $v }}"
+{{- end -}}
+{{- range $k, $v := $metric.Attributes -}}
+	,{{ $k }}="{{ $v }}"
+{{- end -}}
+
 } {{ $metric.Value -}}
 {{- end }}
 {{ end }}`
@@ -134,6 +152,10 @@ var getCPUCoreMetricsTemplate = sync.OnceValue(func() *template.Template {
 	return template.Must(template.New("cpuMetricsFormat").Parse(cpuCoreMetricsFormat))
 })
 
+var getVGPUMetricsTemplate = sync.OnceValue(func() *template.Template {
+	return template.Must(template.New("vgpuMetricsFormat").Parse(vgpuMetricsFormat))
+})
+
 func RenderGroup(w io.Writer, group dcgm.Field_Entity_Group, metrics collector.MetricsByCounter) error {
 	var tmpl *template.Template
 
@@ -148,8 +170,10 @@ func RenderGroup(w io.Writer, group dcgm.Field_Entity_Group, metrics collector.M
 		tmpl = getCPUMetricsTemplate()
 	case dcgm.FE_CPU_CORE:
 		tmpl = getCPUCoreMetricsTemplate()
+	case dcgm.FE_VGPU:
+		tmpl = getVGPUMetricsTemplate()
 	default:
 		return fmt.Errorf("unexpected group: %s", group.String())
 	}
-	return tmpl.Execute(w, metrics)
+	return tmpl.Execute(w, selectLabelGroups(expandViews(metrics)))
 }
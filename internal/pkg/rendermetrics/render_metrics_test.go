@@ -36,6 +36,7 @@ func getMetricsByCounterWithTestMetric() collector.MetricsByCounter {
 		GPU:          "0",
 		GPUDevice:    "nvidia0",
 		GPUModelName: "NVIDIA T400 4GB",
+		VGPUID:       "3584",
 		Hostname:     "testhost",
 		UUID:         "UUID",
 		GPUUUID:      "GPU-00000000-0000-0000-0000-000000000000",
@@ -108,6 +109,30 @@ TEST_METRIC{cpu="0",Hostname="testhost"} 42
 			want: `# HELP TEST_METRIC 
 # TYPE TEST_METRIC gauge
 TEST_METRIC{cpucore="0",cpu="nvidia0",Hostname="testhost"} 42
+`,
+		},
+		{
+			name:    fmt.Sprintf("Render %s", dcgm.FE_VGPU.String()),
+			group:   dcgm.FE_VGPU,
+			metrics: metrics,
+			want: `# HELP TEST_METRIC 
+# TYPE TEST_METRIC gauge
+TEST_METRIC{vgpu_id="3584",gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000",pci_bus_id="",device="nvidia0",modelName="NVIDIA T400 4GB",Hostname="testhost"} 42
+`,
+		},
+		{
+			name:  "Render renamed counter",
+			group: dcgm.FE_CPU,
+			metrics: func() collector.MetricsByCounter {
+				counter := getTestMetric()
+				counter.Name = "test_metric"
+				return collector.MetricsByCounter{
+					counter: {{GPU: "0", Hostname: "testhost", Counter: counter, Value: "42"}},
+				}
+			}(),
+			want: `# HELP test_metric 
+# TYPE test_metric gauge
+test_metric{cpu="0",Hostname="testhost"} 42
 `,
 		},
 		{
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+// SeriesMetadata describes the entity, which a rendered series belongs to.
+type SeriesMetadata struct {
+	// Metric is the name of the metric family
+	Metric string `json:"metric"`
+	// Labels are the labels, which identify the entity in the series
+	Labels map[string]string `json:"labels"`
+	// EntityType is one of gpu, gpu_instance, vgpu, switch, link, cpu and cpu_core
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	// Parent is the entity, which the entity belongs to, as <entity_type>:<entity_id>
+	Parent string `json:"parent,omitempty"`
+	UUID   string `json:"uuid,omitempty"`
+}
+
+// GroupSeriesMetadata returns the metadata of the series, which RenderGroup renders for the metrics, including
+// the families of the additional views of the counters.
+func GroupSeriesMetadata(group dcgm.Field_Entity_Group, metrics collector.MetricsByCounter) []SeriesMetadata {
+	var result []SeriesMetadata
+
+	for counter, values := range metrics {
+		names := []string{counter.MetricName()}
+		for _, view := range counter.ExpandViews() {
+			names = append(names, view.Counter.MetricName())
+		}
+
+		for _, m := range values {
+			entity := entityMetadata(group, m)
+			for _, name := range names {
+				series := entity
+				series.Metric = name
+				result = append(result, series)
+			}
+		}
+	}
+
+	return result
+}
+
+// entityMetadata maps the fields of a metric to its entity, using the labels of the group's template.
+func entityMetadata(group dcgm.Field_Entity_Group, m collector.Metric) SeriesMetadata {
+	labels := map[string]string{}
+	if m.Hostname != "" && m.Counter.HasLabelGroup(counters.LabelGroupHostname) {
+		labels["Hostname"] = m.Hostname
+	}
+
+	var series SeriesMetadata
+
+	switch group {
+	case dcgm.FE_GPU:
+		labels["gpu"] = m.GPU
+		if m.Counter.HasLabelGroup(counters.LabelGroupDevice) {
+			labels[m.UUID] = m.GPUUUID
+		}
+		series.EntityType = entityTypeGPU
+		series.EntityID = m.GPU
+		series.UUID = m.GPUUUID
+		if m.MigProfile != "" {
+			labels["GPU_I_ID"] = m.GPUInstanceID
+			series.EntityType = entityTypeGPUInstance
+			series.EntityID = m.GPUInstanceID
+			series.Parent = entityTypeGPU + ":" + m.GPU
+		}
+		if m.ComputeInstanceID != "" {
+			labels["GPU_CI_ID"] = m.ComputeInstanceID
+			series.EntityType = entityTypeComputeInstance
+			series.EntityID = m.ComputeInstanceID
+			series.Parent = entityTypeGPUInstance + ":" + m.GPUInstanceID
+		}
+	case dcgm.FE_SWITCH:
+		labels["nvswitch"] = m.GPU
+		series.EntityType = "switch"
+		series.EntityID = m.GPU
+	case dcgm.FE_LINK:
+		labels["nvlink"] = m.GPU
+		labels["nvswitch"] = m.GPUDevice
+		series.EntityType = "link"
+		series.EntityID = m.GPU
+		series.Parent = "switch:" + m.GPUDevice
+	case dcgm.FE_CPU:
+		labels["cpu"] = m.GPU
+		series.EntityType = "cpu"
+		series.EntityID = m.GPU
+	case dcgm.FE_CPU_CORE:
+		labels["cpucore"] = m.GPU
+		labels["cpu"] = m.GPUDevice
+		series.EntityType = "cpu_core"
+		series.EntityID = m.GPU
+		series.Parent = "cpu:" + m.GPUDevice
+	case dcgm.FE_VGPU:
+		labels["vgpu_id"] = m.VGPUID
+		labels["gpu"] = m.GPU
+		if m.Counter.HasLabelGroup(counters.LabelGroupDevice) {
+			labels[m.UUID] = m.GPUUUID
+		}
+		series.EntityType = "vgpu"
+		series.EntityID = m.VGPUID
+		series.Parent = entityTypeGPU + ":" + m.GPU
+		series.UUID = m.GPUUUID
+	}
+
+	series.Labels = labels
+
+	return series
+}
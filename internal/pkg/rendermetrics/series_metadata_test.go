@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestGroupSeriesMetadata(t *testing.T) {
+	t.Run("GPU instances belong to their GPU", func(t *testing.T) {
+		counter := counters.Counter{FieldName: "TEST_ENERGY", PromType: "counter", Views: "rate"}
+		metrics := collector.MetricsByCounter{
+			counter: {
+				{GPU: "0", UUID: "UUID", GPUUUID: "GPU-0", Hostname: "testhost"},
+				{GPU: "0", UUID: "UUID", GPUUUID: "GPU-0", MigProfile: "1g.10gb", GPUInstanceID: "3"},
+			},
+		}
+
+		got := GroupSeriesMetadata(dcgm.FE_GPU, metrics)
+
+		// Both the counter and its rate view are described
+		assert.ElementsMatch(t, []SeriesMetadata{
+			{
+				Metric:     "TEST_ENERGY",
+				Labels:     map[string]string{"gpu": "0", "UUID": "GPU-0", "Hostname": "testhost"},
+				EntityType: "gpu",
+				EntityID:   "0",
+				UUID:       "GPU-0",
+			},
+			{
+				Metric:     "TEST_ENERGY_rate",
+				Labels:     map[string]string{"gpu": "0", "UUID": "GPU-0", "Hostname": "testhost"},
+				EntityType: "gpu",
+				EntityID:   "0",
+				UUID:       "GPU-0",
+			},
+			{
+				Metric:     "TEST_ENERGY",
+				Labels:     map[string]string{"gpu": "0", "UUID": "GPU-0", "GPU_I_ID": "3"},
+				EntityType: "gpu_instance",
+				EntityID:   "3",
+				Parent:     "gpu:0",
+				UUID:       "GPU-0",
+			},
+			{
+				Metric:     "TEST_ENERGY_rate",
+				Labels:     map[string]string{"gpu": "0", "UUID": "GPU-0", "GPU_I_ID": "3"},
+				EntityType: "gpu_instance",
+				EntityID:   "3",
+				Parent:     "gpu:0",
+				UUID:       "GPU-0",
+			},
+		}, got)
+	})
+
+	t.Run("links belong to their switch", func(t *testing.T) {
+		counter := counters.Counter{FieldName: "TEST_LINK_STATUS", PromType: "gauge"}
+		metrics := collector.MetricsByCounter{
+			counter: {{GPU: "5", GPUDevice: "1"}},
+		}
+
+		assert.Equal(t, []SeriesMetadata{
+			{
+				Metric:     "TEST_LINK_STATUS",
+				Labels:     map[string]string{"nvlink": "5", "nvswitch": "1"},
+				EntityType: "link",
+				EntityID:   "5",
+				Parent:     "switch:1",
+			},
+		}, GroupSeriesMetadata(dcgm.FE_LINK, metrics))
+	})
+
+	t.Run("vGPUs belong to their GPU", func(t *testing.T) {
+		counter := counters.Counter{FieldName: "TEST_VGPU_LICENSE_STATUS", PromType: "gauge"}
+		metrics := collector.MetricsByCounter{
+			counter: {{GPU: "1", UUID: "UUID", GPUUUID: "GPU-1", VGPUID: "3584"}},
+		}
+
+		assert.Equal(t, []SeriesMetadata{
+			{
+				Metric:     "TEST_VGPU_LICENSE_STATUS",
+				Labels:     map[string]string{"vgpu_id": "3584", "gpu": "1", "UUID": "GPU-1"},
+				EntityType: "vgpu",
+				EntityID:   "3584",
+				Parent:     "gpu:1",
+				UUID:       "GPU-1",
+			},
+		}, GroupSeriesMetadata(dcgm.FE_VGPU, metrics))
+	})
+}
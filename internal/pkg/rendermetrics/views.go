@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+)
+
+// expandViews adds metric families for the additional representations of the counters. The rate views show the rates,
+// which the registry computed for the values.
+func expandViews(metrics collector.MetricsByCounter) collector.MetricsByCounter {
+	var expanded collector.MetricsByCounter
+
+	for counter, values := range metrics {
+		views := counter.ExpandViews()
+		if len(views) == 0 {
+			continue
+		}
+
+		if expanded == nil {
+			expanded = make(collector.MetricsByCounter, len(metrics))
+			for c, v := range metrics {
+				expanded[c] = v
+			}
+		}
+
+		for _, view := range views {
+			viewMetrics := make([]collector.Metric, 0, len(values))
+			for _, m := range values {
+				m.Counter = view.Counter
+				if view.Rate {
+					// The registry computes the rates, since it sees every collection
+					if m.Rate == "" {
+						continue
+					}
+					m.Value = m.Rate
+				}
+				viewMetrics = append(viewMetrics, m)
+			}
+
+			if len(viewMetrics) > 0 {
+				expanded[view.Counter] = viewMetrics
+			}
+		}
+	}
+
+	if expanded == nil {
+		return metrics
+	}
+
+	return expanded
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendermetrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestRenderGroup_Views(t *testing.T) {
+	counter := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION,
+		FieldName: "TEST_ENERGY",
+		PromType:  "counter",
+		Help:      "Total energy",
+		Views:     "gauge|rate",
+	}
+
+	metricsWithRate := func(value, rate string) collector.MetricsByCounter {
+		return collector.MetricsByCounter{
+			counter: {
+				{
+					Counter:    counter,
+					Value:      value,
+					Rate:       rate,
+					GPU:        "0",
+					GPUUUID:    "GPU-00000000-0000-0000-0000-000000000000",
+					UUID:       "UUID",
+					Hostname:   "testhost",
+					Attributes: map[string]string{},
+				},
+			},
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderGroup(&buf, dcgm.FE_GPU, metricsWithRate("1000", "")))
+
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE TEST_ENERGY counter\n")
+	assert.Contains(t, out, "# TYPE TEST_ENERGY_gauge gauge\n")
+	assert.Contains(t, out, `TEST_ENERGY_gauge{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000"`)
+	assert.NotContains(t, out, "TEST_ENERGY_rate", "the rate is not known yet")
+
+	buf.Reset()
+	require.NoError(t, RenderGroup(&buf, dcgm.FE_GPU, metricsWithRate("1500", "50")))
+
+	out = buf.String()
+	assert.Contains(t, out, "# HELP TEST_ENERGY_rate Total energy (per second rate)\n")
+	assert.Contains(t, out, "# TYPE TEST_ENERGY_rate gauge\n")
+	assert.Contains(t, out, `Hostname="testhost"} 50`)
+	assert.Contains(t, out, `TEST_ENERGY{gpu="0"`)
+	assert.Contains(t, out, `Hostname="testhost"} 1500`, "the counter keeps its value")
+}
+
+func TestRenderGroup_WithoutViews(t *testing.T) {
+	metrics := getMetricsByCounterWithTestMetric()
+	assert.Equal(t, metrics, expandViews(metrics))
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package selfmetrics keeps track of metrics describing the state of dcgm-exporter itself,
+// such as entity discovery status, and renders them in the Prometheus text format.
+package selfmetrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	typeGauge   = "gauge"
+	typeCounter = "counter"
+)
+
+type sample struct {
+	labels string
+	// pairs are the label names and values, which labels is formatted from
+	pairs []string
+	value float64
+}
+
+type family struct {
+	name     string
+	help     string
+	promType string
+	samples  map[string]*sample
+}
+
+// Registry holds self-metrics families.
+type Registry struct {
+	families map[string]*family
+	mtx      sync.Mutex
+}
+
+var defaultRegistry = NewRegistry()
+
+// NewRegistry creates a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		families: map[string]*family{},
+	}
+}
+
+// Default returns the process wide registry, rendered by the metrics server.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Gauge is a self-metric, which value can go up and down.
+type Gauge struct {
+	r    *Registry
+	name string
+}
+
+// Counter is a self-metric, which value only goes up.
+type Counter struct {
+	r    *Registry
+	name string
+}
+
+// Gauge returns the gauge with the given name, registering it on first use.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	r.family(name, help, typeGauge)
+	return &Gauge{r: r, name: name}
+}
+
+// Counter returns the counter with the given name, registering it on first use.
+func (r *Registry) Counter(name, help string) *Counter {
+	r.family(name, help, typeCounter)
+	return &Counter{r: r, name: name}
+}
+
+// Set sets the gauge value for the given label pairs: key1, value1, key2, value2...
+func (g *Gauge) Set(value float64, labels ...string) {
+	g.r.update(g.name, labels, func(float64) float64 { return value })
+}
+
+// Reset removes all samples of the gauge.
+func (g *Gauge) Reset() {
+	g.r.mtx.Lock()
+	defer g.r.mtx.Unlock()
+
+	g.r.families[g.name].samples = map[string]*sample{}
+}
+
+// Inc increments the counter by one for the given label pairs: key1, value1, key2, value2...
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add increments the counter by delta for the given label pairs: key1, value1, key2, value2...
+func (c *Counter) Add(delta float64, labels ...string) {
+	c.r.update(c.name, labels, func(v float64) float64 { return v + delta })
+}
+
+// Value returns the current value of the metric for the given label pairs and true if the sample exists.
+func (r *Registry) Value(name string, labels ...string) (float64, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	f, exists := r.families[name]
+	if !exists {
+		return 0, false
+	}
+
+	s, exists := f.samples[formatLabels(labels)]
+	if !exists {
+		return 0, false
+	}
+
+	return s.value, true
+}
+
+// Render writes all registered metrics, which have at least one sample, in the Prometheus text format.
+func (r *Registry) Render(w io.Writer) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+
+	for _, name := range names {
+		f := r.families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", f.name, f.promType)
+
+		keys := make([]string, 0, len(f.samples))
+		for key := range f.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.samples[key]
+			fmt.Fprintf(&sb, "%s%s %s\n", f.name, s.labels, strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Families returns all registered metrics, which have at least one sample, as metric families sorted by name, so
+// that they can be written in other formats than the Prometheus text format.
+func (r *Registry) Families() []*dto.MetricFamily {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		f := r.families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		family := &dto.MetricFamily{Name: proto.String(f.name), Help: proto.String(f.help)}
+		if f.promType == typeCounter {
+			family.Type = dto.MetricType_COUNTER.Enum()
+		} else {
+			family.Type = dto.MetricType_GAUGE.Enum()
+		}
+
+		keys := make([]string, 0, len(f.samples))
+		for key := range f.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.samples[key]
+			m := &dto.Metric{}
+			for i := 0; i+1 < len(s.pairs); i += 2 {
+				m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(s.pairs[i]), Value: proto.String(s.pairs[i+1])})
+			}
+			if f.promType == typeCounter {
+				m.Counter = &dto.Counter{Value: proto.Float64(s.value)}
+			} else {
+				m.Gauge = &dto.Gauge{Value: proto.Float64(s.value)}
+			}
+			family.Metric = append(family.Metric, m)
+		}
+		families = append(families, family)
+	}
+
+	return families
+}
+
+func (r *Registry) family(name, help, promType string) *family {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	f, exists := r.families[name]
+	if !exists {
+		f = &family{
+			name:     name,
+			help:     help,
+			promType: promType,
+			samples:  map[string]*sample{},
+		}
+		r.families[name] = f
+	}
+
+	return f
+}
+
+func (r *Registry) update(name string, labels []string, fn func(float64) float64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	f := r.families[name]
+
+	key := formatLabels(labels)
+	s, exists := f.samples[key]
+	if !exists {
+		s = &sample{labels: key, pairs: slices.Clone(labels)}
+		f.samples[key] = s
+	}
+
+	s.value = fn(s.value)
+}
+
+// formatLabels converts label pairs into the {key="value",...} form. A trailing key without value is ignored.
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package selfmetrics
+
+import (
+	"bytes"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Render(t *testing.T) {
+	r := NewRegistry()
+
+	status := r.Gauge("dcgm_exporter_test_status", "Test status.")
+	retries := r.Counter("dcgm_exporter_test_retries_total", "Test retries.")
+	r.Gauge("dcgm_exporter_test_unused", "Not rendered without samples.")
+
+	status.Set(1, "entity", "CPU Core")
+	status.Set(0, "entity", "CPU")
+	retries.Inc("entity", "CPU")
+	retries.Add(2, "entity", "CPU")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf))
+
+	expected := `# HELP dcgm_exporter_test_retries_total Test retries.
+# TYPE dcgm_exporter_test_retries_total counter
+dcgm_exporter_test_retries_total{entity="CPU"} 3
+# HELP dcgm_exporter_test_status Test status.
+# TYPE dcgm_exporter_test_status gauge
+dcgm_exporter_test_status{entity="CPU Core"} 1
+dcgm_exporter_test_status{entity="CPU"} 0
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_Value(t *testing.T) {
+	r := NewRegistry()
+
+	_, exists := r.Value("dcgm_exporter_missing")
+	assert.False(t, exists)
+
+	g := r.Gauge("dcgm_exporter_value", "Value.")
+	_, exists = r.Value("dcgm_exporter_value")
+	assert.False(t, exists)
+
+	g.Set(42)
+	v, exists := r.Value("dcgm_exporter_value")
+	assert.True(t, exists)
+	assert.Equal(t, float64(42), v)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf))
+	assert.Contains(t, buf.String(), "dcgm_exporter_value 42\n")
+}
+
+func TestRegistry_Families(t *testing.T) {
+	r := NewRegistry()
+
+	status := r.Gauge("dcgm_exporter_test_status", "Test status.")
+	retries := r.Counter("dcgm_exporter_test_retries_total", "Test retries.")
+	r.Gauge("dcgm_exporter_test_unused", "Not returned without samples.")
+
+	status.Set(1, "entity", "CPU", "group", "0")
+	retries.Add(3)
+
+	families := r.Families()
+	require.Len(t, families, 2)
+
+	assert.Equal(t, "dcgm_exporter_test_retries_total", families[0].GetName())
+	assert.Equal(t, dto.MetricType_COUNTER, families[0].GetType())
+	require.Len(t, families[0].GetMetric(), 1)
+	assert.Empty(t, families[0].GetMetric()[0].GetLabel())
+	assert.Equal(t, float64(3), families[0].GetMetric()[0].GetCounter().GetValue())
+
+	assert.Equal(t, "dcgm_exporter_test_status", families[1].GetName())
+	assert.Equal(t, "Test status.", families[1].GetHelp())
+	assert.Equal(t, dto.MetricType_GAUGE, families[1].GetType())
+	require.Len(t, families[1].GetMetric(), 1)
+	labels := families[1].GetMetric()[0].GetLabel()
+	require.Len(t, labels, 2)
+	assert.Equal(t, "entity", labels[0].GetName())
+	assert.Equal(t, "CPU", labels[0].GetValue())
+	assert.Equal(t, "group", labels[1].GetName())
+	assert.Equal(t, "0", labels[1].GetValue())
+	assert.Equal(t, float64(1), families[1].GetMetric()[0].GetGauge().GetValue())
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/gorilla/mux"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	defaultResetDuration = 10 * time.Minute
+	maxResetDuration     = time.Hour
+)
+
+// readAdminToken reads the bearer token of the admin endpoints.
+func readAdminToken(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the admin token file; err: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("the admin token file '%s' is empty", filename)
+	}
+	return token, nil
+}
+
+// requireAdminToken rejects the requests, which don't carry the admin token as a bearer token.
+func requireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// registerAdminRoutes adds the endpoints, which put GPUs under maintenance and change the counters, and the
+// diagnostic endpoint, when it is enabled.
+func (s *MetricsServer) registerAdminRoutes(router *mux.Router, token string) {
+	router.HandleFunc("/admin/counters", requireAdminToken(token, s.Counters)).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/admin/gpus", requireAdminToken(token, s.MaintenanceStates)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/gpus/{gpu}/drain", requireAdminToken(token, s.Drain)).
+		Methods(http.MethodPost, http.MethodDelete)
+	router.HandleFunc("/admin/gpus/{gpu}/reset", requireAdminToken(token, s.Reset)).
+		Methods(http.MethodPost, http.MethodDelete)
+	if s.diagnostics != nil {
+		router.HandleFunc("/diag", requireAdminToken(token, s.Diag)).Methods(http.MethodGet, http.MethodPost)
+	}
+}
+
+// MaintenanceStates returns the GPUs under maintenance.
+func (s *MetricsServer) MaintenanceStates(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.maintenance.States()); err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// Drain marks the GPU as draining on POST, and clears the mark on DELETE.
+func (s *MetricsServer) Drain(w http.ResponseWriter, r *http.Request) {
+	gpu, ok := s.maintenanceGPU(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.maintenance.Undrain(gpu)
+	} else {
+		s.maintenance.Drain(gpu)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetMaintenanceReload sets the function, which creates the field watches again, when the reset of a GPU starts or
+// ends. The function must not block.
+func (s *MetricsServer) SetMaintenanceReload(reload func()) {
+	if s.maintenance != nil {
+		s.maintenance.SetReload(reload)
+	}
+}
+
+// Reset stops monitoring the GPU on POST, for the duration query parameter (10 minutes by default), and monitors
+// it again on DELETE.
+func (s *MetricsServer) Reset(w http.ResponseWriter, r *http.Request) {
+	gpu, ok := s.maintenanceGPU(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.maintenance.EndReset(gpu)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	duration := defaultResetDuration
+	if value := r.URL.Query().Get("duration"); value != "" {
+		var err error
+		duration, err = time.ParseDuration(value)
+		if err != nil || duration <= 0 || duration > maxResetDuration {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'; it must be positive and at most %s", value,
+				maxResetDuration), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.maintenance.StartReset(gpu, duration)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maintenanceGPU returns the GPU of the request, and writes an error, when it is not monitored by the exporter.
+func (s *MetricsServer) maintenanceGPU(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	id, err := strconv.ParseUint(mux.Vars(r)["gpu"], 10, 32)
+	if err != nil {
+		http.Error(w, "invalid GPU", http.StatusBadRequest)
+		return 0, false
+	}
+	gpu := uint(id)
+
+	if slices.Contains(s.monitoredGPUs(), gpu) {
+		return gpu, true
+	}
+
+	http.Error(w, fmt.Sprintf("GPU %d is not monitored", gpu), http.StatusNotFound)
+	return 0, false
+}
+
+// monitoredGPUs returns the IDs of the GPUs, which are monitored by the exporter.
+func (s *MetricsServer) monitoredGPUs() []uint {
+	var gpus []uint
+
+	_, deviceWatchListManager, _ := s.collection()
+	if deviceWatchListManager != nil {
+		if watchList, exists := deviceWatchListManager.EntityWatchList(dcgm.FE_GPU); exists {
+			for _, info := range watchList.DeviceInfo().GPUs() {
+				if info.DeviceInfo.UUID != "" && !slices.Contains(gpus, info.DeviceInfo.GPU) {
+					gpus = append(gpus, info.DeviceInfo.GPU)
+				}
+			}
+		}
+	}
+
+	return gpus
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const maxCountersRequestSize = 1 << 20
+
+// CountersUpdater adds the counters of the CSV records and removes the counters of the fields. It returns, once the
+// counters are collected, or with the error, which rolled the change back.
+type CountersUpdater func(ctx context.Context, added [][]string, removed []string) error
+
+// counterSpec is a counter of the admin API, with the columns of the counters file.
+type counterSpec struct {
+	Field string `json:"field"`
+	Type  string `json:"type"`
+	Help  string `json:"help"`
+}
+
+type countersRequest struct {
+	Add    []counterSpec `json:"add"`
+	Remove []string      `json:"remove"`
+}
+
+type skippedCounterSpec struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type countersResponse struct {
+	Counters []counterSpec        `json:"counters"`
+	Skipped  []skippedCounterSpec `json:"skipped"`
+}
+
+// SetCountersUpdater enables the admin endpoint, which changes the counters at runtime.
+func (s *MetricsServer) SetCountersUpdater(updater CountersUpdater) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.updateCounters = updater
+}
+
+// Counters returns the collected counters on GET. On POST, it adds and removes the counters of the request, and
+// returns the counters, once they are collected.
+func (s *MetricsServer) Counters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if r.Method == http.MethodPost {
+		s.Lock()
+		updateCounters := s.updateCounters
+		s.Unlock()
+		if updateCounters == nil {
+			http.Error(w, "the counters cannot be changed", http.StatusServiceUnavailable)
+			return
+		}
+
+		added, removed, err := parseCountersRequest(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := updateCounters(r.Context(), added, removed); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			http.Error(w, fmt.Sprintf("the counters were not changed: %s", err), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	_, _, counterSet := s.collection()
+
+	response := countersResponse{Counters: []counterSpec{}, Skipped: []skippedCounterSpec{}}
+	if counterSet != nil {
+		for _, counter := range counterSet.DCGMCounters {
+			response.Counters = append(response.Counters, counterSpec{counter.FieldName, counter.PromType, counter.Help})
+		}
+		for _, counter := range counterSet.ExporterCounters {
+			// The labels are copied from the DCGM counters
+			if !counter.IsLabel() {
+				response.Counters = append(response.Counters,
+					counterSpec{counter.FieldName, counter.PromType, counter.Help})
+			}
+		}
+		for _, skipped := range counterSet.Skipped {
+			response.Skipped = append(response.Skipped, skippedCounterSpec{skipped.FieldName, skipped.Reason})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// parseCountersRequest returns the CSV records of the added counters and the removed fields of the request.
+func parseCountersRequest(w http.ResponseWriter, r *http.Request) ([][]string, []string, error) {
+	var request countersRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCountersRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		return nil, nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if len(request.Add) == 0 && len(request.Remove) == 0 {
+		return nil, nil, errors.New("the request neither adds nor removes counters")
+	}
+
+	var added [][]string
+	for _, spec := range request.Add {
+		field, promType := strings.TrimSpace(spec.Field), strings.TrimSpace(spec.Type)
+		if field == "" || promType == "" {
+			return nil, nil, errors.New("the added counters need a field and a type")
+		}
+		added = append(added, []string{field, promType, strings.TrimSpace(spec.Help)})
+	}
+
+	var removed []string
+	for _, field := range request.Remove {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, nil, errors.New("the removed fields cannot be empty")
+		}
+		removed = append(removed, field)
+	}
+
+	return added, removed, nil
+}
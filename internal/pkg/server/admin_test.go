@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/maintenance"
+)
+
+func TestReadAdminToken(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0o600))
+
+	token, err := readAdminToken(tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", token)
+
+	_, err = readAdminToken(emptyFile)
+	assert.Error(t, err)
+	_, err = readAdminToken(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestAdminRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().GPUs().Return([]deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+	}).AnyTimes()
+
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).
+		Return(*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1), true).AnyTimes()
+
+	metricServer := &MetricsServer{
+		deviceWatchListManager: mockDeviceWatchListManager,
+		maintenance:            maintenance.New(),
+	}
+	router := mux.NewRouter()
+	metricServer.registerAdminRoutes(router, "s3cret")
+
+	request := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/admin/gpus/0/drain", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/admin/gpus/0/drain", "wrong").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/admin/gpus/3/drain", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/admin/gpus/0/reset?duration=2h", "s3cret").Code)
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, "/admin/gpus/0/drain", "s3cret").Code)
+	recorder := request(http.MethodGet, "/admin/gpus", "s3cret")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[{"gpu":0,"draining":true,"resetting":false}]`,
+		recorder.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/admin/gpus/0/drain", "s3cret").Code)
+	assert.Equal(t, "[]\n", request(http.MethodGet, "/admin/gpus", "s3cret").Body.String())
+}
+
+func TestAdminCounters(t *testing.T) {
+	metricServer := &MetricsServer{
+		counterSet: &counters.CounterSet{
+			DCGMCounters: []counters.Counter{{FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge", Help: "temperature"}},
+		},
+	}
+	router := mux.NewRouter()
+	metricServer.registerAdminRoutes(router, "s3cret")
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/counters", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := request(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"counters":[{"field":"DCGM_FI_DEV_GPU_TEMP","type":"gauge","help":"temperature"}],"skipped":[]}`,
+		recorder.Body.String())
+
+	// The counters cannot be changed without an updater
+	body := `{"add":[{"field":"DCGM_FI_PROF_SM_ACTIVE","type":"gauge","help":"SM active"}]}`
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodPost, body).Code)
+
+	var gotAdded [][]string
+	var gotRemoved []string
+	metricServer.SetCountersUpdater(func(_ context.Context, added [][]string, removed []string) error {
+		gotAdded, gotRemoved = added, removed
+		if len(removed) > 0 {
+			return errors.New("rolled back")
+		}
+		metricServer.SetCollection(nil, nil, &counters.CounterSet{
+			DCGMCounters: []counters.Counter{{FieldName: "DCGM_FI_PROF_SM_ACTIVE", PromType: "gauge", Help: "SM active"}},
+		})
+		return nil
+	})
+
+	recorder = request(http.MethodPost, body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, [][]string{{"DCGM_FI_PROF_SM_ACTIVE", "gauge", "SM active"}}, gotAdded)
+	assert.JSONEq(t, `{"counters":[{"field":"DCGM_FI_PROF_SM_ACTIVE","type":"gauge","help":"SM active"}],"skipped":[]}`,
+		recorder.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, request(http.MethodPost, `{"remove":["DCGM_FI_DEV_GPU_TEMP"]}`).Code)
+	assert.Equal(t, []string{"DCGM_FI_DEV_GPU_TEMP"}, gotRemoved)
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, `{"add":[{"field":"DCGM_FI_DEV_GPU_TEMP"}]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, `{"drop":["DCGM_FI_DEV_GPU_TEMP"]}`).Code)
+}
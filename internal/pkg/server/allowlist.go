@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var rejectedRequests = selfmetrics.Default().Counter("dcgm_exporter_http_rejected_requests_total",
+	"Number of HTTP requests rejected, because the source IP is not in the allowed CIDRs.")
+
+// parseAllowedSources parses CIDRs and single IP addresses, which are allowed to connect.
+func parseAllowedSources(sources []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(sources))
+
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+
+		if !strings.Contains(source, "/") {
+			addr, err := netip.ParseAddr(source)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed source '%s'; err: %w", source, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed source '%s'; err: %w", source, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// allowSources rejects requests from source IPs, which are not in the allowed prefixes.
+// When there are no allowed prefixes, all requests are allowed.
+func allowSources(allowed []netip.Prefix, next http.Handler) http.Handler {
+	if len(allowed) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAllowedSource(allowed, r.RemoteAddr) {
+			rejectedRequests.Inc()
+			slog.Debug(fmt.Sprintf("Rejected request from '%s' to '%s'", r.RemoteAddr, r.URL.Path))
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AllowSources rejects the requests from the source IPs, which are not in the allowed CIDRs of the configuration,
+// for the HTTP servers other than the MetricsServer, e.g. the one of the federation.
+func AllowSources(c *appconfig.Config, next http.Handler) (http.Handler, error) {
+	allowed, err := parseAllowedSources(c.AllowedSourceCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return allowSources(allowed, next), nil
+}
+
+func isAllowedSource(allowed []netip.Prefix, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
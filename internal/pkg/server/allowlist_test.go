@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestParseAllowedSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "CIDRs and addresses",
+			sources: []string{"10.0.0.0/8", " 192.168.1.7 ", "", "fd00::/8", "10.1.2.3/16"},
+			want:    []string{"10.0.0.0/8", "192.168.1.7/32", "fd00::/8", "10.1.0.0/16"},
+		},
+		{
+			name:    "Invalid CIDR",
+			sources: []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid address",
+			sources: []string{"prometheus"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAllowedSources(tt.sources)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var prefixes []string
+			for _, prefix := range got {
+				prefixes = append(prefixes, prefix.String())
+			}
+			assert.Equal(t, tt.want, prefixes)
+		})
+	}
+}
+
+func TestAllowSources(t *testing.T) {
+	allowed, err := parseAllowedSources([]string{"10.0.0.0/8", "2001:db8::1"})
+	require.NoError(t, err)
+
+	handler := allowSources(allowed, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{remoteAddr: "10.1.2.3:54321", want: http.StatusOK},
+		{remoteAddr: "[::ffff:10.1.2.3]:54321", want: http.StatusOK},
+		{remoteAddr: "[2001:db8::1]:54321", want: http.StatusOK},
+		{remoteAddr: "192.168.1.1:54321", want: http.StatusForbidden},
+		{remoteAddr: "[2001:db8::2]:54321", want: http.StatusForbidden},
+		{remoteAddr: "malformed", want: http.StatusForbidden},
+	}
+
+	// Rejections are counted in a separate registry, so they don't show up in the output of other tests
+	registry := selfmetrics.NewRegistry()
+	defaultRejectedRequests := rejectedRequests
+	rejectedRequests = registry.Counter("dcgm_exporter_http_rejected_requests_total", "")
+	defer func() { rejectedRequests = defaultRejectedRequests }()
+
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.want, recorder.Code)
+		})
+	}
+
+	rejected, _ := registry.Value("dcgm_exporter_http_rejected_requests_total")
+	assert.Equal(t, float64(3), rejected)
+}
+
+func TestAllowSources_AllowsAllWithoutSources(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "192.168.1.1:54321"
+
+	recorder := httptest.NewRecorder()
+	allowSources(nil, http.NotFoundHandler()).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestAllowSources_Config(t *testing.T) {
+	defaultRejectedRequests := rejectedRequests
+	rejectedRequests = selfmetrics.NewRegistry().Counter("dcgm_exporter_http_rejected_requests_total", "")
+	defer func() { rejectedRequests = defaultRejectedRequests }()
+
+	handler, err := AllowSources(&appconfig.Config{AllowedSourceCIDRs: []string{"10.0.0.0/8"}}, http.NotFoundHandler())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "192.168.1.1:54321"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	_, err = AllowSources(&appconfig.Config{AllowedSourceCIDRs: []string{"10.0.0.0/33"}}, http.NotFoundHandler())
+	assert.Error(t, err)
+}
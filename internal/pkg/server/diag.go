@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/diagnostics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// diagWriteTimeout replaces the write timeout of the server for the diagnostic requests, which respond, when the
+// diagnostic finished.
+const diagWriteTimeout = 30 * time.Minute
+
+// Diag runs the DCGM diagnostic of the level query parameter (1 by default) on the GPUs of the gpu query parameter,
+// a comma-separated list of GPU IDs (all the monitored GPUs by default), on POST, and responds with its result. It
+// responds with the result of the last diagnostic on GET.
+func (s *MetricsServer) Diag(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if r.Method == http.MethodGet {
+		result, exists := s.diagnostics.Last()
+		if !exists {
+			http.Error(w, "no diagnostic ran yet", http.StatusNotFound)
+			return
+		}
+		writeDiagResult(w, result)
+		return
+	}
+
+	level := 1
+	if value := r.URL.Query().Get("level"); value != "" {
+		var err error
+		level, err = diagnostics.ParseLevel(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	monitored := s.monitoredGPUs()
+	gpus := monitored
+	if value := r.URL.Query().Get("gpu"); value != "" {
+		gpus = nil
+		for _, id := range strings.Split(value, ",") {
+			gpu, err := strconv.ParseUint(strings.TrimSpace(id), 10, 32)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid GPU '%s'", id), http.StatusBadRequest)
+				return
+			}
+			if !slices.Contains(monitored, uint(gpu)) {
+				http.Error(w, fmt.Sprintf("GPU %d is not monitored", gpu), http.StatusNotFound)
+				return
+			}
+			gpus = append(gpus, uint(gpu))
+		}
+	}
+	if len(gpus) == 0 {
+		http.Error(w, "no GPU is monitored", http.StatusNotFound)
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(diagWriteTimeout)); err != nil {
+		slog.Warn("Cannot extend the write deadline of the diagnostic request",
+			slog.String(logging.ErrorKey, err.Error()))
+	}
+
+	result, err := s.diagnostics.Run(level, gpus)
+	if errors.Is(err, diagnostics.ErrRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		slog.Error("The DCGM diagnostic failed", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeDiagResult(w, result)
+}
+
+func writeDiagResult(w http.ResponseWriter, result diagnostics.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/diagnostics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/maintenance"
+)
+
+func TestDiag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().GPUs().Return([]deviceinfo.GPUInfo{
+		{DeviceInfo: dcgm.Device{GPU: 0, UUID: "GPU-00000000-0000-0000-0000-000000000000"}},
+		{DeviceInfo: dcgm.Device{GPU: 1, UUID: "GPU-00000000-0000-0000-0000-000000000001"}},
+	}).AnyTimes()
+
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).
+		Return(*devicewatchlistmanager.NewWatchList(mockDeviceInfo, nil, nil, nil, 1), true).AnyTimes()
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	group := dcgm.GroupHandle{}
+	mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(group, nil)
+	mockDCGM.EXPECT().AddEntityToGroup(group, dcgm.FE_GPU, uint(1)).Return(nil)
+	mockDCGM.EXPECT().RunDiag(dcgm.DiagType(dcgm.DiagMedium), group).Return(dcgm.DiagResults{
+		Software: []dcgm.DiagResult{{Status: "pass", TestName: "inforom corruption"}},
+	}, nil)
+	mockDCGM.EXPECT().DestroyGroup(group).Return(nil)
+
+	metricServer := &MetricsServer{
+		deviceWatchListManager: mockDeviceWatchListManager,
+		maintenance:            maintenance.New(),
+		diagnostics:            diagnostics.New(),
+	}
+	// The result of the diagnostic is removed from the self-metrics, which the other tests render
+	t.Cleanup(func() { diagnostics.New() })
+	router := mux.NewRouter()
+	metricServer.registerAdminRoutes(router, "s3cret")
+
+	request := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/diag", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/diag", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/diag?level=3", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/diag?gpu=a", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/diag?gpu=2", "s3cret").Code)
+
+	recorder := request(http.MethodPost, "/diag?level=2&gpu=1", "s3cret")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var result diagnostics.Result
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+	assert.Equal(t, 2, result.Level)
+	assert.Equal(t, []uint{1}, result.GPUs)
+	assert.True(t, result.Passed)
+
+	recorder = request(http.MethodGet, "/diag", "s3cret")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"name":"inforom corruption"`)
+}
+
+func TestDiag_Disabled(t *testing.T) {
+	router := mux.NewRouter()
+	(&MetricsServer{}).registerAdminRoutes(router, "s3cret")
+
+	req := httptest.NewRequest(http.MethodPost, "/diag", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/encoding"
+)
+
+const (
+	formatParam       = "format"
+	formatPrometheus  = "prometheus"
+	formatOpenMetrics = "openmetrics"
+)
+
+// selfMetricsPrefix is the prefix of the self-metrics, which counters start with the exporter.
+const selfMetricsPrefix = "dcgm_exporter_"
+
+// exemplarMaxRunes is the limit of OpenMetrics on the length of the label names and values of an exemplar.
+const exemplarMaxRunes = 128
+
+// startTime is the creation time of the self-metrics counters, written as their _created lines in OpenMetrics.
+var startTime = time.Now()
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// exemplarLabelsFunc returns the exemplar labels of a series with the labels, or nil, when there are none.
+type exemplarLabelsFunc func(labels map[string]string) map[string]string
+
+// negotiateFormat returns the format of the response: the format of the format query parameter, when it is set, and
+// the format, which the Accept header prefers, otherwise. The encoder is nil, when the format is the Prometheus text
+// format or OpenMetrics, which the exporter renders itself.
+func negotiateFormat(r *http.Request) (expfmt.Format, encoding.Encoder, error) {
+	name := r.URL.Query().Get(formatParam)
+	switch name {
+	case "":
+	case formatPrometheus:
+		return expfmt.NewFormat(expfmt.TypeTextPlain), nil, nil
+	case formatOpenMetrics:
+		return expfmt.NewFormat(expfmt.TypeOpenMetrics).WithEscapingScheme(model.NameEscapingScheme), nil, nil
+	default:
+		encoder, exists := encoding.ByName(name)
+		if !exists {
+			return "", nil, fmt.Errorf("invalid %s '%s', expected one of %s", formatParam, name,
+				strings.Join(append([]string{formatPrometheus, formatOpenMetrics}, encoding.Names()...), ", "))
+		}
+		return "", encoder, nil
+	}
+
+	if encoder, exists := encoding.Negotiate(r.Header); exists {
+		return "", encoder, nil
+	}
+	format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+	if format.FormatType() != expfmt.TypeOpenMetrics {
+		format = expfmt.NewFormat(expfmt.TypeTextPlain)
+	}
+	return format, nil, nil
+}
+
+// writeNegotiated writes the metric families in the negotiated format: OpenMetrics or the Prometheus text format. The
+// response is compressed with gzip, when the Accept-Encoding header allows it.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, families []*dto.MetricFamily, format expfmt.Format,
+	exemplars exemplarLabelsFunc,
+) error {
+	var (
+		payload []byte
+		err     error
+	)
+	if format.FormatType() == expfmt.TypeOpenMetrics {
+		payload, err = toOpenMetrics(families, format, exemplars)
+	} else {
+		payload, err = encodeFamilies(families, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeCompressed(w, r, string(format), payload)
+}
+
+// writeEncoded writes the metric families in the format of the encoder. The response is compressed with gzip, when
+// the Accept-Encoding header allows it.
+func writeEncoded(w http.ResponseWriter, r *http.Request, families []*dto.MetricFamily, encoder encoding.Encoder) error {
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, families); err != nil {
+		return fmt.Errorf("failed to encode the metrics; err: %w", err)
+	}
+
+	return writeCompressed(w, r, encoder.ContentType(), buf.Bytes())
+}
+
+// writeCompressed writes the payload with the content type, compressed with gzip, when the Accept-Encoding header
+// allows it.
+func writeCompressed(w http.ResponseWriter, r *http.Request, contentType string, payload []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept, Accept-Encoding")
+
+	if !acceptsGzip(r.Header) {
+		_, err := w.Write(payload)
+		return err
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(gz)
+	gz.Reset(w)
+
+	if _, err := gz.Write(payload); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// toOpenMetrics encodes the metric families in OpenMetrics. The counters of the self-metrics get a _created line with
+// the start time of the exporter. When exemplars is set, the other counters get the exemplar it returns for their
+// labels. OpenMetrics allows exemplars on counters and histograms only, and the counters without the _total suffix are
+// encoded as unknown, so only the counters with the suffix carry them.
+func toOpenMetrics(families []*dto.MetricFamily, format expfmt.Format, exemplars exemplarLabelsFunc) ([]byte, error) {
+	created := timestamppb.New(startTime)
+	now := timestamppb.Now()
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, format, expfmt.WithCreatedLines())
+	for _, family := range families {
+		name := family.GetName()
+		if family.GetType() == dto.MetricType_COUNTER && strings.HasPrefix(name, selfMetricsPrefix) {
+			for _, m := range family.GetMetric() {
+				m.Counter.CreatedTimestamp = created
+			}
+		} else if family.GetType() == dto.MetricType_COUNTER && strings.HasSuffix(name, "_total") && exemplars != nil {
+			for _, m := range family.GetMetric() {
+				m.Counter.Exemplar = toExemplar(exemplars(toLabels(m.GetLabel())), m.Counter.GetValue(), now)
+			}
+		}
+
+		if err := encoder.Encode(family); err != nil {
+			return nil, fmt.Errorf("failed to encode the metric family '%s'; err: %w", name, err)
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeFamilies encodes the metric families in the format.
+func encodeFamilies(families []*dto.MetricFamily, format expfmt.Format) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, format)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return nil, fmt.Errorf("failed to encode the metric family '%s'; err: %w", family.GetName(), err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// parseFamilies parses the metrics, rendered in the Prometheus text format, and returns their families sorted by
+// name.
+func parseFamilies(payload []byte) ([]*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the rendered metrics; err: %w", err)
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, family := range parsed {
+		families = append(families, family)
+	}
+	slices.SortFunc(families, func(a, b *dto.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return families, nil
+}
+
+// toLabels returns the labels of a series by name.
+func toLabels(pairs []*dto.LabelPair) map[string]string {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+// toExemplar returns the exemplar with the labels, sorted by name, and the value of the series, or nil, when there
+// are no labels. Labels are dropped from the end, until the exemplar fits in the length limit of OpenMetrics.
+func toExemplar(labels map[string]string, value float64, ts *timestamppb.Timestamp) *dto.Exemplar {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	runes := 0
+	pairs := make([]*dto.LabelPair, 0, len(names))
+	for _, name := range names {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(labels[name])
+		if runes > exemplarMaxRunes {
+			break
+		}
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(labels[name])})
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	return &dto.Exemplar{Label: pairs, Value: proto.Float64(value), Timestamp: ts}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip, i.e. lists it without a zero quality.
+func acceptsGzip(h http.Header) bool {
+	for _, value := range h.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(encoding, ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+				weight, err := strconv.ParseFloat(q, 64)
+				return err == nil && weight > 0
+			}
+			return true
+		}
+	}
+	return false
+}
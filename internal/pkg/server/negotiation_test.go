@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const negotiationPayload = `# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000"} 42
+# HELP dcgm_exporter_http_rejected_requests_total Number of rejected requests.
+# TYPE dcgm_exporter_http_rejected_requests_total counter
+dcgm_exporter_http_rejected_requests_total 3
+`
+
+func mustParseFamilies(t *testing.T, payload string) []*dto.MetricFamily {
+	t.Helper()
+
+	families, err := parseFamilies([]byte(payload))
+	require.NoError(t, err)
+	return families
+}
+
+func TestWriteNegotiated(t *testing.T) {
+	realStartTime := startTime
+	defer func() { startTime = realStartTime }()
+	startTime = time.Unix(1700000000, 0)
+
+	const openMetricsPayload = `# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-00000000-0000-0000-0000-000000000000"} 42.0
+# HELP dcgm_exporter_http_rejected_requests Number of rejected requests.
+# TYPE dcgm_exporter_http_rejected_requests counter
+dcgm_exporter_http_rejected_requests_total 3.0
+dcgm_exporter_http_rejected_requests_created 1.7e+09
+# EOF
+`
+
+	const jsonPayload = `[{"name":"DCGM_FI_DEV_GPU_UTIL","help":"GPU utilization (in %).","type":"gauge",` +
+		`"metrics":[{"labels":{"UUID":"GPU-00000000-0000-0000-0000-000000000000","gpu":"0"},"value":42}]},` +
+		`{"name":"dcgm_exporter_http_rejected_requests_total","help":"Number of rejected requests.","type":"counter",` +
+		`"metrics":[{"labels":{},"value":3}]}]
+`
+
+	const influxPayload = `DCGM_FI_DEV_GPU_UTIL,UUID=GPU-00000000-0000-0000-0000-000000000000,gpu=0 value=42
+dcgm_exporter_http_rejected_requests_total value=3
+`
+
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		acceptEncoding  string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "Prometheus text format by default",
+			wantContentType: "text/plain; version=0.0.4; charset=utf-8",
+			wantBody:        negotiationPayload,
+		},
+		{
+			name:            "Prometheus text format compressed with gzip",
+			acceptEncoding:  "gzip, deflate",
+			wantContentType: "text/plain; version=0.0.4; charset=utf-8",
+			wantBody:        negotiationPayload,
+		},
+		{
+			name:            "OpenMetrics when preferred",
+			accept:          "application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4",
+			acceptEncoding:  "gzip",
+			wantContentType: "application/openmetrics-text; version=1.0.0; charset=utf-8; escaping=underscores",
+			wantBody:        openMetricsPayload,
+		},
+		{
+			name:            "OpenMetrics by the format parameter",
+			target:          "/metrics?format=openmetrics",
+			wantContentType: "application/openmetrics-text; version=1.0.0; charset=utf-8; escaping=underscores",
+			wantBody:        openMetricsPayload,
+		},
+		{
+			name:            "Prometheus text format by the format parameter",
+			target:          "/metrics?format=prometheus",
+			accept:          "application/openmetrics-text;version=1.0.0",
+			wantContentType: "text/plain; version=0.0.4; charset=utf-8",
+			wantBody:        negotiationPayload,
+		},
+		{
+			name:            "JSON when preferred",
+			accept:          "application/json, text/plain;q=0.5",
+			acceptEncoding:  "gzip",
+			wantContentType: "application/json",
+			wantBody:        jsonPayload,
+		},
+		{
+			name:            "JSON by the format parameter",
+			target:          "/metrics?format=json",
+			accept:          "text/plain",
+			wantContentType: "application/json",
+			wantBody:        jsonPayload,
+		},
+		{
+			name:            "Influx line protocol by the format parameter",
+			target:          "/metrics?format=influx",
+			wantContentType: "application/vnd.influx.line-protocol; charset=utf-8",
+			wantBody:        influxPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/metrics"
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			format, encoder, err := negotiateFormat(req)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			if encoder != nil {
+				require.NoError(t, writeEncoded(recorder, req, mustParseFamilies(t, negotiationPayload), encoder))
+			} else {
+				require.NoError(t, writeNegotiated(recorder, req, mustParseFamilies(t, negotiationPayload), format, nil))
+			}
+
+			assert.Equal(t, tt.wantContentType, recorder.Header().Get("Content-Type"))
+
+			body := io.Reader(recorder.Body)
+			if tt.acceptEncoding != "" {
+				assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(recorder.Body)
+				require.NoError(t, err)
+				body = gz
+			} else {
+				assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+			}
+
+			got, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(got))
+		})
+	}
+}
+
+func TestNegotiateFormatInvalid(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics?format=xml", nil)
+
+	_, _, err := negotiateFormat(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prometheus, openmetrics, influx, json")
+}
+
+func TestToOpenMetricsExemplars(t *testing.T) {
+	const payload = `# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",pod="trainer"} 42
+# HELP DCGM_FI_DEV_PCIE_REPLAY_COUNTER Total number of PCIe retries.
+# TYPE DCGM_FI_DEV_PCIE_REPLAY_COUNTER counter
+DCGM_FI_DEV_PCIE_REPLAY_COUNTER{gpu="0",pod="trainer"} 5
+# HELP DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total Total energy consumption since boot (in mJ).
+# TYPE DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total counter
+DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total{gpu="0",pod="trainer"} 1000
+DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total{gpu="1",pod=""} 2000
+# HELP dcgm_exporter_http_rejected_requests_total Number of rejected requests.
+# TYPE dcgm_exporter_http_rejected_requests_total counter
+dcgm_exporter_http_rejected_requests_total{pod="trainer"} 3
+`
+
+	exemplars := func(labels map[string]string) map[string]string {
+		if labels["pod"] != "trainer" {
+			return nil
+		}
+		return map[string]string{"pod_uid": "0b7e2f44-5c1e-4f3a-9d5b-6a2f1c9e8d70", "container_id": "abc123"}
+	}
+
+	got, err := toOpenMetrics(mustParseFamilies(t, payload), expfmt.NewFormat(expfmt.TypeOpenMetrics), exemplars)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(got), "\n")
+	assert.Contains(t, lines, `DCGM_FI_DEV_GPU_UTIL{gpu="0",pod="trainer"} 42.0`)
+	assert.Contains(t, lines, `DCGM_FI_DEV_PCIE_REPLAY_COUNTER{gpu="0",pod="trainer"} 5.0`)
+	assert.Contains(t, lines, `DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total{gpu="1",pod=""} 2000.0`)
+	assert.Contains(t, lines, `dcgm_exporter_http_rejected_requests_total{pod="trainer"} 3.0`)
+
+	var exemplar string
+	for _, line := range lines {
+		if strings.HasPrefix(line, `DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total{gpu="0"`) {
+			exemplar = line
+		}
+	}
+	assert.Regexp(t, `^DCGM_FI_DEV_TOTAL_ENERGY_CONSUMPTION_total\{gpu="0",pod="trainer"\} 1000\.0 `+
+		`# \{container_id="abc123",pod_uid="0b7e2f44-5c1e-4f3a-9d5b-6a2f1c9e8d70"\} 1000\.0 \S+$`, exemplar)
+}
+
+func TestToExemplar(t *testing.T) {
+	ts := timestamppb.Now()
+
+	assert.Nil(t, toExemplar(nil, 1, ts))
+
+	exemplar := toExemplar(map[string]string{
+		"pod_uid":      strings.Repeat("u", 36),
+		"container_id": strings.Repeat("c", 100),
+	}, 1, ts)
+	require.NotNil(t, exemplar)
+	require.Len(t, exemplar.GetLabel(), 1, "the labels beyond the length limit are dropped")
+	assert.Equal(t, "container_id", exemplar.GetLabel()[0].GetName())
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{acceptEncoding: "", want: false},
+		{acceptEncoding: "gzip", want: true},
+		{acceptEncoding: "deflate, GZIP;q=0.5", want: true},
+		{acceptEncoding: "gzip;q=0", want: false},
+		{acceptEncoding: "identity", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.acceptEncoding), func(t *testing.T) {
+			h := http.Header{}
+			if tt.acceptEncoding != "" {
+				h.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			assert.Equal(t, tt.want, acceptsGzip(h))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var unauthorizedScrapes = selfmetrics.Default().Counter("dcgm_exporter_http_unauthorized_scrapes_total",
+	"Number of requests to the metrics endpoints rejected, because they carry neither an allowed bearer token "+
+		"nor an allowed client certificate.")
+
+// scrapeAuth authorizes the requests to the endpoints, which serve the metrics, by a bearer token or by the common
+// name of the verified client certificate. A request is authorized by either of them.
+type scrapeAuth struct {
+	tokens    []string
+	clientCNs []string
+}
+
+// newScrapeAuth creates the authorization of the metrics endpoints, or returns nil, when neither tokens nor client
+// common names are configured, so that the endpoints are open.
+func newScrapeAuth(tokenFile string, clientCNs []string) (*scrapeAuth, error) {
+	auth := &scrapeAuth{}
+	for _, cn := range clientCNs {
+		if cn = strings.TrimSpace(cn); cn != "" {
+			auth.clientCNs = append(auth.clientCNs, cn)
+		}
+	}
+
+	if tokenFile != "" {
+		tokens, err := readScrapeTokens(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.tokens = tokens
+	}
+
+	if len(auth.tokens) == 0 && len(auth.clientCNs) == 0 {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// readScrapeTokens reads the bearer tokens of the metrics endpoints, one per line, so that every tenant, e.g. every
+// Prometheus, can be given a token of its own, which can be revoked separately.
+func readScrapeTokens(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the metrics token file; err: %w", err)
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if token := strings.TrimSpace(line); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the metrics token file '%s' is empty", filename)
+	}
+	return tokens, nil
+}
+
+func (a *scrapeAuth) authorized(r *http.Request) bool {
+	if got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		// Every token is compared, so that the time doesn't tell, which of them matched
+		matched := 0
+		for _, token := range a.tokens {
+			matched |= subtle.ConstantTimeCompare([]byte(got), []byte(token))
+		}
+		if matched == 1 {
+			return true
+		}
+	}
+
+	// Only the certificates, which were verified against the client CA of the web config, are considered
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return slices.Contains(a.clientCNs, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}
+	return false
+}
+
+// require rejects the requests, which are not authorized. A nil scrapeAuth allows all requests.
+func (a *scrapeAuth) require(next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.authorized(r) {
+			unauthorizedScrapes.Inc()
+			slog.Debug(fmt.Sprintf("Rejected unauthorized request from '%s' to '%s'", r.RemoteAddr, r.URL.Path))
+			if len(a.tokens) > 0 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireScrapeAuth rejects the requests, which carry neither an allowed bearer token nor an allowed client
+// certificate of the configuration, for the metrics endpoints, which are not served by the MetricsServer, e.g. the
+// merged metrics of the federation.
+func RequireScrapeAuth(c *appconfig.Config, next http.HandlerFunc) (http.HandlerFunc, error) {
+	auth, err := newScrapeAuth(c.MetricsTokenFile, c.MetricsAllowedClientCNs)
+	if err != nil {
+		return nil, err
+	}
+	return auth.require(next), nil
+}
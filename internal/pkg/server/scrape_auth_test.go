@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestNewScrapeAuth(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "tokens")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("tenant-a\n\n  tenant-b  \n"), 0o600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0o600))
+
+	auth, err := newScrapeAuth(tokenFile, []string{" prometheus ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, auth.tokens)
+	assert.Equal(t, []string{"prometheus"}, auth.clientCNs)
+
+	auth, err = newScrapeAuth("", nil)
+	require.NoError(t, err)
+	assert.Nil(t, auth, "the metrics are open, when nothing is configured")
+
+	_, err = newScrapeAuth(emptyFile, nil)
+	assert.Error(t, err)
+	_, err = newScrapeAuth(filepath.Join(dir, "missing"), nil)
+	assert.Error(t, err)
+}
+
+func TestScrapeAuth_Require(t *testing.T) {
+	verified := func(cn string) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		auth   *scrapeAuth
+		token  string
+		tls    *tls.ConnectionState
+		status int
+	}{
+		{
+			name:   "Open",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Allowed token",
+			auth:   &scrapeAuth{tokens: []string{"tenant-a", "tenant-b"}},
+			token:  "tenant-b",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Wrong token",
+			auth:   &scrapeAuth{tokens: []string{"tenant-a"}},
+			token:  "tenant-c",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "Missing token",
+			auth:   &scrapeAuth{tokens: []string{"tenant-a"}},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "Allowed client certificate",
+			auth:   &scrapeAuth{clientCNs: []string{"prometheus"}},
+			tls:    verified("prometheus"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Client certificate of another client",
+			auth:   &scrapeAuth{clientCNs: []string{"prometheus"}},
+			tls:    verified("someone-else"),
+			status: http.StatusForbidden,
+		},
+		{
+			name: "Unverified client certificate",
+			auth: &scrapeAuth{clientCNs: []string{"prometheus"}},
+			tls: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "prometheus"}}},
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Client certificate, when tokens are allowed too",
+			auth:   &scrapeAuth{tokens: []string{"tenant-a"}, clientCNs: []string{"prometheus"}},
+			tls:    verified("prometheus"),
+			status: http.StatusOK,
+		},
+	}
+
+	// Rejections are counted in a separate registry, so they don't show up in the output of other tests
+	registry := selfmetrics.NewRegistry()
+	defaultUnauthorizedScrapes := unauthorizedScrapes
+	unauthorizedScrapes = registry.Counter("dcgm_exporter_http_unauthorized_scrapes_total", "")
+	defer func() { unauthorizedScrapes = defaultUnauthorizedScrapes }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.auth.require(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			req.TLS = tt.tls
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+
+	rejected, _ := registry.Value("dcgm_exporter_http_unauthorized_scrapes_total")
+	assert.Equal(t, float64(4), rejected)
+}
+
+func TestRequireScrapeAuth(t *testing.T) {
+	defaultUnauthorizedScrapes := unauthorizedScrapes
+	unauthorizedScrapes = selfmetrics.NewRegistry().Counter("dcgm_exporter_http_unauthorized_scrapes_total", "")
+	defer func() { unauthorizedScrapes = defaultUnauthorizedScrapes }()
+
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("tenant-a\n"), 0o600))
+
+	handler, err := RequireScrapeAuth(&appconfig.Config{MetricsTokenFile: tokenFile},
+		func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer tenant-a")
+	recorder = httptest.NewRecorder()
+	handler(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	_, err = RequireScrapeAuth(&appconfig.Config{MetricsTokenFile: filepath.Join(t.TempDir(), "missing")},
+		func(http.ResponseWriter, *http.Request) {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var scrapeCacheAgeGauge = selfmetrics.Default().Gauge("dcgm_exporter_scrape_cache_age_seconds",
+	"Age of the cached metrics served by the last full scrape: 0 when the scrape rendered them.")
+
+// scrapeCache keeps the metrics rendered by a full scrape for the TTL, so that concurrent scrapes, e.g. of several
+// Prometheus replicas, are served the same payload, instead of each reading DCGM. Scrapes, which arrive while the
+// metrics are rendered, wait for the render and share its payload.
+type scrapeCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	// mtx is held while the metrics are rendered
+	mtx        sync.Mutex
+	payload    []byte
+	renderedAt time.Time
+}
+
+func newScrapeCache(ttl time.Duration) *scrapeCache {
+	return &scrapeCache{ttl: ttl, now: time.Now}
+}
+
+// write writes the cached metrics, unless they are older than the TTL; then it renders and caches them first. Failed
+// renders are not cached.
+func (c *scrapeCache) write(w io.Writer, render func(io.Writer) error) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.now()
+	if c.payload == nil || now.Sub(c.renderedAt) >= c.ttl {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			return err
+		}
+		c.payload = buf.Bytes()
+		c.renderedAt = now
+	}
+
+	scrapeCacheAgeGauge.Set(now.Sub(c.renderedAt).Seconds())
+	_, err := w.Write(c.payload)
+	return err
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestScrapeCache(t *testing.T) {
+	t.Cleanup(scrapeCacheAgeGauge.Reset)
+
+	now := time.Unix(1700000000, 0)
+	cache := newScrapeCache(5 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	renders := 0
+	render := func(w io.Writer) error {
+		renders++
+		_, err := fmt.Fprintf(w, "render %d\n", renders)
+		return err
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, cache.write(&out, render))
+	assert.Equal(t, "render 1\n", out.String())
+
+	now = now.Add(2 * time.Second)
+	out.Reset()
+	require.NoError(t, cache.write(&out, render))
+	assert.Equal(t, "render 1\n", out.String())
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_scrape_cache_age_seconds")
+	assert.Equal(t, 2.0, value)
+
+	now = now.Add(3 * time.Second)
+	out.Reset()
+	require.NoError(t, cache.write(&out, render))
+	assert.Equal(t, "render 2\n", out.String())
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_scrape_cache_age_seconds")
+	assert.Equal(t, 0.0, value)
+}
+
+func TestScrapeCacheDoesNotCacheErrors(t *testing.T) {
+	t.Cleanup(scrapeCacheAgeGauge.Reset)
+
+	cache := newScrapeCache(time.Minute)
+
+	err := cache.write(io.Discard, func(w io.Writer) error {
+		return errors.New("boom")
+	})
+	require.Error(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, cache.write(&out, func(w io.Writer) error {
+		_, err := io.WriteString(w, "metrics\n")
+		return err
+	}))
+	assert.Equal(t, "metrics\n", out.String())
+}
+
+func TestScrapeCacheConcurrentScrapesRenderOnce(t *testing.T) {
+	t.Cleanup(scrapeCacheAgeGauge.Reset)
+
+	cache := newScrapeCache(time.Minute)
+
+	var renders atomic.Int32
+	render := func(w io.Writer) error {
+		renders.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		_, err := io.WriteString(w, "metrics\n")
+		return err
+	}
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, 8)
+	for i := range outputs {
+		wg.Add(1)
+		go func(out *bytes.Buffer) {
+			defer wg.Done()
+			assert.NoError(t, cache.write(out, render))
+		}(&outputs[i])
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), renders.Load())
+	for _, out := range outputs {
+		assert.Equal(t, "metrics\n", out.String())
+	}
+}
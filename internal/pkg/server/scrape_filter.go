@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+const (
+	entityTypeParam = "entity_type"
+	shardParam      = "shard"
+)
+
+// entityTypeNames maps values of the entity_type query parameter to entity groups.
+var entityTypeNames = map[string]dcgm.Field_Entity_Group{
+	"gpu":      dcgm.FE_GPU,
+	"switch":   dcgm.FE_SWITCH,
+	"link":     dcgm.FE_LINK,
+	"cpu":      dcgm.FE_CPU,
+	"cpu_core": dcgm.FE_CPU_CORE,
+	"vgpu":     dcgm.FE_VGPU,
+}
+
+// scrapeFilter selects the part of the metrics rendered by a single scrape, so that several scrape jobs can
+// split the metrics of a node, which exceed the response limits of Prometheus.
+type scrapeFilter struct {
+	// entityTypes are the entity groups to render, all of them when empty
+	entityTypes []dcgm.Field_Entity_Group
+	// shard is the 1-based index of the rendered shard out of shards; shards is 0 when sharding is disabled
+	shard  uint32
+	shards uint32
+	// complete is set, when the collectors are waited for, however long they take, instead of serving the latest
+	// metrics of the ones, which don't finish within the scrape deadline
+	complete bool
+}
+
+// parseScrapeFilter parses the entity_type and shard query parameters, for example
+// entity_type=link&shard=1of4. The entity_type parameter can be repeated or take a comma separated list.
+func parseScrapeFilter(query url.Values) (scrapeFilter, error) {
+	var filter scrapeFilter
+
+	for _, value := range query[entityTypeParam] {
+		for _, name := range strings.Split(value, ",") {
+			entityType, exists := entityTypeNames[strings.ToLower(strings.TrimSpace(name))]
+			if !exists {
+				return scrapeFilter{}, fmt.Errorf("invalid %s '%s'", entityTypeParam, name)
+			}
+			if !slices.Contains(filter.entityTypes, entityType) {
+				filter.entityTypes = append(filter.entityTypes, entityType)
+			}
+		}
+	}
+
+	if value := query.Get(shardParam); value != "" {
+		shard, shards, found := strings.Cut(value, "of")
+		if !found {
+			return scrapeFilter{}, fmt.Errorf("invalid %s '%s', expected <index>of<count>", shardParam, value)
+		}
+
+		index, err := strconv.ParseUint(shard, 10, 32)
+		if err != nil {
+			return scrapeFilter{}, fmt.Errorf("invalid %s '%s'; err: %w", shardParam, value, err)
+		}
+		count, err := strconv.ParseUint(shards, 10, 32)
+		if err != nil {
+			return scrapeFilter{}, fmt.Errorf("invalid %s '%s'; err: %w", shardParam, value, err)
+		}
+		if index < 1 || index > count {
+			return scrapeFilter{}, fmt.Errorf("invalid %s '%s', the index must be between 1 and the count",
+				shardParam, value)
+		}
+
+		filter.shard, filter.shards = uint32(index), uint32(count)
+	}
+
+	return filter, nil
+}
+
+// isPrimary reports whether the scrape renders the self-metrics and drives the adaptive collect interval.
+// Only the first shard of the scrape including GPUs does, so that they are not duplicated across the scrape
+// jobs of a split node.
+func (f scrapeFilter) isPrimary() bool {
+	if len(f.entityTypes) > 0 && !slices.Contains(f.entityTypes, dcgm.FE_GPU) {
+		return false
+	}
+
+	return f.shards == 0 || f.shard == 1
+}
+
+// isFull reports whether the scrape renders the metrics of all entities.
+func (f scrapeFilter) isFull() bool {
+	return len(f.entityTypes) == 0 && f.shards == 0
+}
+
+// apply removes the metrics of entities, which belong to other shards. Counters left without metrics are removed.
+func (f scrapeFilter) apply(metricGroups registry.MetricsByCounterGroup) {
+	if f.shards <= 1 {
+		return
+	}
+
+	for _, metrics := range metricGroups {
+		for counter, values := range metrics {
+			values = slices.DeleteFunc(values, func(m collector.Metric) bool {
+				return entityShard(m, f.shards) != f.shard
+			})
+			if len(values) == 0 {
+				delete(metrics, counter)
+				continue
+			}
+			metrics[counter] = values
+		}
+	}
+}
+
+// entityShard assigns all metrics of an entity to the same 1-based shard.
+func entityShard(m collector.Metric, shards uint32) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(m.GPUDevice + "/" + m.GPU + "/" + m.GPUInstanceID))
+	return h.Sum32()%shards + 1
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+func TestParseScrapeFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    scrapeFilter
+		wantErr bool
+	}{
+		{
+			name:  "No parameters",
+			query: "",
+			want:  scrapeFilter{},
+		},
+		{
+			name:  "Entity types and shard",
+			query: "entity_type=link,switch&entity_type=LINK&shard=2of4",
+			want: scrapeFilter{
+				entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_LINK, dcgm.FE_SWITCH},
+				shard:       2,
+				shards:      4,
+			},
+		},
+		{
+			name:    "Unknown entity type",
+			query:   "entity_type=fan",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed shard",
+			query:   "shard=2/4",
+			wantErr: true,
+		},
+		{
+			name:    "Shard out of range",
+			query:   "shard=0of4",
+			wantErr: true,
+		},
+		{
+			name:    "Shard above count",
+			query:   "shard=5of4",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			got, err := parseScrapeFilter(query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScrapeFilter_IsPrimary(t *testing.T) {
+	assert.True(t, scrapeFilter{}.isPrimary())
+	assert.True(t, scrapeFilter{shard: 1, shards: 4}.isPrimary())
+	assert.False(t, scrapeFilter{shard: 2, shards: 4}.isPrimary())
+	assert.True(t, scrapeFilter{entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_GPU, dcgm.FE_LINK}}.isPrimary())
+	assert.False(t, scrapeFilter{entityTypes: []dcgm.Field_Entity_Group{dcgm.FE_LINK}}.isPrimary())
+}
+
+func TestScrapeFilter_Apply(t *testing.T) {
+	powerCounter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+	tempCounter := counters.Counter{FieldID: 150, FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge"}
+
+	newMetricGroups := func() registry.MetricsByCounterGroup {
+		metrics := collector.MetricsByCounter{}
+		for _, gpu := range []string{"0", "1", "2", "3", "4", "5", "6", "7"} {
+			for _, counter := range []counters.Counter{powerCounter, tempCounter} {
+				metrics[counter] = append(metrics[counter], collector.Metric{Counter: counter, GPU: gpu})
+			}
+		}
+		return registry.MetricsByCounterGroup{dcgm.FE_GPU: metrics}
+	}
+
+	const shards = 3
+	seen := map[string]uint32{}
+	total := 0
+
+	for shard := uint32(1); shard <= shards; shard++ {
+		metricGroups := newMetricGroups()
+		scrapeFilter{shard: shard, shards: shards}.apply(metricGroups)
+
+		for counter, values := range metricGroups[dcgm.FE_GPU] {
+			require.NotEmpty(t, values, "counters without metrics must be removed")
+			for _, m := range values {
+				if counter == powerCounter {
+					total++
+				}
+				// All metrics of an entity belong to the same shard
+				if previous, exists := seen[m.GPU]; exists {
+					assert.Equal(t, previous, shard, "GPU %s", m.GPU)
+				}
+				seen[m.GPU] = shard
+			}
+		}
+	}
+
+	// Every entity is rendered by exactly one shard
+	assert.Equal(t, 8, total)
+	assert.Len(t, seen, 8)
+
+	metricGroups := newMetricGroups()
+	scrapeFilter{}.apply(metricGroups)
+	assert.Equal(t, newMetricGroups(), metricGroups)
+}
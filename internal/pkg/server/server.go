@@ -18,39 +18,73 @@ package server
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 	"github.com/gorilla/mux"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	"github.com/prometheus/exporter-toolkit/web"
 
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/adaptiveinterval"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dashboardmodel"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/diagnostics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dmon"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/downsample"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/idlemode"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/maintenance"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/rendermetrics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/transformation"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/utils"
 )
 
 const internalServerError = "internal server error"
 
+// maxGatherDeadline caps the time a scrape waits for the collectors, so that the response is written within the
+// write timeout of the server. The collectors, which don't finish in time, are served from their latest gathering.
+const maxGatherDeadline = 5 * time.Second
+
 func NewMetricsServer(
 	c *appconfig.Config,
-	metrics chan string,
 	deviceWatchListManager devicewatchlistmanager.Manager,
 	registry *registry.Registry,
+	counterSet *counters.CounterSet,
+	bus *eventbus.Bus,
 ) (*MetricsServer, func(), error) {
+	allowedSources, err := parseAllowedSources(c.AllowedSourceCIDRs)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	auth, err := newScrapeAuth(c.MetricsTokenFile, c.MetricsAllowedClientCNs)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
 	router := mux.NewRouter()
 	serverv1 := &MetricsServer{
 		server: &http.Server{
 			Addr:         c.Address,
-			Handler:      router,
+			Handler:      allowSources(allowedSources, router),
 			ReadTimeout:  10 * time.Second,
 			WriteTimeout: 10 * time.Second,
 		},
@@ -59,12 +93,24 @@ func NewMetricsServer(
 			WebSystemdSocket:   &c.WebSystemdSocket,
 			WebConfigFile:      &c.WebConfigFile,
 		},
-		metricsChan:            metrics,
-		metrics:                "",
 		registry:               registry,
 		config:                 c,
 		transformations:        transformation.GetTransformations(c),
 		deviceWatchListManager: deviceWatchListManager,
+		counterSet:             counterSet,
+		gpuInstanceMetrics:     c.GPUInstanceMetrics,
+		bus:                    bus,
+	}
+
+	if c.AdaptiveCollectInterval {
+		serverv1.scrapeTracker = adaptiveinterval.NewTracker(
+			time.Duration(c.CollectInterval)*time.Millisecond,
+			time.Duration(c.MinCollectInterval)*time.Millisecond,
+			time.Duration(c.MaxCollectInterval)*time.Millisecond,
+			func(interval int64) error {
+				reg, _, _ := serverv1.collection()
+				return reg.SetCollectInterval(interval)
+			})
 	}
 
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -85,9 +131,58 @@ func NewMetricsServer(
 	})
 
 	router.HandleFunc("/health", serverv1.Health)
-	router.HandleFunc("/metrics", serverv1.Metrics)
+	router.HandleFunc("/metrics", auth.require(serverv1.Metrics))
+	router.HandleFunc("/dashboard-model", serverv1.DashboardModel)
+	router.HandleFunc("/api/v1/series/metadata", auth.require(serverv1.SeriesMetadata))
+
+	if len(c.DownsampleCounters) > 0 {
+		serverv1.downsampler = downsample.New(c.DownsampleCounters, c.DownsampleWindow)
+		router.HandleFunc("/metrics/downsampled", auth.require(serverv1.DownsampledMetrics))
+	}
+
+	if c.AdminTokenFile != "" {
+		token, err := readAdminToken(c.AdminTokenFile)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		serverv1.maintenance = maintenance.New()
+		if c.DiagEndpoint {
+			serverv1.diagnostics = diagnostics.New()
+		}
+		serverv1.registerAdminRoutes(router, token)
+	}
+
+	if c.StaleMetricsMaxAge > 0 {
+		serverv1.standby = newStandby(c.StaleMetricsMaxAge)
+	}
+
+	if c.ScrapeCacheTTL > 0 {
+		serverv1.scrapeCache = newScrapeCache(c.ScrapeCacheTTL)
+	}
+
+	if len(c.DmonColumns) > 0 {
+		serverv1.dmonColumns, err = dmon.ParseColumns(c.DmonColumns)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		router.HandleFunc("/compat/dmon", auth.require(serverv1.Dmon))
+	}
+
+	if len(c.IdleCounters) > 0 {
+		if !hasCollectedCounter(counterSet, c.IdleCounters) {
+			return nil, func() {}, fmt.Errorf("none of the idle counters %v is collected, so GPU allocations "+
+				"could not be detected while idle", c.IdleCounters)
+		}
+		serverv1.idleMode = idlemode.New(c.IdleCounters, c.IdleAfter, time.Now())
+	}
 
-	return serverv1, func() {}, nil
+	return serverv1, func() {
+		for _, t := range serverv1.transformations {
+			if stopper, ok := t.(transformation.Stopper); ok {
+				stopper.Stop()
+			}
+		}
+	}, nil
 }
 
 func (s *MetricsServer) Run(stop chan interface{}, wg *sync.WaitGroup) {
@@ -104,6 +199,12 @@ func (s *MetricsServer) Run(stop chan interface{}, wg *sync.WaitGroup) {
 		}
 	}()
 
+	if s.downsampler != nil && s.bus != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.downsampler.Run(s.bus.Collections.Subscribe(ctx, 1))
+	}
+
 	httpwg.Add(1)
 	go func() {
 		defer httpwg.Done()
@@ -131,48 +232,256 @@ func (s *MetricsServer) fatal() {
 	os.Exit(1)
 }
 
-func (s *MetricsServer) Metrics(w http.ResponseWriter, _ *http.Request) {
+// SetCollection swaps the components, which collect the served metrics, while the server is running.
+func (s *MetricsServer) SetCollection(
+	deviceWatchListManager devicewatchlistmanager.Manager,
+	registry *registry.Registry,
+	counterSet *counters.CounterSet,
+) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.deviceWatchListManager = deviceWatchListManager
+	s.registry = registry
+	s.counterSet = counterSet
+}
+
+// collection returns the components, which collect the served metrics.
+func (s *MetricsServer) collection() (*registry.Registry, devicewatchlistmanager.Manager, *counters.CounterSet) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.registry, s.deviceWatchListManager, s.counterSet
+}
+
+func (s *MetricsServer) Metrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Content-Type-Options", "nosniff")
-	metricGroups, err := s.registry.Gather()
+	filter, err := parseScrapeFilter(r.URL.Query())
 	if err != nil {
-		slog.Error("Failed to gather metrics from collectors", slog.String(logging.ErrorKey, err.Error()))
-		http.Error(w, internalServerError, http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	var buf bytes.Buffer
-	err = s.render(&buf, metricGroups)
+	if s.scrapeTracker != nil && filter.isPrimary() {
+		s.scrapeTracker.ObserveScrape(time.Now())
+	}
+
+	format, encoder, err := negotiateFormat(r)
 	if err != nil {
-		http.Error(w, internalServerError, http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	_, err = w.Write(buf.Bytes())
-	if err != nil {
-		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
-		http.Error(w, "failed to write response", http.StatusInternalServerError)
+
+	if encoder != nil || format.FormatType() == expfmt.TypeOpenMetrics {
+		// OpenMetrics and the other formats are written from the metric families of the whole payload
+		families, err := s.gatherFamilies(filter)
+		if err != nil {
+			http.Error(w, internalServerError, http.StatusInternalServerError)
+			return
+		}
+		if encoder != nil {
+			err = writeEncoded(w, r, families, encoder)
+		} else {
+			err = writeNegotiated(w, r, families, format, s.exemplarLabels())
+		}
+		if err != nil {
+			slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+		}
 		return
 	}
+
+	sw := newStreamWriter(w, r)
+	err = s.writeMetrics(sw, filter)
+	if err == nil {
+		err = sw.Close()
+	}
+	if err != nil {
+		if sw.Written() {
+			// The response is already on its way, so it can only be cut short
+			slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+			return
+		}
+		w.Header().Del("Content-Encoding")
+		http.Error(w, internalServerError, http.StatusInternalServerError)
+	}
 }
 
-func (s *MetricsServer) render(w io.Writer, metricGroups registry.MetricsByCounterGroup) error {
-	for group, metrics := range metricGroups {
-		deviceWatchList, exists := s.deviceWatchListManager.EntityWatchList(group)
-		if exists {
-			for _, transformation := range s.transformations {
-				err := transformation.Process(metrics, deviceWatchList.DeviceInfo())
-				if err != nil {
-					slog.LogAttrs(context.Background(), slog.LevelError, "Failed to apply transformations on metrics",
-						slog.String(logging.ErrorKey, err.Error()),
-						slog.String(logging.FieldEntityGroupKey, group.String()),
-						slog.Any(logging.MetricsKey, metrics),
-						slog.Any(logging.DeviceInfoKey, deviceWatchList.DeviceInfo),
-					)
-					return err
-				}
+// writeStandby writes the last rendered metrics, unless they are too old. It reports whether they were written.
+func (s *MetricsServer) writeStandby(w io.Writer) (bool, error) {
+	payload, ok := s.standby.load(time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	slog.Debug("Serving the last rendered metrics, while the collection restarts or fails")
+	_, err := w.Write(payload)
+	return true, err
+}
+
+// WriteMetrics gathers the metrics from the registered collectors and writes them in the Prometheus text format.
+func (s *MetricsServer) WriteMetrics(w io.Writer) error {
+	return s.writeMetrics(w, scrapeFilter{})
+}
+
+// WriteCompleteMetrics writes the metrics like WriteMetrics, but waits for every collector to finish, however long it
+// takes, so that no collector is left out, e.g. of a one-shot collection.
+func (s *MetricsServer) WriteCompleteMetrics(w io.Writer) error {
+	return s.writeMetrics(w, scrapeFilter{complete: true})
+}
+
+// writeMetrics writes the metrics, followed by the self-metrics, when the scrape is the primary one. The metrics of
+// full scrapes are served from the scrape cache, when it is enabled.
+func (s *MetricsServer) writeMetrics(w io.Writer, filter scrapeFilter) error {
+	var err error
+	if s.scrapeCache != nil && filter.isFull() {
+		err = s.scrapeCache.write(w, func(w io.Writer) error {
+			return s.writeCollected(w, filter)
+		})
+	} else {
+		err = s.writeCollected(w, filter)
+	}
+	if err != nil || !filter.isPrimary() {
+		return err
+	}
+	return s.renderSelfMetrics(w)
+}
+
+// gatherFamilies gathers the metrics as metric families, followed by the self-metrics, when the scrape is the primary
+// one. The scrape cache keeps the Prometheus text format, so it is not used, and the last metrics served by the
+// standby are parsed from it.
+func (s *MetricsServer) gatherFamilies(filter scrapeFilter) ([]*dto.MetricFamily, error) {
+	encoder := &rendermetrics.FamilyEncoder{}
+	var standby bytes.Buffer
+	if err := s.encodeCollected(&standby, filter, encoder); err != nil {
+		return nil, err
+	}
+
+	families := encoder.Families()
+	if standby.Len() > 0 {
+		var err error
+		families, err = parseFamilies(standby.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !filter.isPrimary() {
+		return families, nil
+	}
+
+	families = append(families, selfmetrics.Default().Families()...)
+	slices.SortStableFunc(families, func(a, b *dto.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return families, nil
+}
+
+// writeCollected gathers the metrics and writes them, group by group, as they are rendered. Nothing is written
+// before the metrics are gathered and transformed, so that their errors can still fail the response.
+func (s *MetricsServer) writeCollected(w io.Writer, filter scrapeFilter) error {
+	return s.encodeCollected(w, filter, rendermetrics.TextEncoder{})
+}
+
+// encodeCollected gathers and transforms the metrics and encodes them with the encoder. When the standby serves the
+// last metrics instead, they are written to w in the Prometheus text format. The standby keeps a copy of the metrics
+// of the full scrapes, which are rendered in that format.
+func (s *MetricsServer) encodeCollected(w io.Writer, filter scrapeFilter, encoder rendermetrics.Encoder) error {
+	reg, deviceWatchListManager, _ := s.collection()
+	metricGroups, err := s.gather(reg, filter)
+	if s.standby != nil && filter.isFull() &&
+		(err != nil || isEmpty(metricGroups) || s.standby.isPartial(reg, metricGroups)) {
+		if ok, writeErr := s.writeStandby(w); ok {
+			return writeErr
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to gather metrics from collectors", slog.String(logging.ErrorKey, err.Error()))
+		return err
+	}
+	filter.apply(metricGroups)
+	if s.idleMode != nil {
+		s.idleMode.Filter(metricGroups)
+	}
+	if s.maintenance != nil {
+		s.maintenance.Annotate(metricGroups)
+	}
+	err = s.transform(deviceWatchListManager, metricGroups)
+	if err != nil {
+		if s.standby != nil && filter.isFull() {
+			if ok, writeErr := s.writeStandby(w); ok {
+				return writeErr
 			}
+		}
+		return err
+	}
 
-			err := rendermetrics.RenderGroup(w, group, metrics)
+	// The standby keeps a copy of the full scrapes only
+	_, text := encoder.(rendermetrics.TextEncoder)
+	var rendered *bytes.Buffer
+	if s.standby != nil && filter.isFull() && text {
+		rendered = &bytes.Buffer{}
+		w = io.MultiWriter(w, rendered)
+	}
+	err = s.render(w, deviceWatchListManager, metricGroups, encoder)
+	if err != nil {
+		return err
+	}
+	if rendered != nil {
+		s.standby.store(rendered.Bytes(), reg, metricGroups, time.Now())
+	}
+	return nil
+}
+
+// gather gathers the metrics of the entity groups of the filter. Unless the filter is complete, the collectors, which
+// don't finish within the scrape deadline, are served from their latest metrics.
+func (s *MetricsServer) gather(reg *registry.Registry, filter scrapeFilter) (registry.MetricsByCounterGroup, error) {
+	if filter.complete {
+		return reg.Gather(filter.entityTypes...)
+	}
+
+	deadline := s.gatherDeadline()
+	metricGroups, overruns, err := reg.GatherWithin(deadline, registry.MaxStalenessIntervals*s.collectInterval(),
+		filter.entityTypes...)
+	if overruns > 0 {
+		slog.Debug("Collectors did not finish within the scrape deadline; serving their latest metrics",
+			slog.Int("collectors", overruns), slog.Duration("deadline", deadline))
+	}
+	return metricGroups, err
+}
+
+func (s *MetricsServer) renderSelfMetrics(w io.Writer) error {
+	err := selfmetrics.Default().Render(w)
+	if err != nil {
+		slog.Error("Failed to render self-metrics", slog.String(logging.ErrorKey, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// exemplarLabels returns the exemplar labels of the transformation, which attributes the metrics to pods, or nil,
+// when none links the metrics to exemplars.
+func (s *MetricsServer) exemplarLabels() exemplarLabelsFunc {
+	for _, t := range s.transformations {
+		if labeler, ok := t.(transformation.ExemplarLabeler); ok {
+			return labeler.ExemplarLabels
+		}
+	}
+	return nil
+}
+
+// transform applies the transformations, e.g. the pod mapping, to the metrics of the groups, which have a watch list.
+func (s *MetricsServer) transform(
+	deviceWatchListManager devicewatchlistmanager.Manager, metricGroups registry.MetricsByCounterGroup,
+) error {
+	for group, metrics := range metricGroups {
+		deviceWatchList, exists := deviceWatchListManager.EntityWatchList(group)
+		if !exists {
+			continue
+		}
+
+		for _, transformation := range s.transformations {
+			err := transformation.Process(metrics, deviceWatchList.DeviceInfo())
 			if err != nil {
-				slog.LogAttrs(context.Background(), slog.LevelError, "Failed to renderGroup metrics",
+				slog.LogAttrs(context.Background(), slog.LevelError, "Failed to apply transformations on metrics",
 					slog.String(logging.ErrorKey, err.Error()),
 					slog.String(logging.FieldEntityGroupKey, group.String()),
 					slog.Any(logging.MetricsKey, metrics),
@@ -181,10 +490,190 @@ func (s *MetricsServer) render(w io.Writer, metricGroups registry.MetricsByCount
 				return err
 			}
 		}
+
+		if s.idleMode != nil && group == dcgm.FE_GPU {
+			s.idleMode.Observe(time.Now(), hasAttributedMetric(metrics))
+		}
 	}
 	return nil
 }
 
+// collectInterval returns the current collect interval.
+func (s *MetricsServer) collectInterval() time.Duration {
+	if s.scrapeTracker != nil {
+		return s.scrapeTracker.Current()
+	}
+	if s.config != nil {
+		return time.Duration(s.config.CollectInterval) * time.Millisecond
+	}
+	return 0
+}
+
+// gatherDeadline returns the time a scrape waits for the collectors: the collect interval, at most maxGatherDeadline.
+func (s *MetricsServer) gatherDeadline() time.Duration {
+	interval := s.collectInterval()
+	if interval <= 0 || interval > maxGatherDeadline {
+		return maxGatherDeadline
+	}
+	return interval
+}
+
+// render encodes the transformed metrics of the groups, which have a watch list.
+func (s *MetricsServer) render(
+	w io.Writer, deviceWatchListManager devicewatchlistmanager.Manager, metricGroups registry.MetricsByCounterGroup,
+	encoder rendermetrics.Encoder,
+) error {
+	groups := make(registry.MetricsByCounterGroup, len(metricGroups))
+	for group, metrics := range metricGroups {
+		if _, exists := deviceWatchListManager.EntityWatchList(group); !exists {
+			continue
+		}
+
+		groups[group] = rendermetrics.SeparateGPUInstances(group, metrics, s.gpuInstanceMetrics)
+	}
+
+	err := encoder.Encode(w, groups)
+	if err != nil {
+		slog.LogAttrs(context.Background(), slog.LevelError, "Failed to render metrics",
+			slog.String(logging.ErrorKey, err.Error()),
+			slog.Any(logging.MetricsKey, groups),
+		)
+	}
+	return err
+}
+
+// hasAttributedMetric reports whether any metric was attributed to a pod.
+func hasAttributedMetric(metrics collector.MetricsByCounter) bool {
+	for _, values := range metrics {
+		if slices.ContainsFunc(values, transformation.IsAttributed) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCollectedCounter reports whether any of the named counters is collected.
+func hasCollectedCounter(counterSet *counters.CounterSet, names []string) bool {
+	if counterSet == nil {
+		return false
+	}
+	collected := slices.Concat(counterSet.DCGMCounters, counterSet.ExporterCounters)
+	return slices.ContainsFunc(collected, func(c counters.Counter) bool {
+		return slices.Contains(names, c.FieldName)
+	})
+}
+
+// DownsampledMetrics writes the averages and maxima of the downsampled counters over the last completed window.
+func (s *MetricsServer) DownsampledMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	var buf bytes.Buffer
+	if err := s.downsampler.Render(&buf); err != nil {
+		slog.Error("Failed to render downsampled metrics", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, internalServerError, http.StatusInternalServerError)
+		return
+	}
+	_, err := w.Write(buf.Bytes())
+	if err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// Dmon writes the latest GPU metrics in the CSV format of nvidia-smi dmon.
+func (s *MetricsServer) Dmon(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	reg, _, _ := s.collection()
+	metricGroups, err := reg.Gather(dcgm.FE_GPU)
+	if err != nil {
+		slog.Error("Failed to gather metrics from collectors", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, internalServerError, http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := dmon.Render(&buf, s.dmonColumns, metricGroups[dcgm.FE_GPU]); err != nil {
+		slog.Error("Failed to render dmon metrics", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, internalServerError, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	_, err = w.Write(buf.Bytes())
+	if err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
+// DashboardModel returns a JSON description of the enabled counters, grouped by subsystem,
+// with suggested panel types and units.
+func (s *MetricsServer) DashboardModel(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/json")
+
+	var counterSet counters.CounterSet
+	if _, _, cs := s.collection(); cs != nil {
+		counterSet = *cs
+	}
+
+	err := json.NewEncoder(w).Encode(dashboardmodel.Build(&counterSet))
+	if err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+		http.Error(w, internalServerError, http.StatusInternalServerError)
+		return
+	}
+}
+
+// seriesMetadataResponse follows the response format of the Prometheus HTTP API.
+type seriesMetadataResponse struct {
+	Status    string                         `json:"status"`
+	Data      []rendermetrics.SeriesMetadata `json:"data,omitempty"`
+	ErrorType string                         `json:"errorType,omitempty"`
+	Error     string                         `json:"error,omitempty"`
+}
+
+// SeriesMetadata returns the entity type, the parent entity and the UUID of the entity behind every series
+// exported at /metrics, so that query tooling doesn't have to parse them from the labels.
+func (s *MetricsServer) SeriesMetadata(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/json")
+
+	response := seriesMetadataResponse{Status: "success", Data: []rendermetrics.SeriesMetadata{}}
+	status := http.StatusOK
+
+	reg, deviceWatchListManager, _ := s.collection()
+	metricGroups, err := reg.Gather()
+	if err != nil {
+		slog.Error("Failed to gather metrics from collectors", slog.String(logging.ErrorKey, err.Error()))
+		response = seriesMetadataResponse{Status: "error", ErrorType: "internal", Error: internalServerError}
+		status = http.StatusInternalServerError
+	} else {
+		if s.idleMode != nil {
+			s.idleMode.Filter(metricGroups)
+		}
+		if s.maintenance != nil {
+			s.maintenance.Annotate(metricGroups)
+		}
+		for group, metrics := range metricGroups {
+			if _, exists := deviceWatchListManager.EntityWatchList(group); !exists {
+				continue
+			}
+			metrics = rendermetrics.SeparateGPUInstances(group, metrics, s.gpuInstanceMetrics)
+			response.Data = append(response.Data, rendermetrics.GroupSeriesMetadata(group, metrics)...)
+		}
+		slices.SortFunc(response.Data, func(a, b rendermetrics.SeriesMetadata) int {
+			return cmp.Or(
+				cmp.Compare(a.Metric, b.Metric),
+				cmp.Compare(a.EntityType, b.EntityType),
+				cmp.Compare(a.Parent, b.Parent),
+				cmp.Compare(a.EntityID, b.EntityID),
+			)
+		})
+	}
+
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		slog.Error("Failed to write response.", slog.String(logging.ErrorKey, err.Error()))
+	}
+}
+
 func (s *MetricsServer) Health(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	_, err := w.Write([]byte("KO"))
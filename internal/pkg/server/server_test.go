@@ -17,6 +17,7 @@
 package server
 
 import (
+	"bytes"
 	"errors"
 	"net"
 	"net/http"
@@ -24,9 +25,11 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	mockcollectorpkg "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/collector"
@@ -83,6 +86,7 @@ func TestMetrics(t *testing.T) {
 
 	tests := []struct {
 		name        string
+		target      string
 		group       dcgm.Field_Entity_Group
 		collector   func() collector.Collector
 		transformer func() transformation.Transform
@@ -106,6 +110,20 @@ func TestMetrics(t *testing.T) {
 				assert.Equal(t, expectedResponse, recorder.Body.String())
 			},
 		},
+		{
+			name:   "Returns 400 when shard is invalid",
+			target: "/metrics?shard=5of4",
+			group:  dcgm.FE_GPU,
+			collector: func() collector.Collector {
+				return mockcollectorpkg.NewMockCollector(ctrl)
+			},
+			transformer: func() transformation.Transform {
+				return mocktransformation.NewMockTransform(ctrl)
+			},
+			assert: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "Returns 500 when Collector return error",
 			group: dcgm.FE_GPU,
@@ -193,8 +211,13 @@ func TestMetrics(t *testing.T) {
 				},
 			}
 
+			target := "/metrics"
+			if tt.target != "" {
+				target = tt.target
+			}
+
 			recorder := httptest.NewRecorder()
-			metricServer.Metrics(recorder, nil)
+			metricServer.Metrics(recorder, httptest.NewRequest(http.MethodGet, target, nil))
 			if tt.assert != nil {
 				tt.assert(t, recorder)
 			}
@@ -233,7 +256,7 @@ func TestMetricsReturnsErrorWhenClientClosedConnection(t *testing.T) {
 	reg.Register(entityCollectorTuple)
 
 	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
-	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_CPU).AnyTimes()
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
 	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{}).AnyTimes()
 
 	defaultDeviceWatchList := *devicewatchlistmanager.NewWatchList(
@@ -248,7 +271,7 @@ func TestMetricsReturnsErrorWhenClientClosedConnection(t *testing.T) {
 		registry: reg,
 		deviceWatchListManager: func() devicewatchlistmanager.Manager {
 			mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
-			mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_CPU).Return(defaultDeviceWatchList,
+			mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(defaultDeviceWatchList,
 				true).AnyTimes()
 			mockDeviceWatchListManager.EXPECT().EntityWatchList(gomock.Any()).Return(devicewatchlistmanager.WatchList{},
 				false).AnyTimes()
@@ -257,11 +280,99 @@ func TestMetricsReturnsErrorWhenClientClosedConnection(t *testing.T) {
 		transformations: []transformation.Transform{},
 	}
 	recorder := &mockResponseWriter{}
-	metricServer.Metrics(recorder, nil)
+	metricServer.Metrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	assert.Nil(t, recorder.Body)
 }
 
+func TestMetricsServesLatestMetricsOfOverrunningCollectors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mockCollector := mockcollectorpkg.NewMockCollector(ctrl)
+	first := mockCollector.EXPECT().GetMetrics().Return(getMetricsByCounterWithTestMetric(), nil)
+	mockCollector.EXPECT().GetMetrics().DoAndReturn(func() (collector.MetricsByCounter, error) {
+		<-release
+		return getMetricsByCounterWithTestMetric(), nil
+	}).After(first)
+
+	reg := registry.NewRegistry()
+	entityCollectorTuple := collector.EntityCollectorTuple{}
+	entityCollectorTuple.SetEntity(dcgm.FE_GPU)
+	entityCollectorTuple.SetCollector(mockCollector)
+	reg.Register(entityCollectorTuple)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{}).AnyTimes()
+
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, []dcgm.Short{42}, nil, deviceWatcher, 1), true).AnyTimes()
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(gomock.Any()).Return(devicewatchlistmanager.WatchList{},
+		false).AnyTimes()
+
+	metricServer := &MetricsServer{
+		registry:               reg,
+		config:                 &appconfig.Config{CollectInterval: 20},
+		deviceWatchListManager: mockDeviceWatchListManager,
+		transformations:        []transformation.Transform{},
+	}
+
+	recorder := httptest.NewRecorder()
+	metricServer.Metrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `Hostname="testhost"} 42`)
+
+	// The collector doesn't finish within the collect interval, so its latest metrics are served with the same
+	// series, and counted as stale
+	recorder = httptest.NewRecorder()
+	metricServer.Metrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `Hostname="testhost"} 42`)
+	assert.Contains(t, recorder.Body.String(), `dcgm_exporter_stale_collector_results_total{group="GPU"}`)
+}
+
+func TestWriteCompleteMetricsWaitsForCollectors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCollector := mockcollectorpkg.NewMockCollector(ctrl)
+	mockCollector.EXPECT().GetMetrics().DoAndReturn(func() (collector.MetricsByCounter, error) {
+		time.Sleep(100 * time.Millisecond)
+		return getMetricsByCounterWithTestMetric(), nil
+	})
+
+	reg := registry.NewRegistry()
+	entityCollectorTuple := collector.EntityCollectorTuple{}
+	entityCollectorTuple.SetEntity(dcgm.FE_GPU)
+	entityCollectorTuple.SetCollector(mockCollector)
+	reg.Register(entityCollectorTuple)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{}).AnyTimes()
+
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(
+		*devicewatchlistmanager.NewWatchList(mockDeviceInfo, []dcgm.Short{42}, nil, deviceWatcher, 1), true).AnyTimes()
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(gomock.Any()).Return(devicewatchlistmanager.WatchList{},
+		false).AnyTimes()
+
+	metricServer := &MetricsServer{
+		registry:               reg,
+		config:                 &appconfig.Config{CollectInterval: 10},
+		deviceWatchListManager: mockDeviceWatchListManager,
+		transformations:        []transformation.Transform{},
+	}
+
+	// The collector takes longer than the scrape deadline and has no earlier metrics, so they would be left out
+	var buf bytes.Buffer
+	require.NoError(t, metricServer.WriteCompleteMetrics(&buf))
+	assert.Contains(t, buf.String(), `Hostname="testhost"} 42`)
+}
+
 func TestHealthReturnsOK(t *testing.T) {
 	metricServer := &MetricsServer{}
 	recorder := httptest.NewRecorder()
@@ -275,3 +386,74 @@ func TestHealthReturnsOKWhenWriteReturnsError(t *testing.T) {
 	metricServer.Health(recorder, nil)
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 }
+
+func TestDashboardModelReturnsJSON(t *testing.T) {
+	metricServer := &MetricsServer{
+		counterSet: &counters.CounterSet{
+			ExporterCounters: counters.CounterList{
+				{
+					FieldID:   dcgm.Short(counters.DCGMXIDErrorsCount),
+					FieldName: counters.DCGMExpXIDErrorsCount,
+					PromType:  "gauge",
+				},
+			},
+		},
+	}
+	recorder := httptest.NewRecorder()
+	metricServer.DashboardModel(recorder, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `"name":"reliability"`)
+	assert.Contains(t, recorder.Body.String(), `"metric":"DCGM_EXP_XID_ERRORS_COUNT"`)
+}
+
+func TestDashboardModelAfterSetCollection(t *testing.T) {
+	metricServer := &MetricsServer{counterSet: &counters.CounterSet{}}
+	metricServer.SetCollection(nil, nil, &counters.CounterSet{
+		ExporterCounters: counters.CounterList{
+			{
+				FieldID:   dcgm.Short(counters.DCGMXIDErrorsCount),
+				FieldName: counters.DCGMExpXIDErrorsCount,
+				PromType:  "gauge",
+			},
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	metricServer.DashboardModel(recorder, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"metric":"DCGM_EXP_XID_ERRORS_COUNT"`)
+}
+
+func TestSeriesMetadataReturnsJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCollector := mockcollectorpkg.NewMockCollector(ctrl)
+	mockCollector.EXPECT().GetMetrics().Return(getMetricsByCounterWithTestMetric(), nil)
+
+	reg := registry.NewRegistry()
+	entityCollectorTuple := collector.EntityCollectorTuple{}
+	entityCollectorTuple.SetEntity(dcgm.FE_GPU)
+	entityCollectorTuple.SetCollector(mockCollector)
+	reg.Register(entityCollectorTuple)
+
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(devicewatchlistmanager.WatchList{}, true)
+
+	metricServer := &MetricsServer{
+		registry:               reg,
+		deviceWatchListManager: mockDeviceWatchListManager,
+	}
+
+	recorder := httptest.NewRecorder()
+	metricServer.SeriesMetadata(recorder, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"success","data":[{
+		"metric":"TEST_METRIC",
+		"labels":{"gpu":"0","UUID":"GPU-00000000-0000-0000-0000-000000000000","Hostname":"testhost"},
+		"entity_type":"gpu",
+		"entity_id":"0",
+		"uuid":"GPU-00000000-0000-0000-0000-000000000000"
+	}]}`, recorder.Body.String())
+}
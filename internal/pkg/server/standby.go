@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	dataStaleGauge = selfmetrics.Default().Gauge("dcgm_exporter_data_stale",
+		"1 when /metrics serves the last rendered metrics, because the collection is restarting.")
+	lastRenderedGauge = selfmetrics.Default().Gauge("dcgm_exporter_last_rendered_timestamp_seconds",
+		"Time of the last metrics rendered at /metrics, as a Unix timestamp.")
+	dataAgeGauge = selfmetrics.Default().Gauge("dcgm_exporter_data_age_seconds",
+		"Age of the metrics served at /metrics: 0 while they are fresh, otherwise the time since they were rendered.")
+)
+
+// standby keeps the last metrics rendered by a full scrape, so that they can be served while the collection
+// restarts, e.g. on a reload or a reconnection to the hostengine, or fails a cycle, instead of an empty, a partial or
+// a failed response.
+type standby struct {
+	sync.Mutex
+
+	maxAge     time.Duration
+	payload    []byte
+	renderedAt time.Time
+	// registry gathered the last rendered metrics of the groups
+	registry *registry.Registry
+	groups   []dcgm.Field_Entity_Group
+}
+
+func newStandby(maxAge time.Duration) *standby {
+	return &standby{maxAge: maxAge}
+}
+
+// store keeps the rendered metrics, and the groups, which the registry gathered metrics of, and reports them as fresh.
+func (s *standby) store(
+	payload []byte, reg *registry.Registry, metricGroups registry.MetricsByCounterGroup, now time.Time,
+) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.payload = bytes.Clone(payload)
+	s.renderedAt = now
+	s.registry = reg
+	s.groups = s.groups[:0]
+	for group, metrics := range metricGroups {
+		if !isEmpty(registry.MetricsByCounterGroup{group: metrics}) {
+			s.groups = append(s.groups, group)
+		}
+	}
+
+	dataStaleGauge.Set(0)
+	dataAgeGauge.Set(0)
+	lastRenderedGauge.Set(float64(now.UnixNano()) / float64(time.Second))
+}
+
+// isPartial reports whether the registry, which gathered the last rendered metrics, gathered no metrics of a group,
+// which it gathered metrics of before, e.g. while the hostengine restarts. The groups of a new registry, e.g. after a
+// reload, may differ, so they are never partial.
+func (s *standby) isPartial(reg *registry.Registry, metricGroups registry.MetricsByCounterGroup) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if reg != s.registry {
+		return false
+	}
+	for _, group := range s.groups {
+		if isEmpty(registry.MetricsByCounterGroup{group: metricGroups[group]}) {
+			return true
+		}
+	}
+	return false
+}
+
+// load returns the last rendered metrics, unless they are older than the maximum age, and reports them as stale.
+func (s *standby) load(now time.Time) ([]byte, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.payload == nil || now.Sub(s.renderedAt) > s.maxAge {
+		return nil, false
+	}
+
+	dataStaleGauge.Set(1)
+	dataAgeGauge.Set(now.Sub(s.renderedAt).Seconds())
+	return s.payload, true
+}
+
+// isEmpty reports whether no metrics were gathered, which happens while a new collection has not collected yet.
+func isEmpty(metricGroups registry.MetricsByCounterGroup) bool {
+	for _, metrics := range metricGroups {
+		for _, values := range metrics {
+			if len(values) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
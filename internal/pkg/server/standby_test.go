@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockcollectorpkg "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/collector"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestStandby(t *testing.T) {
+	t.Cleanup(func() {
+		dataStaleGauge.Reset()
+		dataAgeGauge.Reset()
+		lastRenderedGauge.Reset()
+	})
+
+	now := time.Unix(1700000000, 0)
+	s := newStandby(time.Minute)
+
+	_, ok := s.load(now)
+	assert.False(t, ok, "nothing was rendered yet")
+
+	payload := []byte("TEST_METRIC 42\n")
+	s.store(payload, nil, nil, now)
+	payload[0] = 'X'
+
+	stale, ok := s.load(now.Add(time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, "TEST_METRIC 42\n", string(stale))
+
+	value, _ := selfmetrics.Default().Value("dcgm_exporter_data_stale")
+	assert.Equal(t, float64(1), value)
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_last_rendered_timestamp_seconds")
+	assert.Equal(t, float64(1700000000), value)
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_data_age_seconds")
+	assert.Equal(t, float64(60), value)
+
+	_, ok = s.load(now.Add(time.Minute + time.Second))
+	assert.False(t, ok, "the rendered metrics are older than the maximum age")
+
+	s.store(payload, nil, nil, now.Add(2*time.Minute))
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_data_stale")
+	assert.Equal(t, float64(0), value)
+	value, _ = selfmetrics.Default().Value("dcgm_exporter_data_age_seconds")
+	assert.Equal(t, float64(0), value)
+}
+
+func TestMetricsServesStandbyWhileRestarting(t *testing.T) {
+	t.Cleanup(func() {
+		dataStaleGauge.Reset()
+		dataAgeGauge.Reset()
+		lastRenderedGauge.Reset()
+	})
+
+	ctrl := gomock.NewController(t)
+
+	mockCollector := mockcollectorpkg.NewMockCollector(ctrl)
+	gomock.InOrder(
+		mockCollector.EXPECT().GetMetrics().Return(getMetricsByCounterWithTestMetric(), nil),
+		mockCollector.EXPECT().GetMetrics().Return(collector.MetricsByCounter{}, nil),
+		mockCollector.EXPECT().GetMetrics().Return(nil, errors.New("boom")),
+	)
+
+	reg := registry.NewRegistry()
+	entityCollectorTuple := collector.EntityCollectorTuple{}
+	entityCollectorTuple.SetEntity(dcgm.FE_GPU)
+	entityCollectorTuple.SetCollector(mockCollector)
+	reg.Register(entityCollectorTuple)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().InfoType().Return(dcgm.FE_GPU).AnyTimes()
+	mockDeviceInfo.EXPECT().GOpts().Return(appconfig.DeviceOptions{}).AnyTimes()
+
+	watchList := *devicewatchlistmanager.NewWatchList(mockDeviceInfo, []dcgm.Short{42}, nil, deviceWatcher, 1)
+	mockDeviceWatchListManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockDeviceWatchListManager.EXPECT().EntityWatchList(dcgm.FE_GPU).Return(watchList, true).AnyTimes()
+
+	metricServer := &MetricsServer{
+		registry:               reg,
+		deviceWatchListManager: mockDeviceWatchListManager,
+		standby:                newStandby(time.Minute),
+	}
+
+	for _, scrape := range []string{"collected", "restarting", "failing"} {
+		recorder := httptest.NewRecorder()
+		metricServer.Metrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code, scrape)
+		assert.Contains(t, recorder.Body.String(), expectedResponse, scrape)
+
+		stale := "0"
+		if scrape != "collected" {
+			stale = "1"
+		}
+		assert.Contains(t, recorder.Body.String(), "\ndcgm_exporter_data_stale "+stale+"\n", scrape)
+	}
+}
+
+func TestStandbyIsPartial(t *testing.T) {
+	t.Cleanup(func() {
+		dataStaleGauge.Reset()
+		dataAgeGauge.Reset()
+		lastRenderedGauge.Reset()
+	})
+
+	gpuMetrics := getMetricsByCounterWithTestMetric()
+	full := registry.MetricsByCounterGroup{dcgm.FE_GPU: gpuMetrics, dcgm.FE_SWITCH: gpuMetrics}
+	reg := registry.NewRegistry()
+
+	s := newStandby(time.Minute)
+	s.store([]byte("TEST_METRIC 42\n"), reg, full, time.Now())
+
+	tests := []struct {
+		name         string
+		reg          *registry.Registry
+		metricGroups registry.MetricsByCounterGroup
+		want         bool
+	}{
+		{
+			name:         "all groups",
+			reg:          reg,
+			metricGroups: full,
+			want:         false,
+		},
+		{
+			name:         "missing group",
+			reg:          reg,
+			metricGroups: registry.MetricsByCounterGroup{dcgm.FE_GPU: gpuMetrics},
+			want:         true,
+		},
+		{
+			name: "empty group",
+			reg:  reg,
+			metricGroups: registry.MetricsByCounterGroup{
+				dcgm.FE_GPU:    gpuMetrics,
+				dcgm.FE_SWITCH: collector.MetricsByCounter{},
+			},
+			want: true,
+		},
+		{
+			name:         "new registry",
+			reg:          registry.NewRegistry(),
+			metricGroups: registry.MetricsByCounterGroup{dcgm.FE_GPU: gpuMetrics},
+			want:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.isPartial(tt.reg, tt.metricGroups))
+		})
+	}
+}
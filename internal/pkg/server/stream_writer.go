@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// streamBufferSize is the size of the buffer, which the rendered metrics are written to the response through, so
+// that the many small writes of the templates don't reach the connection one by one.
+const streamBufferSize = 32 * 1024
+
+// streamWriter writes the metrics to the response in the Prometheus text format as they are rendered, compressed with
+// gzip, when the scraper accepts it, instead of rendering the whole payload in memory first. Until the buffer is
+// first flushed, nothing is sent, so the response can still fail.
+type streamWriter struct {
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	written *countingWriter
+}
+
+// countingWriter counts the bytes written to the response.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func newStreamWriter(w http.ResponseWriter, r *http.Request) *streamWriter {
+	w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	w.Header().Add("Vary", "Accept, Accept-Encoding")
+
+	sw := &streamWriter{written: &countingWriter{w: w}}
+	var out io.Writer = sw.written
+	if acceptsGzip(r.Header) {
+		w.Header().Set("Content-Encoding", "gzip")
+		sw.gz = gzipWriters.Get().(*gzip.Writer)
+		sw.gz.Reset(sw.written)
+		out = sw.gz
+	}
+	sw.buf = bufio.NewWriterSize(out, streamBufferSize)
+	return sw
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	return sw.buf.Write(p)
+}
+
+// Written reports whether any part of the response was sent, after which it can't fail anymore.
+func (sw *streamWriter) Written() bool {
+	return sw.written.n > 0
+}
+
+// Close flushes the buffered metrics and finishes the compressed stream.
+func (sw *streamWriter) Close() error {
+	if err := sw.buf.Flush(); err != nil {
+		return err
+	}
+	if sw.gz == nil {
+		return nil
+	}
+	defer gzipWriters.Put(sw.gz)
+	return sw.gz.Close()
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriter(t *testing.T) {
+	line := "DCGM_FI_DEV_GPU_UTIL{gpu=\"0\"} 42\n"
+	payload := strings.Repeat(line, 2*streamBufferSize/len(line))
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+	}{
+		{name: "Plain"},
+		{name: "Compressed with gzip", acceptEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+
+			sw := newStreamWriter(recorder, req)
+			_, err := io.WriteString(sw, line)
+			require.NoError(t, err)
+			assert.False(t, sw.Written(), "the first lines are buffered")
+
+			_, err = io.WriteString(sw, payload)
+			require.NoError(t, err)
+			require.NoError(t, sw.Close())
+			assert.True(t, sw.Written())
+
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+			body := io.Reader(recorder.Body)
+			if tt.acceptEncoding != "" {
+				assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(recorder.Body)
+				require.NoError(t, err)
+				body = gz
+			}
+			got, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, line+payload, string(got))
+		})
+	}
+}
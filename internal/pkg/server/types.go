@@ -22,8 +22,16 @@ import (
 
 	"github.com/prometheus/exporter-toolkit/web"
 
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/adaptiveinterval"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/diagnostics"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dmon"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/downsample"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/idlemode"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/maintenance"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/transformation"
 )
@@ -33,10 +41,20 @@ type MetricsServer struct {
 
 	server                 *http.Server
 	webConfig              *web.FlagConfig
-	metrics                string
-	metricsChan            chan string
 	registry               *registry.Registry
 	config                 *appconfig.Config
 	transformations        []transformation.Transform
 	deviceWatchListManager devicewatchlistmanager.Manager
+	counterSet             *counters.CounterSet
+	gpuInstanceMetrics     appconfig.GPUInstanceMetricsMode
+	scrapeTracker          *adaptiveinterval.Tracker
+	downsampler            *downsample.Downsampler
+	idleMode               *idlemode.Controller
+	dmonColumns            []dmon.Column
+	bus                    *eventbus.Bus
+	maintenance            *maintenance.Controller
+	diagnostics            *diagnostics.Runner
+	standby                *standby
+	scrapeCache            *scrapeCache
+	updateCounters         CountersUpdater
 }
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package soak
+
+import (
+	"sync/atomic"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+// CountingClient counts the DCGM groups and field groups, which are created and not destroyed yet.
+type CountingClient struct {
+	dcgmprovider.DCGM
+	groups      atomic.Int64
+	fieldGroups atomic.Int64
+}
+
+// NewCountingClient wraps the DCGM client.
+func NewCountingClient(client dcgmprovider.DCGM) *CountingClient {
+	return &CountingClient{DCGM: client}
+}
+
+// Groups returns the number of open groups.
+func (c *CountingClient) Groups() int64 {
+	return c.groups.Load()
+}
+
+// FieldGroups returns the number of open field groups.
+func (c *CountingClient) FieldGroups() int64 {
+	return c.fieldGroups.Load()
+}
+
+func (c *CountingClient) CreateGroup(groupName string) (dcgm.GroupHandle, error) {
+	group, err := c.DCGM.CreateGroup(groupName)
+	if err == nil {
+		c.groups.Add(1)
+	}
+	return group, err
+}
+
+func (c *CountingClient) NewDefaultGroup(groupName string) (dcgm.GroupHandle, error) {
+	group, err := c.DCGM.NewDefaultGroup(groupName)
+	if err == nil {
+		c.groups.Add(1)
+	}
+	return group, err
+}
+
+func (c *CountingClient) DestroyGroup(groupID dcgm.GroupHandle) error {
+	err := c.DCGM.DestroyGroup(groupID)
+	if err == nil {
+		c.groups.Add(-1)
+	}
+	return err
+}
+
+func (c *CountingClient) FieldGroupCreate(fieldsGroupName string, fields []dcgm.Short) (dcgm.FieldHandle, error) {
+	fieldGroup, err := c.DCGM.FieldGroupCreate(fieldsGroupName, fields)
+	if err == nil {
+		c.fieldGroups.Add(1)
+	}
+	return fieldGroup, err
+}
+
+func (c *CountingClient) FieldGroupDestroy(fieldGroup dcgm.FieldHandle) error {
+	err := c.DCGM.FieldGroupDestroy(fieldGroup)
+	if err == nil {
+		c.fieldGroups.Add(-1)
+	}
+	return err
+}
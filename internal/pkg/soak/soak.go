@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package soak repeatedly builds and tears down the collection of the exporter, and samples the resources held by
+// the process after every cycle, so that leaks of new DCGM and driver versions are detected before a rollout.
+package soak
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procSelfFD     = "/proc/self/fd"
+	procStatusPath = "/proc/self/status"
+)
+
+// Sample is the resources held by the process after a cycle.
+type Sample struct {
+	Cycle       int
+	OpenFDs     int
+	Goroutines  int
+	HeapBytes   uint64
+	RSSBytes    uint64
+	Groups      int64
+	FieldGroups int64
+}
+
+// Options configure the soak test.
+type Options struct {
+	// Iterations is the number of cycles, the test runs until the context is canceled, when it is 0
+	Iterations int
+	// Interval is the pause between cycles
+	Interval time.Duration
+}
+
+// Run runs the cycle until the number of iterations is reached or the context is canceled, and logs a sample after
+// every cycle. The first cycle warms up caches and lazily initialized libraries, so it is the baseline, which the
+// last sample is compared with. Run returns an error, when the cycle fails or the process leaks resources.
+func Run(ctx context.Context, opts Options, client *CountingClient, cycle func() error) ([]Sample, error) {
+	var samples []Sample
+
+	for i := 1; opts.Iterations == 0 || i <= opts.Iterations; i++ {
+		err := cycle()
+		if err != nil {
+			return samples, fmt.Errorf("cycle %d failed; err: %w", i, err)
+		}
+
+		sample := TakeSample(i, client)
+		samples = append(samples, sample)
+		sample.Log()
+
+		select {
+		case <-ctx.Done():
+			return samples, Leaks(samples)
+		case <-time.After(opts.Interval):
+		}
+	}
+
+	return samples, Leaks(samples)
+}
+
+// TakeSample samples the resources held by the process.
+func TakeSample(cycle int, client *CountingClient) Sample {
+	// Garbage is not a leak
+	runtime.GC()
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	sample := Sample{
+		Cycle:      cycle,
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  memStats.HeapAlloc,
+	}
+
+	if entries, err := os.ReadDir(procSelfFD); err == nil {
+		sample.OpenFDs = len(entries)
+	}
+
+	if rss, err := processRSS(); err == nil {
+		sample.RSSBytes = rss
+	}
+
+	if client != nil {
+		sample.Groups = client.Groups()
+		sample.FieldGroups = client.FieldGroups()
+	}
+
+	return sample
+}
+
+// Log logs the sample.
+func (s Sample) Log() {
+	slog.Info("Soak test cycle completed",
+		slog.Int("cycle", s.Cycle),
+		slog.Int("openFDs", s.OpenFDs),
+		slog.Int("goroutines", s.Goroutines),
+		slog.Uint64("heapBytes", s.HeapBytes),
+		slog.Uint64("rssBytes", s.RSSBytes),
+		slog.Int64("dcgmGroups", s.Groups),
+		slog.Int64("dcgmFieldGroups", s.FieldGroups),
+	)
+}
+
+// Leaks compares the last sample with the first one, and returns an error listing the resources, which the
+// process holds more of. The memory is logged for trend analysis only, because the Go runtime and the DCGM
+// caches don't release it immediately.
+func Leaks(samples []Sample) error {
+	if len(samples) < 2 {
+		return nil
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+
+	slog.Info("Soak test completed",
+		slog.Int("cycles", len(samples)),
+		slog.Int64("heapBytesDelta", int64(last.HeapBytes)-int64(first.HeapBytes)),
+		slog.Int64("rssBytesDelta", int64(last.RSSBytes)-int64(first.RSSBytes)),
+	)
+
+	var leaks []string
+	if last.OpenFDs > first.OpenFDs {
+		leaks = append(leaks, fmt.Sprintf("open file descriptors grew from %d to %d", first.OpenFDs, last.OpenFDs))
+	}
+	if last.Goroutines > first.Goroutines {
+		leaks = append(leaks, fmt.Sprintf("goroutines grew from %d to %d", first.Goroutines, last.Goroutines))
+	}
+	if last.Groups > first.Groups {
+		leaks = append(leaks, fmt.Sprintf("DCGM groups grew from %d to %d", first.Groups, last.Groups))
+	}
+	if last.FieldGroups > first.FieldGroups {
+		leaks = append(leaks, fmt.Sprintf("DCGM field groups grew from %d to %d", first.FieldGroups,
+			last.FieldGroups))
+	}
+
+	if len(leaks) > 0 {
+		return fmt.Errorf("resources leaked after %d cycles: %s", len(samples), strings.Join(leaks, ", "))
+	}
+
+	return nil
+}
+
+func processRSS() (uint64, error) {
+	file, err := os.Open(procStatusPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return parseRSS(file)
+}
+
+// parseRSS reads the resident set size in bytes from the content of /proc/<pid>/status.
+func parseRSS(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "VmRSS:")
+		if found {
+			kb, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB")), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("no VmRSS in %s", procStatusPath)
+}
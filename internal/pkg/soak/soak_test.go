@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package soak
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+)
+
+func TestCountingClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(dcgm.GroupHandle{}, nil).Times(2)
+	mockDCGM.EXPECT().DestroyGroup(gomock.Any()).Return(nil)
+	mockDCGM.EXPECT().FieldGroupCreate(gomock.Any(), gomock.Any()).Return(dcgm.FieldHandle{}, nil)
+	mockDCGM.EXPECT().FieldGroupCreate(gomock.Any(), gomock.Any()).Return(dcgm.FieldHandle{}, errors.New("boom"))
+
+	client := NewCountingClient(mockDCGM)
+
+	_, _ = client.CreateGroup("a")
+	_, _ = client.CreateGroup("b")
+	_ = client.DestroyGroup(dcgm.GroupHandle{})
+	_, _ = client.FieldGroupCreate("a", nil)
+	_, _ = client.FieldGroupCreate("b", nil)
+
+	assert.Equal(t, int64(1), client.Groups())
+	assert.Equal(t, int64(1), client.FieldGroups())
+}
+
+func TestRun(t *testing.T) {
+	t.Run("no leaks", func(t *testing.T) {
+		samples, err := Run(context.Background(), Options{Iterations: 3}, nil, func() error {
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, samples, 3)
+	})
+
+	t.Run("leaked file descriptors", func(t *testing.T) {
+		dir := t.TempDir()
+
+		var files []*os.File
+		defer func() {
+			for _, f := range files {
+				_ = f.Close()
+			}
+		}()
+
+		_, err := Run(context.Background(), Options{Iterations: 3}, nil, func() error {
+			f, err := os.Create(filepath.Join(dir, strings.Repeat("f", len(files)+1)))
+			files = append(files, f)
+			return err
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "open file descriptors grew")
+	})
+
+	t.Run("failed cycle", func(t *testing.T) {
+		samples, err := Run(context.Background(), Options{Iterations: 3}, nil, func() error {
+			return errors.New("boom")
+		})
+		require.Error(t, err)
+		assert.Empty(t, samples)
+	})
+}
+
+func TestLeaks(t *testing.T) {
+	assert.NoError(t, Leaks([]Sample{{Groups: 2, HeapBytes: 10}, {Groups: 2, HeapBytes: 20}}))
+
+	err := Leaks([]Sample{{Groups: 2, FieldGroups: 1}, {Groups: 3, FieldGroups: 2}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DCGM groups grew from 2 to 3")
+	assert.Contains(t, err.Error(), "DCGM field groups grew from 1 to 2")
+}
+
+func Test_parseRSS(t *testing.T) {
+	rss, err := parseRSS(strings.NewReader("Name:\tdcgm-exporter\nVmRSS:\t   2048 kB\nThreads:\t12\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2048*1024), rss)
+
+	_, err = parseRSS(strings.NewReader("Name:\tdcgm-exporter\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package startupreport summarizes what dcgm-exporter discovered and enabled during initialization, so that
+// a misconfigured node can be diagnosed from a single log record or from the metrics endpoint.
+package startupreport
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var (
+	entitiesGauge = selfmetrics.Default().Gauge("dcgm_exporter_startup_entities",
+		"Number of entities discovered at startup, per entity type.")
+	countersGauge = selfmetrics.Default().Gauge("dcgm_exporter_startup_counter_enabled",
+		"Counters of the counters file at startup: 1 when the counter is collected, 0 when it is disabled for the reason.")
+	unsupportedGauge = selfmetrics.Default().Gauge("dcgm_exporter_counter_unsupported",
+		"Counters of the counters file, which are not supported on this node, by reason code.")
+	infoGauge = selfmetrics.Default().Gauge("dcgm_exporter_startup_info",
+		"Startup configuration of dcgm-exporter: version, pod attribution mode and listeners.")
+)
+
+// CounterStatus tells whether a counter is collected and, if not, why.
+type CounterStatus struct {
+	Name    string
+	Enabled bool
+	Reason  string
+	// UnsupportedCode identifies the reason of counters, which are not supported on this node
+	UnsupportedCode string
+}
+
+// Report is the consolidated state of dcgm-exporter after initialization.
+type Report struct {
+	Version     string
+	Hostname    string
+	Entities    map[string]int
+	Counters    []CounterStatus
+	Attribution []string
+	Listeners   []string
+}
+
+// Log writes the report as a single block, so that it is not interleaved with other log records.
+func (r *Report) Log() {
+	slog.Info("Startup report\n" + r.String())
+}
+
+// Publish sets the startup gauges, replacing the values of a previous report.
+func (r *Report) Publish() {
+	entitiesGauge.Reset()
+	for entityType, count := range r.Entities {
+		entitiesGauge.Set(float64(count), "entity", entityType)
+	}
+
+	countersGauge.Reset()
+	for _, counter := range r.Counters {
+		value := 0.0
+		if counter.Enabled {
+			value = 1
+		}
+		countersGauge.Set(value, "counter", counter.Name, "reason", counter.Reason)
+	}
+
+	unsupportedGauge.Reset()
+	for _, counter := range r.Counters {
+		if counter.UnsupportedCode != "" {
+			unsupportedGauge.Set(1, "field_name", counter.Name, "reason", counter.UnsupportedCode)
+		}
+	}
+
+	infoGauge.Reset()
+	infoGauge.Set(1,
+		"version", r.Version,
+		"attribution", r.attribution(),
+		"listeners", strings.Join(r.Listeners, ","))
+}
+
+func (r *Report) entityTypes() []string {
+	entityTypes := make([]string, 0, len(r.Entities))
+	for entityType := range r.Entities {
+		entityTypes = append(entityTypes, entityType)
+	}
+	sort.Strings(entityTypes)
+
+	return entityTypes
+}
+
+func (r *Report) attribution() string {
+	if len(r.Attribution) == 0 {
+		return "none"
+	}
+
+	return strings.Join(r.Attribution, ",")
+}
+
+// String renders the report as a human readable block.
+func (r *Report) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("version: " + r.Version + "\n")
+	if r.Hostname != "" {
+		sb.WriteString("hostname: " + r.Hostname + "\n")
+	}
+	sb.WriteString("entities:\n")
+	for _, entityType := range r.entityTypes() {
+		sb.WriteString("  " + entityType + ": " + strconv.Itoa(r.Entities[entityType]) + "\n")
+	}
+	sb.WriteString("counters:\n")
+	for _, counter := range r.Counters {
+		if counter.Enabled {
+			sb.WriteString("  " + counter.Name + ": enabled\n")
+		} else {
+			sb.WriteString("  " + counter.Name + ": disabled (" + counter.Reason + ")\n")
+		}
+	}
+	sb.WriteString("attribution: " + r.attribution() + "\n")
+	sb.WriteString("listeners: " + strings.Join(r.Listeners, ",") + "\n")
+
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package startupreport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func newTestReport() *Report {
+	return &Report{
+		Version:  "4.0.0",
+		Entities: map[string]int{"GPU": 2, "CPU": 0},
+		Counters: []CounterStatus{
+			{Name: "DCGM_FI_DEV_POWER_USAGE", Enabled: true},
+			{
+				Name:            "DCGM_FI_PROF_GR_ENGINE_ACTIVE",
+				Reason:          "profiling metrics are not collected",
+				UnsupportedCode: "profiling_disabled",
+			},
+		},
+		Listeners: []string{":9400"},
+	}
+}
+
+func TestReport_String(t *testing.T) {
+	want := `version: 4.0.0
+entities:
+  CPU: 0
+  GPU: 2
+counters:
+  DCGM_FI_DEV_POWER_USAGE: enabled
+  DCGM_FI_PROF_GR_ENGINE_ACTIVE: disabled (profiling metrics are not collected)
+attribution: none
+listeners: :9400
+`
+	assert.Equal(t, want, newTestReport().String())
+
+	report := newTestReport()
+	report.Hostname = "node-1 (NODE_NAME)"
+	assert.Contains(t, report.String(), "version: 4.0.0\nhostname: node-1 (NODE_NAME)\nentities:\n")
+}
+
+func TestReport_Publish(t *testing.T) {
+	registry := selfmetrics.Default()
+
+	first := newTestReport()
+	first.Publish()
+
+	value, exists := registry.Value("dcgm_exporter_startup_entities", "entity", "GPU")
+	assert.True(t, exists)
+	assert.Equal(t, 2.0, value)
+
+	value, exists = registry.Value("dcgm_exporter_startup_counter_enabled",
+		"counter", "DCGM_FI_PROF_GR_ENGINE_ACTIVE", "reason", "profiling metrics are not collected")
+	assert.True(t, exists)
+	assert.Equal(t, 0.0, value)
+
+	value, exists = registry.Value("dcgm_exporter_counter_unsupported",
+		"field_name", "DCGM_FI_PROF_GR_ENGINE_ACTIVE", "reason", "profiling_disabled")
+	assert.True(t, exists)
+	assert.Equal(t, 1.0, value)
+
+	_, exists = registry.Value("dcgm_exporter_counter_unsupported",
+		"field_name", "DCGM_FI_DEV_POWER_USAGE", "reason", "")
+	assert.False(t, exists)
+
+	value, exists = registry.Value("dcgm_exporter_startup_info",
+		"version", "4.0.0", "attribution", "none", "listeners", ":9400")
+	assert.True(t, exists)
+	assert.Equal(t, 1.0, value)
+
+	// A report published after a restart replaces the previous one
+	second := newTestReport()
+	second.Counters = second.Counters[:1]
+	second.Attribution = []string{"hpc:/var/run/jobs"}
+	second.Publish()
+
+	_, exists = registry.Value("dcgm_exporter_startup_counter_enabled",
+		"counter", "DCGM_FI_PROF_GR_ENGINE_ACTIVE", "reason", "profiling metrics are not collected")
+	assert.False(t, exists)
+
+	_, exists = registry.Value("dcgm_exporter_counter_unsupported",
+		"field_name", "DCGM_FI_PROF_GR_ENGINE_ACTIVE", "reason", "profiling_disabled")
+	assert.False(t, exists)
+
+	_, exists = registry.Value("dcgm_exporter_startup_info",
+		"version", "4.0.0", "attribution", "none", "listeners", ":9400")
+	assert.False(t, exists)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"maps"
+	"strconv"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+var allocationEfficiencyCounter = counters.Counter{
+	FieldName: "dcgm_gpu_allocation_efficiency",
+	PromType:  "gauge",
+	Help:      "Utilization of a GPU allocated to a pod, as a ratio (0 to 1).",
+}
+
+// addAllocationEfficiency adds the allocation efficiency of every GPU and GPU instance, which is allocated to a pod.
+// The graphics engine activity is preferred, because it is also reported for GPU instances, and the GPU utilization
+// is used otherwise. Unallocated GPUs are not reported.
+func addAllocationEfficiency(metrics collector.MetricsByCounter, attributed func(collector.Metric) bool) {
+	var efficiency []collector.Metric
+	seen := map[string]struct{}{}
+
+	for _, source := range []struct {
+		fieldID dcgm.Short
+		scale   float64
+	}{
+		{fieldID: dcgm.DCGM_FI_PROF_GR_ENGINE_ACTIVE, scale: 1},
+		{fieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, scale: 100},
+	} {
+		for counter, values := range metrics {
+			if counter.FieldID != source.fieldID {
+				continue
+			}
+
+			for _, metric := range values {
+				key := metric.GPU + "/" + metric.GPUInstanceID
+				// Pods are allocated GPUs and GPU instances, so compute instances are skipped
+				if _, exists := seen[key]; exists || !attributed(metric) || metric.ComputeInstanceID != "" {
+					continue
+				}
+
+				value, err := strconv.ParseFloat(metric.Value, 64)
+				if err != nil {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				metric.Counter = allocationEfficiencyCounter
+				metric.Value = strconv.FormatFloat(value/source.scale, 'f', -1, 64)
+				metric.Attributes = maps.Clone(metric.Attributes)
+				efficiency = append(efficiency, metric)
+			}
+		}
+	}
+
+	if len(efficiency) > 0 {
+		metrics[allocationEfficiencyCounter] = efficiency
+	}
+}
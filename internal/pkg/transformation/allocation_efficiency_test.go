@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestAddAllocationEfficiency(t *testing.T) {
+	gpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+	grActive := counters.Counter{
+		FieldID: dcgm.DCGM_FI_PROF_GR_ENGINE_ACTIVE, FieldName: "DCGM_FI_PROF_GR_ENGINE_ACTIVE", PromType: "gauge",
+	}
+
+	metric := func(counter counters.Counter, gpu, instance, value string) collector.Metric {
+		return collector.Metric{
+			Counter:       counter,
+			GPU:           gpu,
+			GPUInstanceID: instance,
+			Value:         value,
+			Attributes:    map[string]string{podAttribute: "pod-" + gpu + instance},
+		}
+	}
+
+	metrics := collector.MetricsByCounter{
+		gpuUtil: {
+			metric(gpuUtil, "0", "", "40"),
+			metric(gpuUtil, "1", "", "75"),
+			// Not allocated
+			metric(gpuUtil, "2", "", "90"),
+		},
+		grActive: {
+			// Preferred over the GPU utilization
+			metric(grActive, "1", "", "0.5"),
+			metric(grActive, "3", "2", "0.25"),
+		},
+	}
+
+	addAllocationEfficiency(metrics, func(m collector.Metric) bool { return m.GPU != "2" })
+
+	want := map[string]string{"0/": "0.4", "1/": "0.5", "3/2": "0.25"}
+	got := map[string]string{}
+	for _, m := range metrics[allocationEfficiencyCounter] {
+		assert.Equal(t, "pod-"+m.GPU+m.GPUInstanceID, m.Attributes[podAttribute])
+		got[m.GPU+"/"+m.GPUInstanceID] = m.Value
+	}
+	assert.Equal(t, want, got)
+
+	// The source metrics are left untouched
+	assert.Len(t, metrics[gpuUtil], 3)
+	assert.Equal(t, "40", metrics[gpuUtil][0].Value)
+}
+
+func TestProcessPodMapper_AllocationEfficiency(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("gpu-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu0),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:  appconfig.GPUUID,
+		KubeletAPIURL:        server.URL,
+		AllocationEfficiency: true,
+	})
+
+	gpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+	metrics := collector.MetricsByCounter{
+		gpuUtil: {
+			{Counter: gpuUtil, GPU: "0", GPUUUID: gpu0, Value: "30", Attributes: map[string]string{}},
+			{Counter: gpuUtil, GPU: "1", GPUUUID: gpu1, Value: "80", Attributes: map[string]string{}},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	require.NoError(t, podMapper.Process(metrics, mockdeviceinfo.NewMockProvider(ctrl)))
+
+	require.Len(t, metrics[allocationEfficiencyCounter], 1)
+	efficiency := metrics[allocationEfficiencyCounter][0]
+	assert.Equal(t, gpu0, efficiency.GPUUUID)
+	assert.Equal(t, "0.3", efficiency.Value)
+	assert.Equal(t, "gpu-pod", efficiency.Attributes[podAttribute])
+}
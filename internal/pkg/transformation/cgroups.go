@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+var (
+	// procRoot is the mount point of the proc filesystem of the host; it is replaced in tests.
+	procRoot = "/proc"
+
+	// Container IDs at the end of the cgroup paths of the container runtimes, e.g. cri-containerd-<ID>.scope,
+	// crio-<ID>.scope and docker-<ID>.scope with the systemd driver, or /docker/<ID> with the cgroupfs driver
+	cgroupContainerIDRegex = regexp.MustCompile(`(?:^|[/-])([0-9a-f]{64})(?:\.scope)?$`)
+	// Pod UIDs in the cgroup paths of kubelet, e.g. kubepods-besteffort-pod<UID>.slice with the systemd driver, where
+	// the dashes of the UID are underscores, or /kubepods/burstable/pod<UID> with the cgroupfs driver
+	cgroupPodUIDRegex = regexp.MustCompile(
+		`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// cgroupContainer is the container of a process, as read from its cgroup. The pod UID is empty outside Kubernetes.
+type cgroupContainer struct {
+	id     string
+	podUID string
+}
+
+// cgroupContainerOf returns the container, which the cgroup path belongs to, and false, when it is not the cgroup
+// of a container.
+func cgroupContainerOf(path string) (cgroupContainer, bool) {
+	match := cgroupContainerIDRegex.FindStringSubmatch(path)
+	if match == nil {
+		return cgroupContainer{}, false
+	}
+
+	container := cgroupContainer{id: match[1]}
+	if match := cgroupPodUIDRegex.FindStringSubmatch(path); match != nil {
+		container.podUID = strings.ReplaceAll(match[1], "_", "-")
+	}
+	return container, true
+}
+
+// processContainer returns the container of the process, read from /proc/<pid>/cgroup, and false, when the process
+// doesn't run in a container. Every line of the file is a hierarchy-ID:controllers:path triple: a single one with
+// cgroup v2, and one per controller with cgroup v1, which all share the container.
+func processContainer(pid uint32) (cgroupContainer, bool, error) {
+	f, err := os.Open(filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "cgroup"))
+	if err != nil {
+		return cgroupContainer{}, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if container, ok := cgroupContainerOf(fields[2]); ok {
+			return container, true, nil
+		}
+	}
+	return cgroupContainer{}, false, scanner.Err()
+}
+
+// cgroupPods returns the pods of the GPUs, by GPU UUID, from the containers of the processes running on them. The
+// containers are named after the pods read from the kubelet API, and only by their ID otherwise, e.g. outside
+// Kubernetes. GPUs with processes of several containers, or without processes in containers, have no pod, since their
+// metrics can't be split.
+func (p *PodMapper) cgroupPods(deviceInfo deviceinfo.Provider) map[string]PodInfo {
+	pods := map[string]PodInfo{}
+
+	for i := uint(0); i < deviceInfo.GPUCount(); i++ {
+		uuid := deviceInfo.GPU(i).DeviceInfo.UUID
+
+		processes, err := nvmlprovider.Client().GetProcesses(uuid, 0)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Failed to read the processes of GPU %s", uuid),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+
+		containers := map[cgroupContainer]struct{}{}
+		for _, process := range processes.Processes {
+			container, ok, err := processContainer(process.Pid)
+			if err != nil {
+				// The process may have exited, or runs in another PID namespace, e.g. without hostPID
+				slog.Debug(fmt.Sprintf("Failed to read the cgroup of process %d", process.Pid),
+					slog.String(logging.ErrorKey, err.Error()))
+				continue
+			}
+			if ok {
+				containers[container] = struct{}{}
+			}
+		}
+
+		if len(containers) != 1 {
+			if len(containers) > 1 {
+				slog.Debug(fmt.Sprintf("GPU %s is used by %d containers; not attributing it by cgroups", uuid,
+					len(containers)))
+			}
+			continue
+		}
+		for container := range containers {
+			pods[uuid] = p.cgroupPodInfo(container)
+		}
+	}
+
+	return pods
+}
+
+// cgroupPodInfo returns the pod of the container, as reported by the kubelet API, or only the ID of the container,
+// when the kubelet API doesn't report it.
+func (p *PodMapper) cgroupPodInfo(container cgroupContainer) PodInfo {
+	p.identitiesMtx.RLock()
+	defer p.identitiesMtx.RUnlock()
+
+	for ref, identity := range p.identities {
+		if identity.containerID == container.id {
+			return PodInfo{
+				Name:      ref.pod,
+				Namespace: ref.namespace,
+				Container: ref.container,
+				Source:    attributionSourceCgroup,
+			}
+		}
+	}
+
+	return PodInfo{Container: container.id, Source: attributionSourceCgroup}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	stdos "os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+const (
+	testContainerID      = "3f4e5d6c7b8a9f0e1d2c3b4a5f6e7d8c9b0a1f2e3d4c5b6a7f8e9d0c1b2a3f4e"
+	testOtherContainerID = "a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2"
+	testPodUID           = "8a2b0c1d-1111-2222-3333-444455556666"
+)
+
+func TestCgroupContainerOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		want   cgroupContainer
+		wantOK bool
+	}{
+		{
+			name: "containerd with the systemd driver",
+			path: "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" +
+				strings.ReplaceAll(testPodUID, "-", "_") + ".slice/cri-containerd-" + testContainerID + ".scope",
+			want:   cgroupContainer{id: testContainerID, podUID: testPodUID},
+			wantOK: true,
+		},
+		{
+			name:   "CRI-O with the cgroupfs driver",
+			path:   "/kubepods/burstable/pod" + testPodUID + "/crio-" + testContainerID,
+			want:   cgroupContainer{id: testContainerID, podUID: testPodUID},
+			wantOK: true,
+		},
+		{
+			name:   "Docker with the cgroupfs driver",
+			path:   "/docker/" + testContainerID,
+			want:   cgroupContainer{id: testContainerID},
+			wantOK: true,
+		},
+		{
+			name:   "Docker with the systemd driver",
+			path:   "/system.slice/docker-" + testContainerID + ".scope",
+			want:   cgroupContainer{id: testContainerID},
+			wantOK: true,
+		},
+		{
+			name: "Service of the host",
+			path: "/system.slice/containerd.service",
+		},
+		{
+			name: "Session of a user",
+			path: "/user.slice/user-1000.slice/session-3.scope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cgroupContainerOf(tt.path)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPodMapperCgroupAttribution(t *testing.T) {
+	realProcRoot := procRoot
+	realNVML := nvmlprovider.Client()
+	t.Cleanup(func() {
+		procRoot = realProcRoot
+		nvmlprovider.SetClient(realNVML)
+	})
+	procRoot = t.TempDir()
+
+	cgroups := map[uint32]string{
+		// Process of a pod, reported by the kubelet API, with cgroup v2
+		100: "0::/kubepods.slice/kubepods-pod" + strings.ReplaceAll(testPodUID, "-", "_") +
+			".slice/cri-containerd-" + testContainerID + ".scope\n",
+		// Process of a container outside Kubernetes, with cgroup v1
+		200: "12:memory:/docker/" + testOtherContainerID + "\n11:cpu,cpuacct:/docker/" + testOtherContainerID + "\n",
+		// Processes of two containers sharing a GPU
+		300: "0::/docker/" + testContainerID + "\n",
+		301: "0::/docker/" + testOtherContainerID + "\n",
+		// Process of the host
+		400: "0::/user.slice/user-1000.slice/session-3.scope\n",
+	}
+	for pid, cgroup := range cgroups {
+		dir := filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10))
+		require.NoError(t, stdos.MkdirAll(dir, 0o755))
+		require.NoError(t, stdos.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0o644))
+	}
+
+	gpuProcesses := map[string][]uint32{
+		"GPU-0": {100},
+		"GPU-1": {200},
+		"GPU-2": {300, 301},
+		"GPU-3": {400},
+		// The process exited after NVML listed it
+		"GPU-4": {500},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockNVML := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVML.EXPECT().GetProcesses(gomock.Any(), uint64(0)).DoAndReturn(
+		func(uuid string, _ uint64) (*nvmlprovider.GPUProcesses, error) {
+			processes := &nvmlprovider.GPUProcesses{}
+			for _, pid := range gpuProcesses[uuid] {
+				processes.Processes = append(processes.Processes, nvmlprovider.GPUProcess{Pid: pid})
+			}
+			return processes, nil
+		}).AnyTimes()
+	nvmlprovider.SetClient(mockNVML)
+
+	mockDeviceInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockDeviceInfo.EXPECT().GPUCount().Return(uint(len(gpuProcesses))).AnyTimes()
+	for i := range len(gpuProcesses) {
+		mockDeviceInfo.EXPECT().GPU(uint(i)).Return(deviceinfo.GPUInfo{
+			DeviceInfo: dcgm.Device{GPU: uint(i), UUID: "GPU-" + strconv.Itoa(i)},
+		}).AnyTimes()
+	}
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: filepath.Join(t.TempDir(), "kubelet.sock"),
+		PodAttributionCgroups:     true,
+		PodAttributionSource:      true,
+	})
+	podMapper.setIdentities(map[containerRef]containerIdentity{
+		{namespace: "team-a", pod: "train", container: "worker"}: {podUID: testPodUID, containerID: testContainerID},
+	})
+
+	counter := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL"}
+	metrics := collector.MetricsByCounter{}
+	for i := range len(gpuProcesses) {
+		metrics[counter] = append(metrics[counter], collector.Metric{
+			Counter:    counter,
+			GPU:        strconv.Itoa(i),
+			GPUUUID:    "GPU-" + strconv.Itoa(i),
+			Value:      "42",
+			Attributes: map[string]string{},
+		})
+	}
+
+	require.NoError(t, podMapper.Process(metrics, mockDeviceInfo))
+
+	got := map[string]map[string]string{}
+	for _, m := range metrics[counter] {
+		got[m.GPUUUID] = m.Attributes
+	}
+	assert.Equal(t, map[string]map[string]string{
+		"GPU-0": {
+			podAttribute:               "train",
+			namespaceAttribute:         "team-a",
+			containerAttribute:         "worker",
+			attributionSourceAttribute: attributionSourceCgroup,
+		},
+		"GPU-1": {
+			containerAttribute:         testOtherContainerID,
+			attributionSourceAttribute: attributionSourceCgroup,
+		},
+		"GPU-2": {},
+		"GPU-3": {},
+		"GPU-4": {},
+	}, got)
+}
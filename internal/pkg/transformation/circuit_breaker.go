@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker stops calls to an unhealthy dependency after a number of consecutive failures.
+// Once the cool down passes, a single probe call is allowed; its result closes or re-opens the circuit.
+type circuitBreaker struct {
+	state         circuitState
+	failures      int
+	threshold     int
+	coolDown      time.Duration
+	openedAt      time.Time
+	now           func() time.Time
+	onStateChange func(circuitState)
+	mtx           sync.Mutex
+}
+
+func newCircuitBreaker(threshold int, coolDown time.Duration, onStateChange func(circuitState)) *circuitBreaker {
+	if onStateChange == nil {
+		onStateChange = func(circuitState) {}
+	}
+
+	return &circuitBreaker{
+		threshold:     threshold,
+		coolDown:      coolDown,
+		now:           time.Now,
+		onStateChange: onStateChange,
+	}
+}
+
+// Allow reports whether a call may be made.
+func (cb *circuitBreaker) Allow() bool {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.coolDown {
+			return false
+		}
+		cb.setState(circuitHalfOpen)
+		return true
+	case circuitHalfOpen:
+		// A probe call is in flight
+		return false
+	}
+
+	return true
+}
+
+// Success records a successful call and closes the circuit.
+func (cb *circuitBreaker) Success() {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	cb.failures = 0
+	if cb.state != circuitClosed {
+		cb.setState(circuitClosed)
+	}
+}
+
+// Failure records a failed call and returns true if the circuit is open after it.
+func (cb *circuitBreaker) Failure() bool {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+		if cb.state != circuitOpen {
+			cb.setState(circuitOpen)
+		}
+		return true
+	}
+
+	return false
+}
+
+func (cb *circuitBreaker) State() circuitState {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	return cb.state
+}
+
+func (cb *circuitBreaker) setState(state circuitState) {
+	cb.state = state
+	cb.onStateChange(state)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	currentTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var states []circuitState
+	cb := newCircuitBreaker(2, time.Minute, func(state circuitState) {
+		states = append(states, state)
+	})
+	cb.now = func() time.Time { return currentTime }
+
+	assert.True(t, cb.Allow())
+	assert.False(t, cb.Failure(), "circuit must stay closed below the threshold")
+	assert.True(t, cb.Allow())
+	assert.True(t, cb.Failure(), "circuit must open at the threshold")
+	assert.Equal(t, circuitOpen, cb.State())
+	assert.False(t, cb.Allow(), "calls are not allowed while the circuit is open")
+
+	currentTime = currentTime.Add(time.Minute)
+	assert.True(t, cb.Allow(), "a probe is allowed after the cool down")
+	assert.Equal(t, circuitHalfOpen, cb.State())
+	assert.False(t, cb.Allow(), "only a single probe is allowed")
+
+	assert.True(t, cb.Failure(), "failed probe must re-open the circuit")
+	assert.False(t, cb.Allow())
+
+	currentTime = currentTime.Add(time.Minute)
+	assert.True(t, cb.Allow())
+	cb.Success()
+	assert.Equal(t, circuitClosed, cb.State())
+	assert.True(t, cb.Allow())
+
+	assert.Equal(t, []circuitState{
+		circuitOpen, circuitHalfOpen, circuitOpen, circuitHalfOpen, circuitClosed,
+	}, states)
+}
@@ -24,7 +24,38 @@ const (
 
 	hpcJobAttribute = "hpc_job"
 
+	// UUID of the MIG device of the GPU instance or compute instance, e.g. MIG-b3c6e4e4-0b2d-5c6a-8c3b-0e6f6a4d9c11
+	migUUIDAttribute = "mig_uuid"
+
+	// Prefix of the attributes of the Kubernetes node labels, as in kube-state-metrics
+	nodeLabelAttributePrefix = "label_"
+
+	attributionSourceAttribute = "attribution_source"
+
+	// Attributes of the series attributed through DRA: the resource claim and the allocated device of its pool
+	claimAttribute          = "resource_claim"
+	claimNamespaceAttribute = "resource_claim_namespace"
+	draPoolAttribute        = "dra_pool"
+	draDeviceAttribute      = "dra_device"
+
+	// Labels of the exemplars, which link the series attributed to pods to the pod and the container
+	podUIDExemplarLabel      = "pod_uid"
+	containerIDExemplarLabel = "container_id"
+
 	oldPodAttribute       = "pod_name"
 	oldNamespaceAttribute = "pod_namespace"
 	oldContainerAttribute = "container_name"
+
+	// Sources of the pod attribution
+	attributionSourceDevicePlugin = "device-plugin"
+	attributionSourceDRA          = "dra"
+	attributionSourceCDI          = "cdi"
+	attributionSourceCgroup       = "cgroup"
+
+	// Prefix of the allocated resources of DRA claims in the container statuses
+	draClaimResourcePrefix = "claim:"
+	// Prefix of the pod annotations, which request CDI devices, e.g. cdi.k8s.io/gpu: nvidia.com/gpu=0
+	cdiAnnotationPrefix = "cdi.k8s.io/"
+	// Name of the CDI device, which stands for all the GPUs
+	cdiAllDevices = "all"
 )
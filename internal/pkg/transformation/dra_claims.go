@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"strings"
+)
+
+// draAllocation is a device allocated through a DRA resource claim. Kubelet reports it in the allocated resources of
+// the container status, with the resource named claim:<claim>/<request>, and the device identified as
+// <driver>/<pool>/<device>.
+type draAllocation struct {
+	claim   string
+	request string
+	driver  string
+	pool    string
+	device  string
+}
+
+// parseDRAAllocation parses an allocated resource of a container, and reports false, when it isn't allocated through
+// a DRA claim, e.g. by a device plugin.
+func parseDRAAllocation(resourceName, deviceID string) (draAllocation, bool) {
+	claimRequest, found := strings.CutPrefix(resourceName, draClaimResourcePrefix)
+	if !found {
+		return draAllocation{}, false
+	}
+
+	var allocation draAllocation
+	allocation.claim, allocation.request, _ = strings.Cut(claimRequest, "/")
+	allocation.driver, allocation.pool, allocation.device = parseDRADeviceID(deviceID)
+
+	return allocation, true
+}
+
+// parseDRADeviceID splits the ID of a DRA device, <driver>/<pool>/<device>. Pool names may contain slashes, the names
+// of drivers and devices don't. The driver and the pool are empty, when the ID isn't qualified by them.
+func parseDRADeviceID(deviceID string) (driver, pool, device string) {
+	first, last := strings.Index(deviceID, "/"), strings.LastIndex(deviceID, "/")
+	if first < 0 || first == last {
+		return "", "", deviceID[last+1:]
+	}
+
+	return deviceID[:first], deviceID[first+1 : last], deviceID[last+1:]
+}
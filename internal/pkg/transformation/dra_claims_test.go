@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func TestParseDRAAllocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceName string
+		deviceID     string
+		want         draAllocation
+		wantDRA      bool
+	}{
+		{
+			name:         "Claim with request",
+			resourceName: "claim:gpu-claim/gpu",
+			deviceID:     "gpu.nvidia.com/node-a/gpu-0",
+			want: draAllocation{
+				claim: "gpu-claim", request: "gpu", driver: "gpu.nvidia.com", pool: "node-a", device: "gpu-0",
+			},
+			wantDRA: true,
+		},
+		{
+			name:         "Pool with slashes",
+			resourceName: "claim:gpu-claim/gpu",
+			deviceID:     "gpu.nvidia.com/cluster/node-a/gpu-0",
+			want: draAllocation{
+				claim: "gpu-claim", request: "gpu", driver: "gpu.nvidia.com", pool: "cluster/node-a", device: "gpu-0",
+			},
+			wantDRA: true,
+		},
+		{
+			name:         "Claim without request and unqualified device",
+			resourceName: "claim:gpu-claim",
+			deviceID:     "gpu-0",
+			want:         draAllocation{claim: "gpu-claim", device: "gpu-0"},
+			wantDRA:      true,
+		},
+		{
+			name:         "Device plugin",
+			resourceName: appconfig.NvidiaResourceName,
+			deviceID:     "GPU-b8ea3855-276c-c9cb-b366-c6fa655957c5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isDRA := parseDRAAllocation(tt.resourceName, tt.deviceID)
+			assert.Equal(t, tt.wantDRA, isDRA)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
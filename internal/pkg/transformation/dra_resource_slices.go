@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	// draPoolIndex indexes the ResourceSlices by <driver>/<pool>
+	draPoolIndex = "pool"
+	// Attribute of the devices of the NVIDIA DRA driver with the UUID of the GPU or the MIG device
+	draUUIDAttribute = "uuid"
+)
+
+var (
+	draResourceSlices = selfmetrics.Default().Gauge("dcgm_exporter_dra_resource_slices",
+		"Number of ResourceSlices in the cache of the DRA device resolution.")
+	draResourceSliceEvents = selfmetrics.Default().Counter("dcgm_exporter_dra_resource_slice_events_total",
+		"Number of ResourceSlice changes received from the Kubernetes API, by event.")
+	draDeviceLookups = selfmetrics.Default().Counter("dcgm_exporter_dra_device_lookups_total",
+		"Number of DRA devices resolved from the ResourceSlices, by result: hit, or miss, when the device is identified by its name only.")
+)
+
+// DRAResourceSliceManager resolves the devices, which are allocated through DRA and reported by kubelet as
+// <driver>/<pool>/<device>, to the UUIDs of their GPUs or MIG devices. The ResourceSlices are watched cluster-wide,
+// so devices, which a driver publishes after the exporter started, are resolved as well. Only the ResourceSlices of
+// the latest generation of a pool are considered, so the devices of a driver, which restarted, are resolved from the
+// ResourceSlices it published again.
+type DRAResourceSliceManager struct {
+	client   kubernetes.Interface
+	informer cache.SharedIndexInformer
+	// revision changes with every change of the ResourceSlices, so resolved devices can be cached until then
+	revision atomic.Uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+func NewDRAResourceSliceManager(client kubernetes.Interface) *DRAResourceSliceManager {
+	slog.Info("DRA devices are resolved from the ResourceSlices")
+
+	return &DRAResourceSliceManager{
+		client: client,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Revision returns a value, which changes whenever the ResourceSlices change.
+func (m *DRAResourceSliceManager) Revision() uint64 {
+	m.startOnce.Do(m.start)
+
+	return m.revision.Load()
+}
+
+// Resolve returns the UUID of the GPU or the MIG device of a DRA device, identified as <driver>/<pool>/<device>.
+func (m *DRAResourceSliceManager) Resolve(deviceID string) (string, bool) {
+	m.startOnce.Do(m.start)
+
+	uuid, found := m.resolve(deviceID)
+	if found {
+		draDeviceLookups.Inc("result", "hit")
+	} else {
+		draDeviceLookups.Inc("result", "miss")
+	}
+
+	return uuid, found
+}
+
+func (m *DRAResourceSliceManager) resolve(deviceID string) (string, bool) {
+	driver, pool, device := parseDRADeviceID(deviceID)
+	if driver == "" {
+		return "", false
+	}
+
+	objs, err := m.informer.GetIndexer().ByIndex(draPoolIndex, driver+"/"+pool)
+	if err != nil {
+		return "", false
+	}
+
+	// Slices of older generations are left over by a driver, which is updating the pool
+	var generation int64
+	for _, obj := range objs {
+		generation = max(generation, obj.(*resourcev1alpha3.ResourceSlice).Spec.Pool.Generation)
+	}
+
+	for _, obj := range objs {
+		slice := obj.(*resourcev1alpha3.ResourceSlice)
+		if slice.Spec.Pool.Generation != generation {
+			continue
+		}
+		for _, d := range slice.Spec.Devices {
+			if d.Name != device || d.Basic == nil {
+				continue
+			}
+			if uuid, exists := draDeviceUUID(driver, d.Basic.Attributes); exists {
+				return uuid, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// draDeviceUUID returns the UUID attribute of a device, which is qualified with the domain of the driver, or not.
+func draDeviceUUID(driver string, attributes map[resourcev1alpha3.QualifiedName]resourcev1alpha3.DeviceAttribute) (
+	string, bool,
+) {
+	for _, name := range []string{draUUIDAttribute, driver + "/" + draUUIDAttribute} {
+		if attribute, exists := attributes[resourcev1alpha3.QualifiedName(name)]; exists && attribute.StringValue != nil {
+			return *attribute.StringValue, true
+		}
+	}
+
+	return "", false
+}
+
+// start watches the ResourceSlices and waits for them to be read, for at most draResourceSlicesSyncTimeout, so that
+// the first metrics are attributed as well.
+func (m *DRAResourceSliceManager) start() {
+	factory := informers.NewSharedInformerFactory(m.client, 0)
+	m.informer = factory.Resource().V1alpha3().ResourceSlices().Informer()
+
+	err := m.informer.AddIndexers(cache.Indexers{draPoolIndex: func(obj any) ([]string, error) {
+		slice := obj.(*resourcev1alpha3.ResourceSlice)
+		return []string{slice.Spec.Driver + "/" + slice.Spec.Pool.Name}, nil
+	}})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to index the ResourceSlices; err: %v", err))
+	}
+
+	_, err = m.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { m.changed("add") },
+		UpdateFunc: func(any, any) { m.changed("update") },
+		DeleteFunc: func(any) { m.changed("delete") },
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to watch the ResourceSlices; err: %v", err))
+	}
+
+	factory.Start(m.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), draResourceSlicesSyncTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), m.informer.HasSynced) {
+		slog.Warn(fmt.Sprintf("The ResourceSlices aren't read after %s; DRA devices are resolved once they are",
+			draResourceSlicesSyncTimeout))
+	}
+}
+
+func (m *DRAResourceSliceManager) changed(event string) {
+	m.revision.Add(1)
+	draResourceSliceEvents.Inc("event", event)
+	draResourceSlices.Set(float64(len(m.informer.GetStore().ListKeys())))
+}
+
+func (m *DRAResourceSliceManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func newTestResourceSlice(name, pool string, generation int64, uuids map[string]string) *resourcev1alpha3.ResourceSlice {
+	slice := &resourcev1alpha3.ResourceSlice{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: resourcev1alpha3.ResourceSliceSpec{
+			Driver:   "gpu.nvidia.com",
+			Pool:     resourcev1alpha3.ResourcePool{Name: pool, Generation: generation, ResourceSliceCount: 1},
+			NodeName: pool,
+		},
+	}
+	for device, uuid := range uuids {
+		slice.Spec.Devices = append(slice.Spec.Devices, resourcev1alpha3.Device{
+			Name: device,
+			Basic: &resourcev1alpha3.BasicDevice{
+				Attributes: map[resourcev1alpha3.QualifiedName]resourcev1alpha3.DeviceAttribute{
+					draUUIDAttribute: {StringValue: ptr.To(uuid)},
+					"type":           {StringValue: ptr.To("gpu")},
+				},
+			},
+		})
+	}
+	return slice
+}
+
+func TestDRAResourceSliceManager_Resolve(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newTestResourceSlice("node-a-gpu", "node-a", 1, map[string]string{
+			"gpu-0":       "GPU-00000000-0000-0000-0000-000000000000",
+			"gpu-1-mig-0": "MIG-11111111-1111-1111-1111-111111111111",
+		}),
+		// Left over by a driver, which republished the pool
+		newTestResourceSlice("node-b-gpu-old", "node-b", 1, map[string]string{
+			"gpu-0": "GPU-stale",
+		}),
+		newTestResourceSlice("node-b-gpu", "node-b", 2, map[string]string{
+			"gpu-0": "GPU-22222222-2222-2222-2222-222222222222",
+		}),
+	)
+
+	manager := NewDRAResourceSliceManager(client)
+	defer manager.Stop()
+
+	tests := []struct {
+		deviceID  string
+		wantUUID  string
+		wantFound bool
+	}{
+		{deviceID: "gpu.nvidia.com/node-a/gpu-0", wantUUID: "GPU-00000000-0000-0000-0000-000000000000", wantFound: true},
+		{deviceID: "gpu.nvidia.com/node-a/gpu-1-mig-0", wantUUID: "MIG-11111111-1111-1111-1111-111111111111", wantFound: true},
+		{deviceID: "gpu.nvidia.com/node-b/gpu-0", wantUUID: "GPU-22222222-2222-2222-2222-222222222222", wantFound: true},
+		{deviceID: "gpu.nvidia.com/node-a/gpu-7"},
+		{deviceID: "other.example.com/node-a/gpu-0"},
+		{deviceID: "gpu-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.deviceID, func(t *testing.T) {
+			uuid, found := manager.Resolve(tt.deviceID)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantUUID, uuid)
+		})
+	}
+
+	// Devices published after the start are resolved as well
+	revision := manager.Revision()
+	_, err := client.ResourceV1alpha3().ResourceSlices().Create(context.Background(),
+		newTestResourceSlice("node-c-gpu", "node-c", 1, map[string]string{"gpu-0": "GPU-33333333"}),
+		metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		uuid, _ := manager.Resolve("gpu.nvidia.com/node-c/gpu-0")
+		return uuid == "GPU-33333333" && manager.Revision() != revision
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestProcessPodMapper_DRAResourceSlices(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("dra-pod", corev1.PodRunning, "claim:gpu-claim/gpu", "gpu.nvidia.com/node-a/gpu-0"),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType: appconfig.GPUUID,
+		KubeletAPIURL:       server.URL,
+	})
+	podMapper.draDevices = NewDRAResourceSliceManager(fake.NewSimpleClientset(
+		newTestResourceSlice("node-a-gpu", "node-a", 1, map[string]string{"gpu-0": gpu0})))
+	defer podMapper.Stop()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	metrics := newPodMapperTestMetrics(gpu0)
+	require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+	for _, values := range metrics {
+		assert.Equal(t, "dra-pod", values[0].Attributes[podAttribute])
+	}
+}
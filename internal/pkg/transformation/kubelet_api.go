@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1alpha1"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/kubeclient"
+)
+
+const kubeletPodsPath = "/pods"
+
+// queryKubeletAPI lists the pods of the node through the kubelet HTTP API and converts the device
+// allocations reported in the pod status into a PodResources response.
+func (p *PodMapper) queryKubeletAPI() (*podresourcesapi.ListPodResourcesResponse, error) {
+	p.kubeletClientOnce.Do(func() {
+		p.kubeletClient, p.kubeletClientErr = p.newKubeletAPIClient()
+	})
+	if p.kubeletClientErr != nil {
+		return nil, p.kubeletClientErr
+	}
+	client := p.kubeletClient
+
+	timeout := connectionTimeout
+	if p.Config.PodResourcesTimeout > 0 {
+		timeout = p.Config.PodResourcesTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(p.Config.KubeletAPIURL, "/") + kubeletPodsPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failure creating kubelet API request; err: %w", err)
+	}
+
+	if p.Config.KubeletAPITokenFile != "" {
+		token, err := readKubeletFile(p.Config.KubeletAPITokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failure reading kubelet API token; err: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failure getting pods from '%s'; err: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failure getting pods from '%s'; status: %s", url, resp.Status)
+	}
+
+	var pods corev1.PodList
+	if err := json.NewDecoder(resp.Body).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("failure decoding pods from '%s'; err: %w", url, err)
+	}
+
+	if p.Config.OpenMetricsExemplars || p.Config.PodAttributionCgroups {
+		p.setIdentities(toContainerIdentities(&pods))
+	}
+
+	return toPodResources(&pods), nil
+}
+
+func (p *PodMapper) newKubeletAPIClient() (*http.Client, error) {
+	proxy, err := kubeclient.Proxy(p.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(p.Config.KubeletAPIURL, "https://") {
+		return &http.Client{
+			Transport: &http.Transport{Proxy: proxy},
+		}, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: p.Config.KubeletAPIInsecure, //nolint:gosec // kubelet serving certificates are often self-signed
+	}
+
+	// The CA bundle of the Kubernetes clients also applies to the kubelet, e.g. when both are behind the same
+	// intercepting proxy
+	caFile := p.Config.KubeletAPICAFile
+	if caFile == "" {
+		caFile = p.Config.KubernetesCAFile
+	}
+
+	if caFile != "" {
+		ca, err := readKubeletFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failure reading kubelet API CA file; err: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in '%s'", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{Proxy: proxy, TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// toPodResources builds the device allocations from the allocatedResourcesStatus of the container statuses.
+// Pods, which reached a terminal phase, no longer hold their devices and are skipped.
+func toPodResources(pods *corev1.PodList) *podresourcesapi.ListPodResourcesResponse {
+	resp := &podresourcesapi.ListPodResourcesResponse{}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		podResources := &podresourcesapi.PodResources{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			containerResources := &podresourcesapi.ContainerResources{
+				Name: status.Name,
+			}
+
+			for _, allocated := range status.AllocatedResourcesStatus {
+				devices := &podresourcesapi.ContainerDevices{
+					ResourceName: string(allocated.Name),
+				}
+				for _, resource := range allocated.Resources {
+					devices.DeviceIds = append(devices.DeviceIds, string(resource.ResourceID))
+				}
+				containerResources.Devices = append(containerResources.Devices, devices)
+			}
+
+			podResources.Containers = append(podResources.Containers, containerResources)
+		}
+
+		if len(podResources.Containers) > 0 {
+			podResources.Containers[0].Devices = append(podResources.Containers[0].Devices,
+				cdiAnnotationDevices(pod.Annotations)...)
+		}
+
+		resp.PodResources = append(resp.PodResources, podResources)
+	}
+
+	return resp
+}
+
+// toContainerIdentities returns the pod UIDs and the container IDs of the containers of the pods. Container IDs are
+// reported as <runtime>://<ID>, and only the ID is kept, so the exemplars stay within the length limit of OpenMetrics.
+func toContainerIdentities(pods *corev1.PodList) map[containerRef]containerIdentity {
+	identities := map[containerRef]containerIdentity{}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			containerID := status.ContainerID
+			if _, id, found := strings.Cut(containerID, "://"); found {
+				containerID = id
+			}
+			identities[containerRef{namespace: pod.Namespace, pod: pod.Name, container: status.Name}] =
+				containerIdentity{podUID: string(pod.UID), containerID: containerID}
+		}
+	}
+
+	return identities
+}
+
+func (p *PodMapper) setIdentities(identities map[containerRef]containerIdentity) {
+	p.identitiesMtx.Lock()
+	defer p.identitiesMtx.Unlock()
+
+	p.identities = identities
+}
+
+// ExemplarLabels returns the pod UID and the container ID of the series, which are attributed to the container of a
+// pod, so that the exemplars of the series link them to the workload.
+func (p *PodMapper) ExemplarLabels(labels map[string]string) map[string]string {
+	if !p.Config.OpenMetricsExemplars {
+		return nil
+	}
+
+	ref := containerRef{
+		namespace: labels[namespaceAttribute],
+		pod:       labels[podAttribute],
+		container: labels[containerAttribute],
+	}
+	if p.Config.UseOldNamespace {
+		ref = containerRef{
+			namespace: labels[oldNamespaceAttribute],
+			pod:       labels[oldPodAttribute],
+			container: labels[oldContainerAttribute],
+		}
+	}
+	if ref.pod == "" {
+		return nil
+	}
+
+	p.identitiesMtx.RLock()
+	identity, exists := p.identities[ref]
+	p.identitiesMtx.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	exemplar := map[string]string{podUIDExemplarLabel: identity.podUID}
+	if identity.containerID != "" {
+		exemplar[containerIDExemplarLabel] = identity.containerID
+	}
+
+	return exemplar
+}
+
+// cdiAnnotationDevices returns the CDI devices, which the pod annotations request, e.g.
+// cdi.k8s.io/gpu: nvidia.com/gpu=0,nvidia.com/gpu=1. The annotations don't name a container, so their devices are
+// attributed to the first container of the pod.
+func cdiAnnotationDevices(annotations map[string]string) []*podresourcesapi.ContainerDevices {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		if strings.HasPrefix(key, cdiAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	devices := make([]*podresourcesapi.ContainerDevices, 0, len(keys))
+	for _, key := range keys {
+		var deviceIDs []string
+		for _, deviceID := range strings.Split(annotations[key], ",") {
+			if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+				deviceIDs = append(deviceIDs, deviceID)
+			}
+		}
+		devices = append(devices, &podresourcesapi.ContainerDevices{ResourceName: key, DeviceIds: deviceIDs})
+	}
+
+	return devices
+}
+
+func readKubeletFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
@@ -0,0 +1,487 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func newKubeletAPITestPod(name string, phase corev1.PodPhase, resourceName string, deviceIDs ...string) corev1.Pod {
+	status := corev1.ContainerStatus{Name: "default"}
+	if len(deviceIDs) > 0 {
+		allocated := corev1.ResourceStatus{Name: corev1.ResourceName(resourceName)}
+		for _, id := range deviceIDs {
+			allocated.Resources = append(allocated.Resources, corev1.ResourceHealth{
+				ResourceID: corev1.ResourceID(id),
+				Health:     corev1.ResourceHealthStatusHealthy,
+			})
+		}
+		status.AllocatedResourcesStatus = []corev1.ResourceStatus{allocated}
+	}
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: []corev1.ContainerStatus{status},
+		},
+	}
+}
+
+func TestProcessPodMapper_KubeletAPI(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+	gpu2 := "0a4f6a1e-9a5e-4e02-b0f4-4a7c9b3d8e22"
+
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("gpu-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu0),
+			newKubeletAPITestPod("completed-pod", corev1.PodSucceeded, appconfig.NvidiaResourceName, gpu1),
+			newKubeletAPITestPod("cpu-pod", corev1.PodRunning, "example.com/foo", gpu2),
+			newKubeletAPITestPod("no-status-pod", corev1.PodRunning, ""),
+		},
+	}
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != kubeletPodsPath {
+			http.NotFound(w, r)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	tokenFile, err := os.CreateTemp(t.TempDir(), "token")
+	require.NoError(t, err)
+	_, err = tokenFile.WriteString("secret\n")
+	require.NoError(t, err)
+	require.NoError(t, tokenFile.Close())
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType: appconfig.GPUUID,
+		KubeletAPIURL:       server.URL + "/",
+		KubeletAPITokenFile: tokenFile.Name(),
+		// The socket must not be required, when the kubelet API is used
+		PodResourcesKubeletSocket: "/nonexistent/kubelet.sock",
+	})
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	for gpu, want := range map[string]string{gpu0: "gpu-pod", gpu1: "", gpu2: ""} {
+		metrics := newPodMapperTestMetrics(gpu)
+		require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+		for _, values := range metrics {
+			assert.Equal(t, want, values[0].Attributes[podAttribute], gpu)
+		}
+	}
+	assert.Equal(t, "Bearer secret", authorization)
+}
+
+func TestProcessPodMapper_KubeletAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType: appconfig.GPUUID,
+		KubeletAPIURL:       server.URL,
+	})
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	err := podMapper.Process(newPodMapperTestMetrics("b8ea3855-276c-c9cb-b366-c6fa655957c5"), mockSystemInfo)
+	require.ErrorContains(t, err, "403")
+}
+
+func TestProcessPodMapper_KubeletAPIProxy(t *testing.T) {
+	gpu := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("gpu-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu),
+		},
+	}
+
+	// The proxy receives the requests for the kubelet, which is not reachable directly
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer proxy.Close()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType: appconfig.GPUUID,
+		KubeletAPIURL:       "http://kubelet.invalid:10255",
+		KubernetesProxyURL:  proxy.URL,
+	})
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	metrics := newPodMapperTestMetrics(gpu)
+	require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+	for _, values := range metrics {
+		assert.Equal(t, "gpu-pod", values[0].Attributes[podAttribute])
+	}
+	assert.Equal(t, "http://kubelet.invalid:10255"+kubeletPodsPath, proxied)
+}
+
+func TestPodMapper_ExemplarLabels(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+
+	pod := newKubeletAPITestPod("gpu-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu0)
+	pod.UID = "0b7e2f44-5c1e-4f3a-9d5b-6a2f1c9e8d70"
+	pod.Status.ContainerStatuses[0].ContainerID = "containerd://4f1c2e9a"
+	pods := corev1.PodList{Items: []corev1.Pod{pod}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	tests := []struct {
+		name      string
+		exemplars bool
+		gpu       string
+		want      map[string]string
+	}{
+		{
+			name:      "attributed series",
+			exemplars: true,
+			gpu:       gpu0,
+			want:      map[string]string{podUIDExemplarLabel: string(pod.UID), containerIDExemplarLabel: "4f1c2e9a"},
+		},
+		{
+			name:      "series not attributed",
+			exemplars: true,
+			gpu:       gpu1,
+		},
+		{
+			name: "exemplars disabled",
+			gpu:  gpu0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podMapper := NewPodMapper(&appconfig.Config{
+				KubernetesGPUIdType:  appconfig.GPUUID,
+				KubeletAPIURL:        server.URL,
+				OpenMetricsExemplars: tt.exemplars,
+			})
+
+			metrics := newPodMapperTestMetrics(tt.gpu)
+			require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+			for _, values := range metrics {
+				assert.Equal(t, tt.want, podMapper.ExemplarLabels(values[0].Attributes))
+			}
+		})
+	}
+}
+
+func TestProcessPodMapper_AttributionSource(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+	gpu2 := "0a4f6a1e-9a5e-4e02-b0f4-4a7c9b3d8e22"
+
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("device-plugin-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu0),
+			newKubeletAPITestPod("cdi-pod", corev1.PodRunning, appconfig.NvidiaResourceName, "nvidia.com/gpu="+gpu1),
+			newKubeletAPITestPod("dra-pod", corev1.PodRunning, "claim:gpu-claim/gpu", "gpu.nvidia.com/node-a/"+gpu2),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:  appconfig.GPUUID,
+		KubeletAPIURL:        server.URL,
+		PodAttributionSource: true,
+	})
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	tests := []struct {
+		gpu        string
+		wantPod    string
+		wantSource string
+	}{
+		{gpu: gpu0, wantPod: "device-plugin-pod", wantSource: attributionSourceDevicePlugin},
+		{gpu: gpu1, wantPod: "cdi-pod", wantSource: attributionSourceCDI},
+		{gpu: gpu2, wantPod: "dra-pod", wantSource: attributionSourceDRA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantSource, func(t *testing.T) {
+			metrics := newPodMapperTestMetrics(tt.gpu)
+			require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+			for _, values := range metrics {
+				assert.Equal(t, tt.wantPod, values[0].Attributes[podAttribute])
+				assert.Equal(t, tt.wantSource, values[0].Attributes[attributionSourceAttribute])
+
+				// Only the devices allocated through DRA are attributed to their claim
+				if tt.wantSource == attributionSourceDRA {
+					assert.Equal(t, "gpu-claim", values[0].Attributes[claimAttribute])
+					assert.Equal(t, "default", values[0].Attributes[claimNamespaceAttribute])
+					assert.Equal(t, "node-a", values[0].Attributes[draPoolAttribute])
+					assert.Equal(t, gpu2, values[0].Attributes[draDeviceAttribute])
+				} else {
+					assert.NotContains(t, values[0].Attributes, claimAttribute)
+				}
+			}
+
+			series, ok := selfmetrics.Default().Value("dcgm_exporter_pod_attribution_series", "source", tt.wantSource)
+			require.True(t, ok)
+			assert.Equal(t, float64(1), series)
+		})
+	}
+}
+
+func TestProcessPodMapper_GPUInstances(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+	mig1 := "MIG-5b1c3f0e-8d6a-5e3b-9c2f-7a4d1e6b0c33"
+	mig2 := "MIG-9e2d4a1b-3c5f-5a7e-8b0d-1f6c2e9a4d44"
+
+	// A whole GPU and two GPU instances of another GPU, allocated by the device plugin and by DRA
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("gpu-pod", corev1.PodRunning, appconfig.NvidiaResourceName, gpu0),
+			newKubeletAPITestPod("mig-pod", corev1.PodRunning, appconfig.NvidiaMigResourcePrefix+"1g.10gb", mig1),
+			newKubeletAPITestPod("dra-mig-pod", corev1.PodRunning, "claim:mig-claim/mig", "gpu.nvidia.com/node-a/"+mig2),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	mockNVMLProvider := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVMLProvider.EXPECT().GetMIGDeviceInfoByID(mig1).Return(&nvmlprovider.MIGDeviceInfo{
+		ParentUUID:    gpu1,
+		GPUInstanceID: 1,
+	}, nil).AnyTimes()
+	mockNVMLProvider.EXPECT().GetMIGDeviceInfoByID(mig2).Return(&nvmlprovider.MIGDeviceInfo{
+		ParentUUID:    gpu1,
+		GPUInstanceID: 2,
+	}, nil).AnyTimes()
+	nvmlprovider.SetClient(mockNVMLProvider)
+
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockSystemInfo.EXPECT().GPUCount().Return(uint(2)).AnyTimes()
+	mockSystemInfo.EXPECT().GPU(uint(0)).Return(deviceinfo.GPUInfo{
+		DeviceInfo: dcgm.Device{GPU: 0, UUID: gpu0},
+	}).AnyTimes()
+	mockSystemInfo.EXPECT().GPU(uint(1)).Return(deviceinfo.GPUInfo{
+		DeviceInfo: dcgm.Device{GPU: 1, UUID: gpu1},
+		MigEnabled: true,
+	}).AnyTimes()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:        appconfig.GPUUID,
+		KubeletAPIURL:              server.URL,
+		PodAttributionGPUInstances: true,
+	})
+
+	tests := []struct {
+		name    string
+		metric  collector.Metric
+		wantPod string
+	}{
+		{
+			name:    "whole GPU",
+			metric:  collector.Metric{GPU: "0", GPUUUID: gpu0},
+			wantPod: "gpu-pod",
+		},
+		{
+			name:    "GPU with GPU instances",
+			metric:  collector.Metric{GPU: "1", GPUUUID: gpu1},
+			wantPod: "",
+		},
+		{
+			name:    "GPU instance allocated by the device plugin",
+			metric:  collector.Metric{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "1", MigProfile: "1g.10gb"},
+			wantPod: "mig-pod",
+		},
+		{
+			name:    "GPU instance allocated by DRA without a MIG profile",
+			metric:  collector.Metric{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "2"},
+			wantPod: "dra-mig-pod",
+		},
+		{
+			name:    "GPU instance not allocated",
+			metric:  collector.Metric{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "3", MigProfile: "1g.10gb"},
+			wantPod: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+			tt.metric.Counter = counter
+			tt.metric.Attributes = map[string]string{}
+			metrics := collector.MetricsByCounter{counter: {tt.metric}}
+
+			require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+			assert.Equal(t, tt.wantPod, metrics[counter][0].Attributes[podAttribute])
+		})
+	}
+}
+
+func TestProcessPodMapper_CDIDevices(t *testing.T) {
+	gpu0 := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+	gpu1 := "c3a3c4d2-1f8e-4c7b-9a9e-2b1b5a0f8d11"
+	gpu2 := "0a4f6a1e-9a5e-4e02-b0f4-4a7c9b3d8e22"
+	mig1 := "MIG-5b1c3f0e-8d6a-5e3b-9c2f-7a4d1e6b0c33"
+
+	// A GPU and a MIG device named by their index, and a GPU requested by a CDI annotation
+	annotationPod := newKubeletAPITestPod("annotation-pod", corev1.PodRunning, "")
+	annotationPod.Annotations = map[string]string{cdiAnnotationPrefix + "gpu": "nvidia.com/gpu=2"}
+	pods := corev1.PodList{
+		Items: []corev1.Pod{
+			newKubeletAPITestPod("index-pod", corev1.PodRunning, appconfig.NvidiaResourceName, "nvidia.com/gpu=0"),
+			newKubeletAPITestPod("mig-pod", corev1.PodRunning, appconfig.NvidiaResourceName, "nvidia.com/gpu=1:0"),
+			annotationPod,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(pods))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	mockNVMLProvider := mocknvmlprovider.NewMockNVML(ctrl)
+	mockNVMLProvider.EXPECT().GetMIGDevices(gpu1).Return(&nvmlprovider.MIGDevices{
+		Devices: []nvmlprovider.MIGDevice{{Index: 0, UUID: mig1, GPUInstanceID: 1}},
+	}, nil).AnyTimes()
+	mockNVMLProvider.EXPECT().GetMIGDeviceInfoByID(mig1).Return(&nvmlprovider.MIGDeviceInfo{
+		ParentUUID:    gpu1,
+		GPUInstanceID: 1,
+	}, nil).AnyTimes()
+	nvmlprovider.SetClient(mockNVMLProvider)
+
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	mockSystemInfo.EXPECT().GPUCount().Return(uint(3)).AnyTimes()
+	for i, uuid := range []string{gpu0, gpu1, gpu2} {
+		mockSystemInfo.EXPECT().GPU(uint(i)).Return(deviceinfo.GPUInfo{
+			DeviceInfo: dcgm.Device{GPU: uint(i), UUID: uuid},
+			MigEnabled: uuid == gpu1,
+		}).AnyTimes()
+	}
+
+	tests := []struct {
+		name       string
+		gpuIDType  appconfig.KubernetesGPUIDType
+		metric     collector.Metric
+		wantPod    string
+		wantSource string
+	}{
+		{
+			name:       "GPU index by UUID",
+			gpuIDType:  appconfig.GPUUID,
+			metric:     collector.Metric{GPU: "0", GPUUUID: gpu0},
+			wantPod:    "index-pod",
+			wantSource: attributionSourceCDI,
+		},
+		{
+			name:       "GPU index by device name",
+			gpuIDType:  appconfig.DeviceName,
+			metric:     collector.Metric{GPU: "0", GPUDevice: "nvidia0"},
+			wantPod:    "index-pod",
+			wantSource: attributionSourceCDI,
+		},
+		{
+			name:       "MIG device index",
+			gpuIDType:  appconfig.GPUUID,
+			metric:     collector.Metric{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "1", MigProfile: "1g.10gb"},
+			wantPod:    "mig-pod",
+			wantSource: attributionSourceCDI,
+		},
+		{
+			name:       "CDI annotation",
+			gpuIDType:  appconfig.GPUUID,
+			metric:     collector.Metric{GPU: "2", GPUUUID: gpu2},
+			wantPod:    "annotation-pod",
+			wantSource: attributionSourceCDI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podMapper := NewPodMapper(&appconfig.Config{
+				KubernetesGPUIdType:        tt.gpuIDType,
+				KubeletAPIURL:              server.URL,
+				PodAttributionGPUInstances: true,
+				PodAttributionSource:       true,
+			})
+
+			counter := counters.Counter{FieldID: 155, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"}
+			tt.metric.Counter = counter
+			tt.metric.Attributes = map[string]string{}
+			metrics := collector.MetricsByCounter{counter: {tt.metric}}
+
+			require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+			assert.Equal(t, tt.wantPod, metrics[counter][0].Attributes[podAttribute])
+			assert.Equal(t, tt.wantSource, metrics[counter][0].Attributes[attributionSourceAttribute])
+		})
+	}
+}
+
+func TestCDIAnnotationDevices(t *testing.T) {
+	devices := cdiAnnotationDevices(map[string]string{
+		"cdi.k8s.io/net":  "vendor.com/net=eth0",
+		"cdi.k8s.io/gpus": "nvidia.com/gpu=0, nvidia.com/gpu=1,",
+		"other":           "nvidia.com/gpu=2",
+	})
+
+	require.Len(t, devices, 2)
+	assert.Equal(t, "cdi.k8s.io/gpus", devices[0].GetResourceName())
+	assert.Equal(t, []string{"nvidia.com/gpu=0", "nvidia.com/gpu=1"}, devices[0].GetDeviceIds())
+	assert.Equal(t, "cdi.k8s.io/net", devices[1].GetResourceName())
+}
@@ -23,7 +23,9 @@ import (
 	"net"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc/resolver"
@@ -35,22 +37,58 @@ import (
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
 )
 
 var (
 	connectionTimeout = 10 * time.Second
 
+	// Number of consecutive kubelet failures, which opens the circuit, and how long attribution is skipped
+	kubeletFailureThreshold = 3
+	kubeletCoolDown         = 30 * time.Second
+
+	kubeletCircuitState = selfmetrics.Default().Gauge("dcgm_exporter_kubelet_circuit_breaker_state",
+		"State of the circuit breaker for kubelet PodResources calls: 0 closed, 1 open, 2 half-open.")
+	kubeletErrors = selfmetrics.Default().Counter("dcgm_exporter_kubelet_pod_resources_errors_total",
+		"Number of failed kubelet PodResources calls.")
+	podAttributionSeries = selfmetrics.Default().Gauge("dcgm_exporter_pod_attribution_series",
+		"Number of series attributed to pods during the last scrape, by attribution source.")
+
 	// Allow for MIG devices with or without GPU sharing to match in GKE.
 	gkeMigDeviceIDRegex            = regexp.MustCompile(`^nvidia([0-9]+)/gi([0-9]+)(/vgpu[0-9]+)?$`)
 	gkeVirtualGPUDeviceIDSeparator = "/vgpu"
+
+	// Fully qualified CDI device names, e.g. nvidia.com/gpu=GPU-8a2b...
+	cdiDeviceNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*/[a-zA-Z0-9][a-zA-Z0-9_.-]*=(.+)$`)
+	// CDI devices of GPUs and MIG devices named by their index, e.g. nvidia.com/gpu=0 or nvidia.com/gpu=0:1
+	cdiGPUIndexRegex = regexp.MustCompile(`^[0-9]+$`)
+	cdiMIGIndexRegex = regexp.MustCompile(`^([0-9]+):([0-9]+)$`)
+
+	// now is replaced in tests.
+	now = time.Now
 )
 
 func NewPodMapper(c *appconfig.Config) *PodMapper {
 	slog.Info("Kubernetes metrics collection enabled!")
 
+	collectInterval := time.Duration(c.CollectInterval) * time.Millisecond
+	if collectInterval > 0 && c.PodResourcesCacheTTL > collectInterval {
+		slog.Warn(fmt.Sprintf("Pod resources cache TTL %s is longer than the collect interval %s; "+
+			"the metrics of a GPU may be attributed to a deleted pod for several collections",
+			c.PodResourcesCacheTTL, collectInterval))
+	}
+
+	kubeletCircuitState.Set(float64(circuitClosed))
+
 	return &PodMapper{
 		Config: c,
+		breaker: newCircuitBreaker(kubeletFailureThreshold, kubeletCoolDown, func(state circuitState) {
+			slog.Info(fmt.Sprintf("Kubelet PodResources circuit breaker is %s", state))
+			kubeletCircuitState.Set(float64(state))
+		}),
+		stop: make(chan struct{}),
 	}
 }
 
@@ -59,58 +97,312 @@ func (p *PodMapper) Name() string {
 }
 
 func (p *PodMapper) Process(metrics collector.MetricsByCounter, deviceInfo deviceinfo.Provider) error {
-	socketPath := p.Config.PodResourcesKubeletSocket
-	_, err := os.Stat(socketPath)
-	if os.IsNotExist(err) {
-		slog.Info("No Kubelet socket, ignoring")
-		return nil
-	}
-
-	// TODO: This needs to be moved out of the critical path.
-	c, cleanup, err := connectToServer(socketPath)
+	pods, err := p.attributablePods()
 	if err != nil {
 		return err
 	}
-	defer cleanup()
 
-	pods, err := p.listPods(c)
-	if err != nil {
-		return err
+	if pods == nil && !p.Config.PodAttributionCgroups {
+		return nil
 	}
 
 	slog.Debug(fmt.Sprintf("Podresources API response: %+v", pods))
 
-	deviceToPod := p.toDeviceToPod(pods, deviceInfo)
+	gpuUUIDs := sync.OnceValue(func() map[string]string {
+		return gpuUUIDsByIndex(deviceInfo)
+	})
+
+	deviceToPod := p.cachedDeviceToPod(pods, deviceInfo, gpuUUIDs)
 
 	slog.Debug(fmt.Sprintf("Device to pod mapping: %+v", deviceToPod))
 
+	// GPUs, which no pod is allocated by the pod resources, are attributed by the cgroups of their processes
+	var cgroupPods map[string]PodInfo
+	if p.Config.PodAttributionCgroups {
+		cgroupPods = p.cgroupPods(deviceInfo)
+		slog.Debug(fmt.Sprintf("GPU to pod mapping by cgroups: %+v", cgroupPods))
+	}
+
+	podOf := func(m collector.Metric) (PodInfo, bool, error) {
+		key, err := p.metricDeviceKey(m, gpuUUIDs)
+		if err != nil {
+			return PodInfo{}, false, err
+		}
+		if podInfo, exists := deviceToPod[key]; exists {
+			return podInfo, true, nil
+		}
+		if m.GPUInstanceID == "" {
+			podInfo, exists := cgroupPods[m.GPUUUID]
+			return podInfo, exists, nil
+		}
+		return PodInfo{}, false, nil
+	}
+
+	attributed := map[string]int{}
+
 	// Note: for loop are copies the value, if we want to change the value
 	// and not the copy, we need to use the indexes
 	for counter := range metrics {
 		for j, val := range metrics[counter] {
-			deviceID, err := val.GetIDOfType(p.Config.KubernetesGPUIdType)
+			podInfo, exists, err := podOf(val)
 			if err != nil {
 				return err
 			}
 
-			podInfo, exists := deviceToPod[deviceID]
 			if exists {
-				if !p.Config.UseOldNamespace {
-					metrics[counter][j].Attributes[podAttribute] = podInfo.Name
-					metrics[counter][j].Attributes[namespaceAttribute] = podInfo.Namespace
-					metrics[counter][j].Attributes[containerAttribute] = podInfo.Container
-				} else {
-					metrics[counter][j].Attributes[oldPodAttribute] = podInfo.Name
-					metrics[counter][j].Attributes[oldNamespaceAttribute] = podInfo.Namespace
-					metrics[counter][j].Attributes[oldContainerAttribute] = podInfo.Container
-				}
+				p.setPodAttributes(metrics[counter][j].Attributes, podInfo)
+				attributed[podInfo.Source]++
 			}
 		}
 	}
 
+	allocatedPod := func(m collector.Metric) (PodInfo, bool) {
+		podInfo, exists, _ := podOf(m)
+		return podInfo, exists && podInfo.Name != ""
+	}
+
+	if p.Config.AllocationEfficiency {
+		addAllocationEfficiency(metrics, func(m collector.Metric) bool {
+			_, exists := allocatedPod(m)
+			return exists
+		})
+	}
+
+	if p.Config.PodAggregates {
+		if !p.Config.UseOldNamespace {
+			addPodAggregates(metrics, allocatedPod, podAttribute, namespaceAttribute)
+		} else {
+			addPodAggregates(metrics, allocatedPod, oldPodAttribute, oldNamespaceAttribute)
+		}
+	}
+
+	podAttributionSeries.Reset()
+	for source, count := range attributed {
+		podAttributionSeries.Set(float64(count), "source", source)
+	}
+
 	return nil
 }
 
+// attributablePods returns the pod resources, which the devices are attributed by, or nil, when there are none, e.g.
+// when there is no kubelet socket, or the kubelet circuit breaker is open. When the devices are attributed by cgroups
+// as well, the pod resources are optional, so their errors are logged only.
+func (p *PodMapper) attributablePods() (*podresourcesapi.ListPodResourcesResponse, error) {
+	if p.Config.KubeletAPIURL == "" {
+		_, err := os.Stat(p.Config.PodResourcesKubeletSocket)
+		if os.IsNotExist(err) {
+			if !p.Config.PodAttributionCgroups {
+				slog.Info("No Kubelet socket, ignoring")
+			}
+			return nil, nil
+		}
+	}
+
+	pods, err := p.podResources()
+	if err != nil {
+		if !p.Config.PodAttributionCgroups {
+			return nil, err
+		}
+		slog.Warn("Failed to get the pod resources; attributing the GPUs by cgroups only",
+			slog.String(logging.ErrorKey, err.Error()))
+		return nil, nil
+	}
+
+	if pods == nil {
+		slog.Debug("Kubelet PodResources circuit breaker is open; skipping pod attribution")
+	}
+	return pods, nil
+}
+
+// setPodAttributes attaches the pod, the namespace and the container of the pod info to the attributes of a series,
+// and the resource claim and its device, when the device is allocated through DRA. Containers outside pods only
+// have a container.
+func (p *PodMapper) setPodAttributes(attributes map[string]string, podInfo PodInfo) {
+	pod, namespace, container := podAttribute, namespaceAttribute, containerAttribute
+	if p.Config.UseOldNamespace {
+		pod, namespace, container = oldPodAttribute, oldNamespaceAttribute, oldContainerAttribute
+	}
+
+	if podInfo.Name != "" {
+		attributes[pod] = podInfo.Name
+		attributes[namespace] = podInfo.Namespace
+	}
+	attributes[container] = podInfo.Container
+	if podInfo.Claim != "" {
+		attributes[claimAttribute] = podInfo.Claim
+		attributes[claimNamespaceAttribute] = podInfo.Namespace
+		attributes[draPoolAttribute] = podInfo.Pool
+		attributes[draDeviceAttribute] = podInfo.Device
+	}
+	if p.Config.PodAttributionSource {
+		attributes[attributionSourceAttribute] = podInfo.Source
+	}
+}
+
+// IsAttributed reports whether the pod mapper attributed the metric to a pod.
+func IsAttributed(m collector.Metric) bool {
+	return m.Attributes[podAttribute] != "" || m.Attributes[oldPodAttribute] != ""
+}
+
+// IsPodAttribute reports whether the attribute is attached by the pod or the HPC job mapper.
+func IsPodAttribute(name string) bool {
+	switch name {
+	case podAttribute, namespaceAttribute, containerAttribute,
+		oldPodAttribute, oldNamespaceAttribute, oldContainerAttribute,
+		attributionSourceAttribute, hpcJobAttribute,
+		claimAttribute, claimNamespaceAttribute, draPoolAttribute, draDeviceAttribute:
+		return true
+	}
+	return false
+}
+
+// Stop stops the pod resources prefetch, the watch of the DRA ResourceSlices and the watch of the pods.
+func (p *PodMapper) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	if p.draDevices != nil {
+		p.draDevices.Stop()
+	}
+	if p.podDeletions != nil {
+		p.podDeletions.Stop()
+	}
+}
+
+// podResources returns the pod resources used for attribution. When the prefetch or the cache TTL is enabled, kubelet
+// is queried on the scrape path only until a response is cached, or once the cached response is older than the TTL,
+// e.g. because the prefetch keeps failing. A nil response means that kubelet is considered unhealthy and attribution
+// should be skipped.
+func (p *PodMapper) podResources() (*podresourcesapi.ListPodResourcesResponse, error) {
+	if p.Config.PodResourcesRefresh > 0 {
+		p.startOnce.Do(func() {
+			go p.prefetch(p.refreshInterval())
+		})
+	}
+
+	if p.Config.PodResourcesRefresh > 0 || p.Config.PodResourcesCacheTTL > 0 {
+		p.podsMtx.RLock()
+		pods, fetched := p.pods, p.podsFetched
+		p.podsMtx.RUnlock()
+
+		ttl := p.Config.PodResourcesCacheTTL
+		if pods != nil && (ttl <= 0 || now().Sub(fetched) < ttl) {
+			return pods, nil
+		}
+	}
+
+	return p.fetchPodResources()
+}
+
+// refreshInterval returns the prefetch interval. The mapping is refreshed at least twice per collect interval,
+// so labels of a deleted pod are not attached to the metrics of the next pod on the same GPU.
+func (p *PodMapper) refreshInterval() time.Duration {
+	interval := p.Config.PodResourcesRefresh
+
+	maxInterval := time.Duration(p.Config.CollectInterval) * time.Millisecond / 2
+	if maxInterval > 0 && interval > maxInterval {
+		slog.Info(fmt.Sprintf("Pod resources refresh interval %s is reduced to %s, half of the collect interval",
+			interval, maxInterval))
+		interval = maxInterval
+	}
+
+	return interval
+}
+
+func (p *PodMapper) prefetch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if _, err := p.fetchPodResources(); err != nil {
+				slog.Warn("Failed to prefetch pod resources", slog.String(logging.ErrorKey, err.Error()))
+			}
+		}
+	}
+}
+
+func (p *PodMapper) fetchPodResources() (*podresourcesapi.ListPodResourcesResponse, error) {
+	if !p.breaker.Allow() {
+		return nil, nil
+	}
+
+	pods, err := p.queryKubelet()
+	if err != nil {
+		kubeletErrors.Inc()
+		if p.breaker.Failure() {
+			p.setPods(nil)
+		}
+		return nil, err
+	}
+
+	p.breaker.Success()
+	p.setPods(pods)
+
+	return pods, nil
+}
+
+func (p *PodMapper) queryKubelet() (*podresourcesapi.ListPodResourcesResponse, error) {
+	if p.Config.KubeletAPIURL != "" {
+		return p.queryKubeletAPI()
+	}
+
+	c, cleanup, err := connectToServer(p.Config.PodResourcesKubeletSocket)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return p.listPods(c)
+}
+
+func (p *PodMapper) setPods(pods *podresourcesapi.ListPodResourcesResponse) {
+	p.podsMtx.Lock()
+	defer p.podsMtx.Unlock()
+
+	p.pods = pods
+	p.podsFetched = now()
+}
+
+// cachedDeviceToPod returns the device to pod mapping of the pod resources. The mapping is only rebuilt, when the
+// pod resources, the device info, the DRA ResourceSlices or the deleted pods change, so that scrapes served from the
+// cached pod resources don't resolve the devices, e.g. the MIG devices through NVML, again.
+func (p *PodMapper) cachedDeviceToPod(
+	pods *podresourcesapi.ListPodResourcesResponse, deviceInfo deviceinfo.Provider, gpuUUIDs func() map[string]string,
+) map[deviceKey]PodInfo {
+	p.mappingMtx.Lock()
+	defer p.mappingMtx.Unlock()
+
+	var draRevision uint64
+	if p.draDevices != nil {
+		draRevision = p.draDevices.Revision()
+	}
+
+	var podRevision uint64
+	if p.podDeletions != nil {
+		podRevision = p.podDeletions.Revision()
+	}
+
+	if p.mapping.pods != pods || p.mapping.deviceInfo != deviceInfo || p.mapping.draRevision != draRevision ||
+		p.mapping.podRevision != podRevision {
+		if p.podDeletions != nil {
+			p.podDeletions.Prune(pods)
+		}
+		p.mapping = deviceToPodMapping{
+			pods:        pods,
+			deviceInfo:  deviceInfo,
+			draRevision: draRevision,
+			podRevision: podRevision,
+			deviceToPod: p.toDeviceToPod(pods, gpuUUIDs),
+		}
+	}
+
+	return p.mapping.deviceToPod
+}
+
 func connectToServer(socket string) (*grpc.ClientConn, func(), error) {
 	resolver.SetDefaultScheme("passthrough")
 	conn, err := grpc.NewClient(
@@ -131,7 +423,12 @@ func connectToServer(socket string) (*grpc.ClientConn, func(), error) {
 func (p *PodMapper) listPods(conn *grpc.ClientConn) (*podresourcesapi.ListPodResourcesResponse, error) {
 	client := podresourcesapi.NewPodResourcesListerClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), connectionTimeout)
+	timeout := connectionTimeout
+	if p.Config.PodResourcesTimeout > 0 {
+		timeout = p.Config.PodResourcesTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	resp, err := client.List(ctx, &podresourcesapi.ListPodResourcesRequest{})
@@ -143,59 +440,55 @@ func (p *PodMapper) listPods(conn *grpc.ClientConn) (*podresourcesapi.ListPodRes
 }
 
 func (p *PodMapper) toDeviceToPod(
-	devicePods *podresourcesapi.ListPodResourcesResponse, deviceInfo deviceinfo.Provider,
-) map[string]PodInfo {
-	deviceToPodMap := make(map[string]PodInfo)
+	devicePods *podresourcesapi.ListPodResourcesResponse, gpuUUIDs func() map[string]string,
+) map[deviceKey]PodInfo {
+	deviceToPodMap := make(map[deviceKey]PodInfo)
 
 	for _, pod := range devicePods.GetPodResources() {
+		// The devices of a deleted pod are free for the next pod, even though the cached pod resources still list it
+		if p.podDeletions != nil && p.podDeletions.Deleted(pod.GetNamespace(), pod.GetName()) {
+			continue
+		}
 		for _, container := range pod.GetContainers() {
 			for _, device := range container.GetDevices() {
 
 				resourceName := device.GetResourceName()
-				if resourceName != appconfig.NvidiaResourceName && !slices.Contains(p.Config.NvidiaResourceNames, resourceName) {
+				source := attributionSourceDevicePlugin
+				if strings.HasPrefix(resourceName, draClaimResourcePrefix) {
+					source = attributionSourceDRA
+				} else if strings.HasPrefix(resourceName, cdiAnnotationPrefix) {
+					source = attributionSourceCDI
+				} else if resourceName != appconfig.NvidiaResourceName && !slices.Contains(p.Config.NvidiaResourceNames, resourceName) {
 					// Mig resources appear differently than GPU resources
 					if !strings.HasPrefix(resourceName, appconfig.NvidiaMigResourcePrefix) {
 						continue
 					}
 				}
 
-				podInfo := PodInfo{
-					Name:      pod.GetName(),
-					Namespace: pod.GetNamespace(),
-					Container: container.GetName(),
-				}
-
 				for _, deviceID := range device.GetDeviceIds() {
-					if strings.HasPrefix(deviceID, appconfig.MIG_UUID_PREFIX) {
-						migDevice, err := nvmlprovider.Client().GetMIGDeviceInfoByID(deviceID)
-						if err == nil {
-							giIdentifier := deviceinfo.GetGPUInstanceIdentifier(deviceInfo, migDevice.ParentUUID,
-								uint(migDevice.GPUInstanceID))
-							deviceToPodMap[giIdentifier] = podInfo
-						}
-						gpuUUID := deviceID[len(appconfig.MIG_UUID_PREFIX):]
-						deviceToPodMap[gpuUUID] = podInfo
-					} else if gkeMigDeviceIDMatches := gkeMigDeviceIDRegex.FindStringSubmatch(deviceID); gkeMigDeviceIDMatches != nil {
-						var gpuIndex string
-						var gpuInstanceID string
-						for groupIdx, group := range gkeMigDeviceIDMatches {
-							switch groupIdx {
-							case 1:
-								gpuIndex = group
-							case 2:
-								gpuInstanceID = group
-							}
+					podInfo := PodInfo{
+						Name:      pod.GetName(),
+						Namespace: pod.GetNamespace(),
+						Container: container.GetName(),
+						Source:    source,
+					}
+
+					deviceIDs := []string{deviceID}
+					if allocation, isDRA := parseDRAAllocation(resourceName, deviceID); isDRA {
+						podInfo.Claim = allocation.claim
+						podInfo.Pool = allocation.pool
+						podInfo.Device = allocation.device
+						deviceIDs = []string{p.draDeviceID(deviceID, allocation)}
+					} else if cdiDeviceName := cdiDeviceNameRegex.FindStringSubmatch(deviceID); cdiDeviceName != nil {
+						deviceIDs = cdiDeviceIDs(cdiDeviceName[1], gpuUUIDs)
+						podInfo.Source = attributionSourceCDI
+					}
+
+					for _, id := range deviceIDs {
+						for _, key := range p.deviceKeys(id, gpuUUIDs) {
+							deviceToPodMap[key] = podInfo
 						}
-						giIdentifier := fmt.Sprintf("%s-%s", gpuIndex, gpuInstanceID)
-						deviceToPodMap[giIdentifier] = podInfo
-					} else if strings.Contains(deviceID, gkeVirtualGPUDeviceIDSeparator) {
-						deviceToPodMap[strings.Split(deviceID, gkeVirtualGPUDeviceIDSeparator)[0]] = podInfo
-					} else if strings.Contains(deviceID, "::") {
-						gpuInstanceID := strings.Split(deviceID, "::")[0]
-						deviceToPodMap[gpuInstanceID] = podInfo
 					}
-					// Default mapping between deviceID and pod information
-					deviceToPodMap[deviceID] = podInfo
 				}
 			}
 		}
@@ -203,3 +496,121 @@ func (p *PodMapper) toDeviceToPod(
 
 	return deviceToPodMap
 }
+
+// draDeviceID returns the ID of a DRA device, which is identified as <driver>/<pool>/<device>: the UUID of its GPU or
+// MIG device, when the ResourceSlices are watched and publish it, and the device name otherwise.
+func (p *PodMapper) draDeviceID(deviceID string, allocation draAllocation) string {
+	if p.draDevices != nil {
+		if uuid, found := p.draDevices.Resolve(deviceID); found {
+			return uuid
+		}
+	}
+
+	return allocation.device
+}
+
+// cdiDeviceIDs returns the device IDs of the device of a CDI device name. The CDI specs of the NVIDIA Container
+// Toolkit name the devices by their UUID, by the index of the GPU, by <GPU index>:<MIG device index>, or "all".
+// GPUs named by their index are identified by both their UUID and their device name, and MIG devices by their UUID.
+func cdiDeviceIDs(device string, gpuUUIDs func() map[string]string) []string {
+	switch {
+	case device == cdiAllDevices:
+		var deviceIDs []string
+		for index, gpuUUID := range gpuUUIDs() {
+			deviceIDs = append(deviceIDs, gpuUUID, "nvidia"+index)
+		}
+		return deviceIDs
+	case cdiGPUIndexRegex.MatchString(device):
+		if gpuUUID, exists := gpuUUIDs()[device]; exists {
+			return []string{gpuUUID, "nvidia" + device}
+		}
+		return []string{"nvidia" + device}
+	case cdiMIGIndexRegex.MatchString(device):
+		matches := cdiMIGIndexRegex.FindStringSubmatch(device)
+		gpuUUID, exists := gpuUUIDs()[matches[1]]
+		if !exists {
+			return nil
+		}
+
+		migDevices, err := nvmlprovider.Client().GetMIGDevices(gpuUUID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Failed to resolve the CDI device '%s'", device),
+				slog.String(logging.ErrorKey, err.Error()))
+			return nil
+		}
+		for _, migDevice := range migDevices.Devices {
+			if strconv.Itoa(migDevice.Index) == matches[2] {
+				return []string{migDevice.UUID}
+			}
+		}
+		return nil
+	}
+
+	return []string{device}
+}
+
+// deviceKeys returns the keys of the GPU or the GPU instance, which is identified by the device ID reported by
+// kubelet. MIG devices are identified by their MIG UUID, or by <device name>/gi<GPU instance ID> on GKE, and both
+// are resolved to their GPU instance. Unless the attribution is bound to GPU instances, the device ID itself, and
+// the MIG UUID without its prefix, are also used as keys of whole GPUs, as in earlier versions.
+func (p *PodMapper) deviceKeys(deviceID string, gpuUUIDs func() map[string]string) []deviceKey {
+	var keys []deviceKey
+
+	switch {
+	case strings.HasPrefix(deviceID, appconfig.MIG_UUID_PREFIX):
+		migDevice, err := nvmlprovider.Client().GetMIGDeviceInfoByID(deviceID)
+		if err == nil {
+			keys = append(keys, gpuInstanceKey(migDevice.ParentUUID, strconv.Itoa(migDevice.GPUInstanceID)))
+		}
+		if p.Config.PodAttributionGPUInstances {
+			return keys
+		}
+		keys = append(keys, gpuKey(deviceID[len(appconfig.MIG_UUID_PREFIX):]))
+	case gkeMigDeviceIDRegex.MatchString(deviceID):
+		matches := gkeMigDeviceIDRegex.FindStringSubmatch(deviceID)
+		if gpuUUID, exists := gpuUUIDs()[matches[1]]; exists {
+			keys = append(keys, gpuInstanceKey(gpuUUID, matches[2]))
+		}
+		if p.Config.PodAttributionGPUInstances {
+			return keys
+		}
+	case strings.Contains(deviceID, gkeVirtualGPUDeviceIDSeparator):
+		keys = append(keys, gpuKey(strings.Split(deviceID, gkeVirtualGPUDeviceIDSeparator)[0]))
+	case strings.Contains(deviceID, "::"):
+		keys = append(keys, gpuKey(strings.Split(deviceID, "::")[0]))
+	}
+
+	// Default mapping between deviceID and pod information
+	return append(keys, gpuKey(deviceID))
+}
+
+// metricDeviceKey returns the key of the GPU or the GPU instance, which the metric belongs to. Unless the
+// attribution is bound to GPU instances, metrics of GPU instances, which have no MIG profile yet, belong to their GPU,
+// as in earlier versions.
+func (p *PodMapper) metricDeviceKey(m collector.Metric, gpuUUIDs func() map[string]string) (deviceKey, error) {
+	gpuInstance := m.MigProfile != ""
+	if p.Config.PodAttributionGPUInstances {
+		gpuInstance = m.GPUInstanceID != ""
+	}
+	if gpuInstance {
+		return gpuInstanceKey(gpuUUIDs()[m.GPU], m.GPUInstanceID), nil
+	}
+
+	switch p.Config.KubernetesGPUIdType {
+	case appconfig.GPUUID:
+		return gpuKey(m.GPUUUID), nil
+	case appconfig.DeviceName:
+		return gpuKey(m.GPUDevice), nil
+	}
+	return deviceKey{}, fmt.Errorf("unsupported KubernetesGPUIDType for MetricID '%s'", p.Config.KubernetesGPUIdType)
+}
+
+// gpuUUIDsByIndex maps the GPU indexes to the UUIDs of the GPUs.
+func gpuUUIDsByIndex(deviceInfo deviceinfo.Provider) map[string]string {
+	uuids := map[string]string{}
+	for i := uint(0); i < deviceInfo.GPUCount(); i++ {
+		gpu := deviceInfo.GPU(i).DeviceInfo
+		uuids[strconv.FormatUint(uint64(gpu.GPU), 10)] = gpu.UUID
+	}
+	return uuids
+}
@@ -17,9 +17,12 @@
 package transformation
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -229,3 +232,301 @@ func TestProcessPodMapper_WithD_Different_Format_Of_DeviceID(t *testing.T) {
 			})
 	}
 }
+
+func newPodMapperTestMetrics(gpuUUID string) collector.MetricsByCounter {
+	counter := counters.Counter{
+		FieldID:   155,
+		FieldName: "DCGM_FI_DEV_POWER_USAGE",
+		PromType:  "gauge",
+	}
+
+	return collector.MetricsByCounter{
+		counter: {
+			{
+				GPU:        "0",
+				GPUUUID:    gpuUUID,
+				Value:      "42",
+				Counter:    counter,
+				Attributes: map[string]string{},
+			},
+		},
+	}
+}
+
+func TestProcessPodMapper_CircuitBreakerSkipsAttribution(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	tmpDir, cleanup := testutils.CreateTmpDir(t)
+	defer cleanup()
+
+	// The socket path exists, but nobody listens on it
+	socketFile, err := os.CreateTemp(tmpDir, "kubelet.sock")
+	require.NoError(t, err)
+	require.NoError(t, socketFile.Close())
+	socketPath := socketFile.Name()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: socketPath,
+		PodResourcesTimeout:       time.Second,
+	})
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	for i := 0; i < kubeletFailureThreshold; i++ {
+		err := podMapper.Process(newPodMapperTestMetrics("b8ea3855-276c-c9cb-b366-c6fa655957c5"), mockSystemInfo)
+		require.Error(t, err)
+	}
+	assert.Equal(t, circuitOpen, podMapper.breaker.State())
+
+	metrics := newPodMapperTestMetrics("b8ea3855-276c-c9cb-b366-c6fa655957c5")
+	err = podMapper.Process(metrics, mockSystemInfo)
+	require.NoError(t, err, "attribution must be skipped while the circuit is open")
+	for _, values := range metrics {
+		assert.Empty(t, values[0].Attributes)
+	}
+}
+
+type countingPodResourcesServer struct {
+	*testutils.MockPodResourcesServer
+	calls atomic.Int32
+}
+
+func (s *countingPodResourcesServer) List(
+	ctx context.Context, req *podresourcesapi.ListPodResourcesRequest,
+) (*podresourcesapi.ListPodResourcesResponse, error) {
+	s.calls.Add(1)
+	return s.MockPodResourcesServer.List(ctx, req)
+}
+
+func TestProcessPodMapper_Prefetch(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	tmpDir, cleanup := testutils.CreateTmpDir(t)
+	defer cleanup()
+	socketPath := tmpDir + "/kubelet.sock"
+
+	gpuUUID := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	server := grpc.NewServer()
+	lister := &countingPodResourcesServer{
+		MockPodResourcesServer: testutils.NewMockPodResourcesServer(appconfig.NvidiaResourceName, []string{gpuUUID}),
+	}
+	podresourcesapi.RegisterPodResourcesListerServer(server, lister)
+	cleanup = testutils.StartMockServer(t, server, socketPath)
+	defer cleanup()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: socketPath,
+		PodResourcesRefresh:       time.Hour,
+	})
+	defer podMapper.Stop()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	for i := 0; i < 2; i++ {
+		metrics := newPodMapperTestMetrics(gpuUUID)
+		require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+		for _, values := range metrics {
+			assert.Equal(t, "gpu-pod-0", values[0].Attributes[podAttribute])
+		}
+	}
+
+	// Only the first scrape queries kubelet, the next one uses the prefetched mapping
+	assert.Equal(t, int32(1), lister.calls.Load())
+}
+
+func TestProcessPodMapper_CacheTTL(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	tmpDir, cleanup := testutils.CreateTmpDir(t)
+	defer cleanup()
+	socketPath := tmpDir + "/kubelet.sock"
+
+	gpuUUID := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	server := grpc.NewServer()
+	lister := &countingPodResourcesServer{
+		MockPodResourcesServer: testutils.NewMockPodResourcesServer(appconfig.NvidiaResourceName, []string{gpuUUID}),
+	}
+	podresourcesapi.RegisterPodResourcesListerServer(server, lister)
+	cleanup = testutils.StartMockServer(t, server, socketPath)
+	defer cleanup()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() {
+		now = time.Now
+	}()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: socketPath,
+		PodResourcesCacheTTL:      time.Minute,
+		// The TTL is honoured, although it is longer than the collect interval
+		CollectInterval: 30000,
+	})
+	defer podMapper.Stop()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	scrape := func() {
+		metrics := newPodMapperTestMetrics(gpuUUID)
+		require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+		for _, values := range metrics {
+			assert.Equal(t, "gpu-pod-0", values[0].Attributes[podAttribute])
+		}
+	}
+
+	scrape()
+	current = current.Add(30 * time.Second)
+	scrape()
+	// The second scrape uses the cached mapping
+	assert.Equal(t, int32(1), lister.calls.Load())
+
+	current = current.Add(time.Minute)
+	scrape()
+	// The cached mapping is expired, kubelet is queried again
+	assert.Equal(t, int32(2), lister.calls.Load())
+}
+
+// The PodResources v1alpha1 API of the kubelet socket doesn't report DRA allocations, so the series attributed through
+// the socket are never attributed to resource claims. TestProcessPodMapper_AttributionSource covers the kubelet API,
+// which does.
+func TestProcessPodMapper_PodResourcesSocketWithoutClaims(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	tmpDir, cleanup := testutils.CreateTmpDir(t)
+	defer cleanup()
+	socketPath := tmpDir + "/kubelet.sock"
+
+	gpuUUID := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	server := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(server,
+		testutils.NewMockPodResourcesServer(appconfig.NvidiaResourceName, []string{gpuUUID}))
+	cleanup = testutils.StartMockServer(t, server, socketPath)
+	defer cleanup()
+
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: socketPath,
+	})
+	defer podMapper.Stop()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	metrics := newPodMapperTestMetrics(gpuUUID)
+	require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+	for _, values := range metrics {
+		assert.Equal(t, "gpu-pod-0", values[0].Attributes[podAttribute])
+		for _, attribute := range []string{
+			claimAttribute, claimNamespaceAttribute, draPoolAttribute, draDeviceAttribute,
+		} {
+			assert.NotContains(t, values[0].Attributes, attribute)
+		}
+	}
+}
+
+// churningPodResourcesServer returns the pod currently assigned to a single shared GPU.
+type churningPodResourcesServer struct {
+	gpu string
+	pod atomic.Value
+}
+
+func (s *churningPodResourcesServer) List(
+	_ context.Context, _ *podresourcesapi.ListPodResourcesRequest,
+) (*podresourcesapi.ListPodResourcesResponse, error) {
+	pod := s.pod.Load().(string)
+	if pod == "" {
+		return &podresourcesapi.ListPodResourcesResponse{}, nil
+	}
+
+	return &podresourcesapi.ListPodResourcesResponse{
+		PodResources: []*podresourcesapi.PodResources{
+			{
+				Name:      pod,
+				Namespace: "default",
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Name: "default",
+						Devices: []*podresourcesapi.ContainerDevices{
+							{
+								ResourceName: appconfig.NvidiaResourceName,
+								DeviceIds:    []string{s.gpu},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func TestProcessPodMapper_PodChurnOnSharedGPU(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	gpuUUID := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	tests := []struct {
+		name    string
+		refresh time.Duration
+	}{
+		{
+			name: "kubelet queried on every scrape",
+		},
+		{
+			name:    "prefetched mapping",
+			refresh: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir, cleanup := testutils.CreateTmpDir(t)
+			defer cleanup()
+			socketPath := tmpDir + "/kubelet.sock"
+
+			lister := &churningPodResourcesServer{gpu: gpuUUID}
+			lister.pod.Store("pod-0")
+
+			server := grpc.NewServer()
+			podresourcesapi.RegisterPodResourcesListerServer(server, lister)
+			cleanup = testutils.StartMockServer(t, server, socketPath)
+			defer cleanup()
+
+			podMapper := NewPodMapper(&appconfig.Config{
+				KubernetesGPUIdType:       appconfig.GPUUID,
+				PodResourcesKubeletSocket: socketPath,
+				PodResourcesRefresh:       tt.refresh,
+				// The refresh interval is capped at half of the collect interval
+				CollectInterval: 20,
+			})
+			defer podMapper.Stop()
+
+			ctrl := gomock.NewController(t)
+			mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+			podOnGPU := func() string {
+				metrics := newPodMapperTestMetrics(gpuUUID)
+				require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+				for _, values := range metrics {
+					return values[0].Attributes[podAttribute]
+				}
+				return ""
+			}
+
+			for i, pod := range []string{"pod-0", "", "pod-1", "pod-2", ""} {
+				lister.pod.Store(pod)
+				assert.Eventually(t, func() bool {
+					return podOnGPU() == pod
+				}, time.Second, 5*time.Millisecond, "step %d: GPU must be attributed to %q", i, pod)
+			}
+		})
+	}
+}
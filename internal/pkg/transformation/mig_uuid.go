@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+// migUUIDMapper attaches the UUID of the MIG device (MIG-<UUID>) to the metrics of GPU instances and compute
+// instances. Unlike the GPU instance IDs, which are reused, when the instances are destroyed and created again, the
+// MIG device UUIDs identify the instances across MIG reconfigurations.
+type migUUIDMapper struct{}
+
+func newMIGUUIDMapper() *migUUIDMapper {
+	slog.Info("The MIG device UUIDs are attached to the metrics of the GPU instances")
+	return &migUUIDMapper{}
+}
+
+func (p *migUUIDMapper) Name() string {
+	return "migUUIDMapper"
+}
+
+func (p *migUUIDMapper) Process(metrics collector.MetricsByCounter, _ deviceinfo.Provider) error {
+	// The MIG devices are read once per GPU and scrape, so reconfigurations are picked up on the next scrape
+	gpuMIGDevices := map[string]*nvmlprovider.MIGDevices{}
+
+	for counter := range metrics {
+		for j, metric := range metrics[counter] {
+			if metric.GPUInstanceID == "" {
+				continue
+			}
+
+			migDevices, exists := gpuMIGDevices[metric.GPUUUID]
+			if !exists {
+				var err error
+				migDevices, err = nvmlprovider.Client().GetMIGDevices(metric.GPUUUID)
+				if err != nil {
+					slog.Debug(fmt.Sprintf("Unable to read the MIG devices of the GPU %s", metric.GPUUUID),
+						slog.String(logging.ErrorKey, err.Error()))
+				}
+				gpuMIGDevices[metric.GPUUUID] = migDevices
+			}
+
+			uuid, found := migDeviceUUID(migDevices, metric.GPUInstanceID, metric.ComputeInstanceID)
+			if !found {
+				continue
+			}
+
+			if metrics[counter][j].Attributes == nil {
+				metrics[counter][j].Attributes = map[string]string{}
+			}
+			metrics[counter][j].Attributes[migUUIDAttribute] = uuid
+		}
+	}
+
+	return nil
+}
+
+// migDeviceUUID returns the UUID of the MIG device of the GPU instance and, when it is set, the compute instance. The
+// metrics of a GPU instance are only attributed, when it has exactly one compute instance, since the MIG device is
+// ambiguous otherwise.
+func migDeviceUUID(migDevices *nvmlprovider.MIGDevices, gpuInstanceID, computeInstanceID string) (string, bool) {
+	if migDevices == nil {
+		return "", false
+	}
+
+	gi, err := strconv.Atoi(gpuInstanceID)
+	if err != nil {
+		return "", false
+	}
+	ci := -1
+	if computeInstanceID != "" {
+		if ci, err = strconv.Atoi(computeInstanceID); err != nil {
+			return "", false
+		}
+	}
+
+	var uuid string
+	var matches int
+	for _, device := range migDevices.Devices {
+		if device.GPUInstanceID == gi && (ci < 0 || device.ComputeInstanceID == ci) {
+			uuid = device.UUID
+			matches++
+		}
+	}
+	return uuid, matches == 1
+}
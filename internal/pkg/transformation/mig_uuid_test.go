@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocknvmlprovider "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+)
+
+func TestMIGUUIDMapper_Process(t *testing.T) {
+	gpu0 := "GPU-00000000-0000-0000-0000-000000000000"
+	gpu1 := "GPU-11111111-1111-1111-1111-111111111111"
+	gpu2 := "GPU-22222222-2222-2222-2222-222222222222"
+	mig1 := "MIG-5b1c3f0e-8d6a-5e3b-9c2f-7a4d1e6b0c33"
+	mig2 := "MIG-6c2d4a1f-9e7b-4f1c-8d3a-8b5e2f7c1d44"
+	mig3 := "MIG-7d3e5b2a-0f8c-4a2d-9e4b-9c6f3a8d2e55"
+
+	ctrl := gomock.NewController(t)
+	mockNVMLProvider := mocknvmlprovider.NewMockNVML(ctrl)
+	// The MIG devices are read once per GPU
+	mockNVMLProvider.EXPECT().GetMIGDevices(gpu1).Return(&nvmlprovider.MIGDevices{
+		Devices: []nvmlprovider.MIGDevice{
+			{Index: 0, UUID: mig1, GPUInstanceID: 1, ComputeInstanceID: 0},
+			{Index: 1, UUID: mig2, GPUInstanceID: 2, ComputeInstanceID: 0},
+			{Index: 2, UUID: mig3, GPUInstanceID: 2, ComputeInstanceID: 1},
+		},
+	}, nil).Times(1)
+	mockNVMLProvider.EXPECT().GetMIGDevices(gpu2).Return(nil, errors.New("not supported")).Times(1)
+	realNVML := nvmlprovider.Client()
+	defer nvmlprovider.SetClient(realNVML)
+	nvmlprovider.SetClient(mockNVMLProvider)
+
+	counter := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_UTIL"}
+	metrics := collector.MetricsByCounter{
+		counter: {
+			{GPU: "0", GPUUUID: gpu0},
+			{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "1"},
+			{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "2"},
+			{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "2", ComputeInstanceID: "1"},
+			{GPU: "1", GPUUUID: gpu1, GPUInstanceID: "3"},
+			{GPU: "2", GPUUUID: gpu2, GPUInstanceID: "1"},
+		},
+	}
+
+	require.NoError(t, newMIGUUIDMapper().Process(metrics, nil))
+
+	var got []string
+	for _, metric := range metrics[counter] {
+		got = append(got, metric.Attributes[migUUIDAttribute])
+	}
+	// A GPU instance with several compute instances is ambiguous, and unknown instances are not attributed
+	assert.Equal(t, []string{"", mig1, "", mig3, "", ""}, got)
+}
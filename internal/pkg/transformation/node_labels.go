@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// nodeLabelsMapper attaches the selected labels of the Kubernetes node, which the exporter runs on, to every metric.
+// The node is read once and kept up to date with a watch, so the labels don't cost an API request per scrape.
+type nodeLabelsMapper struct {
+	nodeName string
+	// Attribute names of the selected node labels, by node label
+	attributes map[string]string
+
+	client    kubernetes.Interface
+	lister    listersv1.NodeLister
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+func newNodeLabelsMapper(client kubernetes.Interface, nodeName string, labels []string) *nodeLabelsMapper {
+	slog.Info(fmt.Sprintf("Kubernetes node labels %v of the node %q are attached to the metrics", labels, nodeName))
+
+	attributes := make(map[string]string, len(labels))
+	for _, label := range labels {
+		attributes[label] = nodeLabelAttribute(label)
+	}
+
+	return &nodeLabelsMapper{
+		nodeName:   nodeName,
+		attributes: attributes,
+		client:     client,
+		stop:       make(chan struct{}),
+	}
+}
+
+func (p *nodeLabelsMapper) Name() string {
+	return "nodeLabelsMapper"
+}
+
+func (p *nodeLabelsMapper) Process(metrics collector.MetricsByCounter, _ deviceinfo.Provider) error {
+	p.startOnce.Do(p.start)
+
+	node, err := p.lister.Get(p.nodeName)
+	if err != nil {
+		slog.Debug("The node isn't read yet; skipping the node labels",
+			slog.String(logging.ErrorKey, err.Error()))
+		return nil
+	}
+
+	attributes := map[string]string{}
+	for label, attribute := range p.attributes {
+		if value, exists := node.Labels[label]; exists {
+			attributes[attribute] = value
+		}
+	}
+
+	for counter := range metrics {
+		for j := range metrics[counter] {
+			if metrics[counter][j].Attributes == nil {
+				metrics[counter][j].Attributes = map[string]string{}
+			}
+			for attribute, value := range attributes {
+				metrics[counter][j].Attributes[attribute] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+// start watches the node and waits for it to be read, for at most nodeLabelsSyncTimeout, so that the first metrics
+// carry the node labels as well.
+func (p *nodeLabelsMapper) start() {
+	factory := informers.NewSharedInformerFactoryWithOptions(p.client, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector(metav1.ObjectNameField, p.nodeName).String()
+		}))
+	nodes := factory.Core().V1().Nodes()
+	p.lister = nodes.Lister()
+	informer := nodes.Informer()
+
+	factory.Start(p.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), nodeLabelsSyncTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		slog.Warn(fmt.Sprintf("The node %q isn't read after %s; the node labels are attached once it is",
+			p.nodeName, nodeLabelsSyncTimeout))
+	}
+}
+
+func (p *nodeLabelsMapper) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// nodeLabelAttribute returns the name of the attribute of a node label, following kube-state-metrics: the label is
+// prefixed with label_ and the characters, which are not allowed in Prometheus label names, are replaced with
+// underscores, e.g. topology.kubernetes.io/zone becomes label_topology_kubernetes_io_zone.
+func nodeLabelAttribute(label string) string {
+	return nodeLabelAttributePrefix + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, label)
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestNodeLabelsMapper_Process(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "gpu-node-1",
+			Labels: map[string]string{
+				"topology.kubernetes.io/zone":      "us-east-1a",
+				"nvidia.com/gpu.product":           "NVIDIA-H100-80GB-HBM3",
+				"node.kubernetes.io/instance-type": "p5.48xlarge",
+			},
+		},
+	}
+	client := fake.NewSimpleClientset(node)
+
+	mapper := newNodeLabelsMapper(client, "gpu-node-1",
+		[]string{"topology.kubernetes.io/zone", "nvidia.com/gpu.product", "cloud.google.com/gke-nodepool"})
+	defer mapper.Stop()
+
+	counter := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_UTIL"}
+	newMetrics := func() collector.MetricsByCounter {
+		return collector.MetricsByCounter{
+			counter: {
+				{GPU: "0", Attributes: map[string]string{}},
+				{GPU: "1"},
+			},
+		}
+	}
+
+	metrics := newMetrics()
+	require.NoError(t, mapper.Process(metrics, nil))
+
+	for _, metric := range metrics[counter] {
+		assert.Equal(t, map[string]string{
+			"label_topology_kubernetes_io_zone": "us-east-1a",
+			"label_nvidia_com_gpu_product":      "NVIDIA-H100-80GB-HBM3",
+		}, metric.Attributes)
+	}
+
+	// The labels follow the updates of the node
+	node = node.DeepCopy()
+	node.Labels["topology.kubernetes.io/zone"] = "us-east-1b"
+	_, err := client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		metrics := newMetrics()
+		require.NoError(t, mapper.Process(metrics, nil))
+		return metrics[counter][0].Attributes["label_topology_kubernetes_io_zone"] == "us-east-1b"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNodeLabelsMapper_ProcessWithoutNode(t *testing.T) {
+	realSyncTimeout := nodeLabelsSyncTimeout
+	defer func() { nodeLabelsSyncTimeout = realSyncTimeout }()
+	nodeLabelsSyncTimeout = 100 * time.Millisecond
+
+	mapper := newNodeLabelsMapper(fake.NewSimpleClientset(), "gpu-node-1", []string{"topology.kubernetes.io/zone"})
+	defer mapper.Stop()
+
+	counter := counters.Counter{FieldName: "DCGM_FI_DEV_GPU_UTIL"}
+	metrics := collector.MetricsByCounter{counter: {{GPU: "0", Attributes: map[string]string{}}}}
+
+	require.NoError(t, mapper.Process(metrics, nil))
+	assert.Empty(t, metrics[counter][0].Attributes)
+}
+
+func TestNodeLabelAttribute(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{label: "nodepool", want: "label_nodepool"},
+		{label: "topology.kubernetes.io/zone", want: "label_topology_kubernetes_io_zone"},
+		{label: "nvidia.com/gpu.product", want: "label_nvidia_com_gpu_product"},
+		{label: "node-role.kubernetes.io/gpu_worker", want: "label_node_role_kubernetes_io_gpu_worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			assert.Equal(t, tt.want, nodeLabelAttribute(tt.label))
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+// aggregateLabelGroups omits the device labels from the aggregated series, which sum several devices.
+const aggregateLabelGroups = counters.LabelGroupPod + "|" + counters.LabelGroupHostname
+
+// podAggregate sums the source field over the GPUs and GPU instances, which are allocated to a pod, into the pod
+// counter, and over the pods of a namespace into the namespace counter.
+type podAggregate struct {
+	fieldID   dcgm.Short
+	pod       counters.Counter
+	namespace counters.Counter
+}
+
+var podAggregates = []podAggregate{
+	{
+		fieldID: dcgm.DCGM_FI_DEV_GPU_UTIL,
+		pod: counters.Counter{
+			FieldName:   "DCGM_EXP_POD_GPU_UTIL",
+			PromType:    "gauge",
+			Help:        "Sum of the utilization (in %) of the GPUs allocated to the pod.",
+			LabelGroups: aggregateLabelGroups,
+		},
+		namespace: counters.Counter{
+			FieldName:   "DCGM_EXP_NAMESPACE_GPU_UTIL",
+			PromType:    "gauge",
+			Help:        "Sum of the utilization (in %) of the GPUs allocated to the pods of the namespace.",
+			LabelGroups: aggregateLabelGroups,
+		},
+	},
+	{
+		fieldID: dcgm.DCGM_FI_DEV_FB_USED,
+		pod: counters.Counter{
+			FieldName:   "DCGM_EXP_POD_FB_USED",
+			PromType:    "gauge",
+			Help:        "Sum of the framebuffer memory used (in MiB) on the GPUs allocated to the pod.",
+			LabelGroups: aggregateLabelGroups,
+		},
+		namespace: counters.Counter{
+			FieldName:   "DCGM_EXP_NAMESPACE_FB_USED",
+			PromType:    "gauge",
+			Help:        "Sum of the framebuffer memory used (in MiB) on the GPUs allocated to the pods of the namespace.",
+			LabelGroups: aggregateLabelGroups,
+		},
+	},
+}
+
+// addPodAggregates adds the sums of the utilization and of the used memory of the GPUs and GPU instances, which are
+// allocated to a pod, per pod and per namespace. The series are labeled with the pod and the namespace only, so
+// chargeback dashboards don't need to join the series of every device. Unallocated GPUs are not counted.
+func addPodAggregates(metrics collector.MetricsByCounter, podOf func(collector.Metric) (PodInfo, bool),
+	podLabel, namespaceLabel string,
+) {
+	for _, aggregate := range podAggregates {
+		byPod := map[PodInfo]float64{}
+		byNamespace := map[string]float64{}
+		seen := map[string]struct{}{}
+		hostname := ""
+
+		for counter, values := range metrics {
+			if counter.FieldID != aggregate.fieldID {
+				continue
+			}
+
+			for _, metric := range values {
+				key := metric.GPU + "/" + metric.GPUInstanceID
+				// Pods are allocated GPUs and GPU instances, so compute instances are skipped
+				if _, exists := seen[key]; exists || metric.ComputeInstanceID != "" {
+					continue
+				}
+
+				podInfo, allocated := podOf(metric)
+				if !allocated {
+					continue
+				}
+
+				value, err := strconv.ParseFloat(metric.Value, 64)
+				if err != nil {
+					continue
+				}
+				seen[key] = struct{}{}
+				hostname = metric.Hostname
+
+				// Containers of a pod share the pod series
+				pod := PodInfo{Name: podInfo.Name, Namespace: podInfo.Namespace}
+				byPod[pod] += value
+				byNamespace[pod.Namespace] += value
+			}
+		}
+
+		if len(byPod) == 0 {
+			continue
+		}
+
+		podSeries := make([]collector.Metric, 0, len(byPod))
+		for pod, value := range byPod {
+			podSeries = append(podSeries, aggregateMetric(aggregate.pod, value, hostname, map[string]string{
+				podLabel:       pod.Name,
+				namespaceLabel: pod.Namespace,
+			}))
+		}
+		sort.Slice(podSeries, func(i, j int) bool {
+			return podSeries[i].Attributes[namespaceLabel]+"/"+podSeries[i].Attributes[podLabel] <
+				podSeries[j].Attributes[namespaceLabel]+"/"+podSeries[j].Attributes[podLabel]
+		})
+		metrics[aggregate.pod] = podSeries
+
+		namespaceSeries := make([]collector.Metric, 0, len(byNamespace))
+		for namespace, value := range byNamespace {
+			namespaceSeries = append(namespaceSeries, aggregateMetric(aggregate.namespace, value, hostname,
+				map[string]string{namespaceLabel: namespace}))
+		}
+		sort.Slice(namespaceSeries, func(i, j int) bool {
+			return namespaceSeries[i].Attributes[namespaceLabel] < namespaceSeries[j].Attributes[namespaceLabel]
+		})
+		metrics[aggregate.namespace] = namespaceSeries
+	}
+}
+
+func aggregateMetric(counter counters.Counter, value float64, hostname string,
+	attributes map[string]string,
+) collector.Metric {
+	return collector.Metric{
+		Counter:    counter,
+		Value:      strconv.FormatFloat(value, 'f', -1, 64),
+		Hostname:   hostname,
+		Labels:     map[string]string{},
+		Attributes: attributes,
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+func TestAddPodAggregates(t *testing.T) {
+	gpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+	fbUsed := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_FB_USED, FieldName: "DCGM_FI_DEV_FB_USED", PromType: "gauge"}
+
+	pods := map[string]PodInfo{
+		"0": {Name: "train", Namespace: "team-a", Container: "worker"},
+		"1": {Name: "train", Namespace: "team-a", Container: "sidecar"},
+		"2": {Name: "serve", Namespace: "team-a", Container: "server"},
+		"3": {Name: "notebook", Namespace: "team-b", Container: "jupyter"},
+	}
+	podOf := func(m collector.Metric) (PodInfo, bool) {
+		pod, exists := pods[m.GPU]
+		return pod, exists
+	}
+
+	metric := func(counter counters.Counter, gpu, value string) collector.Metric {
+		return collector.Metric{Counter: counter, GPU: gpu, Value: value, Hostname: "node"}
+	}
+
+	metrics := collector.MetricsByCounter{
+		gpuUtil: {
+			metric(gpuUtil, "0", "40"),
+			metric(gpuUtil, "1", "60"),
+			metric(gpuUtil, "2", "10"),
+			metric(gpuUtil, "3", "5"),
+			// Not allocated
+			metric(gpuUtil, "4", "90"),
+		},
+		fbUsed: {
+			metric(fbUsed, "0", "1024"),
+			metric(fbUsed, "3", "512"),
+		},
+	}
+
+	addPodAggregates(metrics, podOf, podAttribute, namespaceAttribute)
+
+	values := func(field string) map[string]string {
+		got := map[string]string{}
+		for counter, series := range metrics {
+			if counter.FieldName != field {
+				continue
+			}
+			assert.Equal(t, aggregateLabelGroups, counter.LabelGroups)
+			for _, m := range series {
+				assert.Empty(t, m.GPU)
+				assert.Equal(t, "node", m.Hostname)
+				assert.NotContains(t, m.Attributes, containerAttribute)
+				got[m.Attributes[namespaceAttribute]+"/"+m.Attributes[podAttribute]] = m.Value
+			}
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]string{"team-a/train": "100", "team-a/serve": "10", "team-b/notebook": "5"},
+		values("DCGM_EXP_POD_GPU_UTIL"))
+	assert.Equal(t, map[string]string{"team-a/": "110", "team-b/": "5"}, values("DCGM_EXP_NAMESPACE_GPU_UTIL"))
+	assert.Equal(t, map[string]string{"team-a/train": "1024", "team-b/notebook": "512"},
+		values("DCGM_EXP_POD_FB_USED"))
+	assert.Equal(t, map[string]string{"team-a/": "1024", "team-b/": "512"}, values("DCGM_EXP_NAMESPACE_FB_USED"))
+
+	// The source metrics are left untouched
+	require.Len(t, metrics[gpuUtil], 5)
+	assert.Empty(t, metrics[gpuUtil][0].Attributes)
+}
+
+func TestAddPodAggregates_NotAllocated(t *testing.T) {
+	gpuUtil := counters.Counter{FieldID: dcgm.DCGM_FI_DEV_GPU_UTIL, FieldName: "DCGM_FI_DEV_GPU_UTIL", PromType: "gauge"}
+	metrics := collector.MetricsByCounter{
+		gpuUtil: {{Counter: gpuUtil, GPU: "0", Value: "40"}},
+	}
+
+	addPodAggregates(metrics, func(collector.Metric) (PodInfo, bool) { return PodInfo{}, false },
+		oldPodAttribute, oldNamespaceAttribute)
+
+	assert.Len(t, metrics, 1)
+}
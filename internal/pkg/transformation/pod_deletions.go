@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1alpha1"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var podDeletionEvents = selfmetrics.Default().Counter("dcgm_exporter_pod_deletion_events_total",
+	"Number of deleted or terminated pods of the node, which are not attributed anymore.")
+
+// PodDeletionWatcher watches the pods of the node, so that the devices of a pod, which is deleted or terminated, are
+// not attributed to it anymore, even though the cached pod resources still list it. A deleted pod is forgotten once
+// the pod resources don't list it anymore, or a pod with the same name is scheduled to the node again.
+type PodDeletionWatcher struct {
+	client   kubernetes.Interface
+	nodeName string
+	informer cache.SharedIndexInformer
+	// revision changes with every deleted pod, so the device to pod mapping can be cached until then
+	revision atomic.Uint64
+
+	deleted    map[podRef]struct{}
+	deletedMtx sync.RWMutex
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+func NewPodDeletionWatcher(client kubernetes.Interface, nodeName string) *PodDeletionWatcher {
+	slog.Info(fmt.Sprintf("Pod deletions are watched on the node %s", nodeName))
+
+	return &PodDeletionWatcher{
+		client:   client,
+		nodeName: nodeName,
+		deleted:  make(map[podRef]struct{}),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Revision returns a value, which changes whenever a pod is deleted or scheduled again.
+func (w *PodDeletionWatcher) Revision() uint64 {
+	w.startOnce.Do(w.start)
+
+	return w.revision.Load()
+}
+
+// Deleted reports whether the pod was deleted or terminated.
+func (w *PodDeletionWatcher) Deleted(namespace, name string) bool {
+	w.deletedMtx.RLock()
+	defer w.deletedMtx.RUnlock()
+
+	_, deleted := w.deleted[podRef{namespace: namespace, name: name}]
+	return deleted
+}
+
+// Prune forgets the deleted pods, which the pod resources don't list anymore.
+func (w *PodDeletionWatcher) Prune(pods *podresourcesapi.ListPodResourcesResponse) {
+	listed := make(map[podRef]struct{}, len(pods.GetPodResources()))
+	for _, pod := range pods.GetPodResources() {
+		listed[podRef{namespace: pod.GetNamespace(), name: pod.GetName()}] = struct{}{}
+	}
+
+	w.deletedMtx.Lock()
+	defer w.deletedMtx.Unlock()
+
+	for ref := range w.deleted {
+		if _, exists := listed[ref]; !exists {
+			delete(w.deleted, ref)
+		}
+	}
+}
+
+// start watches the pods of the node and waits for them to be read, for at most podDeletionsSyncTimeout, so that the
+// pods deleted right after the first scrape are noticed as well.
+func (w *PodDeletionWatcher) start() {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.client, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", w.nodeName).String()
+		}))
+	w.informer = factory.Core().V1().Pods().Informer()
+
+	_, err := w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if pod, ok := obj.(*corev1.Pod); ok && !terminated(pod) {
+				w.scheduled(pod)
+			}
+		},
+		UpdateFunc: func(_, obj any) {
+			if pod, ok := obj.(*corev1.Pod); ok && terminated(pod) {
+				w.deletedPod(pod)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.deletedPod(pod)
+			}
+		},
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to watch the pods; err: %v", err))
+	}
+
+	factory.Start(w.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), podDeletionsSyncTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		slog.Warn(fmt.Sprintf("The pods aren't read after %s; pod deletions are watched once they are",
+			podDeletionsSyncTimeout))
+	}
+}
+
+// terminated reports whether all containers of the pod stopped, so its devices are free for the next pod.
+func terminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+func (w *PodDeletionWatcher) deletedPod(pod *corev1.Pod) {
+	ref := podRef{namespace: pod.Namespace, name: pod.Name}
+
+	w.deletedMtx.Lock()
+	_, exists := w.deleted[ref]
+	w.deleted[ref] = struct{}{}
+	w.deletedMtx.Unlock()
+
+	if !exists {
+		w.revision.Add(1)
+		podDeletionEvents.Inc()
+	}
+}
+
+// scheduled forgets a deleted pod, when a pod with the same name, e.g. of a StatefulSet, is scheduled again.
+func (w *PodDeletionWatcher) scheduled(pod *corev1.Pod) {
+	ref := podRef{namespace: pod.Namespace, name: pod.Name}
+
+	w.deletedMtx.Lock()
+	_, exists := w.deleted[ref]
+	delete(w.deleted, ref)
+	w.deletedMtx.Unlock()
+
+	if exists {
+		w.revision.Add(1)
+	}
+}
+
+func (w *PodDeletionWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformation
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1alpha1"
+
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/testutils"
+)
+
+// sharedGPUPodResourcesServer lists pods, which share a single GPU, e.g. with time-slicing.
+type sharedGPUPodResourcesServer struct {
+	gpu   string
+	pods  []string
+	calls atomic.Int32
+}
+
+func (s *sharedGPUPodResourcesServer) List(
+	_ context.Context, _ *podresourcesapi.ListPodResourcesRequest,
+) (*podresourcesapi.ListPodResourcesResponse, error) {
+	s.calls.Add(1)
+
+	response := &podresourcesapi.ListPodResourcesResponse{}
+	for _, pod := range s.pods {
+		response.PodResources = append(response.PodResources, &podresourcesapi.PodResources{
+			Name:      pod,
+			Namespace: "default",
+			Containers: []*podresourcesapi.ContainerResources{
+				{
+					Name: "default",
+					Devices: []*podresourcesapi.ContainerDevices{
+						{
+							ResourceName: appconfig.NvidiaResourceName,
+							DeviceIds:    []string{s.gpu},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return response, nil
+}
+
+func newPodDeletionTestPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestProcessPodMapper_PodDeletionsOnSharedGPU(t *testing.T) {
+	testutils.RequireLinux(t)
+
+	gpuUUID := "b8ea3855-276c-c9cb-b366-c6fa655957c5"
+
+	tmpDir, cleanup := testutils.CreateTmpDir(t)
+	defer cleanup()
+	socketPath := tmpDir + "/kubelet.sock"
+
+	// The later pod of the pod resources is attributed the shared GPU
+	lister := &sharedGPUPodResourcesServer{gpu: gpuUUID, pods: []string{"pod-0", "pod-1"}}
+
+	server := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(server, lister)
+	cleanup = testutils.StartMockServer(t, server, socketPath)
+	defer cleanup()
+
+	client := fake.NewSimpleClientset(newPodDeletionTestPod("pod-0"), newPodDeletionTestPod("pod-1"))
+
+	// The pod resources are cached for the whole test, so only the pod deletions change the attribution
+	podMapper := NewPodMapper(&appconfig.Config{
+		KubernetesGPUIdType:       appconfig.GPUUID,
+		PodResourcesKubeletSocket: socketPath,
+		PodResourcesRefresh:       time.Hour,
+	})
+	podMapper.podDeletions = NewPodDeletionWatcher(client, "node-a")
+	defer podMapper.Stop()
+
+	ctrl := gomock.NewController(t)
+	mockSystemInfo := mockdeviceinfo.NewMockProvider(ctrl)
+
+	podOnGPU := func() string {
+		metrics := newPodMapperTestMetrics(gpuUUID)
+		require.NoError(t, podMapper.Process(metrics, mockSystemInfo))
+		for _, values := range metrics {
+			return values[0].Attributes[podAttribute]
+		}
+		return ""
+	}
+
+	ctx := context.Background()
+	steps := []struct {
+		name    string
+		change  func() error
+		wantPod string
+	}{
+		{
+			name:    "both pods running",
+			change:  func() error { return nil },
+			wantPod: "pod-1",
+		},
+		{
+			name: "pod-1 deleted",
+			change: func() error {
+				return client.CoreV1().Pods("default").Delete(ctx, "pod-1", metav1.DeleteOptions{})
+			},
+			wantPod: "pod-0",
+		},
+		{
+			name: "pod-0 terminated",
+			change: func() error {
+				pod := newPodDeletionTestPod("pod-0")
+				pod.Status.Phase = corev1.PodSucceeded
+				_, err := client.CoreV1().Pods("default").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+				return err
+			},
+			wantPod: "",
+		},
+		{
+			name: "pod-1 scheduled again",
+			change: func() error {
+				_, err := client.CoreV1().Pods("default").Create(ctx, newPodDeletionTestPod("pod-1"),
+					metav1.CreateOptions{})
+				return err
+			},
+			wantPod: "pod-1",
+		},
+	}
+
+	for _, step := range steps {
+		require.NoError(t, step.change(), step.name)
+		assert.Eventually(t, func() bool {
+			return podOnGPU() == step.wantPod
+		}, time.Second, 5*time.Millisecond, "%s: GPU must be attributed to %q", step.name, step.wantPod)
+	}
+
+	assert.Equal(t, int32(1), lister.calls.Load())
+}
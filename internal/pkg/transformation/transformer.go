@@ -17,14 +17,40 @@
 package transformation
 
 import (
+	"log/slog"
+
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/hostname"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/kubeclient"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 )
 
 // GetTransformations return list of transformation applicable for metrics
 func GetTransformations(c *appconfig.Config) []Transform {
 	var transformations []Transform
+	if c.MIGUUIDLabel {
+		transformations = append(transformations, newMIGUUIDMapper())
+	}
+
 	if c.Kubernetes {
 		podMapper := NewPodMapper(c)
+		if c.DRAResourceSlices {
+			client, err := kubeclient.NewClient(c)
+			if err != nil {
+				slog.Error("Not resolving the DRA devices from the ResourceSlices",
+					slog.String(logging.ErrorKey, err.Error()))
+			} else {
+				podMapper.draDevices = NewDRAResourceSliceManager(client)
+			}
+		}
+		if c.WatchPodDeletions {
+			client, err := kubeclient.NewClient(c)
+			if err != nil {
+				slog.Error("Not watching the pod deletions", slog.String(logging.ErrorKey, err.Error()))
+			} else {
+				podMapper.podDeletions = NewPodDeletionWatcher(client, os.Getenv(hostname.OriginNodeName))
+			}
+		}
 		transformations = append(transformations, podMapper)
 	}
 
@@ -33,5 +59,15 @@ func GetTransformations(c *appconfig.Config) []Transform {
 		transformations = append(transformations, hpcMapper)
 	}
 
+	if len(c.KubernetesNodeLabels) > 0 {
+		client, err := kubeclient.NewClient(c)
+		if err != nil {
+			slog.Error("Not attaching the Kubernetes node labels", slog.String(logging.ErrorKey, err.Error()))
+		} else {
+			nodeLabelsMapper := newNodeLabelsMapper(client, os.Getenv(hostname.OriginNodeName), c.KubernetesNodeLabels)
+			transformations = append(transformations, nodeLabelsMapper)
+		}
+	}
+
 	return transformations
 }
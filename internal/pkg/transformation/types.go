@@ -17,6 +17,13 @@
 package transformation
 
 import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1alpha1"
+
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
@@ -29,12 +36,102 @@ type Transform interface {
 	Name() string
 }
 
+// ExemplarLabeler is implemented by transformations, which know the exemplar labels of the series they attributed,
+// e.g. the UID of the pod.
+type ExemplarLabeler interface {
+	// ExemplarLabels returns the exemplar labels of a series with the labels, or nil, when there are none.
+	ExemplarLabels(labels map[string]string) map[string]string
+}
+
+// Stopper is implemented by transformations, which run background work that must be stopped on shutdown.
+type Stopper interface {
+	Stop()
+}
+
 type PodMapper struct {
 	Config *appconfig.Config
+
+	breaker     *circuitBreaker
+	pods        *podresourcesapi.ListPodResourcesResponse
+	podsFetched time.Time
+	podsMtx     sync.RWMutex
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	stop        chan struct{}
+
+	// Device to pod mapping built from the cached pod resources, reused until they are refreshed
+	mapping    deviceToPodMapping
+	mappingMtx sync.Mutex
+
+	// Resolves the DRA devices to their UUIDs, when the ResourceSlices are watched
+	draDevices *DRAResourceSliceManager
+
+	// Reports the deleted pods of the node, when the pods are watched
+	podDeletions *PodDeletionWatcher
+
+	// UIDs of the pods and IDs of the containers, read from the kubelet API, when exemplars are enabled
+	identities    map[containerRef]containerIdentity
+	identitiesMtx sync.RWMutex
+
+	kubeletClient     *http.Client
+	kubeletClientErr  error
+	kubeletClientOnce sync.Once
+}
+
+// deviceKey identifies a device, which is allocated to pods: a whole GPU or a GPU instance (MIG). GPUs are
+// identified by their UUID or their device name, according to the KubernetesGPUIdType, and GPU instances by the UUID
+// of their GPU and their GPU instance ID.
+type deviceKey struct {
+	entityType    dcgm.Field_Entity_Group
+	id            string
+	gpuInstanceID string
+}
+
+func gpuKey(id string) deviceKey {
+	return deviceKey{entityType: dcgm.FE_GPU, id: id}
+}
+
+func gpuInstanceKey(gpuUUID, gpuInstanceID string) deviceKey {
+	return deviceKey{entityType: dcgm.FE_GPU_I, id: gpuUUID, gpuInstanceID: gpuInstanceID}
+}
+
+// deviceToPodMapping is the device to pod mapping, together with the pod resources, the device info and the revision of
+// the DRA ResourceSlices, which it is built from.
+type deviceToPodMapping struct {
+	pods        *podresourcesapi.ListPodResourcesResponse
+	deviceInfo  deviceinfo.Provider
+	draRevision uint64
+	podRevision uint64
+	deviceToPod map[deviceKey]PodInfo
+}
+
+// podRef identifies a pod by its namespace and its name.
+type podRef struct {
+	namespace string
+	name      string
+}
+
+// containerRef identifies a container by the names of its pod, as in the labels of the attributed series.
+type containerRef struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// containerIdentity is the pod UID and the container ID of a container, which exemplars link the series to.
+type containerIdentity struct {
+	podUID      string
+	containerID string
 }
 
 type PodInfo struct {
 	Name      string
 	Namespace string
 	Container string
+	Source    string
+	// Claim is the DRA resource claim, in the namespace of the pod, and Pool and Device identify the allocated device
+	// in the ResourceSlices of its driver, when the device is allocated through DRA
+	Claim  string
+	Pool   string
+	Device string
 }
@@ -16,10 +16,26 @@
 
 package transformation
 
-import osinterface "github.com/NVIDIA/dcgm-exporter/internal/pkg/os"
+import (
+	"time"
+
+	osinterface "github.com/NVIDIA/dcgm-exporter/internal/pkg/os"
+)
 
 var os osinterface.OS = osinterface.RealOS{}
 
+// nodeLabelsSyncTimeout is how long the first scrape waits for the node to be read, before the metrics are exported
+// without the node labels.
+var nodeLabelsSyncTimeout = 5 * time.Second
+
+// draResourceSlicesSyncTimeout is how long the first scrape waits for the ResourceSlices to be read, before the DRA
+// devices are identified by their device names only.
+var draResourceSlicesSyncTimeout = 5 * time.Second
+
+// podDeletionsSyncTimeout is how long the first scrape waits for the pods of the node to be read, before the pod
+// deletions are watched.
+var podDeletionsSyncTimeout = 5 * time.Second
+
 var doNothing = func() {
 	// This function is intentionally left blank
 }
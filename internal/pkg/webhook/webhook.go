@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package webhook implements a validating admission webhook, which rejects counters ConfigMaps with records
+// the exporter would fail on or would skip.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// Validator validates the records of a counters file.
+type Validator func(records [][]string) error
+
+// NewHandler returns the handler of AdmissionReview requests for ConfigMaps.
+func NewHandler(validate Validator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var review admissionv1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+			http.Error(w, "malformed AdmissionReview", http.StatusBadRequest)
+			return
+		}
+
+		review.Response = admit(review.Request, validate)
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			slog.Error("Failed to write AdmissionReview response.", slog.String(logging.ErrorKey, err.Error()))
+		}
+	})
+}
+
+func admit(req *admissionv1.AdmissionRequest, validate Validator) *admissionv1.AdmissionResponse {
+	resp := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+
+	// Deleted objects are not validated
+	if len(req.Object.Raw) == 0 {
+		return resp
+	}
+
+	var cm corev1.ConfigMap
+	if err := json.Unmarshal(req.Object.Raw, &cm); err != nil {
+		return deny(resp, http.StatusBadRequest, fmt.Sprintf("failed to decode ConfigMap; err: %v", err))
+	}
+
+	data, exists := cm.Data[counters.ConfigMapMetricsKey]
+	if !exists {
+		return resp
+	}
+
+	records, err := counters.ReadCSV(strings.NewReader(data))
+	if err != nil {
+		return deny(resp, http.StatusUnprocessableEntity, fmt.Sprintf("malformed CSV; err: %v", err))
+	}
+
+	if err = validate(records); err != nil {
+		return deny(resp, http.StatusUnprocessableEntity,
+			fmt.Sprintf("invalid counters in ConfigMap '%s/%s':\n%v", req.Namespace, req.Name, err))
+	}
+
+	return resp
+}
+
+func deny(resp *admissionv1.AdmissionResponse, code int32, message string) *admissionv1.AdmissionResponse {
+	resp.Allowed = false
+	resp.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Message: message,
+	}
+
+	return resp
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandler(t *testing.T) {
+	validate := func(records [][]string) error {
+		for _, record := range records {
+			if record[0] == "DCGM_FI_PROF_GR_ENGINE_ACTIVE" {
+				return errors.New("line 1 ('DCGM_FI_PROF_GR_ENGINE_ACTIVE'): metric is not enabled and would be skipped")
+			}
+		}
+		return nil
+	}
+
+	configMap := func(data map[string]string) runtime.RawExtension {
+		raw, err := json.Marshal(corev1.ConfigMap{Data: data})
+		require.NoError(t, err)
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	tests := []struct {
+		name        string
+		object      runtime.RawExtension
+		wantAllowed bool
+		wantMessage string
+	}{
+		{
+			name:        "Valid counters",
+			object:      configMap(map[string]string{"metrics": "DCGM_FI_DEV_GPU_TEMP, gauge, temperature\n"}),
+			wantAllowed: true,
+		},
+		{
+			name: "Skipped counters",
+			object: configMap(map[string]string{
+				"metrics": "DCGM_FI_DEV_GPU_TEMP, gauge, temperature\nDCGM_FI_PROF_GR_ENGINE_ACTIVE, gauge, active\n",
+			}),
+			wantMessage: "invalid counters in ConfigMap 'gpu-monitoring/counters':\nline 1",
+		},
+		{
+			name:        "Malformed CSV",
+			object:      configMap(map[string]string{"metrics": "DCGM_FI_DEV_GPU_TEMP, gauge\nDCGM_FI_DEV_POWER_USAGE\n"}),
+			wantMessage: "malformed CSV",
+		},
+		{
+			name:        "ConfigMap without counters",
+			object:      configMap(map[string]string{"other": "value"}),
+			wantAllowed: true,
+		},
+		{
+			name:        "Deleted ConfigMap",
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "42",
+					Name:      "counters",
+					Namespace: "gpu-monitoring",
+					Object:    tt.object,
+				},
+			}
+			body, err := json.Marshal(review)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			NewHandler(validate).ServeHTTP(recorder,
+				httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var got admissionv1.AdmissionReview
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+			require.NotNil(t, got.Response)
+			assert.Nil(t, got.Request)
+			assert.Equal(t, "AdmissionReview", got.Kind)
+			assert.EqualValues(t, "42", got.Response.UID)
+			assert.Equal(t, tt.wantAllowed, got.Response.Allowed)
+			if tt.wantMessage != "" {
+				require.NotNil(t, got.Response.Result)
+				assert.Contains(t, got.Response.Result.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandler_MalformedRequests(t *testing.T) {
+	handler := NewHandler(func([][]string) error { return nil })
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/validate", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/alerting"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+)
+
+// newAlertEvaluator loads the alert rules. Rules on fields, which are not collected, never fire.
+func newAlertEvaluator(config *appconfig.Config, cs *counters.CounterSet) (*alerting.Evaluator, error) {
+	rules, err := alerting.LoadRules(config.AlertRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	collected := slices.Concat(cs.DCGMCounters, cs.ExporterCounters)
+	for _, rule := range rules {
+		if !slices.ContainsFunc(collected, func(c counters.Counter) bool { return c.FieldName == rule.Field }) {
+			slog.Warn(fmt.Sprintf("Alert rule %s uses %s, which is not collected; the rule never fires",
+				rule.Name, rule.Field))
+		}
+	}
+
+	var notifier alerting.Notifier
+	if config.AlertWebhookURL != "" {
+		notifier = alerting.NewWebhookNotifier(config.AlertWebhookURL)
+	} else {
+		slog.Info("No alert webhook URL is set; alerts are only logged")
+	}
+
+	slog.Info(fmt.Sprintf("Evaluating %d alert rules", len(rules)))
+
+	return alerting.NewEvaluator(rules, notifier), nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
@@ -18,17 +19,26 @@ import (
 	"time"
 
 	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/prometheus/common/model"
 	"github.com/urfave/cli/v2"
 
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/configbackend"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/cputuning"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatcher"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dmon"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/grpcapi"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/hostenginestats"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/hostname"
 	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/otlp"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/prerequisites"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
 	"github.com/NVIDIA/dcgm-exporter/internal/pkg/server"
@@ -44,6 +54,7 @@ const (
 	deviceUsageTemplate    = `Specify which devices dcgm-exporter monitors.
 	Possible values: {{.FlexKey}} or 
 	                 {{.MajorKey}}[:id1[,-id2...] or 
+	                 {{.MajorKey}}[:uuid-or-pci-bus-id1[,...]] or 
 	                 {{.MinorKey}}[:id1[,-id2...].
 	If an id list is used, then devices with match IDs must exist on the system. For example:
 		(default) = monitor all GPU instances in MIG mode, all GPUs if MIG mode is disabled. (See {{.FlexKey}})
@@ -54,6 +65,7 @@ const (
                              will be monitored. If it doesn't, then the GPU will be monitored.
                              This is our recommended option for single or mixed MIG Strategies.
 		{{.MajorKey}}:0,1 = monitor GPUs 0 and 1
+		{{.MajorKey}}:GPU-8a2b...,0000:3b:00.0 = monitor GPUs by UUID or PCI bus ID, which are stable across reboots
 		{{.MinorKey}}:0,2-4 = monitor GPU instances 0, 2, 3, and 4.
 
 	NOTE 1: -i cannot be specified unless MIG mode is enabled.
@@ -73,6 +85,7 @@ const (
 	CLIGPUDevices                 = "devices"
 	CLISwitchDevices              = "switch-devices"
 	CLICPUDevices                 = "cpu-devices"
+	CLIExcludeEntities            = "exclude-entities"
 	CLINoHostname                 = "no-hostname"
 	CLIUseFakeGPUs                = "fake-gpus"
 	CLIConfigMapData              = "configmap-data"
@@ -87,6 +100,70 @@ const (
 	CLIPodResourcesKubeletSocket  = "pod-resources-kubelet-socket"
 	CLIHPCJobMappingDir           = "hpc-job-mapping-dir"
 	CLINvidiaResourceNames        = "nvidia-resource-names"
+	CLIEnableEncoderDecoder       = "enable-encoder-decoder-metrics"
+	CLIEnableProcessTypes         = "enable-process-type-metrics"
+	CLICollectDriverState         = "collect-driver-state"
+	CLINVMLFallback               = "nvml-fallback"
+	CLINVMLOnly                   = "nvml-only"
+	CLIPodResourcesTimeout        = "pod-resources-timeout"
+	CLIPodResourcesRefresh        = "pod-resources-refresh-interval"
+	CLIPodResourcesCacheTTL       = "pod-resources-cache-ttl"
+	CLIGPUInstanceMetrics         = "gpu-instance-metrics"
+	CLIComputeInstanceMetrics     = "compute-instance-metrics"
+	CLIMIGUUIDLabel               = "mig-uuid-label"
+	CLIAllowedSourceCIDRs         = "allowed-source-cidrs"
+	CLIMetricsTokenFile           = "metrics-token-file"
+	CLIMetricsAllowedClientCNs    = "metrics-allowed-client-cns"
+	CLIAdaptiveCollectInterval    = "adaptive-collect-interval"
+	CLIMinCollectInterval         = "min-collect-interval"
+	CLIMaxCollectInterval         = "max-collect-interval"
+	CLIKubeletAPIURL              = "kubelet-api-url"
+	CLIKubeletAPITokenFile        = "kubelet-api-token-file"
+	CLIKubeletAPICAFile           = "kubelet-api-ca-file"
+	CLIKubeletAPIInsecure         = "kubelet-api-insecure-skip-verify"
+	CLIPodAttributionSource       = "pod-attribution-source-label"
+	CLIPodAttributionCgroups      = "pod-attribution-cgroups"
+	CLIAlertRulesFile             = "alert-rules-file"
+	CLIAlertWebhookURL            = "alert-webhook-url"
+	CLIHostengineLabel            = "hostengine-label"
+	CLIAllocationEfficiency       = "enable-allocation-efficiency-metric"
+	CLIPodAggregates              = "enable-pod-aggregate-metrics"
+	CLIDownsampleCounters         = "downsample-counters"
+	CLIDownsampleWindow           = "downsample-window"
+	CLIHostnameSource             = "hostname-source"
+	CLIIdleCounters               = "idle-counters"
+	CLIIdleAfter                  = "idle-after"
+	CLIKubeConfig                 = "kubeconfig"
+	CLIKubernetesCAFile           = "kubernetes-ca-file"
+	CLIKubernetesProxyURL         = "kubernetes-proxy-url"
+	CLIKubernetesNodeLabels       = "kubernetes-node-labels"
+	CLIEmitK8sEvents              = "emit-k8s-events"
+	CLIDRAResourceSlices          = "dra-resource-slices"
+	CLIWatchPodDeletions          = "watch-pod-deletions"
+	CLIOpenMetricsExemplars       = "openmetrics-exemplars"
+	CLIGoMaxProcs                 = "gomaxprocs"
+	CLICollectWorkers             = "collect-workers"
+	CLIDmonColumns                = "dmon-columns"
+	CLIConfigBackend              = "config-backend"
+	CLIAdminTokenFile             = "admin-token-file"
+	CLIDiagEndpoint               = "enable-diag-endpoint"
+	CLIPodAttributionGPUInstances = "pod-attribution-gpu-instances"
+	CLIStaleMetricsMaxAge         = "stale-metrics-max-age"
+	CLIScrapeCacheTTL             = "scrape-cache-ttl"
+	CLIHAStandby                  = "ha-standby"
+	CLIHAStandbyProbeInterval     = "ha-standby-probe-interval"
+	CLIHAStandbyFailures          = "ha-standby-failures"
+	CLICollectorsWatchInterval    = "collectors-watch-interval"
+	CLITopologyWatchInterval      = "topology-watch-interval"
+	CLIOTLPEndpoint               = "otlp-endpoint"
+	CLIOTLPProtocol               = "otlp-protocol"
+	CLIOTLPPushInterval           = "otlp-push-interval"
+	CLIOTLPResourceAttributes     = "otlp-resource-attributes"
+	CLIGRPCAddress                = "grpc-address"
+	CLISkipEntityOnError          = "skip-entity-on-error"
+	CLIMetricPrefix               = "metric-prefix"
+	CLILogFormat                  = "log-format"
+	CLILogLevel                   = "log-level"
 )
 
 func NewApp(buildVersion ...string) *cli.App {
@@ -146,6 +223,12 @@ func NewApp(buildVersion ...string) *cli.App {
 			Usage:   DeviceUsageStr,
 			EnvVars: []string{"DCGM_EXPORTER_CPU_DEVICES_STR"},
 		},
+		&cli.StringFlag{
+			Name:    CLIExcludeEntities,
+			Value:   "",
+			Usage:   "Entities to exclude from the export, as <type>:<id> separated by semicolons, e.g. 'gpu:GPU-8a2b...;link:*'. Types are gpu, switch, link, cpu, cpu_core and vgpu; the ID '*' excludes all entities of the type.",
+			EnvVars: []string{"DCGM_EXPORTER_EXCLUDE_ENTITIES"},
+		},
 		&cli.StringFlag{
 			Name:    CLIConfigMapData,
 			Aliases: []string{"m"},
@@ -157,7 +240,7 @@ func NewApp(buildVersion ...string) *cli.App {
 			Name:    CLIRemoteHEInfo,
 			Aliases: []string{"r"},
 			Value:   "localhost:5555",
-			Usage:   "Connect to remote hostengine at <HOST>:<PORT>",
+			Usage:   "Connect to remote hostengine at <HOST>:<PORT>. With a comma-separated list, a worker is started per hostengine and their metrics are merged.",
 			EnvVars: []string{"DCGM_REMOTE_HOSTENGINE_INFO"},
 		},
 		&cli.StringFlag{
@@ -256,6 +339,393 @@ func NewApp(buildVersion ...string) *cli.App {
 			Usage:   "Nvidia resource names for specified GPU type like nvidia.com/a100, nvidia.com/a10.",
 			EnvVars: []string{"NVIDIA_RESOURCE_NAMES"},
 		},
+		&cli.BoolFlag{
+			Name:    CLIEnableEncoderDecoder,
+			Value:   false,
+			Usage:   "Enable video encoder session count and encoder/decoder utilization metrics collected through NVML.",
+			EnvVars: []string{"DCGM_EXPORTER_ENABLE_ENCODER_DECODER_METRICS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIEnableProcessTypes,
+			Value:   false,
+			Usage:   "Enable compute and graphics process count and utilization metrics collected through NVML.",
+			EnvVars: []string{"DCGM_EXPORTER_ENABLE_PROCESS_TYPE_METRICS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLICollectDriverState,
+			Value:   false,
+			Usage:   "Collect the state of the driver, which DCGM doesn't report, from procfs, sysfs and NVML: the kernel module versions, the loaded kernel modules, the persistence mode and the open device handles.",
+			EnvVars: []string{"DCGM_EXPORTER_COLLECT_DRIVER_STATE"},
+		},
+		&cli.BoolFlag{
+			Name:    CLINVMLFallback,
+			Value:   true,
+			Usage:   "Read the fields, which DCGM returns no value for, e.g. the fan speed on some SKUs, from NVML, when NVML supports them. Not applied with a remote hostengine.",
+			EnvVars: []string{"DCGM_EXPORTER_NVML_FALLBACK"},
+		},
+		&cli.BoolFlag{
+			Name:    CLINVMLOnly,
+			Value:   false,
+			Usage:   "Collect the metrics with NVML only, without DCGM, on hosts DCGM is not available on, e.g. WSL2. Only a subset of the GPU fields is exported.",
+			EnvVars: []string{"DCGM_EXPORTER_NVML_ONLY"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIPodResourcesTimeout,
+			Value:   10 * time.Second,
+			Usage:   "Timeout of a single kubelet pod-resources call.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_RESOURCES_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIPodResourcesRefresh,
+			Value:   0,
+			Usage:   "Interval of refreshing the pod to device mapping in the background, outside of scrapes. It is capped at half of the collect interval. When 0, kubelet is queried on every scrape.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_RESOURCES_REFRESH_INTERVAL"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIPodResourcesCacheTTL,
+			Value:   0,
+			Usage:   "Time, for which the pod to device mapping is reused by scrapes, before kubelet is queried again. A warning is logged, when it is longer than the collect interval. When 0, the prefetched mapping is reused until it is refreshed, or kubelet is queried on every scrape without prefetch.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_RESOURCES_CACHE_TTL"},
+		},
+		&cli.StringFlag{
+			Name:  CLIGPUInstanceMetrics,
+			Value: string(appconfig.GPUInstancesMixed),
+			Usage: fmt.Sprintf("Choose how GPU instance (MIG) metrics are separated from physical GPU metrics. Possible values: '%s', '%s', '%s'",
+				appconfig.GPUInstancesMixed, appconfig.GPUInstancesSuffix, appconfig.GPUInstancesLabel),
+			EnvVars: []string{"DCGM_EXPORTER_GPU_INSTANCE_METRICS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIComputeInstanceMetrics,
+			Value:   false,
+			Usage:   "Monitor the compute instances of the monitored GPU instances, exporting their metrics with the GPU_CI_ID label.",
+			EnvVars: []string{"DCGM_EXPORTER_COMPUTE_INSTANCE_METRICS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIMIGUUIDLabel,
+			Value:   false,
+			Usage:   "Attach the UUID of the MIG device, resolved via NVML, to the metrics of GPU instances and compute instances as the mig_uuid label.",
+			EnvVars: []string{"DCGM_EXPORTER_MIG_UUID_LABEL"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIAllowedSourceCIDRs,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Source IP addresses or CIDRs, e.g. 10.0.0.0/8, allowed to connect to the HTTP server. When empty, all sources are allowed.",
+			EnvVars: []string{"DCGM_EXPORTER_ALLOWED_SOURCE_CIDRS"},
+		},
+		&cli.StringFlag{
+			Name:    CLIMetricsTokenFile,
+			Value:   "",
+			Usage:   "File with the bearer tokens, one per line, which are allowed to read the metrics endpoints. When empty, and no client common names are allowed, the metrics are served to all clients.",
+			EnvVars: []string{"DCGM_EXPORTER_METRICS_TOKEN_FILE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIMetricsAllowedClientCNs,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Common names of the client certificates, verified against the client CA of the web config, which are allowed to read the metrics endpoints.",
+			EnvVars: []string{"DCGM_EXPORTER_METRICS_ALLOWED_CLIENT_CNS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIAdaptiveCollectInterval,
+			Value:   false,
+			Usage:   "Adjust the collect interval to the observed scrape interval, within the min and max collect interval.",
+			EnvVars: []string{"DCGM_EXPORTER_ADAPTIVE_COLLECT_INTERVAL"},
+		},
+		&cli.IntFlag{
+			Name:    CLIMinCollectInterval,
+			Value:   1000,
+			Usage:   "Lower bound of the adaptive collect interval (in milliseconds).",
+			EnvVars: []string{"DCGM_EXPORTER_MIN_COLLECT_INTERVAL"},
+		},
+		&cli.IntFlag{
+			Name:    CLIMaxCollectInterval,
+			Value:   60000,
+			Usage:   "Upper bound of the adaptive collect interval (in milliseconds).",
+			EnvVars: []string{"DCGM_EXPORTER_MAX_COLLECT_INTERVAL"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubeletAPIURL,
+			Value:   "",
+			Usage:   "URL of the kubelet API, e.g. https://$(NODE_NAME):10250 or http://localhost:10255, used for pod attribution instead of the pod-resources socket.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBELET_API_URL"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubeletAPITokenFile,
+			Value:   "",
+			Usage:   "Path to the bearer token file used to authenticate to the kubelet API.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBELET_API_TOKEN_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubeletAPICAFile,
+			Value:   "",
+			Usage:   "Path to the CA certificate file used to verify the kubelet API serving certificate.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBELET_API_CA_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIKubeletAPIInsecure,
+			Value:   false,
+			Usage:   "Skip the verification of the kubelet API serving certificate.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBELET_API_INSECURE_SKIP_VERIFY"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIPodAttributionSource,
+			Value:   false,
+			Usage:   "Add the attribution_source label (device-plugin, dra, cdi or cgroup) to metrics attributed to pods.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_ATTRIBUTION_SOURCE_LABEL"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIPodAttributionCgroups,
+			Value:   false,
+			Usage:   "Attribute the GPUs, which no pod is allocated by the pod resources, to the container of their processes, read from the cgroups of the processes. Requires the host PID namespace.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_ATTRIBUTION_CGROUPS"},
+		},
+		&cli.StringFlag{
+			Name:    CLIAlertRulesFile,
+			Value:   "",
+			Usage:   "Path to a file with threshold alert rules, which are evaluated in-process every collect interval.",
+			EnvVars: []string{"DCGM_EXPORTER_ALERT_RULES_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    CLIAlertWebhookURL,
+			Value:   "",
+			Usage:   "URL, to which notifications of firing and resolved alerts are posted.",
+			EnvVars: []string{"DCGM_EXPORTER_ALERT_WEBHOOK_URL"},
+		},
+		&cli.StringFlag{
+			Name:    CLIHostengineLabel,
+			Value:   "",
+			Usage:   "Name of the label with the address of the remote hostengine, which is added to every metric.",
+			EnvVars: []string{"DCGM_EXPORTER_HOSTENGINE_LABEL"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIAllocationEfficiency,
+			Value:   false,
+			Usage:   "Export the utilization of GPUs allocated to pods as dcgm_gpu_allocation_efficiency.",
+			EnvVars: []string{"DCGM_EXPORTER_ENABLE_ALLOCATION_EFFICIENCY_METRIC"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIPodAggregates,
+			Value:   false,
+			Usage:   "Export the utilization and the used memory of the GPUs allocated to pods, summed per pod and per namespace.",
+			EnvVars: []string{"DCGM_EXPORTER_ENABLE_POD_AGGREGATE_METRICS"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIDownsampleCounters,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Counters, which averages and maxima over the downsample window are served at /metrics/downsampled.",
+			EnvVars: []string{"DCGM_EXPORTER_DOWNSAMPLE_COUNTERS"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIDownsampleWindow,
+			Value:   5 * time.Minute,
+			Usage:   "Window of the downsampled counters.",
+			EnvVars: []string{"DCGM_EXPORTER_DOWNSAMPLE_WINDOW"},
+		},
+		&cli.StringFlag{
+			Name:  CLIHostnameSource,
+			Value: string(appconfig.HostnameSourceAuto),
+			Usage: fmt.Sprintf("Choose where the Hostname label is read from, unless a remote hostengine is used. Possible values: '%s' (NODE_NAME when set, otherwise the OS hostname), '%s' (NODE_NAME only), '%s' (OS hostname only)",
+				appconfig.HostnameSourceAuto, appconfig.HostnameSourceNodeName, appconfig.HostnameSourceOS),
+			EnvVars: []string{"DCGM_EXPORTER_HOSTNAME_SOURCE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIIdleCounters,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Counters, which are the only ones exported while no pod is allocated a GPU. Requires -k. When empty, all counters are always exported.",
+			EnvVars: []string{"DCGM_EXPORTER_IDLE_COUNTERS"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIIdleAfter,
+			Value:   10 * time.Minute,
+			Usage:   "Period without any GPU allocated to a pod, after which only the idle counters are exported.",
+			EnvVars: []string{"DCGM_EXPORTER_IDLE_AFTER"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubeConfig,
+			Value:   "",
+			Usage:   "Path to the kubeconfig file of the Kubernetes API client. When empty, the in-cluster configuration is used.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBECONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubernetesCAFile,
+			Value:   "",
+			Usage:   "Path to the CA bundle, which the Kubernetes API and kubelet API clients verify server certificates with.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBERNETES_CA_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    CLIKubernetesProxyURL,
+			Value:   "",
+			Usage:   "URL of the proxy for the Kubernetes API and kubelet API clients. When empty, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBERNETES_PROXY_URL"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIKubernetesNodeLabels,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Labels of the Kubernetes node, e.g. topology.kubernetes.io/zone, attached to every metric as label_<name>. The node is read from the Kubernetes API and named by the NODE_NAME environment variable.",
+			EnvVars: []string{"DCGM_EXPORTER_KUBERNETES_NODE_LABELS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIEmitK8sEvents,
+			Value:   false,
+			Usage:   "Post Kubernetes Events on the node, named by the NODE_NAME environment variable, when a GPU reports a critical condition, e.g. double-bit ECC errors, thermal violations, critical XID errors or a needed reset.",
+			EnvVars: []string{"DCGM_EXPORTER_EMIT_K8S_EVENTS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIDRAResourceSlices,
+			Value:   false,
+			Usage:   "Resolve the devices allocated through DRA to their GPU or MIG device UUIDs from the ResourceSlices of the Kubernetes API, which are watched. Requires -k.",
+			EnvVars: []string{"DCGM_EXPORTER_DRA_RESOURCE_SLICES"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIWatchPodDeletions,
+			Value:   false,
+			Usage:   "Watch the pods of the node, named by the NODE_NAME environment variable, in the Kubernetes API, and stop attributing the devices of a pod as soon as it is deleted or terminated. Requires -k.",
+			EnvVars: []string{"DCGM_EXPORTER_WATCH_POD_DELETIONS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIOpenMetricsExemplars,
+			Value:   false,
+			Usage:   "Attach exemplars with the pod UID and the container ID to the counters with the _total suffix, which are attributed to pods, when OpenMetrics is served. Requires -k and --kubelet-api-url.",
+			EnvVars: []string{"DCGM_EXPORTER_OPENMETRICS_EXEMPLARS"},
+		},
+		&cli.IntFlag{
+			Name:    CLIGoMaxProcs,
+			Value:   0,
+			Usage:   "GOMAXPROCS of the exporter. When 0, it is derived from the CPU quota of the container, unless the GOMAXPROCS environment variable is set.",
+			EnvVars: []string{"DCGM_EXPORTER_GOMAXPROCS"},
+		},
+		&cli.IntFlag{
+			Name:    CLICollectWorkers,
+			Value:   0,
+			Usage:   "Maximum number of collectors gathering metrics concurrently. When 0, it follows GOMAXPROCS.",
+			EnvVars: []string{"DCGM_EXPORTER_COLLECT_WORKERS"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIDmonColumns,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Columns of the nvidia-smi dmon compatible CSV served at /compat/dmon. When empty, the endpoint is disabled.",
+			EnvVars: []string{"DCGM_EXPORTER_DMON_COLUMNS"},
+		},
+		&cli.StringFlag{
+			Name:    CLIConfigBackend,
+			Value:   "",
+			Usage:   "URL of the etcd or Consul prefix, which supplies the counters and the device options, e.g. consul://127.0.0.1:8500/dcgm-exporter. Changes are applied without a restart.",
+			EnvVars: []string{"DCGM_EXPORTER_CONFIG_BACKEND"},
+		},
+		&cli.StringFlag{
+			Name:    CLIAdminTokenFile,
+			Value:   "",
+			Usage:   "File with the bearer token of the /admin endpoints, which drain GPUs, pause their monitoring during resets and change the counters at runtime. When empty, the endpoints are disabled.",
+			EnvVars: []string{"DCGM_EXPORTER_ADMIN_TOKEN_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIDiagEndpoint,
+			Value:   false,
+			Usage:   "Enable the /diag endpoint, which runs the DCGM diagnostic (level 1 or 2) on demand and responds with its result. Requires the admin token.",
+			EnvVars: []string{"DCGM_EXPORTER_ENABLE_DIAG_ENDPOINT"},
+		},
+		&cli.BoolFlag{
+			Name:    CLIPodAttributionGPUInstances,
+			Value:   false,
+			Usage:   "Attribute the metrics of GPU instances (MIG) only to the pods, which were allocated the GPU instance, and the metrics of GPUs only to the pods, which were allocated the whole GPU. For clusters mixing whole GPUs and MIG devices allocated by device plugins and DRA.",
+			EnvVars: []string{"DCGM_EXPORTER_POD_ATTRIBUTION_GPU_INSTANCES"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIStaleMetricsMaxAge,
+			Value:   time.Minute,
+			Usage:   "Maximum age of the last rendered metrics, which are served at /metrics while the collection restarts and has no metrics yet. 0 disables serving them.",
+			EnvVars: []string{"DCGM_EXPORTER_STALE_METRICS_MAX_AGE"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIScrapeCacheTTL,
+			Value:   0,
+			Usage:   "Time the metrics rendered by a full scrape are served to the following scrapes, e.g. of several Prometheus replicas, instead of reading DCGM again. 0 disables the cache.",
+			EnvVars: []string{"DCGM_EXPORTER_SCRAPE_CACHE_TTL"},
+		},
+		&cli.StringFlag{
+			Name:    CLIHAStandby,
+			Value:   "",
+			Usage:   "URL of the health endpoint of the primary exporter, e.g. http://primary:9400/health. When set, the exporter runs as its standby: DCGM is only initialized and /metrics only served, once the primary stops responding.",
+			EnvVars: []string{"DCGM_EXPORTER_HA_STANDBY"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIHAStandbyProbeInterval,
+			Value:   5 * time.Second,
+			Usage:   "Interval, at which the standby probes the health endpoint of the primary exporter. It is also the timeout of a probe.",
+			EnvVars: []string{"DCGM_EXPORTER_HA_STANDBY_PROBE_INTERVAL"},
+		},
+		&cli.IntFlag{
+			Name:    CLIHAStandbyFailures,
+			Value:   3,
+			Usage:   "Number of consecutive failed probes of the primary exporter, after which the standby takes over.",
+			EnvVars: []string{"DCGM_EXPORTER_HA_STANDBY_FAILURES"},
+		},
+		&cli.DurationFlag{
+			Name:    CLICollectorsWatchInterval,
+			Value:   0,
+			Usage:   "Interval, at which the counters file or the counters ConfigMap are checked for changes, which are applied like on SIGHUP. 0 disables watching them.",
+			EnvVars: []string{"DCGM_EXPORTER_COLLECTORS_WATCH_INTERVAL"},
+		},
+		&cli.DurationFlag{
+			Name:    CLITopologyWatchInterval,
+			Value:   0,
+			Usage:   "Interval, at which the GPUs, MIG instances and NVSwitches are checked for changes, e.g. hotplugged GPUs, which are applied like on SIGHUP. 0 disables watching them.",
+			EnvVars: []string{"DCGM_EXPORTER_TOPOLOGY_WATCH_INTERVAL"},
+		},
+		&cli.StringFlag{
+			Name:    CLIOTLPEndpoint,
+			Value:   "",
+			Usage:   "URL of the OpenTelemetry collector, e.g. http://otel-collector:4317, which the metrics are pushed to over OTLP. The https scheme enables TLS. When empty, the metrics are not pushed.",
+			EnvVars: []string{"DCGM_EXPORTER_OTLP_ENDPOINT"},
+		},
+		&cli.StringFlag{
+			Name:    CLIOTLPProtocol,
+			Value:   otlp.ProtocolGRPC,
+			Usage:   fmt.Sprintf("OTLP protocol. Possible values: '%s', '%s'", otlp.ProtocolGRPC, otlp.ProtocolHTTP),
+			EnvVars: []string{"DCGM_EXPORTER_OTLP_PROTOCOL"},
+		},
+		&cli.DurationFlag{
+			Name:    CLIOTLPPushInterval,
+			Value:   30 * time.Second,
+			Usage:   "Interval, at which the metrics are pushed to the OpenTelemetry collector.",
+			EnvVars: []string{"DCGM_EXPORTER_OTLP_PUSH_INTERVAL"},
+		},
+		&cli.StringSliceFlag{
+			Name:    CLIOTLPResourceAttributes,
+			Value:   cli.NewStringSlice(),
+			Usage:   "Attributes of the OTLP resource as <key>=<value>, e.g. k8s.cluster.name=prod. They override service.name and service.version.",
+			EnvVars: []string{"DCGM_EXPORTER_OTLP_RESOURCE_ATTRIBUTES"},
+		},
+		&cli.StringFlag{
+			Name:    CLIGRPCAddress,
+			Value:   "",
+			Usage:   "Address of the gRPC API, which serves the collected field values, either <host>:<port> or unix:<path>. When empty, the API is not served.",
+			EnvVars: []string{"DCGM_EXPORTER_GRPC_ADDRESS"},
+		},
+		&cli.BoolFlag{
+			Name:    CLISkipEntityOnError,
+			Value:   false,
+			Usage:   "Disable only the collectors, which cannot be initialized, e.g. of NvLinks or NvSwitches, instead of exiting.",
+			EnvVars: []string{"DCGM_EXPORTER_SKIP_ENTITY_ON_ERROR"},
+		},
+		&cli.StringFlag{
+			Name:    CLIMetricPrefix,
+			Value:   "",
+			Usage:   "Prefix, which replaces the namespace of the field names, e.g. DCGM_FI_DEV_, in the metric names. Fields renamed in the counters file keep their name.",
+			EnvVars: []string{"DCGM_EXPORTER_METRIC_PREFIX"},
+		},
+		&cli.StringFlag{
+			Name:    CLILogFormat,
+			Value:   FormatText,
+			Usage:   fmt.Sprintf("Log format. Possible values: '%s', '%s'", FormatText, FormatJSON),
+			EnvVars: []string{"DCGM_EXPORTER_LOG_FORMAT"},
+		},
+		&cli.StringFlag{
+			Name:  CLILogLevel,
+			Value: LevelInfo,
+			Usage: fmt.Sprintf("Minimum level of the logged messages. '--debug' sets it to '%s'. Possible values: %s",
+				LevelDebug, strings.Join(Levels, ", ")),
+			EnvVars: []string{"DCGM_EXPORTER_LOG_LEVEL"},
+		},
 	}
 
 	if runtime.GOOS == "linux" {
@@ -272,6 +742,13 @@ func NewApp(buildVersion ...string) *cli.App {
 		return nil
 	}
 
+	c.Commands = []*cli.Command{
+		newCollectCommand(),
+		newValidateCommand(),
+		newDryRunCommand(),
+		newSoakTestCommand(),
+	}
+
 	c.Action = func(c *cli.Context) error {
 		return action(c)
 	}
@@ -306,79 +783,189 @@ func action(c *cli.Context) (err error) {
 }
 
 func startDCGMExporter(c *cli.Context, cancel context.CancelFunc) error {
-restart:
-
-	var version string
-	if c != nil && c.App != nil {
-		version = c.App.Version
-	}
-
-	slog.Info("Starting dcgm-exporter", slog.String("Version", version))
+	version := appVersion(c)
 
 	config, err := contextToConfig(c)
 	if err != nil {
 		return err
 	}
 
-	enableDebugLogging(config)
-
-	err = prerequisites.Validate()
-	if err != nil {
+	if err := configureLogging(config); err != nil {
 		return err
 	}
 
-	// Initialize DCGM Provider Instance
-	dcgmprovider.Initialize(config)
-	defer dcgmprovider.Client().Cleanup()
-
-	slog.Info("DCGM successfully initialized!")
-
-	// Initialize NVML Provider Instance
-	nvmlprovider.Initialize()
-	defer nvmlprovider.Client().Cleanup()
-
-	slog.Info("NVML provider successfully initialized!")
-
-	fillConfigMetricGroups(config)
+	slog.Info("Starting dcgm-exporter", slog.String("Version", version))
 
-	cs := getCounters(config)
+	if len(config.RemoteHETargets) > 0 {
+		return runFederation(config)
+	}
 
-	deviceWatchListManager := startDeviceWatchListManager(cs, config)
+	if config.HAStandby != "" && !runStandby(config) {
+		return nil
+	}
 
-	hostname, err := hostname.GetHostname(config)
+	coll, collCleanup, err := initCollection(config)
+	defer collCleanup()
 	if err != nil {
 		return err
 	}
 
-	cf := collector.InitCollectorFactory(cs, deviceWatchListManager, hostname, config)
+	bus := eventbus.New()
 
-	cRegistry := registry.NewRegistry()
-	for _, entityCollector := range cf.NewCollectors() {
-		cRegistry.Register(entityCollector)
+	if config.EmitKubernetesEvents {
+		stopEmitting, err := startEventEmitter(config, bus)
+		if err != nil {
+			return err
+		}
+		defer stopEmitting()
 	}
 
-	defer func() {
-		cRegistry.Cleanup()
-	}()
-
-	ch := make(chan string, 10)
+	stopCollectionTasks, err := startCollectionTasks(config, coll, bus)
+	defer func() { stopCollectionTasks() }()
+	if err != nil {
+		return err
+	}
 
 	var wg sync.WaitGroup
 	stop := make(chan interface{})
 
 	wg.Add(1)
 
-	server, cleanup, err := server.NewMetricsServer(config, ch, deviceWatchListManager, cRegistry)
+	server, cleanup, err := server.NewMetricsServer(config, coll.deviceWatchListManager, coll.registry,
+		coll.counterSet, bus)
 	defer cleanup()
 	if err != nil {
 		return err
 	}
 
+	publishStartupReport(version, config, coll)
+
 	go server.Run(stop, &wg)
 
+	if config.OTLPEndpoint != "" {
+		exporter, err := otlp.New(otlp.Options{
+			Endpoint: config.OTLPEndpoint,
+			Protocol: config.OTLPProtocol,
+			Interval: config.OTLPPushInterval,
+			Resource: config.OTLPResourceAttributes,
+			Version:  version,
+		})
+		if err != nil {
+			return err
+		}
+		pushCtx, stopPushing := context.WithCancel(context.Background())
+		defer stopPushing()
+		go exporter.Run(pushCtx, server)
+	}
+
+	if config.GRPCAddress != "" {
+		lis, err := grpcapi.Listen(config.GRPCAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen on the gRPC address '%s'; err: %w", config.GRPCAddress, err)
+		}
+		grpcCtx, stopServing := context.WithCancel(context.Background())
+		defer stopServing()
+		go grpcapi.New(bus.Collections).Run(grpcCtx, lis)
+	}
+
 	sigs := newOSWatcher(syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
-	sig := <-sigs
+
+	if config.ConfigBackend != "" {
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		go watchConfigBackend(watchCtx, config.ConfigBackend, sigs)
+	}
+
+	if config.CollectorsWatchInterval > 0 {
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		go watchCounters(watchCtx, config, sigs)
+	}
+
+	if config.TopologyWatchInterval > 0 {
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		go watchTopology(watchCtx, config.TopologyWatchInterval, sigs, bus)
+	}
+
+	// The groups and the field watches are created again without the GPUs being reset, and with them once their reset
+	// ended. A reload, which is pending already, does the same.
+	server.SetMaintenanceReload(func() {
+		select {
+		case sigs <- syscall.SIGHUP:
+		default:
+		}
+	})
+
+	updates := make(chan countersUpdate)
+	server.SetCountersUpdater(func(ctx context.Context, added [][]string, removed []string) error {
+		return requestCountersUpdate(ctx, updates, added, removed)
+	})
+
+loop:
+	for {
+		overrides := coll.overrides
+		var result chan<- error
+
+		select {
+		case sig := <-sigs:
+			if sig != syscall.SIGHUP {
+				break loop
+			}
+		case <-c.Context.Done():
+			// The exporter is stopped by the context of RunContext as well, when it is embedded
+			break loop
+		case update := <-updates:
+			overrides = coll.overrides.Update(update.added, update.removed)
+			result = update.result
+		case <-dcgmprovider.ConnectionLost():
+			// The last metrics are served, while the groups and the field watches are created again
+			stopCollectionTasks()
+			next := reconnectCollection(config, coll, sigs)
+			if next == nil {
+				break loop
+			}
+			// The lost collection is cleaned up, once it isn't served anymore. Its groups and field watches were lost
+			// with the connection, so they aren't released on the new one.
+			lost := coll.registry
+			server.SetCollection(next.deviceWatchListManager, next.registry, next.counterSet)
+			*coll = *next
+			lost.Cleanup()
+
+			stopCollectionTasks, err = startCollectionTasks(config, coll, bus)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// The new counters are applied, while the current ones keep being served
+		next, err := reloadCollection(config, coll, overrides)
+		if err != nil {
+			if result != nil {
+				result <- err
+			}
+			continue
+		}
+
+		stopCollectionTasks()
+		server.SetCollection(next.deviceWatchListManager, next.registry, next.counterSet)
+		coll.registry.Cleanup()
+		*coll = *next
+
+		stopCollectionTasks, err = startCollectionTasks(config, coll, bus)
+		if result != nil {
+			result <- err
+		}
+		if err != nil {
+			return err
+		}
+
+		publishStartupReport(version, config, coll)
+	}
+
 	close(stop)
+	stopCollectionTasks()
 	cancel()
 	err = utils.WaitWithTimeout(&wg, time.Second*2)
 	if err != nil {
@@ -386,89 +973,210 @@ restart:
 		fatal()
 	}
 
-	if sig == syscall.SIGHUP {
-		goto restart
-	}
-
 	return nil
 }
 
-func startDeviceWatchListManager(
-	cs *counters.CounterSet, config *appconfig.Config,
-) devicewatchlistmanager.Manager {
-	// Create a list containing DCGM Collector, Exp Collectors and all the label Collectors
-	var allCounters counters.CounterList
-	var deviceWatchListManager devicewatchlistmanager.Manager
+// startCollectionTasks starts the background tasks, which belong to the collection, and publishes its metrics on
+// the bus. The returned function stops them.
+func startCollectionTasks(config *appconfig.Config, coll *collection, bus *eventbus.Bus) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
 
-	allCounters = append(allCounters, cs.DCGMCounters...)
+	// The tasks keep their own references, so the collection can be swapped, while they are stopping
+	deviceWatchListManager, cRegistry := coll.deviceWatchListManager, coll.registry
 
-	allCounters = appendDCGMXIDErrorsCountDependency(allCounters, cs)
-	allCounters = appendDCGMClockEventsCountDependency(cs, allCounters)
+	waitForDiscovery := discoverPendingEntities(ctx, coll.pendingEntities, deviceWatchListManager,
+		coll.collectorFactory, cRegistry, int64(config.CollectInterval), bus)
+	// The registry is cleaned up after the tasks are stopped, so the discovery must not register into it anymore
+	stop := func() {
+		cancel()
+		waitForDiscovery()
+	}
 
-	deviceWatchListManager = devicewatchlistmanager.NewWatchListManager(allCounters, config)
-	deviceWatcher := devicewatcher.NewDeviceWatcher()
+	if !config.UseRemoteHE && !config.NVMLOnly {
+		go hostenginestats.Run(ctx, time.Duration(config.CollectInterval)*time.Millisecond, deviceWatchListManager)
+	}
 
-	for _, deviceType := range devicewatchlistmanager.DeviceTypesToWatch {
-		err := deviceWatchListManager.CreateEntityWatchList(deviceType, deviceWatcher, int64(config.CollectInterval))
+	if config.AlertRulesFile != "" {
+		evaluator, err := newAlertEvaluator(config, coll.counterSet)
 		if err != nil {
-			slog.Info(fmt.Sprintf("Not collecting %s metrics; %s", deviceType.String(), err))
+			return stop, err
 		}
+		go evaluator.Run(bus.Collections.Subscribe(ctx, 1))
+	}
+
+	go bus.PublishCollections(ctx, time.Duration(config.CollectInterval)*time.Millisecond, cRegistry.GatherWithin)
+
+	return stop, nil
+}
+
+func publishStartupReport(version string, config *appconfig.Config, coll *collection) {
+	report := newStartupReport(version, config, coll.counterSet, coll.deviceWatchListManager, coll.pendingEntities)
+	if !config.NoHostname {
+		report.Hostname = coll.hostname + " (" + coll.hostnameOrigin + ")"
 	}
-	return deviceWatchListManager
+	report.Log()
+	report.Publish()
 }
 
-// appendDCGMXIDErrorsCountDependency appends DCGM counters required for the DCGM_EXP_CLOCK_EVENTS_COUNT metric
-func appendDCGMClockEventsCountDependency(
-	cs *counters.CounterSet, allCounters []counters.Counter,
-) []counters.Counter {
-	if len(cs.ExporterCounters) > 0 {
-		if containsField(cs.ExporterCounters, counters.DCGMClockEventsCount) &&
-			!containsField(allCounters, dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS) {
-			allCounters = append(allCounters,
-				counters.Counter{
-					FieldID: dcgm.DCGM_FI_DEV_CLOCKS_EVENT_REASONS,
-				})
+// collection holds the components, which collect the metrics.
+type collection struct {
+	counterSet *counters.CounterSet
+	// overrides are the counters added and removed through the admin API, which the counter set includes
+	overrides              counters.Overrides
+	deviceWatchListManager devicewatchlistmanager.Manager
+	pendingEntities        []dcgm.Field_Entity_Group
+	collectorFactory       collector.Factory
+	registry               *registry.Registry
+	hostname               string
+	hostnameOrigin         string
+}
+
+// initCollection initializes DCGM and NVML, and registers the collectors. The returned function releases
+// the initialized resources and must be called even when an error is returned.
+func initCollection(config *appconfig.Config) (*collection, func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
 		}
 	}
-	return allCounters
-}
 
-// appendDCGMXIDErrorsCountDependency appends DCGM counters required for the DCGM_EXP_XID_ERRORS_COUNT metric
-func appendDCGMXIDErrorsCountDependency(
-	allCounters []counters.Counter, cs *counters.CounterSet,
-) []counters.Counter {
-	if len(cs.ExporterCounters) > 0 {
-		if containsField(cs.ExporterCounters, counters.DCGMXIDErrorsCount) &&
-			!containsField(allCounters, dcgm.DCGM_FI_DEV_XID_ERRORS) {
-			allCounters = append(allCounters,
-				counters.Counter{
-					FieldID: dcgm.DCGM_FI_DEV_XID_ERRORS,
-				})
+	config.CollectWorkers = cputuning.Tune(config.GoMaxProcs, config.CollectWorkers).CollectWorkers
+
+	if config.NVMLOnly {
+		// The NVML backend serves the DCGM interface from the NVML provider, so DCGM isn't loaded at all
+		nvmlprovider.Initialize()
+		cleanups = append(cleanups, func() { nvmlprovider.Client().Cleanup() })
+
+		dcgmprovider.Initialize(config)
+		cleanups = append(cleanups, func() { dcgmprovider.Client().Cleanup() })
+
+		slog.Info("NVML backend successfully initialized!")
+	} else {
+		err := prerequisites.Validate()
+		if err != nil {
+			return nil, cleanup, err
 		}
+
+		// Initialize DCGM Provider Instance
+		dcgmprovider.Initialize(config)
+		cleanups = append(cleanups, func() { dcgmprovider.Client().Cleanup() })
+
+		slog.Info("DCGM successfully initialized!")
+		if config.UseRemoteHE {
+			hostengineConnected.Set(1)
+		}
+
+		// Initialize NVML Provider Instance
+		nvmlprovider.Initialize()
+		cleanups = append(cleanups, func() { nvmlprovider.Client().Cleanup() })
+
+		slog.Info("NVML provider successfully initialized!")
 	}
-	return allCounters
+
+	fillConfigMetricGroups(config)
+
+	coll, err := newCollection(config, getCounters(config))
+	if err != nil {
+		return nil, cleanup, err
+	}
+	// The collection may be swapped in place on a reload, so the registry is looked up when cleaning up
+	cleanups = append(cleanups, func() { coll.registry.Cleanup() })
+
+	return coll, cleanup, nil
 }
 
-func containsField(slice []counters.Counter, fieldID counters.ExporterCounter) bool {
-	return slices.ContainsFunc(slice, func(counter counters.Counter) bool {
-		return counter.FieldID == dcgm.Short(fieldID)
-	})
+// newCollection watches the fields of the counters and registers their collectors.
+func newCollection(config *appconfig.Config, cs *counters.CounterSet) (*collection, error) {
+	// The excluded entities are neither watched nor collected
+	devicemonitoring.SetExclusions(config.ExcludedEntities)
+
+	deviceWatchListManager, pendingEntities := startDeviceWatchListManager(cs, config)
+
+	hostname, hostnameOrigin, err := hostname.Resolve(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cf := collector.InitCollectorFactory(cs, deviceWatchListManager, hostname, config)
+
+	cRegistry := registry.NewRegistry()
+	cRegistry.SetWorkers(config.CollectWorkers)
+	for _, entityCollector := range cf.NewCollectors() {
+		cRegistry.Register(entityCollector)
+	}
+
+	return &collection{
+		counterSet:             cs,
+		deviceWatchListManager: deviceWatchListManager,
+		pendingEntities:        pendingEntities,
+		collectorFactory:       cf,
+		registry:               cRegistry,
+		hostname:               hostname,
+		hostnameOrigin:         hostnameOrigin,
+	}, nil
+}
+
+func startDeviceWatchListManager(
+	cs *counters.CounterSet, config *appconfig.Config,
+) (devicewatchlistmanager.Manager, []dcgm.Field_Entity_Group) {
+	// Create a list containing DCGM Collector, Exp Collectors and all the label Collectors
+	allCounters := cs.WatchedCounters()
+
+	deviceWatchListManager := devicewatchlistmanager.NewWatchListManager(allCounters, config)
+	deviceWatcher := devicewatcher.NewDeviceWatcher()
+
+	// Entity types, which are not available yet, but may appear later
+	var pending []dcgm.Field_Entity_Group
+
+	for _, deviceType := range devicewatchlistmanager.DeviceTypesToWatch {
+		err := deviceWatchListManager.CreateEntityWatchList(deviceType, deviceWatcher, int64(config.CollectInterval))
+		if err != nil {
+			if isRetryableDiscoveryError(deviceType, err) &&
+				len(deviceWatcher.GetDeviceFields(allCounters, deviceType)) > 0 {
+				slog.Info(fmt.Sprintf("%s metrics are not collected yet; will retry discovery; %s",
+					deviceType.String(), err))
+				entityDiscoveryStatus.Set(0, "entity", deviceType.String())
+				pending = append(pending, deviceType)
+				continue
+			}
+			slog.Info(fmt.Sprintf("Not collecting %s metrics; %s", deviceType.String(), err))
+		}
+	}
+	return deviceWatchListManager, pending
 }
 
 func getCounters(config *appconfig.Config) *counters.CounterSet {
-	cs, err := counters.GetCounterSet(config)
+	cs, err := loadCounters(config, counters.Overrides{})
 	if err != nil {
 		slog.Error(err.Error())
 		os.Exit(1)
 	}
+	return cs
+}
 
-	// Copy labels from DCGM Counters to ExporterCounters
-	for i := range cs.DCGMCounters {
-		if cs.DCGMCounters[i].PromType == "label" {
-			cs.ExporterCounters = append(cs.ExporterCounters, cs.DCGMCounters[i])
-		}
+// loadCounters reads the counters from the counters file, the ConfigMap or the configuration backend, and applies
+// the overrides of the admin API.
+func loadCounters(config *appconfig.Config, overrides counters.Overrides) (*counters.CounterSet, error) {
+	var (
+		cs  *counters.CounterSet
+		err error
+	)
+	if config.ConfigBackend != "" {
+		cs, err = loadBackendCounters(config)
+	} else {
+		cs, err = counters.GetCounterSet(config)
 	}
-	return cs
+	if err != nil {
+		return nil, err
+	}
+
+	if err := overrides.Apply(cs, config); err != nil {
+		return nil, fmt.Errorf("failed to apply the counters of the admin API; err: %w", err)
+	}
+
+	cs.CopyLabelCounters()
+	return cs, nil
 }
 
 func fillConfigMetricGroups(config *appconfig.Config) {
@@ -483,26 +1191,34 @@ func fillConfigMetricGroups(config *appconfig.Config) {
 	}
 }
 
-func enableDebugLogging(config *appconfig.Config) {
+// configureLogging sets the log format and level of the configuration. The debug mode overrides the level.
+func configureLogging(config *appconfig.Config) error {
+	level := config.LogLevel
+	if config.Debug {
+		level = LevelDebug
+	}
+
+	if err := Configure(config.LogFormat, level, os.Stderr); err != nil {
+		return err
+	}
+
 	if config.Debug {
-		// enable debug logging
-		slog.SetLogLoggerLevel(slog.LevelDebug)
 		slog.Debug("Debug output is enabled")
 	}
 
 	slog.Debug(fmt.Sprintf("Command line: %s", strings.Join(os.Args, " ")))
 
 	slog.Debug("Loaded configuration", slog.String(DumpKey, fmt.Sprintf("%+v", config)))
+
+	return nil
 }
 
 func parseDeviceOptions(devices string) (appconfig.DeviceOptions, error) {
 	var dOpt appconfig.DeviceOptions
 
-	letterAndRange := strings.Split(devices, ":")
+	// PCI bus IDs contain colons themselves, so only the first one separates the range
+	letterAndRange := strings.SplitN(devices, ":", 2)
 	count := len(letterAndRange)
-	if count > 2 {
-		return dOpt, fmt.Errorf("Invalid ranged device option '%s': there can only be one specified range", devices)
-	}
 
 	letter := letterAndRange[0]
 	if letter == FlexKey {
@@ -518,6 +1234,15 @@ func parseDeviceOptions(devices string) (appconfig.DeviceOptions, error) {
 		} else {
 			numbers := strings.Split(letterAndRange[1], ",")
 			for _, numberOrRange := range numbers {
+				if isGPUIdentifier(numberOrRange) {
+					if letter != MajorKey {
+						return dOpt, fmt.Errorf("only GPUs can be specified by UUID or PCI bus ID, but found '%s'",
+							numberOrRange)
+					}
+					dOpt.MajorIdentifiers = append(dOpt.MajorIdentifiers, numberOrRange)
+					continue
+				}
+
 				rangeTokens := strings.Split(numberOrRange, "-")
 				rangeTokenCount := len(rangeTokens)
 				if rangeTokenCount > 2 {
@@ -558,11 +1283,61 @@ func parseDeviceOptions(devices string) (appconfig.DeviceOptions, error) {
 	return dOpt, nil
 }
 
+// parseEntityExclusions parses the excluded entities, <type>:<id> separated by semicolons, e.g. gpu:0;link:*. GPUs
+// are identified by index, UUID or PCI bus ID, NVLinks by <switch>/<port>, and the other entities by their entity ID.
+func parseEntityExclusions(entities string) ([]appconfig.EntityExclusion, error) {
+	var exclusions []appconfig.EntityExclusion
+
+	for _, entity := range strings.Split(entities, ";") {
+		entity = strings.TrimSpace(entity)
+		if entity == "" {
+			continue
+		}
+
+		// PCI bus IDs contain colons themselves, so only the first one separates the type
+		entityType, id, found := strings.Cut(entity, ":")
+		if !found || id == "" {
+			return nil, fmt.Errorf("excluded entity must be '<type>:<id>', but found '%s'", entity)
+		}
+		group, exists := exclusionEntityGroups[strings.ToLower(entityType)]
+		if !exists {
+			return nil, fmt.Errorf("unknown type of excluded entity '%s'", entity)
+		}
+
+		valid := id == devicemonitoring.AllEntities
+		switch {
+		case valid:
+		case group == dcgm.FE_GPU:
+			_, err := strconv.ParseUint(id, 10, 32)
+			valid = err == nil || isGPUIdentifier(id)
+		case group == dcgm.FE_LINK:
+			valid = linkIDRegex.MatchString(id)
+		default:
+			_, err := strconv.ParseUint(id, 10, 32)
+			valid = err == nil
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid ID of excluded entity '%s'", entity)
+		}
+
+		exclusions = append(exclusions, appconfig.EntityExclusion{EntityGroup: group, ID: id})
+	}
+
+	return exclusions, nil
+}
+
+// isGPUIdentifier reports whether a device of the device options is a GPU UUID, e.g. GPU-8a2b..., or a PCI bus ID,
+// e.g. 0000:3b:00.0, rather than an index or a range of indices.
+func isGPUIdentifier(device string) bool {
+	return strings.HasPrefix(device, gpuUUIDPrefix) || pciBusIDRegex.MatchString(device)
+}
+
 func contextToConfig(c *cli.Context) (*appconfig.Config, error) {
 	gOpt, err := parseDeviceOptions(c.String(CLIGPUDevices))
 	if err != nil {
 		return nil, err
 	}
+	gOpt.ComputeInstances = c.Bool(CLIComputeInstanceMetrics)
 
 	sOpt, err := parseDeviceOptions(c.String(CLISwitchDevices))
 	if err != nil {
@@ -574,11 +1349,238 @@ func contextToConfig(c *cli.Context) (*appconfig.Config, error) {
 		return nil, err
 	}
 
+	if len(sOpt.MajorIdentifiers) > 0 || len(cOpt.MajorIdentifiers) > 0 {
+		return nil, fmt.Errorf("only GPUs can be specified by UUID or PCI bus ID, in the %s parameter",
+			CLIGPUDevices)
+	}
+
+	excludedEntities, err := parseEntityExclusions(c.String(CLIExcludeEntities))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s parameter value: %w", CLIExcludeEntities, err)
+	}
+
 	dcgmLogLevel := c.String(CLIDCGMLogLevel)
 	if !slices.Contains(DCGMDbgLvlValues, dcgmLogLevel) {
 		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIDCGMLogLevel, dcgmLogLevel)
 	}
 
+	gpuInstanceMetrics := appconfig.GPUInstanceMetricsMode(c.String(CLIGPUInstanceMetrics))
+	if !slices.Contains(GPUInstanceMetricsValues, gpuInstanceMetrics) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIGPUInstanceMetrics, gpuInstanceMetrics)
+	}
+
+	minCollectInterval := c.Int(CLIMinCollectInterval)
+	maxCollectInterval := c.Int(CLIMaxCollectInterval)
+	if minCollectInterval <= 0 || minCollectInterval > maxCollectInterval {
+		return nil, fmt.Errorf("invalid %s and %s parameter values: %d, %d", CLIMinCollectInterval,
+			CLIMaxCollectInterval, minCollectInterval, maxCollectInterval)
+	}
+
+	kubeletAPIURL := c.String(CLIKubeletAPIURL)
+	if kubeletAPIURL != "" && !strings.HasPrefix(kubeletAPIURL, "http://") && !strings.HasPrefix(kubeletAPIURL, "https://") {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIKubeletAPIURL, kubeletAPIURL)
+	}
+
+	alertWebhookURL := c.String(CLIAlertWebhookURL)
+	if alertWebhookURL != "" && !strings.HasPrefix(alertWebhookURL, "http://") && !strings.HasPrefix(alertWebhookURL, "https://") {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIAlertWebhookURL, alertWebhookURL)
+	}
+
+	var remoteHETargets []string
+	if remoteHEInfo := c.String(CLIRemoteHEInfo); strings.Contains(remoteHEInfo, ",") {
+		for _, target := range strings.Split(remoteHEInfo, ",") {
+			target = strings.TrimSpace(target)
+			if target == "" {
+				return nil, fmt.Errorf("invalid %s parameter value: %s", CLIRemoteHEInfo, remoteHEInfo)
+			}
+			remoteHETargets = append(remoteHETargets, target)
+		}
+	}
+
+	logFormat := c.String(CLILogFormat)
+	if !slices.Contains(Formats, logFormat) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLILogFormat, logFormat)
+	}
+
+	logLevel := c.String(CLILogLevel)
+	if !slices.Contains(Levels, logLevel) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLILogLevel, logLevel)
+	}
+
+	metricPrefix := c.String(CLIMetricPrefix)
+	if metricPrefix != "" && !model.IsValidMetricName(model.LabelValue(metricPrefix)) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIMetricPrefix, metricPrefix)
+	}
+
+	hostengineLabel := c.String(CLIHostengineLabel)
+	if hostengineLabel != "" && !model.LabelName(hostengineLabel).IsValidLegacy() {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIHostengineLabel, hostengineLabel)
+	}
+
+	downsampleWindow := c.Duration(CLIDownsampleWindow)
+	if downsampleWindow < time.Duration(c.Int(CLICollectInterval))*time.Millisecond {
+		return nil, fmt.Errorf("invalid %s parameter value: %s, it must not be shorter than the collect interval",
+			CLIDownsampleWindow, downsampleWindow)
+	}
+
+	hostnameSource := appconfig.HostnameSource(c.String(CLIHostnameSource))
+	if !slices.Contains(HostnameSourceValues, hostnameSource) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIHostnameSource, hostnameSource)
+	}
+
+	idleCounters := c.StringSlice(CLIIdleCounters)
+	if len(idleCounters) > 0 && !c.Bool(CLIKubernetes) {
+		return nil, fmt.Errorf("%s requires %s", CLIIdleCounters, CLIKubernetes)
+	}
+
+	idleAfter := c.Duration(CLIIdleAfter)
+	if idleAfter <= 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIIdleAfter, idleAfter)
+	}
+
+	podResourcesCacheTTL := c.Duration(CLIPodResourcesCacheTTL)
+	if podResourcesCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIPodResourcesCacheTTL, podResourcesCacheTTL)
+	}
+
+	staleMetricsMaxAge := c.Duration(CLIStaleMetricsMaxAge)
+	if staleMetricsMaxAge < 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIStaleMetricsMaxAge, staleMetricsMaxAge)
+	}
+
+	scrapeCacheTTL := c.Duration(CLIScrapeCacheTTL)
+	if scrapeCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIScrapeCacheTTL, scrapeCacheTTL)
+	}
+
+	haStandby := c.String(CLIHAStandby)
+	if haStandby != "" {
+		if !strings.HasPrefix(haStandby, "http://") && !strings.HasPrefix(haStandby, "https://") {
+			return nil, fmt.Errorf("invalid %s parameter value: %s", CLIHAStandby, haStandby)
+		}
+		if c.Duration(CLIHAStandbyProbeInterval) <= 0 {
+			return nil, fmt.Errorf("invalid %s parameter value: %s", CLIHAStandbyProbeInterval,
+				c.Duration(CLIHAStandbyProbeInterval))
+		}
+		if c.Int(CLIHAStandbyFailures) < 1 {
+			return nil, fmt.Errorf("invalid %s parameter value: %d", CLIHAStandbyFailures, c.Int(CLIHAStandbyFailures))
+		}
+		if len(remoteHETargets) > 0 {
+			return nil, fmt.Errorf("the %s parameter can't be used with several remote hostengines", CLIHAStandby)
+		}
+	}
+
+	kubernetesProxyURL := c.String(CLIKubernetesProxyURL)
+	if kubernetesProxyURL != "" {
+		u, err := url.Parse(kubernetesProxyURL)
+		if err != nil || !slices.Contains([]string{"http", "https", "socks5"}, u.Scheme) || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s parameter value: %s", CLIKubernetesProxyURL, kubernetesProxyURL)
+		}
+	}
+
+	kubernetesNodeLabels := c.StringSlice(CLIKubernetesNodeLabels)
+	if len(kubernetesNodeLabels) > 0 && os.Getenv(hostname.OriginNodeName) == "" {
+		return nil, fmt.Errorf("the %s parameter requires the %s environment variable",
+			CLIKubernetesNodeLabels, hostname.OriginNodeName)
+	}
+
+	if c.Bool(CLIEmitK8sEvents) && os.Getenv(hostname.OriginNodeName) == "" {
+		return nil, fmt.Errorf("the %s parameter requires the %s environment variable",
+			CLIEmitK8sEvents, hostname.OriginNodeName)
+	}
+
+	if c.Bool(CLIDRAResourceSlices) && !c.Bool(CLIKubernetes) {
+		return nil, fmt.Errorf("the %s parameter requires the %s parameter", CLIDRAResourceSlices, CLIKubernetes)
+	}
+
+	if c.Bool(CLIWatchPodDeletions) && !c.Bool(CLIKubernetes) {
+		return nil, fmt.Errorf("the %s parameter requires the %s parameter", CLIWatchPodDeletions, CLIKubernetes)
+	}
+
+	if c.Bool(CLIWatchPodDeletions) && os.Getenv(hostname.OriginNodeName) == "" {
+		return nil, fmt.Errorf("the %s parameter requires the %s environment variable",
+			CLIWatchPodDeletions, hostname.OriginNodeName)
+	}
+
+	if c.Bool(CLIPodAttributionCgroups) && !c.Bool(CLIKubernetes) {
+		return nil, fmt.Errorf("the %s parameter requires the %s parameter", CLIPodAttributionCgroups, CLIKubernetes)
+	}
+
+	if c.Bool(CLINVMLOnly) && c.IsSet(CLIRemoteHEInfo) {
+		return nil, fmt.Errorf("the %s parameter can't be used with the %s parameter", CLINVMLOnly, CLIRemoteHEInfo)
+	}
+
+	if c.Bool(CLIDiagEndpoint) && c.String(CLIAdminTokenFile) == "" {
+		return nil, fmt.Errorf("the %s parameter requires the %s parameter", CLIDiagEndpoint, CLIAdminTokenFile)
+	}
+
+	if len(c.StringSlice(CLIMetricsAllowedClientCNs)) > 0 && c.String(CLIWebConfigFile) == "" {
+		return nil, fmt.Errorf("the %s parameter requires the %s parameter", CLIMetricsAllowedClientCNs,
+			CLIWebConfigFile)
+	}
+
+	if c.Bool(CLIDiagEndpoint) && c.Bool(CLINVMLOnly) {
+		return nil, fmt.Errorf("the %s parameter can't be used with the %s parameter", CLIDiagEndpoint, CLINVMLOnly)
+	}
+
+	// The pod resources API doesn't report the UIDs of the pods, nor the IDs of the containers
+	if c.Bool(CLIOpenMetricsExemplars) && (!c.Bool(CLIKubernetes) || kubeletAPIURL == "") {
+		return nil, fmt.Errorf("the %s parameter requires the %s and %s parameters",
+			CLIOpenMetricsExemplars, CLIKubernetes, CLIKubeletAPIURL)
+	}
+
+	for _, name := range []string{CLIGoMaxProcs, CLICollectWorkers} {
+		if c.Int(name) < 0 {
+			return nil, fmt.Errorf("invalid %s parameter value: %d", name, c.Int(name))
+		}
+	}
+
+	dmonColumns := c.StringSlice(CLIDmonColumns)
+	if _, err := dmon.ParseColumns(dmonColumns); err != nil {
+		return nil, fmt.Errorf("invalid %s parameter value: %w", CLIDmonColumns, err)
+	}
+
+	configBackend := c.String(CLIConfigBackend)
+	if configBackend != "" {
+		if c.String(CLIConfigMapData) != undefinedConfigMapData {
+			return nil, fmt.Errorf("%s can not be used with %s", CLIConfigBackend, CLIConfigMapData)
+		}
+		if _, err := configbackend.New(configBackend); err != nil {
+			return nil, fmt.Errorf("invalid %s parameter value: %s; err: %w", CLIConfigBackend, configBackend, err)
+		}
+	}
+
+	otlpProtocol := c.String(CLIOTLPProtocol)
+	if !slices.Contains(otlp.Protocols, otlpProtocol) {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIOTLPProtocol, otlpProtocol)
+	}
+
+	otlpPushInterval := c.Duration(CLIOTLPPushInterval)
+	if otlpPushInterval <= 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLIOTLPPushInterval, otlpPushInterval)
+	}
+
+	otlpResourceAttributes := c.StringSlice(CLIOTLPResourceAttributes)
+	if _, err := otlp.ParseAttributes(otlpResourceAttributes); err != nil {
+		return nil, fmt.Errorf("invalid %s parameter value: %w", CLIOTLPResourceAttributes, err)
+	}
+
+	collectorsWatchInterval := c.Duration(CLICollectorsWatchInterval)
+	if collectorsWatchInterval < 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLICollectorsWatchInterval, collectorsWatchInterval)
+	}
+	if collectorsWatchInterval > 0 && configBackend != "" {
+		return nil, fmt.Errorf("%s can not be used with %s", CLICollectorsWatchInterval, CLIConfigBackend)
+	}
+
+	topologyWatchInterval := c.Duration(CLITopologyWatchInterval)
+	if topologyWatchInterval < 0 {
+		return nil, fmt.Errorf("invalid %s parameter value: %s", CLITopologyWatchInterval, topologyWatchInterval)
+	}
+	if topologyWatchInterval > 0 && c.Bool(CLINVMLOnly) {
+		return nil, fmt.Errorf("the %s parameter can't be used with the %s parameter", CLITopologyWatchInterval, CLINVMLOnly)
+	}
+
 	return &appconfig.Config{
 		CollectorsFile:             c.String(CLIFieldsFile),
 		Address:                    c.String(CLIAddress),
@@ -589,9 +1591,11 @@ func contextToConfig(c *cli.Context) (*appconfig.Config, error) {
 		UseOldNamespace:            c.Bool(CLIUseOldNamespace),
 		UseRemoteHE:                c.IsSet(CLIRemoteHEInfo),
 		RemoteHEInfo:               c.String(CLIRemoteHEInfo),
+		RemoteHETargets:            remoteHETargets,
 		GPUDeviceOptions:           gOpt,
 		SwitchDeviceOptions:        sOpt,
 		CPUDeviceOptions:           cOpt,
+		ExcludedEntities:           excludedEntities,
 		NoHostname:                 c.Bool(CLINoHostname),
 		UseFakeGPUs:                c.Bool(CLIUseFakeGPUs),
 		ConfigMapData:              c.String(CLIConfigMapData),
@@ -606,5 +1610,77 @@ func contextToConfig(c *cli.Context) (*appconfig.Config, error) {
 		PodResourcesKubeletSocket:  c.String(CLIPodResourcesKubeletSocket),
 		HPCJobMappingDir:           c.String(CLIHPCJobMappingDir),
 		NvidiaResourceNames:        c.StringSlice(CLINvidiaResourceNames),
+		CollectEncoderDecoder:      c.Bool(CLIEnableEncoderDecoder),
+		CollectProcessTypes:        c.Bool(CLIEnableProcessTypes),
+		CollectDriverState:         c.Bool(CLICollectDriverState),
+		NVMLFallback:               c.Bool(CLINVMLFallback),
+		NVMLOnly:                   c.Bool(CLINVMLOnly),
+		PodResourcesTimeout:        c.Duration(CLIPodResourcesTimeout),
+		PodResourcesRefresh:        c.Duration(CLIPodResourcesRefresh),
+		PodResourcesCacheTTL:       podResourcesCacheTTL,
+		GPUInstanceMetrics:         gpuInstanceMetrics,
+		MIGUUIDLabel:               c.Bool(CLIMIGUUIDLabel),
+		AllowedSourceCIDRs:         c.StringSlice(CLIAllowedSourceCIDRs),
+		MetricsTokenFile:           c.String(CLIMetricsTokenFile),
+		MetricsAllowedClientCNs:    c.StringSlice(CLIMetricsAllowedClientCNs),
+		AdaptiveCollectInterval:    c.Bool(CLIAdaptiveCollectInterval),
+		MinCollectInterval:         minCollectInterval,
+		MaxCollectInterval:         maxCollectInterval,
+		KubeletAPIURL:              kubeletAPIURL,
+		KubeletAPITokenFile:        c.String(CLIKubeletAPITokenFile),
+		KubeletAPICAFile:           c.String(CLIKubeletAPICAFile),
+		KubeletAPIInsecure:         c.Bool(CLIKubeletAPIInsecure),
+		PodAttributionSource:       c.Bool(CLIPodAttributionSource),
+		PodAttributionCgroups:      c.Bool(CLIPodAttributionCgroups),
+		AlertRulesFile:             c.String(CLIAlertRulesFile),
+		AlertWebhookURL:            alertWebhookURL,
+		HostengineLabel:            hostengineLabel,
+		AllocationEfficiency:       c.Bool(CLIAllocationEfficiency),
+		PodAggregates:              c.Bool(CLIPodAggregates),
+		DownsampleCounters:         c.StringSlice(CLIDownsampleCounters),
+		DownsampleWindow:           downsampleWindow,
+		HostnameSource:             hostnameSource,
+		IdleCounters:               idleCounters,
+		IdleAfter:                  idleAfter,
+		KubeConfig:                 c.String(CLIKubeConfig),
+		KubernetesCAFile:           c.String(CLIKubernetesCAFile),
+		KubernetesProxyURL:         kubernetesProxyURL,
+		KubernetesNodeLabels:       kubernetesNodeLabels,
+		EmitKubernetesEvents:       c.Bool(CLIEmitK8sEvents),
+		DRAResourceSlices:          c.Bool(CLIDRAResourceSlices),
+		WatchPodDeletions:          c.Bool(CLIWatchPodDeletions),
+		OpenMetricsExemplars:       c.Bool(CLIOpenMetricsExemplars),
+		GoMaxProcs:                 c.Int(CLIGoMaxProcs),
+		CollectWorkers:             c.Int(CLICollectWorkers),
+		DmonColumns:                dmonColumns,
+		ConfigBackend:              configBackend,
+		AdminTokenFile:             c.String(CLIAdminTokenFile),
+		DiagEndpoint:               c.Bool(CLIDiagEndpoint),
+		PodAttributionGPUInstances: c.Bool(CLIPodAttributionGPUInstances),
+		StaleMetricsMaxAge:         staleMetricsMaxAge,
+		ScrapeCacheTTL:             scrapeCacheTTL,
+		HAStandby:                  haStandby,
+		HAStandbyProbeInterval:     c.Duration(CLIHAStandbyProbeInterval),
+		HAStandbyFailures:          c.Int(CLIHAStandbyFailures),
+		CollectorsWatchInterval:    collectorsWatchInterval,
+		TopologyWatchInterval:      topologyWatchInterval,
+		OTLPEndpoint:               c.String(CLIOTLPEndpoint),
+		OTLPProtocol:               otlpProtocol,
+		OTLPPushInterval:           otlpPushInterval,
+		OTLPResourceAttributes:     otlpResourceAttributes,
+		GRPCAddress:                c.String(CLIGRPCAddress),
+		SkipEntityOnError:          c.Bool(CLISkipEntityOnError),
+		MetricPrefix:               metricPrefix,
+		LogFormat:                  logFormat,
+		LogLevel:                   logLevel,
+		Version:                    appVersion(c),
 	}, nil
 }
+
+// appVersion returns the version of the exporter, which is set at build time.
+func appVersion(c *cli.Context) string {
+	if c == nil || c.App == nil {
+		return ""
+	}
+	return c.App.Version
+}
@@ -188,7 +188,7 @@ func Test_getDeviceWatchListManager(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := startDeviceWatchListManager(tt.counterSet, config)
+			got, _ := startDeviceWatchListManager(tt.counterSet, config)
 			if tt.assertion == nil {
 				t.Skip(tt.name)
 			}
@@ -196,3 +196,108 @@ func Test_getDeviceWatchListManager(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseDeviceOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices string
+		want    appconfig.DeviceOptions
+		wantErr bool
+	}{
+		{
+			name:    "GPU indices",
+			devices: "g:0,2-3",
+			want:    appconfig.DeviceOptions{MajorRange: []int{0, 2, 3}},
+		},
+		{
+			name:    "GPU UUIDs and PCI bus IDs",
+			devices: "g:1,GPU-8a2b0c1d,0000:3b:00.0,5e:00.0",
+			want: appconfig.DeviceOptions{
+				MajorRange:       []int{1},
+				MajorIdentifiers: []string{"GPU-8a2b0c1d", "0000:3b:00.0", "5e:00.0"},
+			},
+		},
+		{
+			name:    "GPU instances by UUID",
+			devices: "i:GPU-8a2b0c1d",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDeviceOptions(tt.devices)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseEntityExclusions(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities string
+		want     []appconfig.EntityExclusion
+		wantErr  string
+	}{
+		{
+			name:     "No exclusions",
+			entities: "",
+		},
+		{
+			name:     "GPUs and NVLinks",
+			entities: "gpu:GPU-8a2b0c1d; gpu:0000:3b:00.0;gpu:2;link:*;link:0/12",
+			want: []appconfig.EntityExclusion{
+				{EntityGroup: dcgm.FE_GPU, ID: "GPU-8a2b0c1d"},
+				{EntityGroup: dcgm.FE_GPU, ID: "0000:3b:00.0"},
+				{EntityGroup: dcgm.FE_GPU, ID: "2"},
+				{EntityGroup: dcgm.FE_LINK, ID: "*"},
+				{EntityGroup: dcgm.FE_LINK, ID: "0/12"},
+			},
+		},
+		{
+			name:     "Other entities",
+			entities: "switch:1;cpu:0;cpu_core:*;vgpu:7",
+			want: []appconfig.EntityExclusion{
+				{EntityGroup: dcgm.FE_SWITCH, ID: "1"},
+				{EntityGroup: dcgm.FE_CPU, ID: "0"},
+				{EntityGroup: dcgm.FE_CPU_CORE, ID: "*"},
+				{EntityGroup: dcgm.FE_VGPU, ID: "7"},
+			},
+		},
+		{
+			name:     "Missing ID",
+			entities: "gpu",
+			wantErr:  "excluded entity must be '<type>:<id>', but found 'gpu'",
+		},
+		{
+			name:     "Unknown type",
+			entities: "nic:0",
+			wantErr:  "unknown type of excluded entity 'nic:0'",
+		},
+		{
+			name:     "NVLink without switch",
+			entities: "link:12",
+			wantErr:  "invalid ID of excluded entity 'link:12'",
+		},
+		{
+			name:     "Switch by name",
+			entities: "switch:first",
+			wantErr:  "invalid ID of excluded entity 'switch:first'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEntityExclusions(tt.entities)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
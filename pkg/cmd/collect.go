@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/server"
+)
+
+const (
+	CLICollectCommand = "collect"
+	CLICollectOnce    = "once"
+)
+
+// metricsWriter writes the collected metrics in the Prometheus text format.
+type metricsWriter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// metricsWriterFunc adapts a function to the metricsWriter interface.
+type metricsWriterFunc func(w io.Writer) error
+
+func (f metricsWriterFunc) WriteMetrics(w io.Writer) error {
+	return f(w)
+}
+
+func newCollectCommand() *cli.Command {
+	return &cli.Command{
+		Name: CLICollectCommand,
+		Usage: "Collect metrics and print them in the Prometheus text format to stdout. " +
+			"The exporter options must be specified before the command, e.g. 'dcgm-exporter -f counters.csv collect --once'",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  CLICollectOnce,
+				Value: false,
+				Usage: "Perform a single collection and exit. The exit status is non-zero, when the collection fails.",
+			},
+		},
+		Action: collectAction,
+	}
+}
+
+func collectAction(c *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Encountered a failure.", slog.String(StackTrace, string(debug.Stack())))
+			err = fmt.Errorf("encountered a failure; err: %v", r)
+		}
+	}()
+
+	config, err := contextToConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if err := configureLogging(config); err != nil {
+		return err
+	}
+
+	out, restoreStdout, err := redirectStdout()
+	if err != nil {
+		return err
+	}
+	defer restoreStdout()
+
+	coll, collCleanup, err := initCollection(config)
+	defer collCleanup()
+	if err != nil {
+		return err
+	}
+
+	metricsServer, cleanup, err := server.NewMetricsServer(config, coll.deviceWatchListManager, coll.registry,
+		coll.counterSet, nil)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(CLICollectOnce) {
+		// A single collection waits for every collector, instead of leaving out the ones, which don't finish within
+		// the scrape deadline, as they have no earlier metrics to be served from
+		return collectOnce(out, metricsWriterFunc(metricsServer.WriteCompleteMetrics))
+	}
+
+	ticker := time.NewTicker(time.Duration(config.CollectInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	sigs := newOSWatcher(syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	for {
+		if err := collectOnce(out, metricsServer); err != nil {
+			slog.Error("Failed to collect metrics", slog.String(ErrorKey, err.Error()))
+		}
+
+		select {
+		case <-sigs:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectOnce forces DCGM to update the watched fields and writes the collected metrics.
+func collectOnce(w io.Writer, mw metricsWriter) error {
+	err := dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return fmt.Errorf("failed to update DCGM fields; err: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = mw.WriteMetrics(&buf)
+	if err != nil {
+		return fmt.Errorf("failed to collect metrics; err: %w", err)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStdout reserves the original stdout for the collected metrics, because DCGM and NVML may print
+// to stdout. Anything else written to stdout goes to stderr until the returned function is called.
+func redirectStdout() (*os.File, func(), error) {
+	fd, err := syscall.Dup(syscall.Stdout)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	err = syscall.Dup3(syscall.Stderr, syscall.Stdout, 0)
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, func() {}, err
+	}
+
+	out := os.NewFile(uintptr(fd), "stdout")
+
+	return out, func() {
+		_ = syscall.Dup3(fd, syscall.Stdout, 0)
+		_ = out.Close()
+	}, nil
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+)
+
+type fakeMetricsWriter struct {
+	metrics string
+	err     error
+}
+
+func (f fakeMetricsWriter) WriteMetrics(w io.Writer) error {
+	_, _ = io.WriteString(w, f.metrics)
+	return f.err
+}
+
+func Test_collectOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		updateErr error
+		writer    fakeMetricsWriter
+		want      string
+		wantErr   string
+	}{
+		{
+			name:   "Metrics are written",
+			writer: fakeMetricsWriter{metrics: "DCGM_FI_DEV_GPU_TEMP{gpu=\"0\"} 42\n"},
+			want:   "DCGM_FI_DEV_GPU_TEMP{gpu=\"0\"} 42\n",
+		},
+		{
+			name:      "Update failure",
+			updateErr: errors.New("connection lost"),
+			writer:    fakeMetricsWriter{metrics: "unexpected"},
+			wantErr:   "failed to update DCGM fields; err: connection lost",
+		},
+		{
+			name:    "Partial output is not written on collection failure",
+			writer:  fakeMetricsWriter{metrics: "partial", err: errors.New("collector failed")},
+			wantErr: "failed to collect metrics; err: collector failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+			mockDCGM.EXPECT().UpdateAllFields().Return(tt.updateErr)
+
+			realDCGM := dcgmprovider.Client()
+			defer dcgmprovider.SetClient(realDCGM)
+			dcgmprovider.SetClient(mockDCGM)
+
+			var out bytes.Buffer
+			err := collectOnce(&out, tt.writer)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
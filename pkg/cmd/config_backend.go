@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/configbackend"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+const (
+	configBackendTimeout    = 30 * time.Second
+	configBackendRetryDelay = 10 * time.Second
+)
+
+// loadBackendCounters reads the counters and the device options from the configuration backend. The device
+// options, which are set in the backend, replace the ones of the parameters.
+func loadBackendCounters(config *appconfig.Config) (*counters.CounterSet, error) {
+	backend, err := configbackend.New(config.ConfigBackend)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), configBackendTimeout)
+	defer cancel()
+
+	data, err := backend.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration backend '%s'; err: %w", config.ConfigBackend, err)
+	}
+
+	gOpt, sOpt, cOpt := config.GPUDeviceOptions, config.SwitchDeviceOptions, config.CPUDeviceOptions
+	for _, option := range []struct {
+		key   string
+		value string
+		opt   *appconfig.DeviceOptions
+	}{
+		{key: configbackend.GPUDevicesKey, value: data.GPUDevices, opt: &gOpt},
+		{key: configbackend.SwitchDevicesKey, value: data.SwitchDevices, opt: &sOpt},
+		{key: configbackend.CPUDevicesKey, value: data.CPUDevices, opt: &cOpt},
+	} {
+		if option.value == "" {
+			continue
+		}
+		*option.opt, err = parseDeviceOptions(strings.TrimSpace(option.value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' key in the configuration backend; err: %w", option.key, err)
+		}
+	}
+
+	records, err := counters.ReadCSV(strings.NewReader(data.Counters))
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' key in the configuration backend; err: %w", configbackend.CountersKey,
+			err)
+	}
+
+	cs, err := counters.ExtractCounters(records, config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.GPUDeviceOptions, config.SwitchDeviceOptions, config.CPUDeviceOptions = gOpt, sOpt, cOpt
+	slog.Info("Read the configuration from the configuration backend",
+		slog.String("backend", config.ConfigBackend), slog.Uint64("revision", data.Revision))
+	return cs, nil
+}
+
+// watchConfigBackend sends SIGHUP to reload, whenever the configuration in the backend changes, until the context
+// is done. The reload follows the same path as a SIGHUP sent to the exporter.
+func watchConfigBackend(ctx context.Context, rawURL string, reload chan<- os.Signal) {
+	backend, err := configbackend.New(rawURL)
+	if err != nil {
+		slog.Error("Not watching the configuration backend", slog.String(logging.ErrorKey, err.Error()))
+		return
+	}
+
+	var revision uint64
+	for {
+		if revision == 0 {
+			var data configbackend.Data
+			data, err = backend.Load(ctx)
+			revision = data.Revision
+		} else {
+			var next uint64
+			next, err = backend.Watch(ctx, revision)
+			if err == nil && next != revision {
+				revision = next
+				slog.Info("The configuration in the backend changed", slog.Uint64("revision", revision))
+				select {
+				case reload <- syscall.SIGHUP:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Warn("Failed to watch the configuration backend; retrying",
+				slog.String(logging.ErrorKey, err.Error()))
+			select {
+			case <-time.After(configBackendRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+func newConsulServer(t *testing.T, values map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var pairs []map[string]any
+		for key, value := range values {
+			pairs = append(pairs, map[string]any{"Key": "dcgm-exporter/" + key, "Value": []byte(value)})
+		}
+		w.Header().Set("X-Consul-Index", "3")
+		_ = json.NewEncoder(w).Encode(pairs)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestLoadBackendCounters(t *testing.T) {
+	server := newConsulServer(t, map[string]string{
+		"counters": "DCGM_FI_DEV_GPU_TEMP, gauge, GPU temperature (in C).\n",
+		"devices":  "g:1",
+	})
+	config := &appconfig.Config{
+		ConfigBackend:    "consul://" + strings.TrimPrefix(server.URL, "http://") + "/dcgm-exporter",
+		GPUDeviceOptions: appconfig.DeviceOptions{Flex: true},
+		CPUDeviceOptions: appconfig.DeviceOptions{Flex: true},
+	}
+
+	cs, err := loadBackendCounters(config)
+	require.NoError(t, err)
+	require.Len(t, cs.DCGMCounters, 1)
+	assert.Equal(t, "DCGM_FI_DEV_GPU_TEMP", cs.DCGMCounters[0].FieldName)
+	assert.Equal(t, appconfig.DeviceOptions{MajorRange: []int{1}}, config.GPUDeviceOptions)
+	assert.Equal(t, appconfig.DeviceOptions{Flex: true}, config.CPUDeviceOptions)
+}
+
+func TestLoadBackendCounters_InvalidDevicesKeepsConfig(t *testing.T) {
+	server := newConsulServer(t, map[string]string{
+		"counters": "DCGM_FI_DEV_GPU_TEMP, gauge, GPU temperature (in C).\n",
+		"devices":  "x:1",
+	})
+	config := &appconfig.Config{
+		ConfigBackend:    "consul://" + strings.TrimPrefix(server.URL, "http://") + "/dcgm-exporter",
+		GPUDeviceOptions: appconfig.DeviceOptions{Flex: true},
+	}
+
+	_, err := loadBackendCounters(config)
+	assert.ErrorContains(t, err, "'devices' key")
+	assert.Equal(t, appconfig.DeviceOptions{Flex: true}, config.GPUDeviceOptions)
+}
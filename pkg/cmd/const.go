@@ -16,6 +16,34 @@
 
 package cmd
 
+import (
+	"regexp"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+)
+
+// gpuUUIDPrefix is the prefix of the GPU UUIDs, which GPUs can be selected by in the device options.
+const gpuUUIDPrefix = "GPU-"
+
+// pciBusIDRegex matches the PCI bus IDs, which GPUs can be selected by in the device options, with or without the
+// domain, e.g. 0000:3b:00.0 or 3b:00.0.
+var pciBusIDRegex = regexp.MustCompile(`^([0-9a-fA-F]{4,8}:)?[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+
+// exclusionEntityGroups maps the entity types of the excluded entities to entity groups.
+var exclusionEntityGroups = map[string]dcgm.Field_Entity_Group{
+	"gpu":      dcgm.FE_GPU,
+	"switch":   dcgm.FE_SWITCH,
+	"link":     dcgm.FE_LINK,
+	"cpu":      dcgm.FE_CPU,
+	"cpu_core": dcgm.FE_CPU_CORE,
+	"vgpu":     dcgm.FE_VGPU,
+}
+
+// linkIDRegex matches the IDs of the excluded NVLinks, <switch>/<port>, e.g. 0/12.
+var linkIDRegex = regexp.MustCompile(`^[0-9]+/[0-9]+$`)
+
 // DCGMDbgLvl is a DCGM library debug level.
 const (
 	DCGMDbgLvlNone  = "NONE"
@@ -36,3 +64,15 @@ var DCGMDbgLvlValues = []string{
 	DCGMDbgLvlDebug,
 	DCGMDbgLvlVerb,
 }
+
+var GPUInstanceMetricsValues = []appconfig.GPUInstanceMetricsMode{
+	appconfig.GPUInstancesMixed,
+	appconfig.GPUInstancesSuffix,
+	appconfig.GPUInstancesLabel,
+}
+
+var HostnameSourceValues = []appconfig.HostnameSource{
+	appconfig.HostnameSourceAuto,
+	appconfig.HostnameSourceNodeName,
+	appconfig.HostnameSourceOS,
+}
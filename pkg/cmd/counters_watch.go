@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/kubeclient"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+)
+
+// watchCounters sends SIGHUP to reload, whenever the counters in the counters file or in the ConfigMap change, until
+// the context is done. The counters are compared every collectors watch interval, and the reload follows the same path as a SIGHUP
+// sent to the exporter.
+func watchCounters(ctx context.Context, config *appconfig.Config, reload chan<- os.Signal) {
+	var client kubernetes.Interface
+	if config.ConfigMapData != undefinedConfigMapData {
+		var err error
+		client, err = kubeclient.NewClient(config)
+		if err != nil {
+			slog.Error("Not watching the counters", slog.String(logging.ErrorKey, err.Error()))
+			return
+		}
+	}
+
+	revision, err := counters.SourceRevision(client, config)
+	if err != nil {
+		slog.Warn("Failed to read the counters", slog.String(logging.ErrorKey, err.Error()))
+	}
+
+	ticker := time.NewTicker(config.CollectorsWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		next, err := counters.SourceRevision(client, config)
+		if err != nil {
+			slog.Warn("Failed to read the counters; retrying", slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+		if next == revision {
+			continue
+		}
+
+		revision = next
+		slog.Info("The counters changed", slog.String("revision", revision))
+		select {
+		case reload <- syscall.SIGHUP:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicemonitoring"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/startupreport"
+)
+
+const CLIDryRunCommand = "dry-run"
+
+const (
+	reasonFieldNotSupported   = "not supported by the GPU or the driver"
+	reasonFieldNotPermitted   = "not permitted; the exporter needs more privileges"
+	reasonFieldNoValue        = "no value yet"
+	reasonFieldUnknownPattern = "DCGM status %d"
+)
+
+func newDryRunCommand() *cli.Command {
+	return &cli.Command{
+		Name: CLIDryRunCommand,
+		Usage: "Load the counters file or ConfigMap, watch its fields once, and print a report of the metrics, " +
+			"which would be exported, and of the ones, which would not, with the reason, to stdout. " +
+			"The exporter options must be specified before the command, e.g. 'dcgm-exporter -f counters.csv dry-run'",
+		Action: dryRunAction,
+	}
+}
+
+func dryRunAction(c *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Encountered a failure.", slog.String(StackTrace, string(debug.Stack())))
+			err = fmt.Errorf("encountered a failure; err: %v", r)
+		}
+	}()
+
+	config, err := contextToConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if err := configureLogging(config); err != nil {
+		return err
+	}
+
+	out, restoreStdout, err := redirectStdout()
+	if err != nil {
+		return err
+	}
+	defer restoreStdout()
+
+	coll, collCleanup, err := initCollection(config)
+	defer collCleanup()
+	if err != nil {
+		return err
+	}
+
+	err = dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return fmt.Errorf("failed to update DCGM fields; err: %w", err)
+	}
+
+	report, err := newDryRunReport(c.App.Version, config, coll)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(out, report.String())
+	return err
+}
+
+// newDryRunReport is the startup report, where the counters, which are watched, but which none of the entities has a
+// value for, are disabled as well.
+func newDryRunReport(version string, config *appconfig.Config, coll *collection) (*startupreport.Report, error) {
+	report := newStartupReport(version, config, coll.counterSet, coll.deviceWatchListManager, coll.pendingEntities)
+	if !config.NoHostname {
+		report.Hostname = coll.hostname + " (" + coll.hostnameOrigin + ")"
+	}
+
+	values, err := latestFieldValues(coll.deviceWatchListManager)
+	if err != nil {
+		return nil, err
+	}
+	unavailable := unavailableFields(values)
+
+	fieldIDs := map[string]dcgm.Short{}
+	for _, counter := range coll.counterSet.DCGMCounters {
+		fieldIDs[counter.FieldName] = counter.FieldID
+	}
+
+	// The error of the profiling module explains, why profiling metrics are not collected
+	_, profilingErr := dcgmprovider.Client().GetSupportedMetricGroups(0)
+
+	for i, status := range report.Counters {
+		switch {
+		case status.Enabled:
+			if reason, exists := unavailable[fieldIDs[status.Name]]; exists {
+				report.Counters[i].Enabled = false
+				report.Counters[i].Reason = reason
+			}
+		case status.UnsupportedCode == counters.SkipCodeProfilingDisabled && profilingErr != nil:
+			report.Counters[i].Reason += ": " + profilingErr.Error()
+		}
+	}
+
+	return report, nil
+}
+
+// latestFieldValues returns the latest values of the watched fields of all monitored entities.
+func latestFieldValues(manager devicewatchlistmanager.Manager) ([]dcgm.FieldValue_v2, error) {
+	var values []dcgm.FieldValue_v2
+
+	for _, entityType := range devicewatchlistmanager.DeviceTypesToWatch {
+		watchList, exists := manager.EntityWatchList(entityType)
+		if !exists || len(watchList.DeviceFields()) == 0 {
+			continue
+		}
+
+		var entities []dcgm.GroupEntityPair
+		for _, mi := range devicemonitoring.GetMonitoredEntities(watchList.DeviceInfo()) {
+			entities = append(entities, mi.Entity)
+		}
+		if len(entities) == 0 {
+			continue
+		}
+
+		entityValues, err := dcgmprovider.Client().EntitiesGetLatestValues(entities, watchList.DeviceFields(), 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the %s field values; err: %w", entityType, err)
+		}
+		values = append(values, entityValues...)
+	}
+
+	return values, nil
+}
+
+// unavailableFields returns why a field has no value, by field, for the fields, which none of the entities has a value
+// for.
+func unavailableFields(values []dcgm.FieldValue_v2) map[dcgm.Short]string {
+	available := map[dcgm.Short]bool{}
+	unavailable := map[dcgm.Short]string{}
+
+	for _, value := range values {
+		fieldID := dcgm.Short(value.FieldId)
+		reason := fieldValueUnavailable(value)
+		if reason == "" {
+			available[fieldID] = true
+			delete(unavailable, fieldID)
+			continue
+		}
+		if _, exists := unavailable[fieldID]; !exists && !available[fieldID] {
+			unavailable[fieldID] = reason
+		}
+	}
+
+	return unavailable
+}
+
+// fieldValueUnavailable returns why the field value is blank, or an empty string, when it holds a value.
+func fieldValueUnavailable(value dcgm.FieldValue_v2) string {
+	switch value.Status {
+	case dcgm.DCGM_ST_OK:
+	case dcgm.DCGM_ST_NOT_SUPPORTED:
+		return reasonFieldNotSupported
+	case dcgm.DCGM_ST_NO_PERMISSION:
+		return reasonFieldNotPermitted
+	case dcgm.DCGM_ST_NO_DATA, dcgm.DCGM_ST_NOT_WATCHED:
+		return reasonFieldNoValue
+	default:
+		return fmt.Sprintf(reasonFieldUnknownPattern, value.Status)
+	}
+
+	switch value.FieldType {
+	case dcgm.DCGM_FT_INT64:
+		switch value.Int64() {
+		case dcgm.DCGM_FT_INT32_NOT_SUPPORTED, dcgm.DCGM_FT_INT64_NOT_SUPPORTED:
+			return reasonFieldNotSupported
+		case dcgm.DCGM_FT_INT32_NOT_PERMISSIONED, dcgm.DCGM_FT_INT64_NOT_PERMISSIONED:
+			return reasonFieldNotPermitted
+		case dcgm.DCGM_FT_INT32_BLANK, dcgm.DCGM_FT_INT32_NOT_FOUND,
+			dcgm.DCGM_FT_INT64_BLANK, dcgm.DCGM_FT_INT64_NOT_FOUND:
+			return reasonFieldNoValue
+		}
+	case dcgm.DCGM_FT_DOUBLE:
+		switch value.Float64() {
+		case dcgm.DCGM_FT_FP64_NOT_SUPPORTED:
+			return reasonFieldNotSupported
+		case dcgm.DCGM_FT_FP64_NOT_PERMISSIONED:
+			return reasonFieldNotPermitted
+		case dcgm.DCGM_FT_FP64_BLANK, dcgm.DCGM_FT_FP64_NOT_FOUND:
+			return reasonFieldNoValue
+		}
+	case dcgm.DCGM_FT_STRING:
+		switch value.String() {
+		case dcgm.DCGM_FT_STR_NOT_SUPPORTED:
+			return reasonFieldNotSupported
+		case dcgm.DCGM_FT_STR_NOT_PERMISSIONED:
+			return reasonFieldNotPermitted
+		case dcgm.DCGM_FT_STR_BLANK, dcgm.DCGM_FT_STR_NOT_FOUND:
+			return reasonFieldNoValue
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+)
+
+func int64FieldValue(gpu uint, fieldID dcgm.Short, value int64) dcgm.FieldValue_v2 {
+	v := dcgm.FieldValue_v2{
+		EntityGroupId: dcgm.FE_GPU,
+		EntityId:      gpu,
+		FieldId:       uint(fieldID),
+		FieldType:     dcgm.DCGM_FT_INT64,
+	}
+	binary.LittleEndian.PutUint64(v.Value[:], uint64(value))
+	return v
+}
+
+func Test_unavailableFields(t *testing.T) {
+	noPermission := int64FieldValue(0, dcgm.DCGM_FI_DEV_ECC_DBE_VOL_TOTAL, 0)
+	noPermission.Status = dcgm.DCGM_ST_NO_PERMISSION
+
+	values := []dcgm.FieldValue_v2{
+		int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, 42),
+		int64FieldValue(0, dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		int64FieldValue(1, dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT, dcgm.DCGM_FT_INT64_NOT_SUPPORTED),
+		// A field is available, when any of the entities has a value
+		int64FieldValue(0, dcgm.DCGM_FI_DEV_MEM_COPY_UTIL, dcgm.DCGM_FT_INT64_BLANK),
+		int64FieldValue(1, dcgm.DCGM_FI_DEV_MEM_COPY_UTIL, 7),
+		int64FieldValue(0, dcgm.DCGM_FI_DEV_FB_USED, dcgm.DCGM_FT_INT32_NOT_PERMISSIONED),
+		noPermission,
+	}
+
+	assert.Equal(t, map[dcgm.Short]string{
+		dcgm.DCGM_FI_DEV_POWER_MGMT_LIMIT:  reasonFieldNotSupported,
+		dcgm.DCGM_FI_DEV_FB_USED:           reasonFieldNotPermitted,
+		dcgm.DCGM_FI_DEV_ECC_DBE_VOL_TOTAL: reasonFieldNotPermitted,
+	}, unavailableFields(values))
+}
+
+func Test_fieldValueUnavailable(t *testing.T) {
+	notWatched := int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, 0)
+	notWatched.Status = dcgm.DCGM_ST_NOT_WATCHED
+	unknown := int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, 0)
+	unknown.Status = dcgm.DCGM_ST_GPU_IS_LOST
+
+	tests := []struct {
+		name  string
+		value dcgm.FieldValue_v2
+		want  string
+	}{
+		{name: "Value", value: int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, 42)},
+		{
+			name:  "Blank",
+			value: int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT64_BLANK),
+			want:  reasonFieldNoValue,
+		},
+		{
+			name:  "Not supported",
+			value: int64FieldValue(0, dcgm.DCGM_FI_DEV_GPU_TEMP, dcgm.DCGM_FT_INT32_NOT_SUPPORTED),
+			want:  reasonFieldNotSupported,
+		},
+		{name: "Not watched", value: notWatched, want: reasonFieldNoValue},
+		{name: "Other status", value: unknown, want: "DCGM status -18"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fieldValueUnavailable(tt.value))
+		})
+	}
+}
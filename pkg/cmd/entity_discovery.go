@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/avast/retry-go/v4"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	entityDiscoveryInitialDelay = 5 * time.Second
+	entityDiscoveryMaxDelay     = 5 * time.Minute
+)
+
+var (
+	entityDiscoveryStatus = selfmetrics.Default().Gauge("dcgm_exporter_entity_discovery_status",
+		"Entity discovery status: 1 when metrics for the entity type are collected, 0 while discovery is pending.")
+	entityDiscoveryRetries = selfmetrics.Default().Counter("dcgm_exporter_entity_discovery_retries_total",
+		"Number of failed attempts to discover entities that were not available at startup.")
+)
+
+// isRetryableDiscoveryError reports whether an entity type may become available later.
+// DCGM may report an empty CPU hierarchy on Grace systems until its CPU module is fully initialized, or fail to load
+// the module while the hostengine is initializing the CPUs.
+func isRetryableDiscoveryError(entityType dcgm.Field_Entity_Group, err error) bool {
+	if entityType != dcgm.FE_CPU && entityType != dcgm.FE_CPU_CORE {
+		return false
+	}
+	return errors.Is(err, deviceinfo.ErrNoCPUs) ||
+		(deviceinfo.NVIDIACPUExpected && errors.Is(err, deviceinfo.ErrCPUModuleNotLoaded))
+}
+
+// discoverPendingEntities re-probes entity types, which were not available at startup, on a backoff schedule.
+// Once an entity type appears, its DCGM collector is registered and metrics are collected on the next scrape.
+// The returned function waits for the discovery to stop, after the context is done, so that the registry can be
+// cleaned up without a collector being registered into it at the same time.
+func discoverPendingEntities(
+	ctx context.Context,
+	pending []dcgm.Field_Entity_Group,
+	deviceWatchListManager devicewatchlistmanager.Manager,
+	cf collector.Factory,
+	cRegistry *registry.Registry,
+	collectInterval int64,
+	bus *eventbus.Bus,
+) (wait func()) {
+	var wg sync.WaitGroup
+	for _, entityType := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := retry.Do(
+				func() error {
+					return discoverEntity(ctx, entityType, deviceWatchListManager, cf, cRegistry, collectInterval)
+				},
+				retry.Context(ctx),
+				retry.UntilSucceeded(),
+				retry.Delay(entityDiscoveryInitialDelay),
+				retry.MaxDelay(entityDiscoveryMaxDelay),
+				retry.RetryIf(func(err error) bool {
+					return isRetryableDiscoveryError(entityType, err)
+				}),
+				retry.OnRetry(func(n uint, err error) {
+					entityDiscoveryRetries.Inc("entity", entityType.String())
+					slog.Debug(fmt.Sprintf("%s entities are not available yet; attempt: %d", entityType.String(), n+1),
+						slog.String(logging.ErrorKey, err.Error()))
+				}),
+			)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn(fmt.Sprintf("Not collecting %s metrics", entityType.String()),
+						slog.String(logging.ErrorKey, err.Error()))
+					bus.Errors.Publish(eventbus.ErrorEvent{Source: "entity_discovery", Err: err})
+				}
+				return
+			}
+
+			entityDiscoveryStatus.Set(1, "entity", entityType.String())
+			bus.TopologyChanges.Publish(eventbus.TopologyChangeEvent{EntityGroup: entityType})
+			slog.Info(fmt.Sprintf("%s entities discovered; collecting %s metrics", entityType.String(),
+				entityType.String()))
+		}()
+	}
+
+	return wg.Wait
+}
+
+func discoverEntity(
+	ctx context.Context,
+	entityType dcgm.Field_Entity_Group,
+	deviceWatchListManager devicewatchlistmanager.Manager,
+	cf collector.Factory,
+	cRegistry *registry.Registry,
+	collectInterval int64,
+) error {
+	err := deviceWatchListManager.CreateEntityWatchList(entityType, devicewatcher.NewDeviceWatcher(), collectInterval)
+	if err != nil {
+		return err
+	}
+
+	entityCollector, err := cf.NewEntityCollector(entityType)
+	if err != nil {
+		return err
+	}
+
+	// The registry is cleaned up, once the discovery is stopped, so the collector would never be cleaned up
+	if err := ctx.Err(); err != nil {
+		entityCollector.Collector().Cleanup()
+		return err
+	}
+
+	cRegistry.Register(entityCollector)
+
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+func Test_isRetryableDiscoveryError(t *testing.T) {
+	tests := []struct {
+		name              string
+		entityType        dcgm.Field_Entity_Group
+		err               error
+		nvidiaCPUExpected bool
+		want              bool
+	}{
+		{
+			name:       "CPU without CPUs",
+			entityType: dcgm.FE_CPU,
+			err:        deviceinfo.ErrNoCPUs,
+			want:       true,
+		},
+		{
+			name:       "CPU Core with wrapped error",
+			entityType: dcgm.FE_CPU_CORE,
+			err:        fmt.Errorf("init failed: %w", deviceinfo.ErrNoCPUs),
+			want:       true,
+		},
+		{
+			name:       "CPU with other error",
+			entityType: dcgm.FE_CPU,
+			err:        errors.New("not supported"),
+			want:       false,
+		},
+		{
+			name:       "GPU is not retried",
+			entityType: dcgm.FE_GPU,
+			err:        deviceinfo.ErrNoCPUs,
+			want:       false,
+		},
+		{
+			name:              "CPU module not loaded on NVIDIA CPUs",
+			entityType:        dcgm.FE_CPU,
+			err:               fmt.Errorf("%w; err: module not loaded", deviceinfo.ErrCPUModuleNotLoaded),
+			nvidiaCPUExpected: true,
+			want:              true,
+		},
+		{
+			name:       "CPU module not loaded on other CPUs",
+			entityType: dcgm.FE_CPU_CORE,
+			err:        fmt.Errorf("%w; err: module not loaded", deviceinfo.ErrCPUModuleNotLoaded),
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nvidiaCPUExpected := deviceinfo.NVIDIACPUExpected
+			t.Cleanup(func() { deviceinfo.NVIDIACPUExpected = nvidiaCPUExpected })
+			deviceinfo.NVIDIACPUExpected = tt.nvidiaCPUExpected
+
+			assert.Equal(t, tt.want, isRetryableDiscoveryError(tt.entityType, tt.err))
+		})
+	}
+}
+
+func Test_discoverEntity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockManager.EXPECT().CreateEntityWatchList(dcgm.FE_CPU, gomock.Any(), int64(30)).Return(deviceinfo.ErrNoCPUs)
+
+	err := discoverEntity(context.Background(), dcgm.FE_CPU, mockManager, nil, registry.NewRegistry(), 30)
+	assert.ErrorIs(t, err, deviceinfo.ErrNoCPUs)
+}
+
+// entityCollectorFactory creates the collectors of the discovered entities.
+type entityCollectorFactory struct {
+	collector.Factory
+	collector *cleanupCollector
+}
+
+func (f *entityCollectorFactory) NewEntityCollector(entityType dcgm.Field_Entity_Group) (
+	collector.EntityCollectorTuple, error,
+) {
+	tuple := collector.EntityCollectorTuple{}
+	tuple.SetEntity(entityType)
+	tuple.SetCollector(f.collector)
+	return tuple, nil
+}
+
+type cleanupCollector struct {
+	cleanedUp bool
+}
+
+func (c *cleanupCollector) GetMetrics() (collector.MetricsByCounter, error) {
+	return collector.MetricsByCounter{}, nil
+}
+
+func (c *cleanupCollector) Cleanup() {
+	c.cleanedUp = true
+}
+
+func Test_discoverEntity_Stopped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockManager.EXPECT().CreateEntityWatchList(dcgm.FE_CPU, gomock.Any(), int64(30)).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	factory := &entityCollectorFactory{collector: &cleanupCollector{}}
+	reg := registry.NewRegistry()
+	err := discoverEntity(ctx, dcgm.FE_CPU, mockManager, factory, reg, 30)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, factory.collector.cleanedUp, "the collector, which is not registered, must be cleaned up")
+
+	metrics, err := reg.Gather()
+	require.NoError(t, err)
+	assert.Empty(t, metrics, "the collector must not be registered, once the discovery is stopped")
+}
+
+func Test_discoverPendingEntities_Wait(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockManager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	mockManager.EXPECT().CreateEntityWatchList(dcgm.FE_CPU, gomock.Any(), int64(30)).
+		Return(deviceinfo.ErrNoCPUs).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := discoverPendingEntities(ctx, []dcgm.Field_Entity_Group{dcgm.FE_CPU}, mockManager, nil,
+		registry.NewRegistry(), 30, eventbus.New())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "the discovery did not stop")
+	}
+}
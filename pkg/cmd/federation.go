@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/federation"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/server"
+)
+
+const defaultFederationLabel = "hostengine"
+
+// workerExcludedFlags are the flags of this process, with their environment variables, which the workers don't
+// inherit, because they apply to the merged metrics served by this process only: the workers are only scraped by it,
+// from the loopback interface.
+var workerExcludedFlags = map[string]string{
+	CLIAllowedSourceCIDRs:      "DCGM_EXPORTER_ALLOWED_SOURCE_CIDRS",
+	CLIMetricsTokenFile:        "DCGM_EXPORTER_METRICS_TOKEN_FILE",
+	CLIMetricsAllowedClientCNs: "DCGM_EXPORTER_METRICS_ALLOWED_CLIENT_CNS",
+}
+
+// runFederation runs a worker per remote hostengine, with the command line of this process, and serves their merged
+// metrics, until the process is interrupted.
+func runFederation(config *appconfig.Config) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the executable of the workers; err: %w", err)
+	}
+
+	label := config.HostengineLabel
+	if label == "" {
+		label = defaultFederationLabel
+	}
+
+	fed, err := federation.New(federation.Options{
+		Targets:      config.RemoteHETargets,
+		Command:      workerCommand(executable, os.Args[1:], os.Environ()),
+		Label:        label,
+		Timeout:      10 * time.Second,
+		RestartDelay: 5 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fed.Run(ctx)
+	}()
+
+	metrics, err := server.RequireScrapeAuth(config, fed.ServeHTTP)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler, err := server.AllowSources(config, mux)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:         config.Address,
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		slog.Info(fmt.Sprintf("Serving the metrics of the hostengines %v", config.RemoteHETargets))
+		err := web.ListenAndServe(srv, &web.FlagConfig{
+			WebListenAddresses: &[]string{config.Address},
+			WebSystemdSocket:   &config.WebSystemdSocket,
+			WebConfigFile:      &config.WebConfigFile,
+		}, slog.Default())
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Failed to Listen and Server HTTP server.", slog.String(ErrorKey, err.Error()))
+			fatal()
+		}
+	}()
+
+	sigs := newOSWatcher(syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	<-sigs
+
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Failed to shut down the HTTP server.", slog.String(ErrorKey, err.Error()))
+	}
+
+	cancel()
+	<-done
+
+	return nil
+}
+
+// workerCommand returns the command of a worker: the command line and the environment of this process, with the
+// remote hostengine and the listen address of the worker, and without the workerExcludedFlags. The worker listens on
+// the loopback interface without TLS, and neither pushes the metrics over OTLP nor serves the gRPC API, whose
+// address would be the same for all the workers, as it is only scraped by this process.
+func workerCommand(executable string, args, environ []string) federation.CommandFunc {
+	return func(ctx context.Context, target, address string) *exec.Cmd {
+		workerArgs := withoutFlags(args, workerExcludedFlags)
+		workerArgs = append(workerArgs,
+			"--"+CLIRemoteHEInfo+"="+target,
+			"--"+CLIAddress+"="+address,
+			"--"+CLIWebConfigFile+"=",
+			"--"+CLIOTLPEndpoint+"=",
+			"--"+CLIGRPCAddress+"=",
+		)
+		if runtime.GOOS == "linux" {
+			workerArgs = append(workerArgs, "--"+CLIWebSystemdSocket+"=false")
+		}
+
+		cmd := exec.CommandContext(ctx, executable, workerArgs...)
+		cmd.Env = withoutEnvVars(environ, workerExcludedFlags)
+		return cmd
+	}
+}
+
+// withoutFlags returns the arguments without the flags, given as -name or --name, with their value, either after
+// an equal sign or as the next argument.
+func withoutFlags(args []string, flags map[string]string) []string {
+	kept := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(kept, args[i:]...)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			kept = append(kept, arg)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if _, excluded := flags[name]; !excluded {
+			kept = append(kept, arg)
+			continue
+		}
+		if !hasValue {
+			i++
+		}
+	}
+	return kept
+}
+
+// withoutEnvVars returns the environment without the environment variables of the flags.
+func withoutEnvVars(environ []string, flags map[string]string) []string {
+	return slices.DeleteFunc(slices.Clone(environ), func(env string) bool {
+		name, _, _ := strings.Cut(env, "=")
+		for _, envVar := range flags {
+			if name == envVar {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerCommand(t *testing.T) {
+	args := []string{
+		"-f", "/etc/dcgm-exporter/default-counters.csv",
+		"--metrics-token-file=/etc/dcgm-exporter/tokens",
+		"--metrics-allowed-client-cns", "prometheus",
+		"--allowed-source-cidrs", "10.0.0.0/8",
+		"-k",
+	}
+	environ := []string{
+		"PATH=/usr/bin",
+		"DCGM_EXPORTER_METRICS_TOKEN_FILE=/etc/dcgm-exporter/tokens",
+		"DCGM_EXPORTER_METRICS_ALLOWED_CLIENT_CNS=prometheus",
+		"DCGM_EXPORTER_ALLOWED_SOURCE_CIDRS=10.0.0.0/8",
+		"DCGM_EXPORTER_KUBERNETES=true",
+	}
+
+	cmd := workerCommand("/usr/bin/dcgm-exporter", args, environ)(context.Background(), "node1:5555",
+		"127.0.0.1:40001")
+
+	want := []string{
+		"-f", "/etc/dcgm-exporter/default-counters.csv",
+		"-k",
+		"--remote-hostengine-info=node1:5555",
+		"--address=127.0.0.1:40001",
+		"--web-config-file=",
+		"--otlp-endpoint=",
+		"--grpc-address=",
+	}
+	if runtime.GOOS == "linux" {
+		want = append(want, "--web-systemd-socket=false")
+	}
+
+	assert.Equal(t, "/usr/bin/dcgm-exporter", cmd.Path)
+	assert.Equal(t, want, cmd.Args[1:])
+	assert.Equal(t, []string{"PATH=/usr/bin", "DCGM_EXPORTER_KUBERNETES=true"}, cmd.Env)
+}
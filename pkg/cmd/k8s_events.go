@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/hostname"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/k8sevents"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/kubeclient"
+)
+
+// startEventEmitter posts Kubernetes Events on the node for the critical GPU conditions in the collected metrics. It
+// subscribes to the bus once, so the conditions are tracked across reloads. The returned function stops it.
+func startEventEmitter(config *appconfig.Config, bus *eventbus.Bus) (func(), error) {
+	client, err := kubeclient.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client of the events; err: %w", err)
+	}
+
+	emitter, shutdown := k8sevents.New(client, os.Getenv(hostname.OriginNodeName))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go emitter.Run(bus.Collections.Subscribe(ctx, 1))
+
+	return func() {
+		cancel()
+		shutdown()
+	}, nil
+}
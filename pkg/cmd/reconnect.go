@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+var (
+	hostengineReconnects = selfmetrics.Default().Counter("dcgm_exporter_hostengine_reconnects_total",
+		"Number of reconnections to the remote hostengine, after the connection was lost.")
+	hostengineConnected = selfmetrics.Default().Gauge("dcgm_exporter_hostengine_connected",
+		"Whether the exporter is connected to the remote hostengine (1) or reconnecting (0).")
+)
+
+// reconnectCollection connects to the remote hostengine again, after the connection was lost, e.g. because the
+// hostengine restarted, and watches the fields of the counters of the current collection again. It retries with a
+// backoff, until it is connected, or a signal other than SIGHUP stops it, in which case it returns nil.
+func reconnectCollection(config *appconfig.Config, current *collection, sigs <-chan os.Signal) *collection {
+	hostengineConnected.Set(0)
+	slog.Warn("Lost the connection to the remote hostengine; reconnecting",
+		slog.String("remote", config.RemoteHEInfo))
+
+	delay := reconnectMinDelay
+	for {
+		next, err := newReconnectedCollection(config, current)
+		if err == nil {
+			hostengineReconnects.Inc()
+			hostengineConnected.Set(1)
+			slog.Info("Reconnected to the remote hostengine", slog.String("remote", config.RemoteHEInfo))
+			return next
+		}
+
+		slog.Warn("Failed to reconnect to the remote hostengine",
+			slog.String("remote", config.RemoteHEInfo),
+			slog.Duration("retryIn", delay),
+			slog.String(logging.ErrorKey, err.Error()))
+
+		select {
+		case sig := <-sigs:
+			// Reloads are ignored, while reconnecting
+			if sig != syscall.SIGHUP {
+				return nil
+			}
+		case <-time.After(delay):
+			delay = min(2*delay, reconnectMaxDelay)
+		}
+	}
+}
+
+func newReconnectedCollection(config *appconfig.Config, current *collection) (*collection, error) {
+	if err := dcgmprovider.Reconnect(config); err != nil {
+		return nil, err
+	}
+
+	next, err := newCollection(config, current.counterSet)
+	if err != nil {
+		return nil, err
+	}
+	next.overrides = current.overrides
+	return next, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/canary"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+const (
+	reloadApplied    = "applied"
+	reloadRolledBack = "rolled_back"
+)
+
+var (
+	configReloads = selfmetrics.Default().Counter("dcgm_exporter_config_reloads_total",
+		"Number of configuration reloads by result: applied or rolled_back.")
+	configLastReloadSuccess = selfmetrics.Default().Gauge("dcgm_exporter_config_last_reload_success",
+		"Whether the last configuration reload was applied: 1 when applied, 0 when rolled back.")
+)
+
+// reloadCollection reads the counters again, applies the overrides and builds a new collection from them, while the
+// current collection keeps being served. The DCGM fields, which are not collected yet, are evaluated by a canary
+// first, and the reload is rolled back, when any of them returns an error.
+func reloadCollection(
+	config *appconfig.Config, current *collection, overrides counters.Overrides,
+) (*collection, error) {
+	slog.Info("Reloading the counters")
+
+	next, result, err := newReloadedCollection(config, current, overrides)
+	if err != nil {
+		configReloads.Inc("result", reloadRolledBack)
+		configLastReloadSuccess.Set(0)
+		slog.Error("Configuration reload rolled back; the current counters are still collected",
+			slog.String(logging.ErrorKey, err.Error()),
+			slog.Any("passed", result.Passed),
+			slog.Any("failed", result.Failed),
+			slog.Any("skipped", result.Skipped),
+		)
+		return nil, err
+	}
+
+	configReloads.Inc("result", reloadApplied)
+	configLastReloadSuccess.Set(1)
+	slog.Info("Configuration reload applied",
+		slog.Int("dcgmCounters", len(next.counterSet.DCGMCounters)),
+		slog.Int("exporterCounters", len(next.counterSet.ExporterCounters)),
+		slog.Any("passed", result.Passed),
+		slog.Any("skipped", result.Skipped),
+	)
+	return next, nil
+}
+
+func newReloadedCollection(
+	config *appconfig.Config, current *collection, overrides counters.Overrides,
+) (*collection, canary.Result, error) {
+	cs, err := loadCounters(config, overrides)
+	if err != nil {
+		return nil, canary.Result{}, err
+	}
+
+	added := canary.NewCounters(current.counterSet.DCGMCounters, cs.DCGMCounters)
+	result, err := canary.Evaluate(added, time.Duration(config.CollectInterval)*time.Millisecond)
+	if err != nil {
+		return nil, result, fmt.Errorf("failed to evaluate the new counters; err: %w", err)
+	}
+	if !result.OK() {
+		var failed []string
+		for name, reason := range result.Failed {
+			failed = append(failed, name+": "+reason)
+		}
+		slices.Sort(failed)
+		return nil, result, fmt.Errorf("new counters returned errors: %s", strings.Join(failed, ", "))
+	}
+
+	next, err := newCollection(config, cs)
+	if err != nil {
+		return nil, result, err
+	}
+	next.overrides = overrides
+	return next, result, nil
+}
+
+// countersUpdate is a change of the counters through the admin API, which is applied like a reload.
+type countersUpdate struct {
+	added   [][]string
+	removed []string
+	result  chan<- error
+}
+
+// requestCountersUpdate sends the update to the loop, which reloads the collection, and waits for the result.
+func requestCountersUpdate(
+	ctx context.Context, updates chan<- countersUpdate, added [][]string, removed []string,
+) error {
+	result := make(chan error, 1)
+	select {
+	case updates <- countersUpdate{added: added, removed: removed, result: result}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
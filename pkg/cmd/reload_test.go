@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+func TestReloadCollection_RollsBackFailedCounters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	countersFile := filepath.Join(t.TempDir(), "counters.csv")
+	require.NoError(t, os.WriteFile(countersFile, []byte(
+		"DCGM_FI_DEV_GPU_TEMP, gauge, GPU temperature (in C).\n"+
+			"DCGM_FI_DEV_SM_CLOCK, gauge, SM clock frequency (in MHz).\n"), 0o600))
+
+	config := &appconfig.Config{
+		CollectorsFile:  countersFile,
+		ConfigMapData:   undefinedConfigMapData,
+		CollectInterval: 1000,
+	}
+	current := &collection{
+		counterSet: &counters.CounterSet{
+			DCGMCounters: counters.CounterList{
+				{FieldID: dcgm.DCGM_FI_DEV_GPU_TEMP, FieldName: "DCGM_FI_DEV_GPU_TEMP", PromType: "gauge"},
+			},
+		},
+	}
+
+	notSupported := [4096]byte{}
+	binary.LittleEndian.PutUint64(notSupported[:], uint64(dcgm.DCGM_FT_INT64_NOT_SUPPORTED))
+
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_SM_CLOCK)).
+		Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_GPU})
+	mockDCGM.EXPECT().GetSupportedDevices().Return([]uint{0}, nil)
+	mockDCGM.EXPECT().CreateGroup(gomock.Any()).Return(dcgm.GroupHandle{}, nil)
+	mockDCGM.EXPECT().AddEntityToGroup(gomock.Any(), dcgm.FE_GPU, uint(0)).Return(nil)
+	mockDCGM.EXPECT().FieldGroupCreate(gomock.Any(), gomock.Any()).Return(dcgm.FieldHandle{}, nil)
+	mockDCGM.EXPECT().WatchFieldsWithGroupEx(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	mockDCGM.EXPECT().UpdateAllFields().Return(nil)
+	mockDCGM.EXPECT().EntityGetLatestValues(dcgm.FE_GPU, uint(0), gomock.Any()).Return([]dcgm.FieldValue_v1{
+		{FieldId: dcgm.DCGM_FI_DEV_SM_CLOCK, FieldType: dcgm.DCGM_FT_INT64, Value: notSupported},
+	}, nil)
+	mockDCGM.EXPECT().FieldGroupDestroy(gomock.Any()).Return(nil)
+	mockDCGM.EXPECT().DestroyGroup(gomock.Any()).Return(nil)
+
+	rolledBack, _ := selfmetrics.Default().Value("dcgm_exporter_config_reloads_total", "result", reloadRolledBack)
+
+	next, err := reloadCollection(config, current, counters.Overrides{})
+	require.Error(t, err)
+	assert.Nil(t, next)
+	assert.Contains(t, err.Error(), "DCGM_FI_DEV_SM_CLOCK: not supported")
+
+	got, _ := selfmetrics.Default().Value("dcgm_exporter_config_reloads_total", "result", reloadRolledBack)
+	assert.Equal(t, rolledBack+1, got)
+	success, _ := selfmetrics.Default().Value("dcgm_exporter_config_last_reload_success")
+	assert.Equal(t, float64(0), success)
+}
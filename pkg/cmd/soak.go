@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/soak"
+)
+
+const (
+	CLISoakTestCommand    = "soak-test"
+	CLISoakTestIterations = "iterations"
+	CLISoakTestInterval   = "interval"
+)
+
+func newSoakTestCommand() *cli.Command {
+	return &cli.Command{
+		Name: CLISoakTestCommand,
+		Usage: "Repeatedly discover the entities, watch the fields, collect the metrics once and tear everything " +
+			"down, while logging the open file descriptors, the memory and the open DCGM groups after every cycle. " +
+			"The exit status is non-zero, when any of them but the memory grew. " +
+			"Intended for the qualification of DCGM and driver versions",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  CLISoakTestIterations,
+				Value: 100,
+				Usage: "Number of cycles. With 0, cycles run until the process is interrupted.",
+			},
+			&cli.DurationFlag{
+				Name:  CLISoakTestInterval,
+				Value: time.Second,
+				Usage: "Pause between cycles.",
+			},
+		},
+		Action: soakTestAction,
+	}
+}
+
+func soakTestAction(c *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Encountered a failure.", slog.String(StackTrace, string(debug.Stack())))
+			err = fmt.Errorf("encountered a failure; err: %v", r)
+		}
+	}()
+
+	config, err := contextToConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if err := configureLogging(config); err != nil {
+		return err
+	}
+
+	// Like on a reload, the collections of the cycles are built, while the initial collection exists
+	coll, collCleanup, err := initCollection(config)
+	defer collCleanup()
+	if err != nil {
+		return err
+	}
+
+	client := soak.NewCountingClient(dcgmprovider.Client())
+	dcgmprovider.SetClient(client)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	_, err = soak.Run(ctx, soak.Options{
+		Iterations: c.Int(CLISoakTestIterations),
+		Interval:   c.Duration(CLISoakTestInterval),
+	}, client, func() error {
+		return soakCycle(config, coll.counterSet)
+	})
+	return err
+}
+
+// soakCycle builds a collection, like a reload does, collects the metrics once and tears the collection down.
+func soakCycle(config *appconfig.Config, cs *counters.CounterSet) error {
+	coll, err := newCollection(config, cs)
+	if err != nil {
+		return err
+	}
+	defer coll.registry.Cleanup()
+
+	err = dcgmprovider.Client().UpdateAllFields()
+	if err != nil {
+		return fmt.Errorf("failed to update DCGM fields; err: %w", err)
+	}
+
+	_, err = coll.registry.Gather()
+	return err
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var haStandby = selfmetrics.Default().Gauge("dcgm_exporter_ha_standby",
+	"Whether the exporter is the standby (1), waiting for the primary to stop responding, or serves the metrics (0).")
+
+// runStandby waits, until the primary exporter stops responding, without initializing DCGM, so that the fields of
+// the hostengine are only watched once. Meanwhile, /health responds, and /metrics responds with 503 Service
+// Unavailable. It returns true, when the standby takes over, and false, when the process is interrupted.
+func runStandby(config *appconfig.Config) bool {
+	haStandby.Set(1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "standby; the metrics are served by the primary", http.StatusServiceUnavailable)
+	})
+
+	srv := &http.Server{
+		Addr:         config.Address,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		err := web.ListenAndServe(srv, &web.FlagConfig{
+			WebListenAddresses: &[]string{config.Address},
+			WebSystemdSocket:   &config.WebSystemdSocket,
+			WebConfigFile:      &config.WebConfigFile,
+		}, slog.Default())
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Failed to Listen and Server HTTP server.", slog.String(ErrorKey, err.Error()))
+			fatal()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	slog.Info("Running as the standby of the primary exporter", slog.String("primary", config.HAStandby))
+	failed := waitForPrimaryFailure(ctx, &http.Client{Timeout: config.HAStandbyProbeInterval}, config.HAStandby,
+		config.HAStandbyProbeInterval, config.HAStandbyFailures)
+
+	// The listen address is released for the metrics server
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Failed to shut down the HTTP server.", slog.String(ErrorKey, err.Error()))
+	}
+	<-served
+
+	if failed {
+		haStandby.Set(0)
+		slog.Warn("The primary exporter stopped responding; taking over", slog.String("primary", config.HAStandby))
+	}
+	return failed
+}
+
+// waitForPrimaryFailure probes the health endpoint of the primary at the interval, until it fails the number of
+// consecutive probes, and returns true then. A probe fails, when the endpoint doesn't respond with a 2xx status. It
+// returns false, when the context is done.
+func waitForPrimaryFailure(ctx context.Context, client *http.Client, url string, interval time.Duration,
+	failures int,
+) bool {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failed := 0
+	for {
+		if err := probePrimary(ctx, client, url); ctx.Err() != nil {
+			return false
+		} else if err != nil {
+			failed++
+			slog.Warn(fmt.Sprintf("The primary exporter failed %d of %d probes", failed, failures),
+				slog.String("primary", url), slog.String(ErrorKey, err.Error()))
+			if failed >= failures {
+				return true
+			}
+		} else {
+			failed = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+func probePrimary(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
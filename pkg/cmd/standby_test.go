@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForPrimaryFailure(t *testing.T) {
+	// The primary fails a probe, recovers, and then fails for good
+	statuses := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK}
+	var probes atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(probes.Add(1)) - 1
+		if n < len(statuses) {
+			w.WriteHeader(statuses[n])
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+
+	failed := waitForPrimaryFailure(context.Background(), primary.Client(), primary.URL+"/health",
+		time.Millisecond, 2)
+	assert.True(t, failed)
+	// The failed probe, which the primary recovered from, is not counted
+	assert.Equal(t, int32(5), probes.Load())
+}
+
+func TestWaitForPrimaryFailure_Unreachable(t *testing.T) {
+	primary := httptest.NewServer(http.NotFoundHandler())
+	url := primary.URL + "/health"
+	primary.Close()
+
+	failed := waitForPrimaryFailure(context.Background(), http.DefaultClient, url, time.Millisecond, 3)
+	assert.True(t, failed)
+}
+
+func TestWaitForPrimaryFailure_Interrupted(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer primary.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	failed := waitForPrimaryFailure(ctx, primary.Client(), primary.URL+"/health", time.Millisecond, 1)
+	assert.False(t, failed)
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/startupreport"
+)
+
+// newStartupReport summarizes the discovered entities, the collected counters and the serving configuration.
+func newStartupReport(
+	version string,
+	config *appconfig.Config,
+	cs *counters.CounterSet,
+	manager devicewatchlistmanager.Manager,
+	pending []dcgm.Field_Entity_Group,
+) *startupreport.Report {
+	report := &startupreport.Report{
+		Version:  version,
+		Entities: map[string]int{},
+	}
+
+	report.Entities[dcgm.FE_GPU_I.String()] = 0
+
+	var watchedFields []dcgm.Short
+	for _, entityType := range devicewatchlistmanager.DeviceTypesToWatch {
+		report.Entities[entityType.String()] = 0
+
+		entityWatchList, exists := manager.EntityWatchList(entityType)
+		if !exists {
+			continue
+		}
+		addEntities(report.Entities, entityType, entityWatchList.DeviceInfo())
+		watchedFields = append(watchedFields, entityWatchList.DeviceFields()...)
+	}
+
+	_, gpusWatched := manager.EntityWatchList(dcgm.FE_GPU)
+
+	for _, counter := range cs.DCGMCounters {
+		if counter.IsLabel() {
+			continue
+		}
+
+		status := startupreport.CounterStatus{Name: counter.FieldName, Enabled: true}
+		if !slices.Contains(watchedFields, counter.FieldID) {
+			level := dcgmprovider.Client().FieldGetById(counter.FieldID).EntityLevel
+			status.Enabled = false
+			status.Reason = fmt.Sprintf("no %s is watched", level)
+			if slices.Contains(pending, level) {
+				status.Reason = fmt.Sprintf("%s discovery is pending", level)
+			}
+		}
+		report.Counters = append(report.Counters, status)
+	}
+
+	for _, counter := range cs.ExporterCounters {
+		if counter.IsLabel() {
+			continue
+		}
+
+		status := startupreport.CounterStatus{Name: counter.FieldName, Enabled: gpusWatched}
+		if !gpusWatched {
+			status.Reason = "no GPU is watched"
+		}
+		report.Counters = append(report.Counters, status)
+	}
+
+	for _, skipped := range cs.Skipped {
+		report.Counters = append(report.Counters, startupreport.CounterStatus{
+			Name:            skipped.FieldName,
+			Reason:          skipped.Reason,
+			UnsupportedCode: skipped.Code,
+		})
+	}
+
+	if config.Kubernetes {
+		if config.KubeletAPIURL != "" {
+			report.Attribution = append(report.Attribution, "kubernetes:"+config.KubeletAPIURL)
+		} else {
+			report.Attribution = append(report.Attribution, "kubernetes:"+config.PodResourcesKubeletSocket)
+		}
+	}
+	if config.HPCJobMappingDir != "" {
+		report.Attribution = append(report.Attribution, "hpc:"+config.HPCJobMappingDir)
+	}
+
+	listener := config.Address
+	if config.WebSystemdSocket {
+		listener = "systemd-socket"
+	}
+	if config.WebConfigFile != "" {
+		listener += " (web config " + config.WebConfigFile + ")"
+	}
+	report.Listeners = append(report.Listeners, listener)
+
+	return report
+}
+
+// addEntities counts the watched entities of the entity type. GPU instances are counted separately from GPUs.
+func addEntities(entities map[string]int, entityType dcgm.Field_Entity_Group, info deviceinfo.Provider) {
+	switch entityType {
+	case dcgm.FE_GPU:
+		for _, gpu := range info.GPUs() {
+			entities[dcgm.FE_GPU.String()]++
+			entities[dcgm.FE_GPU_I.String()] += len(gpu.GPUInstances)
+		}
+	case dcgm.FE_SWITCH:
+		for _, sw := range info.Switches() {
+			if info.IsSwitchWatched(sw.EntityId) {
+				entities[entityType.String()]++
+			}
+		}
+	case dcgm.FE_LINK:
+		for _, sw := range info.Switches() {
+			for _, link := range sw.NvLinks {
+				if info.IsLinkWatched(link.Index, sw.EntityId) {
+					entities[entityType.String()]++
+				}
+			}
+		}
+	case dcgm.FE_CPU:
+		for _, cpu := range info.CPUs() {
+			if info.IsCPUWatched(cpu.EntityId) {
+				entities[entityType.String()]++
+			}
+		}
+	case dcgm.FE_CPU_CORE:
+		for _, cpu := range info.CPUs() {
+			for _, core := range cpu.Cores {
+				if info.IsCoreWatched(core, cpu.EntityId) {
+					entities[entityType.String()]++
+				}
+			}
+		}
+	case dcgm.FE_VGPU:
+		entities[entityType.String()] += len(info.VGPUs())
+	}
+}
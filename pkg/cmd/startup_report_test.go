@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	mockdeviceinfo "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/deviceinfo"
+	mockdevicewatchlistmanager "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/deviceinfo"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/startupreport"
+)
+
+func TestNewStartupReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+	mockDCGM.EXPECT().FieldGetById(dcgm.Short(dcgm.DCGM_FI_DEV_CPU_UTIL_TOTAL)).
+		Return(dcgm.FieldMeta{EntityLevel: dcgm.FE_CPU})
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	gpuInfo := mockdeviceinfo.NewMockProvider(ctrl)
+	gpuInfo.EXPECT().GPUs().Return([]deviceinfo.GPUInfo{
+		{GPUInstances: []deviceinfo.GPUInstanceInfo{{EntityId: 0}, {EntityId: 1}}},
+		{},
+	})
+
+	manager := mockdevicewatchlistmanager.NewMockManager(ctrl)
+	manager.EXPECT().EntityWatchList(gomock.Any()).DoAndReturn(
+		func(entityType dcgm.Field_Entity_Group) (devicewatchlistmanager.WatchList, bool) {
+			if entityType != dcgm.FE_GPU {
+				return devicewatchlistmanager.WatchList{}, false
+			}
+			return *devicewatchlistmanager.NewWatchList(gpuInfo, []dcgm.Short{dcgm.DCGM_FI_DEV_POWER_USAGE},
+				nil, nil, 1000), true
+		}).AnyTimes()
+
+	cs := &counters.CounterSet{
+		DCGMCounters: counters.CounterList{
+			{FieldID: dcgm.DCGM_FI_DEV_POWER_USAGE, FieldName: "DCGM_FI_DEV_POWER_USAGE", PromType: "gauge"},
+			{FieldID: dcgm.DCGM_FI_DEV_CPU_UTIL_TOTAL, FieldName: "DCGM_FI_DEV_CPU_UTIL_TOTAL", PromType: "gauge"},
+			{FieldID: dcgm.DCGM_FI_DRIVER_VERSION, FieldName: "DCGM_FI_DRIVER_VERSION", PromType: "label"},
+		},
+		ExporterCounters: counters.CounterList{
+			{FieldID: dcgm.Short(counters.DCGMXIDErrorsCount), FieldName: counters.DCGMExpXIDErrorsCount, PromType: "gauge"},
+		},
+		Skipped: []counters.SkippedCounter{
+			{
+				FieldName: "DCGM_FI_PROF_GR_ENGINE_ACTIVE",
+				Code:      counters.SkipCodeProfilingDisabled,
+				Reason:    "profiling metrics are not collected",
+			},
+		},
+	}
+
+	config := &appconfig.Config{
+		Address:                   ":9400",
+		Kubernetes:                true,
+		PodResourcesKubeletSocket: "/var/lib/kubelet/pod-resources/kubelet.sock",
+		WebConfigFile:             "web-config.yaml",
+	}
+
+	report := newStartupReport("4.0.0", config, cs, manager, []dcgm.Field_Entity_Group{dcgm.FE_CPU})
+
+	assert.Equal(t, map[string]int{
+		"GPU":          2,
+		"GPU Instance": 2,
+		"NvSwitch":     0,
+		"NvLink":       0,
+		"CPU":          0,
+		"CPU Core":     0,
+		"vGPU":         0,
+	}, report.Entities)
+	assert.Equal(t, []startupreport.CounterStatus{
+		{Name: "DCGM_FI_DEV_POWER_USAGE", Enabled: true},
+		{Name: "DCGM_FI_DEV_CPU_UTIL_TOTAL", Reason: "CPU discovery is pending"},
+		{Name: counters.DCGMExpXIDErrorsCount, Enabled: true},
+		{
+			Name:            "DCGM_FI_PROF_GR_ENGINE_ACTIVE",
+			Reason:          "profiling metrics are not collected",
+			UnsupportedCode: counters.SkipCodeProfilingDisabled,
+		},
+	}, report.Counters)
+	assert.Equal(t, []string{"kubernetes:/var/lib/kubelet/pod-resources/kubelet.sock"}, report.Attribution)
+	assert.Equal(t, []string{":9400 (web config web-config.yaml)"}, report.Listeners)
+}
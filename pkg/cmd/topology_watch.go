@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/selfmetrics"
+)
+
+var topologyChanges = selfmetrics.Default().Counter("dcgm_exporter_topology_changes_total",
+	"Number of changes of the entities, e.g. hotplugged GPUs or reconfigured MIG instances, by entity type.")
+
+// topologyReaders read the entities of the entity groups, which the topology watch compares. GPU instances and
+// compute instances are read together from the MIG hierarchy.
+var topologyReaders = map[dcgm.Field_Entity_Group]func() ([]string, error){
+	dcgm.FE_GPU:    readGPUs,
+	dcgm.FE_GPU_I:  readMIGInstances,
+	dcgm.FE_SWITCH: readSwitches,
+}
+
+// readGPUs returns the IDs and UUIDs of the GPUs, so that a replaced GPU is detected, even when it has the same ID.
+func readGPUs() ([]string, error) {
+	ids, err := dcgmprovider.Client().GetSupportedDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	gpus := make([]string, 0, len(ids))
+	for _, id := range ids {
+		device, err := dcgmprovider.Client().GetDeviceInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		gpus = append(gpus, fmt.Sprintf("%d:%s", id, device.UUID))
+	}
+	return gpus, nil
+}
+
+// readMIGInstances returns the GPU instances and compute instances with their parents and profiles.
+func readMIGInstances() ([]string, error) {
+	hierarchy, err := dcgmprovider.Client().GetGpuInstanceHierarchy()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]string, 0, hierarchy.Count)
+	for _, entity := range hierarchy.EntityList[:hierarchy.Count] {
+		instances = append(instances, fmt.Sprintf("%d:%d:%d:%d:%d", entity.Entity.EntityGroupId,
+			entity.Entity.EntityId, entity.Parent.EntityId, entity.Info.NvmlInstanceId, entity.Info.NvmlMigProfileId))
+	}
+	return instances, nil
+}
+
+func readSwitches() ([]string, error) {
+	ids, err := dcgmprovider.Client().GetEntityGroupEntities(dcgm.FE_SWITCH)
+	if err != nil {
+		return nil, err
+	}
+
+	switches := make([]string, 0, len(ids))
+	for _, id := range ids {
+		switches = append(switches, fmt.Sprint(id))
+	}
+	return switches, nil
+}
+
+// readTopology returns the sorted entities by entity group. Groups, which could not be read, are omitted.
+func readTopology() map[dcgm.Field_Entity_Group][]string {
+	topology := map[dcgm.Field_Entity_Group][]string{}
+	for group, read := range topologyReaders {
+		entities, err := read()
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Failed to read the %s entities", group.String()),
+				slog.String(logging.ErrorKey, err.Error()))
+			continue
+		}
+		slices.Sort(entities)
+		topology[group] = entities
+	}
+	return topology
+}
+
+// changedGroups returns the entity groups, whose entities differ. Groups, which are missing from either topology,
+// are not compared, so a failure to read a group is not a change.
+func changedGroups(current, next map[dcgm.Field_Entity_Group][]string) []dcgm.Field_Entity_Group {
+	var changed []dcgm.Field_Entity_Group
+	for group, entities := range next {
+		if previous, exists := current[group]; exists && !slices.Equal(previous, entities) {
+			changed = append(changed, group)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// watchTopology sends SIGHUP to reload, whenever GPUs are added or removed, MIG instances are created or destroyed,
+// or NVSwitches are added or removed, until the context is done. The entities are compared every interval, and the
+// reload creates the device groups and the field watches again, like a SIGHUP sent to the exporter.
+func watchTopology(ctx context.Context, interval time.Duration, reload chan<- os.Signal, bus *eventbus.Bus) {
+	current := readTopology()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		next := readTopology()
+		changed := changedGroups(current, next)
+		for group, entities := range next {
+			current[group] = entities
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		for _, group := range changed {
+			topologyChanges.Inc("entity", group.String())
+			bus.TopologyChanges.Publish(eventbus.TopologyChangeEvent{EntityGroup: group})
+		}
+		slog.Info("The entities changed; rebuilding the device groups", slog.Any("entityTypes", changed))
+		select {
+		case reload <- syscall.SIGHUP:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
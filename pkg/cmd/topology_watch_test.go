@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/eventbus"
+)
+
+func TestChangedGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		current map[dcgm.Field_Entity_Group][]string
+		next    map[dcgm.Field_Entity_Group][]string
+		want    []dcgm.Field_Entity_Group
+	}{
+		{
+			name:    "Unchanged",
+			current: map[dcgm.Field_Entity_Group][]string{dcgm.FE_GPU: {"0:GPU-0"}},
+			next:    map[dcgm.Field_Entity_Group][]string{dcgm.FE_GPU: {"0:GPU-0"}},
+		},
+		{
+			name: "Changed groups are sorted",
+			current: map[dcgm.Field_Entity_Group][]string{
+				dcgm.FE_GPU:    {"0:GPU-0"},
+				dcgm.FE_GPU_I:  {},
+				dcgm.FE_SWITCH: {"0"},
+			},
+			next: map[dcgm.Field_Entity_Group][]string{
+				dcgm.FE_GPU:    {"0:GPU-0", "1:GPU-1"},
+				dcgm.FE_GPU_I:  {"6:0:0:1:19"},
+				dcgm.FE_SWITCH: {"0"},
+			},
+			want: []dcgm.Field_Entity_Group{dcgm.FE_GPU, dcgm.FE_GPU_I},
+		},
+		{
+			name:    "Groups failing to be read are not changed",
+			current: map[dcgm.Field_Entity_Group][]string{dcgm.FE_GPU: {"0:GPU-0"}},
+			next:    map[dcgm.Field_Entity_Group][]string{dcgm.FE_SWITCH: {"0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, changedGroups(tt.current, tt.next))
+		})
+	}
+}
+
+func TestWatchTopology(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	var gpus atomic.Int32
+	gpus.Store(1)
+	mockDCGM.EXPECT().GetSupportedDevices().DoAndReturn(func() ([]uint, error) {
+		ids := make([]uint, gpus.Load())
+		for i := range ids {
+			ids[i] = uint(i)
+		}
+		return ids, nil
+	}).AnyTimes()
+	mockDCGM.EXPECT().GetDeviceInfo(gomock.Any()).DoAndReturn(func(id uint) (dcgm.Device, error) {
+		return dcgm.Device{GPU: id, UUID: fmt.Sprintf("GPU-%d", id)}, nil
+	}).AnyTimes()
+	mockDCGM.EXPECT().GetGpuInstanceHierarchy().Return(dcgm.MigHierarchy_v2{}, nil).AnyTimes()
+	mockDCGM.EXPECT().GetEntityGroupEntities(dcgm.FE_SWITCH).Return(nil, errors.New("not supported")).AnyTimes()
+
+	bus := eventbus.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := bus.TopologyChanges.Subscribe(ctx, 1)
+	reload := make(chan os.Signal)
+	go watchTopology(ctx, 10*time.Millisecond, reload, bus)
+
+	select {
+	case <-reload:
+		t.Fatal("the collection was reloaded, though the entities did not change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	gpus.Store(2)
+
+	select {
+	case sig := <-reload:
+		assert.Equal(t, syscall.SIGHUP, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the collection was not reloaded after a GPU was added")
+	}
+	assert.Equal(t, eventbus.TopologyChangeEvent{EntityGroup: dcgm.FE_GPU}, <-changes)
+}
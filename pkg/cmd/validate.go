@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	. "github.com/NVIDIA/dcgm-exporter/internal/pkg/logging"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/webhook"
+)
+
+const (
+	CLIValidateCommand    = "validate-counters"
+	CLIWebhookAddress     = "webhook-address"
+	CLIWebhookTLSCertFile = "webhook-tls-cert-file"
+	CLIWebhookTLSKeyFile  = "webhook-tls-key-file"
+
+	webhookPath = "/validate"
+)
+
+func newValidateCommand() *cli.Command {
+	return &cli.Command{
+		Name: CLIValidateCommand,
+		Usage: "Validate the counters file against the capabilities of the GPUs and the driver on this node. " +
+			"The exit status is non-zero, when the exporter would fail on or would skip any record. " +
+			"With --webhook-address, run a validating admission webhook for counters ConfigMaps instead",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    CLIWebhookAddress,
+				Usage:   "Address of the validating admission webhook, e.g. ':8443'.",
+				EnvVars: []string{"DCGM_EXPORTER_WEBHOOK_ADDRESS"},
+			},
+			&cli.StringFlag{
+				Name:    CLIWebhookTLSCertFile,
+				Usage:   "Path to the TLS certificate of the validating admission webhook.",
+				EnvVars: []string{"DCGM_EXPORTER_WEBHOOK_TLS_CERT_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    CLIWebhookTLSKeyFile,
+				Usage:   "Path to the TLS key of the validating admission webhook.",
+				EnvVars: []string{"DCGM_EXPORTER_WEBHOOK_TLS_KEY_FILE"},
+			},
+		},
+		Action: validateAction,
+	}
+}
+
+func validateAction(c *cli.Context) error {
+	config, err := contextToConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if err := configureLogging(config); err != nil {
+		return err
+	}
+
+	if config.NVMLOnly {
+		nvmlprovider.Initialize()
+		defer nvmlprovider.Client().Cleanup()
+	}
+
+	dcgmprovider.Initialize(config)
+	defer dcgmprovider.Client().Cleanup()
+
+	// Profiling metrics, which the GPUs don't support, are skipped
+	fillConfigMetricGroups(config)
+
+	validate := func(records [][]string) error {
+		return counters.ValidateRecords(records, config)
+	}
+
+	if c.IsSet(CLIWebhookAddress) {
+		return runValidatingWebhook(c, validate)
+	}
+
+	return validateCountersFile(config, validate)
+}
+
+func validateCountersFile(config *appconfig.Config, validate webhook.Validator) error {
+	records, err := counters.ReadCSVFile(config.CollectorsFile)
+	if err != nil {
+		return fmt.Errorf("could not read metrics file '%s'; err: %w", config.CollectorsFile, err)
+	}
+
+	err = validate(records)
+	if err != nil {
+		return fmt.Errorf("metrics file '%s' is not valid on this node:\n%w", config.CollectorsFile, err)
+	}
+
+	slog.Info(fmt.Sprintf("Metrics file '%s' is valid on this node", config.CollectorsFile))
+	return nil
+}
+
+func runValidatingWebhook(c *cli.Context, validate webhook.Validator) error {
+	mux := http.NewServeMux()
+	mux.Handle(webhookPath, webhook.NewHandler(validate))
+
+	srv := &http.Server{
+		Addr:         c.String(CLIWebhookAddress),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Starting validating webhook", slog.String("address", srv.Addr))
+		errCh <- srv.ListenAndServeTLS(c.String(CLIWebhookTLSCertFile), c.String(CLIWebhookTLSKeyFile))
+	}()
+
+	sigs := newOSWatcher(syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("validating webhook stopped; err: %w", err)
+	case <-sigs:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("Failed to shutdown validating webhook.", slog.String(ErrorKey, err.Error()))
+	}
+
+	return nil
+}
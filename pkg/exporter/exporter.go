@@ -0,0 +1,268 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package exporter collects the metrics of dcgm-exporter for Go programs, which embed the collection of the GPU
+// metrics instead of scraping the metrics endpoint.
+//
+//	e, err := exporter.NewExporter(exporter.Config{CountersFile: "/etc/dcgm-exporter/default-counters.csv"})
+//	if err != nil {
+//		return err
+//	}
+//	defer e.Close()
+//
+//	samples, err := e.Collect(ctx)
+//
+// DCGM is initialized once per process, so only one Exporter can exist at a time.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/appconfig"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatcher"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/devicewatchlistmanager"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/hostname"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/nvmlprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/prerequisites"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+const (
+	defaultCollectInterval = 30000 // ms
+	flexDevices            = "f"
+)
+
+// errClosed is returned by Collect, after the Exporter was closed.
+var errClosed = errors.New("the exporter is closed")
+
+// Exporter collects the metrics of the counters file.
+type Exporter struct {
+	registry  *registry.Registry
+	closeOnce sync.Once
+	cleanups  []func()
+
+	// mtx guards closed, so that no collection starts, while Close waits for the collections in flight
+	mtx      sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// NewExporter initializes DCGM and NVML, and watches the fields of the counters file. Failures, e.g. DCGM not being
+// installed or a collector, which cannot be initialized, are returned.
+func NewExporter(config Config) (*Exporter, error) {
+	c := config.appConfig()
+
+	err := prerequisites.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Exporter{}
+
+	if err := dcgmprovider.TryInitialize(c); err != nil {
+		return nil, fmt.Errorf("failed to initialize DCGM; err: %w", err)
+	}
+	e.cleanups = append(e.cleanups, func() { dcgmprovider.Client().Cleanup() })
+
+	nvmlprovider.Initialize()
+	e.cleanups = append(e.cleanups, func() { nvmlprovider.Client().Cleanup() })
+
+	groups, err := dcgmprovider.Client().GetSupportedMetricGroups(0)
+	if err != nil {
+		c.CollectDCP = false
+	} else {
+		c.MetricGroups = groups
+	}
+
+	records, err := counters.ReadCSVFile(c.CollectorsFile)
+	if err != nil {
+		e.Close()
+		return nil, fmt.Errorf("could not read metrics file '%s'; err: %w", c.CollectorsFile, err)
+	}
+	cs, err := counters.ExtractCounters(records, c)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	cs.CopyLabelCounters()
+
+	manager := devicewatchlistmanager.NewWatchListManager(cs.WatchedCounters(), c)
+	deviceWatcher := devicewatcher.NewDeviceWatcher()
+	for _, deviceType := range devicewatchlistmanager.DeviceTypesToWatch {
+		// Entity types, which the node doesn't have, are not collected
+		_ = manager.CreateEntityWatchList(deviceType, deviceWatcher, int64(c.CollectInterval))
+	}
+
+	host, _, err := hostname.Resolve(c)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	entityCollectors, err := collector.InitCollectorFactory(cs, manager, host, c).TryNewCollectors()
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	e.registry = registry.NewRegistry()
+	e.cleanups = append(e.cleanups, func() { e.registry.Cleanup() })
+	for _, entityCollector := range entityCollectors {
+		e.registry.Register(entityCollector)
+	}
+
+	return e, nil
+}
+
+func (config Config) appConfig() *appconfig.Config {
+	collectInterval := defaultCollectInterval
+	if config.CollectInterval > 0 {
+		collectInterval = int(config.CollectInterval.Milliseconds())
+	}
+
+	return &appconfig.Config{
+		CollectorsFile:      config.CountersFile,
+		UseRemoteHE:         config.RemoteHostengine != "",
+		RemoteHEInfo:        config.RemoteHostengine,
+		CollectInterval:     collectInterval,
+		MetricPrefix:        config.MetricPrefix,
+		CollectDCP:          true,
+		GPUDeviceOptions:    appconfig.DeviceOptions{Flex: true},
+		SwitchDeviceOptions: appconfig.DeviceOptions{Flex: true},
+		CPUDeviceOptions:    appconfig.DeviceOptions{Flex: true},
+		HostnameSource:      appconfig.HostnameSourceAuto,
+	}
+}
+
+// Collect forces DCGM to update the watched fields and returns the samples of all the entities. When the context is
+// done first, the context error is returned and the collection completes in the background; Close waits for it.
+func (e *Exporter) Collect(ctx context.Context) ([]Sample, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e.mtx.Lock()
+	if e.closed {
+		e.mtx.Unlock()
+		return nil, errClosed
+	}
+	e.inFlight.Add(1)
+	e.mtx.Unlock()
+
+	type result struct {
+		metrics registry.MetricsByCounterGroup
+		err     error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer e.inFlight.Done()
+
+		err := dcgmprovider.Client().UpdateAllFields()
+		if err != nil {
+			done <- result{err: fmt.Errorf("failed to update DCGM fields; err: %w", err)}
+			return
+		}
+		metrics, err := e.registry.Gather()
+		done <- result{metrics: metrics, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return toSamples(r.metrics), nil
+	}
+}
+
+// Close stops watching the fields and shuts DCGM and NVML down, after the collections in flight completed.
+func (e *Exporter) Close() {
+	e.mtx.Lock()
+	e.closed = true
+	e.mtx.Unlock()
+	e.inFlight.Wait()
+
+	e.closeOnce.Do(func() {
+		for i := len(e.cleanups) - 1; i >= 0; i-- {
+			e.cleanups[i]()
+		}
+	})
+}
+
+// toSamples converts the gathered metrics to samples. Labels and values, which are not numbers, are omitted.
+func toSamples(metrics registry.MetricsByCounterGroup) []Sample {
+	var samples []Sample
+
+	for group, metricsByCounter := range metrics {
+		for counter, values := range metricsByCounter {
+			if counter.IsLabel() {
+				continue
+			}
+
+			for _, metric := range values {
+				value, err := strconv.ParseFloat(metric.Value, 64)
+				if err != nil {
+					continue
+				}
+
+				labels := make(map[string]string, len(metric.Labels)+len(metric.Attributes))
+				maps.Copy(labels, metric.Labels)
+				maps.Copy(labels, metric.Attributes)
+
+				samples = append(samples, Sample{
+					Name:   counter.MetricName(),
+					Field:  counter.FieldName,
+					Type:   counter.PromType,
+					Help:   counter.Help,
+					Value:  value,
+					Entity: toEntity(group, metric),
+					Labels: labels,
+				})
+			}
+		}
+	}
+
+	return samples
+}
+
+func toEntity(group dcgm.Field_Entity_Group, metric collector.Metric) Entity {
+	return Entity{
+		Type:              group.String(),
+		GPU:               metric.GPU,
+		UUID:              metric.GPUUUID,
+		Device:            metric.GPUDevice,
+		ModelName:         metric.GPUModelName,
+		PCIBusID:          metric.GPUPCIBusID,
+		MIGProfile:        metric.MigProfile,
+		GPUInstanceID:     metric.GPUInstanceID,
+		ComputeInstanceID: metric.ComputeInstanceID,
+		VGPUID:            metric.VGPUID,
+		Hostname:          metric.Hostname,
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-dcgm/pkg/dcgm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockdcgm "github.com/NVIDIA/dcgm-exporter/internal/mocks/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/collector"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/counters"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/registry"
+)
+
+func TestConfig_appConfig(t *testing.T) {
+	c := Config{CountersFile: "counters.csv"}.appConfig()
+	assert.Equal(t, "counters.csv", c.CollectorsFile)
+	assert.Equal(t, 30000, c.CollectInterval)
+	assert.False(t, c.UseRemoteHE)
+	assert.True(t, c.GPUDeviceOptions.Flex)
+
+	c = Config{RemoteHostengine: "localhost:5555", CollectInterval: 5 * time.Second}.appConfig()
+	assert.True(t, c.UseRemoteHE)
+	assert.Equal(t, "localhost:5555", c.RemoteHEInfo)
+	assert.Equal(t, 5000, c.CollectInterval)
+}
+
+func TestToSamples(t *testing.T) {
+	temp := counters.Counter{
+		FieldID:   dcgm.DCGM_FI_DEV_GPU_TEMP,
+		FieldName: "DCGM_FI_DEV_GPU_TEMP",
+		PromType:  "gauge",
+		Help:      "GPU temperature (in C).",
+	}
+	driver := counters.Counter{FieldName: "DCGM_FI_DRIVER_VERSION", PromType: "label"}
+
+	metrics := registry.MetricsByCounterGroup{
+		dcgm.FE_GPU: collector.MetricsByCounter{
+			temp: {
+				{
+					Counter:      temp,
+					Value:        "42",
+					GPU:          "0",
+					GPUUUID:      "GPU-00000000-0000-0000-0000-000000000000",
+					GPUDevice:    "nvidia0",
+					GPUModelName: "NVIDIA A100",
+					Hostname:     "testhost",
+					Labels:       map[string]string{"DCGM_FI_DRIVER_VERSION": "550.54.15"},
+					Attributes:   map[string]string{"pod": "gpu-pod"},
+				},
+				// Blank values are not numbers
+				{Counter: temp, Value: "", GPU: "1"},
+			},
+			driver: {{Counter: driver, Value: "550.54.15", GPU: "0"}},
+		},
+	}
+
+	assert.Equal(t, []Sample{
+		{
+			Name:  "DCGM_FI_DEV_GPU_TEMP",
+			Field: "DCGM_FI_DEV_GPU_TEMP",
+			Type:  "gauge",
+			Help:  "GPU temperature (in C).",
+			Value: 42,
+			Entity: Entity{
+				Type:      "GPU",
+				GPU:       "0",
+				UUID:      "GPU-00000000-0000-0000-0000-000000000000",
+				Device:    "nvidia0",
+				ModelName: "NVIDIA A100",
+				Hostname:  "testhost",
+			},
+			Labels: map[string]string{"DCGM_FI_DRIVER_VERSION": "550.54.15", "pod": "gpu-pod"},
+		},
+	}, toSamples(metrics))
+}
+
+func TestExporter_CollectCanceled(t *testing.T) {
+	e := &Exporter{registry: registry.NewRegistry()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := e.Collect(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExporter_CloseWaitsForCollections(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDCGM := mockdcgm.NewMockDCGM(ctrl)
+
+	realDCGM := dcgmprovider.Client()
+	defer dcgmprovider.SetClient(realDCGM)
+	dcgmprovider.SetClient(mockDCGM)
+
+	release := make(chan struct{})
+	mockDCGM.EXPECT().UpdateAllFields().DoAndReturn(func() error {
+		<-release
+		return nil
+	})
+
+	cleanedUp := make(chan struct{})
+	e := &Exporter{registry: registry.NewRegistry(), cleanups: []func(){func() { close(cleanedUp) }}}
+
+	// The collection keeps running in the background, after the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := e.Collect(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-cleanedUp:
+		t.Fatal("the exporter was cleaned up while a collection was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the exporter was not closed after the collection completed")
+	}
+
+	_, err = e.Collect(context.Background())
+	require.ErrorIs(t, err, errClosed)
+}
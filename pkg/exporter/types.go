@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package exporter
+
+import "time"
+
+// Config configures the collection. The zero value collects the fields of the counters file from an embedded DCGM,
+// every 30 seconds, on all GPUs, NVSwitches and CPUs.
+type Config struct {
+	// CountersFile is the path of the counters file, in the format of etc/default-counters.csv
+	CountersFile string
+	// RemoteHostengine is the address of a remote nv-hostengine, e.g. localhost:5555. When empty, DCGM is embedded.
+	RemoteHostengine string
+	// CollectInterval is how often DCGM samples the watched fields. When zero, it is 30 seconds.
+	CollectInterval time.Duration
+	// MetricPrefix is prepended to the names of the metrics
+	MetricPrefix string
+}
+
+// Sample is the value of a metric for an entity, e.g. the temperature of a GPU.
+type Sample struct {
+	// Name is the name of the metric, e.g. DCGM_FI_DEV_GPU_TEMP
+	Name string
+	// Field is the name of the field in the counters file, which the metric is collected from
+	Field string
+	// Type is the Prometheus type of the metric: gauge or counter
+	Type string
+	// Help is the description of the metric in the counters file
+	Help   string
+	Value  float64
+	Entity Entity
+	// Labels are the labels of the counters file and the labels, which the collectors add, e.g. err_code
+	Labels map[string]string
+}
+
+// Entity identifies the GPU, GPU instance, vGPU, NVSwitch, NVLink or CPU of a sample. Fields, which don't apply to
+// the entity, are empty.
+type Entity struct {
+	// Type is the DCGM entity group, e.g. GPU, SWITCH or CPU. Samples of GPU instances and vGPUs have the type GPU.
+	Type string
+	// GPU is the index of the GPU, or of the NVSwitch, the NVLink or the CPU
+	GPU       string
+	UUID      string
+	Device    string
+	ModelName string
+	PCIBusID  string
+
+	MIGProfile        string
+	GPUInstanceID     string
+	ComputeInstanceID string
+	VGPUID            string
+
+	Hostname string
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/dcgm-exporter/internal/pkg/dcgmprovider"
+	"github.com/NVIDIA/dcgm-exporter/pkg/cmd"
+)
+
+func TestStartWithInjectedFaults(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	t.Setenv(dcgmprovider.FaultInjectionEnv, "errors=0.2,blanks=0.2,nans=0.2,delay=50ms")
+
+	app := cmd.NewApp()
+	args := os.Args[0:1]
+	args = append(args, "-f=./testdata/default-counters.csv") // Append a file with default counters
+	port := getRandomAvailablePort(t)
+	args = append(args, fmt.Sprintf("-a=:%d", port))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func(ctx context.Context) {
+		err := app.Run(args)
+		require.NoError(t, err)
+	}(ctx)
+	defer cancel()
+
+	t.Logf("Read metrics from http://localhost:%d/metrics", port)
+
+	// Every scrape either fails, or returns valid metrics, while the exporter keeps running
+	var served int
+	for i := 0; i < 20; i++ {
+		err := retry.Do(
+			func() error {
+				metricsResp, status, err := httpGet(t, fmt.Sprintf("http://localhost:%d/metrics", port))
+				if err != nil {
+					return err
+				}
+				if status != http.StatusOK {
+					require.Equal(t, http.StatusInternalServerError, status)
+					return nil
+				}
+				if len(metricsResp) == 0 {
+					return errors.New("empty response")
+				}
+
+				var parser expfmt.TextParser
+				_, err = parser.TextToMetricFamilies(strings.NewReader(metricsResp))
+				require.NoError(t, err)
+				served++
+				return nil
+			},
+			retry.Attempts(10),
+			retry.MaxDelay(10*time.Second),
+		)
+		require.NoError(t, err)
+	}
+	require.Greater(t, served, 0, "expected metrics despite the injected faults")
+
+	_, status, err := httpGet(t, fmt.Sprintf("http://localhost:%d/health", port))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+}